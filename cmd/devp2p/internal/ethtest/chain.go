@@ -69,7 +69,7 @@ func (c *Chain) TotalDifficultyAt(height int) *big.Int {
 
 // ForkID gets the fork id of the chain.
 func (c *Chain) ForkID() forkid.ID {
-	return forkid.NewID(c.chainConfig, c.blocks[0].Hash(), uint64(c.Len()))
+	return forkid.NewID(c.chainConfig, c.blocks[0].Hash(), c.blocks[0].Time(), uint64(c.Len()), c.blocks[c.Len()-1].Time())
 }
 
 // Shorten returns a copy chain of a desired height from the imported
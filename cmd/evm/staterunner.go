@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/tests"
 	"github.com/urfave/cli/v2"
@@ -68,16 +69,16 @@ func stateTestCmd(ctx *cli.Context) error {
 		EnableReturnData: !ctx.Bool(DisableReturnDataFlag.Name),
 	}
 	var (
-		tracer   vm.EVMLogger
+		tracer   *hooks.Hooks
 		debugger *logger.StructLogger
 	)
 	switch {
 	case ctx.Bool(MachineFlag.Name):
-		tracer = logger.NewJSONLogger(config, os.Stderr)
+		tracer = logger.NewJSONLogger(config, os.Stderr).Hooks()
 
 	case ctx.Bool(DebugFlag.Name):
 		debugger = logger.NewStructLogger(config)
-		tracer = debugger
+		tracer = debugger.Hooks()
 
 	default:
 		debugger = logger.NewStructLogger(config)
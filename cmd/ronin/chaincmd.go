@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/internal/era"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/node"
@@ -140,6 +141,116 @@ Optional second and third arguments control the first and
 last block to write. In this mode, the file will be appended
 if already existing. If the file ends with .gz, the output will
 be gzipped.`,
+	}
+	exportParquetCommand = &cli.Command{
+		Action:    exportParquet,
+		Name:      "export-parquet",
+		Usage:     "Export a range of the blockchain into Parquet files",
+		ArgsUsage: "<directory> <blockNumFirst> <blockNumLast>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.StateSchemeFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Requires a directory to write the blocks, transactions, receipts and
+logs tables into, followed by the first and last block to export.
+Existing files in the directory are overwritten.`,
+	}
+	eraNetworkFlag = &cli.StringFlag{
+		Name:  "network",
+		Usage: "Network name of the era archives",
+		Value: "ronin",
+	}
+	exportEraCommand = &cli.Command{
+		Action:    exportEra,
+		Name:      "export-era",
+		Usage:     "Export a range of the blockchain into era archives",
+		ArgsUsage: "<directory> <blockNumFirst> <blockNumLast>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.StateSchemeFlag,
+			eraNetworkFlag,
+			&cli.Uint64Flag{
+				Name:  "step",
+				Usage: "Number of blocks per era archive",
+				Value: era.MaxEraBatchSize,
+			},
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Requires a directory to write the era archives into, followed by the
+first and last block to export. Each archive holds the blocks of an
+epoch of --step blocks along with their receipts and total difficulties.
+Archives already exported are kept, so an interrupted export resumes.`,
+	}
+	importEraCommand = &cli.Command{
+		Action:    importEra,
+		Name:      "import-era",
+		Usage:     "Import the chain history out of era archives",
+		ArgsUsage: "<directory>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.StateSchemeFlag,
+			eraNetworkFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Requires a directory holding the era archives of the network, which must
+continue the local chain. The blocks and receipts are verified and written
+directly into the freezer, the state has to be synced separately.`,
+	}
+	exportStateCommand = &cli.Command{
+		Action:    exportState,
+		Name:      "export-state",
+		Usage:     "Export a state out of the snapshot into file",
+		ArgsUsage: "<filename> [<root> [<startAccountHash>]]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.StateSchemeFlag,
+			&cli.BoolFlag{
+				Name:  "proofs",
+				Usage: "Include the Merkle proofs of the accounts",
+			},
+			&cli.StringSliceFlag{
+				Name:  "contracts",
+				Usage: "Export only the given accounts, along with their Merkle proofs",
+			},
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Requires a first argument of the file to write to. The optional second
+argument is the root of the state to export, the head state by default,
+and the optional third one the account hash to resume an interrupted
+export from, appending to its file. If the file ends with .gz, the output
+will be gzipped.
+
+With --contracts, only the given accounts are exported, e.g. to move the
+full state of a few contracts into a test environment. Such an export can
+be checked with verify-state.`,
+	}
+	verifyStateCommand = &cli.Command{
+		Action:    verifyStateExport,
+		Name:      "verify-state",
+		Usage:     "Verify a state export with proofs against its state root",
+		ArgsUsage: "<filename>",
+		Category:  "BLOCKCHAIN COMMANDS",
+		Description: `
+Checks that the accounts of a state export made with --proofs or --contracts
+are proven against its root, and that their code and storage match the hashes
+committed to by them. If the file ends with .gz, it's gunzipped.`,
 	}
 	importPreimagesCommand = &cli.Command{
 		Action:    importPreimages,
@@ -374,6 +485,128 @@ func exportChain(ctx *cli.Context) error {
 	return nil
 }
 
+func exportState(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	var (
+		root  = chain.CurrentBlock().Root()
+		begin common.Hash
+	)
+	if ctx.Args().Len() > 1 {
+		root = common.HexToHash(ctx.Args().Get(1))
+	}
+	if ctx.Args().Len() > 2 {
+		begin = common.HexToHash(ctx.Args().Get(2))
+	}
+	start := time.Now()
+	if ctx.IsSet("contracts") {
+		var addrs []common.Address
+		for _, addr := range ctx.StringSlice("contracts") {
+			if !common.IsHexAddress(addr) {
+				utils.Fatalf("Invalid contract address: %q", addr)
+			}
+			addrs = append(addrs, common.HexToAddress(addr))
+		}
+		if err := utils.ExportContracts(chain, ctx.Args().First(), root, addrs); err != nil {
+			utils.Fatalf("Export error: %v\n", err)
+		}
+	} else if err := utils.ExportState(chain, ctx.Args().First(), root, begin, ctx.Bool("proofs")); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+func verifyStateExport(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	start := time.Now()
+	root, err := utils.VerifyStateExport(ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Verification error: %v\n", err)
+	}
+	fmt.Printf("Verified state %x in %v\n", root, time.Since(start))
+	return nil
+}
+
+func exportParquet(ctx *cli.Context) error {
+	if ctx.Args().Len() < 3 {
+		utils.Fatalf("This command requires three arguments.")
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack)
+	start := time.Now()
+
+	if head := chain.CurrentFastBlock(); last > head.NumberU64() {
+		utils.Fatalf("Export error: block number %d larger than head block %d\n", last, head.NumberU64())
+	}
+	if err := chain.ExportParquet(ctx.Args().First(), first, last); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+func exportEra(ctx *cli.Context) error {
+	if ctx.Args().Len() < 3 {
+		utils.Fatalf("This command requires three arguments.")
+	}
+	first, ferr := strconv.ParseUint(ctx.Args().Get(1), 10, 64)
+	last, lerr := strconv.ParseUint(ctx.Args().Get(2), 10, 64)
+	if ferr != nil || lerr != nil {
+		utils.Fatalf("Export error in parsing parameters: block number not an integer\n")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, _ := utils.MakeChain(ctx, stack)
+	start := time.Now()
+
+	if head := chain.CurrentFastBlock(); last > head.NumberU64() {
+		utils.Fatalf("Export error: block number %d larger than head block %d\n", last, head.NumberU64())
+	}
+	if err := chain.ExportEra(ctx.Args().First(), ctx.String(eraNetworkFlag.Name), first, last, ctx.Uint64("step")); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+func importEra(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack)
+	defer db.Close()
+
+	start := time.Now()
+	err := chain.ImportEra(ctx.Args().First(), ctx.String(eraNetworkFlag.Name))
+	chain.Stop()
+	if err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
+
 // importPreimages imports preimage data from the specified file.
 func importPreimages(ctx *cli.Context) error {
 	if ctx.Args().Len() < 1 {
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -67,11 +68,13 @@ Remove blockchain and state databases`,
 			dbInspectCmd,
 			dbStatCmd,
 			dbCompactCmd,
+			dbConvertSchemeCmd,
 			dbGetCmd,
 			dbDeleteCmd,
 			dbPutCmd,
 			dbGetSlotsCmd,
 			dbDumpFreezerIndex,
+			dbFreezerRestoreCmd,
 			dbImportCmd,
 			dbExportCmd,
 			dbInspectEnodeDBCmd,
@@ -129,6 +132,28 @@ Remove blockchain and state databases`,
 		Description: `This command performs a database compaction. 
 WARNING: This operation may take a very long time to finish, and may cause database
 corruption if it is aborted during execution'!`,
+	}
+	dbConvertSchemeCmd = &cli.Command{
+		Action:    dbConvertScheme,
+		Name:      "convert-scheme",
+		Usage:     "Convert the persisted state into another state scheme",
+		ArgsUsage: "<hash|path>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.DBEngineFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+		},
+		Description: `This command converts the persisted state of the node into the given state
+scheme, and verifies the converted state against its root. The node must be stopped.
+On the next start with the matching --state.scheme, the chain is rewound to the block
+of the converted state if the head is ahead of it.`,
 	}
 	dbGetCmd = &cli.Command{
 		Action:    dbGet,
@@ -218,6 +243,24 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		},
 		Description: "This command displays information about the freezer index.",
 	}
+	dbFreezerRestoreCmd = &cli.Command{
+		Action:    freezerRestore,
+		Name:      "freezer-restore",
+		Usage:     "Restore the chain freezer from a backup",
+		ArgsUsage: "<backupdir>",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.SepoliaFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+		},
+		Description: `This command restores the chain freezer from a backup created by
+debug.backupFreezer on a running node. The node must be stopped, and its chain
+freezer must not hold any of the backed up files.`,
+	}
 	dbImportCmd = &cli.Command{
 		Action:    importLDBdata,
 		Name:      "import",
@@ -485,6 +528,26 @@ func dbCompact(ctx *cli.Context) error {
 	return nil
 }
 
+func dbConvertScheme(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	start := time.Now()
+	status, err := core.ConvertStateScheme(db, ctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	log.Info("State scheme converted", "number", status.Number, "root", status.Root, "accounts", status.Accounts,
+		"slots", status.Slots, "nodes", status.Nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
 // dbGet shows the value of a given database key
 func dbGet(ctx *cli.Context) error {
 	if ctx.NArg() != 1 {
@@ -676,6 +739,28 @@ func freezerInspect(ctx *cli.Context) error {
 
 }
 
+func freezerRestore(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	stack, config := makeConfigNode(ctx)
+	defer stack.Close()
+
+	ancientDir := config.Eth.DatabaseFreezer
+	switch {
+	case ancientDir == "":
+		ancientDir = filepath.Join(stack.ResolvePath("chaindata"), "ancient")
+	case !filepath.IsAbs(ancientDir):
+		ancientDir = config.Node.ResolvePath(ancientDir)
+	}
+	backup, err := rawdb.RestoreFreezerBackup(ctx.Args().Get(0), filepath.Join(ancientDir, rawdb.ChainFreezerName))
+	if err != nil {
+		return err
+	}
+	log.Info("Restored chain freezer", "items", backup.Items, "tail", backup.Tail, "files", len(backup.Files), "created", backup.Created)
+	return nil
+}
+
 // ParseHexOrString tries to hexdecode b, but if the prefix is missing, it instead just returns the raw bytes
 func parseHexOrString(str string) ([]byte, error) {
 	b, err := hexutil.Decode(str)
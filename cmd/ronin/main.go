@@ -87,25 +87,55 @@ var (
 		utils.EthashDatasetsOnDiskFlag,
 		utils.EthashDatasetsLockMmapFlag,
 		utils.TxPoolLocalsFlag,
+		utils.TxPoolManagedFlag,
 		utils.TxPoolNoLocalsFlag,
 		utils.TxPoolJournalFlag,
 		utils.TxPoolRejournalFlag,
+		utils.TxPoolLocalsJournalFlag,
+		utils.TxPoolMinedJournalFlag,
+		utils.TxPoolSnapshotFlag,
+		utils.TxPoolMinedLimitFlag,
 		utils.TxPoolPriceLimitFlag,
 		utils.TxPoolPriceBumpFlag,
 		utils.TxPoolAccountSlotsFlag,
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolGlobalBytesFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolInvariantCheckFlag,
+		utils.TxPoolForkLookaheadFlag,
+		utils.TxPoolExpirySlackFlag,
+		utils.TxPoolChurnLimitFlag,
+		utils.TxPoolChurnBurstFlag,
+		utils.TxPoolTipFloorsFlag,
+		utils.TxPoolSponsorWhitelistFlag,
 		utils.SyncModeFlag,
 		utils.ExitWhenSyncedFlag,
 		utils.GCModeFlag,
 		utils.NoPruningSideCarFlag,
+		utils.SideCarRetentionFlag,
 		utils.SnapshotFlag,
 		utils.TxLookupLimitFlag, // deprecated
 		utils.TransactionHistoryFlag,
 		utils.StateSchemeFlag,
 		utils.StateHistoryFlag,
+		utils.StateRecentFlag,
+		utils.StateMigrateFlag,
+		utils.ChainAuditFlag,
+		utils.WitnessStatsFlag,
+		utils.WitnessCacheFlag,
+		utils.SupplyCheckFlag,
+		utils.SupplyBridgesFlag,
+		utils.SstoreStatsFlag,
+		utils.TraceHashesFlag,
+		utils.ParallelWorkersFlag,
+		utils.DuplicateTxWindowFlag,
+		utils.BlockDelayThresholdFlag,
+		utils.TxInclusionSLAFlag,
+		utils.HistoryPruneFlag,
+		utils.ImportAcksFlag,
+		utils.FeeChangeThresholdFlag,
 		utils.TriesInMemoryFlag,
 		utils.LightServeFlag,
 		utils.LightIngressFlag,
@@ -126,8 +156,14 @@ var (
 		utils.CacheTrieRejournalFlag, // deprecated
 		utils.CacheGCFlag,
 		utils.CacheSnapshotFlag,
+		utils.CacheSnapshotAccountsFlag,
+		utils.CacheSnapshotRebalanceFlag,
 		utils.CacheNoPrefetchFlag,
+		utils.CacheHotContractsFlag,
+		utils.CacheCompactionIntervalFlag,
 		utils.CachePreimagesFlag,
+		utils.CacheJumpDestJournalFlag,
+		utils.CacheJumpDestSizeFlag,
 		utils.FDLimitFlag,
 		utils.CryptoKZGFlag,
 		utils.ListenPortFlag,
@@ -140,6 +176,7 @@ var (
 		utils.MinerGasLimitFlag,
 		utils.MinerGasReserveFlag,
 		utils.MinerGasPriceFlag,
+		utils.MinerTipBucketFlag,
 		utils.MinerEtherbaseFlag,
 		utils.MinerExtraDataFlag,
 		utils.MinerRecommitIntervalFlag,
@@ -175,6 +212,11 @@ var (
 		utils.CatalystFlag,
 		utils.MonitorDoubleSign,
 		utils.MonitorFinalityVoteFlag,
+		utils.ReorgProtectWindowFlag,
+		utils.MaxReorgDepthFlag,
+		utils.ContractCreationIndexFlag,
+		utils.ReceiptIndexFlag,
+		utils.SnapshotVerifyIntervalFlag,
 		utils.StoreInternalTransactions,
 		utils.MaxCurVoteAmountPerBlock,
 		utils.EnableFastFinality,
@@ -182,6 +224,7 @@ var (
 		utils.BlsPasswordPath,
 		utils.BlsWalletPath,
 		utils.DisableRoninProtocol,
+		utils.BloomlessReceiptsFlag,
 		utils.AdditionalChainEventFlag,
 		utils.DBEngineFlag,
 	}
@@ -211,6 +254,7 @@ var (
 		utils.EnableSigningMethodsFlag,
 		utils.RPCGlobalGasCapFlag,
 		utils.RPCGlobalEVMTimeoutFlag,
+		utils.RPCGlobalEVMStepLimitFlag,
 		utils.RPCGlobalTxFeeCapFlag,
 		utils.AllowUnprotectedTxs,
 		utils.ReadinessEnabledFlag,
@@ -260,6 +304,11 @@ func init() {
 		initCommand,
 		importCommand,
 		exportCommand,
+		exportParquetCommand,
+		exportEraCommand,
+		importEraCommand,
+		exportStateCommand,
+		verifyStateCommand,
 		importPreimagesCommand,
 		exportPreimagesCommand,
 		removedbCommand,
@@ -234,7 +234,7 @@ func verifyState(ctx *cli.Context) error {
 	triedb := utils.MakeTrieDatabase(ctx, chaindb, false, true)
 	defer triedb.Close()
 
-	snaptree, err := snapshot.New(chaindb, triedb, 256, headBlock.Root(), false, false, false)
+	snaptree, err := snapshot.New(chaindb, triedb, snapshot.CacheConfig{Size: 256}, headBlock.Root(), false, false, false)
 	if err != nil {
 		log.Error("Failed to open snapshot tree", "err", err)
 		return err
@@ -516,64 +516,10 @@ func dumpState(ctx *cli.Context) error {
 	}
 	triedb := utils.MakeTrieDatabase(ctx, db, false, true)
 	defer triedb.Close()
-	snaptree, err := snapshot.New(db, triedb, 256, root, false, false, false)
+	snaptree, err := snapshot.New(db, triedb, snapshot.CacheConfig{Size: 256}, root, false, false, false)
 	if err != nil {
 		return err
 	}
-	accIt, err := snaptree.AccountIterator(root, common.BytesToHash(conf.Start))
-	if err != nil {
-		return err
-	}
-	defer accIt.Release()
-
-	log.Info("Snapshot dumping started", "root", root)
-	var (
-		start    = time.Now()
-		logged   = time.Now()
-		accounts uint64
-	)
-	enc := json.NewEncoder(os.Stdout)
-	enc.Encode(struct {
-		Root common.Hash `json:"root"`
-	}{root})
-	for accIt.Next() {
-		account, err := types.FullAccount(accIt.Account())
-		if err != nil {
-			return err
-		}
-		da := &state.DumpAccount{
-			Balance:   account.Balance.String(),
-			Nonce:     account.Nonce,
-			Root:      account.Root.Bytes(),
-			CodeHash:  account.CodeHash,
-			SecureKey: accIt.Hash().Bytes(),
-		}
-		if !conf.SkipCode && !bytes.Equal(account.CodeHash, emptyCode) {
-			da.Code = rawdb.ReadCode(db, common.BytesToHash(account.CodeHash))
-		}
-		if !conf.SkipStorage {
-			da.Storage = make(map[common.Hash]string)
-
-			stIt, err := snaptree.StorageIterator(root, accIt.Hash(), common.Hash{})
-			if err != nil {
-				return err
-			}
-			for stIt.Next() {
-				da.Storage[stIt.Hash()] = common.Bytes2Hex(stIt.Slot())
-			}
-		}
-		enc.Encode(da)
-		accounts++
-		if time.Since(logged) > 8*time.Second {
-			log.Info("Snapshot dumping in progress", "at", accIt.Hash(), "accounts", accounts,
-				"elapsed", common.PrettyDuration(time.Since(start)))
-			logged = time.Now()
-		}
-		if conf.Max > 0 && accounts >= conf.Max {
-			break
-		}
-	}
-	log.Info("Snapshot dumping complete", "accounts", accounts,
-		"elapsed", common.PrettyDuration(time.Since(start)))
-	return nil
+	_, err = state.DumpSnapshotToCollector(snaptree, root, db, state.NewIterativeDumpCollector(json.NewEncoder(os.Stdout)), conf)
+	return err
 }
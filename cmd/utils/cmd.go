@@ -267,6 +267,128 @@ func ExportChain(blockchain *core.BlockChain, fn string) error {
 	return nil
 }
 
+// ExportState exports the state of the given root into the specified file,
+// starting from the account of the given hash. An export resumed from a non
+// zero hash is appended to the file, dropping the partial record the
+// interrupted export may have left at its end if not gzipped.
+func ExportState(blockchain *core.BlockChain, fn string, root common.Hash, start common.Hash, proofs bool) error {
+	log.Info("Exporting state", "file", fn, "root", root, "start", start)
+
+	// Open the file handle and potentially wrap with a gzip stream
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if start != (common.Hash{}) {
+		flags = os.O_CREATE | os.O_RDWR | os.O_APPEND
+	}
+	fh, err := os.OpenFile(fn, flags, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	if start != (common.Hash{}) && !strings.HasSuffix(fn, ".gz") {
+		if err := trimStateExport(fh); err != nil {
+			return err
+		}
+	}
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	if err := blockchain.ExportStateFrom(writer, root, start, proofs); err != nil {
+		return err
+	}
+	log.Info("Exported state", "file", fn)
+
+	return nil
+}
+
+// trimStateExport truncates a state export file after its last complete record.
+func trimStateExport(fh *os.File) error {
+	if _, err := fh.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var (
+		counter = &countingReader{r: bufio.NewReader(fh)}
+		stream  = rlp.NewStream(counter, 0)
+		end     int64
+	)
+	for {
+		if _, err := stream.Raw(); err != nil {
+			break
+		}
+		end = counter.n
+	}
+	return fh.Truncate(end)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// ExportContracts exports the given accounts of the state of the given root into
+// the specified file, along with their Merkle proofs.
+func ExportContracts(blockchain *core.BlockChain, fn string, root common.Hash, addrs []common.Address) error {
+	log.Info("Exporting contracts", "file", fn, "root", root, "count", len(addrs))
+
+	// Open the file handle and potentially wrap with a gzip stream
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	if err := blockchain.ExportContracts(writer, root, addrs); err != nil {
+		return err
+	}
+	log.Info("Exported contracts", "file", fn)
+
+	return nil
+}
+
+// VerifyStateExport checks the state export with proofs in the specified file,
+// returning the root of the verified state.
+func VerifyStateExport(fn string) (common.Hash, error) {
+	log.Info("Verifying state export", "file", fn)
+
+	// Open the file handle and potentially unwrap the gzip stream
+	fh, err := os.Open(fn)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	return core.VerifyStateExport(reader)
+}
+
 // ExportAppendChain exports a blockchain into the specified file, appending to
 // the file if data already exists in it.
 func ExportAppendChain(blockchain *core.BlockChain, fn string, first uint64, last uint64) error {
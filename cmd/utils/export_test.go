@@ -196,3 +196,32 @@ func TestImportFutureFormat(t *testing.T) {
 		t.Fatalf("wrong error: %v", err)
 	}
 }
+
+// Tests that the partial record an interrupted state export left at the end of
+// its file is dropped before the export is resumed.
+func TestTrimStateExport(t *testing.T) {
+	f, err := os.CreateTemp("", "stateexport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	first, _ := rlp.EncodeToBytes([]interface{}{uint8(0), []byte("root")})
+	second, _ := rlp.EncodeToBytes([]interface{}{uint8(1), make([]byte, 100)})
+	for _, blob := range [][]byte{first, second, second[:50]} {
+		if _, err := f.Write(blob); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := trimStateExport(f); err != nil {
+		t.Fatalf("failed to trim export: %v", err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(len(first) + len(second)); stat.Size() != want {
+		t.Fatalf("trimmed size mismatch: have %d, want %d", stat.Size(), want)
+	}
+}
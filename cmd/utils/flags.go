@@ -50,6 +50,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -234,6 +235,11 @@ var (
 		Value:    true,
 		Category: flags.StateCategory,
 	}
+	SideCarRetentionFlag = &cli.Uint64Flag{
+		Name:     "sidecar.retention",
+		Usage:    "Number of recent blocks to keep the blob sidecars of (default = 518400 blocks)",
+		Category: flags.StateCategory,
+	}
 	StateSchemeFlag = &cli.StringFlag{
 		Name:     "state.scheme",
 		Usage:    `State scheme to use for trie storage ("hash" or "path")`,
@@ -246,6 +252,86 @@ var (
 		Value:    ethconfig.Defaults.StateHistory,
 		Category: flags.StateCategory,
 	}
+	StateRecentFlag = &cli.Uint64Flag{
+		Name:     "state.recent",
+		Usage:    "Number of recent blocks whose state is kept available regardless of the in-memory trie pruning (hash scheme only, 0 = disabled)",
+		Category: flags.StateCategory,
+	}
+	StateMigrateFlag = &cli.BoolFlag{
+		Name:     "state.migrate",
+		Usage:    "Migrate the hash-based state into the path-based layout in the background, switching over once completed (ruled out by an explicit --state.scheme)",
+		Category: flags.StateCategory,
+	}
+	ChainAuditFlag = &cli.BoolFlag{
+		Name:     "chainaudit",
+		Usage:    "Keep a hash-chained audit log of the head changes, rewinds and configuration overrides of the chain",
+		Category: flags.MiscCategory,
+	}
+	WitnessStatsFlag = &cli.BoolFlag{
+		Name:     "witness.stats",
+		Usage:    "Estimate the execution witness size (trie nodes and code touched) of the processed blocks, reading the state through the tries instead of the snapshot",
+		Category: flags.MiscCategory,
+	}
+	WitnessCacheFlag = &cli.IntFlag{
+		Name:     "witness.cache",
+		Usage:    "Number of recent execution witnesses of the imported blocks to keep for retrieval (0 = disabled)",
+		Category: flags.MiscCategory,
+	}
+	SupplyCheckFlag = &cli.BoolFlag{
+		Name:     "supply.check",
+		Usage:    "Verify that the supply change of the imported blocks matches the block rewards and burned fees",
+		Category: flags.MiscCategory,
+	}
+	SupplyBridgesFlag = &cli.StringFlag{
+		Name:     "supply.bridges",
+		Usage:    "Comma separated accounts holding the ether locked by the bridges, excluded from the circulating supply",
+		Category: flags.MiscCategory,
+	}
+	SstoreStatsFlag = &cli.BoolFlag{
+		Name:     "sstore.stats",
+		Usage:    "Count the SSTOREs of the processed blocks by slot transition, per block and contract",
+		Category: flags.MiscCategory,
+	}
+	TraceHashesFlag = &cli.BoolFlag{
+		Name:     "trace.hashes",
+		Usage:    "Hash the execution events of the transactions of the processed blocks, for comparison across nodes",
+		Category: flags.MiscCategory,
+	}
+	ParallelWorkersFlag = &cli.IntFlag{
+		Name:     "parallel.workers",
+		Usage:    "Number of workers executing the transactions of the imported blocks in parallel (0 = serial execution)",
+		Category: flags.MiscCategory,
+	}
+	DuplicateTxWindowFlag = &cli.Uint64Flag{
+		Name:     "txreplay.window",
+		Usage:    "Number of recent blocks whose transactions are rejected if included again by an imported block (0 = disabled)",
+		Category: flags.MiscCategory,
+	}
+	BlockDelayThresholdFlag = &cli.DurationFlag{
+		Name:     "blockdelay.threshold",
+		Usage:    "Arrival delay of a block after its expected sealing time above which it is reported as late (0 = disabled)",
+		Category: flags.MiscCategory,
+	}
+	TxInclusionSLAFlag = &cli.DurationFlag{
+		Name:     "txinclusion.sla",
+		Usage:    "Time within which the local transactions are expected to be included, above which they are reported (0 = disabled)",
+		Category: flags.MiscCategory,
+	}
+	HistoryPruneFlag = &cli.Uint64Flag{
+		Name:     "history.prune",
+		Usage:    "Number of recent blocks whose bodies and receipts are kept, older ones being pruned (0 = entire chain)",
+		Category: flags.EthCategory,
+	}
+	ImportAcksFlag = &cli.BoolFlag{
+		Name:     "importacks",
+		Usage:    "Sign acknowledgments of the blocks imported and made canonical with the etherbase key while mining",
+		Category: flags.MiscCategory,
+	}
+	FeeChangeThresholdFlag = &cli.Uint64Flag{
+		Name:     "feechange.threshold",
+		Usage:    "Change of the base fee between consecutive blocks in percent above which it is reported (0 = disabled)",
+		Category: flags.MiscCategory,
+	}
 	TransactionHistoryFlag = &cli.Uint64Flag{
 		Name:     "history.transactions",
 		Usage:    "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
@@ -258,6 +344,11 @@ var (
 		Value:    true,
 		Category: flags.EthCategory,
 	}
+	SnapshotVerifyIntervalFlag = &cli.DurationFlag{
+		Name:     "snapshot.verify.interval",
+		Usage:    "Time interval between cross-checking randomly sampled snapshot entries against the state trie (0 = disabled)",
+		Category: flags.EthCategory,
+	}
 	TriesInMemoryFlag = &cli.IntFlag{
 		Name:     "triesinmemory",
 		Usage:    "The number of tries is kept in memory before pruning (default = 128)",
@@ -274,6 +365,26 @@ var (
 		Usage:    "Enable finality vote monitoring",
 		Category: flags.EthCategory,
 	}
+	ReorgProtectWindowFlag = &cli.DurationFlag{
+		Name:     "reorg.protectwindow",
+		Usage:    "Refuse reorgs retracting locally sealed blocks younger than this unless the competing branch is finalized (0 = disabled)",
+		Category: flags.EthCategory,
+	}
+	MaxReorgDepthFlag = &cli.Uint64Flag{
+		Name:     "reorg.maxdepth",
+		Usage:    "Refuse reorgs retracting more canonical blocks than this and keep the current chain (0 = unlimited)",
+		Category: flags.EthCategory,
+	}
+	ContractCreationIndexFlag = &cli.BoolFlag{
+		Name:     "index.contracts",
+		Usage:    "Enable indexing the creator and deploying transaction of every contract (factory deployments require --additionalchainevent.enable)",
+		Category: flags.EthCategory,
+	}
+	ReceiptIndexFlag = &cli.BoolFlag{
+		Name:     "index.receipts",
+		Usage:    "Enable indexing the position and outcome of every transaction for single receipt lookups",
+		Category: flags.EthCategory,
+	}
 	StoreInternalTransactions = &cli.BoolFlag{
 		Name:     "internaltxs",
 		Usage:    "Enable storing internal transactions to db",
@@ -403,6 +514,11 @@ var (
 		Usage:    "Comma separated accounts to treat as locals (no flush, priority inclusion)",
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolManagedFlag = &cli.StringFlag{
+		Name:     "txpool.managed",
+		Usage:    "Comma separated accounts whose nonces are reserved for concurrent eth_fillTransaction callers",
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolNoLocalsFlag = &cli.BoolFlag{
 		Name:     "txpool.nolocals",
 		Usage:    "Disables price exemptions for locally submitted transactions",
@@ -420,6 +536,29 @@ var (
 		Value:    legacypool.DefaultConfig.Rejournal,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolLocalsJournalFlag = &cli.StringFlag{
+		Name:     "txpool.localsjournal",
+		Usage:    "Disk journal for the accounts treated as local to survive node restarts",
+		Value:    legacypool.DefaultConfig.LocalsJournal,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolMinedJournalFlag = &cli.StringFlag{
+		Name:     "txpool.minedjournal",
+		Usage:    "Disk journal for recently mined transaction hashes to survive node restarts",
+		Value:    legacypool.DefaultConfig.MinedJournal,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolSnapshotFlag = &cli.StringFlag{
+		Name:     "txpool.snapshot",
+		Usage:    "Disk snapshot of all the pooled transactions, written on shutdown and restored on startup (empty = disabled)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolMinedLimitFlag = &cli.Uint64Flag{
+		Name:     "txpool.minedlimit",
+		Usage:    "Number of recently mined transaction hashes remembered to reject re-gossiped ones (0 = disabled)",
+		Value:    legacypool.DefaultConfig.MinedLimit,
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolPriceLimitFlag = &cli.Uint64Flag{
 		Name:     "txpool.pricelimit",
 		Usage:    "Minimum gas price limit to enforce for acceptance into the pool",
@@ -456,12 +595,58 @@ var (
 		Value:    ethconfig.Defaults.TxPool.GlobalQueue,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolGlobalBytesFlag = &cli.Uint64Flag{
+		Name:     "txpool.globalbytes",
+		Usage:    "Maximum encoded size in bytes of all pooled transactions (0 = slot limits only)",
+		Value:    ethconfig.Defaults.TxPool.GlobalBytes,
+		Category: flags.TxPoolCategory,
+	}
 	TxPoolLifetimeFlag = &cli.DurationFlag{
 		Name:     "txpool.lifetime",
 		Usage:    "Maximum amount of time non-executable transaction are queued",
 		Value:    ethconfig.Defaults.TxPool.Lifetime,
 		Category: flags.TxPoolCategory,
 	}
+	TxPoolInvariantCheckFlag = &cli.DurationFlag{
+		Name:     "txpool.invariantcheck",
+		Usage:    "Time interval between sampled transaction pool consistency checks (0 = disabled)",
+		Value:    ethconfig.Defaults.TxPool.InvariantCheck,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolForkLookaheadFlag = &cli.Uint64Flag{
+		Name:     "txpool.forklookahead",
+		Usage:    "Number of upcoming blocks whose fork fee rules admitted transactions must already satisfy (0 = disabled)",
+		Value:    ethconfig.Defaults.TxPool.ForkLookahead,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolExpirySlackFlag = &cli.DurationFlag{
+		Name:     "txpool.expiryslack",
+		Usage:    "Margin ahead of the chain head timestamp within which sponsored transactions are treated as expired",
+		Value:    ethconfig.Defaults.TxPool.ExpirySlack,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolChurnLimitFlag = &cli.Uint64Flag{
+		Name:     "txpool.churnlimit",
+		Usage:    "Maximum number of remote transactions admitted or replaced per second across all pools, local ones exempt (0 = unlimited)",
+		Value:    ethconfig.Defaults.TxPool.ChurnLimit,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolChurnBurstFlag = &cli.Uint64Flag{
+		Name:     "txpool.churnburst",
+		Usage:    "Number of remote transactions admitted or replaced in a burst above the churn limit (0 = churn limit)",
+		Value:    ethconfig.Defaults.TxPool.ChurnBurst,
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolTipFloorsFlag = &cli.StringFlag{
+		Name:     "txpool.tipfloors",
+		Usage:    "Comma separated minimum gas tips per transaction type, on top of the pool wide one (e.g. blob=2000000000,sponsored=1000000000)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPoolSponsorWhitelistFlag = &cli.StringFlag{
+		Name:     "txpool.sponsorwhitelist",
+		Usage:    "Comma separated payers whose sponsored transactions are admitted, each optionally followed by =recipient+recipient to restrict the sponsored recipients",
+		Category: flags.TxPoolCategory,
+	}
 	// Blob transaction pool settings
 	BlobPoolDataDirFlag = &cli.StringFlag{
 		Name:     "blobpool.datadir",
@@ -512,16 +697,47 @@ var (
 		Value:    10,
 		Category: flags.PerfCategory,
 	}
+	CacheSnapshotAccountsFlag = &cli.IntFlag{
+		Name:     "cache.snapshot.accounts",
+		Usage:    "Percentage of the snapshot cache reserved for accounts, the rest holding storage (0 = shared cache)",
+		Category: flags.PerfCategory,
+	}
+	CacheSnapshotRebalanceFlag = &cli.BoolFlag{
+		Name:     "cache.snapshot.rebalance",
+		Usage:    "Rebalance the snapshot account and storage caches based on their hit rates",
+		Category: flags.PerfCategory,
+	}
 	CacheNoPrefetchFlag = &cli.BoolFlag{
 		Name:     "cache.noprefetch",
 		Usage:    "Disable heuristic state prefetch during block import (less CPU and disk IO, more time waiting for data)",
 		Category: flags.PerfCategory,
 	}
+	CacheHotContractsFlag = &cli.StringFlag{
+		Name:     "cache.hotcontracts",
+		Usage:    "Comma separated contracts whose recently accessed storage is kept warm across blocks (default = system contracts, empty = disabled)",
+		Category: flags.PerfCategory,
+	}
+	CacheCompactionIntervalFlag = &cli.DurationFlag{
+		Name:     "cache.compaction.interval",
+		Usage:    "Interval of the compactions of the freed database ranges, deferred around the sealing turns of the validator, which also throttle the database's own compactions (0 = disabled)",
+		Category: flags.PerfCategory,
+	}
 	CachePreimagesFlag = &cli.BoolFlag{
 		Name:     "cache.preimages",
 		Usage:    "Enable recording the SHA3/keccak preimages of trie keys",
 		Category: flags.PerfCategory,
 	}
+	CacheJumpDestJournalFlag = &cli.StringFlag{
+		Name:     "cache.jumpdest.journal",
+		Usage:    "Disk journal for JUMPDEST analysis results to survive node restarts (disabled if empty)",
+		Category: flags.PerfCategory,
+	}
+	CacheJumpDestSizeFlag = &cli.IntFlag{
+		Name:     "cache.jumpdest.size",
+		Usage:    "Maximum number of contracts whose JUMPDEST analysis is journaled",
+		Value:    ethconfig.Defaults.JumpDestCacheSize,
+		Category: flags.PerfCategory,
+	}
 	FDLimitFlag = &cli.IntFlag{
 		Name:     "fdlimit",
 		Usage:    "Raise the open file descriptor resource limit (default = system fd limit)",
@@ -572,6 +788,11 @@ var (
 		Value:    ethconfig.Defaults.Miner.GasPrice,
 		Category: flags.MinerCategory,
 	}
+	MinerTipBucketFlag = &flags.BigFlag{
+		Name:     "miner.tipbucket",
+		Usage:    "Granularity of effective tips (wei) within which transactions are ordered by arrival time instead of price (0 = disabled)",
+		Category: flags.MinerCategory,
+	}
 	MinerEtherbaseFlag = &cli.StringFlag{
 		Name:     "miner.etherbase",
 		Usage:    "Public address for block mining rewards (default = first account)",
@@ -652,6 +873,11 @@ var (
 		Value:    ethconfig.Defaults.RPCEVMTimeout,
 		Category: flags.APICategory,
 	}
+	RPCGlobalEVMStepLimitFlag = &cli.Uint64Flag{
+		Name:     "rpc.evmsteplimit",
+		Usage:    "Sets a cap on the instructions executed by eth_call/estimateGas (0=infinite)",
+		Category: flags.APICategory,
+	}
 	RPCGlobalTxFeeCapFlag = &cli.Float64Flag{
 		Name:     "rpc.txfeecap",
 		Usage:    "Sets a cap on transaction fee (in ether) that can be sent via the RPC APIs (0 = no cap)",
@@ -1094,6 +1320,12 @@ var (
 		Category: flags.EthCategory,
 	}
 
+	BloomlessReceiptsFlag = &cli.BoolFlag{
+		Name:     "receipts.nobloom",
+		Usage:    "Advertise the eth/101 protocol, exchanging the receipts without blooms to save sync bandwidth",
+		Category: flags.EthCategory,
+	}
+
 	AdditionalChainEventFlag = &cli.BoolFlag{
 		Name:     "additionalchainevent.enable",
 		Usage:    "Enable additional chain event",
@@ -1686,6 +1918,16 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 			}
 		}
 	}
+	if ctx.IsSet(TxPoolManagedFlag.Name) {
+		managed := strings.Split(ctx.String(TxPoolManagedFlag.Name), ",")
+		for _, account := range managed {
+			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid account in --txpool.managed: %s", trimmed)
+			} else {
+				cfg.ManagedAccounts = append(cfg.ManagedAccounts, common.HexToAddress(account))
+			}
+		}
+	}
 	if ctx.IsSet(TxPoolNoLocalsFlag.Name) {
 		cfg.NoLocals = ctx.Bool(TxPoolNoLocalsFlag.Name)
 	}
@@ -1695,6 +1937,18 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 	if ctx.IsSet(TxPoolRejournalFlag.Name) {
 		cfg.Rejournal = ctx.Duration(TxPoolRejournalFlag.Name)
 	}
+	if ctx.IsSet(TxPoolLocalsJournalFlag.Name) {
+		cfg.LocalsJournal = ctx.String(TxPoolLocalsJournalFlag.Name)
+	}
+	if ctx.IsSet(TxPoolMinedJournalFlag.Name) {
+		cfg.MinedJournal = ctx.String(TxPoolMinedJournalFlag.Name)
+	}
+	if ctx.IsSet(TxPoolSnapshotFlag.Name) {
+		cfg.Snapshot = ctx.String(TxPoolSnapshotFlag.Name)
+	}
+	if ctx.IsSet(TxPoolMinedLimitFlag.Name) {
+		cfg.MinedLimit = ctx.Uint64(TxPoolMinedLimitFlag.Name)
+	}
 	if ctx.IsSet(TxPoolPriceLimitFlag.Name) {
 		cfg.PriceLimit = ctx.Uint64(TxPoolPriceLimitFlag.Name)
 	}
@@ -1713,9 +1967,46 @@ func setTxPool(ctx *cli.Context, cfg *legacypool.Config) {
 	if ctx.IsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.Uint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.IsSet(TxPoolGlobalBytesFlag.Name) {
+		cfg.GlobalBytes = ctx.Uint64(TxPoolGlobalBytesFlag.Name)
+	}
 	if ctx.IsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.Duration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.IsSet(TxPoolInvariantCheckFlag.Name) {
+		cfg.InvariantCheck = ctx.Duration(TxPoolInvariantCheckFlag.Name)
+	}
+	if ctx.IsSet(TxPoolForkLookaheadFlag.Name) {
+		cfg.ForkLookahead = ctx.Uint64(TxPoolForkLookaheadFlag.Name)
+	}
+	if ctx.IsSet(TxPoolExpirySlackFlag.Name) {
+		cfg.ExpirySlack = ctx.Duration(TxPoolExpirySlackFlag.Name)
+	}
+	if ctx.IsSet(TxPoolChurnLimitFlag.Name) {
+		cfg.ChurnLimit = ctx.Uint64(TxPoolChurnLimitFlag.Name)
+	}
+	if ctx.IsSet(TxPoolChurnBurstFlag.Name) {
+		cfg.ChurnBurst = ctx.Uint64(TxPoolChurnBurstFlag.Name)
+	}
+	if ctx.IsSet(TxPoolTipFloorsFlag.Name) {
+		cfg.TipFloors = parseTipFloors(ctx)
+	}
+	if ctx.IsSet(TxPoolSponsorWhitelistFlag.Name) {
+		whitelist, err := txpool.ParseSponsorWhitelist(ctx.String(TxPoolSponsorWhitelistFlag.Name))
+		if err != nil {
+			Fatalf("Invalid --%s: %v", TxPoolSponsorWhitelistFlag.Name, err)
+		}
+		cfg.SponsorWhitelist = whitelist
+	}
+}
+
+// parseTipFloors parses the per transaction type tip floors shared by the pools.
+func parseTipFloors(ctx *cli.Context) txpool.TipFloors {
+	floors, err := txpool.ParseTipFloors(ctx.String(TxPoolTipFloorsFlag.Name))
+	if err != nil {
+		Fatalf("Invalid --%s: %v", TxPoolTipFloorsFlag.Name, err)
+	}
+	return floors
 }
 
 func setBlobPool(ctx *cli.Context, cfg *blobpool.Config) {
@@ -1728,6 +2019,9 @@ func setBlobPool(ctx *cli.Context, cfg *blobpool.Config) {
 	if ctx.IsSet(BlobPoolPriceBumpFlag.Name) {
 		cfg.PriceBump = ctx.Uint64(BlobPoolPriceBumpFlag.Name)
 	}
+	if ctx.IsSet(TxPoolTipFloorsFlag.Name) {
+		cfg.TipFloors = parseTipFloors(ctx)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *ethconfig.Config) {
@@ -1771,6 +2065,9 @@ func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.IsSet(MinerGasPriceFlag.Name) {
 		cfg.GasPrice = flags.GlobalBig(ctx, MinerGasPriceFlag.Name)
 	}
+	if ctx.IsSet(MinerTipBucketFlag.Name) {
+		cfg.TipBucket = flags.GlobalBig(ctx, MinerTipBucketFlag.Name)
+	}
 	if ctx.IsSet(MinerRecommitIntervalFlag.Name) {
 		cfg.Recommit = ctx.Duration(MinerRecommitIntervalFlag.Name)
 	}
@@ -1918,9 +2215,33 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if cfg.NoPruning && ctx.Bool(NoPruningSideCarFlag.Name) {
 		cfg.NoPruningSideCar = true
 	}
+	if ctx.IsSet(SideCarRetentionFlag.Name) {
+		cfg.SideCarRetention = ctx.Uint64(SideCarRetentionFlag.Name)
+	}
 	if ctx.IsSet(CacheNoPrefetchFlag.Name) {
 		cfg.NoPrefetch = ctx.Bool(CacheNoPrefetchFlag.Name)
 	}
+	if ctx.IsSet(CacheCompactionIntervalFlag.Name) {
+		cfg.CompactionInterval = ctx.Duration(CacheCompactionIntervalFlag.Name)
+	}
+	if ctx.IsSet(CacheHotContractsFlag.Name) {
+		cfg.HotContracts = []common.Address{}
+		for _, contract := range strings.Split(ctx.String(CacheHotContractsFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(contract); trimmed == "" {
+				continue
+			} else if !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid contract in --%s: %s", CacheHotContractsFlag.Name, trimmed)
+			} else {
+				cfg.HotContracts = append(cfg.HotContracts, common.HexToAddress(trimmed))
+			}
+		}
+	}
+	if ctx.IsSet(CacheJumpDestJournalFlag.Name) {
+		cfg.JumpDestCacheJournal = ctx.String(CacheJumpDestJournalFlag.Name)
+	}
+	if ctx.IsSet(CacheJumpDestSizeFlag.Name) {
+		cfg.JumpDestCacheSize = ctx.Int(CacheJumpDestSizeFlag.Name)
+	}
 	// Read the value from the flag no matter if it's set or not.
 	cfg.Preimages = ctx.Bool(CachePreimagesFlag.Name)
 	if cfg.NoPruning && !cfg.Preimages {
@@ -1930,6 +2251,62 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(StateHistoryFlag.Name) {
 		cfg.StateHistory = ctx.Uint64(StateHistoryFlag.Name)
 	}
+	if ctx.IsSet(StateRecentFlag.Name) {
+		cfg.RecentStates = ctx.Uint64(StateRecentFlag.Name)
+	}
+	if ctx.IsSet(StateMigrateFlag.Name) {
+		cfg.StateMigration = ctx.Bool(StateMigrateFlag.Name)
+	}
+	if ctx.IsSet(ChainAuditFlag.Name) {
+		cfg.ChainAudit = ctx.Bool(ChainAuditFlag.Name)
+	}
+	if ctx.IsSet(WitnessStatsFlag.Name) {
+		cfg.WitnessStats = ctx.Bool(WitnessStatsFlag.Name)
+	}
+	if ctx.IsSet(WitnessCacheFlag.Name) {
+		cfg.WitnessCache = ctx.Int(WitnessCacheFlag.Name)
+	}
+	if ctx.IsSet(SupplyCheckFlag.Name) {
+		cfg.SupplyCheck = ctx.Bool(SupplyCheckFlag.Name)
+	}
+	if ctx.IsSet(SstoreStatsFlag.Name) {
+		cfg.SstoreStats = ctx.Bool(SstoreStatsFlag.Name)
+	}
+	if ctx.IsSet(TraceHashesFlag.Name) {
+		cfg.TraceHashes = ctx.Bool(TraceHashesFlag.Name)
+	}
+	if ctx.IsSet(SupplyBridgesFlag.Name) {
+		for _, bridge := range strings.Split(ctx.String(SupplyBridgesFlag.Name), ",") {
+			if trimmed := strings.TrimSpace(bridge); trimmed == "" {
+				continue
+			} else if !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid account in --%s: %s", SupplyBridgesFlag.Name, trimmed)
+			} else {
+				cfg.SupplyBridges = append(cfg.SupplyBridges, common.HexToAddress(trimmed))
+			}
+		}
+	}
+	if ctx.IsSet(ParallelWorkersFlag.Name) {
+		cfg.ParallelWorkers = ctx.Int(ParallelWorkersFlag.Name)
+	}
+	if ctx.IsSet(DuplicateTxWindowFlag.Name) {
+		cfg.DuplicateTxWindow = ctx.Uint64(DuplicateTxWindowFlag.Name)
+	}
+	if ctx.IsSet(BlockDelayThresholdFlag.Name) {
+		cfg.BlockDelayThreshold = ctx.Duration(BlockDelayThresholdFlag.Name)
+	}
+	if ctx.IsSet(TxInclusionSLAFlag.Name) {
+		cfg.TxInclusionSLA = ctx.Duration(TxInclusionSLAFlag.Name)
+	}
+	if ctx.IsSet(HistoryPruneFlag.Name) {
+		cfg.HistoryPrune = ctx.Uint64(HistoryPruneFlag.Name)
+	}
+	if ctx.IsSet(ImportAcksFlag.Name) {
+		cfg.ImportAcks = ctx.Bool(ImportAcksFlag.Name)
+	}
+	if ctx.IsSet(FeeChangeThresholdFlag.Name) {
+		cfg.FeeChangeThreshold = ctx.Uint64(FeeChangeThresholdFlag.Name)
+	}
 
 	/* State Scheme Config logic */
 	// Parse the state scheme from chaindb firstly.
@@ -1940,6 +2317,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 		Fatalf("%v", err)
 	}
 	cfg.StateScheme = scheme
+	cfg.StateSchemePinned = ctx.IsSet(StateSchemeFlag.Name)
 
 	// Parse transaction history flag, if user is still using legacy config
 	// file with 'TxLookupLimit' configured, copy the value to 'TransactionHistory'.
@@ -1972,6 +2350,19 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(CacheFlag.Name) || ctx.IsSet(CacheSnapshotFlag.Name) {
 		cfg.SnapshotCache = ctx.Int(CacheFlag.Name) * ctx.Int(CacheSnapshotFlag.Name) / 100
 	}
+	if ctx.IsSet(CacheSnapshotAccountsFlag.Name) {
+		share := ctx.Int(CacheSnapshotAccountsFlag.Name)
+		if share < 0 || share >= 100 {
+			Fatalf("Invalid --%s: %d, must be in [0, 100)", CacheSnapshotAccountsFlag.Name, share)
+		}
+		cfg.SnapshotAccountRatio = float64(share) / 100
+	}
+	if ctx.IsSet(CacheSnapshotRebalanceFlag.Name) {
+		if cfg.SnapshotAccountRatio == 0 {
+			Fatalf("--%s requires --%s", CacheSnapshotRebalanceFlag.Name, CacheSnapshotAccountsFlag.Name)
+		}
+		cfg.SnapshotRebalance = ctx.Bool(CacheSnapshotRebalanceFlag.Name)
+	}
 	cfg.TriesInMemory = ctx.Int(TriesInMemoryFlag.Name)
 	if !ctx.Bool(SnapshotFlag.Name) {
 		// If snap-sync is requested, this flag is also required
@@ -2001,6 +2392,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalEVMTimeoutFlag.Name) {
 		cfg.RPCEVMTimeout = ctx.Duration(RPCGlobalEVMTimeoutFlag.Name)
 	}
+	if ctx.IsSet(RPCGlobalEVMStepLimitFlag.Name) {
+		cfg.RPCEVMStepLimit = ctx.Uint64(RPCGlobalEVMStepLimitFlag.Name)
+	}
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
@@ -2017,6 +2411,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(DisableRoninProtocol.Name) {
 		cfg.DisableRoninProtocol = ctx.Bool(DisableRoninProtocol.Name)
 	}
+	if ctx.IsSet(BloomlessReceiptsFlag.Name) {
+		cfg.BloomlessReceipts = ctx.Bool(BloomlessReceiptsFlag.Name)
+	}
 	// Override any default configs for hard coded networks.
 	switch {
 	case ctx.Bool(MainnetFlag.Name):
@@ -2113,6 +2510,22 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.Bool(MonitorFinalityVoteFlag.Name) {
 		cfg.EnableMonitorFinalityVote = true
 	}
+
+	if ctx.IsSet(ReorgProtectWindowFlag.Name) {
+		cfg.ReorgProtectWindow = ctx.Duration(ReorgProtectWindowFlag.Name)
+	}
+	if ctx.IsSet(MaxReorgDepthFlag.Name) {
+		cfg.MaxReorgDepth = ctx.Uint64(MaxReorgDepthFlag.Name)
+	}
+	if ctx.Bool(ContractCreationIndexFlag.Name) {
+		cfg.ContractCreationIndex = true
+	}
+	if ctx.Bool(ReceiptIndexFlag.Name) {
+		cfg.ReceiptIndex = true
+	}
+	if ctx.IsSet(SnapshotVerifyIntervalFlag.Name) {
+		cfg.SnapshotVerifyInterval = ctx.Duration(SnapshotVerifyIntervalFlag.Name)
+	}
 	// Set any dangling config values
 	if ctx.String(CryptoKZGFlag.Name) != "gokzg" && ctx.String(CryptoKZGFlag.Name) != "ckzg" {
 		Fatalf("--%s flag must be 'gokzg' or 'ckzg'", CryptoKZGFlag.Name)
@@ -2356,6 +2769,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		SnapshotLimit:       ethconfig.Defaults.SnapshotCache,
 		Preimages:           ctx.Bool(CachePreimagesFlag.Name),
 		NoPruningSideCar:    isArchive && ctx.Bool(NoPruningSideCarFlag.Name),
+		SideCarRetention:    ctx.Uint64(SideCarRetentionFlag.Name),
 	}
 	if cache.TrieDirtyDisabled && !cache.Preimages {
 		cache.Preimages = true
@@ -19,6 +19,7 @@ package consensus
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -173,6 +174,59 @@ type FastFinalityPoSA interface {
 	GetFinalityVoterAt(chain ChainHeaderReader, blockNumber uint64, blockHash common.Hash) []finality.ValidatorWithBlsPub
 }
 
+// SealScheduler is a consensus engine sealing the blocks on a fixed schedule,
+// allowing to measure how late the blocks arrive compared to the time they were
+// expected to be sealed.
+type SealScheduler interface {
+	// ExpectedSealTime returns the earliest time at which the validator of the
+	// given header was allowed to seal and broadcast the block.
+	ExpectedSealTime(chain ChainHeaderReader, header *types.Header) time.Time
+}
+
+// TurnScheduler is a consensus engine whose validators seal the blocks in turns,
+// allowing a validator node to keep heavy background work away from its turns.
+type TurnScheduler interface {
+	// NextSealTurn returns the time at which the local validator is expected to
+	// seal its next in-turn block on top of the given head, or false if it's not
+	// a validator or its next turn is unknown.
+	NextSealTurn(chain ChainHeaderReader, head *types.Header) (time.Time, bool)
+}
+
+// ForcedTransactor is a consensus engine mandating transactions in the blocks,
+// e.g. the acknowledgments of the bridge deposits. The forced transactions of a
+// block must lead it in the given order and are never admitted into the pool.
+type ForcedTransactor interface {
+	// ForcedTransactions returns the transactions the block of the given header
+	// must start with.
+	ForcedTransactions(chain ChainHeaderReader, header *types.Header) ([]*types.Transaction, error)
+
+	// IsForcedTransaction reports whether a transaction may only be included by
+	// the engine as a forced one.
+	IsForcedTransaction(tx *types.Transaction, header *types.Header) bool
+}
+
+// UncleFree is a consensus engine which never accepts uncles, letting the chain
+// skip uncle validation and the miner skip the uncle bookkeeping entirely.
+type UncleFree interface {
+	// UncleFree reports whether blocks sealed by the engine must carry no
+	// uncles and the empty uncle hash.
+	UncleFree() bool
+}
+
+// IsUncleFree reports whether the given engine never accepts uncles.
+func IsUncleFree(engine Engine) bool {
+	e, ok := engine.(UncleFree)
+	return ok && e.UncleFree()
+}
+
+// Rewarder is a consensus engine minting new ether on finalization of the
+// blocks, as opposed to only redistributing the transaction fees.
+type Rewarder interface {
+	// BlockReward returns the total amount of ether minted to the miner and
+	// the uncles of the given header on finalization.
+	BlockReward(chain ChainHeaderReader, header *types.Header, uncles []*types.Header) *big.Int
+}
+
 type VotePool interface {
 	FetchVoteByBlockHash(blockHash common.Hash) []*types.VoteEnvelope
 }
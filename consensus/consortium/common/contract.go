@@ -568,7 +568,7 @@ func applyMessage(
 	opts.EVMContext.CurrentTransaction = tx
 	from, _ := types.Sender(types.MakeSigner(opts.ChainConfig, opts.Header.Number), tx)
 
-	chainRules := opts.ChainConfig.Rules(opts.Header.Number)
+	chainRules := opts.ChainConfig.Rules(opts.Header.Number, opts.Header.Time)
 	if chainRules.IsShanghai {
 		opts.State.Prepare(chainRules, from, from, tx.To(), vm.ActivePrecompiles(chainRules), nil)
 	} else if chainRules.IsBerlin {
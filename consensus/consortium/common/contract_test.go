@@ -381,7 +381,7 @@ func TestApplyTransaction(t *testing.T) {
 		t.Fatalf("Expect recipient to be in the access list after Shanghai")
 	}
 
-	for _, addr := range vm.ActivePrecompiles(chainConfig.Rules(common.Big0)) {
+	for _, addr := range vm.ActivePrecompiles(chainConfig.Rules(common.Big0, 0)) {
 		if !state.AddressInAccessList(addr) {
 			t.Fatalf("Expect precompile %v to be in the access list after Shanghai", addr)
 		}
@@ -2,6 +2,7 @@ package consortium
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
@@ -69,6 +70,12 @@ func (c *Consortium) VerifyHeader(chain consensus.ChainHeaderReader, header *typ
 // VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
 // method returns a quit channel to abort the operations and a results channel to
 // retrieve the async verifications (the order is that of the input slice).
+//
+// The seals mask is ignored, the seal of every header is verified: the snapshots
+// the headers are checked against are built from the signers of their ancestors,
+// and the epoch boundary and finality vote bearing headers carry the validator
+// sets and attestations the following ones rely on. The sampled verification of
+// a header chain (e.g. during fast sync) is therefore always a full one.
 func (c *Consortium) VerifyHeaders(chain consensus.ChainHeaderReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
 	abort := make(chan struct{})
 	results := make(chan error, len(headers))
@@ -169,6 +176,31 @@ func (c *Consortium) APIs(chain consensus.ChainHeaderReader) []rpc.API {
 	return apis
 }
 
+// ExpectedSealTime implements consensus.SealScheduler. Consortium v1 blocks are
+// expected at their header time.
+func (c *Consortium) ExpectedSealTime(chain consensus.ChainHeaderReader, header *types.Header) time.Time {
+	if c.chainConfig.IsConsortiumV2(header.Number) {
+		return c.v2.ExpectedSealTime(chain, header)
+	}
+
+	return time.Unix(int64(header.Time), 0)
+}
+
+// NextSealTurn implements consensus.TurnScheduler. The turns are only known
+// once Consortium v2 is activated.
+func (c *Consortium) NextSealTurn(chain consensus.ChainHeaderReader, head *types.Header) (time.Time, bool) {
+	if c.chainConfig.IsConsortiumV2(new(big.Int).Add(head.Number, common.Big1)) {
+		return c.v2.NextSealTurn(chain, head)
+	}
+	return time.Time{}, false
+}
+
+// UncleFree implements consensus.UncleFree, as neither engine version accepts
+// uncles.
+func (c *Consortium) UncleFree() bool {
+	return true
+}
+
 // CalcDifficulty is the difficulty adjustment algorithm
 func (c *Consortium) CalcDifficulty(chain consensus.ChainHeaderReader, time uint64, parent *types.Header) *big.Int {
 	if c.chainConfig.IsConsortiumV2(parent.Number) {
@@ -601,6 +601,15 @@ func (c *Consortium) verifyCascadingFields(chain consensus.ChainHeaderReader, he
 			return fmt.Errorf("invalid blob fields before cancun: have %v, %v, want all nils", header.BlobGasUsed, header.ExcessBlobGas)
 		}
 	}
+	// The settlement roots are mandatory after the settlement fork, the
+	// operations themselves are verified against them with the body
+	if chain.Config().IsSettlement(header.Number) {
+		if header.WithdrawalsHash == nil || header.DepositsHash == nil {
+			return errors.New("missing settlement roots after fork")
+		}
+	} else if header.WithdrawalsHash != nil || header.DepositsHash != nil {
+		return fmt.Errorf("invalid settlement roots before fork: have %v, %v, want all nils", header.WithdrawalsHash, header.DepositsHash)
+	}
 
 	if number == c.forkedBlock {
 		snap, err = c.snapshotAtConsortiumFork(chain, number-1, header.ParentHash, header, parents)
@@ -1514,6 +1523,66 @@ func (c *Consortium) Seal(chain consensus.ChainHeaderReader, block *types.Block,
 	return nil
 }
 
+// ExpectedSealTime implements consensus.SealScheduler, returning the earliest
+// time at which the block was allowed to be sealed. After the Buba hardfork,
+// the back off of the out-of-turn validators is included in the header time
+// already, before that they had to wait at least a wiggle time more.
+func (c *Consortium) ExpectedSealTime(chain consensus.ChainHeaderReader, header *types.Header) time.Time {
+	expected := time.Unix(int64(header.Time), 0)
+	if !c.chainConfig.IsBuba(header.Number) && header.Difficulty.Cmp(diffInTurn) != 0 {
+		expected = expected.Add(wiggleTime)
+	}
+	return expected
+}
+
+// NextSealTurn implements consensus.TurnScheduler, returning the time of the next
+// in-turn block of the local validator. The validator set switches within each
+// epoch, the turns past a switch are computed with the set of the checkpoint
+// header if already known, and are unknown otherwise.
+func (c *Consortium) NextSealTurn(chain consensus.ChainHeaderReader, head *types.Header) (time.Time, bool) {
+	c.lock.RLock()
+	val := c.val
+	c.lock.RUnlock()
+
+	if val == (common.Address{}) {
+		return time.Time{}, false
+	}
+	snap, err := c.snapshot(chain, head.Number.Uint64(), head.Hash(), nil)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var (
+		number     = head.Number.Uint64()
+		validators = snap.validators()
+		limit      = number + uint64(len(validators))
+	)
+	for next := number + 1; next <= limit; next++ {
+		// The set switches once the block at half its size into the epoch is
+		// applied, the following blocks are sealed by the new set
+		if parent := next - 1; parent > number && parent%c.config.EpochV2 == uint64(len(validators)/2) {
+			offset := uint64(len(validators) / 2)
+			if parent-offset > number {
+				return time.Time{}, false
+			}
+			checkpoint := FindAncientHeader(head, number-(parent-offset), chain, nil)
+			if checkpoint == nil {
+				return time.Time{}, false
+			}
+			rules := c.chainConfig.Rules(new(big.Int).SetUint64(parent), head.Time+(parent-number)*c.config.Period)
+			snap = snap.copy()
+			if err := snap.applyCheckpoint(checkpoint, parent, &rules); err != nil {
+				return time.Time{}, false
+			}
+			validators = snap.validators()
+			limit = parent + uint64(len(validators))
+		}
+		if validators[next%uint64(len(validators))] == val {
+			return time.Unix(int64(head.Time+(next-number)*c.config.Period), 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
 // SealHash returns the hash of a block prior to it being sealed.
 func (c *Consortium) SealHash(header *types.Header) common.Hash {
 	isShillin := c.chainConfig.IsShillin(header.Number)
@@ -1916,6 +1985,11 @@ func encodeSigHeader(w io.Writer, header *types.Header, chainId *big.Int) {
 		enc = append(enc, header.BlobGasUsed)
 		enc = append(enc, header.ExcessBlobGas)
 	}
+	// settlement roots are assumed to had been verified
+	if header.WithdrawalsHash != nil {
+		enc = append(enc, header.WithdrawalsHash)
+		enc = append(enc, header.DepositsHash)
+	}
 	if err := rlp.Encode(w, enc); err != nil {
 		panic("can't encode: " + err.Error())
 	}
@@ -3273,3 +3273,110 @@ func TestVerifyValidatorExtraDataWithContract(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the seals of the headers are verified regardless of the seals mask,
+// so that the epoch boundary headers are always fully verified when the header
+// chain is only verified by sampling.
+func TestVerifyHeadersIgnoresSealsMask(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+
+	validatorKey, _ := crypto.GenerateKey()
+	validator := crypto.PubkeyToAddress(validatorKey.PublicKey)
+	outsiderKey, _ := crypto.GenerateKey()
+	outsider := crypto.PubkeyToAddress(outsiderKey.PublicKey)
+
+	chainConfig := params.ChainConfig{
+		ChainID:           big.NewInt(2021),
+		HomesteadBlock:    common.Big0,
+		EIP150Block:       common.Big0,
+		EIP155Block:       common.Big0,
+		EIP158Block:       common.Big0,
+		ConsortiumV2Block: common.Big0,
+		Consortium: &params.ConsortiumConfig{
+			EpochV2: 4,
+		},
+	}
+	gspec := &core.Genesis{
+		Config: &chainConfig,
+	}
+	genesis := gspec.MustCommit(db, trie.NewDatabase(db, nil))
+
+	mock := &mockContract{
+		validators: map[common.Address]mockValidator{validator: {}},
+	}
+	recents, _ := arc.NewARC[common.Hash, *Snapshot](inmemorySnapshots)
+	signatures, _ := arc.NewARC[common.Hash, common.Address](inmemorySignatures)
+
+	v2 := Consortium{
+		chainConfig: &chainConfig,
+		contract:    mock,
+		recents:     recents,
+		signatures:  signatures,
+		config:      chainConfig.Consortium,
+		db:          db,
+	}
+	chain, _ := core.NewBlockChain(db, nil, gspec, nil, &v2, vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	extra := func(number uint64) []byte {
+		var extraData finality.HeaderExtraData
+		if number%chainConfig.Consortium.EpochV2 == 0 {
+			extraData.CheckpointValidators = []finality.ValidatorWithBlsPub{{Address: validator}}
+		}
+		return extraData.Encode(false)
+	}
+	seal := func(header *types.Header, key *ecdsa.PrivateKey) {
+		hash := calculateSealHash(header, chainConfig.ChainID)
+		sig, err := crypto.Sign(hash[:], key)
+		if err != nil {
+			t.Fatalf("Failed to sign block, err %s", err)
+		}
+		copy(header.Extra[len(header.Extra)-consortiumCommon.ExtraSeal:], sig)
+	}
+	blocks, _ := core.GenerateConsortiumChain(
+		&chainConfig,
+		genesis,
+		&v2,
+		db,
+		4,
+		func(i int, bg *core.BlockGen) {
+			bg.SetCoinbase(validator)
+			bg.SetExtra(extra(uint64(i + 1)))
+			bg.SetDifficulty(big.NewInt(7))
+		},
+		true,
+		func(i int, bg *core.BlockGen) {
+			header := bg.Header()
+			seal(header, validatorKey)
+			bg.SetExtra(header.Extra)
+		},
+	)
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	verify := func(headers []*types.Header) error {
+		_, results := v2.VerifyHeaders(chain, headers, make([]bool, len(headers)))
+		for range headers {
+			if err := <-results; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := verify(headers); err != nil {
+		t.Fatalf("Failed to verify headers, err %s", err)
+	}
+	// Both the regular and the epoch boundary headers sealed by an outsider are
+	// rejected, even though none of the seals were requested to be verified
+	for _, number := range []int{3, 4} {
+		forged := types.CopyHeader(headers[number-1])
+		forged.Coinbase = outsider
+		seal(forged, outsiderKey)
+
+		chain := append(append([]*types.Header{}, headers[:number-1]...), forged)
+		if err := verify(chain); !errors.Is(err, errUnauthorizedValidator) {
+			t.Fatalf("Header %d: expect err: %v got: %v", number, errUnauthorizedValidator, err)
+		}
+	}
+}
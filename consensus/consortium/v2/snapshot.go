@@ -225,7 +225,7 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 			validator common.Address
 			err       error
 		)
-		chainRules := snap.chainConfig.Rules(header.Number)
+		chainRules := snap.chainConfig.Rules(header.Number, header.Time)
 		// If the headers come from v1 the block hash function does not include chainId,
 		// we need to use the correct ecrecover function the get the correct signer
 		if !chainRules.IsConsortiumV2 {
@@ -284,60 +284,8 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 				return nil, consensus.ErrUnknownAncestor
 			}
 
-			// this case is only happened in mock mode
-			if checkpointHeader.Number.Cmp(common.Big0) == 0 {
-				snap.Validators = make(map[common.Address]struct{})
-				for _, validator := range consortiumCommon.Validators.GetValidators() {
-					snap.Validators[validator] = struct{}{}
-				}
-				snap.ValidatorsWithBlsPub = nil
-			} else {
-				// Get validator set from headers and use that for new validator set
-				extraData, err := finality.DecodeExtraV2(checkpointHeader.Extra, chain.Config(), checkpointHeader.Number)
-				if err != nil {
-					return nil, err
-				}
-
-				oldLimit := len(snap.validators())/2 + 1
-				newLimit := newRecentListLimit(&chainRules, extraData)
-				if newLimit < oldLimit {
-					for i := 0; i < oldLimit-newLimit; i++ {
-						delete(snap.Recents, number-uint64(newLimit)-uint64(i))
-					}
-				}
-
-				// After Aaron, block producer list in snapshot is
-				// reconstructed from bit set and validator candidate list.
-				if isAaronEffective(&chainRules, extraData) {
-					if len(extraData.CheckpointValidators) != 0 {
-						snap.ValidatorsWithBlsPub = extraData.CheckpointValidators
-					}
-					snap.BlockProducers = decodeValidatorBitSet(extraData.BlockProducersBitSet, snap.ValidatorsWithBlsPub)
-					snap.Validators = nil
-				} else if isTrippEffective(&chainRules, extraData) {
-					// After Tripp is effective, the checkpoint validators in header's extra data
-					// is set only at the period block, not at all checkpoint blocks anymore. So
-					// only update snapshot's validator with bls public key when checkpoint
-					// validator is not empty.
-					if len(extraData.CheckpointValidators) != 0 {
-						snap.ValidatorsWithBlsPub = extraData.CheckpointValidators
-					}
-					snap.BlockProducers = extraData.BlockProducers
-					snap.Validators = nil
-				} else if chainRules.IsShillin {
-					// The validator information in checkpoint header is already sorted,
-					// we don't need to sort here
-					snap.ValidatorsWithBlsPub = extraData.CheckpointValidators
-					snap.Validators = nil
-					snap.BlockProducers = nil
-				} else {
-					snap.Validators = make(map[common.Address]struct{})
-					for _, validator := range extraData.CheckpointValidators {
-						snap.Validators[validator.Address] = struct{}{}
-					}
-					snap.ValidatorsWithBlsPub = nil
-					snap.BlockProducers = nil
-				}
+			if err := snap.applyCheckpoint(checkpointHeader, number, &chainRules); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -346,6 +294,67 @@ func (s *Snapshot) apply(headers []*types.Header, chain consensus.ChainHeaderRea
 	return snap, nil
 }
 
+// applyCheckpoint switches the validator set to the one of the given checkpoint
+// header, once the block of the given number is applied.
+func (s *Snapshot) applyCheckpoint(checkpointHeader *types.Header, number uint64, chainRules *params.Rules) error {
+	// this case is only happened in mock mode
+	if checkpointHeader.Number.Cmp(common.Big0) == 0 {
+		s.Validators = make(map[common.Address]struct{})
+		for _, validator := range consortiumCommon.Validators.GetValidators() {
+			s.Validators[validator] = struct{}{}
+		}
+		s.ValidatorsWithBlsPub = nil
+	} else {
+		// Get validator set from headers and use that for new validator set
+		extraData, err := finality.DecodeExtraV2(checkpointHeader.Extra, s.chainConfig, checkpointHeader.Number)
+		if err != nil {
+			return err
+		}
+
+		oldLimit := len(s.validators())/2 + 1
+		newLimit := newRecentListLimit(chainRules, extraData)
+		if newLimit < oldLimit {
+			for i := 0; i < oldLimit-newLimit; i++ {
+				delete(s.Recents, number-uint64(newLimit)-uint64(i))
+			}
+		}
+
+		// After Aaron, block producer list in snapshot is
+		// reconstructed from bit set and validator candidate list.
+		if isAaronEffective(chainRules, extraData) {
+			if len(extraData.CheckpointValidators) != 0 {
+				s.ValidatorsWithBlsPub = extraData.CheckpointValidators
+			}
+			s.BlockProducers = decodeValidatorBitSet(extraData.BlockProducersBitSet, s.ValidatorsWithBlsPub)
+			s.Validators = nil
+		} else if isTrippEffective(chainRules, extraData) {
+			// After Tripp is effective, the checkpoint validators in header's extra data
+			// is set only at the period block, not at all checkpoint blocks anymore. So
+			// only update snapshot's validator with bls public key when checkpoint
+			// validator is not empty.
+			if len(extraData.CheckpointValidators) != 0 {
+				s.ValidatorsWithBlsPub = extraData.CheckpointValidators
+			}
+			s.BlockProducers = extraData.BlockProducers
+			s.Validators = nil
+		} else if chainRules.IsShillin {
+			// The validator information in checkpoint header is already sorted,
+			// we don't need to sort here
+			s.ValidatorsWithBlsPub = extraData.CheckpointValidators
+			s.Validators = nil
+			s.BlockProducers = nil
+		} else {
+			s.Validators = make(map[common.Address]struct{})
+			for _, validator := range extraData.CheckpointValidators {
+				s.Validators[validator.Address] = struct{}{}
+			}
+			s.ValidatorsWithBlsPub = nil
+			s.BlockProducers = nil
+		}
+	}
+	return nil
+}
+
 // validators retrieves the list of validators in ascending order.
 func (s *Snapshot) validators() []common.Address {
 	if s.BlockProducers != nil {
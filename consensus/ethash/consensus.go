@@ -655,6 +655,16 @@ var (
 // reward. The total reward consists of the static block reward and rewards for
 // included uncles. The coinbase of each uncle block is also rewarded.
 func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	reward, uncleRewards := blockRewards(config, header, uncles)
+	for i, uncle := range uncles {
+		state.AddBalance(uncle.Coinbase, uncleRewards[i])
+	}
+	state.AddBalance(header.Coinbase, reward)
+}
+
+// blockRewards returns the reward of the miner of the given block, including
+// the ones for the inclusion of the uncles, and the rewards of the uncles.
+func blockRewards(config *params.ChainConfig, header *types.Header, uncles []*types.Header) (*big.Int, []*big.Int) {
 	// Select the correct block reward based on chain progression
 	blockReward := FrontierBlockReward
 	if config.IsByzantium(header.Number) {
@@ -664,17 +674,28 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 		blockReward = ConstantinopleBlockReward
 	}
 	// Accumulate the rewards for the miner and any included uncles
-	reward := new(big.Int).Set(blockReward)
-	r := new(big.Int)
-	for _, uncle := range uncles {
-		r.Add(uncle.Number, big8)
+	var (
+		reward       = new(big.Int).Set(blockReward)
+		uncleRewards = make([]*big.Int, len(uncles))
+	)
+	for i, uncle := range uncles {
+		r := new(big.Int).Add(uncle.Number, big8)
 		r.Sub(r, header.Number)
 		r.Mul(r, blockReward)
 		r.Div(r, big8)
-		state.AddBalance(uncle.Coinbase, r)
+		uncleRewards[i] = r
 
-		r.Div(blockReward, big32)
+		reward.Add(reward, new(big.Int).Div(blockReward, big32))
+	}
+	return reward, uncleRewards
+}
+
+// BlockReward implements consensus.Rewarder, returning the total amount of the
+// block and uncle rewards.
+func (ethash *Ethash) BlockReward(chain consensus.ChainHeaderReader, header *types.Header, uncles []*types.Header) *big.Int {
+	reward, uncleRewards := blockRewards(chain.Config(), header, uncles)
+	for _, r := range uncleRewards {
 		reward.Add(reward, r)
 	}
-	state.AddBalance(header.Coinbase, reward)
+	return reward
 }
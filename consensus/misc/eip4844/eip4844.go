@@ -80,6 +80,15 @@ func CalcBlobFee(excessBlobGas uint64) *big.Int {
 	return fakeExponential(minBlobGasPrice, new(big.Int).SetUint64(excessBlobGas), blobGaspriceUpdateFraction)
 }
 
+// HeaderBlobFee returns the blobfee of the block with the given header, nil if
+// the block predates Cancun.
+func HeaderBlobFee(header *types.Header) *big.Int {
+	if header == nil || header.ExcessBlobGas == nil {
+		return nil
+	}
+	return CalcBlobFee(*header.ExcessBlobGas)
+}
+
 // fakeExponential approximates factor * e ** (numerator / denominator) using
 // Taylor expansion.
 func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
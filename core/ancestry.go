@@ -0,0 +1,142 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// MaxAncestryProofHeaders is the maximum number of headers an ancestry proof
+// may contain, bounding the work of both the prover and the verifier.
+const MaxAncestryProofHeaders = 8192
+
+var (
+	// ErrAncestryUnknownBlock is returned if one of the blocks of an ancestry
+	// proof request is not known.
+	ErrAncestryUnknownBlock = errors.New("unknown block")
+
+	// ErrNotAncestor is returned if the requested ancestor is not an ancestor
+	// of the requested descendant.
+	ErrNotAncestor = errors.New("block is not an ancestor")
+
+	// ErrAncestryTooLong is returned if the distance between the ancestor and
+	// the descendant exceeds MaxAncestryProofHeaders.
+	ErrAncestryTooLong = errors.New("ancestry proof too long")
+
+	// ErrInvalidAncestryProof is returned if an ancestry proof doesn't link the
+	// claimed ancestor to the claimed descendant.
+	ErrInvalidAncestryProof = errors.New("invalid ancestry proof")
+)
+
+// AncestryProof is the chain of headers linking an ancestor block to one of
+// its descendants, ordered from the ancestor to the descendant. Since every
+// header commits to its parent, hashing the headers in order is enough for a
+// verifier trusting the descendant to also trust the ancestor.
+type AncestryProof struct {
+	Headers []*types.Header
+}
+
+// Verify checks that the proof links ancestor to descendant.
+func (p *AncestryProof) Verify(ancestor, descendant common.Hash) error {
+	if len(p.Headers) == 0 {
+		return fmt.Errorf("%w: no headers", ErrInvalidAncestryProof)
+	}
+	if len(p.Headers) > MaxAncestryProofHeaders {
+		return fmt.Errorf("%w: %d headers", ErrAncestryTooLong, len(p.Headers))
+	}
+	if hash := p.Headers[0].Hash(); hash != ancestor {
+		return fmt.Errorf("%w: ancestor mismatch: have %x, want %x", ErrInvalidAncestryProof, hash, ancestor)
+	}
+	for i := 1; i < len(p.Headers); i++ {
+		parent, header := p.Headers[i-1], p.Headers[i]
+		if header.Number.Uint64() != parent.Number.Uint64()+1 {
+			return fmt.Errorf("%w: non contiguous header #%d after #%d", ErrInvalidAncestryProof, header.Number, parent.Number)
+		}
+		if header.ParentHash != parent.Hash() {
+			return fmt.Errorf("%w: broken link at header #%d", ErrInvalidAncestryProof, header.Number)
+		}
+	}
+	if hash := p.Headers[len(p.Headers)-1].Hash(); hash != descendant {
+		return fmt.Errorf("%w: descendant mismatch: have %x, want %x", ErrInvalidAncestryProof, hash, descendant)
+	}
+	return nil
+}
+
+// ProveAncestry generates a proof that ancestor is an ancestor of descendant
+// (or the same block), from the locally stored headers. Only headers are
+// required, so nodes with pruned block bodies can serve proofs too.
+//
+// Non-canonical segments are resolved by walking the parent hashes, but as soon
+// as the walk reaches the canonical chain, the canonical number index is used to
+// skip straight to the ancestor, rejecting unrelated blocks without touching any
+// of the headers in between.
+func (bc *BlockChain) ProveAncestry(ancestor, descendant common.Hash) (*AncestryProof, error) {
+	anc := bc.GetHeaderByHash(ancestor)
+	if anc == nil {
+		return nil, fmt.Errorf("%w: ancestor %x", ErrAncestryUnknownBlock, ancestor)
+	}
+	desc := bc.GetHeaderByHash(descendant)
+	if desc == nil {
+		return nil, fmt.Errorf("%w: descendant %x", ErrAncestryUnknownBlock, descendant)
+	}
+	first, last := anc.Number.Uint64(), desc.Number.Uint64()
+	if first > last {
+		return nil, ErrNotAncestor
+	}
+	if last-first >= MaxAncestryProofHeaders {
+		return nil, fmt.Errorf("%w: %d headers, max %d", ErrAncestryTooLong, last-first+1, MaxAncestryProofHeaders)
+	}
+	headers := make([]*types.Header, last-first+1)
+	headers[len(headers)-1] = desc
+
+	var (
+		hash   = desc.ParentHash
+		number = last - 1
+	)
+	for i := len(headers) - 2; i >= 0; i-- {
+		if rawdb.ReadCanonicalHash(bc.db, number) == hash {
+			// The rest of the chain is canonical, the ancestor must be too
+			if rawdb.ReadCanonicalHash(bc.db, first) != ancestor {
+				return nil, ErrNotAncestor
+			}
+			for ; i >= 0; i-- {
+				header := bc.GetHeaderByNumber(number)
+				if header == nil {
+					return nil, fmt.Errorf("%w: canonical header #%d", ErrAncestryUnknownBlock, number)
+				}
+				headers[i] = header
+				number--
+			}
+			break
+		}
+		header := bc.GetHeader(hash, number)
+		if header == nil {
+			return nil, fmt.Errorf("%w: header #%d [%x]", ErrAncestryUnknownBlock, number, hash)
+		}
+		headers[i] = header
+		hash, number = header.ParentHash, number-1
+	}
+	if headers[0].Hash() != ancestor {
+		return nil, ErrNotAncestor
+	}
+	return &AncestryProof{Headers: headers}, nil
+}
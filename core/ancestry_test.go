@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that ancestry proofs are generated across canonical and side chains,
+// and that they are rejected for unrelated blocks.
+func TestProveAncestry(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(db, trie.NewDatabase(db, nil))
+	)
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	canon, _ := GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, b *BlockGen) {}, true)
+	if _, err := chain.InsertChain(canon, nil); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	// Fork off a shorter side chain at block #4
+	side, _ := GenerateChain(params.TestChainConfig, canon[3], ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	}, true)
+	if _, err := chain.InsertChain(side, nil); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+	tests := []struct {
+		ancestor   *types.Block
+		descendant *types.Block
+		err        error
+	}{
+		{genesis, canon[9], nil},
+		{canon[2], canon[2], nil},
+		{canon[1], side[2], nil},
+		{canon[3], side[0], nil},
+		{side[0], side[2], nil},
+		{canon[4], side[2], ErrNotAncestor},
+		{side[0], canon[9], ErrNotAncestor},
+		{canon[9], canon[1], ErrNotAncestor},
+		{canon[0], types.NewBlockWithHeader(&types.Header{Number: big.NewInt(5)}), ErrAncestryUnknownBlock},
+	}
+	for i, tt := range tests {
+		proof, err := chain.ProveAncestry(tt.ancestor.Hash(), tt.descendant.Hash())
+		if !errors.Is(err, tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if have, want := len(proof.Headers), int(tt.descendant.NumberU64()-tt.ancestor.NumberU64())+1; have != want {
+			t.Errorf("test %d: header count mismatch: have %d, want %d", i, have, want)
+		}
+		if err := proof.Verify(tt.ancestor.Hash(), tt.descendant.Hash()); err != nil {
+			t.Errorf("test %d: failed to verify proof: %v", i, err)
+		}
+	}
+	// Tamper with a valid proof and ensure it's rejected
+	proof, err := chain.ProveAncestry(canon[0].Hash(), canon[5].Hash())
+	if err != nil {
+		t.Fatalf("failed to generate proof: %v", err)
+	}
+	proof.Headers[2] = side[0].Header()
+	if err := proof.Verify(canon[0].Hash(), canon[5].Hash()); !errors.Is(err, ErrInvalidAncestryProof) {
+		t.Fatalf("tampered proof error mismatch: have %v, want %v", err, ErrInvalidAncestryProof)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// blockDelayWindow is the number of recent blocks of each validator the
+	// rolling delay statistics are computed over.
+	blockDelayWindow = 128
+
+	// blockDelayLimit is the arrival delay above which a block is considered to
+	// be synced rather than propagated, hence not accounted.
+	blockDelayLimit = time.Minute
+)
+
+var (
+	blockDelayTimer     = metrics.NewRegisteredTimer("chain/delay/arrival", nil)
+	blockDelayLateMeter = metrics.NewRegisteredMeter("chain/delay/late", nil)
+)
+
+// BlockDelayStats is the rolling statistics of the arrival delays of the recent
+// blocks sealed by a validator.
+type BlockDelayStats struct {
+	Validator common.Address `json:"validator"`
+	Blocks    int            `json:"blocks"`    // Number of blocks in the window
+	Late      int            `json:"late"`      // Number of blocks in the window above the threshold
+	Mean      time.Duration  `json:"mean"`      // Mean arrival delay in the window
+	Max       time.Duration  `json:"max"`       // Maximum arrival delay in the window
+	Last      time.Duration  `json:"last"`      // Arrival delay of the last block
+	LastBlock uint64         `json:"lastBlock"` // Number of the last block
+}
+
+// validatorDelays is the ring buffer of the recent arrival delays of a validator.
+type validatorDelays struct {
+	delays    []time.Duration
+	next      int
+	lastBlock uint64
+}
+
+// blockDelays tracks the arrival delays of the imported blocks per validator.
+type blockDelays struct {
+	validators map[common.Address]*validatorDelays
+	lock       sync.RWMutex
+}
+
+// recordBlockDelay accounts the delay between the arrival of a block and the
+// time it was expected to be sealed, as scheduled by the consensus engine. The
+// arrival is the time the block was received from the network, or the time of
+// its import if unknown. Blocks arriving way too late are being synced, their
+// delays are not accounted.
+func (bc *BlockChain) recordBlockDelay(block *types.Block) {
+	arrived := block.ReceivedAt
+	if arrived.IsZero() {
+		arrived = time.Now()
+	}
+	header := block.Header()
+	expected := time.Unix(int64(header.Time), 0)
+	if scheduler, ok := bc.engine.(consensus.SealScheduler); ok {
+		expected = scheduler.ExpectedSealTime(bc, header)
+	}
+	delay := arrived.Sub(expected)
+	if delay > blockDelayLimit {
+		return
+	}
+	// Blocks arriving ahead of time are caused by clock drifts, count them as
+	// arriving on time
+	if delay < 0 {
+		delay = 0
+	}
+	validator, err := bc.engine.Author(header)
+	if err != nil {
+		log.Debug("Failed to retrieve block author", "number", header.Number, "hash", block.Hash(), "err", err)
+		return
+	}
+	blockDelayTimer.Update(delay)
+
+	bc.blockDelays.lock.Lock()
+	stats := bc.blockDelays.validators[validator]
+	if stats == nil {
+		stats = &validatorDelays{delays: make([]time.Duration, 0, blockDelayWindow)}
+		bc.blockDelays.validators[validator] = stats
+	}
+	if len(stats.delays) < blockDelayWindow {
+		stats.delays = append(stats.delays, delay)
+	} else {
+		stats.delays[stats.next] = delay
+	}
+	stats.next = (stats.next + 1) % blockDelayWindow
+	stats.lastBlock = header.Number.Uint64()
+	bc.blockDelays.lock.Unlock()
+
+	if threshold := bc.cacheConfig.BlockDelayThreshold; threshold > 0 && delay > threshold {
+		blockDelayLateMeter.Mark(1)
+		log.Debug("Block arrived late", "number", header.Number, "hash", block.Hash(), "validator", validator,
+			"delay", common.PrettyDuration(delay))
+
+		bc.blockDelayFeed.Send(BlockDelayEvent{
+			Header:    header,
+			Validator: validator,
+			Expected:  expected,
+			Arrived:   arrived,
+			Delay:     delay,
+		})
+	}
+}
+
+// BlockDelayStats returns the rolling arrival delay statistics of the validators
+// whose blocks were imported, ordered by validator address.
+func (bc *BlockChain) BlockDelayStats() []BlockDelayStats {
+	bc.blockDelays.lock.RLock()
+	defer bc.blockDelays.lock.RUnlock()
+
+	threshold := bc.cacheConfig.BlockDelayThreshold
+	stats := make([]BlockDelayStats, 0, len(bc.blockDelays.validators))
+	for validator, delays := range bc.blockDelays.validators {
+		entry := BlockDelayStats{
+			Validator: validator,
+			Blocks:    len(delays.delays),
+			Last:      delays.delays[(delays.next+len(delays.delays)-1)%len(delays.delays)],
+			LastBlock: delays.lastBlock,
+		}
+		var total time.Duration
+		for _, delay := range delays.delays {
+			total += delay
+			if delay > entry.Max {
+				entry.Max = delay
+			}
+			if threshold > 0 && delay > threshold {
+				entry.Late++
+			}
+		}
+		entry.Mean = total / time.Duration(len(delays.delays))
+		stats = append(stats, entry)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return bytes.Compare(stats[i].Validator[:], stats[j].Validator[:]) < 0
+	})
+	return stats
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the arrival delays of the imported blocks are accounted per
+// validator and that the late blocks are reported.
+func TestBlockDelays(t *testing.T) {
+	var (
+		genDb   = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+		first   = common.Address{0x01}
+		second  = common.Address{0x02}
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), genDb, 5, func(i int, b *BlockGen) {
+		if i%2 == 0 {
+			b.SetCoinbase(first)
+		} else {
+			b.SetCoinbase(second)
+		}
+	}, true)
+
+	// Blocks arriving ahead of time are accounted as on time, the ones synced
+	// way after their sealing time are not accounted at all
+	delays := []time.Duration{time.Second, -time.Second, 3 * time.Second, 500 * time.Millisecond, time.Hour}
+	for i, block := range blocks {
+		block.ReceivedAt = time.Unix(int64(block.Time()), 0).Add(delays[i])
+	}
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.BlockDelayThreshold = 2 * time.Second
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan BlockDelayEvent, 10)
+	sub := chain.SubscribeBlockDelayEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	want := []BlockDelayStats{
+		{Validator: first, Blocks: 2, Late: 1, Mean: 2 * time.Second, Max: 3 * time.Second, Last: 3 * time.Second, LastBlock: 3},
+		{Validator: second, Blocks: 2, Mean: 250 * time.Millisecond, Max: 500 * time.Millisecond, Last: 500 * time.Millisecond, LastBlock: 4},
+	}
+	stats := chain.BlockDelayStats()
+	if len(stats) != len(want) {
+		t.Fatalf("stats count mismatch: have %d, want %d", len(stats), len(want))
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("stats %d mismatch: have %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+	select {
+	case ev := <-events:
+		if ev.Header.Number.Uint64() != 3 || ev.Validator != first || ev.Delay != 3*time.Second {
+			t.Errorf("late block event mismatch: number %d, validator %x, delay %v", ev.Header.Number, ev.Validator, ev.Delay)
+		}
+	default:
+		t.Fatalf("late block not reported")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected late block event: number %d", ev.Header.Number)
+	default:
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BlockStateReader is a read only view of the state resulting from the
+// execution of a block.
+type BlockStateReader interface {
+	Exist(addr common.Address) bool
+	GetBalance(addr common.Address) *big.Int
+	GetNonce(addr common.Address) uint64
+	GetCode(addr common.Address) []byte
+	GetCodeHash(addr common.Address) common.Hash
+	GetState(addr common.Address, key common.Hash) common.Hash
+}
+
+// BlockValidatorHook is an external validator of the blocks imported by
+// InsertChain, e.g. a bridge operator or a monitoring agent, which can veto or
+// observe the imports without being part of the consensus engine.
+//
+// The hooks are invoked synchronously on the import path, so they should be
+// quick and must not call back into the blockchain.
+type BlockValidatorHook interface {
+	// PreInsert is called once the block is executed and its state validated,
+	// before it is written. Returning an error rejects the block as invalid.
+	PreInsert(block *types.Block, receipts types.Receipts, state BlockStateReader) error
+
+	// PostInsert is called once the block is written, whether it became the
+	// head of the chain or not.
+	PostInsert(block *types.Block, receipts types.Receipts, state BlockStateReader)
+}
+
+// validatorHook is a registered hook, unique even if the hook itself is
+// registered several times.
+type validatorHook struct {
+	BlockValidatorHook
+}
+
+// RegisterValidatorHook registers a hook invoked around the import of all the
+// blocks executed by InsertChain from then on. It returns a function removing
+// the hook.
+func (bc *BlockChain) RegisterValidatorHook(hook BlockValidatorHook) func() {
+	bc.validatorHooksLock.Lock()
+	defer bc.validatorHooksLock.Unlock()
+
+	var (
+		current    = bc.loadValidatorHooks()
+		registered = &validatorHook{hook}
+		hooks      = make([]*validatorHook, 0, len(current)+1)
+	)
+	hooks = append(append(hooks, current...), registered)
+	bc.validatorHooks.Store(&hooks)
+
+	return func() { bc.unregisterValidatorHook(registered) }
+}
+
+// unregisterValidatorHook removes a registered hook.
+func (bc *BlockChain) unregisterValidatorHook(hook *validatorHook) {
+	bc.validatorHooksLock.Lock()
+	defer bc.validatorHooksLock.Unlock()
+
+	var hooks []*validatorHook
+	for _, registered := range bc.loadValidatorHooks() {
+		if registered != hook {
+			hooks = append(hooks, registered)
+		}
+	}
+	bc.validatorHooks.Store(&hooks)
+}
+
+// loadValidatorHooks returns the currently registered hooks.
+func (bc *BlockChain) loadValidatorHooks() []*validatorHook {
+	if hooks := bc.validatorHooks.Load(); hooks != nil {
+		return *hooks
+	}
+	return nil
+}
+
+// preInsertHooks runs the pre-insertion checks of the given hooks, stopping at
+// the first veto.
+func preInsertHooks(hooks []*validatorHook, block *types.Block, receipts types.Receipts, state BlockStateReader) error {
+	for _, hook := range hooks {
+		if err := hook.PreInsert(block, receipts, state); err != nil {
+			return fmt.Errorf("%w: %v", ErrBlockVetoed, err)
+		}
+	}
+	return nil
+}
+
+// postInsertHooks notifies the given hooks of the insertion of a block.
+func postInsertHooks(hooks []*validatorHook, block *types.Block, receipts types.Receipts, state BlockStateReader) {
+	for _, hook := range hooks {
+		hook.PostInsert(block, receipts, state)
+	}
+}
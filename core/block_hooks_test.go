@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testValidatorHook vetoes the blocks of a given number and records the
+// balances of an account after the inserted blocks.
+type testValidatorHook struct {
+	veto     uint64
+	account  common.Address
+	balances map[uint64]*big.Int
+}
+
+func (h *testValidatorHook) PreInsert(block *types.Block, receipts types.Receipts, state BlockStateReader) error {
+	if len(receipts) != len(block.Transactions()) {
+		return errors.New("receipts mismatch")
+	}
+	if block.NumberU64() == h.veto {
+		return errors.New("vetoed")
+	}
+	return nil
+}
+
+func (h *testValidatorHook) PostInsert(block *types.Block, receipts types.Receipts, state BlockStateReader) {
+	h.balances[block.NumberU64()] = state.GetBalance(h.account)
+}
+
+// Tests that the registered validator hooks can veto block imports and observe
+// the state resulting from the imported blocks.
+func TestValidatorHooks(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.Address{0xaa}
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, func(i int, b *BlockGen) {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: b.TxNonce(addr), To: &to, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.header.BaseFee})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	hook := &testValidatorHook{veto: 2, account: to, balances: make(map[uint64]*big.Int)}
+	unregister := chain.RegisterValidatorHook(hook)
+
+	if n, err := chain.InsertChain(blocks, nil); !errors.Is(err, ErrBlockVetoed) || n != 1 {
+		t.Fatalf("vetoed import mismatch: have %d/%v, want %d/%v", n, err, 1, ErrBlockVetoed)
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 1 {
+		t.Fatalf("head mismatch: have %d, want %d", head, 1)
+	}
+	if len(hook.balances) != 1 || hook.balances[1].Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("observed balances mismatch: have %v, want 1000 after block 1", hook.balances)
+	}
+	// Removing the hook lets the vetoed blocks in
+	unregister()
+	if _, err := chain.InsertChain(blocks[1:], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 3 {
+		t.Fatalf("head mismatch: have %d, want %d", head, 3)
+	}
+	if len(hook.balances) != 1 {
+		t.Fatalf("removed hook still invoked: %v", hook.balances)
+	}
+}
@@ -47,6 +47,32 @@ func NewBlockValidator(config *params.ChainConfig, blockchain *BlockChain, engin
 	return validator
 }
 
+// verifyOperations checks the settlement operations of the block against the
+// header roots. The operations may only be present after the settlement fork,
+// from which the roots are mandatory.
+func verifyOperations(config *params.ChainConfig, block *types.Block) error {
+	header := block.Header()
+	if !config.IsSettlement(header.Number) {
+		if header.WithdrawalsHash != nil || header.DepositsHash != nil {
+			return fmt.Errorf("invalid settlement roots before fork: have %v, %v, want all nils", header.WithdrawalsHash, header.DepositsHash)
+		}
+		if block.Withdrawals() != nil || block.Deposits() != nil {
+			return errors.New("settlement operations present in block body before fork")
+		}
+		return nil
+	}
+	if header.WithdrawalsHash == nil || header.DepositsHash == nil {
+		return errors.New("missing settlement roots in header")
+	}
+	if hash := types.DeriveSha(block.Withdrawals(), trie.NewStackTrie(nil)); hash != *header.WithdrawalsHash {
+		return fmt.Errorf("withdrawal root hash mismatch: have %x, want %x", hash, *header.WithdrawalsHash)
+	}
+	if hash := types.DeriveSha(block.Deposits(), trie.NewStackTrie(nil)); hash != *header.DepositsHash {
+		return fmt.Errorf("deposit root hash mismatch: have %x, want %x", hash, *header.DepositsHash)
+	}
+	return nil
+}
+
 // ValidateBody validates the given block's uncles and verifies the block
 // header's transaction and uncle roots. The headers are assumed to be already
 // validated at this point.
@@ -57,15 +83,29 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	}
 	// Header validity is known at this point, check the uncles and transactions
 	header := block.Header()
-	if err := v.engine.VerifyUncles(v.bc, block); err != nil {
-		return err
-	}
-	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
-		return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
+	if consensus.IsUncleFree(v.engine) {
+		// Engines without uncles only accept the empty uncle set, no need to
+		// consult the engine or hash the uncle list.
+		if len(block.Uncles()) > 0 {
+			return ErrUnclesNotAllowed
+		}
+		if header.UncleHash != types.EmptyUncleHash {
+			return fmt.Errorf("uncle root hash mismatch: have %x, want %x", header.UncleHash, types.EmptyUncleHash)
+		}
+	} else {
+		if err := v.engine.VerifyUncles(v.bc, block); err != nil {
+			return err
+		}
+		if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+			return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
+		}
 	}
 	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
+	if err := verifyOperations(v.config, block); err != nil {
+		return err
+	}
 
 	// Blob transactions may be present after the Cancun fork.
 	var blobCnt int
@@ -99,7 +139,14 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 		}
 		return consensus.ErrPrunedAncestor
 	}
-	return nil
+	// The block must not replay the transactions of its recent ancestors
+	if v.bc.recentTxs != nil {
+		if err := v.bc.recentTxs.verify(v.bc, block); err != nil {
+			return err
+		}
+	}
+	// The block must start with the transactions mandated by the engine
+	return verifyForcedTransactions(v.engine, v.bc, block)
 }
 
 // ValidateState validates the various changes that happen after a state
@@ -17,10 +17,13 @@
 package core
 
 import (
+	"math/big"
 	"runtime"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -203,6 +206,90 @@ func testHeaderConcurrentAbortion(t *testing.T, threads int) {
 	}
 }
 
+// uncleFreeEngine is a consensus engine which never accepts uncles.
+type uncleFreeEngine struct {
+	consensus.Engine
+}
+
+func (e uncleFreeEngine) UncleFree() bool { return true }
+
+// Tests that bodies of engines without uncles are only accepted with no uncles
+// and the empty uncle hash.
+func TestUncleFreeBodyValidation(t *testing.T) {
+	var (
+		testdb    = rawdb.NewMemoryDatabase()
+		gspec     = &Genesis{Config: params.TestChainConfig}
+		genesis   = gspec.MustCommit(testdb, trie.NewDatabase(testdb, newDbConfig(rawdb.HashScheme)))
+		blocks, _ = GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), testdb, 3, func(i int, gen *BlockGen) {
+			if i == 2 {
+				gen.AddUncle(&types.Header{ParentHash: gen.PrevBlock(1).Hash(), Number: big.NewInt(2)})
+			}
+		}, true)
+	)
+	engine := uncleFreeEngine{ethash.NewFaker()}
+	if !consensus.IsUncleFree(engine) {
+		t.Fatal("engine not reported uncle free")
+	}
+	chain, _ := NewBlockChain(testdb, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, engine, vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	if err := chain.Validator().ValidateBody(blocks[0]); err != nil {
+		t.Fatalf("uncle-less body rejected: %v", err)
+	}
+	if err := chain.Validator().ValidateBody(blocks[2]); err != ErrUnclesNotAllowed {
+		t.Fatalf("body with uncles: have %v, want %v", err, ErrUnclesNotAllowed)
+	}
+	header := blocks[1].Header()
+	header.UncleHash = common.Hash{0x01}
+	block := types.NewBlockWithHeader(header).WithBody(blocks[1].Transactions(), nil)
+	if err := chain.Validator().ValidateBody(block); err == nil {
+		t.Fatal("body with non-empty uncle hash accepted")
+	}
+}
+
+// Tests that the settlement operations are only accepted after the settlement
+// fork, matching the header roots.
+func TestSettlementBodyValidation(t *testing.T) {
+	config := *params.TestChainConfig
+	config.SettlementBlock = big.NewInt(2)
+
+	var (
+		testdb    = rawdb.NewMemoryDatabase()
+		gspec     = &Genesis{Config: &config}
+		genesis   = gspec.MustCommit(testdb, trie.NewDatabase(testdb, newDbConfig(rawdb.HashScheme)))
+		blocks, _ = GenerateChain(&config, genesis, ethash.NewFaker(), testdb, 3, nil, true)
+	)
+	chain, _ := NewBlockChain(testdb, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	defer chain.Stop()
+
+	if blocks[0].Header().WithdrawalsHash != nil || blocks[1].Header().WithdrawalsHash == nil {
+		t.Fatal("settlement roots not set from the fork")
+	}
+	if _, err := chain.InsertChain(blocks[:2], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Operations are rejected before the fork
+	withdrawals := []*types.Withdrawal{{Index: 1, Amount: big.NewInt(1)}}
+	block := blocks[0].WithOperations(withdrawals, nil)
+	if err := verifyOperations(&config, block); err == nil {
+		t.Fatal("operations accepted before the fork")
+	}
+	// Operations must match the header roots after the fork
+	block = blocks[2].WithOperations(withdrawals, nil)
+	if err := chain.Validator().ValidateBody(block); err == nil {
+		t.Fatal("operations mismatching the header root accepted")
+	}
+	header := blocks[2].Header()
+	header.DepositsHash = nil
+	block = types.NewBlockWithHeader(header).WithBody(blocks[2].Transactions(), nil)
+	if err := chain.Validator().ValidateBody(block); err == nil {
+		t.Fatal("body without deposit root accepted")
+	}
+	if err := chain.Validator().ValidateBody(blocks[2]); err != nil {
+		t.Fatalf("empty operations rejected: %v", err)
+	}
+}
+
 func TestCalcGasLimit(t *testing.T) {
 	for i, tc := range []struct {
 		pGasLimit uint64
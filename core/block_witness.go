@@ -0,0 +1,59 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var witnessSizeHist = metrics.NewRegisteredHistogram("chain/witness/size", nil, metrics.NewExpDecaySample(1028, 0.015))
+
+// recordWitness collects the execution witness of a block from the state it was
+// processed on, once validated but before being committed.
+func (bc *BlockChain) recordWitness(block *types.Block, parentRoot common.Hash, statedb *state.StateDB) {
+	nodes, codes := statedb.Witness()
+
+	witness := &types.Witness{
+		Block:  block.Hash(),
+		Number: block.NumberU64(),
+		Root:   parentRoot,
+		State:  make([]hexutil.Bytes, 0, len(nodes)),
+		Codes:  make([]hexutil.Bytes, 0, len(codes)),
+	}
+	for _, node := range nodes {
+		witness.State = append(witness.State, common.CopyBytes(node))
+	}
+	for _, code := range codes {
+		witness.Codes = append(witness.Codes, common.CopyBytes(code))
+	}
+	witnessSizeHist.Update(int64(witness.Size()))
+	bc.witnesses.Add(witness.Block, witness)
+}
+
+// GetBlockWitness retrieves the execution witness of a recently imported block,
+// or nil if it's not available or the witness collection is disabled.
+func (bc *BlockChain) GetBlockWitness(hash common.Hash) *types.Witness {
+	if bc.witnesses == nil {
+		return nil
+	}
+	witness, _ := bc.witnesses.Get(hash)
+	return witness
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func TestBlockWitness(t *testing.T) {
+	testBlockWitness(t, rawdb.HashScheme)
+	testBlockWitness(t, rawdb.PathScheme)
+}
+
+// Tests that the collected execution witnesses are enough to replay the blocks
+// statelessly, against the state root of their parents only.
+func testBlockWitness(t *testing.T, scheme string) {
+	var (
+		key, _  = crypto.GenerateKey()
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		writer  = common.HexToAddress("0xaaaa")
+		sizer   = common.HexToAddress("0xbbbb")
+		genDb   = rawdb.NewMemoryDatabase()
+		signer  = types.LatestSigner(params.TestChainConfig)
+		storage = map[common.Hash]common.Hash{
+			{0x00}: {0x01}, {0x01}: {0x02}, {0x02}: {0x03}, {0x03}: {0x04},
+		}
+		gspec = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// NUMBER PUSH1 0 SSTORE PUSH1 1 SLOAD POP STOP
+				writer: {Balance: common.Big0, Code: common.FromHex("0x4360005560015450"), Storage: storage},
+				// PUSH20 writer EXTCODESIZE POP STOP
+				sizer: {Balance: common.Big0, Code: append(append([]byte{0x73}, writer.Bytes()...), 0x3b, 0x50)},
+			},
+		}
+		genesis = gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), genDb, 3, func(i int, b *BlockGen) {
+		for _, to := range []common.Address{writer, sizer, {0x01}, {0x02}} {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), to, big.NewInt(1), 100000, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	}, true)
+
+	cacheConfig := DefaultCacheConfigWithScheme(scheme)
+	cacheConfig.WitnessCache = 16
+	cacheConfig.ParallelWorkers = 4 // Must be ignored while recording witnesses
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// The speculative executions would miss reads from the witnesses
+	if workers := chain.processor.(*StateProcessor).parallelWorkers(blocks[0], vm.Config{}, nil); workers != 0 {
+		t.Fatalf("parallel execution enabled while recording witnesses: %d workers", workers)
+	}
+	parent := genesis.Header()
+	for _, block := range blocks {
+		witness := chain.GetBlockWitness(block.Hash())
+		if witness == nil {
+			t.Fatalf("block %d: missing witness", block.NumberU64())
+		}
+		if witness.Root != parent.Root || witness.Number != block.NumberU64() {
+			t.Fatalf("block %d: witness mismatch: root %x, number %d", block.NumberU64(), witness.Root, witness.Number)
+		}
+		if len(witness.Codes) != 2 {
+			t.Fatalf("block %d: witness code count mismatch: have %d, want %d", block.NumberU64(), len(witness.Codes), 2)
+		}
+		// Replay the block on a database holding nothing but the witness
+		db := rawdb.NewMemoryDatabase()
+		for _, node := range witness.State {
+			rawdb.WriteLegacyTrieNode(db, crypto.Keccak256Hash(node), node)
+		}
+		for _, code := range witness.Codes {
+			rawdb.WriteCode(db, crypto.Keccak256Hash(code), code)
+		}
+		statedb, err := state.New(witness.Root, state.NewDatabase(db), nil)
+		if err != nil {
+			t.Fatalf("block %d: failed to open witness state: %v", block.NumberU64(), err)
+		}
+		if _, _, _, _, err := chain.Processor().Process(block, statedb, vm.Config{}, nil); err != nil {
+			t.Fatalf("block %d: failed to replay: %v", block.NumberU64(), err)
+		}
+		if err := statedb.Error(); err != nil {
+			t.Fatalf("block %d: witness incomplete: %v", block.NumberU64(), err)
+		}
+		if root := statedb.IntermediateRoot(true); root != block.Root() {
+			t.Fatalf("block %d: replayed state root mismatch: have %x, want %x", block.NumberU64(), root, block.Root())
+		}
+		parent = block.Header()
+	}
+	// Witnesses are not collected unless enabled
+	if witness := (&BlockChain{}).GetBlockWitness(blocks[0].Hash()); witness != nil {
+		t.Fatalf("witness retrieved while disabled")
+	}
+}
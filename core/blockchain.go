@@ -32,11 +32,13 @@ import (
 	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/internal/syncx"
@@ -80,10 +82,13 @@ var (
 	blockReorgMeter     = metrics.NewRegisteredMeter("chain/reorg/executes", nil)
 	blockReorgAddMeter  = metrics.NewRegisteredMeter("chain/reorg/add", nil)
 	blockReorgDropMeter = metrics.NewRegisteredMeter("chain/reorg/drop", nil)
+	blockReorgDenyMeter = metrics.NewRegisteredMeter("chain/reorg/denied", nil)
 
 	blockPrefetchExecuteTimer   = metrics.NewRegisteredTimer("chain/prefetch/executes", nil)
 	blockPrefetchInterruptMeter = metrics.NewRegisteredMeter("chain/prefetch/interrupts", nil)
 
+	senderCacheHitMeter = metrics.NewRegisteredMeter("chain/senders/hits", nil)
+
 	blockTxsGauge     = metrics.NewRegisteredGauge("chain/block/txs", nil)
 	blockGasUsedGauge = metrics.NewRegisteredGauge("chain/block/gasUsed", nil)
 
@@ -97,6 +102,7 @@ const (
 	receiptsCacheLimit      = 32
 	txLookupCacheLimit      = 1024
 	maxFutureBlocks         = 256
+	hotStorageRetention     = 32 // Number of blocks a hot contract slot is kept warm without being accessed
 	maxTimeFutureBlocks     = 30
 	DefaultTriesInMemory    = 128
 	dirtyAccountsCacheLimit = 32
@@ -104,6 +110,8 @@ const (
 
 	blobSidecarsCacheLimit = 32
 
+	snapshotVerifySamples = 16 // Number of state positions sampled in each snapshot verification round
+
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	//
 	// Changelog:
@@ -142,9 +150,129 @@ type CacheConfig struct {
 	Preimages           bool          // Whether to store preimage of trie key to the disk
 	TriesInMemory       int           // The number of tries is kept in memory before pruning
 	NoPruningSideCar    bool          // Whether to disable blob sidecar pruning
+	SideCarRetention    uint64        // Number of blocks from head whose blob sidecars are kept (0 = params.BlobPrunePeriod)
 	StateHistory        uint64        // Number of blocks from head whose state histories are reserved.
 	StateScheme         string        // Scheme used to store ethereum states and merkle tree nodes on top
 
+	// ReorgProtectWindow is the duration after sealing during which a locally
+	// produced block may only be reorged out by a finalized competing branch.
+	// Zero disables the protection.
+	ReorgProtectWindow time.Duration
+
+	// MaxReorgDepth is the maximum number of canonical blocks a reorg may retract.
+	// Deeper reorgs are refused and announced as a DeepReorgEvent. Zero disables
+	// the limit.
+	MaxReorgDepth uint64
+
+	// ContractCreationIndex enables maintaining an index of the creator, deploying
+	// transaction and creation code of every contract on the canonical chain.
+	ContractCreationIndex bool
+
+	// ReceiptIndex enables maintaining an index of the position and outcome of
+	// every canonical transaction, sparing single receipt lookups the decoding
+	// of the whole receipt list of the block.
+	ReceiptIndex bool
+
+	// SnapshotVerifyInterval is the time interval between two rounds of sampled
+	// snapshot verification against the state trie. Zero disables it.
+	SnapshotVerifyInterval time.Duration
+
+	// SnapshotAccountRatio is the share of the snapshot cache reserved for the
+	// accounts, the rest holding the storage slots. Zero shares a single cache
+	// between both.
+	SnapshotAccountRatio float64
+
+	// SnapshotRebalance enables moving the snapshot cache partitioning toward
+	// the data with the lower hit rate.
+	SnapshotRebalance bool
+
+	// RecentStates is the number of recent canonical block states kept alive
+	// for StateAtNumber, regardless of TriesInMemory. Zero disables it.
+	RecentStates uint64
+
+	// BlockDelayThreshold is the arrival delay of a block, compared to its
+	// expected sealing time, above which a BlockDelayEvent is posted. Zero
+	// disables the events, the delay statistics are collected regardless.
+	BlockDelayThreshold time.Duration
+
+	// CompactionInterval is the interval at which the database ranges scheduled
+	// for compaction, including the block data migrated into the freezer, are
+	// compacted away from the sealing turns of the local validator, whereas the
+	// database's own compactions are throttled around the turns. Zero disables
+	// both.
+	CompactionInterval time.Duration
+
+	// FeeChangeThreshold is the change of the base fee between a new canonical
+	// head and its parent, in percent, above which a FeeChangeEvent is posted.
+	// Zero disables the base fee events, gas limit changes are always posted.
+	FeeChangeThreshold uint64
+
+	// TxInclusionSLA is the time within which the local transactions tracked
+	// from their pool admission are expected to be included, above which a
+	// TxSLAEvent is posted. Zero disables the tracking.
+	TxInclusionSLA time.Duration
+
+	// SchemeMigration enables the background conversion of a hash-based state
+	// into the path-based layout, switched over as soon as it completes. An
+	// interrupted migration is resumed regardless.
+	SchemeMigration bool
+
+	// StateSchemePinned is set if the state scheme is explicitly configured,
+	// ruling out the scheme migration altogether.
+	StateSchemePinned bool
+
+	// ChainAudit enables the hash-chained audit log of the chain mutations: the
+	// head changes, the rewinds and the configuration overrides.
+	ChainAudit bool
+
+	// WitnessStats enables the estimation of the execution witness size of the
+	// processed blocks, kept for the recent ones. The state of the processed
+	// blocks is read through the tries instead of the snapshot to account for
+	// all the accesses.
+	WitnessStats bool
+
+	// WitnessCache is the number of recent imported blocks whose execution
+	// witness is collected and kept (0 = disabled). The state of the blocks is
+	// read through the tries instead of the snapshot while enabled.
+	WitnessCache int
+
+	// SupplyCheck enables the verification, for every imported block, that the
+	// change of the total supply equals the minted block rewards minus the
+	// burned fees, a SupplyViolationEvent being posted otherwise.
+	SupplyCheck bool
+
+	// SupplyBridges are the accounts holding the ether locked by the bridges.
+	// Their balance changes are accounted as ether burned or minted by the
+	// bridges, instead of as circulating supply.
+	SupplyBridges []common.Address
+
+	// SstoreStats enables the counting of the SSTOREs executed by the imported
+	// blocks, by slot transition, kept for the recent ones.
+	SstoreStats bool
+
+	// TraceHashes enables the hashing of the execution events of the transactions
+	// of the imported blocks, the digests being kept for the recent ones.
+	TraceHashes bool
+
+	// ParallelWorkers is the number of workers executing the transactions of the
+	// imported blocks ahead in parallel (0 = serial execution).
+	ParallelWorkers int
+
+	// DuplicateTxWindow is the number of recent canonical blocks whose
+	// transactions are rejected if included again by an imported block
+	// (0 = disabled).
+	DuplicateTxWindow uint64
+
+	// HistoryPrune is the number of recent blocks whose bodies and receipts are
+	// kept, those of the older blocks being pruned in batches along with their
+	// transaction lookups. The headers and canonical hashes are always kept.
+	// Zero keeps the entire history.
+	HistoryPrune uint64
+
+	// HotContracts are the contracts whose recently accessed storage is kept
+	// warm across blocks. Nil defaults to the system contracts of the chain.
+	HotContracts []common.Address
+
 	SnapshotWait bool // Wait for snapshot construction on startup. TODO(karalabe): This is a dirty hack for testing, nuke it
 }
 
@@ -210,6 +338,32 @@ type BlockChain struct {
 	gcproc time.Duration  // Accumulates canonical block processing for trie dumping
 	triedb *trie.Database // The database handler for maintaining trie nodes.
 
+	snapVerifier *snapshot.Verifier // Background sampled verifier of the snapshot (nil = disabled)
+
+	exportLock sync.Mutex      // Lock protecting the finalized checkpoints pinned for export
+	exportPins []*types.Header // Finalized checkpoints whose state is pinned for export, oldest first
+
+	lastFinalized uint64 // Number of the last block announced as finalized, protected by chainmu
+
+	receiptsLock sync.Mutex // Lock serializing the rewrites of the legacy receipts with the pruning
+
+	recentStates recentStates // References held on the recent and the pinned states
+	blockDelays  blockDelays  // Rolling arrival delay statistics of the validators
+	importAcks   importAcks   // Signed acknowledgments of the recent canonical blocks
+	compactions  compactions  // Database ranges scheduled for compaction
+
+	audit         *chainAudit          // Audit log of the chain mutations (nil = disabled)
+	hotStorage    *state.HotStorage    // Storage of the hot contracts kept warm across blocks (nil = disabled)
+	accessHistory *state.AccessHistory // Storage recently accessed by any contract, warmed up on demand (nil = disabled)
+	witnessStats  *witnessStats        // Witness size estimates of the recent blocks (nil = disabled)
+	sstoreStats   *sstoreStats         // SSTORE counters of the recent blocks (nil = disabled)
+	traceHashes   *traceHashes         // Execution digests of the recent blocks (nil = disabled)
+	recentTxs     *recentTxs           // Transactions of the recent canonical blocks (nil = disabled)
+	txSLA         *txSLAMonitor        // Inclusion tracking of the local transactions (nil = disabled)
+
+	migrationLock sync.Mutex             // Lock protecting the scheme migration status
+	migration     *SchemeMigrationStatus // Status of the running scheme migration (nil = not running)
+
 	// txLookupLimit is the maximum number of blocks from head whose tx indices
 	// are reserved:
 	//  * 0:   means no limit and regenerate any missing indexes
@@ -217,6 +371,10 @@ type BlockChain struct {
 	//  * nil: disable tx reindexer/deleter, but still index new blocks
 	txLookupLimit uint64
 
+	// historyTail is the number of the first block whose body and receipts are
+	// kept, those below being pruned (see HistoryPrune).
+	historyTail atomic.Uint64
+
 	hc            *HeaderChain
 	rmLogsFeed    event.Feed
 	chainFeed     event.Feed
@@ -228,6 +386,13 @@ type BlockChain struct {
 	blockProcFeed    event.Feed
 	internalTxFeed   event.Feed
 	dirtyAccountFeed event.Feed
+	blockDelayFeed   event.Feed
+	finalizedFeed    event.Feed
+	feeChangeFeed    event.Feed
+	supplyFeed       event.Feed
+	txInclusionFeed  event.Feed
+	deepReorgFeed    event.Feed
+	txSLAFeed        event.Feed
 	scope            event.SubscriptionScope
 	genesisBlock     *types.Block
 
@@ -248,6 +413,7 @@ type BlockChain struct {
 	dirtyAccountsCache        *lru.Cache[common.Hash, []*types.DirtyStateAccount]   // Cache for the most recent dirtyAccounts
 	internalTransactionsCache *lru.Cache[common.Hash, []*types.InternalTransaction] // Cache for most recent internal transactions with block hash at key
 	blobSidecarsCache         *lru.Cache[common.Hash, types.BlobSidecars]           // Cache for most recent blob sidecars
+	witnesses                 *lru.Cache[common.Hash, *types.Witness]               // Execution witnesses of the recent blocks (nil = disabled)
 
 	wg            sync.WaitGroup //
 	quit          chan struct{}  // shutdown signal, closed in Stop.
@@ -264,6 +430,11 @@ type BlockChain struct {
 	shouldStoreInternalTxs     bool
 	enableAdditionalChainEvent bool
 	evmHook                    vm.EVMHook
+	senderSource               func(common.Hash) *types.Transaction              // Lookup of transactions with already recovered senders
+	pendingSource              func(int) map[common.Address][]*types.Transaction // Source of the transactions pending inclusion
+
+	validatorHooks     atomic.Pointer[[]*validatorHook] // Hooks vetoing and observing the block imports, copied on write
+	validatorHooksLock sync.Mutex                       // Lock serializing the updates of the hooks
 
 	blobPrunePeriod uint64
 }
@@ -311,6 +482,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		db:                        db,
 		triedb:                    triedb,
 		triegc:                    prque.New(nil),
+		recentStates:              recentStates{pins: make(map[common.Hash]int)},
+		blockDelays:               blockDelays{validators: make(map[common.Address]*validatorDelays)},
 		stateCache:                state.NewDatabaseWithNodeDB(db, triedb),
 		quit:                      make(chan struct{}),
 		chainmu:                   syncx.NewClosableMutex(),
@@ -330,16 +503,55 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		blobSidecarsCache: blobSidecarsCache,
 		blobPrunePeriod:   params.BlobPrunePeriod,
 	}
+	if cacheConfig.SideCarRetention > 0 {
+		bc.blobPrunePeriod = cacheConfig.SideCarRetention
+	}
 
 	if chainConfig.ChainID != nil && chainConfig.ChainID.Cmp(big.NewInt(testnetChainId)) == 0 {
 		bc.evmHook = TestnetHook{}
 	}
 
+	if cacheConfig.ChainAudit {
+		bc.audit = newChainAudit(db)
+	}
+	if cacheConfig.WitnessStats {
+		bc.witnessStats = newWitnessStats()
+	}
+	if cacheConfig.SstoreStats {
+		bc.sstoreStats = newSstoreStats()
+	}
+	if cacheConfig.TraceHashes {
+		bc.traceHashes = newTraceHashes()
+	}
+	if cacheConfig.WitnessCache > 0 {
+		bc.witnesses, _ = lru.New[common.Hash, *types.Witness](cacheConfig.WitnessCache)
+	}
+	if cacheConfig.DuplicateTxWindow > 0 {
+		bc.recentTxs = newRecentTxs(cacheConfig.DuplicateTxWindow)
+	}
+	if cacheConfig.TxInclusionSLA > 0 {
+		bc.txSLA = newTxSLAMonitor(cacheConfig.TxInclusionSLA)
+	}
+	hotContracts := cacheConfig.HotContracts
+	if hotContracts == nil {
+		hotContracts = systemContracts(chainConfig)
+	}
+	if len(hotContracts) > 0 && !cacheConfig.TrieCleanNoPrefetch {
+		bc.hotStorage = state.NewHotStorage(hotContracts, hotStorageRetention)
+	}
+	if !cacheConfig.TrieCleanNoPrefetch {
+		bc.accessHistory = state.NewAccessHistory(accessHistoryRetention, accessHistoryLimit)
+	}
 	bc.stateCache = state.NewDatabaseWithNodeDB(bc.db, bc.triedb)
 	bc.validator = NewBlockValidator(chainConfig, bc, engine)
 	bc.prefetcher = newStatePrefetcher(chainConfig, bc, engine)
 	bc.processor = NewStateProcessor(chainConfig, bc, engine)
 
+	// Discard the leftovers of an ancient block write interrupted by a crash
+	// before anything is loaded from the database.
+	if _, err := rawdb.RecoverBlockWrites(db); err != nil {
+		return nil, err
+	}
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.insertStopped)
 	if err != nil {
@@ -369,6 +581,9 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	if bc.recentTxs != nil {
+		bc.recentTxs.load(bc, bc.CurrentBlock())
+	}
 
 	// Make sure the state associated with the block is available
 	head := bc.CurrentBlock()
@@ -399,11 +614,13 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 				if snapDisk != 0 {
 					rawdb.WriteSnapshotRecoveryNumber(bc.db, snapDisk)
 				}
+				bc.recordMutation(AuditRollback, "node", "head state missing")
 			} else {
 				log.Warn("Head state missing, repairing", "number", head.Number, "hash", head.Hash())
 				if _, err := bc.setHeadBeyondRoot(head.NumberU64(), common.Hash{}, true); err != nil {
 					return nil, err
 				}
+				bc.recordMutation(AuditRollback, "node", "head state missing")
 			}
 		}
 	}
@@ -434,7 +651,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 		}
 		if needRewind {
 			log.Error("Truncating ancient chain", "from", bc.CurrentHeader().Number.Uint64(), "to", low)
-			if err := bc.SetHead(low); err != nil {
+			if err := bc.setHeadAudited(low, AuditRollback, "node", "ancient chain truncated"); err != nil {
 				return nil, err
 			}
 		}
@@ -448,7 +665,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 			// make sure the headerByNumber (if present) is in our current canonical chain
 			if headerByNumber != nil && headerByNumber.Hash() == header.Hash() {
 				log.Error("Found bad hash, rewinding chain", "number", header.Number, "hash", header.ParentHash)
-				if err := bc.SetHead(header.Number.Uint64() - 1); err != nil {
+				if err := bc.setHeadAudited(header.Number.Uint64()-1, AuditRollback, "node", fmt.Sprintf("bad block %x", hash)); err != nil {
 					return nil, err
 				}
 				log.Error("Chain rewind was successful, resuming normal operation")
@@ -470,13 +687,74 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 			log.Warn("Enabling snapshot recovery", "chainhead", head.NumberU64(), "diskbase", *layer)
 			recover = true
 		}
-		bc.snaps, _ = snapshot.New(bc.db, bc.triedb, bc.cacheConfig.SnapshotLimit, head.Root(), !bc.cacheConfig.SnapshotWait, true, recover)
+		snapCache := snapshot.CacheConfig{
+			Size:         bc.cacheConfig.SnapshotLimit,
+			AccountRatio: bc.cacheConfig.SnapshotAccountRatio,
+			Rebalance:    bc.cacheConfig.SnapshotRebalance,
+		}
+		bc.snaps, _ = snapshot.New(bc.db, bc.triedb, snapCache, head.Root(), !bc.cacheConfig.SnapshotWait, true, recover)
+	}
+	if bc.snaps != nil && bc.cacheConfig.SnapshotVerifyInterval > 0 {
+		bc.snapVerifier = snapshot.NewVerifier(bc.snaps, bc.cacheConfig.SnapshotVerifyInterval, snapshotVerifySamples)
+		bc.snapVerifier.Start()
+	}
+	// Start or resume the migration of the state into the path-based layout,
+	// or finish deleting the legacy nodes left behind by a completed one.
+	if bc.triedb.Scheme() == rawdb.HashScheme && (bc.cacheConfig.SchemeMigration || rawdb.ReadSchemeMigration(bc.db) != nil) {
+		if err := bc.StartSchemeMigration(); err != nil {
+			log.Error("Failed to start scheme migration", "err", err)
+		}
+	}
+	if _, pending := rawdb.ReadLegacyTrieCleanup(bc.db); pending && bc.triedb.Scheme() == rawdb.PathScheme {
+		bc.wg.Add(1)
+		go bc.deleteLegacyTrieNodes()
 	}
 
 	// Start future block processor.
 	bc.wg.Add(1)
 	go bc.futureBlocksLoop()
 
+	// Start the compaction of the data freed in the database, the block data
+	// frozen before this run is left to the database's own compactions.
+	if bc.cacheConfig.CompactionInterval > 0 {
+		bc.compactions.frozen, _ = bc.db.Ancients()
+
+		bc.wg.Add(2)
+		go bc.compactionLoop(bc.cacheConfig.CompactionInterval)
+		go bc.compactionGateLoop()
+	}
+
+	// Start checking the local transactions against the inclusion SLA.
+	if bc.txSLA != nil {
+		bc.wg.Add(1)
+		go bc.txSLALoop()
+	}
+
+	// Start pruning the block history beyond the retention window.
+	bc.historyTail.Store(max(rawdb.ReadHistoryTail(bc.db), rawdb.FrozenHistoryTail(bc.db)))
+	if bc.cacheConfig.HistoryPrune > 0 {
+		bc.wg.Add(1)
+		go bc.historyPruneLoop()
+	}
+
+	// Backfill the receipt index of the blocks preceding its enabling, or forget
+	// about the backfill progress for a later enabling to start over
+	if bc.cacheConfig.ReceiptIndex {
+		bc.wg.Add(1)
+		go bc.backfillReceiptIndex()
+	} else {
+		rawdb.DeleteReceiptIndexTail(bc.db)
+	}
+
+	// Index the hashes of the blocks written before the block hash index
+	if !rawdb.HasBlockHashIndex(bc.db) {
+		bc.wg.Add(1)
+		go func() {
+			defer bc.wg.Done()
+			rawdb.IndexBlockHashes(bc.db, bc.quit)
+		}()
+	}
+
 	// Start tx indexer/unindexer.
 	if txLookupLimit != nil {
 		bc.txLookupLimit = *txLookupLimit
@@ -490,12 +768,37 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, genesis *Genesis
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
-		bc.SetHead(compat.RewindTo)
+		if compat.RewindToTime > 0 {
+			bc.SetHeadWithTimestamp(compat.RewindToTime)
+		} else {
+			bc.SetHead(compat.RewindTo)
+		}
 		rawdb.WriteChainConfig(db, genesisHash, chainConfig)
+		bc.recordMutation(AuditConfig, "node", compat.Error())
+	}
+	if overrideArrowGlacier != nil {
+		bc.recordMutation(AuditConfig, "node", fmt.Sprintf("arrow glacier block overridden to %v", overrideArrowGlacier))
 	}
 	return bc, nil
 }
 
+// systemContracts returns the system contracts of the chain, touched by every
+// block.
+func systemContracts(config *params.ChainConfig) []common.Address {
+	var contracts []common.Address
+	if c := config.ConsortiumV2Contracts; c != nil {
+		for _, addr := range []common.Address{c.RoninValidatorSet, c.SlashIndicator, c.StakingContract, c.ProfileContract, c.FinalityTracking} {
+			if addr != (common.Address{}) {
+				contracts = append(contracts, addr)
+			}
+		}
+	}
+	if config.RoninTreasuryAddress != nil {
+		contracts = append(contracts, *config.RoninTreasuryAddress)
+	}
+	return contracts
+}
+
 func (bc *BlockChain) SetHook(evmHook vm.EVMHook) {
 	bc.evmHook = evmHook
 }
@@ -504,6 +807,29 @@ func (bc *BlockChain) GetHook() vm.EVMHook {
 	return bc.evmHook
 }
 
+// SetSenderSource sets a lookup of transactions whose senders have already been
+// recovered, e.g. the transaction pool. The known senders are reused when
+// importing blocks instead of recovering them again. It must be called before
+// any block is imported.
+func (bc *BlockChain) SetSenderSource(source func(common.Hash) *types.Transaction) {
+	bc.senderSource = source
+}
+
+// blockSenders collects the senders of the block's transactions which are
+// already known to the sender source.
+func (bc *BlockChain) blockSenders(block *types.Block) *types.SenderCache {
+	if bc.senderSource == nil {
+		return nil
+	}
+	senders := types.NewSenderCache(types.MakeSigner(bc.chainConfig, block.Number()))
+	for _, tx := range block.Transactions() {
+		if known := bc.senderSource(tx.Hash()); known != nil {
+			senders.AddTransaction(known)
+		}
+	}
+	return senders
+}
+
 // setBlobPrunePeriod is used in tests to override the default prune
 // period for easy testing
 func (bc *BlockChain) setBlobPrunePeriod(period uint64) {
@@ -671,8 +997,20 @@ func (bc *BlockChain) loadLastState() error {
 // was fast synced or full synced and in which state, the method will try to
 // delete minimal data from disk whilst retaining chain consistency.
 func (bc *BlockChain) SetHead(head uint64) error {
-	_, err := bc.setHeadBeyondRoot(head, common.Hash{}, false)
-	return err
+	return bc.setHeadAudited(head, AuditSetHead, "node", "")
+}
+
+// SetHeadWithTimestamp rewinds the local chain to the last canonical block whose
+// timestamp is not beyond the given one, used to undo time scheduled forks.
+func (bc *BlockChain) SetHeadWithTimestamp(timestamp uint64) error {
+	header := bc.CurrentHeader()
+	for header != nil && header.Number.Sign() > 0 && header.Time > timestamp {
+		header = bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	if header == nil {
+		return fmt.Errorf("no canonical block at or before timestamp %d", timestamp)
+	}
+	return bc.SetHead(header.Number.Uint64())
 }
 
 // setHeadBeyondRoot rewinds the local chain to a new head with the extra condition
@@ -796,6 +1134,16 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 	}
 	// Rewind the header chain, deleting all block bodies until then
 	delFn := func(db ethdb.KeyValueWriter, hash common.Hash, num uint64) {
+		// Drop the receipt lookups while the body is still around
+		if bc.cacheConfig.ReceiptIndex {
+			if body := rawdb.ReadBody(bc.db, hash, num); body != nil {
+				hashes := make([]common.Hash, len(body.Transactions))
+				for i, tx := range body.Transactions {
+					hashes[i] = tx.Hash()
+				}
+				rawdb.DeleteReceiptLookupEntries(db, hashes)
+			}
+		}
 		// Ignore the error here since light client won't hit this path
 		frozen, _ := bc.db.Ancients()
 		if num+1 <= frozen {
@@ -813,6 +1161,8 @@ func (bc *BlockChain) setHeadBeyondRoot(head uint64, root common.Hash, repair bo
 			rawdb.DeleteBody(db, hash, num)
 			rawdb.DeleteReceipts(db, hash, num)
 		}
+		// The sidecars status is kept in the active store past freezing
+		rawdb.DeleteBlobSidecarsStatus(db, hash, num)
 		// Todo(rjl493456442) txlookup, bloombits, etc
 	}
 	// If SetHead was only called as a chain reparation method, try to skip
@@ -968,8 +1318,26 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	batch := bc.db.NewBatch()
 	rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
 	rawdb.WriteTxLookupEntriesByBlock(batch, block)
+	if bc.recentTxs != nil {
+		bc.recentTxs.add(block)
+	}
+	if bc.cacheConfig.ContractCreationIndex {
+		for addr, creation := range bc.contractCreations(block) {
+			rawdb.WriteContractCreation(batch, addr, creation)
+		}
+	}
+	if bc.cacheConfig.ReceiptIndex && len(block.Transactions()) > 0 {
+		rawdb.WriteReceiptLookupEntries(batch, block, rawdb.ReadRawReceipts(bc.db, block.Hash(), block.NumberU64()))
+	}
 	rawdb.WriteHeadBlockHash(batch, block.Hash())
 
+	if bc.audit != nil {
+		context := "extend"
+		if current := bc.CurrentBlock(); current != nil && block.ParentHash() != current.Hash() {
+			context = fmt.Sprintf("reorg from %d %x", current.NumberU64(), current.Hash())
+		}
+		bc.audit.append(batch, AuditHead, "chain", context, block)
+	}
 	// If the block is better than our head or is on a different chain, force update heads
 	if updateHeads {
 		rawdb.WriteHeadHeaderHash(batch, block.Hash())
@@ -987,6 +1355,58 @@ func (bc *BlockChain) writeHeadBlock(block *types.Block) {
 	}
 	bc.currentBlock.Store(block)
 	headBlockGauge.Update(int64(block.NumberU64()))
+
+	bc.ackImport(block)
+}
+
+// contractCreations gathers the contracts successfully deployed in a block, both
+// by creation transactions and, if internal transactions are recorded, by the
+// CREATE and CREATE2 opcodes. The block's receipts must already be stored.
+func (bc *BlockChain) contractCreations(block *types.Block) map[common.Address]*rawdb.ContractCreation {
+	receipts := bc.GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(block.Transactions()) {
+		return nil
+	}
+	var (
+		creations = make(map[common.Address]*rawdb.ContractCreation)
+		succeeded = make(map[common.Hash]bool)
+		signer    = types.MakeSigner(bc.chainConfig, block.Number())
+	)
+	for i, tx := range block.Transactions() {
+		if receipts[i].Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		succeeded[tx.Hash()] = true
+		if tx.To() != nil {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			continue
+		}
+		creations[receipts[i].ContractAddress] = &rawdb.ContractCreation{
+			Creator:      from,
+			TxHash:       tx.Hash(),
+			BlockHash:    block.Hash(),
+			BlockNumber:  block.NumberU64(),
+			InitCodeHash: crypto.Keccak256Hash(tx.Data()),
+		}
+	}
+	for _, internal := range bc.ReadInternalTransactions(block.Hash()) {
+		// Creations from within reverted transactions or call frames are rolled
+		// back as well
+		if internal.Type != types.InternalTransactionContractCreation || !internal.Success || internal.Reverted || !succeeded[internal.TransactionHash] {
+			continue
+		}
+		creations[internal.To] = &rawdb.ContractCreation{
+			Creator:      internal.From,
+			TxHash:       internal.TransactionHash,
+			BlockHash:    block.Hash(),
+			BlockNumber:  block.NumberU64(),
+			InitCodeHash: crypto.Keccak256Hash(internal.Input),
+		}
+	}
+	return creations
 }
 
 // Stop stops the blockchain service. If any imports are currently in progress
@@ -1024,6 +1444,12 @@ func (bc *BlockChain) Stop() {
 		rawdb.WriteDirtyAccounts(bc.db, dirtyStateAccounts)
 	}
 
+	if bc.snapVerifier != nil {
+		bc.snapVerifier.Stop()
+	}
+	bc.releaseExportPins()
+	bc.releaseRecentStates()
+
 	// Ensure that the entirety of the state snapshot is journalled to disk.
 	var snapBase common.Hash
 	if bc.snaps != nil {
@@ -1123,8 +1549,10 @@ type numberHash struct {
 }
 
 // InsertReceiptChain attempts to complete an already existing header chain with
-// transaction and receipt data.
-func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain []types.Receipts, ancientLimit uint64) (int, error) {
+// transaction and receipt data. If sidecars are given, they are verified against
+// the blob transactions and the header of their block before being written, as
+// the blocks are never executed, and their verification status is recorded.
+func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain []types.Receipts, sidecars [][]*types.BlobTxSidecar, ancientLimit uint64) (int, error) {
 	// We don't require the chainMu here since we want to maximize the
 	// concurrency of header insertion and receipt insertion.
 	bc.wg.Add(1)
@@ -1133,6 +1561,8 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 	var (
 		ancientBlocks, liveBlocks     types.Blocks
 		ancientReceipts, liveReceipts []types.Receipts
+		ancientSidecars, liveSidecars [][]*types.BlobTxSidecar
+		statuses                      = make([]byte, len(blockChain))
 	)
 	// Do a sanity check that the provided chain is actually ordered and linked
 	for i := 0; i < len(blockChain); i++ {
@@ -1144,10 +1574,37 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 					blockChain[i-1].Hash().Bytes()[:4], i, blockChain[i].NumberU64(), blockChain[i].Hash().Bytes()[:4], blockChain[i].ParentHash().Bytes()[:4])
 			}
 		}
+		// Receipts delivered by peers only carry their consensus fields, derive
+		// the others, the blob gas accounting included, before announcing them.
+		if err := receiptChain[i].DeriveFields(bc.chainConfig, blockChain[i].Hash(), blockChain[i].NumberU64(), eip4844.HeaderBlobFee(blockChain[i].Header()), blockChain[i].Transactions()); err != nil {
+			log.Error("Invalid receipts", "number", blockChain[i].Number(), "hash", blockChain[i].Hash(), "err", err)
+			return i, fmt.Errorf("invalid receipts of block #%d [%x..]: %w", blockChain[i].NumberU64(), blockChain[i].Hash().Bytes()[:4], err)
+		}
+		var sidecar []*types.BlobTxSidecar
+		if sidecars != nil {
+			sidecar = sidecars[i]
+			status, err := bc.verifyImportedSidecars(blockChain[i], &sidecar)
+			if err != nil {
+				log.Error("Invalid blob sidecars", "number", blockChain[i].Number(), "hash", blockChain[i].Hash(), "err", err)
+				return i, fmt.Errorf("invalid sidecars of block #%d [%x..]: %w", blockChain[i].NumberU64(), blockChain[i].Hash().Bytes()[:4], err)
+			}
+			statuses[i] = status
+		}
 		if blockChain[i].NumberU64() <= ancientLimit {
 			ancientBlocks, ancientReceipts = append(ancientBlocks, blockChain[i]), append(ancientReceipts, receiptChain[i])
+			ancientSidecars = append(ancientSidecars, sidecar)
 		} else {
 			liveBlocks, liveReceipts = append(liveBlocks, blockChain[i]), append(liveReceipts, receiptChain[i])
+			liveSidecars = append(liveSidecars, sidecar)
+		}
+	}
+	// writeSidecarsStatus records the verification status of the sidecars of
+	// the given blocks, which start at the given index of the inserted chain.
+	writeSidecarsStatus := func(db ethdb.KeyValueWriter, blocks types.Blocks, offset int) {
+		for i, block := range blocks {
+			if status := statuses[offset+i]; status != rawdb.BlobSidecarsUnverified {
+				rawdb.WriteBlobSidecarsStatus(db, block.Hash(), block.NumberU64(), status)
+			}
 		}
 	}
 
@@ -1168,7 +1625,13 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		// Rewind may have occurred, skip in that case.
 		if bc.CurrentHeader().Number.Cmp(head.Number()) >= 0 {
 			currentFastBlock, td := bc.CurrentFastBlock(), bc.GetTd(head.Hash(), head.NumberU64())
-			if bc.GetTd(currentFastBlock.Hash(), currentFastBlock.NumberU64()).Cmp(td) < 0 {
+			better := bc.GetTd(currentFastBlock.Hash(), currentFastBlock.NumberU64()).Cmp(td) < 0
+			if bc.hc.tdFrozen(head.NumberU64()) {
+				// Total difficulty is not tracked anymore, the header chain is
+				// already canonical so simply advance the fast block.
+				better = head.NumberU64() > currentFastBlock.NumberU64()
+			}
+			if better {
 				rawdb.WriteHeadFastBlockHash(bc.db, head.Hash())
 				bc.currentFastBlock.Store(head)
 				HeadFastBlockGauge.Update(int64(head.NumberU64()))
@@ -1182,16 +1645,16 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 	//
 	// this function only accepts canonical chain data. All side chain will be reverted
 	// eventually.
-	writeAncient := func(blockChain types.Blocks, receiptChain []types.Receipts) (int, error) {
+	writeAncient := func(blockChain types.Blocks, receiptChain []types.Receipts, sidecars [][]*types.BlobTxSidecar) (int, error) {
 		first := blockChain[0]
 		last := blockChain[len(blockChain)-1]
 
 		// Ensure genesis is in ancients.
 		if first.NumberU64() == 1 {
 			if frozen, _ := bc.db.Ancients(); frozen == 0 {
-				b := bc.genesisBlock
-				td := bc.genesisBlock.Difficulty()
-				writeSize, err := rawdb.WriteAncientBlocks(bc.db, []*types.Block{b}, []types.Receipts{nil}, td)
+				writer := rawdb.NewBlockWriteBatch(bc.db)
+				writer.Add(bc.genesisBlock, nil, nil, nil, false)
+				writeSize, err := writer.CommitAncient(bc.genesisBlock.Difficulty(), bc.hc.tdFreezeNumber())
 				size += writeSize
 				if err != nil {
 					log.Error("Error writing genesis to ancients", "err", err)
@@ -1207,16 +1670,8 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			return 0, fmt.Errorf("containing header #%d [%x..] unknown", last.Number(), last.Hash().Bytes()[:4])
 		}
 
-		// Write all chain data to ancients.
-		td := bc.GetTd(first.Hash(), first.NumberU64())
-		writeSize, err := rawdb.WriteAncientBlocks(bc.db, blockChain, receiptChain, td)
-		size += writeSize
-		if err != nil {
-			log.Error("Error importing chain data to ancients", "err", err)
-			return 0, err
-		}
-
-		// Write tx indices if any condition is satisfied:
+		// Write all chain data to ancients, along with the tx indices if any
+		// condition is satisfied:
 		// * If user requires to reserve all tx indices(txlookuplimit=0)
 		// * If all ancient tx indices are required to be reserved(txlookuplimit is even higher than ancientlimit)
 		// * If block number is large enough to be regarded as a recent block
@@ -1227,13 +1682,25 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		// a background routine to re-indexed all indices in [ancients - txlookupLimit, ancients)
 		// range. In this case, all tx indices of newly imported blocks should be
 		// generated.
-		var batch = bc.db.NewBatch()
+		//
+		// The write is committed atomically across the ancient and key-value
+		// stores, any failure rolls the ancient store back.
+		var (
+			writer    = rawdb.NewBlockWriteBatch(bc.db)
+			indexTail = rawdb.ReadTxIndexTail(bc.db) != nil
+		)
+		for i, block := range blockChain {
+			index := bc.txLookupLimit == 0 || ancientLimit <= bc.txLookupLimit || block.NumberU64() >= ancientLimit-bc.txLookupLimit || indexTail
+			writer.Add(block, receiptChain[i], blockSidecars(block, sidecars[i]), nil, index)
+		}
+		td := bc.GetTd(first.Hash(), first.NumberU64())
+		writeSize, err := writer.CommitAncient(td, bc.hc.tdFreezeNumber())
+		size += writeSize
+		if err != nil {
+			log.Error("Error importing chain data to ancients", "err", err)
+			return 0, err
+		}
 		for i, block := range blockChain {
-			if bc.txLookupLimit == 0 || ancientLimit <= bc.txLookupLimit || block.NumberU64() >= ancientLimit-bc.txLookupLimit {
-				rawdb.WriteTxLookupEntriesByBlock(batch, block)
-			} else if rawdb.ReadTxIndexTail(bc.db) != nil {
-				rawdb.WriteTxLookupEntriesByBlock(batch, block)
-			}
 			stats.processed++
 
 			// Send chain event includes block data and logs
@@ -1242,23 +1709,6 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 			}
 		}
 
-		// Flush all tx-lookup index data.
-		size += int64(batch.ValueSize())
-		if err := batch.Write(); err != nil {
-			// The tx index data could not be written.
-			// Roll back the ancient store update.
-			fastBlock := bc.CurrentFastBlock().NumberU64()
-			if _, err := bc.db.TruncateHead(fastBlock + 1); err != nil {
-				log.Error("Can't truncate ancient store after failed insert", "err", err)
-			}
-			return 0, err
-		}
-
-		// Sync the ancient store explicitly to ensure all data has been flushed to disk.
-		if err := bc.db.Sync(); err != nil {
-			return 0, err
-		}
-
 		// Update the current fast block because all block data is now present in DB.
 		previousFastBlock := bc.CurrentFastBlock().NumberU64()
 		if !updateHead(blockChain[len(blockChain)-1]) {
@@ -1271,7 +1721,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		}
 
 		// Delete block data from the main database.
-		batch.Reset()
+		batch := bc.db.NewBatch()
 		canonHashes := make(map[common.Hash]struct{})
 		for _, block := range blockChain {
 			canonHashes[block.Hash()] = struct{}{}
@@ -1287,6 +1737,12 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 				rawdb.DeleteHeader(batch, nh.Hash, nh.Number)
 			}
 		}
+		writeSidecarsStatus(batch, blockChain, 0)
+		if bc.cacheConfig.ReceiptIndex {
+			for i, block := range blockChain {
+				rawdb.WriteReceiptLookupEntries(batch, block, receiptChain[i])
+			}
+		}
 		if err := batch.Write(); err != nil {
 			return 0, err
 		}
@@ -1294,9 +1750,27 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 	}
 
 	// writeLive writes blockchain and corresponding receipt chain into active store.
-	writeLive := func(blockChain types.Blocks, receiptChain []types.Receipts) (int, error) {
-		skipPresenceCheck := false
-		batch := bc.db.NewBatch()
+	writeLive := func(blockChain types.Blocks, receiptChain []types.Receipts, sidecars [][]*types.BlobTxSidecar) (int, error) {
+		var (
+			skipPresenceCheck = false
+			writer            = rawdb.NewBlockWriteBatch(bc.db)
+			staged            []int // Indexes of the blocks staged in the writer
+		)
+		// commit writes the staged blocks along with their receipt lookups and
+		// sidecar statuses, so every block is either entirely present or not
+		commit := func() error {
+			writeSize, err := writer.Commit(func(batch ethdb.KeyValueWriter) {
+				for _, i := range staged {
+					if bc.cacheConfig.ReceiptIndex {
+						rawdb.WriteReceiptLookupEntries(batch, blockChain[i], receiptChain[i])
+					}
+					writeSidecarsStatus(batch, blockChain[i:i+1], len(ancientBlocks)+i)
+				}
+			})
+			size += writeSize
+			staged = staged[:0]
+			return err
+		}
 		for i, block := range blockChain {
 			// Short circuit insertion if shutting down or processing failed
 			if bc.insertStopped() {
@@ -1318,20 +1792,18 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 					skipPresenceCheck = true
 				}
 			}
-			// Write all the data out into the database
-			rawdb.WriteBody(batch, block.Hash(), block.NumberU64(), block.Body())
-			rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receiptChain[i])
-			rawdb.WriteTxLookupEntriesByBlock(batch, block) // Always write tx indices for live blocks, we assume they are needed
+			// Stage all the data of the block, always with the tx indices for
+			// live blocks, we assume they are needed
+			writer.Add(block, receiptChain[i], blockSidecars(block, sidecars[i]), nil, true)
+			staged = append(staged, i)
 
 			// Write everything belongs to the blocks into the database. So that
 			// we can ensure all components of body is completed(body, receipts,
 			// tx indexes)
-			if batch.ValueSize() >= ethdb.IdealBatchSize {
-				if err := batch.Write(); err != nil {
+			if writer.Size() >= ethdb.IdealBatchSize {
+				if err := commit(); err != nil {
 					return 0, err
 				}
-				size += int64(batch.ValueSize())
-				batch.Reset()
 			}
 			stats.processed++
 
@@ -1343,9 +1815,8 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		// Write everything belongs to the blocks into the database. So that
 		// we can ensure all components of body is completed(body, receipts,
 		// tx indexes)
-		if batch.ValueSize() > 0 {
-			size += int64(batch.ValueSize())
-			if err := batch.Write(); err != nil {
+		if writer.Len() > 0 {
+			if err := commit(); err != nil {
 				return 0, err
 			}
 		}
@@ -1355,7 +1826,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 
 	// Write downloaded chain data and corresponding receipt chain data
 	if len(ancientBlocks) > 0 {
-		if n, err := writeAncient(ancientBlocks, ancientReceipts); err != nil {
+		if n, err := writeAncient(ancientBlocks, ancientReceipts, ancientSidecars); err != nil {
 			if err == errInsertionInterrupted {
 				return 0, nil
 			}
@@ -1376,7 +1847,7 @@ func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain [
 		}
 	}
 	if len(liveBlocks) > 0 {
-		if n, err := writeLive(liveBlocks, liveReceipts); err != nil {
+		if n, err := writeLive(liveBlocks, liveReceipts, liveSidecars); err != nil {
 			if err == errInsertionInterrupted {
 				return 0, nil
 			}
@@ -1446,6 +1917,13 @@ func (bc *BlockChain) sendNewBlockEvent(
 var lastWrite uint64
 
 func writeBlockSidecars(batch ethdb.Batch, block *types.Block, sidecars []*types.BlobTxSidecar) {
+	if savedSidecars := blockSidecars(block, sidecars); len(savedSidecars) > 0 {
+		rawdb.WriteBlobSidecars(batch, block.Hash(), block.NumberU64(), savedSidecars)
+	}
+}
+
+// blockSidecars pairs the sidecars of a block with its blob transactions.
+func blockSidecars(block *types.Block, sidecars []*types.BlobTxSidecar) types.BlobSidecars {
 	var (
 		savedSidecars types.BlobSidecars
 		count         int
@@ -1461,9 +1939,36 @@ func writeBlockSidecars(batch ethdb.Batch, block *types.Block, sidecars []*types
 			}
 		}
 	}
-	if len(savedSidecars) > 0 {
-		rawdb.WriteBlobSidecars(batch, block.Hash(), block.NumberU64(), savedSidecars)
+	return savedSidecars
+}
+
+// verifyImportedSidecars verifies the sidecars of a block imported without being
+// executed against its header and blob transactions, returning the verification
+// status to record. Like the consensus engine does, the sidecars of blocks beyond
+// the blob keep period are dropped unverified, peers are not expected to serve
+// them anymore.
+func (bc *BlockChain) verifyImportedSidecars(block *types.Block, sidecars *[]*types.BlobTxSidecar) (byte, error) {
+	var blobGas uint64
+	for _, tx := range block.Transactions() {
+		blobGas += tx.BlobGas()
+	}
+	if used := block.Header().BlobGasUsed; used != nil && *used != blobGas {
+		return rawdb.BlobSidecarsUnverified, fmt.Errorf("blob gas used mismatch: header %d, transactions %d", *used, blobGas)
+	}
+	if blobGas == 0 {
+		if len(*sidecars) > 0 {
+			return rawdb.BlobSidecarsUnverified, fmt.Errorf("unexpected sidecars: have %d, want none", len(*sidecars))
+		}
+		return rawdb.BlobSidecarsUnverified, nil
+	}
+	if time.Unix(int64(block.Time()), 0).Add(params.BlobKeepPeriod).Before(time.Now()) {
+		*sidecars = nil
+		return rawdb.BlobSidecarsExpired, nil
 	}
+	if err := verifyBlockSidecars(block, *sidecars); err != nil {
+		return rawdb.BlobSidecarsUnverified, err
+	}
+	return rawdb.BlobSidecarsVerified, nil
 }
 
 // writeBlockWithoutState writes only the block and its metadata to the database,
@@ -1480,7 +1985,7 @@ func (bc *BlockChain) writeBlockWithoutState(
 	}
 
 	batch := bc.db.NewBatch()
-	rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), td)
+	bc.hc.writeTd(batch, block.Hash(), block.NumberU64(), td)
 	rawdb.WriteBlock(batch, block)
 	writeBlockSidecars(batch, block, sidecars)
 	if err := batch.Write(); err != nil {
@@ -1523,6 +2028,19 @@ func (bc *BlockChain) WriteBlockWithState(
 
 // reorgNeeded determines if the external chain is better than the local chain so reorg is needed
 func (bc *BlockChain) reorgNeeded(localBlock *types.Block, localTd *big.Int, externBlock *types.Block, externTd *big.Int) bool {
+	if !bc.forkChoice(localBlock, localTd, externBlock, externTd) {
+		return false
+	}
+	if bc.reorgProtected(localBlock, externBlock) || bc.reorgTooDeep(localBlock, externBlock) {
+		blockReorgDenyMeter.Mark(1)
+		return false
+	}
+	return true
+}
+
+// forkChoice reports whether the external chain is preferred over the local one
+// according to the justified checkpoints and the total difficulty.
+func (bc *BlockChain) forkChoice(localBlock *types.Block, localTd *big.Int, externBlock *types.Block, externTd *big.Int) bool {
 	if consensusEngine, ok := bc.engine.(consensus.FastFinalityPoSA); ok {
 		localJustifiedBlockNumber, _ := consensusEngine.GetJustifiedBlock(bc, localBlock.NumberU64(), localBlock.Hash())
 		externJustifiedBlockNumber, _ := consensusEngine.GetJustifiedBlock(bc, externBlock.NumberU64(), externBlock.Hash())
@@ -1534,6 +2052,21 @@ func (bc *BlockChain) reorgNeeded(localBlock *types.Block, localTd *big.Int, ext
 		}
 	}
 
+	// After the finality-only fork the total difficulty is not tracked anymore,
+	// so with equal justified blocks the longer chain is preferred.
+	if bc.hc.tdFrozen(localBlock.NumberU64()) || bc.hc.tdFrozen(externBlock.NumberU64()) {
+		if externBlock.Hash() == localBlock.Hash() {
+			return false
+		}
+		if externBlock.NumberU64() != localBlock.NumberU64() {
+			return externBlock.NumberU64() > localBlock.NumberU64()
+		}
+		var localPreserve, externPreserve bool
+		if bc.shouldPreserve != nil {
+			localPreserve, externPreserve = bc.shouldPreserve(localBlock), bc.shouldPreserve(externBlock)
+		}
+		return !localPreserve && (externPreserve || mrand.Float64() < 0.5)
+	}
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
@@ -1555,6 +2088,87 @@ func (bc *BlockChain) reorgNeeded(localBlock *types.Block, localTd *big.Int, ext
 	return reorg
 }
 
+// reorgProtected reports whether switching from the local chain to the external
+// one would retract a locally sealed block that is still inside the configured
+// protection window. Such a reorg is only allowed if the external branch has
+// already finalized a block at or above the retracted one.
+func (bc *BlockChain) reorgProtected(localBlock *types.Block, externBlock *types.Block) bool {
+	window := bc.cacheConfig.ReorgProtectWindow
+	if window == 0 || bc.shouldPreserve == nil {
+		return false
+	}
+	var (
+		cutoff    = uint64(time.Now().Add(-window).Unix())
+		extern    = externBlock.Header()
+		protected *types.Block
+	)
+	// Walk the local chain backwards while its blocks are still inside the
+	// window, stopping as soon as we reach a block shared with the external one.
+	for block := localBlock; block != nil && block.Time() >= cutoff && block.NumberU64() > 0; {
+		for extern != nil && extern.Number.Uint64() > block.NumberU64() {
+			extern = bc.GetHeader(extern.ParentHash, extern.Number.Uint64()-1)
+		}
+		if extern != nil && extern.Hash() == block.Hash() {
+			break
+		}
+		if bc.shouldPreserve(block) {
+			protected = block
+		}
+		block = bc.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	}
+	if protected == nil {
+		return false
+	}
+	if engine, ok := bc.engine.(consensus.FastFinalityPoSA); ok {
+		finalized, _ := engine.GetFinalizedBlock(bc, externBlock.NumberU64(), externBlock.Hash())
+		if finalized >= protected.NumberU64() {
+			return false
+		}
+	}
+	log.Warn("Refusing reorg of recently sealed local block", "number", protected.Number(), "hash", protected.Hash(),
+		"age", common.PrettyAge(time.Unix(int64(protected.Time()), 0)), "window", window,
+		"extern", externBlock.Number(), "externhash", externBlock.Hash())
+	return true
+}
+
+// reorgTooDeep reports whether switching from the local chain to the external
+// one would retract more canonical blocks than the configured limit, posting a
+// DeepReorgEvent if so.
+func (bc *BlockChain) reorgTooDeep(localBlock *types.Block, externBlock *types.Block) bool {
+	limit := bc.cacheConfig.MaxReorgDepth
+	if limit == 0 {
+		return false
+	}
+	var (
+		head   = localBlock.NumberU64()
+		local  = localBlock.Header()
+		extern = externBlock.Header()
+	)
+	for extern != nil && extern.Number.Uint64() > local.Number.Uint64() {
+		extern = bc.GetHeader(extern.ParentHash, extern.Number.Uint64()-1)
+	}
+	// Walk both chains backwards until the common ancestor, stopping as soon as
+	// a retracted block is found below the limit.
+	for local != nil && extern != nil && local.Hash() != extern.Hash() {
+		if depth := head - local.Number.Uint64() + 1; depth > limit {
+			log.Error("Refusing reorg deeper than the limit", "number", localBlock.Number(), "hash", localBlock.Hash(),
+				"depth", depth, "limit", limit, "extern", externBlock.Number(), "externhash", externBlock.Hash())
+			bc.deepReorgFeed.Send(DeepReorgEvent{
+				OldHead: localBlock.Header(),
+				NewHead: externBlock.Header(),
+				Depth:   depth,
+				Limit:   limit,
+			})
+			return true
+		}
+		if local.Number.Uint64() == extern.Number.Uint64() {
+			extern = bc.GetHeader(extern.ParentHash, extern.Number.Uint64()-1)
+		}
+		local = bc.GetHeader(local.ParentHash, local.Number.Uint64()-1)
+	}
+	return false
+}
+
 // pruneBlockSidecars prunes the sidecars of blocks that are older than the keep period
 func (bc *BlockChain) pruneBlockSidecars(db ethdb.KeyValueWriter, curBlock *types.Block) {
 	if bc.cacheConfig.NoPruningSideCar || curBlock.NumberU64() < uint64(bc.blobPrunePeriod) {
@@ -1563,6 +2177,11 @@ func (bc *BlockChain) pruneBlockSidecars(db ethdb.KeyValueWriter, curBlock *type
 	pruneBlockNumber := curBlock.NumberU64() - uint64(bc.blobPrunePeriod)
 	pruneBlockHash := bc.GetCanonicalHash(pruneBlockNumber)
 	rawdb.DeleteBlobSidecars(db, pruneBlockHash, pruneBlockNumber)
+
+	// The sidecars of the frozen blocks are pruned from the blob freezer
+	if err := rawdb.PruneBlobSidecars(bc.db, pruneBlockNumber+1); err != nil {
+		log.Warn("Failed to prune frozen blob sidecars", "number", pruneBlockNumber, "err", err)
+	}
 }
 
 // writeBlockWithState writes the block and all associated state to the database,
@@ -1588,22 +2207,20 @@ func (bc *BlockChain) writeBlockWithState(
 	// Make sure no inconsistent state is leaked during insertion
 	currentBlock := bc.CurrentBlock()
 	localTd := bc.GetTd(currentBlock.Hash(), currentBlock.NumberU64())
-	externTd := new(big.Int).Add(block.Difficulty(), ptd)
+	externTd := bc.hc.nextTd(ptd, block.Header())
 
 	// Irrelevant of the canonical status, write the block itself to the database.
 	//
 	// Note all the components of block(td, hash->number map, header, body, receipts)
-	// should be written atomically. BlockBatch is used for containing all components.
-	blockBatch := bc.db.NewBatch()
-	rawdb.WriteTd(blockBatch, block.Hash(), block.NumberU64(), externTd)
-	rawdb.WriteBlock(blockBatch, block)
-	rawdb.WriteReceipts(blockBatch, block.Hash(), block.NumberU64(), receipts)
-	rawdb.WritePreimages(blockBatch, state.Preimages())
-
-	writeBlockSidecars(blockBatch, block, sidecars)
-	bc.pruneBlockSidecars(blockBatch, block)
-
-	if err := blockBatch.Write(); err != nil {
+	// should be written atomically, the block writer commits them in one batch.
+	writer := rawdb.NewBlockWriteBatch(bc.db)
+	writer.Add(block, receipts, blockSidecars(block, sidecars), nil, false)
+	_, err = writer.Commit(func(batch ethdb.KeyValueWriter) {
+		bc.hc.writeTd(batch, block.Hash(), block.NumberU64(), externTd)
+		rawdb.WritePreimages(batch, state.Preimages())
+		bc.pruneBlockSidecars(batch, block)
+	})
+	if err != nil {
 		log.Crit("Failed to write block into disk", "err", err)
 	}
 	// Commit all cached state changes into underlying memory database.
@@ -1690,6 +2307,10 @@ func (bc *BlockChain) writeBlockWithState(
 	// Set new head.
 	if status == CanonStatTy {
 		bc.writeHeadBlock(block)
+
+		if bc.cacheConfig.RecentStates > 0 && bc.triedb.Scheme() != rawdb.PathScheme && !bc.cacheConfig.TrieDirtyDisabled {
+			bc.retainRecentState(block.NumberU64(), root)
+		}
 	}
 	bc.futureBlocks.Remove(block.Hash())
 
@@ -1707,6 +2328,12 @@ func (bc *BlockChain) writeBlockWithState(
 		if len(logs) > 0 {
 			bc.logsFeed.Send(logs)
 		}
+		bc.sendTxInclusionEvents(block, receipts, false)
+		if bc.txSLA != nil {
+			bc.txSLA.include(block)
+		}
+		bc.sendFeeChangeEvent(block)
+
 		// In theory we should fire a ChainHeadEvent when we inject
 		// a canonical block, but sometimes we can insert a batch of
 		// canonicial blocks. Avoid firing too much ChainHeadEvents,
@@ -1714,6 +2341,7 @@ func (bc *BlockChain) writeBlockWithState(
 		// event here.
 		if emitHeadEvent {
 			bc.chainHeadFeed.Send(ChainHeadEvent{Block: block})
+			bc.sendFinalizedHeadEvent(block)
 		}
 	} else {
 		bc.chainSideFeed.Send(ChainSideEvent{Block: block})
@@ -1721,6 +2349,26 @@ func (bc *BlockChain) writeBlockWithState(
 	return status, nil
 }
 
+// sendFinalizedHeadEvent fires a FinalizedHeadEvent if the fast finality votes
+// included up to the given new head finalized a block above the last announced
+// one. It must be called with the chain mutex held.
+func (bc *BlockChain) sendFinalizedHeadEvent(head *types.Block) {
+	engine, ok := bc.engine.(consensus.FastFinalityPoSA)
+	if !ok {
+		return
+	}
+	number, hash := engine.GetFinalizedBlock(bc, head.NumberU64(), head.Hash())
+	if number == 0 || number <= bc.lastFinalized {
+		return
+	}
+	block := bc.GetBlock(hash, number)
+	if block == nil {
+		return
+	}
+	bc.lastFinalized = number
+	bc.finalizedFeed.Send(FinalizedHeadEvent{Block: block, Head: head.Header()})
+}
+
 // addFutureBlock checks if the block is within the max allowed window to get
 // accepted for future processing, and returns an error if the block is too far
 // ahead and was not added.
@@ -1812,6 +2460,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 	defer func() {
 		if lastCanon != nil && bc.CurrentBlock().Hash() == lastCanon.Hash() {
 			bc.chainHeadFeed.Send(ChainHeadEvent{lastCanon})
+			bc.sendFinalizedHeadEvent(lastCanon)
 		}
 	}()
 	// Start the parallel header verifier
@@ -1842,8 +2491,8 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 			externTd = bc.GetTd(block.ParentHash(), block.NumberU64()-1) // The first block can't be nil
 		)
 		for block != nil && bc.skipBlock(err, it) {
-			externTd = new(big.Int).Add(externTd, block.Difficulty())
-			if localTd.Cmp(externTd) < 0 && bc.reorgNeeded(current, localTd, block, externTd) {
+			externTd = bc.hc.nextTd(externTd, block.Header())
+			if (localTd.Cmp(externTd) < 0 || bc.hc.tdFrozen(block.NumberU64())) && bc.reorgNeeded(current, localTd, block, externTd) {
 				break
 			}
 			log.Debug("Ignoring already known block", "number", block.Number(), "hash", block.Hash())
@@ -1928,7 +2577,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 
 	for ; (block != nil && err == nil) || errors.Is(err, ErrKnownBlock); block, err = it.next() {
 		// err == ErrknownBlock means block != nil
-		externTd = new(big.Int).Add(externTd, block.Difficulty())
+		externTd = bc.hc.nextTd(externTd, block.Header())
 		// If the chain is terminating, stop processing blocks
 		if bc.insertStopped() {
 			log.Debug("Abort during block processing")
@@ -1989,11 +2638,24 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 		if err != nil {
 			return it.index, err
 		}
+		if bc.witnesses != nil || bc.witnessStats != nil {
+			statedb.TrackWitness()
+		}
 
 		// Enable prefetching to pull in trie node paths while processing transactions
 		statedb.StartPrefetcher("chain")
 		activeState = statedb
 
+		// Warm up the storage of the hot contracts, accessed by most blocks
+		if bc.hotStorage != nil {
+			bc.hotStorage.Warm(statedb)
+		}
+		// Warm up the state expected to be touched by the block and the pool
+		var stopPendingWarm func()
+		if bc.accessHistory != nil {
+			stopPendingWarm = bc.warmPendingState(block, statedb)
+		}
+
 		// If we have a followup block, run that against the current state to pre-cache
 		// transactions and probabilistically some of the account/storage trie nodes.
 		var followupInterrupt uint32
@@ -2014,13 +2676,29 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 
 		// Process block using the parent state as reference point
 		substart := time.Now()
-		receipts, logs, internalTxs, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig, bc.OpEvents()...)
+		vmConfig := bc.vmConfig
+		if bc.sstoreStats != nil {
+			vmConfig.SstoreStats = vm.NewSstoreStats()
+		}
+		if bc.traceHashes != nil {
+			vmConfig.TraceHasher = vm.NewTraceHasher()
+		}
+		if stopPendingWarm != nil {
+			stopPendingWarm()
+		}
+		receipts, logs, internalTxs, usedGas, err := bc.processor.Process(block, statedb, vmConfig, bc.blockSenders(block), bc.OpEvents()...)
 		if err != nil {
 			bc.reportBlock(block, receipts, err)
 			atomic.StoreUint32(&followupInterrupt, 1)
 			return it.index, err
 		}
 
+		if bc.hotStorage != nil {
+			bc.hotStorage.Record(statedb, block.NumberU64())
+		}
+		if bc.accessHistory != nil {
+			bc.accessHistory.Record(statedb, block.NumberU64())
+		}
 		// store internal txs to db and send them to internalTxFeed
 		if bc.enableAdditionalChainEvent && len(internalTxs) > 0 {
 			bc.WriteInternalTransactions(block.Hash(), internalTxs)
@@ -2055,6 +2733,28 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 
 		blockValidationTimer.Update(time.Since(substart) - (statedb.AccountHashes + statedb.StorageHashes - triehash))
 
+		if bc.witnessStats != nil {
+			bc.recordWitnessSize(block, statedb)
+		}
+		if bc.sstoreStats != nil {
+			bc.recordSstoreStats(block, vmConfig.SstoreStats)
+		}
+		if bc.traceHashes != nil {
+			bc.recordTraceHashes(block, vmConfig.TraceHasher)
+		}
+		if bc.witnesses != nil {
+			bc.recordWitness(block, parent.Root, statedb)
+		}
+		if bc.cacheConfig.SupplyCheck {
+			bc.checkSupply(block, receipts, statedb)
+		}
+		hooks := bc.loadValidatorHooks()
+		if err := preInsertHooks(hooks, block, receipts, statedb); err != nil {
+			bc.reportBlock(block, receipts, err)
+			atomic.StoreUint32(&followupInterrupt, 1)
+			return it.index, err
+		}
+
 		// Write the block to the chain and get the status.
 		substart = time.Now()
 		var blockSidecars []*types.BlobTxSidecar
@@ -2066,6 +2766,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 		if err != nil {
 			return it.index, err
 		}
+		postInsertHooks(hooks, block, receipts, statedb)
 
 		// Update the metrics touched during block commit
 		accountCommitTimer.Update(statedb.AccountCommits)   // Account commits are complete, we can mark them
@@ -2075,6 +2776,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool, sidecars
 
 		blockWriteTimer.Update(time.Since(substart) - statedb.AccountCommits - statedb.StorageCommits - statedb.SnapshotCommits - statedb.TrieDBCommits)
 		blockInsertTimer.UpdateSince(start)
+		bc.recordBlockDelay(block)
 		blockTxsGauge.Update(int64(len(block.Transactions())))
 		blockGasUsedGauge.Update(int64(block.GasUsed()))
 
@@ -2186,7 +2888,7 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator, si
 		if externTd == nil {
 			externTd = bc.GetTd(block.ParentHash(), block.NumberU64()-1)
 		}
-		externTd = new(big.Int).Add(externTd, block.Difficulty())
+		externTd = bc.hc.nextTd(externTd, block.Header())
 
 		if !bc.HasBlock(block.Hash(), block.NumberU64()) {
 			start := time.Now()
@@ -2210,7 +2912,12 @@ func (bc *BlockChain) insertSideChain(block *types.Block, it *insertIterator, si
 	// If the externTd was larger than our local TD, we now need to reimport the previous
 	// blocks to regenerate the required state
 	localTd := bc.GetTd(current.Hash(), current.NumberU64())
-	if localTd.Cmp(externTd) > 0 {
+	sideLighter := localTd.Cmp(externTd) > 0
+	if last := it.previous(); bc.hc.tdFrozen(last.Number.Uint64()) {
+		// Total difficulty is not tracked anymore, compare the chain lengths
+		sideLighter = last.Number.Uint64() <= current.NumberU64()
+	}
+	if sideLighter {
 		log.Info("Sidechain written to disk", "start", it.first().NumberU64(), "end", it.previous().Number, "sidetd", externTd, "localtd", localTd)
 		return it.index, err
 	}
@@ -2279,6 +2986,7 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	var (
 		newChain    types.Blocks
 		oldChain    types.Blocks
+		oldReceipts []types.Receipts
 		commonBlock *types.Block
 
 		deletedTxs types.Transactions
@@ -2337,7 +3045,8 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		for ; oldBlock != nil && oldBlock.NumberU64() != newBlock.NumberU64(); oldBlock = bc.GetBlock(oldBlock.ParentHash(), oldBlock.NumberU64()-1) {
 			oldChain = append(oldChain, oldBlock)
 			deletedTxs = append(deletedTxs, oldBlock.Transactions()...)
-			collectLogs(oldBlock.Hash(), true)
+			receipts, _ := collectLogs(oldBlock.Hash(), true)
+			oldReceipts = append(oldReceipts, receipts)
 		}
 	} else {
 		// New chain is longer, stash all blocks away for subsequent insertion
@@ -2362,7 +3071,8 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		// Remove an old block as well as stash away a new block
 		oldChain = append(oldChain, oldBlock)
 		deletedTxs = append(deletedTxs, oldBlock.Transactions()...)
-		collectLogs(oldBlock.Hash(), true)
+		receipts, _ := collectLogs(oldBlock.Hash(), true)
+		oldReceipts = append(oldReceipts, receipts)
 
 		newChain = append(newChain, newBlock)
 
@@ -2392,6 +3102,10 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 	} else {
 		log.Error("Impossible reorg, please file an issue", "oldnum", oldBlock.Number(), "oldhash", oldBlock.Hash(), "newnum", newBlock.Number(), "newhash", newBlock.Hash())
 	}
+	// Retract the inclusions of the transactions of the old chain, head first
+	for i, block := range oldChain {
+		bc.sendTxInclusionEvents(block, oldReceipts[i], true)
+	}
 	// Insert the new chain(except the head block(reverse order)),
 	// taking care of the proper incremental order.
 	for i := len(newChain) - 1; i >= 1; i-- {
@@ -2404,15 +3118,35 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		if bc.enableAdditionalChainEvent {
 			bc.sendNewBlockEvent(newChain[i], receipts, true, false)
 		}
+		bc.sendTxInclusionEvents(newChain[i], receipts, false)
 
 		// Collect the new added transactions.
 		addedTxs = append(addedTxs, newChain[i].Transactions()...)
+
+		if bc.txSLA != nil {
+			bc.txSLA.include(newChain[i])
+		}
+		bc.sendFeeChangeEvent(newChain[i])
 	}
 	// Delete useless indexes right now which includes the non-canonical
 	// transaction indexes, canonical chain indexes which above the head.
 	indexesBatch := bc.db.NewBatch()
 	for _, tx := range types.TxDifference(deletedTxs, addedTxs) {
 		rawdb.DeleteTxLookupEntry(indexesBatch, tx.Hash())
+		if bc.cacheConfig.ReceiptIndex {
+			rawdb.DeleteReceiptLookupEntries(indexesBatch, []common.Hash{tx.Hash()})
+		}
+	}
+	// Delete the creation records of the contracts deployed on the old chain,
+	// unless they have been deployed again by the new one
+	if bc.cacheConfig.ContractCreationIndex {
+		for _, block := range oldChain {
+			for addr := range bc.contractCreations(block) {
+				if creation := rawdb.ReadContractCreation(bc.db, addr); creation != nil && creation.BlockHash == block.Hash() {
+					rawdb.DeleteContractCreation(indexesBatch, addr)
+				}
+			}
+		}
 	}
 	// Delete any canonical number assignments above the new head
 	number := bc.CurrentBlock().NumberU64()
@@ -2524,7 +3258,7 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 		if bc.txLookupLimit != 0 && ancients > bc.txLookupLimit {
 			from = ancients - bc.txLookupLimit
 		}
-		rawdb.IndexTransactions(bc.db, from, ancients, bc.quit)
+		rawdb.IndexTransactions(bc.db, max(from, bc.HistoryTail()), ancients, bc.quit)
 	}
 
 	// indexBlocks reindexes or unindexes transactions depending on user configuration
@@ -2543,17 +3277,21 @@ func (bc *BlockChain) maintainTxIndex(ancients uint64) {
 			}
 			return
 		}
-		// If a previous indexing existed, make sure that we fill in any missing entries
+		// If a previous indexing existed, make sure that we fill in any missing entries,
+		// never below the pruned history whose bodies are gone
+		floor := bc.HistoryTail()
 		if bc.txLookupLimit == 0 || head < bc.txLookupLimit {
-			if *tail > 0 {
-				rawdb.IndexTransactions(bc.db, 0, *tail, bc.quit)
+			if *tail > floor {
+				rawdb.IndexTransactions(bc.db, floor, *tail, bc.quit)
 			}
 			return
 		}
 		// Update the transaction index to the new chain state
 		if head-bc.txLookupLimit+1 < *tail {
 			// Reindex a part of missing indices and rewind index tail to HEAD-limit
-			rawdb.IndexTransactions(bc.db, head-bc.txLookupLimit+1, *tail, bc.quit)
+			if from := max(head-bc.txLookupLimit+1, floor); from < *tail {
+				rawdb.IndexTransactions(bc.db, from, *tail, bc.quit)
+			}
 		} else {
 			// Unindex a part of stale indices and forward index tail to HEAD-limit
 			rawdb.UnindexTransactions(bc.db, *tail, head-bc.txLookupLimit+1, bc.quit)
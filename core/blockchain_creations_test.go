@@ -0,0 +1,133 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that contract creations are indexed on canonical insertion and dropped
+// when reorged out, and that the ones rolled back by a failing call frame are
+// not indexed at all.
+func TestContractCreationIndex(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		db     = rawdb.NewMemoryDatabase()
+
+		// PUSH1 0 x5 PUSH2 0x0202 PUSH3 200000 CALL POP STOP: calls the reverting factory
+		caller = common.BigToAddress(big.NewInt(0x201))
+		// PUSH1 0 PUSH1 0 PUSH1 0 CREATE POP PUSH1 0 PUSH1 0 REVERT: creates a child, then reverts
+		reverting = common.BigToAddress(big.NewInt(0x202))
+
+		gspec = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc: GenesisAlloc{
+				addr:      {Balance: big.NewInt(params.Ether)},
+				caller:    {Balance: common.Big0, Code: common.FromHex("0x6000600060006000600061020262030d40f15000")},
+				reverting: {Balance: common.Big0, Code: common.FromHex("0x600060006000f05060006000fd")},
+			},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+		genesis = gspec.MustCommit(db, trie.NewDatabase(db, nil))
+		signer  = types.LatestSigner(gspec.Config)
+
+		// PUSH1 0 PUSH1 0 PUSH1 0 CREATE POP STOP: deploys an empty child contract
+		initcode = common.FromHex("0x600060006000f05000")
+		factory  = crypto.CreateAddress(addr, 0)
+		child    = crypto.CreateAddress(factory, 1)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, b *BlockGen) {
+		if i == 0 {
+			tx, _ := types.SignTx(types.NewContractCreation(0, nil, 100000, b.header.BaseFee, initcode), signer, key)
+			b.AddTx(tx)
+			tx, _ = types.SignTx(types.NewTransaction(1, caller, common.Big0, 300000, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	}, true)
+	forks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	}, true)
+
+	config := *defaultCacheConfig
+	config.ContractCreationIndex = true
+
+	chain, err := NewBlockChain(db, &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	chain.EnableAdditionalChainEvent()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	deployTx := blocks[0].Transactions()[0].Hash()
+	for _, want := range []struct {
+		contract common.Address
+		creator  common.Address
+		code     []byte
+	}{
+		{factory, addr, initcode},
+		{child, factory, nil},
+	} {
+		creation := chain.GetContractCreation(want.contract)
+		if creation == nil {
+			t.Fatalf("contract %x: creation not indexed", want.contract)
+		}
+		if creation.Creator != want.creator {
+			t.Errorf("contract %x: creator mismatch: have %x, want %x", want.contract, creation.Creator, want.creator)
+		}
+		if creation.TxHash != deployTx {
+			t.Errorf("contract %x: transaction mismatch: have %x, want %x", want.contract, creation.TxHash, deployTx)
+		}
+		if creation.BlockHash != blocks[0].Hash() || creation.BlockNumber != 1 {
+			t.Errorf("contract %x: block mismatch: have #%d [%x]", want.contract, creation.BlockNumber, creation.BlockHash)
+		}
+		if creation.InitCodeHash != crypto.Keccak256Hash(want.code) {
+			t.Errorf("contract %x: init code hash mismatch", want.contract)
+		}
+	}
+	if chain.GetContractCreation(addr) != nil {
+		t.Errorf("externally owned account indexed as contract")
+	}
+	if chain.GetContractCreation(crypto.CreateAddress(reverting, 0)) != nil {
+		t.Errorf("creation rolled back by its call frame indexed")
+	}
+	// Reorg onto a longer chain without the deployment
+	if _, err := chain.InsertChain(forks, nil); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	if chain.CurrentBlock().Hash() != forks[2].Hash() {
+		t.Fatalf("chain not reorged onto the fork")
+	}
+	for _, contract := range []common.Address{factory, child} {
+		if rawdb.ReadContractCreation(db, contract) != nil {
+			t.Errorf("contract %x: creation not deleted on reorg", contract)
+		}
+	}
+}
@@ -17,6 +17,7 @@
 package core
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -220,10 +221,42 @@ func (bc *BlockChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
 	if receipts == nil {
 		return nil
 	}
+	bc.upgradeLegacyReceipts(hash, *number)
+	bc.markForcedReceipts(hash, *number, 0, receipts)
 	bc.receiptsCache.Add(hash, receipts)
 	return receipts
 }
 
+// GetReceiptsNoBloomRLP retrieves the receipts of all the transactions in a block
+// in their network encoding without blooms, sparing the receivers the bandwidth
+// of the blooms they can recompute from the logs.
+func (bc *BlockChain) GetReceiptsNoBloomRLP(hash common.Hash) rlp.RawValue {
+	if receipts, ok := bc.receiptsCache.Get(hash); ok {
+		data, err := rlp.EncodeToBytes(receipts.NoBloom())
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+	number := rawdb.ReadHeaderNumber(bc.db, hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadReceiptsNoBloomRLP(bc.db, hash, *number)
+}
+
+// GetCanonicalReceipt retrieves the receipt of a canonical transaction, along
+// with the hash and number of its block and its index therein. Transactions
+// covered by the receipt index are looked up without decoding the receipts of
+// the other transactions of their block.
+func (bc *BlockChain) GetCanonicalReceipt(txHash common.Hash) (*types.Receipt, common.Hash, uint64, uint64) {
+	receipt, hash, number, index := rawdb.ReadCanonicalReceipt(bc.db, txHash, bc.chainConfig)
+	if receipt != nil {
+		bc.markForcedReceipts(hash, number, int(index), types.Receipts{receipt})
+	}
+	return receipt, hash, number, index
+}
+
 // GetBlobSidecarsByNumber retrieves the blobSidecars by a given block number
 // if the blob sidecars are not pruned yet
 func (bc *BlockChain) GetBlobSidecarsByNumber(number uint64) types.BlobSidecars {
@@ -256,6 +289,16 @@ func (bc *BlockChain) GetBlobSidecarsByHash(hash common.Hash) types.BlobSidecars
 	return sidecars
 }
 
+// GetBlobSidecarsStatus retrieves the verification status of the sidecars of
+// the block with the given hash, imported without being executed.
+func (bc *BlockChain) GetBlobSidecarsStatus(hash common.Hash) byte {
+	number := rawdb.ReadHeaderNumber(bc.db, hash)
+	if number == nil {
+		return rawdb.BlobSidecarsUnverified
+	}
+	return rawdb.ReadBlobSidecarsStatus(bc.db, hash, *number)
+}
+
 // GetUnclesInChain retrieves all the uncles from a given block backwards until
 // a specific distance is reached.
 func (bc *BlockChain) GetUnclesInChain(block *types.Block, length int) []*types.Header {
@@ -376,6 +419,26 @@ func (bc *BlockChain) Snapshots() *snapshot.Tree {
 	return bc.snaps
 }
 
+// SnapshotGenerationStatus returns the progress of the background snapshot
+// generation, along with a channel which is closed once it finishes.
+func (bc *BlockChain) SnapshotGenerationStatus() (*snapshot.GenerationStatus, <-chan struct{}, error) {
+	if bc.snaps == nil {
+		return nil, nil, errors.New("snapshots disabled")
+	}
+	return bc.snaps.GenerationStatus()
+}
+
+// VerifySnapshot walks the persistent snapshot layer of the given state root
+// against the trie, reporting the mismatching accounts and storage slots. If
+// repair is set, the mismatching entries are fixed up individually instead of
+// regenerating the whole snapshot.
+func (bc *BlockChain) VerifySnapshot(root common.Hash, repair bool) (*snapshot.RepairResult, error) {
+	if bc.snaps == nil {
+		return nil, errors.New("snapshots disabled")
+	}
+	return bc.snaps.VerifyAndRepair(root, repair)
+}
+
 // Validator returns the current validator.
 func (bc *BlockChain) Validator() Validator {
 	return bc.validator
@@ -463,6 +526,42 @@ func (bc *BlockChain) SubscribeDirtyAccountEvent(ch chan<- []*types.DirtyStateAc
 	return bc.scope.Track(bc.dirtyAccountFeed.Subscribe(ch))
 }
 
+// SubscribeBlockDelayEvent registers a subscription of BlockDelayEvent.
+func (bc *BlockChain) SubscribeBlockDelayEvent(ch chan<- BlockDelayEvent) event.Subscription {
+	return bc.scope.Track(bc.blockDelayFeed.Subscribe(ch))
+}
+
+// SubscribeFinalizedHeadEvent registers a subscription of FinalizedHeadEvent.
+func (bc *BlockChain) SubscribeFinalizedHeadEvent(ch chan<- FinalizedHeadEvent) event.Subscription {
+	return bc.scope.Track(bc.finalizedFeed.Subscribe(ch))
+}
+
+// SubscribeFeeChangeEvent registers a subscription of FeeChangeEvent.
+func (bc *BlockChain) SubscribeFeeChangeEvent(ch chan<- FeeChangeEvent) event.Subscription {
+	return bc.scope.Track(bc.feeChangeFeed.Subscribe(ch))
+}
+
+// SubscribeTxInclusionEvent registers a subscription of []TxInclusionEvent, the
+// transactions of each new canonical block written.
+func (bc *BlockChain) SubscribeTxInclusionEvent(ch chan<- []TxInclusionEvent) event.Subscription {
+	return bc.scope.Track(bc.txInclusionFeed.Subscribe(ch))
+}
+
+// SubscribeDeepReorgEvent registers a subscription of DeepReorgEvent.
+func (bc *BlockChain) SubscribeDeepReorgEvent(ch chan<- DeepReorgEvent) event.Subscription {
+	return bc.scope.Track(bc.deepReorgFeed.Subscribe(ch))
+}
+
+// SubscribeTxSLAEvent registers a subscription of TxSLAEvent.
+func (bc *BlockChain) SubscribeTxSLAEvent(ch chan<- TxSLAEvent) event.Subscription {
+	return bc.scope.Track(bc.txSLAFeed.Subscribe(ch))
+}
+
+// SubscribeSupplyViolationEvent registers a subscription of SupplyViolationEvent.
+func (bc *BlockChain) SubscribeSupplyViolationEvent(ch chan<- SupplyViolationEvent) event.Subscription {
+	return bc.scope.Track(bc.supplyFeed.Subscribe(ch))
+}
+
 func (bc *BlockChain) WriteInternalTransactions(hash common.Hash, internalTxs []*types.InternalTransaction) {
 	// cache first
 	bc.internalTransactionsCache.Add(hash, internalTxs)
@@ -474,6 +573,20 @@ func (bc *BlockChain) WriteInternalTransactions(hash common.Hash, internalTxs []
 	rawdb.WriteInternalTransactions(bc.db, hash, internalTxs)
 }
 
+// GetContractCreation retrieves the creation metadata of a contract deployed on
+// the canonical chain, or nil if it's unknown or the index is disabled.
+func (bc *BlockChain) GetContractCreation(addr common.Address) *rawdb.ContractCreation {
+	creation := rawdb.ReadContractCreation(bc.db, addr)
+	if creation == nil {
+		return nil
+	}
+	// Records of blocks dropped by a rewind are not cleaned up, filter them out
+	if rawdb.ReadCanonicalHash(bc.db, creation.BlockNumber) != creation.BlockHash {
+		return nil
+	}
+	return creation
+}
+
 func (bc *BlockChain) ReadInternalTransactions(hash common.Hash) []*types.InternalTransaction {
 	// get internal txs from cache
 	if internalTxs, exist := bc.internalTransactionsCache.Get(hash); exist {
@@ -0,0 +1,169 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the receipt index is maintained on canonical insertion, resolves
+// the same receipts as the block receipt lists and is dropped by reorgs and
+// rewinds.
+func TestReceiptIndex(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}}, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(db, trie.NewDatabase(db, nil))
+		signer  = types.LatestSigner(gspec.Config)
+
+		// PUSH1 0 PUSH1 0 LOG0 STOP: emits an empty log
+		logcode = common.FromHex("0x60006000a000")
+		// PUSH1 0 PUSH1 0 REVERT: fails
+		revertcode = common.FromHex("0x60006000fd")
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		for _, code := range [][]byte{logcode, revertcode, logcode} {
+			tx, _ := types.SignTx(types.NewContractCreation(b.TxNonce(addr), nil, 100000, b.header.BaseFee, code), signer, key)
+			b.AddTx(tx)
+		}
+	}, true)
+	forks, _ := GenerateChain(gspec.Config, blocks[0], ethash.NewFaker(), db, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	}, true)
+
+	config := *defaultCacheConfig
+	config.ReceiptIndex = true
+
+	chain, err := NewBlockChain(db, &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for _, block := range blocks {
+		receipts := chain.GetReceiptsByHash(block.Hash())
+		for i, tx := range block.Transactions() {
+			entry := rawdb.ReadReceiptLookupEntry(db, tx.Hash())
+			if entry == nil || entry.BlockNumber != block.NumberU64() || entry.Index != uint64(i) || entry.Success != (i != 1) {
+				t.Fatalf("block %d tx %d: lookup entry mismatch: have %+v", block.NumberU64(), i, entry)
+			}
+			receipt, hash, number, index := chain.GetCanonicalReceipt(tx.Hash())
+			if hash != block.Hash() || number != block.NumberU64() || index != uint64(i) {
+				t.Fatalf("block %d tx %d: position mismatch: have #%d [%x] %d", block.NumberU64(), i, number, hash, index)
+			}
+			if !reflect.DeepEqual(receipt, receipts[i]) {
+				t.Errorf("block %d tx %d: receipt mismatch: have %+v, want %+v", block.NumberU64(), i, receipt, receipts[i])
+			}
+		}
+	}
+	// Reorg onto a longer chain dropping the last two blocks
+	if _, err := chain.InsertChain(forks, nil); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	for _, block := range blocks[1:] {
+		for i, tx := range block.Transactions() {
+			if entry := rawdb.ReadReceiptLookupEntry(db, tx.Hash()); entry != nil {
+				t.Errorf("block %d tx %d: lookup entry not deleted on reorg", block.NumberU64(), i)
+			}
+		}
+	}
+	// Rewind below the first block
+	if err := chain.SetHead(0); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	for i, tx := range blocks[0].Transactions() {
+		if entry := rawdb.ReadReceiptLookupEntry(db, tx.Hash()); entry != nil {
+			t.Errorf("tx %d: lookup entry not deleted on rewind", i)
+		}
+	}
+}
+
+// Tests that the receipt index is backfilled for the blocks preceding its
+// enabling, and that its lookups are removed along with the transaction ones.
+func TestReceiptIndexBackfill(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}}, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(db, trie.NewDatabase(db, nil))
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 8, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	}, true)
+
+	chain, err := NewBlockChain(db, defaultCacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	// Enable the index, the blocks inserted before are backfilled on startup
+	config := *defaultCacheConfig
+	config.ReceiptIndex = true
+
+	chain, err = NewBlockChain(db, &config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		if tail := rawdb.ReadReceiptIndexTail(db); tail != nil && *tail == 0 {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("receipt index not backfilled")
+		}
+	}
+	chain.Stop()
+
+	for _, block := range blocks {
+		tx := block.Transactions()[0]
+		if entry := rawdb.ReadReceiptLookupEntry(db, tx.Hash()); entry == nil || entry.BlockNumber != block.NumberU64() {
+			t.Fatalf("block %d: lookup entry mismatch: have %+v", block.NumberU64(), entry)
+		}
+	}
+	// Unindexing the transactions drops their receipt lookups too
+	rawdb.UnindexTransactions(db, 0, 5, nil)
+	for _, block := range blocks {
+		entry := rawdb.ReadReceiptLookupEntry(db, block.Transactions()[0].Hash())
+		if have, want := entry == nil, block.NumberU64() < 5; have != want {
+			t.Errorf("block %d: lookup entry removal mismatch: have %v, want %v", block.NumberU64(), have, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// ReplayRange re-executes the canonical blocks from..to (inclusive) with the
+// given EVM configuration, e.g. an injected tracer, the same way they were
+// processed on import, and streams the outcome of each block to the callback.
+// Nothing is written to the database.
+//
+// The state of the parent of the first block must be available. The following
+// blocks are executed on top of each other in memory, switching over to their
+// archived state whenever it's available to release the memory. The state is
+// passed to the callback after the block is validated against its header, it
+// must not be modified nor retained past the callback.
+func (bc *BlockChain) ReplayRange(from, to uint64, cfg vm.Config, cb func(block *types.Block, receipts types.Receipts, statedb *state.StateDB)) error {
+	if from == 0 {
+		return fmt.Errorf("genesis block cannot be replayed")
+	}
+	if from > to {
+		return fmt.Errorf("invalid replay range: #%d > #%d", from, to)
+	}
+	parent := bc.GetHeaderByNumber(from - 1)
+	if parent == nil {
+		return fmt.Errorf("block #%d not found", from-1)
+	}
+	if !bc.HasState(parent.Root) {
+		return fmt.Errorf("%w: block #%d", ErrStateUnavailable, from-1)
+	}
+	statedb, err := state.New(parent.Root, bc.stateCache, bc.snaps)
+	if err != nil {
+		return err
+	}
+	for number := from; number <= to; number++ {
+		if bc.insertStopped() {
+			return errChainStopped
+		}
+		block := bc.GetBlockByNumber(number)
+		if block == nil {
+			return fmt.Errorf("block #%d not found", number)
+		}
+		if block.ParentHash() != parent.Hash() {
+			return fmt.Errorf("block #%d [%x..] reorged during replay", number, block.Hash().Bytes()[:4])
+		}
+		receipts, _, _, usedGas, err := bc.processor.Process(block, statedb, cfg, nil, bc.OpEvents()...)
+		if err != nil {
+			return fmt.Errorf("failed to replay block #%d [%x..]: %w", number, block.Hash().Bytes()[:4], err)
+		}
+		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+			return fmt.Errorf("replay of block #%d [%x..] diverged: %w", number, block.Hash().Bytes()[:4], err)
+		}
+		cb(block, receipts, statedb)
+
+		// Continue from the archived state if available, dropping the in-memory
+		// changes accumulated so far
+		if number < to && bc.HasState(block.Root()) {
+			if statedb, err = state.New(block.Root(), bc.stateCache, bc.snaps); err != nil {
+				return err
+			}
+		}
+		parent = block.Header()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that replaying a range of canonical blocks re-executes them with the
+// injected configuration, reproducing the receipts of their import.
+func TestReplayRange(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(params.TestChainConfig)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 6, func(i int, b *BlockGen) {
+		for j := 0; j < i; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.TrieDirtyDisabled = true
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	var (
+		txs      int
+		replayed []uint64
+		cfg      = vm.Config{Tracer: &hooks.Hooks{OnTxStart: func(*hooks.VMContext, uint64, *common.Address) { txs++ }}}
+	)
+	err = chain.ReplayRange(2, 5, cfg, func(block *types.Block, receipts types.Receipts, statedb *state.StateDB) {
+		replayed = append(replayed, block.NumberU64())
+
+		want := chain.GetReceiptsByHash(block.Hash())
+		if len(receipts) != len(want) {
+			t.Fatalf("block #%d: receipt count mismatch: have %d, want %d", block.NumberU64(), len(receipts), len(want))
+		}
+		for i := range receipts {
+			if receipts[i].TxHash != want[i].TxHash || receipts[i].CumulativeGasUsed != want[i].CumulativeGasUsed {
+				t.Errorf("block #%d: receipt %d mismatch", block.NumberU64(), i)
+			}
+		}
+		if nonce, want := statedb.GetNonce(addr), block.NumberU64()*(block.NumberU64()-1)/2; nonce != want {
+			t.Errorf("block #%d: nonce mismatch: have %d, want %d", block.NumberU64(), nonce, want)
+		}
+	})
+	if err != nil {
+		t.Fatalf("failed to replay range: %v", err)
+	}
+	if len(replayed) != 4 || replayed[0] != 2 || replayed[3] != 5 {
+		t.Fatalf("replayed blocks mismatch: have %v", replayed)
+	}
+	// Block #n includes n-1 transactions
+	if want := 1 + 2 + 3 + 4; txs != want {
+		t.Fatalf("traced transactions mismatch: have %d, want %d", txs, want)
+	}
+	// Ranges beyond the chain or without a parent state are rejected
+	if err := chain.ReplayRange(5, 7, vm.Config{}, func(*types.Block, types.Receipts, *state.StateDB) {}); err == nil {
+		t.Fatalf("replay beyond the head succeeded")
+	}
+	if err := chain.ReplayRange(0, 1, vm.Config{}, func(*types.Block, types.Receipts, *state.StateDB) {}); err == nil {
+		t.Fatalf("replay of the genesis succeeded")
+	}
+	if err := chain.ReplayRange(3, 2, vm.Config{}, func(*types.Block, types.Receipts, *state.StateDB) {}); err == nil {
+		t.Fatalf("replay of an inverted range succeeded")
+	}
+}
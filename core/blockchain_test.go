@@ -17,6 +17,7 @@
 package core
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -38,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -166,7 +168,7 @@ func testBlockChainImport(chain types.Blocks, blockchain *BlockChain) error {
 		if err != nil {
 			return err
 		}
-		receipts, _, _, usedGas, err := blockchain.processor.Process(block, statedb, vm.Config{})
+		receipts, _, _, usedGas, err := blockchain.processor.Process(block, statedb, vm.Config{}, nil)
 		if err != nil {
 			blockchain.reportBlock(block, receipts, err)
 			return err
@@ -734,7 +736,7 @@ func testFastVsFullChains(t *testing.T, scheme string) {
 	if n, err := fast.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
 	}
-	if n, err := fast.InsertReceiptChain(blocks, receipts, 0); err != nil {
+	if n, err := fast.InsertReceiptChain(blocks, receipts, nil, 0); err != nil {
 		t.Fatalf("failed to insert receipt %d: %v", n, err)
 	}
 	// Freezer style fast import the chain.
@@ -755,7 +757,7 @@ func testFastVsFullChains(t *testing.T, scheme string) {
 	if n, err := ancient.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
 	}
-	if n, err := ancient.InsertReceiptChain(blocks, receipts, uint64(len(blocks)/2)); err != nil {
+	if n, err := ancient.InsertReceiptChain(blocks, receipts, nil, uint64(len(blocks)/2)); err != nil {
 		t.Fatalf("failed to insert receipt %d: %v", n, err)
 	}
 
@@ -902,7 +904,7 @@ func testLightVsFastVsFullChainHeads(t *testing.T, scheme string) {
 	if n, err := fast.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
 	}
-	if n, err := fast.InsertReceiptChain(blocks, receipts, 0); err != nil {
+	if n, err := fast.InsertReceiptChain(blocks, receipts, nil, 0); err != nil {
 		t.Fatalf("failed to insert receipt %d: %v", n, err)
 	}
 	assert(t, "fast", fast, height, height, 0)
@@ -918,7 +920,7 @@ func testLightVsFastVsFullChainHeads(t *testing.T, scheme string) {
 	if n, err := ancient.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
 	}
-	if n, err := ancient.InsertReceiptChain(blocks, receipts, uint64(3*len(blocks)/4)); err != nil {
+	if n, err := ancient.InsertReceiptChain(blocks, receipts, nil, uint64(3*len(blocks)/4)); err != nil {
 		t.Fatalf("failed to insert receipt %d: %v", n, err)
 	}
 	assert(t, "ancient", ancient, height, height, 0)
@@ -1802,7 +1804,7 @@ func testBlockchainRecovery(t *testing.T, scheme string) {
 	if n, err := ancient.InsertHeaderChain(headers, 1); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
 	}
-	if n, err := ancient.InsertReceiptChain(blocks, receipts, uint64(3*len(blocks)/4)); err != nil {
+	if n, err := ancient.InsertReceiptChain(blocks, receipts, nil, uint64(3*len(blocks)/4)); err != nil {
 		t.Fatalf("failed to insert receipt %d: %v", n, err)
 	}
 	rawdb.WriteLastPivotNumber(ancientDb, blocks[len(blocks)-1].NumberU64()) // Force fast sync behavior
@@ -1883,7 +1885,7 @@ func testInsertReceiptChainRollback(t *testing.T, scheme string) {
 	}
 
 	// Try to insert blocks/receipts of the side chain.
-	_, err = ancientChain.InsertReceiptChain(sideblocks, sidechainReceipts, uint64(len(sideblocks)))
+	_, err = ancientChain.InsertReceiptChain(sideblocks, sidechainReceipts, nil, uint64(len(sideblocks)))
 	if err == nil {
 		t.Fatal("expected error from InsertReceiptChain.")
 	}
@@ -1895,7 +1897,7 @@ func testInsertReceiptChainRollback(t *testing.T, scheme string) {
 	}
 
 	// Insert blocks/receipts of the canonical chain.
-	_, err = ancientChain.InsertReceiptChain(canonblocks, canonReceipts, uint64(len(canonblocks)))
+	_, err = ancientChain.InsertReceiptChain(canonblocks, canonReceipts, nil, uint64(len(canonblocks)))
 	if err != nil {
 		t.Fatalf("can't import canon chain receipts: %v", err)
 	}
@@ -2127,7 +2129,7 @@ func testInsertKnownChainData(t *testing.T, typ string, scheme string) {
 			if err != nil {
 				return err
 			}
-			_, err = chain.InsertReceiptChain(blocks, receipts, 0)
+			_, err = chain.InsertReceiptChain(blocks, receipts, nil, 0)
 			return err
 		}
 		asserter = func(t *testing.T, block *types.Block) {
@@ -2419,7 +2421,7 @@ func TestTransactionIndices(t *testing.T) {
 	if n, err := chain.InsertHeaderChain(headers, 0); err != nil {
 		t.Fatalf("failed to insert header %d: %v", n, err)
 	}
-	if n, err := chain.InsertReceiptChain(blocks, receipts, 128); err != nil {
+	if n, err := chain.InsertReceiptChain(blocks, receipts, nil, 128); err != nil {
 		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
 	}
 	chain.Stop()
@@ -2554,7 +2556,7 @@ func testSkipStaleTxIndicesInFastSync(t *testing.T, scheme string) {
 		t.Fatalf("failed to insert header %d: %v", n, err)
 	}
 	// The indices before ancient-N(32) should be ignored. After that all blocks should be indexed.
-	if n, err := chain.InsertReceiptChain(blocks, receipts, 64); err != nil {
+	if n, err := chain.InsertReceiptChain(blocks, receipts, nil, 64); err != nil {
 		t.Fatalf("block %d: failed to insert into chain: %v", n, err)
 	}
 	tail := uint64(32)
@@ -2921,7 +2923,7 @@ func testDeleteRecreateSlots(t *testing.T, scheme string) {
 	diskdb := rawdb.NewMemoryDatabase()
 	chain, err := NewBlockChain(diskdb, DefaultCacheConfigWithScheme(scheme), gspec, nil, engine, vm.Config{
 		Debug:  true,
-		Tracer: logger.NewJSONLogger(nil, os.Stdout),
+		Tracer: logger.NewJSONLogger(nil, os.Stdout).Hooks(),
 	}, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create tester chain: %v", err)
@@ -3008,7 +3010,7 @@ func testDeleteRecreateAccount(t *testing.T, scheme string) {
 	gspec.MustCommit(diskdb, trie.NewDatabase(diskdb, nil))
 	chain, err := NewBlockChain(diskdb, DefaultCacheConfigWithScheme(scheme), gspec, nil, engine, vm.Config{
 		Debug:  true,
-		Tracer: logger.NewJSONLogger(nil, os.Stdout),
+		Tracer: logger.NewJSONLogger(nil, os.Stdout).Hooks(),
 	}, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create tester chain: %v", err)
@@ -4111,7 +4113,7 @@ func testEIP3651(t *testing.T, scheme string) {
 
 		b.AddTx(tx)
 	})
-	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(scheme), gspec, nil, engine, vm.Config{Tracer: logger.NewMarkdownLogger(&logger.Config{}, os.Stderr)}, nil, nil)
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(scheme), gspec, nil, engine, vm.Config{Tracer: logger.NewMarkdownLogger(&logger.Config{}, os.Stderr).Hooks()}, nil, nil)
 	if err != nil {
 		t.Fatalf("failed to create tester chain: %v", err)
 	}
@@ -4736,3 +4738,318 @@ func TestDeleteThenCreate(t *testing.T) {
 		}
 	}
 }
+
+// Tests that reorgs retracting recently sealed local blocks are refused while
+// the protection window is active, and allowed once the window has passed.
+func TestReorgProtectWindow(t *testing.T) {
+	testReorgProtectWindow(t, time.Hour, false)
+	testReorgProtectWindow(t, time.Second, true)
+}
+
+func testReorgProtectWindow(t *testing.T, window time.Duration, wantReorg bool) {
+	var (
+		local  = common.Address{0x01}
+		remote = common.Address{0x02}
+		db     = rawdb.NewMemoryDatabase()
+		gspec  = &Genesis{
+			Config:    params.TestChainConfig,
+			BaseFee:   big.NewInt(params.InitialBaseFee),
+			Timestamp: uint64(time.Now().Unix()) - 120,
+		}
+		genesis = gspec.MustCommit(db, trie.NewDatabase(db, nil))
+	)
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.ReorgProtectWindow = window
+
+	shouldPreserve := func(block *types.Block) bool { return block.Coinbase() == local }
+	chain, err := NewBlockChain(db, cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, shouldPreserve, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	sealed, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, b *BlockGen) { b.SetCoinbase(local) }, true)
+	if _, err := chain.InsertChain(sealed, nil); err != nil {
+		t.Fatalf("failed to insert local chain: %v", err)
+	}
+	competing, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, func(i int, b *BlockGen) { b.SetCoinbase(remote) }, true)
+	if _, err := chain.InsertChain(competing, nil); err != nil {
+		t.Fatalf("failed to insert competing chain: %v", err)
+	}
+	head := chain.CurrentBlock().Hash()
+	if wantReorg && head != competing[len(competing)-1].Hash() {
+		t.Errorf("head mismatch: have %x, want competing head %x", head, competing[len(competing)-1].Hash())
+	}
+	if !wantReorg && head != sealed[len(sealed)-1].Hash() {
+		t.Errorf("head mismatch: have %x, want local head %x", head, sealed[len(sealed)-1].Hash())
+	}
+}
+
+func TestMaxReorgDepth(t *testing.T) {
+	testMaxReorgDepth(t, 2, false)
+	testMaxReorgDepth(t, 3, true)
+}
+
+func testMaxReorgDepth(t *testing.T, limit uint64, wantReorg bool) {
+	var (
+		gspec = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		db    = rawdb.NewMemoryDatabase()
+	)
+	genesis := gspec.MustCommit(db, trie.NewDatabase(db, nil))
+
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.MaxReorgDepth = limit
+	chain, err := NewBlockChain(db, cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan DeepReorgEvent, 16)
+	sub := chain.SubscribeDeepReorgEvent(events)
+	defer sub.Unsubscribe()
+
+	canonical, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{0x01}) }, true)
+	if _, err := chain.InsertChain(canonical, nil); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	// Fork off block 2, retracting the blocks 3 to 5
+	competing, _ := GenerateChain(gspec.Config, canonical[1], ethash.NewFaker(), db, 6, func(i int, b *BlockGen) { b.SetCoinbase(common.Address{0x02}) }, true)
+	if _, err := chain.InsertChain(competing, nil); err != nil {
+		t.Fatalf("failed to insert competing chain: %v", err)
+	}
+	head := chain.CurrentBlock().Hash()
+	if wantReorg {
+		if head != competing[len(competing)-1].Hash() {
+			t.Errorf("head mismatch: have %x, want competing head %x", head, competing[len(competing)-1].Hash())
+		}
+		select {
+		case ev := <-events:
+			t.Errorf("unexpected deep reorg event: %+v", ev)
+		default:
+		}
+		return
+	}
+	if head != canonical[len(canonical)-1].Hash() {
+		t.Errorf("head mismatch: have %x, want canonical head %x", head, canonical[len(canonical)-1].Hash())
+	}
+	select {
+	case ev := <-events:
+		if ev.OldHead.Hash() != canonical[len(canonical)-1].Hash() {
+			t.Errorf("event old head mismatch: have %x, want %x", ev.OldHead.Hash(), canonical[len(canonical)-1].Hash())
+		}
+		if ev.Depth != limit+1 || ev.Limit != limit {
+			t.Errorf("event depth mismatch: have %d/%d, want %d/%d", ev.Depth, ev.Limit, limit+1, limit)
+		}
+	default:
+		t.Error("no deep reorg event posted")
+	}
+}
+
+// Tests that total difficulties are neither accumulated nor stored after the
+// finality-only fork, and that the fork choice switches to the chain length.
+func TestFinalityOnlyFrozenTd(t *testing.T) {
+	var (
+		config = *params.TestChainConfig
+		db     = rawdb.NewMemoryDatabase()
+	)
+	config.FinalityOnlyBlock = big.NewInt(3)
+	gspec := &Genesis{Config: &config, BaseFee: big.NewInt(params.InitialBaseFee)}
+	genesis := gspec.MustCommit(db, trie.NewDatabase(db, nil))
+
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	blocks, _ := GenerateChain(&config, genesis, ethash.NewFaker(), db, 6, func(i int, b *BlockGen) {}, true)
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if freeze := rawdb.ReadTdFreezeBlock(db); freeze == nil || *freeze != 3 {
+		t.Fatalf("td freeze marker mismatch: have %v, want 3", freeze)
+	}
+	frozen := chain.GetTd(blocks[1].Hash(), 2)
+	for _, block := range blocks[2:] {
+		if td := rawdb.ReadTd(db, block.Hash(), block.NumberU64()); td != nil {
+			t.Errorf("block %d: td stored after the fork: %v", block.NumberU64(), td)
+		}
+		if td := chain.GetTd(block.Hash(), block.NumberU64()); td == nil || td.Cmp(frozen) != 0 {
+			t.Errorf("block %d: td mismatch: have %v, want %v", block.NumberU64(), td, frozen)
+		}
+	}
+	// A shorter fork with a higher difficulty must not be adopted anymore
+	heavy, _ := GenerateChain(&config, blocks[2], ethash.NewFaker(), db, 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+		b.OffsetTime(-9)
+	}, true)
+	if _, err := chain.InsertChain(heavy, nil); err != nil {
+		t.Fatalf("failed to insert heavy fork: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("shorter fork adopted: have %x, want %x", head, blocks[len(blocks)-1].Hash())
+	}
+	// A longer fork must be adopted regardless of its difficulty
+	long, _ := GenerateChain(&config, blocks[2], ethash.NewFaker(), db, 5, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+	}, true)
+	if _, err := chain.InsertChain(long, nil); err != nil {
+		t.Fatalf("failed to insert long fork: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != long[len(long)-1].Hash() {
+		t.Fatalf("longer fork not adopted: have %x, want %x", head, long[len(long)-1].Hash())
+	}
+}
+
+// Tests that the sidecars of the blocks imported along with their receipts are
+// verified against the blob transactions before being written, and that their
+// verification status is recorded.
+func TestInsertReceiptChainWithSidecars(t *testing.T) {
+	testInsertReceiptChainWithSidecars(t, uint64(time.Now().Add(-time.Hour).Unix()), rawdb.BlobSidecarsVerified)
+	testInsertReceiptChainWithSidecars(t, 0, rawdb.BlobSidecarsExpired)
+}
+
+func testInsertReceiptChainWithSidecars(t *testing.T, timestamp uint64, status byte) {
+	var (
+		key, _  = crypto.GenerateKey()
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config:    params.TestChainConfig,
+			Timestamp: timestamp,
+			Alloc:     GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		signer = types.NewCancunSigner(gspec.Config.ChainID)
+
+		blob, commitment, proof = randBlob()
+		blobHash                = kzg4844.CalcBlobHashV1(sha256.New(), commitment)
+		sidecar                 = &types.BlobTxSidecar{
+			Blobs:       []kzg4844.Blob{*blob},
+			Commitments: []kzg4844.Commitment{*commitment},
+			Proofs:      []kzg4844.Proof{*proof},
+		}
+	)
+	// Generate a chain with blob transactions in the first and third blocks
+	_, blocks, receipts := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, bg *BlockGen) {
+		if i%2 == 0 {
+			tx, err := types.SignNewTx(key, signer, &types.BlobTx{
+				ChainID:    uint256.MustFromBig(gspec.Config.ChainID),
+				Nonce:      bg.TxNonce(address),
+				GasTipCap:  uint256.NewInt(0),
+				GasFeeCap:  uint256.NewInt(0),
+				Gas:        21000,
+				To:         address,
+				BlobFeeCap: uint256.NewInt(1),
+				BlobHashes: []common.Hash{blobHash},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			bg.AddTx(tx)
+		}
+	})
+	sidecars := [][]*types.BlobTxSidecar{{sidecar}, nil, {sidecar}, nil}
+
+	// Strip the receipts down to their consensus fields, as delivered by peers
+	for i := range receipts {
+		for j, receipt := range receipts[i] {
+			enc, err := receipt.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			receipts[i][j] = new(types.Receipt)
+			if err := receipts[i][j].UnmarshalBinary(enc); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ancientDb, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create temp freezer db: %v", err)
+	}
+	defer ancientDb.Close()
+
+	chain, err := NewBlockChain(ancientDb, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer chain.Stop()
+
+	headers := make([]*types.Header, len(blocks))
+	for i, block := range blocks {
+		headers[i] = block.Header()
+	}
+	if n, err := chain.InsertHeaderChain(headers, 1); err != nil {
+		t.Fatalf("failed to insert header %d: %v", n, err)
+	}
+	// Import the chain with a sidecar not matching its blob transaction
+	if status == rawdb.BlobSidecarsVerified {
+		other, _, _ := randBlob()
+		invalid := [][]*types.BlobTxSidecar{{sidecar}, nil, {{
+			Blobs:       []kzg4844.Blob{*other},
+			Commitments: []kzg4844.Commitment{*commitment},
+			Proofs:      []kzg4844.Proof{*proof},
+		}}, nil}
+		if n, err := chain.InsertReceiptChain(blocks, receipts, invalid, 2); err == nil || n != 2 {
+			t.Fatalf("invalid sidecars accepted: index %d, err %v", n, err)
+		}
+		if n, err := chain.InsertReceiptChain(blocks, receipts, [][]*types.BlobTxSidecar{nil, nil, {sidecar}, nil}, 2); err == nil || n != 0 {
+			t.Fatalf("missing sidecars accepted: index %d, err %v", n, err)
+		}
+	}
+	// Import the chain with the valid sidecars, across the ancient and live stores
+	if n, err := chain.InsertReceiptChain(blocks, receipts, sidecars, 2); err != nil {
+		t.Fatalf("failed to insert receipt %d: %v", n, err)
+	}
+	for i, block := range blocks {
+		want := rawdb.BlobSidecarsUnverified
+		if len(sidecars[i]) > 0 {
+			want = status
+		}
+		if have := chain.GetBlobSidecarsStatus(block.Hash()); have != want {
+			t.Errorf("block %d: status mismatch: have %d, want %d", block.NumberU64(), have, want)
+		}
+		stored := chain.GetBlobSidecarsByHash(block.Hash())
+		if want == rawdb.BlobSidecarsVerified {
+			if len(stored) != 1 || stored[0].TxHash != block.Transactions()[0].Hash() {
+				t.Errorf("block %d: verified sidecars not stored", block.NumberU64())
+			}
+		} else if len(stored) != 0 {
+			t.Errorf("block %d: unexpected sidecars stored: %d", block.NumberU64(), len(stored))
+		}
+		// The blob gas accounting is derived for the imported and the stored receipts
+		for _, have := range []types.Receipts{receipts[i], chain.GetReceiptsByHash(block.Hash())} {
+			if len(have) != len(block.Transactions()) {
+				t.Fatalf("block %d: receipts mismatch: have %d, want %d", block.NumberU64(), len(have), len(block.Transactions()))
+			}
+			for j, receipt := range have {
+				if receipt.TxHash != block.Transactions()[j].Hash() {
+					t.Errorf("block %d receipt %d: tx hash mismatch: have %x, want %x", block.NumberU64(), j, receipt.TxHash, block.Transactions()[j].Hash())
+				}
+				if receipt.BlobGasUsed != params.BlobTxBlobGasPerBlob {
+					t.Errorf("block %d receipt %d: blob gas used mismatch: have %d, want %d", block.NumberU64(), j, receipt.BlobGasUsed, params.BlobTxBlobGasPerBlob)
+				}
+				if want := eip4844.CalcBlobFee(*block.ExcessBlobGas()); receipt.BlobGasPrice == nil || receipt.BlobGasPrice.Cmp(want) != 0 {
+					t.Errorf("block %d receipt %d: blob gas price mismatch: have %v, want %v", block.NumberU64(), j, receipt.BlobGasPrice, want)
+				}
+			}
+		}
+	}
+	// Freeze the live blocks, the statuses must outlive their sidecars
+	rawdb.WriteHeadBlockHash(ancientDb, blocks[len(blocks)-1].Hash())
+	if err := ancientDb.(interface{ Freeze(uint64) error }).Freeze(0); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	if frozen, _ := ancientDb.Ancients(); frozen != uint64(len(blocks))+1 {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, len(blocks)+1)
+	}
+	for i, block := range blocks {
+		want := rawdb.BlobSidecarsUnverified
+		if len(sidecars[i]) > 0 {
+			want = status
+		}
+		if have := chain.GetBlobSidecarsStatus(block.Hash()); have != want {
+			t.Errorf("block %d: frozen status mismatch: have %d, want %d", block.NumberU64(), have, want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// ErrInvalidSegmentAncestor is reported for the blocks of a verified segment
+// following the first block which failed verification.
+var ErrInvalidSegmentAncestor = errors.New("ancestor in segment failed verification")
+
+// SegmentBlockResult is the outcome of verifying a single block of a segment.
+type SegmentBlockResult struct {
+	Number   uint64
+	Hash     common.Hash
+	Known    bool           // Whether the block is already part of the local chain
+	GasUsed  uint64         // Gas used by executing the block locally
+	Receipts types.Receipts // Receipts produced by executing the block locally
+	Elapsed  time.Duration  // Time spent on verifying and executing the block
+	Err      error          // Reason of the verification failure, nil if valid
+}
+
+// VerifyChainSegment fully validates the given contiguous batch of blocks, the
+// same way InsertChain does, but without committing anything: neither blocks,
+// receipts, state nor sidecars are written and the head is left untouched. The
+// blocks are executed on top of each other, starting at the state of the parent
+// of the first block, their states being committed into a throwaway trie
+// database and a snapshot speculation dropped once done.
+//
+// A result is returned for every block. Once a block fails verification, all
+// the following ones are reported with ErrInvalidSegmentAncestor. The returned
+// error is only set if the segment as a whole cannot be verified, e.g. if it's
+// not contiguous or if the state of its parent is not available.
+//
+// The sidecars are assumed to have 0-length or same length as batch of blocks.
+func (bc *BlockChain) VerifyChainSegment(chain types.Blocks, sidecars [][]*types.BlobTxSidecar) ([]*SegmentBlockResult, error) {
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	if len(sidecars) > 0 && len(sidecars) != len(chain) {
+		return nil, fmt.Errorf("sidecar count mismatch: have %d, want %d", len(sidecars), len(chain))
+	}
+	for i := 1; i < len(chain); i++ {
+		block, prev := chain[i], chain[i-1]
+		if block.NumberU64() != prev.NumberU64()+1 || block.ParentHash() != prev.Hash() {
+			return nil, fmt.Errorf("non contiguous segment: item %d is #%d [%x..], item %d is #%d [%x..] (parent [%x..])", i-1, prev.NumberU64(),
+				prev.Hash().Bytes()[:4], i, block.NumberU64(), block.Hash().Bytes()[:4], block.ParentHash().Bytes()[:4])
+		}
+	}
+	if bc.insertStopped() {
+		return nil, errChainStopped
+	}
+	parent := bc.GetHeader(chain[0].ParentHash(), chain[0].NumberU64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	// Read through the snapshot of the parent state if available, it's fine to
+	// fall back to the tries otherwise
+	var spec *snapshot.Speculation
+	if bc.snaps != nil {
+		if speculation, err := bc.snaps.Speculate(parent.Root); err == nil {
+			spec = speculation
+			defer spec.Discard()
+		}
+	}
+	triedb := bc.triedb.Throwaway()
+	defer triedb.Close()
+
+	statedb, err := state.NewSpeculative(parent.Root, state.NewDatabaseWithNodeDB(bc.db, triedb), spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", consensus.ErrPrunedAncestor, err)
+	}
+	SenderCacher.RecoverFromBlocks(types.MakeSigner(bc.chainConfig, chain[0].Number()), chain)
+
+	// Verify the headers in parallel, the same way as the importer does
+	headers := make([]*types.Header, len(chain))
+	seals := make([]bool, len(chain))
+	for i, block := range chain {
+		headers[i] = block.Header()
+		seals[i] = true
+	}
+	abort, errs := bc.engine.VerifyHeaders(bc, headers, seals)
+	defer close(abort)
+
+	results := make([]*SegmentBlockResult, len(chain))
+	for i, block := range chain {
+		results[i] = &SegmentBlockResult{
+			Number: block.NumberU64(),
+			Hash:   block.Hash(),
+		}
+		if i > 0 && results[i-1].Err != nil {
+			results[i].Err = ErrInvalidSegmentAncestor
+			continue
+		}
+		var blockSidecars []*types.BlobTxSidecar
+		if len(sidecars) > 0 {
+			blockSidecars = sidecars[i]
+		}
+		start := time.Now()
+		results[i].Err = bc.verifySegmentBlock(block, i > 0, <-errs, statedb, blockSidecars, results[i])
+
+		// Commit the state for the next block to be executed on top of it
+		if results[i].Err == nil && i < len(chain)-1 {
+			statedb, results[i].Err = commitSegmentState(block, statedb, bc.chainConfig.IsEIP158(block.Number()), spec)
+		}
+		results[i].Elapsed = time.Since(start)
+	}
+	return results, nil
+}
+
+// verifySegmentBlock validates a single block of a segment whose header has
+// already been verified with the given outcome, executing it on top of the
+// given state.
+func (bc *BlockChain) verifySegmentBlock(block *types.Block, inSegment bool, headerErr error, statedb *state.StateDB, sidecars []*types.BlobTxSidecar, result *SegmentBlockResult) error {
+	if headerErr != nil {
+		return headerErr
+	}
+	if BadHashes[block.Hash()] {
+		return ErrBannedHash
+	}
+	// The parents of all but the first block are part of the segment and not in
+	// the database, so the linkability error of those is expected.
+	switch err := bc.validator.ValidateBody(block); {
+	case errors.Is(err, ErrKnownBlock):
+		result.Known = true
+	case errors.Is(err, consensus.ErrUnknownAncestor) && inSegment:
+	case err != nil:
+		return err
+	}
+	if sidecars != nil {
+		if err := verifyBlockSidecars(block, sidecars); err != nil {
+			return err
+		}
+	}
+	receipts, _, _, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig, bc.blockSenders(block), bc.OpEvents()...)
+	if err != nil {
+		return err
+	}
+	if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+		return err
+	}
+	result.GasUsed, result.Receipts = usedGas, receipts
+	return nil
+}
+
+// commitSegmentState commits the post state of a verified block of a segment,
+// which must be speculative, and reopens it for the next block.
+func commitSegmentState(block *types.Block, statedb *state.StateDB, deleteEmptyObjects bool, spec *snapshot.Speculation) (*state.StateDB, error) {
+	root, err := statedb.Commit(block.NumberU64(), deleteEmptyObjects)
+	if err != nil {
+		return nil, err
+	}
+	return state.NewSpeculative(root, statedb.Database(), spec)
+}
+
+// verifyBlockSidecars checks that the given sidecars belong to the blob
+// transactions of the block, in order, and that the blobs match their proofs.
+func verifyBlockSidecars(block *types.Block, sidecars []*types.BlobTxSidecar) error {
+	var (
+		count  int
+		hasher = sha256.New()
+	)
+	for _, tx := range block.Transactions() {
+		if tx.Type() != types.BlobTxType {
+			continue
+		}
+		if count >= len(sidecars) {
+			return fmt.Errorf("missing sidecar of blob transaction %x", tx.Hash())
+		}
+		sidecar, hashes := sidecars[count], tx.BlobHashes()
+		count++
+
+		if len(sidecar.Blobs) != len(hashes) || len(sidecar.Commitments) != len(hashes) || len(sidecar.Proofs) != len(hashes) {
+			return fmt.Errorf("sidecar of transaction %x has %d blobs, want %d", tx.Hash(), len(sidecar.Blobs), len(hashes))
+		}
+		for i, vhash := range hashes {
+			if computed := kzg4844.CalcBlobHashV1(hasher, &sidecar.Commitments[i]); computed != vhash {
+				return fmt.Errorf("sidecar of transaction %x: blob %d hash mismatch: have %x, want %x", tx.Hash(), i, computed, vhash)
+			}
+			if err := kzg4844.VerifyBlobProof(&sidecar.Blobs[i], sidecar.Commitments[i], sidecar.Proofs[i]); err != nil {
+				return fmt.Errorf("sidecar of transaction %x: invalid blob %d: %v", tx.Hash(), i, err)
+			}
+		}
+	}
+	if count != len(sidecars) {
+		return fmt.Errorf("sidecar count mismatch: have %d, want %d", len(sidecars), count)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that a chain segment can be verified without anything being written,
+// and that a block failing verification invalidates the rest of the segment.
+func TestVerifyChainSegment(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		gendb   = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}}, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(gendb, trie.NewDatabase(gendb, nil))
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), gendb, 3, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	}, true)
+
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	results, err := chain.VerifyChainSegment(blocks, nil)
+	if err != nil {
+		t.Fatalf("failed to verify segment: %v", err)
+	}
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("block %d: verification failed: %v", i, result.Err)
+		}
+		if result.Hash != blocks[i].Hash() || result.GasUsed != params.TxGas || len(result.Receipts) != 1 {
+			t.Fatalf("block %d: result mismatch: %+v", i, result)
+		}
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 0 {
+		t.Fatalf("head moved by verification: have %d, want %d", head, 0)
+	}
+	for i, block := range blocks {
+		if chain.HasBlock(block.Hash(), block.NumberU64()) {
+			t.Fatalf("block %d written by verification", i)
+		}
+		if chain.HasState(block.Root()) {
+			t.Fatalf("block %d: state committed by verification", i)
+		}
+		if chain.snaps != nil && chain.snaps.Snapshot(block.Root()) != nil {
+			t.Fatalf("block %d: snapshot layer committed by verification", i)
+		}
+	}
+	// Corrupt the state root of the middle block
+	header := blocks[1].Header()
+	header.Root = common.Hash{0x01}
+	bad := types.NewBlockWithHeader(header).WithBody(blocks[1].Transactions(), blocks[1].Uncles())
+
+	child := blocks[2].Header()
+	child.ParentHash = bad.Hash()
+	segment := types.Blocks{blocks[0], bad, types.NewBlockWithHeader(child).WithBody(blocks[2].Transactions(), blocks[2].Uncles())}
+
+	if results, err = chain.VerifyChainSegment(segment, nil); err != nil {
+		t.Fatalf("failed to verify segment: %v", err)
+	}
+	if results[0].Err != nil {
+		t.Fatalf("valid block failed verification: %v", results[0].Err)
+	}
+	if results[1].Err == nil {
+		t.Fatalf("block with invalid root passed verification")
+	}
+	if !errors.Is(results[2].Err, ErrInvalidSegmentAncestor) {
+		t.Fatalf("descendant error mismatch: have %v, want %v", results[2].Err, ErrInvalidSegmentAncestor)
+	}
+	// Non contiguous segments are rejected as a whole
+	if _, err := chain.VerifyChainSegment(types.Blocks{blocks[0], blocks[2]}, nil); err == nil {
+		t.Fatalf("non contiguous segment verified")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Kinds of the chain mutations recorded in the audit log.
+const (
+	AuditHead     = "head"     // Head block changed by a block import or a reorg
+	AuditSetHead  = "sethead"  // Chain explicitly rewound
+	AuditRollback = "rollback" // Chain rewound to repair the database
+	AuditConfig   = "config"   // Chain configuration overridden
+)
+
+const (
+	// maxAuditEntries is the maximum number of audit log entries returned at once.
+	maxAuditEntries = 1024
+
+	// auditRetention is the number of most recent audit log entries retained,
+	// the older ones are pruned as new ones are appended. With an entry for
+	// every head change, it covers about ten days of the chain.
+	auditRetention = 1 << 18
+)
+
+// chainAudit appends the chain mutations to the hash-chained audit log.
+type chainAudit struct {
+	tail      uint64      // Sequence number of the oldest entry retained
+	length    uint64      // Number of entries ever appended to the log
+	last      common.Hash // Hash of the last entry in the log
+	retention uint64      // Number of most recent entries retained
+	lock      sync.Mutex
+}
+
+// newChainAudit loads the tip of the audit log from the database.
+func newChainAudit(db ethdb.KeyValueReader) *chainAudit {
+	audit := &chainAudit{
+		tail:      rawdb.ReadChainAuditTail(db),
+		length:    rawdb.ReadChainAuditLength(db),
+		retention: auditRetention,
+	}
+	if audit.length > 0 {
+		entry := rawdb.ReadChainAuditEntry(db, audit.length-1)
+		if entry == nil {
+			log.Error("Chain audit log tip missing", "seq", audit.length-1)
+		} else {
+			audit.last = auditEntryHash(entry)
+		}
+	}
+	return audit
+}
+
+// append records a mutation leaving the given block as the head of the chain,
+// pruning the entries falling out of the retention along in the same write.
+func (a *chainAudit) append(db ethdb.KeyValueWriter, kind, actor, context string, head *types.Block) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	entry := &rawdb.ChainAuditEntry{
+		Seq:     a.length,
+		Time:    uint64(time.Now().Unix()),
+		Kind:    kind,
+		Actor:   actor,
+		Context: context,
+		Number:  head.NumberU64(),
+		Hash:    head.Hash(),
+		Prev:    a.last,
+	}
+	rawdb.WriteChainAuditEntry(db, entry)
+
+	a.length++
+	a.last = auditEntryHash(entry)
+
+	if a.length-a.tail > a.retention {
+		for ; a.length-a.tail > a.retention; a.tail++ {
+			rawdb.DeleteChainAuditEntry(db, a.tail)
+		}
+		rawdb.WriteChainAuditTail(db, a.tail)
+	}
+}
+
+// auditEntryHash returns the hash an audit log entry is chained by.
+func auditEntryHash(entry *rawdb.ChainAuditEntry) common.Hash {
+	blob, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode chain audit entry", "err", err)
+	}
+	return crypto.Keccak256Hash(blob)
+}
+
+// recordMutation appends a mutation leaving the current block as the head to
+// the audit log, if enabled.
+func (bc *BlockChain) recordMutation(kind, actor, context string) {
+	if bc.audit != nil {
+		bc.audit.append(bc.db, kind, actor, context, bc.CurrentBlock())
+	}
+}
+
+// SetHeadAs rewinds the local chain to a new head like SetHead, recording the
+// originator and the reason of the rewind in the audit log.
+func (bc *BlockChain) SetHeadAs(head uint64, actor, context string) error {
+	return bc.setHeadAudited(head, AuditSetHead, actor, context)
+}
+
+// setHeadAudited rewinds the local chain to a new head, recording the rewind
+// in the audit log.
+func (bc *BlockChain) setHeadAudited(head uint64, kind, actor, context string) error {
+	if _, err := bc.setHeadBeyondRoot(head, common.Hash{}, false); err != nil {
+		return err
+	}
+	bc.recordMutation(kind, actor, context)
+	return nil
+}
+
+// ChainAuditLog returns at most count entries of the audit log starting at the
+// given position, or at the oldest entry retained if it's already pruned, along
+// with their hashes.
+func (bc *BlockChain) ChainAuditLog(from, count uint64) ([]*rawdb.ChainAuditEntry, []common.Hash) {
+	if count > maxAuditEntries {
+		count = maxAuditEntries
+	}
+	if tail := rawdb.ReadChainAuditTail(bc.db); from < tail {
+		from = tail
+	}
+	var (
+		entries []*rawdb.ChainAuditEntry
+		hashes  []common.Hash
+	)
+	for seq := from; seq < from+count; seq++ {
+		entry := rawdb.ReadChainAuditEntry(bc.db, seq)
+		if entry == nil {
+			break
+		}
+		entries = append(entries, entry)
+		hashes = append(hashes, auditEntryHash(entry))
+	}
+	return entries, hashes
+}
+
+// VerifyChainAuditLog walks the retained audit log checking that every entry is
+// present, in place and chained to its predecessor. The oldest entry retained
+// anchors the chain, as its predecessor is pruned. It returns the number of
+// entries verified.
+func (bc *BlockChain) VerifyChainAuditLog() (uint64, error) {
+	var (
+		tail   = rawdb.ReadChainAuditTail(bc.db)
+		length = rawdb.ReadChainAuditLength(bc.db)
+		prev   common.Hash
+	)
+	for seq := tail; seq < length; seq++ {
+		entry := rawdb.ReadChainAuditEntry(bc.db, seq)
+		if entry == nil {
+			return seq - tail, fmt.Errorf("audit entry %d missing", seq)
+		}
+		if entry.Seq != seq {
+			return seq - tail, fmt.Errorf("audit entry %d misplaced: have seq %d", seq, entry.Seq)
+		}
+		if seq > tail && entry.Prev != prev {
+			return seq - tail, fmt.Errorf("audit entry %d unchained: have prev %x, want %x", seq, entry.Prev, prev)
+		}
+		prev = auditEntryHash(entry)
+	}
+	return length - tail, nil
+}
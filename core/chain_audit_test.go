@@ -0,0 +1,162 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the head changes, including the reorgs, and the rewinds are
+// appended to the audit log, which survives restarts and detects tampered
+// entries.
+func TestChainAudit(t *testing.T) {
+	gspec := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	genDb, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 6, func(i int, b *BlockGen) {})
+	forks, _ := GenerateChain(gspec.Config, blocks[1], ethash.NewFaker(), genDb, 5, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	}, true)
+
+	db := rawdb.NewMemoryDatabase()
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.ChainAudit = true
+
+	chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks[:4], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := chain.SetHeadAs(2, "tester", "undo"); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	chain.Stop()
+
+	// Reopen the chain and keep appending to the log
+	chain, err = NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[2:], nil); err != nil {
+		t.Fatalf("failed to reinsert chain: %v", err)
+	}
+	if _, err := chain.InsertChain(forks, nil); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	want := []struct {
+		kind   string
+		actor  string
+		number uint64
+	}{
+		{AuditHead, "chain", 1}, {AuditHead, "chain", 2}, {AuditHead, "chain", 3}, {AuditHead, "chain", 4},
+		{AuditSetHead, "tester", 2},
+		{AuditHead, "chain", 3}, {AuditHead, "chain", 4}, {AuditHead, "chain", 5}, {AuditHead, "chain", 6},
+		{AuditHead, "chain", 3}, {AuditHead, "chain", 4}, {AuditHead, "chain", 5}, {AuditHead, "chain", 6}, {AuditHead, "chain", 7},
+	}
+	entries, hashes := chain.ChainAuditLog(0, 100)
+	if len(entries) != len(want) {
+		t.Fatalf("entry count mismatch: have %d, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Kind != want[i].kind || entry.Actor != want[i].actor || entry.Number != want[i].number {
+			t.Errorf("entry %d mismatch: have %s/%s/%d, want %s/%s/%d", i, entry.Kind, entry.Actor, entry.Number, want[i].kind, want[i].actor, want[i].number)
+		}
+		if i > 0 && entry.Prev != hashes[i-1] {
+			t.Errorf("entry %d unchained", i)
+		}
+	}
+	if entries[4].Context != "undo" {
+		t.Errorf("context mismatch: have %q, want %q", entries[4].Context, "undo")
+	}
+	if entries[8].Context != "extend" {
+		t.Errorf("extension context mismatch: have %q, want %q", entries[8].Context, "extend")
+	}
+	if want := fmt.Sprintf("reorg from 6 %x", blocks[5].Hash()); entries[9].Context != want {
+		t.Errorf("reorg context mismatch: have %q, want %q", entries[9].Context, want)
+	}
+	if n, err := chain.VerifyChainAuditLog(); err != nil || n != uint64(len(want)) {
+		t.Fatalf("verification failed: verified %d, err %v", n, err)
+	}
+	// Tamper with an entry and ensure it's detected
+	entries[3].Number = 10
+	rawdb.WriteChainAuditEntry(db, entries[3])
+	rawdb.WriteChainAuditEntry(db, entries[len(entries)-1])
+
+	if n, err := chain.VerifyChainAuditLog(); err == nil || n != 4 {
+		t.Fatalf("tampering not detected: verified %d, err %v", n, err)
+	}
+}
+
+// Tests that the audit log only retains its most recent entries, pruning the
+// older ones as new ones are appended.
+func TestChainAuditRetention(t *testing.T) {
+	gspec := &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, func(i int, b *BlockGen) {})
+
+	db := rawdb.NewMemoryDatabase()
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.ChainAudit = true
+
+	chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	chain.audit.retention = 4
+	if _, err := chain.InsertChain(blocks[:6], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	// Reopen the chain and ensure the pruning carries on from where it was
+	chain, err = NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	chain.audit.retention = 4
+	if _, err := chain.InsertChain(blocks[6:], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for seq := uint64(0); seq < 6; seq++ {
+		if rawdb.ReadChainAuditEntry(db, seq) != nil {
+			t.Errorf("entry %d not pruned", seq)
+		}
+	}
+	entries, _ := chain.ChainAuditLog(0, 100)
+	if len(entries) != 4 {
+		t.Fatalf("entry count mismatch: have %d, want %d", len(entries), 4)
+	}
+	for i, entry := range entries {
+		if entry.Seq != uint64(6+i) || entry.Number != uint64(7+i) {
+			t.Errorf("entry %d mismatch: have seq %d, number %d", i, entry.Seq, entry.Number)
+		}
+	}
+	if n, err := chain.VerifyChainAuditLog(); err != nil || n != 4 {
+		t.Fatalf("verification failed: verified %d, err %v", n, err)
+	}
+}
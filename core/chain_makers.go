@@ -202,9 +202,16 @@ func (b *BlockGen) PrevBlock(index int) *types.Block {
 	return b.chain[index]
 }
 
+// Timestamp returns the timestamp of the block being generated, which decides
+// the activation of the time scheduled forks.
+func (b *BlockGen) Timestamp() uint64 {
+	return b.header.Time
+}
+
 // OffsetTime modifies the time instance of a block, implicitly changing its
 // associated difficulty. It's useful to test scenarios where forking is not
-// tied to chain length directly.
+// tied to chain length directly. As the timestamp also activates the time
+// scheduled forks, it should be called before adding any transactions.
 func (b *BlockGen) OffsetTime(seconds int64) {
 	b.header.Time += uint64(seconds)
 	if b.header.Time <= b.parent.Header().Time {
@@ -348,12 +355,7 @@ func generateChain(
 		}
 		block, receipt := genblock(i, parent, triedb, statedb)
 
-		// Prepare Blob receipt
-		var blobGasPrice *big.Int
-		if block.ExcessBlobGas() != nil {
-			blobGasPrice = eip4844.CalcBlobFee(*block.ExcessBlobGas())
-		}
-		if err := receipt.DeriveFields(config, block.Hash(), block.NumberU64(), blobGasPrice, block.Transactions()); err != nil {
+		if err := receipt.DeriveFields(config, block.Hash(), block.NumberU64(), eip4844.HeaderBlobFee(block.Header()), block.Transactions()); err != nil {
 			panic(err)
 		}
 
@@ -418,6 +420,10 @@ func makeHeader(chain consensus.ChainReader, parent *types.Block, state *state.S
 		header.ExcessBlobGas = &excessBlobGas
 		header.BlobGasUsed = new(uint64)
 	}
+	if chain.Config().IsSettlement(header.Number) {
+		header.WithdrawalsHash = &types.EmptyRootHash
+		header.DepositsHash = &types.EmptyRootHash
+	}
 	return header
 }
 
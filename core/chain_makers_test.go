@@ -19,6 +19,7 @@ package core
 import (
 	"fmt"
 	"math/big"
+	"testing"
 
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -99,3 +100,36 @@ func ExampleGenerateChain() {
 	// balance of addr2: 10000
 	// balance of addr3: 19687500000000001000
 }
+
+// Tests that the generated blocks activate time scheduled forks by their
+// timestamps, and that the chain can be rewound to before such a fork.
+func TestGenerateChainTimestampFork(t *testing.T) {
+	var (
+		pragueTime = uint64(25)
+		config     = *params.TestChainConfig
+	)
+	config.PragueTime = &pragueTime
+
+	gspec := &Genesis{Config: &config}
+	db, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, gen *BlockGen) {
+		// Blocks are 10 seconds apart, so the fork activates at the third block
+		if have, want := config.Rules(gen.Number(), gen.Timestamp()).IsPrague, i >= 2; have != want {
+			t.Errorf("block %d: prague rule mismatch: have %v, want %v", i+1, have, want)
+		}
+	})
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := chain.SetHeadWithTimestamp(pragueTime - 1); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if head := chain.CurrentBlock(); head.NumberU64() != 2 {
+		t.Fatalf("head mismatch after rewind: have %d, want %d", head.NumberU64(), 2)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// compactionBatch is the number of frozen blocks whose data is compacted at
+	// once, keeping every single compaction short.
+	compactionBatch = 1024
+
+	// compactionTurnGuard is the time before a sealing turn of the validator
+	// within which no compaction is started.
+	compactionTurnGuard = 10 * time.Second
+
+	// compactionTurnGrace is the time after a sealing turn of the validator the
+	// compactions are deferred to, letting the sealed block propagate.
+	compactionTurnGrace = 3 * time.Second
+
+	// compactionGateRecheck is the interval at which the next sealing turn of
+	// the validator is rechecked for throttling the database's own compactions.
+	compactionGateRecheck = time.Second
+)
+
+var (
+	compactionTimer      = metrics.NewRegisteredTimer("chain/compaction/time", nil)
+	compactionDeferMeter = metrics.NewRegisteredMeter("chain/compaction/deferred", nil)
+	compactionGateMeter  = metrics.NewRegisteredMeter("chain/compaction/throttled", nil)
+)
+
+// compactionRange is a key range of the database scheduled for compaction.
+type compactionRange struct {
+	start, limit []byte
+}
+
+// compactions is the queue of the database ranges scheduled for compaction.
+type compactions struct {
+	queue  []compactionRange
+	frozen uint64 // Number of the frozen blocks whose data was scheduled for compaction
+	lock   sync.Mutex
+}
+
+// ScheduleCompaction queues a key range of the database for compaction. The
+// compactions are run periodically, away from the sealing turns of the local
+// validator, if the compaction interval is configured.
+func (bc *BlockChain) ScheduleCompaction(start, limit []byte) {
+	bc.compactions.lock.Lock()
+	defer bc.compactions.lock.Unlock()
+
+	bc.compactions.queue = append(bc.compactions.queue, compactionRange{
+		start: common.CopyBytes(start),
+		limit: common.CopyBytes(limit),
+	})
+}
+
+// scheduleFrozenCompaction queues the ranges of the block data migrated into the
+// freezer since the last run for compaction, reclaiming the space freed in the
+// key-value store.
+func (bc *BlockChain) scheduleFrozenCompaction() {
+	frozen, err := bc.db.Ancients()
+	if err != nil {
+		return
+	}
+	bc.compactions.lock.Lock()
+	defer bc.compactions.lock.Unlock()
+
+	for from := bc.compactions.frozen; from < frozen; from += compactionBatch {
+		limit := from + compactionBatch
+		if limit > frozen {
+			limit = frozen
+		}
+		for _, r := range rawdb.BlockDataRanges(from, limit) {
+			bc.compactions.queue = append(bc.compactions.queue, compactionRange{start: r[0], limit: r[1]})
+		}
+	}
+	if frozen > bc.compactions.frozen {
+		bc.compactions.frozen = frozen
+	}
+}
+
+// nextCompaction pops the next range scheduled for compaction.
+func (bc *BlockChain) nextCompaction() (compactionRange, bool) {
+	bc.compactions.lock.Lock()
+	defer bc.compactions.lock.Unlock()
+
+	if len(bc.compactions.queue) == 0 {
+		return compactionRange{}, false
+	}
+	next := bc.compactions.queue[0]
+	bc.compactions.queue = bc.compactions.queue[1:]
+	return next, true
+}
+
+// compactionDelay returns how long the compactions must be deferred at the given
+// time to stay away from the next sealing turn of the local validator, or zero
+// if they can run right away.
+func (bc *BlockChain) compactionDelay(now time.Time) time.Duration {
+	scheduler, ok := bc.engine.(consensus.TurnScheduler)
+	if !ok {
+		return 0
+	}
+	turn, ok := scheduler.NextSealTurn(bc, bc.CurrentHeader())
+	if !ok {
+		return 0
+	}
+	until := turn.Sub(now)
+	if until >= compactionTurnGuard || until <= -compactionTurnGrace {
+		// Far from the turn, or the turn was missed already
+		return 0
+	}
+	return until + compactionTurnGrace
+}
+
+// compactionLoop periodically compacts the ranges of the database scheduled for
+// compaction, including the block data migrated into the freezer, one range at
+// a time, deferring them around the sealing turns of the local validator.
+func (bc *BlockChain) compactionLoop(interval time.Duration) {
+	defer bc.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			bc.scheduleFrozenCompaction()
+
+			for {
+				next, ok := bc.nextCompaction()
+				if !ok {
+					break
+				}
+				// Every range is deferred at most once, right after a turn the next
+				// one is the farthest away, so frequent turns don't starve them
+				if delay := bc.compactionDelay(time.Now()); delay > 0 {
+					compactionDeferMeter.Mark(1)
+					log.Debug("Deferring database compaction for sealing turn", "delay", common.PrettyDuration(delay))
+
+					select {
+					case <-time.After(delay):
+					case <-bc.quit:
+						return
+					}
+				}
+				start := time.Now()
+				if err := bc.db.Compact(next.start, next.limit); err != nil {
+					log.Warn("Failed to compact database range", "start", next.start, "limit", next.limit, "err", err)
+				}
+				compactionTimer.UpdateSince(start)
+
+				select {
+				case <-bc.quit:
+					return
+				default:
+				}
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// compactionGateLoop throttles the database's own background compactions around
+// the sealing turns of the local validator, from compactionTurnGuard before a
+// turn until compactionTurnGrace after it.
+func (bc *BlockChain) compactionGateLoop() {
+	defer bc.wg.Done()
+
+	if _, ok := bc.engine.(consensus.TurnScheduler); !ok {
+		return
+	}
+	var throttled bool
+	defer func() {
+		if throttled {
+			bc.db.ThrottleCompactions(false)
+		}
+	}()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			delay := bc.compactionDelay(time.Now())
+			if throttle := delay > 0; throttle != throttled {
+				if throttle {
+					compactionGateMeter.Mark(1)
+					log.Debug("Throttling database compactions for sealing turn", "delay", common.PrettyDuration(delay))
+				}
+				bc.db.ThrottleCompactions(throttle)
+				throttled = throttle
+			}
+			// Recheck at the end of the turn, or periodically for a new one
+			next := compactionGateRecheck
+			if delay > 0 && delay < next {
+				next = delay
+			}
+			timer.Reset(next)
+
+		case <-bc.quit:
+			return
+		}
+	}
+}
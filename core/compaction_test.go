@@ -0,0 +1,129 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// turnEngine is a consensus engine whose local validator seals at a set time.
+type turnEngine struct {
+	consensus.Engine
+	turn atomic.Pointer[time.Time]
+}
+
+func (e *turnEngine) NextSealTurn(chain consensus.ChainHeaderReader, head *types.Header) (time.Time, bool) {
+	if turn := e.turn.Load(); turn != nil {
+		return *turn, true
+	}
+	return time.Time{}, false
+}
+
+// compactionCounter is a database counting the compactions run on it and the
+// times its own compactions were throttled.
+type compactionCounter struct {
+	ethdb.Database
+	compactions atomic.Int32
+	throttles   atomic.Int32
+	throttled   atomic.Bool
+}
+
+func (db *compactionCounter) Compact(start []byte, limit []byte) error {
+	db.compactions.Add(1)
+	return db.Database.Compact(start, limit)
+}
+
+func (db *compactionCounter) ThrottleCompactions(throttle bool) {
+	if throttle {
+		db.throttles.Add(1)
+	}
+	db.throttled.Store(throttle)
+	db.Database.ThrottleCompactions(throttle)
+}
+
+// Tests that the scheduled compactions are run periodically and deferred around
+// the sealing turns of the local validator, throttling the database's own ones.
+func TestCompactionScheduling(t *testing.T) {
+	engine := &turnEngine{Engine: ethash.NewFaker()}
+
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.CompactionInterval = 10 * time.Millisecond
+
+	db := &compactionCounter{Database: rawdb.NewMemoryDatabase()}
+	chain, err := NewBlockChain(db, cacheConfig, &Genesis{Config: params.TestChainConfig}, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Compactions are only deferred close to a turn which wasn't missed
+	now := time.Now()
+	for i, tt := range []struct {
+		turn  *time.Time
+		delay time.Duration
+	}{
+		{nil, 0},
+		{timeRef(now.Add(time.Minute)), 0},
+		{timeRef(now.Add(compactionTurnGuard)), 0},
+		{timeRef(now.Add(time.Second)), time.Second + compactionTurnGrace},
+		{timeRef(now.Add(-time.Second)), compactionTurnGrace - time.Second},
+		{timeRef(now.Add(-compactionTurnGrace)), 0},
+	} {
+		engine.turn.Store(tt.turn)
+		if delay := chain.compactionDelay(now); delay != tt.delay {
+			t.Errorf("test %d: delay mismatch: have %v, want %v", i, delay, tt.delay)
+		}
+	}
+	// Schedule a compaction right before a turn, it must wait until after it
+	turn := time.Now().Add(200 * time.Millisecond)
+	engine.turn.Store(&turn)
+	chain.ScheduleCompaction([]byte("a"), []byte("b"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for db.compactions.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("scheduled compaction not run")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if time.Now().Before(turn.Add(compactionTurnGrace)) {
+		t.Fatalf("compaction not deferred past the sealing turn")
+	}
+	// The database's own compactions must have been throttled for the turn and
+	// released after it
+	if db.throttles.Load() == 0 {
+		t.Fatalf("database compactions not throttled for the sealing turn")
+	}
+	for db.throttled.Load() {
+		if time.Now().After(deadline) {
+			t.Fatalf("database compactions not released after the sealing turn")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func timeRef(t time.Time) *time.Time { return &t }
@@ -0,0 +1,167 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// recentTx is the block including a recent transaction.
+type recentTx struct {
+	number uint64
+	hash   common.Hash
+}
+
+// recentTxs tracks the transactions of the recent canonical blocks, rejecting
+// the imported blocks including them again. It guards against the replay of
+// transactions through crafted blocks, should the chain id checks be
+// misconfigured.
+//
+// The blocks dropped by a reorg are not removed, the including block of a
+// transaction being checked against the ancestors of the imported blocks.
+type recentTxs struct {
+	window uint64                   // Number of recent blocks tracked
+	txs    map[common.Hash]recentTx // Including blocks of the tracked transactions
+	blocks map[uint64][]common.Hash // Tracked transactions by block number, for eviction
+	oldest uint64                   // Number of the oldest tracked block
+	lock   sync.Mutex
+}
+
+func newRecentTxs(window uint64) *recentTxs {
+	return &recentTxs{
+		window: window,
+		txs:    make(map[common.Hash]recentTx),
+		blocks: make(map[uint64][]common.Hash),
+	}
+}
+
+// load tracks the transactions of the canonical blocks within the window below
+// the given head.
+func (r *recentTxs) load(bc *BlockChain, head *types.Block) {
+	number := head.NumberU64()
+	if number >= r.window {
+		number -= r.window - 1
+	} else {
+		number = 0
+	}
+	for ; number <= head.NumberU64(); number++ {
+		if block := bc.GetBlockByNumber(number); block != nil {
+			r.add(block)
+		}
+	}
+	log.Debug("Loaded recent transactions", "head", head.NumberU64(), "txs", len(r.txs))
+}
+
+// add tracks the transactions of a new canonical block, evicting the ones of
+// the blocks falling out of the window.
+func (r *recentTxs) add(block *types.Block) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	number := block.NumberU64()
+	if len(block.Transactions()) > 0 {
+		if len(r.blocks) == 0 || number < r.oldest {
+			r.oldest = number
+		}
+		for _, tx := range block.Transactions() {
+			r.txs[tx.Hash()] = recentTx{number: number, hash: block.Hash()}
+			r.blocks[number] = append(r.blocks[number], tx.Hash())
+		}
+	}
+	if number < r.window {
+		return
+	}
+	// Evict the blocks below the window, visiting the tracked ones only if the
+	// head jumped far ahead
+	limit := number - r.window + 1
+	if limit-min(r.oldest, limit) > uint64(len(r.blocks)) {
+		for n := range r.blocks {
+			if n < limit {
+				r.evict(n)
+			}
+		}
+	} else {
+		for n := r.oldest; n < limit; n++ {
+			r.evict(n)
+		}
+	}
+	r.oldest = max(r.oldest, limit)
+}
+
+// evict stops tracking the transactions of the blocks of the given number.
+func (r *recentTxs) evict(number uint64) {
+	for _, hash := range r.blocks[number] {
+		if r.txs[hash].number == number {
+			delete(r.txs, hash)
+		}
+	}
+	delete(r.blocks, number)
+}
+
+// lookup returns the block including the given transaction, if tracked.
+func (r *recentTxs) lookup(hash common.Hash) (recentTx, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	included, ok := r.txs[hash]
+	return included, ok
+}
+
+// verify checks that the given block includes no transaction twice, nor any
+// transaction included by one of its tracked ancestors.
+func (r *recentTxs) verify(bc *BlockChain, block *types.Block) error {
+	seen := make(map[common.Hash]struct{}, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		hash := tx.Hash()
+		if _, ok := seen[hash]; ok {
+			return fmt.Errorf("%w: tx %d %x included twice", ErrTxAlreadyIncluded, i, hash)
+		}
+		seen[hash] = struct{}{}
+
+		included, ok := r.lookup(hash)
+		if !ok || included.number >= block.NumberU64() {
+			continue
+		}
+		if bc.isAncestor(block, included.number, included.hash) {
+			return fmt.Errorf("%w: tx %d %x included by block %d %x", ErrTxAlreadyIncluded, i, hash, included.number, included.hash)
+		}
+	}
+	return nil
+}
+
+// isAncestor reports whether the block of the given number and hash is an
+// ancestor of the given block.
+func (bc *BlockChain) isAncestor(block *types.Block, number uint64, hash common.Hash) bool {
+	// Walk back along the side chain of the block until joining the canonical one
+	parentHash, parentNumber := block.ParentHash(), block.NumberU64()-1
+	for parentNumber > number && bc.GetCanonicalHash(parentNumber) != parentHash {
+		parent := bc.GetHeader(parentHash, parentNumber)
+		if parent == nil {
+			return false
+		}
+		parentHash, parentNumber = parent.ParentHash, parentNumber-1
+	}
+	if parentNumber == number {
+		return parentHash == hash
+	}
+	return bc.GetCanonicalHash(number) == hash
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the blocks including again the transactions of their recent
+// ancestors are rejected, unlike the ones of the blocks beyond the window or
+// off their chain.
+func TestDuplicateTransactions(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	genDb, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 6, func(i int, b *BlockGen) {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: b.TxNonce(addr), To: &common.Address{0xaa}, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: b.header.BaseFee})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.DuplicateTxWindow = 3
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:5], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// replay crafts the successor of the head including the given transactions
+	replay := func(txs ...*types.Transaction) *types.Block {
+		header := types.CopyHeader(blocks[5].Header())
+		header.TxHash = types.DeriveSha(types.Transactions(txs), trie.NewStackTrie(nil))
+		return types.NewBlockWithHeader(header).WithBody(txs, nil)
+	}
+	var (
+		recent = blocks[3].Transactions()[0] // Within the window of the successor
+		old    = blocks[0].Transactions()[0] // Beyond the window of the successor
+		fresh  = blocks[5].Transactions()[0]
+	)
+	if _, err := chain.InsertChain([]*types.Block{replay(recent)}, nil); !errors.Is(err, ErrTxAlreadyIncluded) {
+		t.Fatalf("recent transaction replay: have %v, want %v", err, ErrTxAlreadyIncluded)
+	}
+	if _, err := chain.InsertChain([]*types.Block{replay(fresh, fresh)}, nil); !errors.Is(err, ErrTxAlreadyIncluded) {
+		t.Fatalf("transaction included twice: have %v, want %v", err, ErrTxAlreadyIncluded)
+	}
+	// Beyond the window, the replay is left to the state transition to reject
+	if _, err := chain.InsertChain([]*types.Block{replay(old)}, nil); err == nil || errors.Is(err, ErrTxAlreadyIncluded) {
+		t.Fatalf("old transaction replay: have %v, want nonce error", err)
+	}
+	// The transactions of a block off the chain of the imported one may be included
+	fork, _ := GenerateChain(gspec.Config, blocks[2], ethash.NewFaker(), genDb, 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0xbb})
+		if i == 1 {
+			b.AddTx(recent)
+		}
+	}, true)
+	if _, err := chain.InsertChain(fork, nil); err != nil {
+		t.Fatalf("failed to insert fork including a sibling transaction: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks[5:], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// eraImportBatch is the number of blocks of an archive inserted at once.
+const eraImportBatch = 1024
+
+// ExportEra writes a range of the canonical chain into the given directory as
+// a set of era archives of the given network, each holding the blocks of one
+// epoch of step blocks, along with their receipts and total difficulties.
+//
+// The export is resumable: archives are only moved into place once complete,
+// and the epochs whose archive already exists with the expected range are
+// skipped.
+func (bc *BlockChain) ExportEra(dir string, network string, first uint64, last uint64, step uint64) error {
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	if first > last {
+		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
+	}
+	if step == 0 || step > era.MaxEraBatchSize {
+		return fmt.Errorf("export failed: invalid step %d, must be in [1, %d]", step, era.MaxEraBatchSize)
+	}
+	if strings.Contains(network, "-") {
+		return fmt.Errorf("export failed: invalid network name %q", network)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	log.Info("Exporting blocks to era archives", "dir", dir, "count", last-first+1)
+
+	start, reported := time.Now(), time.Now()
+	for epoch := first / step; epoch <= last/step; epoch++ {
+		from, to := max(epoch*step, first), min((epoch+1)*step-1, last)
+
+		existing, err := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%s-%05d-*%s", network, epoch, era.Extension)))
+		if err != nil {
+			return err
+		}
+		if len(existing) == 1 && eraCovers(existing[0], from, to) {
+			log.Debug("Skipping exported era archive", "epoch", epoch, "file", existing[0])
+			continue
+		}
+		root, err := bc.exportEraFile(dir, network, int(epoch), from, to)
+		if err != nil {
+			return fmt.Errorf("export failed on epoch %d: %w", epoch, err)
+		}
+		// Drop the stale archives of the epoch, e.g. partial ones of a previous run
+		for _, path := range existing {
+			if filepath.Base(path) != era.Filename(network, int(epoch), root) {
+				os.Remove(path)
+			}
+		}
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Exporting blocks to era archives", "exported", to-first+1, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	return nil
+}
+
+// eraCovers reports whether the archive at the given path holds exactly the
+// given range of blocks.
+func eraCovers(path string, first, last uint64) bool {
+	e, err := era.Open(path)
+	if err != nil {
+		return false
+	}
+	defer e.Close()
+	return e.Start() == first && e.Start()+e.Count()-1 == last
+}
+
+// exportEraFile writes a single era archive of the given range of blocks and
+// returns its accumulator root.
+func (bc *BlockChain) exportEraFile(dir string, network string, epoch int, first uint64, last uint64) (common.Hash, error) {
+	tmp := filepath.Join(dir, fmt.Sprintf("%s-%05d%s.tmp", network, epoch, era.Extension))
+	file, err := os.Create(tmp)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	defer func() {
+		file.Close()
+		os.Remove(tmp) // No-op once renamed
+	}()
+	var (
+		buf     = bufio.NewWriter(file)
+		builder = era.NewBuilder(buf)
+	)
+	for nr := first; nr <= last; nr++ {
+		block := bc.GetBlockByNumber(nr)
+		if block == nil {
+			return common.Hash{}, fmt.Errorf("block #%d not found", nr)
+		}
+		receipts := bc.GetReceiptsByHash(block.Hash())
+		if len(receipts) != len(block.Transactions()) {
+			return common.Hash{}, fmt.Errorf("have %d receipts for %d transactions of block #%d", len(receipts), len(block.Transactions()), nr)
+		}
+		td := bc.GetTd(block.Hash(), nr)
+		if td == nil {
+			return common.Hash{}, fmt.Errorf("total difficulty of block #%d not found", nr)
+		}
+		if err := builder.Add(block, receipts, td); err != nil {
+			return common.Hash{}, err
+		}
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := buf.Flush(); err != nil {
+		return common.Hash{}, err
+	}
+	if err := file.Sync(); err != nil {
+		return common.Hash{}, err
+	}
+	if err := file.Close(); err != nil {
+		return common.Hash{}, err
+	}
+	return root, os.Rename(tmp, filepath.Join(dir, era.Filename(network, epoch, root)))
+}
+
+// ImportEra imports the era archives of the given network in a directory, which
+// must continue the local chain, writing the blocks and receipts directly into
+// the freezer. The archives are verified against their accumulator roots, and
+// the blocks against their headers, which go through the usual header chain
+// validation.
+//
+// Only the chain history is imported, advancing the head header and the head
+// fast block; the state of the imported blocks has to be synced separately.
+// Archives already imported are skipped, so an interrupted import can resume.
+func (bc *BlockChain) ImportEra(dir string, network string) error {
+	paths, err := era.ReadDir(dir, network)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no era archives of network %s found in %s", network, dir)
+	}
+	start := time.Now()
+	for _, path := range paths {
+		if err := bc.importEraFile(path); err != nil {
+			return fmt.Errorf("import of %s failed: %w", filepath.Base(path), err)
+		}
+		log.Info("Imported era archive", "file", filepath.Base(path), "head", bc.CurrentFastBlock().NumberU64(),
+			"elapsed", common.PrettyDuration(time.Since(start)))
+	}
+	return nil
+}
+
+// importEraFile verifies and imports a single era archive.
+func (bc *BlockChain) importEraFile(path string) error {
+	e, err := era.Open(path)
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+
+	// Verify the integrity of the archive before touching the chain
+	root, err := verifyEra(e)
+	if err != nil {
+		return err
+	}
+	network, epoch, err := era.ParseFilename(path)
+	if err != nil {
+		return err
+	}
+	if filepath.Base(path) != era.Filename(network, epoch, root) {
+		return fmt.Errorf("accumulator root %x mismatches the file name", root)
+	}
+	// Skip the blocks already known, they must be the canonical ones
+	var (
+		first = e.Start()
+		last  = e.Start() + e.Count() - 1
+		head  = bc.CurrentFastBlock().NumberU64()
+	)
+	if first > head+1 {
+		return fmt.Errorf("archive starts at #%d, beyond the local chain head #%d", first, head)
+	}
+	if known := min(head, last); known >= first {
+		block, err := e.GetBlockByNumber(known)
+		if err != nil {
+			return err
+		}
+		if hash := bc.GetCanonicalHash(known); hash != block.Hash() {
+			return fmt.Errorf("block #%d mismatches the local chain: have %x, want %x", known, block.Hash(), hash)
+		}
+		first = known + 1
+	}
+	if first > last {
+		return nil
+	}
+	if frozen, err := bc.db.Ancients(); err != nil {
+		return err
+	} else if frozen != first && !(frozen == 0 && first == 1) {
+		return fmt.Errorf("freezer holds %d blocks, not continued by #%d", frozen, first)
+	}
+	for from := first; from <= last; from += eraImportBatch {
+		to := min(from+eraImportBatch-1, last)
+
+		var (
+			headers  = make([]*types.Header, 0, to-from+1)
+			blocks   = make(types.Blocks, 0, to-from+1)
+			receipts = make([]types.Receipts, 0, to-from+1)
+		)
+		for nr := from; nr <= to; nr++ {
+			block, err := e.GetBlockByNumber(nr)
+			if err != nil {
+				return err
+			}
+			rs, err := e.GetReceiptsByNumber(nr)
+			if err != nil {
+				return err
+			}
+			if err := verifyEraBlock(block, rs); err != nil {
+				return err
+			}
+			headers, blocks, receipts = append(headers, block.Header()), append(blocks, block), append(receipts, rs)
+		}
+		if n, err := bc.InsertHeaderChain(headers, 1); err != nil {
+			return fmt.Errorf("invalid header #%d: %w", headers[n].Number, err)
+		}
+		// The total difficulties of the archive must match the validated headers
+		for _, block := range []*types.Block{blocks[0], blocks[len(blocks)-1]} {
+			have, err := e.GetTotalDifficulty(block.NumberU64())
+			if err != nil {
+				return err
+			}
+			if want := bc.GetTd(block.Hash(), block.NumberU64()); want == nil || have.Cmp(want) != 0 {
+				return fmt.Errorf("total difficulty of block #%d mismatches: have %v, want %v", block.NumberU64(), have, want)
+			}
+		}
+		if _, err := bc.InsertReceiptChain(blocks, receipts, nil, math.MaxUint64); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyEra checks the accumulator root stored in an archive against its
+// blocks and total difficulties, returning it.
+func verifyEra(e *era.Era) (common.Hash, error) {
+	want, err := e.Accumulator()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		hashes = make([]common.Hash, 0, e.Count())
+		tds    = make([]*big.Int, 0, e.Count())
+	)
+	for nr := e.Start(); nr < e.Start()+e.Count(); nr++ {
+		block, err := e.GetBlockByNumber(nr)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if len(hashes) > 0 && block.ParentHash() != hashes[len(hashes)-1] {
+			return common.Hash{}, fmt.Errorf("block #%d is not linked to its parent", nr)
+		}
+		td, err := e.GetTotalDifficulty(nr)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		hashes, tds = append(hashes, block.Hash()), append(tds, td)
+	}
+	have, err := era.ComputeAccumulator(hashes, tds)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if have != want {
+		return common.Hash{}, fmt.Errorf("accumulator mismatch: have %x, want %x", have, want)
+	}
+	return want, nil
+}
+
+// verifyEraBlock checks the body and the receipts of an archived block against
+// its header.
+func verifyEraBlock(block *types.Block, receipts types.Receipts) error {
+	header := block.Header()
+	if hash := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); hash != header.TxHash {
+		return fmt.Errorf("transaction root of block #%d mismatches: have %x, want %x", header.Number, hash, header.TxHash)
+	}
+	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
+		return fmt.Errorf("uncle root of block #%d mismatches: have %x, want %x", header.Number, hash, header.UncleHash)
+	}
+	if len(receipts) != len(block.Transactions()) {
+		return fmt.Errorf("have %d receipts for %d transactions of block #%d", len(receipts), len(block.Transactions()), header.Number)
+	}
+	if hash := types.DeriveSha(receipts, trie.NewStackTrie(nil)); hash != header.ReceiptHash {
+		return fmt.Errorf("receipt root of block #%d mismatches: have %x, want %x", header.Number, hash, header.ReceiptHash)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/era"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that a chain exported into era archives is imported into the freezer of
+// a fresh node, and that both the export and the import resume.
+func TestExportImportEra(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}}, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(db, trie.NewDatabase(db, nil))
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 20, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	}, true)
+
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := chain.ExportEra(t.TempDir(), "test", 0, 20, 0); err == nil {
+		t.Fatalf("zero step accepted")
+	}
+	// Export a partial range first, then resume up to the head
+	dir := t.TempDir()
+	if err := chain.ExportEra(dir, "test", 0, 12, 8); err != nil {
+		t.Fatalf("failed to export chain: %v", err)
+	}
+	first, _ := era.ReadDir(dir, "test")
+	if err := chain.ExportEra(dir, "test", 0, 20, 8); err != nil {
+		t.Fatalf("failed to resume export: %v", err)
+	}
+	paths, err := era.ReadDir(dir, "test")
+	if err != nil {
+		t.Fatalf("failed to list archives: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("archive count mismatch: have %d, want 3", len(paths))
+	}
+	if paths[0] != first[0] {
+		t.Fatalf("complete archive rewritten: have %s, want %s", paths[0], first[0])
+	}
+	// Import the archives into a fresh node
+	ancientDb, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer ancientDb.Close()
+	gspec.MustCommit(ancientDb, trie.NewDatabase(ancientDb, nil))
+
+	fresh, err := NewBlockChain(ancientDb, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create fresh chain: %v", err)
+	}
+	defer fresh.Stop()
+
+	// Import the first archive alone, then all of them
+	single := t.TempDir()
+	blob, _ := os.ReadFile(paths[0])
+	os.WriteFile(filepath.Join(single, filepath.Base(paths[0])), blob, 0644)
+	if err := fresh.ImportEra(single, "test"); err != nil {
+		t.Fatalf("failed to import first archive: %v", err)
+	}
+	if err := fresh.ImportEra(dir, "test"); err != nil {
+		t.Fatalf("failed to import archives: %v", err)
+	}
+	if head := fresh.CurrentFastBlock(); head.Hash() != blocks[19].Hash() {
+		t.Fatalf("head fast block mismatch: have #%d, want #%d", head.NumberU64(), blocks[19].NumberU64())
+	}
+	if frozen, _ := ancientDb.Ancients(); frozen != 21 {
+		t.Fatalf("frozen items mismatch: have %d, want 21", frozen)
+	}
+	for _, block := range blocks {
+		if have := fresh.GetBlockByNumber(block.NumberU64()); have == nil || have.Hash() != block.Hash() {
+			t.Fatalf("block #%d mismatch", block.NumberU64())
+		}
+		receipts := fresh.GetReceiptsByHash(block.Hash())
+		if len(receipts) != 1 || receipts[0].TxHash != block.Transactions()[0].Hash() {
+			t.Fatalf("receipts of block #%d mismatch", block.NumberU64())
+		}
+	}
+	// Archives of other networks and corrupted ones must be rejected
+	if err := fresh.ImportEra(dir, "other"); err == nil {
+		t.Fatalf("import of missing network succeeded")
+	}
+	blob, _ = os.ReadFile(paths[2])
+	blob[len(blob)/2] ^= 0xff
+	corrupt := t.TempDir()
+	os.WriteFile(filepath.Join(corrupt, filepath.Base(paths[2])), blob, 0644)
+	if err := fresh.ImportEra(corrupt, "test"); err == nil {
+		t.Fatalf("corrupted archive imported")
+	}
+}
@@ -36,6 +36,29 @@ var (
 	errSideChainReceipts = errors.New("side blocks can't be accepted as ancient chain data")
 
 	ErrOutOfOrderSystemTx = errors.New("out-of-order system transaction detected")
+
+	// ErrForcedTxMismatch is returned if a block does not start with the forced
+	// transactions mandated by the consensus engine.
+	ErrForcedTxMismatch = errors.New("forced transaction mismatch")
+
+	// ErrMisplacedForcedTx is returned if a forced transaction appears past the
+	// leading forced transactions of a block.
+	ErrMisplacedForcedTx = errors.New("misplaced forced transaction")
+
+	// ErrForcedGasExceeded is returned if the forced transactions of a block
+	// claim more than their share of the block gas limit.
+	ErrForcedGasExceeded = errors.New("forced transactions exceed their gas share")
+
+	// ErrTxAlreadyIncluded is returned if a block includes a transaction already
+	// included by one of its recent ancestors, or twice.
+	ErrTxAlreadyIncluded = errors.New("transaction already included")
+
+	// ErrBlockVetoed is returned if a registered validator hook rejects a block.
+	ErrBlockVetoed = errors.New("block vetoed by validator hook")
+
+	// ErrUnclesNotAllowed is returned if a block carries uncles although its
+	// consensus engine never accepts any.
+	ErrUnclesNotAllowed = errors.New("uncles not allowed")
 )
 
 // List of evm-call-message pre-checking errors. All state transition messages will
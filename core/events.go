@@ -17,13 +17,23 @@
 package core
 
 import (
+	"math/big"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
-type NewTxsEvent struct{ Txs []*types.Transaction }
+//
+// The batches of the legacy pool are ordered by sender then nonce, never repeat
+// a transaction still pooled since announced and are numbered in sequence,
+// starting from 1. Seq is zero for the pools not numbering their batches.
+type NewTxsEvent struct {
+	Txs []*types.Transaction
+	Seq uint64 // Sequence number of the batch within the pool
+}
 
 // NewMinedBlockEvent is posted when a block has been imported.
 type NewMinedBlockEvent struct {
@@ -57,5 +67,84 @@ type ChainSideEvent struct {
 	Block *types.Block
 }
 
+// BlockDelayEvent is posted when a block arrives later than the configured
+// threshold after its expected sealing time.
+type BlockDelayEvent struct {
+	Header    *types.Header
+	Validator common.Address
+	Expected  time.Time
+	Arrived   time.Time
+	Delay     time.Duration
+}
+
+// FeeChangeEvent is posted when a new canonical head changes the base fee by
+// more than the configured threshold compared to its parent, or changes the
+// gas limit, hence the gas target, of the chain.
+type FeeChangeEvent struct {
+	Header *types.Header // Header of the new canonical block
+	Parent *types.Header // Header of its parent
+
+	BaseFeeBefore, BaseFeeAfter     *big.Int
+	GasLimitBefore, GasLimitAfter   uint64
+	GasTargetBefore, GasTargetAfter uint64
+}
+
+// SupplyViolationEvent is posted when the change of the total supply caused by
+// an imported block doesn't match the minted rewards and burned fees, or when
+// the block leaves account balances out of the valid range.
+type SupplyViolationEvent struct {
+	Block *types.Block
+
+	Expected *big.Int // Expected change of the circulating supply
+	Actual   *big.Int // Change of the circulating supply applied by the block
+	Rewards  *big.Int // Ether minted by the consensus engine
+	Burned   *big.Int // Fees not credited to any account
+	Bridged  *big.Int // Net ether locked into the bridges
+
+	Overflows []common.Address // Accounts with a negative or over 256 bit balance
+}
+
+// TxInclusionEvent is posted, in batches per block, for each transaction of a
+// block made canonical, with its execution result. On reorgs, the transactions
+// of the blocks reorged out are posted again with Removed set, before the ones
+// of the new chain.
+type TxInclusionEvent struct {
+	TxHash       common.Hash
+	BlockHash    common.Hash
+	BlockNumber  uint64
+	Index        uint     // Index of the transaction in the block
+	Status       uint64   // Receipt status, types.ReceiptStatusSuccessful if executed successfully
+	GasUsed      uint64   // Gas used by the transaction alone
+	EffectiveTip *big.Int // Tip per gas paid to the block producer
+	Removed      bool     // Whether the block was reorged out of the canonical chain
+}
+
+// TxSLAEvent is posted when a tracked local transaction breaches the inclusion
+// SLA, either by staying pending for longer or by being dropped from the pool
+// without inclusion. A pending transaction is announced at most once as overdue.
+type TxSLAEvent struct {
+	TxHash   common.Hash
+	Admitted time.Time     // Time of the admission into the pool
+	Elapsed  time.Duration // Time elapsed since the admission
+	Dropped  bool          // Whether the transaction was dropped, otherwise still pending
+}
+
 type ChainHeadEvent struct{ Block *types.Block }
+
+// FinalizedHeadEvent is posted when the fast finality votes included up to a new
+// chain head finalize a block above the last announced one. If several blocks
+// got finalized at once, only the latest one is announced.
+type FinalizedHeadEvent struct {
+	Block *types.Block  // Newly finalized block
+	Head  *types.Header // Chain head whose votes finalized the block
+}
 type ReorgEvent ChainHeadEvent
+
+// DeepReorgEvent is posted when a reorg is refused for retracting more canonical
+// blocks than the configured limit. The existing canonical chain is kept.
+type DeepReorgEvent struct {
+	OldHead *types.Header // Canonical head which was kept
+	NewHead *types.Header // Head of the refused chain
+	Depth   uint64        // Number of canonical blocks the reorg retracts, counted up to Limit+1
+	Limit   uint64        // Configured maximum reorg depth
+}
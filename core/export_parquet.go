@@ -0,0 +1,251 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/parquet"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ParquetSchemaVersion is the version of the Parquet export schema, stored in
+// the metadata of every exported file. It must be bumped whenever a column is
+// added, removed or changes meaning.
+const ParquetSchemaVersion = 1
+
+// Parquet export tables, each written into a <name>.parquet file.
+var (
+	parquetBlockColumns = []parquet.Column{
+		{Name: "number", Type: parquet.Uint64},
+		{Name: "hash", Type: parquet.Bytes},
+		{Name: "parent_hash", Type: parquet.Bytes},
+		{Name: "timestamp", Type: parquet.Uint64},
+		{Name: "miner", Type: parquet.Bytes},
+		{Name: "state_root", Type: parquet.Bytes},
+		{Name: "difficulty", Type: parquet.String},
+		{Name: "gas_limit", Type: parquet.Uint64},
+		{Name: "gas_used", Type: parquet.Uint64},
+		{Name: "base_fee", Type: parquet.String},
+		{Name: "extra_data", Type: parquet.Bytes},
+		{Name: "size", Type: parquet.Uint64},
+		{Name: "tx_count", Type: parquet.Uint64},
+	}
+	parquetTransactionColumns = []parquet.Column{
+		{Name: "block_number", Type: parquet.Uint64},
+		{Name: "block_hash", Type: parquet.Bytes},
+		{Name: "tx_index", Type: parquet.Uint64},
+		{Name: "hash", Type: parquet.Bytes},
+		{Name: "type", Type: parquet.Uint64},
+		{Name: "from", Type: parquet.Bytes},
+		{Name: "to", Type: parquet.Bytes},
+		{Name: "payer", Type: parquet.Bytes},
+		{Name: "nonce", Type: parquet.Uint64},
+		{Name: "value", Type: parquet.String},
+		{Name: "gas", Type: parquet.Uint64},
+		{Name: "gas_price", Type: parquet.String},
+		{Name: "gas_fee_cap", Type: parquet.String},
+		{Name: "gas_tip_cap", Type: parquet.String},
+		{Name: "input", Type: parquet.Bytes},
+	}
+	parquetReceiptColumns = []parquet.Column{
+		{Name: "block_number", Type: parquet.Uint64},
+		{Name: "tx_hash", Type: parquet.Bytes},
+		{Name: "tx_index", Type: parquet.Uint64},
+		{Name: "status", Type: parquet.Uint64},
+		{Name: "gas_used", Type: parquet.Uint64},
+		{Name: "cumulative_gas_used", Type: parquet.Uint64},
+		{Name: "contract_address", Type: parquet.Bytes},
+		{Name: "log_count", Type: parquet.Uint64},
+	}
+	parquetLogColumns = []parquet.Column{
+		{Name: "block_number", Type: parquet.Uint64},
+		{Name: "tx_hash", Type: parquet.Bytes},
+		{Name: "tx_index", Type: parquet.Uint64},
+		{Name: "log_index", Type: parquet.Uint64},
+		{Name: "address", Type: parquet.Bytes},
+		{Name: "topic0", Type: parquet.Bytes},
+		{Name: "topic1", Type: parquet.Bytes},
+		{Name: "topic2", Type: parquet.Bytes},
+		{Name: "topic3", Type: parquet.Bytes},
+		{Name: "data", Type: parquet.Bytes},
+	}
+)
+
+// parquetTable is a single Parquet file being exported.
+type parquetTable struct {
+	file   *os.File
+	buf    *bufio.Writer
+	writer *parquet.Writer
+}
+
+// newParquetTable creates the Parquet file of a table in the given directory.
+func newParquetTable(dir, name string, columns []parquet.Column, first, last uint64) (*parquetTable, error) {
+	file, err := os.Create(filepath.Join(dir, name+".parquet"))
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriter(file)
+	writer, err := parquet.NewWriter(buf, columns, map[string]string{
+		"ronin.schema.version": strconv.Itoa(ParquetSchemaVersion),
+		"ronin.table":          name,
+		"ronin.block.first":    strconv.FormatUint(first, 10),
+		"ronin.block.last":     strconv.FormatUint(last, 10),
+	})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &parquetTable{file: file, buf: buf, writer: writer}, nil
+}
+
+// close finalises the Parquet file and closes it.
+func (t *parquetTable) close() error {
+	defer t.file.Close()
+
+	if err := t.writer.Close(); err != nil {
+		return err
+	}
+	if err := t.buf.Flush(); err != nil {
+		return err
+	}
+	return t.file.Close()
+}
+
+// ExportParquet writes a range of the canonical chain into the given directory
+// as a set of Parquet files, one per table (blocks, transactions, receipts and
+// logs). Rows are streamed out in row groups, so arbitrarily large ranges can
+// be exported with bounded memory.
+func (bc *BlockChain) ExportParquet(dir string, first uint64, last uint64) error {
+	if !bc.chainmu.TryLock() {
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	if first > last {
+		return fmt.Errorf("export failed: first (%d) is greater than last (%d)", first, last)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	log.Info("Exporting blocks to parquet", "dir", dir, "count", last-first+1)
+
+	var (
+		names  = []string{"blocks", "transactions", "receipts", "logs"}
+		tables = make([]*parquetTable, 0, len(names))
+	)
+	for i, columns := range [][]parquet.Column{parquetBlockColumns, parquetTransactionColumns, parquetReceiptColumns, parquetLogColumns} {
+		table, err := newParquetTable(dir, names[i], columns, first, last)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	// Ensure the files are released on failure, closing twice is harmless
+	defer func() {
+		for _, table := range tables {
+			table.file.Close()
+		}
+	}()
+	blocks, txs, receipts, logs := tables[0].writer, tables[1].writer, tables[2].writer, tables[3].writer
+
+	start, reported := time.Now(), time.Now()
+	for nr := first; nr <= last; nr++ {
+		block := bc.GetBlockByNumber(nr)
+		if block == nil {
+			return fmt.Errorf("export failed on #%d: not found", nr)
+		}
+		header := block.Header()
+		if err := blocks.Write(nr, header.Hash().Bytes(), header.ParentHash.Bytes(), header.Time, header.Coinbase.Bytes(),
+			header.Root.Bytes(), bigString(header.Difficulty), header.GasLimit, header.GasUsed, bigString(header.BaseFee),
+			common.CopyBytes(header.Extra), uint64(block.Size()), uint64(len(block.Transactions()))); err != nil {
+			return err
+		}
+		blockReceipts := bc.GetReceiptsByHash(block.Hash())
+		if len(blockReceipts) != len(block.Transactions()) {
+			return fmt.Errorf("export failed on #%d: have %d receipts for %d transactions", nr, len(blockReceipts), len(block.Transactions()))
+		}
+		signer := types.MakeSigner(bc.chainConfig, block.Number())
+		for i, tx := range block.Transactions() {
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return fmt.Errorf("export failed on #%d: tx %d: %v", nr, i, err)
+			}
+			var to, payer []byte
+			if tx.To() != nil {
+				to = tx.To().Bytes()
+			}
+			if tx.Type() == types.SponsoredTxType {
+				addr, err := types.Payer(signer, tx)
+				if err != nil {
+					return fmt.Errorf("export failed on #%d: tx %d: %v", nr, i, err)
+				}
+				payer = addr.Bytes()
+			}
+			if err := txs.Write(nr, block.Hash().Bytes(), uint64(i), tx.Hash().Bytes(), uint64(tx.Type()), from.Bytes(), to, payer,
+				tx.Nonce(), bigString(tx.Value()), tx.Gas(), bigString(tx.GasPrice()), bigString(tx.GasFeeCap()), bigString(tx.GasTipCap()), tx.Data()); err != nil {
+				return err
+			}
+			receipt := blockReceipts[i]
+			var contract []byte
+			if receipt.ContractAddress != (common.Address{}) {
+				contract = receipt.ContractAddress.Bytes()
+			}
+			if err := receipts.Write(nr, tx.Hash().Bytes(), uint64(i), receipt.Status, receipt.GasUsed, receipt.CumulativeGasUsed,
+				contract, uint64(len(receipt.Logs))); err != nil {
+				return err
+			}
+			for _, l := range receipt.Logs {
+				topics := make([][]byte, 4)
+				for j := 0; j < len(l.Topics) && j < len(topics); j++ {
+					topics[j] = l.Topics[j].Bytes()
+				}
+				if err := logs.Write(nr, tx.Hash().Bytes(), uint64(i), uint64(l.Index), l.Address.Bytes(),
+					topics[0], topics[1], topics[2], topics[3], l.Data); err != nil {
+					return err
+				}
+			}
+		}
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Exporting blocks to parquet", "exported", nr-first, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	for _, table := range tables {
+		if err := table.close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bigString formats an optional big integer in decimal, returning an empty
+// string for nil.
+func bigString(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+	return n.String()
+}
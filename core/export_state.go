@@ -0,0 +1,543 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// finalizedExportRetention is the number of most recent finalized checkpoints
+// whose state is kept pinned for export, allowing a replica which started to
+// stream one checkpoint to finish even if a few newer ones get finalized.
+const finalizedExportRetention = 4
+
+// Kinds of the records of a state export stream.
+const (
+	StateExportHeader  = iota // Key: state root, Value: account hash the export starts from
+	StateExportAccount        // Key: account hash, Value: slim RLP account, Proof: optional
+	StateExportCode           // Key: code hash, Value: contract code
+	StateExportSlot           // Key: slot hash, Value: RLP slot value
+	StateExportEnd            // Key: state root, marks the export complete
+)
+
+var (
+	// ErrNoFinalizedCheckpoint is returned if the consensus engine has not
+	// finalized any block yet, so there's no trusted state to export.
+	ErrNoFinalizedCheckpoint = errors.New("no finalized checkpoint")
+
+	// ErrStateNotPinned is returned if the state of an export request is not
+	// the one of a recent finalized checkpoint.
+	ErrStateNotPinned = errors.New("state is not pinned by a finalized checkpoint")
+
+	// errNoSnapshot is returned if the state is exported without a state snapshot.
+	errNoSnapshot = errors.New("state snapshot unavailable")
+)
+
+// StateRangeItem is a single leaf of a state range, keyed by the hash of the
+// account address or of the storage slot.
+type StateRangeItem struct {
+	Hash  common.Hash   `json:"hash"`
+	Value hexutil.Bytes `json:"value"` // Consensus RLP of the account or the slot value
+}
+
+// StateRange is a chunk of the accounts or of the storage slots of an account
+// in the state of a finalized checkpoint, along with the Merkle proof of its
+// boundaries which lets the receiver verify it against the trusted root.
+type StateRange struct {
+	Root  common.Hash      `json:"root"`  // Root of the trie the range belongs to
+	Items []StateRangeItem `json:"items"` // Consecutive leaves starting at the origin
+	Proof []hexutil.Bytes  `json:"proof"` // Trie nodes proving the origin and the last item
+	More  bool             `json:"more"`  // Whether more leaves follow the last item
+}
+
+// FinalizedCheckpoint returns the header of the latest finalized block, pinning
+// its state so that it can be streamed out via ExportAccountRange and
+// ExportStorageRange until a few newer checkpoints get finalized.
+//
+// Note, pinning the state only works with the hash based trie database. With
+// the path based one, the state of a checkpoint is only available as long as
+// it is one of the recent in-memory layers.
+func (bc *BlockChain) FinalizedCheckpoint() (*types.Header, error) {
+	header := bc.CurrentFinalBlock()
+	if header == nil {
+		return nil, ErrNoFinalizedCheckpoint
+	}
+	if err := bc.pinExportCheckpoint(header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// pinExportCheckpoint keeps the state of the given checkpoint alive for export,
+// releasing the oldest checkpoints beyond the retention limit.
+func (bc *BlockChain) pinExportCheckpoint(header *types.Header) error {
+	bc.exportLock.Lock()
+	defer bc.exportLock.Unlock()
+
+	for _, pinned := range bc.exportPins {
+		if pinned.Hash() == header.Hash() {
+			return nil
+		}
+	}
+	if !bc.HasState(header.Root) {
+		return errors.New("missing state of the finalized checkpoint")
+	}
+	bc.triedb.Reference(header.Root, common.Hash{})
+	bc.exportPins = append(bc.exportPins, header)
+
+	for len(bc.exportPins) > finalizedExportRetention {
+		bc.triedb.Dereference(bc.exportPins[0].Root)
+		bc.exportPins = bc.exportPins[1:]
+	}
+	log.Debug("Pinned finalized state for export", "number", header.Number, "hash", header.Hash(), "root", header.Root)
+	return nil
+}
+
+// releaseExportPins drops the references held on the state of the finalized
+// checkpoints pinned for export.
+func (bc *BlockChain) releaseExportPins() {
+	bc.exportLock.Lock()
+	defer bc.exportLock.Unlock()
+
+	for _, pinned := range bc.exportPins {
+		bc.triedb.Dereference(pinned.Root)
+	}
+	bc.exportPins = nil
+}
+
+// pinnedExportRoot checks whether the given state root belongs to one of the
+// finalized checkpoints pinned for export.
+func (bc *BlockChain) pinnedExportRoot(root common.Hash) bool {
+	bc.exportLock.Lock()
+	defer bc.exportLock.Unlock()
+
+	for _, pinned := range bc.exportPins {
+		if pinned.Root == root {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportAccountRange returns at most max accounts following the given origin in
+// the state of a pinned finalized checkpoint.
+func (bc *BlockChain) ExportAccountRange(root common.Hash, origin common.Hash, max int) (*StateRange, error) {
+	if !bc.pinnedExportRoot(root) {
+		return nil, ErrStateNotPinned
+	}
+	tr, err := trie.New(trie.StateTrieID(root), bc.triedb)
+	if err != nil {
+		return nil, err
+	}
+	return exportRange(tr, root, origin, max)
+}
+
+// ExportStorageRange returns at most max storage slots of the given account,
+// following the given origin in the state of a pinned finalized checkpoint.
+func (bc *BlockChain) ExportStorageRange(root common.Hash, account common.Hash, origin common.Hash, max int) (*StateRange, error) {
+	if !bc.pinnedExportRoot(root) {
+		return nil, ErrStateNotPinned
+	}
+	tr, err := trie.New(trie.StateTrieID(root), bc.triedb)
+	if err != nil {
+		return nil, err
+	}
+	blob, err := tr.TryGet(account[:])
+	if err != nil {
+		return nil, err
+	}
+	var storageRoot = types.EmptyRootHash
+	if len(blob) > 0 {
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(blob, &acc); err != nil {
+			return nil, err
+		}
+		storageRoot = acc.Root
+	}
+	st, err := trie.New(trie.StorageTrieID(root, account, storageRoot), bc.triedb)
+	if err != nil {
+		return nil, err
+	}
+	return exportRange(st, storageRoot, origin, max)
+}
+
+// exportRange collects at most max leaves of the trie starting at the given
+// origin, proving the boundaries of the range.
+func exportRange(tr *trie.Trie, root common.Hash, origin common.Hash, max int) (*StateRange, error) {
+	nodeIt, err := tr.NodeIterator(origin[:])
+	if err != nil {
+		return nil, err
+	}
+	var (
+		it     = trie.NewIterator(nodeIt)
+		result = &StateRange{Root: root}
+	)
+	for it.Next() {
+		if len(result.Items) >= max {
+			result.More = true
+			break
+		}
+		result.Items = append(result.Items, StateRangeItem{
+			Hash:  common.BytesToHash(it.Key),
+			Value: common.CopyBytes(it.Value),
+		})
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	// Prove the origin and the last leaf, so that the range can be verified to
+	// be complete, i.e. that no leaf in between was omitted.
+	proof := make(rangeProof)
+	if err := tr.Prove(origin[:], 0, proof); err != nil {
+		return nil, err
+	}
+	if n := len(result.Items); n > 0 {
+		if err := tr.Prove(result.Items[n-1].Hash[:], 0, proof); err != nil {
+			return nil, err
+		}
+	}
+	result.Proof = proof.list()
+	return result, nil
+}
+
+// rangeProof collects the deduplicated trie nodes of a range proof.
+type rangeProof map[common.Hash][]byte
+
+func (p rangeProof) Put(key []byte, value []byte) error {
+	p[crypto.Keccak256Hash(value)] = common.CopyBytes(value)
+	return nil
+}
+
+func (p rangeProof) Delete(key []byte) error {
+	panic("not supported")
+}
+
+func (p rangeProof) list() []hexutil.Bytes {
+	nodes := make([]hexutil.Bytes, 0, len(p))
+	for _, node := range p {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// StateExportRecord is a record of a state export stream.
+//
+// The accounts are exported in the order of their hashes, each one followed by
+// its code, the first time the code is met, and its storage slots in the order
+// of their hashes. An interrupted export can thus be resumed from the last
+// account whose records are not known to be complete.
+type StateExportRecord struct {
+	Kind  uint8
+	Key   common.Hash
+	Value []byte
+	Proof [][]byte `rlp:"optional"` // Merkle proof of the account against the state root
+}
+
+// ExportState writes a dump of the whole state of the given root to the given
+// writer, see ExportStateFrom.
+func (bc *BlockChain) ExportState(w io.Writer, root common.Hash) error {
+	return bc.ExportStateFrom(w, root, common.Hash{}, false)
+}
+
+// ExportStateFrom writes a dump of the state of the given root to the given
+// writer as a stream of RLP encoded records, starting from the account of the
+// given hash. The state is streamed out of the snapshot layers, never loaded in
+// memory. The Merkle proofs of the accounts are included if requested.
+func (bc *BlockChain) ExportStateFrom(w io.Writer, root common.Hash, start common.Hash, proofs bool) error {
+	if bc.snaps == nil {
+		return errNoSnapshot
+	}
+	accIt, err := bc.snaps.AccountIterator(root, start)
+	if err != nil {
+		return err
+	}
+	defer accIt.Release()
+
+	var tr state.Trie
+	if proofs {
+		if tr, err = bc.stateCache.OpenTrie(root); err != nil {
+			return err
+		}
+	}
+	if err := rlp.Encode(w, &StateExportRecord{Kind: StateExportHeader, Key: root, Value: start.Bytes()}); err != nil {
+		return err
+	}
+	var (
+		codes = make(map[common.Hash]struct{})
+
+		accounts, slots int
+		begin, reported = time.Now(), time.Now()
+	)
+	for accIt.Next() {
+		hash := accIt.Hash()
+		n, err := bc.exportAccount(w, root, tr, hash, accIt.Account(), codes)
+		if err != nil {
+			return err
+		}
+		accounts++
+		slots += n
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Exporting state", "root", root, "at", hash, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(begin)))
+			reported = time.Now()
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return err
+	}
+	log.Info("Exported state", "root", root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(begin)))
+	return rlp.Encode(w, &StateExportRecord{Kind: StateExportEnd, Key: root})
+}
+
+// ExportContracts writes a dump of the given accounts in the state of the given
+// root to the given writer, in the format of ExportStateFrom with the Merkle
+// proofs of the accounts included. It allows moving the full state of a few
+// contracts into another environment, verifiable by VerifyStateExport.
+func (bc *BlockChain) ExportContracts(w io.Writer, root common.Hash, addrs []common.Address) error {
+	if bc.snaps == nil {
+		return errNoSnapshot
+	}
+	snap := bc.snaps.Snapshot(root)
+	if snap == nil {
+		return fmt.Errorf("missing snapshot of state %x", root)
+	}
+	tr, err := bc.stateCache.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	// The accounts of a stream are in the order of their hashes
+	hashes := make([]common.Hash, 0, len(addrs))
+	for _, addr := range addrs {
+		hashes = append(hashes, crypto.Keccak256Hash(addr.Bytes()))
+	}
+	sort.Slice(hashes, func(i, j int) bool { return bytes.Compare(hashes[i][:], hashes[j][:]) < 0 })
+
+	if err := rlp.Encode(w, &StateExportRecord{Kind: StateExportHeader, Key: root}); err != nil {
+		return err
+	}
+	codes := make(map[common.Hash]struct{})
+	for i, hash := range hashes {
+		if i > 0 && hash == hashes[i-1] {
+			continue
+		}
+		blob, err := snap.AccountRLP(hash)
+		if err != nil {
+			return err
+		}
+		if len(blob) == 0 {
+			return fmt.Errorf("account %x not found", hash)
+		}
+		if _, err := bc.exportAccount(w, root, tr, hash, blob, codes); err != nil {
+			return err
+		}
+	}
+	return rlp.Encode(w, &StateExportRecord{Kind: StateExportEnd, Key: root})
+}
+
+// exportAccount writes an account to the given writer along with its Merkle
+// proof if a trie is given, its code unless already exported and its storage
+// slots, returning their number.
+func (bc *BlockChain) exportAccount(w io.Writer, root common.Hash, tr state.Trie, hash common.Hash, blob []byte, codes map[common.Hash]struct{}) (int, error) {
+	record := &StateExportRecord{Kind: StateExportAccount, Key: hash, Value: blob}
+	if tr != nil {
+		proof := make(rangeProof)
+		if err := tr.Prove(hash[:], 0, proof); err != nil {
+			return 0, err
+		}
+		for _, node := range proof.list() {
+			record.Proof = append(record.Proof, node)
+		}
+	}
+	if err := rlp.Encode(w, record); err != nil {
+		return 0, err
+	}
+	account, err := types.FullAccount(blob)
+	if err != nil {
+		return 0, fmt.Errorf("invalid account %x: %w", hash, err)
+	}
+	// Export the code the first time it is met
+	codeHash := common.BytesToHash(account.CodeHash)
+	if _, ok := codes[codeHash]; !ok && codeHash != emptyCodeHash {
+		code, err := bc.stateCache.ContractCode(hash, codeHash)
+		if err != nil {
+			return 0, fmt.Errorf("missing code %x of account %x: %w", codeHash, hash, err)
+		}
+		if err := rlp.Encode(w, &StateExportRecord{Kind: StateExportCode, Key: codeHash, Value: code}); err != nil {
+			return 0, err
+		}
+		codes[codeHash] = struct{}{}
+	}
+	if account.Root == types.EmptyRootHash {
+		return 0, nil
+	}
+	return bc.exportStorage(w, root, hash)
+}
+
+// VerifyStateExport checks a state export stream with the Merkle proofs of its
+// accounts included: the accounts must be proven against the root of the
+// stream, and their code and storage must match the hashes committed to by
+// them. Resumed exports appended to the interrupted ones are accepted, as long
+// as they leave no gap. The root of the verified state is returned.
+func VerifyStateExport(r io.Reader) (common.Hash, error) {
+	var (
+		stream = rlp.NewStream(r, 0)
+		header StateExportRecord
+	)
+	if err := stream.Decode(&header); err != nil {
+		return common.Hash{}, err
+	}
+	if header.Kind != StateExportHeader {
+		return common.Hash{}, fmt.Errorf("unexpected record kind %d, want header", header.Kind)
+	}
+	var (
+		root    = header.Key
+		codes   = make(map[common.Hash]struct{})
+		wanted  = make(map[common.Hash]struct{})   // Code hashes used by the accounts but not yet exported
+		last    = common.BytesToHash(header.Value) // Lower bound of the next account
+		reached = true                             // Whether the next account may equal the bound
+		account *types.StateAccount
+		storage *trie.StackTrie
+	)
+	// finish checks the storage of the last account once all its slots are read
+	finish := func() error {
+		if account == nil {
+			return nil
+		}
+		if hash := storage.Hash(); hash != account.Root {
+			return fmt.Errorf("account %x: storage root mismatch: have %x, want %x", last, hash, account.Root)
+		}
+		return nil
+	}
+	for {
+		var record StateExportRecord
+		if err := stream.Decode(&record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return common.Hash{}, errors.New("state export incomplete")
+			}
+			return common.Hash{}, err
+		}
+		switch record.Kind {
+		case StateExportHeader:
+			// The records of the account an export was interrupted at are
+			// exported again by the resumed one
+			start := common.BytesToHash(record.Value)
+			if record.Key != root {
+				return common.Hash{}, fmt.Errorf("resumed root mismatch: have %x, want %x", record.Key, root)
+			}
+			if bytes.Compare(start[:], last[:]) > 0 {
+				return common.Hash{}, fmt.Errorf("export resumed at %x, beyond %x", start, last)
+			}
+			last, reached, account = start, true, nil
+
+		case StateExportAccount:
+			if err := finish(); err != nil {
+				return common.Hash{}, err
+			}
+			if cmp := bytes.Compare(record.Key[:], last[:]); cmp < 0 || (cmp == 0 && !reached) {
+				return common.Hash{}, fmt.Errorf("account %x out of order", record.Key)
+			}
+			full, err := types.FullAccountRLP(record.Value)
+			if err != nil {
+				return common.Hash{}, fmt.Errorf("account %x: %w", record.Key, err)
+			}
+			proof := memorydb.New()
+			for _, node := range record.Proof {
+				proof.Put(crypto.Keccak256(node), node)
+			}
+			blob, err := trie.VerifyProof(root, record.Key[:], proof)
+			if err != nil {
+				return common.Hash{}, fmt.Errorf("account %x: %w", record.Key, err)
+			}
+			if !bytes.Equal(blob, full) {
+				return common.Hash{}, fmt.Errorf("account %x not proven", record.Key)
+			}
+			if account, err = types.FullAccount(record.Value); err != nil {
+				return common.Hash{}, fmt.Errorf("account %x: %w", record.Key, err)
+			}
+			if codeHash := common.BytesToHash(account.CodeHash); codeHash != emptyCodeHash {
+				if _, ok := codes[codeHash]; !ok {
+					wanted[codeHash] = struct{}{}
+				}
+			}
+			last, reached, storage = record.Key, false, trie.NewStackTrie(nil)
+
+		case StateExportCode:
+			hash := crypto.Keccak256Hash(record.Value)
+			if hash != record.Key {
+				return common.Hash{}, fmt.Errorf("code %x: hash mismatch: have %x", record.Key, hash)
+			}
+			delete(wanted, hash)
+			codes[hash] = struct{}{}
+
+		case StateExportSlot:
+			if account == nil {
+				return common.Hash{}, fmt.Errorf("slot %x without account", record.Key)
+			}
+			if err := storage.TryUpdate(record.Key[:], record.Value); err != nil {
+				return common.Hash{}, err
+			}
+
+		case StateExportEnd:
+			if err := finish(); err != nil {
+				return common.Hash{}, err
+			}
+			if record.Key != root {
+				return common.Hash{}, fmt.Errorf("end root mismatch: have %x, want %x", record.Key, root)
+			}
+			if len(wanted) > 0 {
+				return common.Hash{}, fmt.Errorf("%d codes missing", len(wanted))
+			}
+			return root, nil
+
+		default:
+			return common.Hash{}, fmt.Errorf("unexpected record kind %d", record.Kind)
+		}
+	}
+}
+
+// exportStorage writes the storage slots of an account to the given writer,
+// returning their number.
+func (bc *BlockChain) exportStorage(w io.Writer, root common.Hash, account common.Hash) (int, error) {
+	it, err := bc.snaps.StorageIterator(root, account, common.Hash{})
+	if err != nil {
+		return 0, err
+	}
+	defer it.Release()
+
+	var slots int
+	for it.Next() {
+		if err := rlp.Encode(w, &StateExportRecord{Kind: StateExportSlot, Key: it.Hash(), Value: it.Slot()}); err != nil {
+			return slots, err
+		}
+		slots++
+	}
+	return slots, it.Error()
+}
@@ -0,0 +1,394 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the state of a pinned checkpoint can be streamed out in proven
+// ranges which add up to the entire state.
+func TestExportStateRanges(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		alloc    = make(GenesisAlloc)
+		contract = common.Address{0xff}
+		storage  = make(map[common.Hash]common.Hash)
+	)
+	for i := 0; i < 10; i++ {
+		alloc[common.Address{byte(i + 1)}] = GenesisAccount{Balance: big.NewInt(int64(i + 1))}
+		storage[common.Hash{byte(i + 1)}] = common.Hash{byte(i + 1)}
+	}
+	alloc[contract] = GenesisAccount{Balance: big.NewInt(1), Code: []byte{0x00}, Storage: storage}
+
+	gspec := &Genesis{Config: params.TestChainConfig, Alloc: alloc, BaseFee: big.NewInt(params.InitialBaseFee)}
+	genesis := gspec.MustCommit(db, trie.NewDatabase(db, nil))
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, b *BlockGen) {}, true)
+
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	head := chain.CurrentBlock().Header()
+	if _, err := chain.ExportAccountRange(head.Root, common.Hash{}, 3); !errors.Is(err, ErrStateNotPinned) {
+		t.Fatalf("unpinned export error mismatch: have %v, want %v", err, ErrStateNotPinned)
+	}
+	if err := chain.pinExportCheckpoint(head); err != nil {
+		t.Fatalf("failed to pin checkpoint: %v", err)
+	}
+	accounts := streamStateRange(t, head.Root, func(origin common.Hash) (*StateRange, error) {
+		return chain.ExportAccountRange(head.Root, origin, 3)
+	})
+	// The allocated accounts are accompanied by the rewarded coinbase
+	if len(accounts) != len(alloc)+1 {
+		t.Fatalf("account count mismatch: have %d, want %d", len(accounts), len(alloc)+1)
+	}
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(accounts[crypto.Keccak256Hash(contract[:])], &account); err != nil {
+		t.Fatalf("failed to decode contract account: %v", err)
+	}
+	slots := streamStateRange(t, account.Root, func(origin common.Hash) (*StateRange, error) {
+		return chain.ExportStorageRange(head.Root, crypto.Keccak256Hash(contract[:]), origin, 3)
+	})
+	if len(slots) != len(storage) {
+		t.Fatalf("slot count mismatch: have %d, want %d", len(slots), len(storage))
+	}
+}
+
+// streamStateRange retrieves and verifies all the ranges of a trie, returning
+// the leaves keyed by their hash.
+func streamStateRange(t *testing.T, root common.Hash, fetch func(origin common.Hash) (*StateRange, error)) map[common.Hash][]byte {
+	leaves := make(map[common.Hash][]byte)
+
+	var origin common.Hash
+	for {
+		result, err := fetch(origin)
+		if err != nil {
+			t.Fatalf("failed to export range at %x: %v", origin, err)
+		}
+		proof := memorydb.New()
+		for _, node := range result.Proof {
+			proof.Put(crypto.Keccak256(node), node)
+		}
+		var keys, values [][]byte
+		for _, item := range result.Items {
+			keys = append(keys, common.CopyBytes(item.Hash[:]))
+			values = append(values, item.Value)
+			leaves[item.Hash] = item.Value
+		}
+		var last []byte
+		if len(keys) > 0 {
+			last = keys[len(keys)-1]
+		}
+		more, err := trie.VerifyRangeProof(root, origin[:], last, keys, values, proof)
+		if err != nil {
+			t.Fatalf("invalid range at %x: %v", origin, err)
+		}
+		if more != result.More {
+			t.Fatalf("continuation flag mismatch at %x: have %v, want %v", origin, result.More, more)
+		}
+		if !result.More {
+			return leaves
+		}
+		origin = common.BigToHash(new(big.Int).Add(new(big.Int).SetBytes(last), common.Big1))
+	}
+}
+
+// readStateExport decodes all the records of a state export stream.
+func readStateExport(t *testing.T, r io.Reader) []*StateExportRecord {
+	var (
+		stream  = rlp.NewStream(r, 0)
+		records []*StateExportRecord
+	)
+	for {
+		record := new(StateExportRecord)
+		if err := stream.Decode(record); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records
+			}
+			t.Fatalf("failed to decode record %d: %v", len(records), err)
+		}
+		records = append(records, record)
+	}
+}
+
+// newStateExportChain creates a chain whose state has a few accounts, two of
+// them sharing a contract code and one of them having storage.
+func newStateExportChain(t *testing.T) (*BlockChain, common.Address, []*types.Block) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		// PUSH1 1 NUMBER SSTORE: stores 1 at the slot of the block number
+		code  = common.FromHex("0x6001435500")
+		alloc = GenesisAlloc{
+			addr:              {Balance: big.NewInt(params.Ether)},
+			common.Address{1}: {Code: code, Balance: common.Big0},
+			common.Address{2}: {Code: code, Balance: common.Big0},
+		}
+		gspec = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   alloc,
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, func(i int, b *BlockGen) {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: b.TxNonce(addr), To: &common.Address{1}, Gas: 50000, GasPrice: b.header.BaseFee})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return chain, addr, blocks
+}
+
+// Tests that the state exported out of the snapshot contains all the accounts,
+// codes and storage slots of the state, in order, and that an export can be
+// resumed from any account.
+func TestExportState(t *testing.T) {
+	chain, _, blocks := newStateExportChain(t)
+	defer chain.Stop()
+
+	root := chain.CurrentBlock().Root()
+
+	var buf bytes.Buffer
+	if err := chain.ExportStateFrom(&buf, root, common.Hash{}, true); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	records := readStateExport(t, &buf)
+	if len(records) < 2 {
+		t.Fatalf("too few records: %d", len(records))
+	}
+	if head := records[0]; head.Kind != StateExportHeader || head.Key != root {
+		t.Fatalf("header mismatch: have %d/%x, want %d/%x", head.Kind, head.Key, StateExportHeader, root)
+	}
+	if end := records[len(records)-1]; end.Kind != StateExportEnd || end.Key != root {
+		t.Fatalf("end mismatch: have %d/%x, want %d/%x", end.Kind, end.Key, StateExportEnd, root)
+	}
+	var (
+		accounts []common.Hash
+		codes    int
+		slots    = make(map[common.Hash]int)
+		last     common.Hash
+	)
+	for i, record := range records[1 : len(records)-1] {
+		switch record.Kind {
+		case StateExportAccount:
+			if len(accounts) > 0 && bytes.Compare(record.Key[:], accounts[len(accounts)-1][:]) <= 0 {
+				t.Errorf("record %d: account %x out of order", i, record.Key)
+			}
+			accounts = append(accounts, record.Key)
+			last = record.Key
+
+			proofDb := memorydb.New()
+			for _, node := range record.Proof {
+				proofDb.Put(crypto.Keccak256(node), node)
+			}
+			if _, err := trie.VerifyProof(root, record.Key[:], proofDb); err != nil {
+				t.Errorf("record %d: invalid proof of account %x: %v", i, record.Key, err)
+			}
+		case StateExportCode:
+			if record.Key != crypto.Keccak256Hash(record.Value) {
+				t.Errorf("record %d: code hash mismatch", i)
+			}
+			codes++
+		case StateExportSlot:
+			slots[last]++
+		default:
+			t.Fatalf("record %d: unexpected kind %d", i, record.Kind)
+		}
+	}
+	// The sender, the two contracts and the coinbase
+	if len(accounts) != 4 {
+		t.Errorf("account count mismatch: have %d, want %d", len(accounts), 4)
+	}
+	if codes != 1 {
+		t.Errorf("code count mismatch: have %d, want %d", codes, 1)
+	}
+	if have := slots[crypto.Keccak256Hash(common.Address{1}.Bytes())]; have != len(blocks) {
+		t.Errorf("slot count mismatch: have %d, want %d", have, len(blocks))
+	}
+	// Resuming from an account should export it and all the following ones
+	buf.Reset()
+	if err := chain.ExportStateFrom(&buf, root, accounts[2], false); err != nil {
+		t.Fatalf("failed to resume state export: %v", err)
+	}
+	var resumed []common.Hash
+	for _, record := range readStateExport(t, &buf) {
+		if record.Kind == StateExportAccount {
+			if len(record.Proof) != 0 {
+				t.Errorf("unexpected proof of account %x", record.Key)
+			}
+			resumed = append(resumed, record.Key)
+		}
+	}
+	if len(resumed) != 2 || resumed[0] != accounts[2] || resumed[1] != accounts[3] {
+		t.Errorf("resumed accounts mismatch: have %x, want %x", resumed, accounts[2:])
+	}
+}
+
+// Tests that the exports of a set of contracts contain them only, and that the
+// streams are verified against their state root.
+func TestExportContracts(t *testing.T) {
+	chain, addr, blocks := newStateExportChain(t)
+	defer chain.Stop()
+
+	root := chain.CurrentBlock().Root()
+
+	// A full export with the proofs is verifiable too
+	var buf bytes.Buffer
+	if err := chain.ExportStateFrom(&buf, root, common.Hash{}, true); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	if have, err := VerifyStateExport(&buf); err != nil || have != root {
+		t.Fatalf("failed to verify state export: root %x, err %v", have, err)
+	}
+	buf.Reset()
+	if err := chain.ExportContracts(&buf, root, []common.Address{addr, {1}, addr}); err != nil {
+		t.Fatalf("failed to export contracts: %v", err)
+	}
+	blob := common.CopyBytes(buf.Bytes())
+	if have, err := VerifyStateExport(bytes.NewReader(blob)); err != nil || have != root {
+		t.Fatalf("failed to verify contracts export: root %x, err %v", have, err)
+	}
+	var accounts, codes, slots int
+	for _, record := range readStateExport(t, bytes.NewReader(blob)) {
+		switch record.Kind {
+		case StateExportAccount:
+			accounts++
+		case StateExportCode:
+			codes++
+		case StateExportSlot:
+			slots++
+		}
+	}
+	if accounts != 2 || codes != 1 || slots != len(blocks) {
+		t.Fatalf("export mismatch: have %d accounts/%d codes/%d slots, want 2/1/%d", accounts, codes, slots, len(blocks))
+	}
+	// Tampered or truncated streams must be rejected
+	records := readStateExport(t, bytes.NewReader(blob))
+	tamper := func(name string, change func([]*StateExportRecord) []*StateExportRecord) {
+		var buf bytes.Buffer
+		for _, record := range change(readStateExport(t, bytes.NewReader(blob))) {
+			rlp.Encode(&buf, record)
+		}
+		if _, err := VerifyStateExport(&buf); err == nil {
+			t.Errorf("%s export verified", name)
+		}
+	}
+	for i, record := range records {
+		i := i
+		switch record.Kind {
+		case StateExportCode:
+			tamper("tampered code", func(records []*StateExportRecord) []*StateExportRecord {
+				records[i].Value[0]++
+				return records
+			})
+			tamper("codeless", func(records []*StateExportRecord) []*StateExportRecord {
+				return append(records[:i], records[i+1:]...)
+			})
+		case StateExportSlot:
+			tamper("tampered storage", func(records []*StateExportRecord) []*StateExportRecord {
+				records[i].Value = []byte{0x02}
+				return records
+			})
+			tamper("truncated storage", func(records []*StateExportRecord) []*StateExportRecord {
+				return append(records[:i], records[i+1:]...)
+			})
+		}
+	}
+	tamper("unterminated", func(records []*StateExportRecord) []*StateExportRecord {
+		return records[:len(records)-1]
+	})
+	// Exporting an unknown account must fail
+	if err := chain.ExportContracts(io.Discard, root, []common.Address{{0xff}}); err == nil {
+		t.Fatalf("exported unknown account")
+	}
+}
+
+// Tests that the exports resumed after an interruption are verified along with
+// the interrupted ones they are appended to, unless they leave a gap.
+func TestVerifyResumedStateExport(t *testing.T) {
+	chain, _, _ := newStateExportChain(t)
+	defer chain.Stop()
+
+	root := chain.CurrentBlock().Root()
+
+	var full bytes.Buffer
+	if err := chain.ExportStateFrom(&full, root, common.Hash{}, true); err != nil {
+		t.Fatalf("failed to export state: %v", err)
+	}
+	var (
+		records  = readStateExport(t, bytes.NewReader(full.Bytes()))
+		accounts []int
+	)
+	for i, record := range records {
+		if record.Kind == StateExportAccount {
+			accounts = append(accounts, i)
+		}
+	}
+	// Interrupt the export right after the second account and resume from it
+	// or from the third one
+	interrupted := func() *bytes.Buffer {
+		buf := new(bytes.Buffer)
+		for _, record := range records[:accounts[1]+1] {
+			rlp.Encode(buf, record)
+		}
+		return buf
+	}
+	buf := interrupted()
+	if err := chain.ExportStateFrom(buf, root, records[accounts[1]].Key, true); err != nil {
+		t.Fatalf("failed to resume state export: %v", err)
+	}
+	if have, err := VerifyStateExport(buf); err != nil || have != root {
+		t.Fatalf("failed to verify resumed export: root %x, err %v", have, err)
+	}
+	buf = interrupted()
+	if err := chain.ExportStateFrom(buf, root, records[accounts[2]].Key, true); err != nil {
+		t.Fatalf("failed to resume state export: %v", err)
+	}
+	if _, err := VerifyStateExport(buf); err == nil {
+		t.Fatalf("export resumed beyond the interruption verified")
+	}
+}
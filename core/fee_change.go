@@ -0,0 +1,90 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BaseFeeChange returns the relative change of the base fee in percent. It is
+// zero if either base fee is missing or the parent one is zero.
+func (ev *FeeChangeEvent) BaseFeeChange() float64 {
+	if ev.BaseFeeBefore == nil || ev.BaseFeeAfter == nil || ev.BaseFeeBefore.Sign() == 0 {
+		return 0
+	}
+	delta := new(big.Float).SetInt(new(big.Int).Sub(ev.BaseFeeAfter, ev.BaseFeeBefore))
+	change, _ := delta.Quo(delta, new(big.Float).SetInt(ev.BaseFeeBefore)).Float64()
+	return change * 100
+}
+
+// baseFeeChanged reports whether the base fee moved from before to after by
+// more than threshold percent. A base fee appearing or disappearing is always
+// a material change.
+func baseFeeChanged(before, after *big.Int, threshold uint64) bool {
+	before, after = baseFeeOrZero(before), baseFeeOrZero(after)
+	if before.Sign() == 0 {
+		return after.Sign() != 0
+	}
+	delta := new(big.Int).Sub(after, before)
+	delta.Abs(delta).Mul(delta, big.NewInt(100))
+	return delta.Cmp(new(big.Int).Mul(before, new(big.Int).SetUint64(threshold))) > 0
+}
+
+func baseFeeOrZero(fee *big.Int) *big.Int {
+	if fee == nil {
+		return new(big.Int)
+	}
+	return fee
+}
+
+// sendFeeChangeEvent fires a FeeChangeEvent if the given new canonical head
+// changed the gas limit, or the base fee by more than the configured threshold,
+// compared to its parent. Zero disables the base fee checks.
+func (bc *BlockChain) sendFeeChangeEvent(block *types.Block) {
+	if block.NumberU64() == 0 {
+		return
+	}
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return
+	}
+	header := block.Header()
+
+	threshold := bc.cacheConfig.FeeChangeThreshold
+	feeChanged := threshold > 0 && baseFeeChanged(parent.BaseFee, header.BaseFee, threshold)
+	if !feeChanged && parent.GasLimit == header.GasLimit {
+		return
+	}
+	ev := FeeChangeEvent{
+		Header:          header,
+		Parent:          parent,
+		BaseFeeBefore:   parent.BaseFee,
+		BaseFeeAfter:    header.BaseFee,
+		GasLimitBefore:  parent.GasLimit,
+		GasLimitAfter:   header.GasLimit,
+		GasTargetBefore: parent.GasLimit / params.ElasticityMultiplier,
+		GasTargetAfter:  header.GasLimit / params.ElasticityMultiplier,
+	}
+	log.Debug("Fee parameters changed", "number", header.Number, "hash", block.Hash(),
+		"basefee", header.BaseFee, "prevbasefee", parent.BaseFee, "gaslimit", header.GasLimit, "prevgaslimit", parent.GasLimit)
+
+	bc.feeChangeFeed.Send(ev)
+}
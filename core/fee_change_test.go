@@ -0,0 +1,174 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the material base fee changes and the gas limit changes of the
+// new canonical heads are reported.
+func TestFeeChangeEvents(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig
+		gspec  = &Genesis{
+			Config:   &config,
+			GasLimit: 100000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+			Alloc:    GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(&config)
+	)
+	config.VenokiBlock = common.Big0
+
+	// Fill the first block above its gas target, bumping the base fee of the
+	// second one, which falls back to the minimum in the third one. The fourth
+	// block raises the gas limit.
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, b *BlockGen) {
+		switch i {
+		case 0:
+			for nonce := uint64(0); nonce < 4; nonce++ {
+				tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+				b.AddTx(tx)
+			}
+		case 3:
+			b.header.GasLimit += 50
+		}
+	})
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.FeeChangeThreshold = 1
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan FeeChangeEvent, 10)
+	sub := chain.SubscribeFeeChangeEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	want := []struct {
+		number          uint64
+		feeUp, feeDown  bool
+		gasTargetBefore uint64
+		gasTargetAfter  uint64
+	}{
+		{number: 2, feeUp: true, gasTargetBefore: 50000, gasTargetAfter: 50000},
+		{number: 3, feeDown: true, gasTargetBefore: 50000, gasTargetAfter: 50000},
+		{number: 4, gasTargetBefore: 50000, gasTargetAfter: 50025},
+	}
+	for i, want := range want {
+		select {
+		case ev := <-events:
+			if ev.Header.Number.Uint64() != want.number {
+				t.Fatalf("event %d: number mismatch: have %d, want %d", i, ev.Header.Number, want.number)
+			}
+			change := ev.BaseFeeChange()
+			if (change > 1) != want.feeUp || (change < -1) != want.feeDown {
+				t.Errorf("event %d: base fee change mismatch: have %.2f%%, up %v, down %v", i, change, want.feeUp, want.feeDown)
+			}
+			if ev.GasTargetBefore != want.gasTargetBefore || ev.GasTargetAfter != want.gasTargetAfter {
+				t.Errorf("event %d: gas target mismatch: have %d->%d, want %d->%d", i, ev.GasTargetBefore, ev.GasTargetAfter, want.gasTargetBefore, want.gasTargetAfter)
+			}
+		default:
+			t.Fatalf("event %d: block %d not reported", i, want.number)
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected fee change event: number %d", ev.Header.Number)
+	default:
+	}
+}
+
+// Tests that the fee changes of the blocks brought in by a reorg are reported.
+func TestFeeChangeEventsReorg(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig
+		gspec  = &Genesis{
+			Config:   &config,
+			GasLimit: 100000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+			Alloc:    GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(&config)
+	)
+	config.VenokiBlock = common.Big0
+
+	// The side chain fills its first block above the gas target, bumping the
+	// base fee of its second block, and takes over with its third one.
+	genDb, canonical, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+	side, _ := GenerateChain(&config, gspec.ToBlock(), ethash.NewFaker(), genDb, 3, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0x02})
+		if i == 0 {
+			for nonce := uint64(0); nonce < 4; nonce++ {
+				tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+				b.AddTx(tx)
+			}
+		}
+	}, true)
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.FeeChangeThreshold = 1
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(canonical, nil); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	events := make(chan FeeChangeEvent, 10)
+	sub := chain.SubscribeFeeChangeEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(side, nil); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+	if head := chain.CurrentBlock().Hash(); head != side[2].Hash() {
+		t.Fatalf("side chain not adopted: have %x, want %x", head, side[2].Hash())
+	}
+	reported := make(map[common.Hash]bool)
+	for len(events) > 0 {
+		reported[(<-events).Header.Hash()] = true
+	}
+	for _, block := range side[1:] {
+		if !reported[block.Hash()] {
+			t.Errorf("block %d: fee change not reported", block.NumberU64())
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/consortium/v2/finality"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// laggingFinalityEngine is a fast finality engine finalizing the canonical block
+// a fixed distance behind the queried one.
+type laggingFinalityEngine struct {
+	consensus.Engine
+	lag uint64
+}
+
+func (e *laggingFinalityEngine) IsSystemTransaction(tx *types.Transaction, header *types.Header) (bool, error) {
+	return false, nil
+}
+
+func (e *laggingFinalityEngine) IsSystemContract(to *common.Address) bool { return false }
+
+func (e *laggingFinalityEngine) GetJustifiedBlock(chain consensus.ChainHeaderReader, number uint64, hash common.Hash) (uint64, common.Hash) {
+	return 0, common.Hash{}
+}
+
+func (e *laggingFinalityEngine) GetFinalizedBlock(chain consensus.ChainHeaderReader, number uint64, hash common.Hash) (uint64, common.Hash) {
+	if number <= e.lag {
+		return 0, common.Hash{}
+	}
+	header := chain.GetHeaderByNumber(number - e.lag)
+	return header.Number.Uint64(), header.Hash()
+}
+
+func (e *laggingFinalityEngine) IsFinalityVoterAt(chain consensus.ChainHeaderReader, header *types.Header) bool {
+	return false
+}
+
+func (e *laggingFinalityEngine) VerifyVote(chain consensus.ChainHeaderReader, vote *types.VoteEnvelope) error {
+	return nil
+}
+
+func (e *laggingFinalityEngine) SetVotePool(votePool consensus.VotePool) {}
+
+func (e *laggingFinalityEngine) GetFinalityVoterAt(chain consensus.ChainHeaderReader, number uint64, hash common.Hash) []finality.ValidatorWithBlsPub {
+	return nil
+}
+
+// Tests that a finalized head event is fired when the new chain head finalizes
+// a block above the last announced one.
+func TestFinalizedHeadEvent(t *testing.T) {
+	var (
+		genDb   = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+		engine  = &laggingFinalityEngine{Engine: ethash.NewFaker(), lag: 2}
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, genDb, 6, func(i int, b *BlockGen) {}, true)
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan FinalizedHeadEvent, 10)
+	sub := chain.SubscribeFinalizedHeadEvent(events)
+	defer sub.Unsubscribe()
+
+	tests := []struct {
+		blocks []*types.Block
+		final  uint64 // Announced finalized block, 0 if none
+		head   uint64
+	}{
+		{blocks[:2], 0, 0},  // Nothing finalized yet
+		{blocks[2:5], 3, 5}, // Only the latest of the blocks finalized at once
+		{blocks[5:], 4, 6},
+		{blocks[5:], 0, 0}, // Known blocks do not progress the finality
+	}
+	for i, tt := range tests {
+		if _, err := chain.InsertChain(tt.blocks, nil); err != nil {
+			t.Fatalf("test %d: failed to insert chain: %v", i, err)
+		}
+		select {
+		case ev := <-events:
+			if tt.final == 0 {
+				t.Fatalf("test %d: unexpected finalized head event: number %d", i, ev.Block.NumberU64())
+			}
+			if ev.Block.NumberU64() != tt.final || ev.Block.Hash() != blocks[tt.final-1].Hash() {
+				t.Errorf("test %d: finalized block mismatch: have %d, want %d", i, ev.Block.NumberU64(), tt.final)
+			}
+			if ev.Head.Number.Uint64() != tt.head {
+				t.Errorf("test %d: head mismatch: have %d, want %d", i, ev.Head.Number, tt.head)
+			}
+		default:
+			if tt.final != 0 {
+				t.Fatalf("test %d: finalized head not announced", i)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// forcedGasDivisor bounds the gas the forced transactions of a block may claim
+// to a share of its gas limit, so that they never starve the other ones.
+const forcedGasDivisor = 2
+
+// ForcedGasLimit returns the maximum total gas limit of the forced transactions
+// of a block with the given gas limit.
+func ForcedGasLimit(gasLimit uint64) uint64 {
+	return gasLimit / forcedGasDivisor
+}
+
+// verifyForcedTransactions checks that a block starts with the forced
+// transactions mandated by the consensus engine, within their gas share, and
+// that none appears past them. The rules only apply to the engines forcing
+// transactions, the blocks of the others are left untouched.
+func verifyForcedTransactions(engine consensus.Engine, chain consensus.ChainHeaderReader, block *types.Block) error {
+	forcer, ok := engine.(consensus.ForcedTransactor)
+	if !ok {
+		return nil
+	}
+	header := block.Header()
+	forced, err := forcer.ForcedTransactions(chain, header)
+	if err != nil {
+		return err
+	}
+	txs := block.Transactions()
+	if len(txs) < len(forced) {
+		return fmt.Errorf("%w: have %d transactions, want at least %d", ErrForcedTxMismatch, len(txs), len(forced))
+	}
+	var gas uint64
+	for i, tx := range forced {
+		if txs[i].Hash() != tx.Hash() {
+			return fmt.Errorf("%w: index %d: have %x, want %x", ErrForcedTxMismatch, i, txs[i].Hash(), tx.Hash())
+		}
+		gas += tx.Gas()
+	}
+	if limit := ForcedGasLimit(header.GasLimit); gas > limit {
+		return fmt.Errorf("%w: have %d, limit %d", ErrForcedGasExceeded, gas, limit)
+	}
+	for i := len(forced); i < len(txs); i++ {
+		if forcer.IsForcedTransaction(txs[i], header) {
+			return fmt.Errorf("%w: index %d, hash %x", ErrMisplacedForcedTx, i, txs[i].Hash())
+		}
+	}
+	return nil
+}
+
+// markForcedReceipts flags the receipts read back from the database whose
+// transactions were forced into the block by the consensus engine, the first
+// one belonging to the transaction at the given index. The flag isn't stored
+// with the receipts, it's derived from the engine the same way as on import.
+func (bc *BlockChain) markForcedReceipts(hash common.Hash, number uint64, first int, receipts types.Receipts) {
+	forcer, ok := bc.engine.(consensus.ForcedTransactor)
+	if !ok {
+		return
+	}
+	block := bc.GetBlock(hash, number)
+	if block == nil {
+		return
+	}
+	txs := block.Transactions()
+	for i, receipt := range receipts {
+		if first+i < len(txs) {
+			receipt.Forced = forcer.IsForcedTransaction(txs[first+i], block.Header())
+		}
+	}
+}
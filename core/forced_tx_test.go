@@ -0,0 +1,178 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// bridgeEngine is a consensus engine forcing the transactions of a bridge
+// account at the start of the blocks.
+type bridgeEngine struct {
+	consensus.Engine
+	bridge common.Address
+	signer types.Signer
+	forced map[uint64][]*types.Transaction
+}
+
+func (e *bridgeEngine) ForcedTransactions(chain consensus.ChainHeaderReader, header *types.Header) ([]*types.Transaction, error) {
+	return e.forced[header.Number.Uint64()], nil
+}
+
+func (e *bridgeEngine) IsForcedTransaction(tx *types.Transaction, header *types.Header) bool {
+	from, err := types.Sender(e.signer, tx)
+	return err == nil && from == e.bridge
+}
+
+// Tests that the blocks must start with the forced transactions of the engine,
+// within their gas share, and that they are marked in their receipts, including
+// the ones read back from the database.
+func TestForcedTransactions(t *testing.T) {
+	var (
+		bridgeKey, _ = crypto.GenerateKey()
+		userKey, _   = crypto.GenerateKey()
+		bridge       = crypto.PubkeyToAddress(bridgeKey.PublicKey)
+		user         = crypto.PubkeyToAddress(userKey.PublicKey)
+		recipient    = common.Address{0xaa}
+		gspec        = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: GenesisAlloc{
+				bridge: {Balance: big.NewInt(params.Ether)},
+				user:   {Balance: big.NewInt(params.Ether)},
+			},
+		}
+		signer = types.LatestSigner(gspec.Config)
+		engine = &bridgeEngine{Engine: ethash.NewFaker(), bridge: bridge, signer: signer, forced: make(map[uint64][]*types.Transaction)}
+	)
+	transfer := func(key *ecdsa.PrivateKey, nonce uint64, baseFee *big.Int) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: nonce, To: &recipient, Value: big.NewInt(1), Gas: params.TxGas, GasPrice: baseFee})
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		return tx
+	}
+	makeChain := func(gen func(i int, b *BlockGen)) []*types.Block {
+		genDb := rawdb.NewMemoryDatabase()
+		genesis := gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+		blocks, _ := GenerateChain(gspec.Config, genesis, engine, genDb, 2, gen, true)
+		return blocks
+	}
+	// Generate a valid chain, forcing a bridge transaction into the first block
+	blocks := makeChain(func(i int, b *BlockGen) {
+		if i == 0 {
+			forced := transfer(bridgeKey, 0, b.header.BaseFee)
+			engine.forced[b.header.Number.Uint64()] = []*types.Transaction{forced}
+			b.AddTx(forced)
+		}
+		b.AddTx(transfer(userKey, b.TxNonce(user), b.header.BaseFee))
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	statedb, err := chain.StateAt(chain.Genesis().Root())
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	receipts, _, _, _, err := chain.Processor().Process(blocks[0], statedb, vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to process block: %v", err)
+	}
+	if !receipts[0].Forced || receipts[1].Forced {
+		t.Errorf("forced receipt marks mismatch: have %v/%v, want true/false", receipts[0].Forced, receipts[1].Forced)
+	}
+	chain.receiptsCache.Purge()
+	if receipts := chain.GetReceiptsByHash(blocks[0].Hash()); len(receipts) != 2 || !receipts[0].Forced || receipts[1].Forced {
+		t.Errorf("stored forced receipt marks mismatch: %v", receipts)
+	}
+	for i, tx := range blocks[0].Transactions() {
+		if receipt, _, _, _ := chain.GetCanonicalReceipt(tx.Hash()); receipt == nil || receipt.Forced != (i == 0) {
+			t.Errorf("canonical receipt %d: forced mark mismatch: %v", i, receipt)
+		}
+	}
+
+	// Blocks not starting with their forced transactions are rejected
+	tests := []struct {
+		name string
+		gen  func(i int, b *BlockGen)
+		err  error
+	}{
+		{
+			name: "missing",
+			gen: func(i int, b *BlockGen) {
+				engine.forced[b.header.Number.Uint64()] = []*types.Transaction{transfer(bridgeKey, 0, b.header.BaseFee)}
+				b.AddTx(transfer(userKey, 0, b.header.BaseFee))
+			},
+			err: ErrForcedTxMismatch,
+		},
+		{
+			name: "misplaced",
+			gen: func(i int, b *BlockGen) {
+				b.AddTx(transfer(userKey, 0, b.header.BaseFee))
+				b.AddTx(transfer(bridgeKey, 0, b.header.BaseFee))
+			},
+			err: ErrMisplacedForcedTx,
+		},
+		{
+			name: "oversized",
+			gen: func(i int, b *BlockGen) {
+				tx, err := types.SignNewTx(bridgeKey, signer, &types.LegacyTx{To: &recipient, Gas: ForcedGasLimit(b.header.GasLimit) + 1, GasPrice: b.header.BaseFee})
+				if err != nil {
+					t.Fatalf("failed to sign tx: %v", err)
+				}
+				engine.forced[b.header.Number.Uint64()] = []*types.Transaction{tx}
+				b.AddTx(tx)
+			},
+			err: ErrForcedGasExceeded,
+		},
+	}
+	for _, tt := range tests {
+		engine.forced = make(map[uint64][]*types.Transaction)
+		blocks := makeChain(func(i int, b *BlockGen) {
+			if i == 0 {
+				tt.gen(i, b)
+			}
+		})
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("%s: failed to create tester chain: %v", tt.name, err)
+		}
+		if _, err := chain.InsertChain(blocks, nil); !errors.Is(err, tt.err) {
+			t.Errorf("%s: insertion error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+		chain.Stop()
+	}
+}
@@ -19,6 +19,7 @@ package forkid
 import (
 	"bytes"
 	"math"
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -136,7 +137,7 @@ func TestCreation(t *testing.T) {
 	}
 	for i, tt := range tests {
 		for j, ttt := range tt.cases {
-			if have := NewID(tt.config, tt.genesis, ttt.head); have != ttt.want {
+			if have := NewID(tt.config, tt.genesis, 0, ttt.head, 0); have != ttt.want {
 				t.Errorf("test %d, case %d: fork ID mismatch: have %x, want %x", i, j, have, ttt.want)
 			}
 		}
@@ -218,13 +219,48 @@ func TestValidation(t *testing.T) {
 		{7279999, ID{Hash: checksumToBytes(0xa00bc324), Next: 7279999}, ErrLocalIncompatibleOrStale},
 	}
 	for i, tt := range tests {
-		filter := newFilter(params.MainnetChainConfig, params.MainnetGenesisHash, func() uint64 { return tt.head })
+		filter := newFilter(params.MainnetChainConfig, params.MainnetGenesisHash, 0, func() (uint64, uint64) { return tt.head, 0 })
 		if err := filter(tt.id); err != tt.err {
 			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
 		}
 	}
 }
 
+// Tests that forks scheduled by timestamp follow the block based ones in the
+// fork ID, and are validated against the timestamp of the head.
+func TestTimestampForks(t *testing.T) {
+	var (
+		genesis    = common.HexToHash("0x01")
+		pragueTime = uint64(1700000000)
+		config     = &params.ChainConfig{HomesteadBlock: big.NewInt(10), PragueTime: &pragueTime}
+	)
+	var (
+		preFork  = NewID(config, genesis, 1600000000, 20, pragueTime-1)
+		postFork = NewID(config, genesis, 1600000000, 20, pragueTime)
+	)
+	if preFork.Next != pragueTime {
+		t.Fatalf("next fork mismatch: have %d, want %d", preFork.Next, pragueTime)
+	}
+	if postFork.Next != 0 || postFork.Hash == preFork.Hash {
+		t.Fatalf("fork ID not updated by timestamp fork: pre %v, post %v", preFork, postFork)
+	}
+	// Forks scheduled before genesis are part of the genesis ruleset
+	if id := NewID(config, genesis, pragueTime, 20, pragueTime); id.Next != 0 || id.Hash == postFork.Hash {
+		t.Fatalf("fork before genesis mismatch: have %v", id)
+	}
+	// A node past the fork rejects peers not aware of it, but accepts synced ones
+	filter := newFilter(config, genesis, 1600000000, func() (uint64, uint64) { return 20, pragueTime })
+	if err := filter(ID{Hash: preFork.Hash, Next: 0}); err != ErrRemoteStale {
+		t.Fatalf("stale remote error mismatch: have %v, want %v", err, ErrRemoteStale)
+	}
+	if err := filter(preFork); err != nil {
+		t.Fatalf("syncing remote rejected: %v", err)
+	}
+	if err := filter(postFork); err != nil {
+		t.Fatalf("synced remote rejected: %v", err)
+	}
+}
+
 // Tests that IDs are properly RLP encoded (specifically important because we
 // use uint32 to store the hash, but we need to encode it as [4]byte).
 func TestEncoding(t *testing.T) {
@@ -330,8 +330,12 @@ func SetupGenesisBlockWithOverride(db ethdb.Database, triedb *trie.Database, gen
 	if height == nil {
 		return newcfg, stored, fmt.Errorf("missing block number for head header hash")
 	}
-	compatErr := storedcfg.CheckCompatible(newcfg, *height)
-	if compatErr != nil && *height != 0 && compatErr.RewindTo != 0 {
+	head := rawdb.ReadHeader(db, rawdb.ReadHeadHeaderHash(db), *height)
+	if head == nil {
+		return newcfg, stored, fmt.Errorf("missing head header")
+	}
+	compatErr := storedcfg.CheckCompatible(newcfg, *height, head.Time)
+	if compatErr != nil && ((*height != 0 && compatErr.RewindTo != 0) || (head.Time != 0 && compatErr.RewindToTime != 0)) {
 		return newcfg, stored, compatErr
 	}
 	rawdb.WriteChainConfig(db, stored, newcfg)
@@ -438,6 +442,10 @@ func (g *Genesis) Commit(db ethdb.Database, triedb *trie.Database) (*types.Block
 	if err := g.Alloc.flush(db, triedb); err != nil {
 		return nil, err
 	}
+	// A fresh database has no blocks written before the block hash index
+	if rawdb.ReadCanonicalHash(db, 0) == (common.Hash{}) {
+		rawdb.WriteBlockHashIndexDone(db)
+	}
 	rawdb.WriteTd(db, block.Hash(), block.NumberU64(), block.Difficulty())
 	rawdb.WriteBlock(db, block)
 	rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
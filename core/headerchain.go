@@ -71,6 +71,8 @@ type HeaderChain struct {
 	tdCache     *lru.Cache[common.Hash, *big.Int]      // Cache for the most recent block total difficulties
 	numberCache *lru.Cache[common.Hash, uint64]        // Cache for the most recent block numbers
 
+	frozenTd atomic.Pointer[big.Int] // Total difficulty of all blocks after the finality-only fork
+
 	procInterrupt func() bool
 
 	rand   *mrand.Rand
@@ -115,9 +117,67 @@ func NewHeaderChain(chainDb ethdb.Database, config *params.ChainConfig, engine c
 	hc.currentHeaderHash = hc.CurrentHeader().Hash()
 	headHeaderGauge.Update(hc.CurrentHeader().Number.Int64())
 
+	// Mark the block from which total difficulties are not stored anymore, so
+	// that the freezer can carry the frozen value over into the ancient store.
+	if freeze := hc.tdFreezeNumber(); freeze != math.MaxUint64 {
+		if stored := rawdb.ReadTdFreezeBlock(chainDb); stored == nil || *stored != freeze {
+			rawdb.WriteTdFreezeBlock(chainDb, freeze)
+		}
+	}
 	return hc, nil
 }
 
+// tdFreezeNumber returns the number of the first block whose total difficulty
+// is not tracked anymore, or math.MaxUint64 if the finality-only fork is not
+// scheduled. The genesis total difficulty is always stored.
+func (hc *HeaderChain) tdFreezeNumber() uint64 {
+	if hc.config.FinalityOnlyBlock == nil {
+		return math.MaxUint64
+	}
+	if freeze := hc.config.FinalityOnlyBlock.Uint64(); freeze > 0 {
+		return freeze
+	}
+	return 1
+}
+
+// tdFrozen returns whether the total difficulty of the given block number is
+// frozen, i.e. it equals the one of the last block before the finality-only fork.
+func (hc *HeaderChain) tdFrozen(number uint64) bool {
+	return number >= hc.tdFreezeNumber()
+}
+
+// nextTd returns the total difficulty of the given header on top of its parent
+// total difficulty, stopping the accumulation after the finality-only fork.
+func (hc *HeaderChain) nextTd(ptd *big.Int, header *types.Header) *big.Int {
+	if hc.tdFrozen(header.Number.Uint64()) {
+		return new(big.Int).Set(ptd)
+	}
+	return new(big.Int).Add(ptd, header.Difficulty)
+}
+
+// writeTd stores the total difficulty of a block unless it is frozen.
+func (hc *HeaderChain) writeTd(db ethdb.KeyValueWriter, hash common.Hash, number uint64, td *big.Int) {
+	if hc.tdFrozen(number) {
+		return
+	}
+	rawdb.WriteTd(db, hash, number, td)
+	hc.tdCache.Add(hash, new(big.Int).Set(td))
+}
+
+// getFrozenTd retrieves the total difficulty shared by all blocks after the
+// finality-only fork, which is the one of the last canonical block before it.
+func (hc *HeaderChain) getFrozenTd() *big.Int {
+	if td := hc.frozenTd.Load(); td != nil {
+		return td
+	}
+	number := hc.tdFreezeNumber() - 1
+	td := rawdb.ReadTd(hc.chainDb, rawdb.ReadCanonicalHash(hc.chainDb, number), number)
+	if td != nil {
+		hc.frozenTd.Store(td)
+	}
+	return td
+}
+
 // GetBlockNumber retrieves the block number belonging to the given hash
 // from the cache or database
 func (hc *HeaderChain) GetBlockNumber(hash common.Hash) *uint64 {
@@ -179,15 +239,14 @@ func (hc *HeaderChain) writeHeaders(headers []*types.Header) (result *headerWrit
 			hash = header.Hash()
 		}
 		number := header.Number.Uint64()
-		newTD.Add(newTD, header.Difficulty)
+		newTD = hc.nextTd(newTD, header)
 
 		// If the parent was not present, store it
 		// If the header is already known, skip it, otherwise store
 		alreadyKnown := parentKnown && hc.HasHeader(hash, number)
 		if !alreadyKnown {
 			// Irrelevant of the canonical status, write the TD and header to the database.
-			rawdb.WriteTd(batch, hash, number, newTD)
-			hc.tdCache.Add(hash, new(big.Int).Set(newTD))
+			hc.writeTd(batch, hash, number, newTD)
 
 			rawdb.WriteHeader(batch, header)
 			inserted = append(inserted, numberHash{number, hash})
@@ -221,7 +280,10 @@ func (hc *HeaderChain) writeHeaders(headers []*types.Header) (result *headerWrit
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
 	reorg := newTD.Cmp(localTD) > 0
-	if !reorg && newTD.Cmp(localTD) == 0 {
+	if hc.tdFrozen(lastNumber) || hc.tdFrozen(head) {
+		// Total difficulty is not tracked anymore, prefer the longer chain
+		reorg = lastNumber > head || (lastNumber == head && lastHash != hc.currentHeaderHash && mrand.Float64() < 0.5)
+	} else if !reorg && newTD.Cmp(localTD) == 0 {
 		if lastNumber < head {
 			reorg = true
 		} else if lastNumber == head {
@@ -442,6 +504,14 @@ func (hc *HeaderChain) GetTd(hash common.Hash, number uint64) *big.Int {
 	if td, ok := hc.tdCache.Get(hash); ok {
 		return td
 	}
+	// Total difficulties after the finality-only fork are not stored, every
+	// known block shares the frozen one.
+	if hc.tdFrozen(number) {
+		if !hc.HasHeader(hash, number) {
+			return nil
+		}
+		return hc.getFrozenTd()
+	}
 	td := rawdb.ReadTd(hc.chainDb, hash, number)
 	if td == nil {
 		return nil
@@ -610,6 +680,7 @@ func (hc *HeaderChain) SetHead(head uint64, updateFn UpdateHeadBlocksCallback, d
 	// Clear out any stale content from the caches
 	hc.headerCache.Purge()
 	hc.tdCache.Purge()
+	hc.frozenTd.Store(nil)
 	hc.numberCache.Purge()
 }
 
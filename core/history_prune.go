@@ -0,0 +1,148 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// historyPruneBatch is the number of blocks the history tail must lag behind the
+// retention window before a round of pruning, to prune the blocks in batches.
+const historyPruneBatch = 1024
+
+// HistoryPrunedError is returned when the body or the receipts of a block below
+// the history tail, or within a range pruned on request, are requested.
+type HistoryPrunedError struct {
+	Number uint64 // Number of the block requested
+	Tail   uint64 // Number of the first block whose history is kept past it
+}
+
+func (e *HistoryPrunedError) Error() string {
+	return fmt.Sprintf("history of block %d pruned, available from block %d", e.Number, e.Tail)
+}
+
+// ErrorCode returns the JSON error code of the pruned history, the one used by
+// the other clients expiring the history.
+func (e *HistoryPrunedError) ErrorCode() int { return 4444 }
+
+// HistoryTail returns the number of the first block whose body and receipts are
+// kept, those of the blocks below it but the genesis being pruned.
+func (bc *BlockChain) HistoryTail() uint64 {
+	return bc.historyTail.Load()
+}
+
+// HistoryPruned returns a HistoryPrunedError if the body or the receipts of the
+// block of the given number were pruned, nil otherwise.
+func (bc *BlockChain) HistoryPruned(number uint64) error {
+	if number == 0 {
+		return nil
+	}
+	if tail := max(bc.historyTail.Load(), rawdb.FrozenHistoryTail(bc.db)); number < tail {
+		return &HistoryPrunedError{Number: number, Tail: tail}
+	}
+	for _, r := range rawdb.ReadPrunedRanges(bc.db) {
+		if number >= r.From && number <= r.To {
+			return &HistoryPrunedError{Number: number, Tail: r.To + 1}
+		}
+	}
+	return nil
+}
+
+// pruneHistory prunes the bodies and receipts of the blocks beyond the retention
+// window behind the given head, never above the finalized block. The frozen
+// blocks are truncated from the freezer tail, after their transaction lookups
+// are removed. The blocks not frozen yet are pruned as a range, frozen as empty
+// items later on.
+func (bc *BlockChain) pruneHistory(head uint64) error {
+	retain := bc.cacheConfig.HistoryPrune
+	if head < retain {
+		return nil
+	}
+	cutoff := min(head-retain+1, bc.pruneLimit())
+
+	tail := bc.historyTail.Load()
+	if cutoff < tail+historyPruneBatch {
+		return nil
+	}
+	var (
+		start     = time.Now()
+		frozen, _ = bc.db.Ancients()
+	)
+	if end := min(cutoff, frozen); tail < end {
+		if err := bc.pruneFrozenHistory(tail, end); err != nil {
+			if errors.Is(err, errChainStopped) {
+				return nil
+			}
+			return err
+		}
+	}
+	if from := max(tail, frozen, 1); from < cutoff {
+		if err := bc.PruneRange(from, cutoff-1, HistoryBodies|HistoryReceipts); err != nil {
+			return err
+		}
+	}
+	rawdb.WriteHistoryTail(bc.db, cutoff)
+	bc.historyTail.Store(cutoff)
+
+	log.Info("Pruned block history", "from", tail, "to", cutoff, "retained", retain, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// historyPruneLoop prunes the block history beyond the retention window as the
+// chain head progresses, in the background.
+func (bc *BlockChain) historyPruneLoop() {
+	defer bc.wg.Done()
+
+	var (
+		done   chan struct{}                  // Non-nil if a background pruning is active
+		headCh = make(chan ChainHeadEvent, 1) // Buffered to avoid locking up the event feed
+	)
+	sub := bc.SubscribeChainHeadEvent(headCh)
+	if sub == nil {
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case head := <-headCh:
+			if done == nil {
+				done = make(chan struct{})
+				go func(number uint64, done chan struct{}) {
+					defer close(done)
+					if err := bc.pruneHistory(number); err != nil {
+						log.Warn("Failed to prune block history", "head", number, "err", err)
+					}
+				}(head.Block.NumberU64(), done)
+			}
+		case <-done:
+			done = nil
+		case <-bc.quit:
+			if done != nil {
+				log.Info("Waiting background history pruning to exit")
+				<-done
+			}
+			return
+		}
+	}
+}
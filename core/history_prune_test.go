@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the history beyond the retention window is pruned, from the freezer
+// tail for the frozen blocks and from the key-value store for the others, the
+// headers and the genesis being kept across restarts.
+func TestHistoryPrune(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		engine = &laggingFinalityEngine{Engine: ethash.NewFaker(), lag: 4}
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 2*historyPruneBatch, func(i int, b *BlockGen) {
+		if i%16 == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with freezer: %v", err)
+	}
+	defer db.Close()
+
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.HistoryPrune = historyPruneBatch / 2
+
+	chain, err := NewBlockChain(db, config, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Freeze half of the chain, the pruning then spans both stores
+	head := uint64(len(blocks))
+	db.(interface{ Freeze(uint64) error }).Freeze(historyPruneBatch)
+	if frozen, _ := db.Ancients(); frozen != head-historyPruneBatch+1 {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, head-historyPruneBatch+1)
+	}
+	if err := chain.pruneHistory(head); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	tail := head - config.HistoryPrune + 1
+	if have := chain.HistoryTail(); have != tail {
+		t.Fatalf("history tail mismatch: have %d, want %d", have, tail)
+	}
+	check := func(chain *BlockChain) {
+		t.Helper()
+
+		if chain.GetBlockByNumber(0) == nil {
+			t.Errorf("genesis block missing")
+		}
+		for _, block := range blocks {
+			number := block.NumberU64()
+			pruned := number < tail
+
+			if chain.GetHeaderByNumber(number) == nil {
+				t.Errorf("block %d: header missing", number)
+			}
+			if have := chain.GetBlockByNumber(number) == nil; have != pruned {
+				t.Errorf("block %d: body pruned mismatch: have %v, want %v", number, have, pruned)
+			}
+			if have := chain.GetReceiptsByHash(block.Hash()) == nil; have != pruned {
+				t.Errorf("block %d: receipts pruned mismatch: have %v, want %v", number, have, pruned)
+			}
+			var perr *HistoryPrunedError
+			if have := errors.As(chain.HistoryPruned(number), &perr); have != pruned {
+				t.Errorf("block %d: pruned error mismatch: have %v, want %v", number, have, pruned)
+			}
+			for _, tx := range block.Transactions() {
+				if have := rawdb.ReadTxLookupEntry(db, tx.Hash()) == nil; have != pruned {
+					t.Errorf("block %d: transaction lookup pruned mismatch: have %v, want %v", number, have, pruned)
+				}
+			}
+		}
+	}
+	check(chain)
+
+	// Nothing is pruned until the window moved by a whole batch
+	if err := chain.pruneHistory(head + historyPruneBatch - 1); err != nil {
+		t.Fatalf("failed to prune history: %v", err)
+	}
+	if have := chain.HistoryTail(); have != tail {
+		t.Fatalf("history tail moved: have %d, want %d", have, tail)
+	}
+	chain.Stop()
+
+	// The history tail must survive a restart, the pruned blocks being frozen
+	db.(interface{ Freeze(uint64) error }).Freeze(config.HistoryPrune / 2)
+	if frozen, _ := db.Ancients(); frozen <= tail {
+		t.Fatalf("pruned blocks not frozen: frozen %d, tail %d", frozen, tail)
+	}
+	chain, err = NewBlockChain(db, config, gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to recreate tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if have := chain.HistoryTail(); have != tail {
+		t.Fatalf("history tail mismatch after restart: have %d, want %d", have, tail)
+	}
+	check(chain)
+}
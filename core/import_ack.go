@@ -0,0 +1,174 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	// importAckLimit is the number of recent import acknowledgments retained.
+	importAckLimit = 4096
+
+	// importAckQueue is the number of acknowledgments awaiting their signature
+	// above which new ones are dropped rather than holding up the chain.
+	importAckQueue = 256
+)
+
+// importAckDomain prefixes the data the acknowledgments are signed over, so
+// that their signatures can't be passed off as made over other data by the
+// same validator key.
+var importAckDomain = []byte("ronin-import-ack")
+
+// ImportSignerFn signs the keccak256 hash of the given data with the key of the
+// local validator.
+type ImportSignerFn func(data []byte) ([]byte, error)
+
+// ImportAck is a signed acknowledgment of the local node importing a block and
+// making it canonical, proving the delivery of the block to the signer and its
+// propagation latency.
+type ImportAck struct {
+	Hash       common.Hash    `json:"hash"`
+	Number     uint64         `json:"number"`
+	ReceivedAt uint64         `json:"receivedAt"` // Unix milliseconds the block arrived from the network (0 = sealed locally)
+	ImportedAt uint64         `json:"importedAt"` // Unix milliseconds the block became canonical
+	Signer     common.Address `json:"signer"`
+	Signature  hexutil.Bytes  `json:"signature"`
+}
+
+// signingData returns the data the acknowledgment signature is made over: the
+// domain tag followed by the encoded acknowledgment.
+func (ack *ImportAck) signingData() []byte {
+	data, _ := rlp.EncodeToBytes([]interface{}{ack.Hash, ack.Number, ack.ReceivedAt, ack.ImportedAt, ack.Signer})
+	return append(common.CopyBytes(importAckDomain), data...)
+}
+
+// Verify checks that the acknowledgment is signed by its signer.
+func (ack *ImportAck) Verify() error {
+	pubkey, err := crypto.SigToPub(crypto.Keccak256(ack.signingData()), ack.Signature)
+	if err != nil {
+		return err
+	}
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != ack.Signer {
+		return errors.New("import acknowledgment signer mismatch")
+	}
+	return nil
+}
+
+// importAckRequest is an acknowledgment awaiting its signature.
+type importAckRequest struct {
+	ack    *ImportAck
+	signFn ImportSignerFn
+}
+
+// importAcks signs and retains the acknowledgments of the recent canonical
+// blocks.
+type importAcks struct {
+	signer  common.Address
+	signFn  ImportSignerFn
+	acks    *lru.Cache[common.Hash, *ImportAck]
+	pending chan importAckRequest // Acknowledgments awaiting their signature
+	lock    sync.RWMutex
+}
+
+// SetImportSigner enables the signed acknowledgments of the blocks imported and
+// made canonical from now on, signed by the given validator key.
+func (bc *BlockChain) SetImportSigner(signer common.Address, signFn ImportSignerFn) {
+	bc.importAcks.lock.Lock()
+	defer bc.importAcks.lock.Unlock()
+
+	if bc.importAcks.acks == nil {
+		bc.importAcks.acks, _ = lru.New[common.Hash, *ImportAck](importAckLimit)
+		bc.importAcks.pending = make(chan importAckRequest, importAckQueue)
+
+		bc.wg.Add(1)
+		go bc.importAckLoop(bc.importAcks.pending, bc.importAcks.acks)
+	}
+	bc.importAcks.signer, bc.importAcks.signFn = signer, signFn
+}
+
+// ackImport queues the acknowledgment of a block becoming canonical for its
+// signature, if enabled. It's called with the chain held, the signer may be an
+// external one taking its time.
+func (bc *BlockChain) ackImport(block *types.Block) {
+	bc.importAcks.lock.RLock()
+	signer, signFn, pending := bc.importAcks.signer, bc.importAcks.signFn, bc.importAcks.pending
+	bc.importAcks.lock.RUnlock()
+
+	if signFn == nil {
+		return
+	}
+	ack := &ImportAck{
+		Hash:       block.Hash(),
+		Number:     block.NumberU64(),
+		ImportedAt: uint64(time.Now().UnixMilli()),
+		Signer:     signer,
+	}
+	if !block.ReceivedAt.IsZero() {
+		ack.ReceivedAt = uint64(block.ReceivedAt.UnixMilli())
+	}
+	select {
+	case pending <- importAckRequest{ack: ack, signFn: signFn}:
+	default:
+		log.Warn("Dropped block import acknowledgment, signer lagging", "number", ack.Number, "hash", ack.Hash)
+	}
+}
+
+// importAckLoop signs the queued acknowledgments and retains them.
+func (bc *BlockChain) importAckLoop(pending chan importAckRequest, acks *lru.Cache[common.Hash, *ImportAck]) {
+	defer bc.wg.Done()
+
+	for {
+		select {
+		case req := <-pending:
+			sig, err := req.signFn(req.ack.signingData())
+			if err != nil {
+				log.Warn("Failed to sign block import acknowledgment", "number", req.ack.Number, "hash", req.ack.Hash, "err", err)
+				continue
+			}
+			req.ack.Signature = sig
+			acks.Add(req.ack.Hash, req.ack)
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// GetImportAck retrieves the signed acknowledgment of a block imported and made
+// canonical by the local node, or nil if there's none. An error is returned if
+// the acknowledgments are disabled.
+func (bc *BlockChain) GetImportAck(hash common.Hash) (*ImportAck, error) {
+	bc.importAcks.lock.RLock()
+	acks := bc.importAcks.acks
+	bc.importAcks.lock.RUnlock()
+
+	if acks == nil {
+		return nil, errors.New("import acknowledgments disabled")
+	}
+	ack, _ := acks.Get(hash)
+	return ack, nil
+}
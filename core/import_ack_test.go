@@ -0,0 +1,103 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the blocks made canonical are acknowledged with a verifiable
+// signature once enabled.
+func TestImportAcks(t *testing.T) {
+	var (
+		genDb   = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+		key, _  = crypto.GenerateKey()
+		signer  = crypto.PubkeyToAddress(key.PublicKey)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), genDb, 4, nil, true)
+	received := time.Now().Add(-time.Second)
+	blocks[2].ReceivedAt = received
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.GetImportAck(blocks[0].Hash()); err == nil {
+		t.Fatalf("acknowledgment retrieved while disabled")
+	}
+	// Blocks imported before enabling the acknowledgments are not signed
+	if _, err := chain.InsertChain(blocks[:1], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.SetImportSigner(signer, func(data []byte) ([]byte, error) {
+		return crypto.Sign(crypto.Keccak256(data), key)
+	})
+	if _, err := chain.InsertChain(blocks[1:], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if ack, err := chain.GetImportAck(blocks[0].Hash()); err != nil || ack != nil {
+		t.Fatalf("unexpected acknowledgment of block imported while disabled: %v, err %v", ack, err)
+	}
+	for _, block := range blocks[1:] {
+		// The acknowledgments are signed in the background
+		var ack *ImportAck
+		for i := 0; i < 100 && ack == nil; i++ {
+			if ack, err = chain.GetImportAck(block.Hash()); err != nil {
+				t.Fatalf("block %d: failed to retrieve acknowledgment: %v", block.NumberU64(), err)
+			}
+			if ack == nil {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		if ack == nil {
+			t.Fatalf("block %d: missing acknowledgment", block.NumberU64())
+		}
+		if ack.Number != block.NumberU64() || ack.Signer != signer || ack.ImportedAt == 0 {
+			t.Fatalf("block %d: acknowledgment mismatch: %+v", block.NumberU64(), ack)
+		}
+		if err := ack.Verify(); err != nil {
+			t.Fatalf("block %d: failed to verify acknowledgment: %v", block.NumberU64(), err)
+		}
+	}
+	ack, _ := chain.GetImportAck(blocks[2].Hash())
+	if ack.ReceivedAt != uint64(received.UnixMilli()) {
+		t.Fatalf("arrival time mismatch: have %d, want %d", ack.ReceivedAt, received.UnixMilli())
+	}
+	// The signature is made over the domain tagged acknowledgment only
+	if sig, _ := crypto.Sign(crypto.Keccak256(ack.signingData()[len(importAckDomain):]), key); bytes.Equal(sig, ack.Signature) {
+		t.Fatalf("acknowledgment signed without its domain tag")
+	}
+	// Tampering with the acknowledgment must invalidate it
+	ack.ImportedAt++
+	if err := ack.Verify(); err == nil {
+		t.Fatalf("tampered acknowledgment verified")
+	}
+}
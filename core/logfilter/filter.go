@@ -0,0 +1,379 @@
+// Copyright 2014 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package logfilter implements the address and topic based log search over the
+// canonical chain, bounding the resources every query may consume.
+package logfilter
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	// ErrBlockRangeLimit is returned if a range query spans more blocks than permitted.
+	ErrBlockRangeLimit = errors.New("filter block range is higher than the limit")
+
+	// ErrResultLimit is returned if a query matches more logs than permitted.
+	ErrResultLimit = errors.New("filter results are higher than the limit")
+
+	// ErrQueryTimeout is returned if a query does not finish within its deadline.
+	ErrQueryTimeout = errors.New("filter query timed out")
+
+	// errUnknownBlock is returned if the block of a single block query is not found.
+	errUnknownBlock = errors.New("unknown block")
+)
+
+var (
+	queryMeter    = metrics.NewRegisteredMeter("logfilter/queries", nil)
+	blockMeter    = metrics.NewRegisteredMeter("logfilter/blocks", nil)
+	receiptMeter  = metrics.NewRegisteredMeter("logfilter/receipts", nil)
+	logMeter      = metrics.NewRegisteredMeter("logfilter/logs", nil)
+	rejectedMeter = metrics.NewRegisteredMeter("logfilter/rejected", nil)
+	queryTimer    = metrics.NewRegisteredTimer("logfilter/time", nil)
+)
+
+// Backend provides access to the chain data searched by the filter engine.
+type Backend interface {
+	// CurrentHeader retrieves the head of the canonical chain.
+	CurrentHeader(ctx context.Context) (*types.Header, error)
+
+	// HeaderByNumber retrieves the canonical header with the given number.
+	HeaderByNumber(ctx context.Context, number uint64) (*types.Header, error)
+
+	// HeaderByHash retrieves the header with the given hash.
+	HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error)
+
+	// GetReceipts retrieves the receipts of the block with the given hash.
+	GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error)
+
+	// GetLogs retrieves the logs of the block with the given hash, grouped by
+	// transaction.
+	GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error)
+
+	// BloomStatus returns the section size and the number of sections indexed
+	// by the bloom bits.
+	BloomStatus() (uint64, uint64)
+
+	// ServiceFilter serves the bloom bits retrievals of a matcher session.
+	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+}
+
+// Limits bounds the resources a single query may consume.
+type Limits struct {
+	MaxBlockRange uint64        // Maximum number of blocks a range query may span
+	MaxResults    int           // Maximum number of logs a query may return (0 = unlimited)
+	Timeout       time.Duration // Maximum time a query may run for (0 = unlimited)
+}
+
+// DefaultLimits places no bounds on the queries.
+var DefaultLimits = Limits{
+	MaxBlockRange: math.MaxUint64,
+}
+
+// Usage is the accounting of the resources consumed by a query.
+type Usage struct {
+	Blocks   uint64        // Number of block headers inspected
+	Receipts uint64        // Number of blocks whose logs were retrieved
+	Logs     uint64        // Number of logs matched
+	Elapsed  time.Duration // Time spent running the query
+}
+
+// Engine runs log queries against the chain provided by its backend.
+type Engine struct {
+	backend Backend
+	limits  Limits
+}
+
+// NewEngine creates a log filter engine bounding every query by the given limits.
+func NewEngine(backend Backend, limits Limits) *Engine {
+	return &Engine{
+		backend: backend,
+		limits:  limits,
+	}
+}
+
+// Limits returns the resource bounds of the queries run by the engine.
+func (e *Engine) Limits() Limits {
+	return e.limits
+}
+
+// Query can be used to retrieve and filter logs.
+type Query struct {
+	engine *Engine
+
+	addresses []common.Address
+	topics    [][]common.Hash
+
+	block      common.Hash // Block hash if filtering a single block
+	begin, end int64       // Range interval if filtering multiple blocks
+
+	matcher    *bloombits.Matcher
+	logMatcher *LogMatcher
+	logs       []*types.Log
+	usage      Usage
+}
+
+// NewRangeQuery creates a new query which uses a bloom filter on blocks to
+// figure out whether a particular block is interesting or not. Negative begin
+// or end denote the head of the chain at the time the query is run.
+func (e *Engine) NewRangeQuery(begin, end int64, addresses []common.Address, topics [][]common.Hash) *Query {
+	// Flatten the address and topic filter clauses into a single bloombits filter
+	// system. Since the bloombits are not positional, nil topics are permitted,
+	// which get flattened into a nil byte slice.
+	var filters [][][]byte
+	if len(addresses) > 0 {
+		filter := make([][]byte, len(addresses))
+		for i, address := range addresses {
+			filter[i] = address.Bytes()
+		}
+		filters = append(filters, filter)
+	}
+	for _, topicList := range topics {
+		filter := make([][]byte, len(topicList))
+		for i, topic := range topicList {
+			filter[i] = topic.Bytes()
+		}
+		filters = append(filters, filter)
+	}
+	size, _ := e.backend.BloomStatus()
+
+	return &Query{
+		engine:     e,
+		addresses:  addresses,
+		topics:     topics,
+		begin:      begin,
+		end:        end,
+		matcher:    bloombits.NewMatcher(size, filters),
+		logMatcher: NewLogMatcher(addresses, topics),
+	}
+}
+
+// NewBlockQuery creates a new query which directly inspects the contents of
+// a block to figure out whether it is interesting or not.
+func (e *Engine) NewBlockQuery(block common.Hash, addresses []common.Address, topics [][]common.Hash) *Query {
+	return &Query{
+		engine:     e,
+		addresses:  addresses,
+		topics:     topics,
+		block:      block,
+		logMatcher: NewLogMatcher(addresses, topics),
+	}
+}
+
+// Usage returns the resources consumed by the query so far.
+func (q *Query) Usage() Usage {
+	return q.usage
+}
+
+// Logs searches the blockchain for matching log entries. A query should only be
+// run once, as the progress through its range is tracked internally.
+func (q *Query) Logs(ctx context.Context) ([]*types.Log, error) {
+	start := time.Now()
+	if timeout := q.engine.limits.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	logs, err := q.run(ctx)
+
+	q.usage.Elapsed = time.Since(start)
+	queryMeter.Mark(1)
+	blockMeter.Mark(int64(q.usage.Blocks))
+	receiptMeter.Mark(int64(q.usage.Receipts))
+	logMeter.Mark(int64(q.usage.Logs))
+	queryTimer.Update(q.usage.Elapsed)
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = ErrQueryTimeout
+	}
+	if errors.Is(err, ErrBlockRangeLimit) || errors.Is(err, ErrResultLimit) || errors.Is(err, ErrQueryTimeout) {
+		rejectedMeter.Mark(1)
+		log.Debug("Log filter query rejected", "err", err, "blocks", q.usage.Blocks,
+			"receipts", q.usage.Receipts, "logs", q.usage.Logs, "elapsed", common.PrettyDuration(q.usage.Elapsed))
+	}
+	return logs, err
+}
+
+// run executes the query, splitting the range into the part covered by the
+// bloom bits index and the most recent, not yet indexed part.
+func (q *Query) run(ctx context.Context) ([]*types.Log, error) {
+	backend := q.engine.backend
+
+	// If we're doing singleton block filtering, execute and return
+	if q.block != (common.Hash{}) {
+		header, err := backend.HeaderByHash(ctx, q.block)
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, errUnknownBlock
+		}
+		err = q.blockLogs(ctx, header)
+		return q.logs, err
+	}
+	// Figure out the limits of the filter range
+	header, _ := backend.CurrentHeader(ctx)
+	if header == nil {
+		return nil, nil
+	}
+	head := header.Number.Uint64()
+
+	if q.begin < 0 {
+		q.begin = int64(head)
+	}
+	end := uint64(q.end)
+	if q.end < 0 {
+		end = head
+	}
+	if end >= uint64(q.begin) && end-uint64(q.begin) > q.engine.limits.MaxBlockRange {
+		log.Info("Filter block range is higher than the limit", "limit", q.engine.limits.MaxBlockRange, "request", end-uint64(q.begin))
+		return nil, ErrBlockRangeLimit
+	}
+	// Gather all indexed logs, and finish with non indexed ones
+	size, sections := backend.BloomStatus()
+	if indexed := sections * size; indexed > uint64(q.begin) {
+		var err error
+		if indexed > end {
+			err = q.indexedLogs(ctx, end)
+		} else {
+			err = q.indexedLogs(ctx, indexed-1)
+		}
+		if err != nil {
+			return q.logs, err
+		}
+	}
+	err := q.unindexedLogs(ctx, end)
+	return q.logs, err
+}
+
+// indexedLogs gathers the logs matching the filter criteria based on the bloom
+// bits indexed available locally or via the network.
+func (q *Query) indexedLogs(ctx context.Context, end uint64) error {
+	// Create a matcher session and request servicing from the backend
+	matches := make(chan uint64, 64)
+
+	session, err := q.matcher.Start(ctx, uint64(q.begin), end, matches)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	q.engine.backend.ServiceFilter(ctx, session)
+
+	// Iterate over the matches until exhausted or context closed
+	for {
+		select {
+		case number, ok := <-matches:
+			// Abort if all matches have been fulfilled
+			if !ok {
+				err := session.Error()
+				if err == nil {
+					q.begin = int64(end) + 1
+				}
+				return err
+			}
+			q.begin = int64(number) + 1
+
+			// Retrieve the suggested block and pull any truly matching logs
+			header, err := q.engine.backend.HeaderByNumber(ctx, number)
+			if header == nil || err != nil {
+				return err
+			}
+			q.usage.Blocks++
+			if err := q.checkMatches(ctx, header); err != nil {
+				return err
+			}
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// unindexedLogs gathers the logs matching the filter criteria based on raw block
+// iteration and bloom matching.
+func (q *Query) unindexedLogs(ctx context.Context, end uint64) error {
+	for ; q.begin <= int64(end); q.begin++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		header, err := q.engine.backend.HeaderByNumber(ctx, uint64(q.begin))
+		if header == nil || err != nil {
+			return err
+		}
+		if err := q.blockLogs(ctx, header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blockLogs gathers the logs matching the filter criteria within a single block.
+func (q *Query) blockLogs(ctx context.Context, header *types.Header) error {
+	q.usage.Blocks++
+	if BloomFilter(header.Bloom, q.addresses, q.topics) {
+		return q.checkMatches(ctx, header)
+	}
+	return nil
+}
+
+// checkMatches checks if the receipts belonging to the given header contain any log events that
+// match the filter criteria. This function is called when the bloom filter signals a potential match.
+func (q *Query) checkMatches(ctx context.Context, header *types.Header) error {
+	backend := q.engine.backend
+
+	// Get the logs of the block
+	q.usage.Receipts++
+	logsList, err := backend.GetLogs(ctx, header.Hash())
+	if err != nil {
+		return err
+	}
+	var unfiltered []*types.Log
+	for _, logs := range logsList {
+		unfiltered = append(unfiltered, logs...)
+	}
+	logs := q.logMatcher.Filter(unfiltered, nil, nil)
+	if len(logs) == 0 {
+		return nil
+	}
+	// We have matching logs, check if we need to resolve full logs via the light client
+	if logs[0].TxHash == (common.Hash{}) {
+		receipts, err := backend.GetReceipts(ctx, header.Hash())
+		if err != nil {
+			return err
+		}
+		unfiltered = unfiltered[:0]
+		for _, receipt := range receipts {
+			unfiltered = append(unfiltered, receipt.Logs...)
+		}
+		logs = q.logMatcher.Filter(unfiltered, nil, nil)
+	}
+	if limit := q.engine.limits.MaxResults; limit > 0 && len(q.logs)+len(logs) > limit {
+		return ErrResultLimit
+	}
+	q.logs = append(q.logs, logs...)
+	q.usage.Logs += uint64(len(logs))
+	return nil
+}
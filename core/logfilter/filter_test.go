@@ -0,0 +1,134 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package logfilter
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// testBackend is an in-memory chain with no bloom bits index.
+type testBackend struct {
+	headers []*types.Header
+	logs    map[common.Hash][][]*types.Log
+}
+
+func newTestBackend(blocks int, addr common.Address, topic common.Hash) *testBackend {
+	b := &testBackend{logs: make(map[common.Hash][][]*types.Log)}
+	for i := 0; i < blocks; i++ {
+		receipt := &types.Receipt{
+			Logs: []*types.Log{{
+				Address:     addr,
+				Topics:      []common.Hash{topic},
+				BlockNumber: uint64(i),
+				TxHash:      common.BigToHash(big.NewInt(int64(i + 1))),
+			}},
+		}
+		header := &types.Header{
+			Number: big.NewInt(int64(i)),
+			Bloom:  types.CreateBloom(types.Receipts{receipt}),
+		}
+		b.headers = append(b.headers, header)
+		b.logs[header.Hash()] = [][]*types.Log{receipt.Logs}
+	}
+	return b
+}
+
+func (b *testBackend) CurrentHeader(ctx context.Context) (*types.Header, error) {
+	return b.headers[len(b.headers)-1], nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number uint64) (*types.Header, error) {
+	if number >= uint64(len(b.headers)) {
+		return nil, nil
+	}
+	return b.headers[number], nil
+}
+
+func (b *testBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	for _, header := range b.headers {
+		if header.Hash() == hash {
+			return header, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return nil, nil
+}
+
+func (b *testBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	return b.logs[hash], nil
+}
+
+func (b *testBackend) BloomStatus() (uint64, uint64) {
+	return 4096, 0
+}
+
+func (b *testBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {}
+
+func TestQueryLimits(t *testing.T) {
+	var (
+		addr    = common.HexToAddress("0x1000000000000000000000000000000000000001")
+		other   = common.HexToAddress("0x2000000000000000000000000000000000000002")
+		topic   = common.HexToHash("0x01")
+		backend = newTestBackend(10, addr, topic)
+	)
+	// An unbounded query over the whole chain matches every block
+	query := NewEngine(backend, DefaultLimits).NewRangeQuery(0, -1, []common.Address{addr}, [][]common.Hash{{topic}})
+	logs, err := query.Logs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to filter logs: %v", err)
+	}
+	if len(logs) != 10 {
+		t.Fatalf("log count mismatch: have %d, want %d", len(logs), 10)
+	}
+	if usage := query.Usage(); usage.Blocks != 10 || usage.Receipts != 10 || usage.Logs != 10 {
+		t.Fatalf("usage mismatch: have %+v", usage)
+	}
+	// Blocks failing the bloom pre-check are not fetched
+	query = NewEngine(backend, DefaultLimits).NewRangeQuery(0, -1, []common.Address{other}, nil)
+	if logs, err := query.Logs(context.Background()); err != nil || len(logs) != 0 {
+		t.Fatalf("unexpected result: logs %d, err %v", len(logs), err)
+	}
+	if usage := query.Usage(); usage.Blocks != 10 || usage.Receipts != 0 {
+		t.Fatalf("usage mismatch: have %+v", usage)
+	}
+	// Queries exceeding the limits are rejected
+	limits := DefaultLimits
+	limits.MaxBlockRange = 5
+	if _, err := NewEngine(backend, limits).NewRangeQuery(0, 9, nil, nil).Logs(context.Background()); !errors.Is(err, ErrBlockRangeLimit) {
+		t.Fatalf("block range error mismatch: have %v, want %v", err, ErrBlockRangeLimit)
+	}
+	limits = DefaultLimits
+	limits.MaxResults = 3
+	if _, err := NewEngine(backend, limits).NewRangeQuery(0, 9, nil, nil).Logs(context.Background()); !errors.Is(err, ErrResultLimit) {
+		t.Fatalf("result limit error mismatch: have %v, want %v", err, ErrResultLimit)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if _, err := NewEngine(backend, DefaultLimits).NewRangeQuery(0, 9, nil, nil).Logs(ctx); !errors.Is(err, ErrQueryTimeout) {
+		t.Fatalf("timeout error mismatch: have %v, want %v", err, ErrQueryTimeout)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package logfilter
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// setScanLimit is the number of members up to which a set is scanned linearly,
+// which is faster than hashing the looked up value.
+const setScanLimit = 8
+
+// prefixSet is a set of addresses or topics, indexed by the first 8 bytes of
+// its members when too large to be scanned, which are cheaper to hash than the
+// whole members and almost never collide.
+type prefixSet[T common.Address | common.Hash] struct {
+	members []T
+	index   map[uint64][]T
+}
+
+func newPrefixSet[T common.Address | common.Hash](members []T) *prefixSet[T] {
+	set := &prefixSet[T]{members: members}
+	if len(members) > setScanLimit {
+		set.index = make(map[uint64][]T, len(members))
+		for _, member := range members {
+			prefix := setPrefix(member)
+			set.index[prefix] = append(set.index[prefix], member)
+		}
+	}
+	return set
+}
+
+// setPrefix returns the first 8 bytes of an address or topic.
+func setPrefix[T common.Address | common.Hash](member T) uint64 {
+	switch member := any(member).(type) {
+	case common.Address:
+		return binary.BigEndian.Uint64(member[:8])
+	case common.Hash:
+		return binary.BigEndian.Uint64(member[:8])
+	}
+	panic("unreachable")
+}
+
+func (s *prefixSet[T]) contains(value T) bool {
+	members := s.members
+	if s.index != nil {
+		members = s.index[setPrefix(value)]
+	}
+	for _, member := range members {
+		if member == value {
+			return true
+		}
+	}
+	return false
+}
+
+// LogMatcher is the compiled form of the address and topic criteria of a log
+// filter. It is built once per filter and reused to match the logs of all the
+// blocks, sparing the repeated scans of the criteria of FilterLogs.
+type LogMatcher struct {
+	addresses *prefixSet[common.Address] // nil matches any address
+	topics    []*prefixSet[common.Hash]  // Sets of the topic positions, nil for the wildcards
+	required  uint64                     // Bitset of the topic positions having a set, the first 64 ones
+	minTopics int                        // Number of topics a log must have at least
+}
+
+// NewLogMatcher compiles the given filter criteria. The topics are positional,
+// an empty set of topics matching any topic at its position.
+func NewLogMatcher(addresses []common.Address, topics [][]common.Hash) *LogMatcher {
+	m := &LogMatcher{
+		topics:    make([]*prefixSet[common.Hash], len(topics)),
+		minTopics: len(topics),
+	}
+	if len(addresses) > 0 {
+		m.addresses = newPrefixSet(addresses)
+	}
+	for i, sub := range topics {
+		if len(sub) == 0 {
+			continue
+		}
+		m.topics[i] = newPrefixSet(sub)
+		if i < 64 {
+			m.required |= 1 << i
+		}
+	}
+	return m
+}
+
+// Match reports whether the given log matches the criteria.
+func (m *LogMatcher) Match(log *types.Log) bool {
+	if len(log.Topics) < m.minTopics {
+		return false
+	}
+	if m.addresses != nil && !m.addresses.contains(log.Address) {
+		return false
+	}
+	// Only visit the positions having a set, skipping the wildcards
+	for set := m.required; set != 0; set &= set - 1 {
+		i := bits.TrailingZeros64(set)
+		if !m.topics[i].contains(log.Topics[i]) {
+			return false
+		}
+	}
+	for i := 64; i < len(m.topics); i++ {
+		if m.topics[i] != nil && !m.topics[i].contains(log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter creates a slice of the given logs matching the criteria within the
+// given block range, the bounds of which are ignored if nil or negative.
+func (m *LogMatcher) Filter(logs []*types.Log, fromBlock, toBlock *big.Int) []*types.Log {
+	var ret []*types.Log
+	for _, log := range logs {
+		if fromBlock != nil && fromBlock.Int64() >= 0 && fromBlock.Uint64() > log.BlockNumber {
+			continue
+		}
+		if toBlock != nil && toBlock.Int64() >= 0 && toBlock.Uint64() < log.BlockNumber {
+			continue
+		}
+		if m.Match(log) {
+			ret = append(ret, log)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package logfilter
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// matchLog is the reference implementation of the log matching, scanning the
+// criteria for every log.
+func matchLog(log *types.Log, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		var found bool
+		for _, addr := range addresses {
+			if addr == log.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range topics {
+		match := len(sub) == 0
+		for _, topic := range sub {
+			if log.Topics[i] == topic {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+// Tests that the compiled criteria match the same logs as the criteria scanned
+// for every log, whether the sets are scanned or indexed.
+func TestLogMatcher(t *testing.T) {
+	var (
+		rng       = rand.New(rand.NewSource(1))
+		addresses = make([]common.Address, 32)
+		topics    = make([]common.Hash, 32)
+	)
+	for i := range addresses {
+		rng.Read(addresses[i][:])
+		rng.Read(topics[i][:])
+	}
+	// Addresses and topics sharing their prefix with a criterion
+	addresses[1] = addresses[0]
+	addresses[1][19] ^= 0xff
+	topics[1] = topics[0]
+	topics[1][31] ^= 0xff
+
+	pick := func(set []common.Hash, n int) []common.Hash {
+		picked := make([]common.Hash, n)
+		for i := range picked {
+			picked[i] = set[rng.Intn(len(set))]
+		}
+		return picked
+	}
+	logs := make([]*types.Log, 512)
+	for i := range logs {
+		logs[i] = &types.Log{Address: addresses[rng.Intn(len(addresses))], Topics: pick(topics, rng.Intn(5))}
+	}
+	for i := 0; i < 200; i++ {
+		var (
+			addrs = append([]common.Address{}, addresses[:rng.Intn(len(addresses))]...)
+			crit  = make([][]common.Hash, rng.Intn(5))
+		)
+		rng.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+		for j := range crit {
+			crit[j] = pick(topics, rng.Intn(2)*rng.Intn(len(topics)))
+		}
+		matcher := NewLogMatcher(addrs, crit)
+		for j, log := range logs {
+			if have, want := matcher.Match(log), matchLog(log, addrs, crit); have != want {
+				t.Fatalf("criteria %d, log %d: match mismatch: have %v, want %v", i, j, have, want)
+			}
+		}
+	}
+	// Positions beyond the topic bitset are checked too
+	wide := make([][]common.Hash, 66)
+	wide[65] = []common.Hash{topics[0]}
+	log := &types.Log{Topics: make([]common.Hash, 66)}
+	if NewLogMatcher(nil, wide).Match(log) {
+		t.Fatalf("mismatching topic beyond the bitset matched")
+	}
+	log.Topics[65] = topics[0]
+	if !NewLogMatcher(nil, wide).Match(log) {
+		t.Fatalf("matching topic beyond the bitset not matched")
+	}
+}
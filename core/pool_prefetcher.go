@@ -0,0 +1,163 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// pendingPrefetchLimit is the maximum number of pending pool transactions
+	// whose state is warmed up ahead of a block import.
+	pendingPrefetchLimit = 1024
+
+	// pendingPrefetchAge is the maximum age of a block for the pending pool
+	// transactions to be considered relevant to it, i.e. blocks synced way
+	// after their sealing don't consult the pool.
+	pendingPrefetchAge = time.Minute
+
+	// pendingPrefetchAccountLimit is the maximum number of accounts warmed up
+	// ahead of a block import.
+	pendingPrefetchAccountLimit = 512
+
+	// accessHistoryRetention is the number of blocks a storage slot accessed by
+	// a block is warmed up for without being accessed again.
+	accessHistoryRetention = 16
+
+	// accessHistoryLimit is the maximum number of recently accessed storage slots
+	// tracked for warming up.
+	accessHistoryLimit = 1 << 16
+)
+
+var (
+	pendingPrefetchAccountMeter = metrics.NewRegisteredMeter("chain/prefetch/pending/accounts", nil)
+	pendingPrefetchSlotMeter    = metrics.NewRegisteredMeter("chain/prefetch/pending/slots", nil)
+
+	pendingPrefetchTimer          = metrics.NewRegisteredTimer("chain/prefetch/pending/time", nil)
+	pendingPrefetchInterruptMeter = metrics.NewRegisteredMeter("chain/prefetch/pending/interrupts", nil)
+)
+
+// SetPendingSource sets the source of the transactions pending inclusion grouped
+// by sender, e.g. the transaction pool, returning up to the given number of
+// them. The state they access is warmed up before each block is imported. It
+// must be called before any block is imported.
+func (bc *BlockChain) SetPendingSource(source func(limit int) map[common.Address][]*types.Transaction) {
+	bc.pendingSource = source
+}
+
+// warmPendingState starts scheduling the state expected to be accessed by a
+// block for prefetching into the given state before it's processed: the
+// accounts and access lists of its transactions and, for recent blocks, of the
+// transactions pending in the pool, along with the storage slots of these
+// accounts accessed by the recent blocks, up to pendingPrefetchAccountLimit
+// accounts. The accounts are collected in the background, the returned function
+// must be called before the block is processed to interrupt the warm-up and
+// hand the state back, as it's not safe for concurrent use.
+func (bc *BlockChain) warmPendingState(block *types.Block, statedb *state.StateDB) func() {
+	var (
+		interrupt uint32
+		lock      sync.Mutex // Held while the state is used by the warm-up
+	)
+	go func(start time.Time) {
+		accounts, slots := bc.pendingAccounts(block, &interrupt)
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		if atomic.LoadUint32(&interrupt) == 1 {
+			pendingPrefetchInterruptMeter.Mark(1)
+			return
+		}
+		nAccounts, nSlots := bc.accessHistory.Warm(statedb, accounts, slots, &interrupt)
+		pendingPrefetchAccountMeter.Mark(int64(nAccounts))
+		pendingPrefetchSlotMeter.Mark(int64(nSlots))
+		pendingPrefetchTimer.UpdateSince(start)
+	}(time.Now())
+
+	return func() {
+		atomic.StoreUint32(&interrupt, 1)
+		lock.Lock()
+		lock.Unlock()
+	}
+}
+
+// pendingAccounts collects the accounts expected to be accessed by a block and
+// the storage slots of them listed by access lists, up to the account limit. It
+// returns early with whatever was collected if interrupted.
+func (bc *BlockChain) pendingAccounts(block *types.Block, interrupt *uint32) ([]common.Address, map[common.Address][]common.Hash) {
+	var (
+		signer   = types.MakeSigner(bc.chainConfig, block.Number())
+		seen     = make(map[common.Address]struct{})
+		accounts []common.Address
+		slots    = make(map[common.Address][]common.Hash)
+	)
+	add := func(addr common.Address) {
+		if _, ok := seen[addr]; !ok && len(accounts) < pendingPrefetchAccountLimit {
+			seen[addr] = struct{}{}
+			accounts = append(accounts, addr)
+		}
+	}
+	addTx := func(tx *types.Transaction) {
+		if to := tx.To(); to != nil {
+			add(*to)
+		}
+		for _, tuple := range tx.AccessList() {
+			add(tuple.Address)
+			if _, ok := seen[tuple.Address]; ok {
+				slots[tuple.Address] = append(slots[tuple.Address], tuple.StorageKeys...)
+			}
+		}
+	}
+	full := func() bool {
+		return len(accounts) >= pendingPrefetchAccountLimit || atomic.LoadUint32(interrupt) == 1
+	}
+	// The senders of the block's transactions are only known if pooled, don't
+	// recover them here, the processor does it anyway
+	for _, tx := range block.Transactions() {
+		if full() {
+			return accounts, slots
+		}
+		if bc.senderSource != nil {
+			if known := bc.senderSource(tx.Hash()); known != nil {
+				if from, err := types.Sender(signer, known); err == nil {
+					add(from)
+				}
+			}
+		}
+		addTx(tx)
+	}
+	if bc.pendingSource == nil || full() || time.Since(time.Unix(int64(block.Time()), 0)) >= pendingPrefetchAge {
+		return accounts, slots
+	}
+	for from, txs := range bc.pendingSource(pendingPrefetchLimit) {
+		if full() {
+			break
+		}
+		add(from)
+		for _, tx := range txs {
+			addTx(tx)
+		}
+	}
+	return accounts, slots
+}
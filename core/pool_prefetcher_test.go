@@ -0,0 +1,122 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the pending transactions are consulted for warming up the state of
+// the recent blocks only, up to the account limit, that stopping the warm-up
+// doesn't wait for the pool, and that the storage accessed by the imported
+// blocks is tracked.
+func TestPendingStateWarming(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xaffeaffeaffeaffeaffeaffeaffeaffeaffeaffe")
+		signer   = types.LatestSigner(params.TestChainConfig)
+	)
+	// Deploy a contract reading its first slot, the blocks are sealed in the
+	// past except for the last one
+	gspec := &Genesis{
+		Config:    params.TestChainConfig,
+		Timestamp: uint64(time.Now().Add(-time.Hour).Unix()),
+		BaseFee:   big.NewInt(params.InitialBaseFee),
+		Alloc: GenesisAlloc{
+			addr:     {Balance: big.NewInt(params.Ether)},
+			contract: {Balance: common.Big0, Code: []byte{byte(vm.PUSH1), 0x00, byte(vm.SLOAD), byte(vm.STOP)}, Storage: map[common.Hash]common.Hash{{}: {0x01}}},
+		},
+	}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, func(i int, b *BlockGen) {
+		if i == 2 {
+			b.OffsetTime(time.Now().Unix() - int64(b.header.Time))
+		}
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), contract, nil, 50000, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if n := chain.accessHistory.Slots(contract); n != 1 {
+		t.Fatalf("recorded slots mismatch: have %d, want 1", n)
+	}
+	// Only the recent blocks consult the pool, up to the account limit
+	var calls int
+	chain.SetPendingSource(func(limit int) map[common.Address][]*types.Transaction {
+		calls++
+		if limit != pendingPrefetchLimit {
+			t.Errorf("pending limit mismatch: have %d, want %d", limit, pendingPrefetchLimit)
+		}
+		pending := make(map[common.Address][]*types.Transaction)
+		for i := 0; i < 2*pendingPrefetchAccountLimit; i++ {
+			pending[common.BigToAddress(big.NewInt(int64(i+1)))] = nil
+		}
+		return pending
+	})
+	var interrupt uint32
+	if accounts, _ := chain.pendingAccounts(blocks[1], &interrupt); calls != 0 || len(accounts) != 1 {
+		t.Fatalf("stale block warm-up mismatch: have %d calls/%d accounts, want 0/1", calls, len(accounts))
+	}
+	if accounts, _ := chain.pendingAccounts(blocks[2], &interrupt); calls != 1 || len(accounts) != pendingPrefetchAccountLimit {
+		t.Fatalf("recent block warm-up mismatch: have %d calls/%d accounts, want 1/%d", calls, len(accounts), pendingPrefetchAccountLimit)
+	}
+	// Stopping the warm-up must not wait for the pool
+	var (
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+	chain.SetPendingSource(func(limit int) map[common.Address][]*types.Transaction {
+		close(entered)
+		<-release
+		return nil
+	})
+	statedb, _ := chain.State()
+	statedb.StartPrefetcher("test")
+	defer statedb.StopPrefetcher()
+
+	stop := chain.warmPendingState(blocks[2], statedb)
+	<-entered
+
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("warm-up stop blocked on the pool")
+	}
+	close(release)
+}
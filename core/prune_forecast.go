@@ -0,0 +1,212 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+var (
+	// bodyQueries are the RPC methods which can't be answered for the blocks
+	// whose bodies are pruned, along with their transaction lookups.
+	bodyQueries = []string{
+		"eth_getBlockByHash", "eth_getBlockByNumber",
+		"eth_getBlockTransactionCountByHash", "eth_getBlockTransactionCountByNumber",
+		"eth_getTransactionByBlockHashAndIndex", "eth_getTransactionByBlockNumberAndIndex",
+		"eth_getTransactionByHash", "eth_getRawTransactionByHash", "eth_getTransactionReceipt",
+		"eth_getUncleByBlockHashAndIndex", "eth_getUncleByBlockNumberAndIndex",
+		"eth_getUncleCountByBlockHash", "eth_getUncleCountByBlockNumber",
+		"debug_traceBlockByHash", "debug_traceBlockByNumber", "debug_traceTransaction",
+	}
+	// receiptQueries are the RPC methods which can't be answered for the blocks
+	// whose receipts are pruned.
+	receiptQueries = []string{
+		"eth_getTransactionReceipt", "eth_getBlockReceipts", "eth_getLogs", "eth_getFilterLogs",
+	}
+)
+
+var errNoRetention = errors.New("no history retention window")
+
+// PruneForecast is the outcome of a pruning dry-run: the space each class of
+// block data would release and the queries which would no longer be answered
+// for the blocks pruned. The bytes of the frozen data are those of the freezer
+// files which would be deleted, while the bytes of the data in the key-value
+// store, keys included, are reclaimed once their ranges are compacted.
+type PruneForecast struct {
+	From         uint64   `json:"from"`         // First block pruned
+	To           uint64   `json:"to"`           // Last block pruned
+	Blocks       uint64   `json:"blocks"`       // Number of canonical blocks pruned
+	Transactions uint64   `json:"transactions"` // Number of transactions whose lookups are removed
+	Bodies       uint64   `json:"bodies"`       // Bytes released by the block bodies
+	Receipts     uint64   `json:"receipts"`     // Bytes released by the block receipts
+	TxLookups    uint64   `json:"txLookups"`    // Bytes released by the transaction lookups
+	Total        uint64   `json:"total"`        // Bytes released altogether
+	HistoryTail  uint64   `json:"historyTail"`  // First block whose history is kept after pruning
+	Unanswerable []string `json:"unanswerable"` // RPC methods no longer answered for the range
+}
+
+// PruneRangeForecast computes what pruning the given classes of block data of
+// the range with PruneRange would release, without pruning anything.
+func (bc *BlockChain) PruneRangeForecast(from, to uint64, classes HistoryClass) (*PruneForecast, error) {
+	if err := bc.checkPruneRange(from, to, classes); err != nil {
+		return nil, err
+	}
+	forecast := &PruneForecast{From: from, To: to, HistoryTail: bc.historyTail.Load()}
+
+	// The frozen blocks are truncated from the history tail of the freezer
+	if frozen, _ := bc.db.Ancients(); from < frozen {
+		end := min(to+1, frozen)
+		bodies, receipts, err := rawdb.FrozenHistorySize(bc.db, end)
+		if err != nil {
+			return nil, err
+		}
+		forecast.Bodies += bodies
+		forecast.Receipts += receipts
+
+		if err := bc.forecastBlocks(forecast, from, end-1, classes, false); err != nil {
+			return nil, err
+		}
+		from = end
+	}
+	if from <= to {
+		if err := bc.forecastBlocks(forecast, from, to, classes, true); err != nil {
+			return nil, err
+		}
+	}
+	forecast.finish(classes)
+	return forecast, nil
+}
+
+// HistoryPruneForecast computes what pruning the history beyond a retention
+// window of the given number of blocks, the configured one if zero, would
+// release at the current head, without pruning anything. Unlike the background
+// pruning, the history is accounted up to the window even if it lags behind by
+// less than a batch.
+func (bc *BlockChain) HistoryPruneForecast(retain uint64) (*PruneForecast, error) {
+	if retain == 0 {
+		retain = bc.cacheConfig.HistoryPrune
+	}
+	if retain == 0 {
+		return nil, errNoRetention
+	}
+	var (
+		tail     = bc.historyTail.Load()
+		head     = bc.CurrentBlock().NumberU64()
+		forecast = &PruneForecast{HistoryTail: tail, Unanswerable: []string{}}
+	)
+	if head < retain {
+		return forecast, nil
+	}
+	cutoff := min(head-retain+1, bc.pruneLimit())
+	if cutoff <= max(tail, 1) {
+		return forecast, nil
+	}
+	forecast.From, forecast.To, forecast.HistoryTail = max(tail, 1), cutoff-1, cutoff
+
+	// The frozen blocks are truncated from the freezer tail, the genesis included
+	// as it's kept in the key-value store anyway.
+	frozen, _ := bc.db.Ancients()
+	if end := min(cutoff, frozen); tail < end {
+		bodies, receipts, err := rawdb.FrozenHistorySize(bc.db, end)
+		if err != nil {
+			return nil, err
+		}
+		forecast.Bodies += bodies
+		forecast.Receipts += receipts
+
+		if from := max(tail, 1); from < end {
+			if err := bc.forecastBlocks(forecast, from, end-1, HistoryBodies|HistoryReceipts, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if from := max(tail, frozen, 1); from < cutoff {
+		if err := bc.forecastBlocks(forecast, from, cutoff-1, HistoryBodies|HistoryReceipts, true); err != nil {
+			return nil, err
+		}
+	}
+	forecast.finish(HistoryBodies | HistoryReceipts)
+	return forecast, nil
+}
+
+// forecastBlocks accounts the given classes of block data of the canonical blocks
+// from (inclusive) to to (inclusive) into the forecast: the transaction lookups
+// of their bodies, and their bodies and receipts themselves if they are stored
+// in the key-value store rather than frozen.
+func (bc *BlockChain) forecastBlocks(forecast *PruneForecast, from, to uint64, classes HistoryClass, stored bool) error {
+	for number := from; number <= to; number++ {
+		select {
+		case <-bc.quit:
+			return errChainStopped
+		default:
+		}
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		forecast.Blocks++
+
+		if stored {
+			body, receipts := rawdb.BlockDataSize(bc.db, hash, number)
+			if classes&HistoryBodies != 0 {
+				forecast.Bodies += uint64(body)
+			}
+			if classes&HistoryReceipts != 0 {
+				forecast.Receipts += uint64(receipts)
+			}
+		}
+		if classes&HistoryBodies != 0 {
+			if body := rawdb.ReadBody(bc.db, hash, number); body != nil {
+				for _, tx := range body.Transactions {
+					if size := rawdb.TxLookupSize(bc.db, tx.Hash()); size > 0 {
+						forecast.Transactions++
+						forecast.TxLookups += uint64(size)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// finish sums up the space released and lists the queries no longer answered
+// once the given classes of block data were accounted.
+func (f *PruneForecast) finish(classes HistoryClass) {
+	f.Total = f.Bodies + f.Receipts + f.TxLookups
+	f.Unanswerable = []string{}
+	if f.Blocks == 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	add := func(queries []string) {
+		for _, query := range queries {
+			if !seen[query] {
+				seen[query] = true
+				f.Unanswerable = append(f.Unanswerable, query)
+			}
+		}
+	}
+	if classes&HistoryBodies != 0 {
+		add(bodyQueries)
+	}
+	if classes&HistoryReceipts != 0 {
+		add(receiptQueries)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the pruning dry-runs account the block data exactly as the pruning
+// then deletes it, without deleting anything themselves.
+func TestPruneForecast(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		engine = &laggingFinalityEngine{Engine: ethash.NewFaker(), lag: 4}
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 2*historyPruneBatch, func(i int, b *BlockGen) {
+		if i%16 == 0 {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with freezer: %v", err)
+	}
+	defer db.Close()
+
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	head := uint64(len(blocks))
+	db.(interface{ Freeze(uint64) error }).Freeze(historyPruneBatch)
+	frozen, _ := db.Ancients()
+
+	// Account the block data the way the pruning is expected to release it
+	account := func(from, to uint64, classes HistoryClass) *PruneForecast {
+		want := &PruneForecast{From: from, To: to}
+		for number := from; number <= to; number++ {
+			block := blocks[number-1]
+			want.Blocks++
+
+			body, receipts := rawdb.BlockDataSize(db, block.Hash(), number)
+			if classes&HistoryBodies != 0 {
+				want.Bodies += uint64(body)
+				for _, tx := range block.Transactions() {
+					want.Transactions++
+					want.TxLookups += uint64(rawdb.TxLookupSize(db, tx.Hash()))
+				}
+			}
+			if classes&HistoryReceipts != 0 {
+				want.Receipts += uint64(receipts)
+			}
+		}
+		want.Total = want.Bodies + want.Receipts + want.TxLookups
+		return want
+	}
+	check := func(have, want *PruneForecast) {
+		t.Helper()
+
+		if have.From != want.From || have.To != want.To || have.Blocks != want.Blocks || have.Transactions != want.Transactions {
+			t.Errorf("range mismatch: have %d-%d with %d blocks and %d txs, want %d-%d with %d blocks and %d txs",
+				have.From, have.To, have.Blocks, have.Transactions, want.From, want.To, want.Blocks, want.Transactions)
+		}
+		if have.Bodies != want.Bodies || have.Receipts != want.Receipts || have.TxLookups != want.TxLookups || have.Total != want.Total {
+			t.Errorf("size mismatch: have %d/%d/%d (%d), want %d/%d/%d (%d)", have.Bodies, have.Receipts, have.TxLookups,
+				have.Total, want.Bodies, want.Receipts, want.TxLookups, want.Total)
+		}
+		if want.Total == 0 || len(have.Unanswerable) == 0 {
+			t.Errorf("nothing forecast: %d bytes, %d queries", want.Total, len(have.Unanswerable))
+		}
+	}
+	// A range of the blocks not frozen yet, receipts only
+	from, to := frozen+8, frozen+40
+	forecast, err := chain.PruneRangeForecast(from, to, HistoryReceipts)
+	if err != nil {
+		t.Fatalf("failed to forecast range: %v", err)
+	}
+	check(forecast, account(from, to, HistoryReceipts))
+	for _, query := range forecast.Unanswerable {
+		if query == "eth_getBlockByNumber" {
+			t.Errorf("body query %s unanswerable with receipts pruned only", query)
+		}
+	}
+	if _, err := chain.PruneRangeForecast(frozen-1, to, HistoryBodies); err == nil {
+		t.Errorf("forecast of a frozen range succeeded")
+	}
+	// The history beyond a window, spanning both stores. The frozen data lies in
+	// a single freezer file, none being released.
+	retain := uint64(historyPruneBatch / 2)
+	if _, err := chain.HistoryPruneForecast(0); err == nil {
+		t.Errorf("forecast without retention window succeeded")
+	}
+	forecast, err = chain.HistoryPruneForecast(retain)
+	if err != nil {
+		t.Fatalf("failed to forecast history pruning: %v", err)
+	}
+	cutoff := head - retain + 1
+	want := account(frozen, cutoff-1, HistoryBodies|HistoryReceipts)
+	for number := uint64(1); number < frozen; number++ {
+		want.Blocks++
+		for _, tx := range blocks[number-1].Transactions() {
+			want.Transactions++
+			want.TxLookups += uint64(rawdb.TxLookupSize(db, tx.Hash()))
+		}
+	}
+	want.From, want.Total = 1, want.Bodies+want.Receipts+want.TxLookups
+	check(forecast, want)
+	if forecast.HistoryTail != cutoff {
+		t.Errorf("history tail mismatch: have %d, want %d", forecast.HistoryTail, cutoff)
+	}
+	// Nothing must have been pruned by the dry-runs
+	for _, block := range blocks {
+		if chain.GetBlockByNumber(block.NumberU64()) == nil || chain.GetReceiptsByHash(block.Hash()) == nil {
+			t.Fatalf("block %d: history pruned by the forecast", block.NumberU64())
+		}
+	}
+	if tail := chain.HistoryTail(); tail != 0 {
+		t.Fatalf("history tail moved by the forecast: %d", tail)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// HistoryClass is a set of classes of the block data which can be pruned from
+// the historical blocks, the headers being always kept.
+type HistoryClass uint8
+
+const (
+	HistoryBodies   HistoryClass = 1 << iota // Block bodies, along with their transaction lookups
+	HistoryReceipts                          // Block receipts
+)
+
+// ParseHistoryClasses parses the names of the history classes, "bodies" and
+// "receipts", into a set.
+func ParseHistoryClasses(names []string) (HistoryClass, error) {
+	var classes HistoryClass
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "bodies":
+			classes |= HistoryBodies
+		case "receipts":
+			classes |= HistoryReceipts
+		default:
+			return 0, fmt.Errorf("unknown history class %q", name)
+		}
+	}
+	return classes, nil
+}
+
+var errNoHistoryClass = errors.New("no history class to prune")
+
+// PruneRange deletes the selected classes of block data of the canonical blocks
+// from (inclusive) to to (inclusive), to reclaim the space of specific ranges.
+// The range must be below the finalized block, or the immutability threshold
+// if the engine has no fast finality. The block data in the freezer can only be
+// truncated from its history tail, so a range overlapping the frozen blocks must
+// start at that tail and prune both the bodies and the receipts. The blocks not
+// frozen yet are recorded, for their missing data to be frozen as empty items,
+// and their key ranges are queued for compaction to reclaim the space.
+func (bc *BlockChain) PruneRange(from, to uint64, classes HistoryClass) error {
+	if err := bc.checkPruneRange(from, to, classes); err != nil {
+		return err
+	}
+	start := time.Now()
+	if frozen, _ := bc.db.Ancients(); from < frozen {
+		if err := bc.pruneFrozenHistory(from, min(to+1, frozen)); err != nil {
+			return err
+		}
+		if to < frozen {
+			log.Info("Pruned frozen block data", "from", from, "to", to, "elapsed", common.PrettyDuration(time.Since(start)))
+			return nil
+		}
+		from = frozen
+	}
+	// Record the range before pruning, the freezer may freeze the blocks anytime.
+	// A range following the last one with the same classes extends it instead.
+	var (
+		ranges = rawdb.ReadPrunedRanges(bc.db)
+		pruned = rawdb.PrunedRange{
+			From:     from,
+			To:       to,
+			Bodies:   classes&HistoryBodies != 0,
+			Receipts: classes&HistoryReceipts != 0,
+		}
+	)
+	if n := len(ranges); n > 0 && ranges[n-1].To+1 == from && ranges[n-1].Bodies == pruned.Bodies && ranges[n-1].Receipts == pruned.Receipts {
+		ranges[n-1].To = to
+	} else {
+		ranges = append(ranges, pruned)
+	}
+	rawdb.WritePrunedRanges(bc.db, ranges)
+
+	bc.receiptsLock.Lock()
+	defer bc.receiptsLock.Unlock()
+
+	batch := bc.db.NewBatch()
+	for number := from; number <= to; number++ {
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		if classes&HistoryBodies != 0 {
+			if body := rawdb.ReadBody(bc.db, hash, number); body != nil {
+				for _, tx := range body.Transactions {
+					rawdb.DeleteTxLookupEntry(batch, tx.Hash())
+				}
+			}
+			rawdb.DeleteBody(batch, hash, number)
+		}
+		if classes&HistoryReceipts != 0 {
+			rawdb.DeleteReceipts(batch, hash, number)
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	// Drop the cached data of the pruned blocks and compact their ranges away
+	bc.bodyCache.Purge()
+	bc.bodyRLPCache.Purge()
+	bc.receiptsCache.Purge()
+	bc.blockCache.Purge()
+	bc.txLookupCache.Purge()
+
+	for _, r := range rawdb.BlockDataRanges(from, to+1) {
+		bc.ScheduleCompaction(r[0], r[1])
+	}
+	log.Info("Pruned historical block data", "from", from, "to", to, "bodies", classes&HistoryBodies != 0,
+		"receipts", classes&HistoryReceipts != 0, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// checkPruneRange returns an error if the given classes of block data of the
+// range can't be pruned.
+func (bc *BlockChain) checkPruneRange(from, to uint64, classes HistoryClass) error {
+	if classes&(HistoryBodies|HistoryReceipts) == 0 {
+		return errNoHistoryClass
+	}
+	if from == 0 || from > to {
+		return fmt.Errorf("invalid range %d-%d", from, to)
+	}
+	if limit := bc.pruneLimit(); to >= limit {
+		return fmt.Errorf("range %d-%d is not below the finalized block %d", from, to, limit)
+	}
+	if frozen, _ := bc.db.Ancients(); from < frozen {
+		if classes != HistoryBodies|HistoryReceipts {
+			return fmt.Errorf("range %d-%d overlaps the frozen blocks below %d, only prunable along with both bodies and receipts", from, to, frozen)
+		}
+		if tail := max(rawdb.FrozenHistoryTail(bc.db), 1); from > tail {
+			return fmt.Errorf("range %d-%d overlaps the frozen blocks below %d, only prunable from the frozen history tail %d", from, to, frozen, tail)
+		}
+	}
+	return nil
+}
+
+// pruneFrozenHistory truncates the frozen bodies and receipts below end from the
+// freezer, after removing the transaction lookups of the blocks from from on.
+func (bc *BlockChain) pruneFrozenHistory(from, end uint64) error {
+	from = max(from, rawdb.FrozenHistoryTail(bc.db))
+	if indexed := rawdb.ReadTxIndexTail(bc.db); indexed != nil && *indexed > from {
+		from = *indexed
+	}
+	rawdb.UnindexTransactions(bc.db, from, end, bc.quit)
+	// Leave the lookups partially removed for the next run if interrupted
+	select {
+	case <-bc.quit:
+		return errChainStopped
+	default:
+	}
+	if err := rawdb.PruneFrozenHistory(bc.db, end); err != nil {
+		return err
+	}
+	bc.bodyCache.Purge()
+	bc.bodyRLPCache.Purge()
+	bc.receiptsCache.Purge()
+	bc.blockCache.Purge()
+	bc.txLookupCache.Purge()
+	return nil
+}
+
+// pruneLimit returns the number of the first block whose data can't be pruned:
+// the finalized block, or the block the immutability threshold below the head
+// if the engine has no fast finality.
+func (bc *BlockChain) pruneLimit() uint64 {
+	if _, ok := bc.engine.(consensus.FastFinalityPoSA); ok {
+		if final := bc.CurrentFinalBlock(); final != nil {
+			return final.Number.Uint64()
+		}
+		return 0
+	}
+	if head := bc.CurrentBlock().NumberU64(); head > params.FullImmutabilityThreshold {
+		return head - params.FullImmutabilityThreshold
+	}
+	return 0
+}
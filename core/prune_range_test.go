@@ -0,0 +1,184 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the bodies and receipts of a finalized range of blocks can be
+// pruned, keeping the headers and the blocks around the range intact.
+func TestPruneRange(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		engine = &laggingFinalityEngine{Engine: ethash.NewFaker(), lag: 4}
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 10, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Block 6 is finalized, only the blocks below can be pruned
+	for _, tt := range []struct {
+		from, to uint64
+		classes  HistoryClass
+	}{
+		{0, 3, HistoryBodies},   // Genesis
+		{4, 3, HistoryBodies},   // Inverted range
+		{3, 6, HistoryBodies},   // Finalized block
+		{3, 9, HistoryReceipts}, // Beyond the finalized block
+		{3, 4, 0},               // No class
+	} {
+		if err := chain.PruneRange(tt.from, tt.to, tt.classes); err == nil {
+			t.Errorf("range %d-%d, classes %d: pruned", tt.from, tt.to, tt.classes)
+		}
+	}
+	// Warm the caches up, they must not serve the pruned data
+	for _, block := range blocks {
+		chain.GetBody(block.Hash())
+		chain.GetReceiptsByHash(block.Hash())
+	}
+	if err := chain.PruneRange(2, 5, HistoryBodies|HistoryReceipts); err != nil {
+		t.Fatalf("failed to prune range: %v", err)
+	}
+	for _, block := range blocks {
+		var (
+			number = block.NumberU64()
+			pruned = number >= 2 && number <= 5
+			txHash = block.Transactions()[0].Hash()
+		)
+		if chain.GetHeaderByHash(block.Hash()) == nil {
+			t.Errorf("block %d: header missing", number)
+		}
+		if have := chain.GetBody(block.Hash()) == nil; have != pruned {
+			t.Errorf("block %d: body pruned mismatch: have %v, want %v", number, have, pruned)
+		}
+		if have := chain.GetReceiptsByHash(block.Hash()) == nil; have != pruned {
+			t.Errorf("block %d: receipts pruned mismatch: have %v, want %v", number, have, pruned)
+		}
+		if have := rawdb.ReadTxLookupEntry(chain.db, txHash) == nil; have != pruned {
+			t.Errorf("block %d: transaction lookup pruned mismatch: have %v, want %v", number, have, pruned)
+		}
+	}
+	if ranges := rawdb.ReadPrunedRanges(chain.db); len(ranges) != 1 || ranges[0] != (rawdb.PrunedRange{From: 2, To: 5, Bodies: true, Receipts: true}) {
+		t.Errorf("pruned ranges mismatch: %v", ranges)
+	}
+}
+
+// Tests that the frozen blocks can be pruned from the frozen history tail, and
+// that the blocks pruned either way are reported as such.
+func TestPruneRangeFrozen(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		engine = &laggingFinalityEngine{Engine: ethash.NewFaker(), lag: 4}
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, engine, 32, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	db, err := rawdb.NewDatabaseWithFreezer(rawdb.NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with freezer: %v", err)
+	}
+	defer db.Close()
+
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	db.(interface{ Freeze(uint64) error }).Freeze(16)
+	frozen, _ := db.Ancients()
+	if frozen < 8 || frozen+8 > 28 {
+		t.Fatalf("unexpected frozen blocks: %d", frozen)
+	}
+	// The frozen blocks are only prunable from the tail, bodies and receipts alike
+	if err := chain.PruneRange(1, frozen+2, HistoryBodies); err == nil {
+		t.Errorf("frozen bodies pruned alone")
+	}
+	if err := chain.PruneRange(4, frozen+2, HistoryBodies|HistoryReceipts); err == nil {
+		t.Errorf("frozen blocks pruned above the tail")
+	}
+	if err := chain.PruneRange(1, frozen+2, HistoryBodies|HistoryReceipts); err != nil {
+		t.Fatalf("failed to prune frozen range: %v", err)
+	}
+	if tail := rawdb.FrozenHistoryTail(db); tail != frozen {
+		t.Errorf("frozen history tail mismatch: have %d, want %d", tail, frozen)
+	}
+	if err := chain.PruneRange(frozen+5, frozen+6, HistoryReceipts); err != nil {
+		t.Fatalf("failed to prune range: %v", err)
+	}
+	for _, block := range blocks {
+		var (
+			number   = block.NumberU64()
+			bodies   = number <= frozen+2
+			receipts = bodies || (number >= frozen+5 && number <= frozen+6)
+		)
+		if have := chain.GetBody(block.Hash()) == nil; have != bodies {
+			t.Errorf("block %d: body pruned mismatch: have %v, want %v", number, have, bodies)
+		}
+		if have := chain.GetReceiptsByHash(block.Hash()) == nil; have != receipts {
+			t.Errorf("block %d: receipts pruned mismatch: have %v, want %v", number, have, receipts)
+		}
+		if have := rawdb.ReadTxLookupEntry(db, block.Transactions()[0].Hash()) == nil; have != bodies {
+			t.Errorf("block %d: transaction lookup pruned mismatch: have %v, want %v", number, have, bodies)
+		}
+		var perr *HistoryPrunedError
+		if have := errors.As(chain.HistoryPruned(number), &perr); have != receipts {
+			t.Errorf("block %d: pruned error mismatch: have %v, want %v", number, have, receipts)
+		}
+	}
+	if chain.GetBody(chain.Genesis().Hash()) == nil {
+		t.Errorf("genesis body pruned")
+	}
+}
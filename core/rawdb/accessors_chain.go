@@ -21,6 +21,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"sort"
 
@@ -64,21 +65,97 @@ func DeleteCanonicalHash(db ethdb.KeyValueWriter, number uint64) {
 
 // ReadAllHashes retrieves all the hashes assigned to blocks at a certain heights,
 // both canonical and reorged forks included.
-func ReadAllHashes(db ethdb.Iteratee, number uint64) []common.Hash {
-	prefix := headerKeyPrefix(number)
-
+//
+// The hashes are read from the block hash index once the blocks written before
+// it are indexed, which doesn't need to iterate over the header entries,
+// interleaved with the total difficulties and canonical mappings.
+func ReadAllHashes(db ethdb.KeyValueStore, number uint64) []common.Hash {
+	prefix, keyLength := headerKeyPrefix(number), len(headerPrefix)+8+common.HashLength
+	if HasBlockHashIndex(db) {
+		prefix, keyLength = blockHashIndexKeyPrefix(number), len(blockHashIndexPrefix)+8+common.HashLength
+	}
 	hashes := make([]common.Hash, 0, 1)
 	it := db.NewIterator(prefix, nil)
 	defer it.Release()
 
 	for it.Next() {
-		if key := it.Key(); len(key) == len(prefix)+32 {
-			hashes = append(hashes, common.BytesToHash(key[len(key)-32:]))
+		if key := it.Key(); len(key) == keyLength {
+			hashes = append(hashes, common.BytesToHash(key[len(key)-common.HashLength:]))
 		}
 	}
 	return hashes
 }
 
+// ReadBlockHashes retrieves the hashes of all the known blocks at a certain
+// height, canonical and side chains alike. Blocks which have been moved into
+// the ancient store only retain the canonical one.
+func ReadBlockHashes(db ethdb.Database, number uint64) []common.Hash {
+	var hashes []common.Hash
+	db.ReadAncients(func(reader ethdb.AncientReaderOp) error {
+		if data, _ := reader.Ancient(chainFreezerHashTable, number); len(data) > 0 {
+			hashes = append(hashes, common.BytesToHash(data))
+		}
+		return nil
+	})
+	for _, hash := range ReadAllHashes(db, number) {
+		if len(hashes) == 0 || hashes[0] != hash {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// HasBlockHashIndex returns whether the hashes of all the blocks in the key-value
+// store are indexed, including the ones written before the block hash index.
+func HasBlockHashIndex(db ethdb.KeyValueReader) bool {
+	_, migrating := ReadBlockHashIndexProgress(db)
+	return !migrating
+}
+
+// ReadBlockHashIndexProgress retrieves the number of the block the migration
+// indexing the hashes of the existing blocks resumes at, and whether the
+// migration is still pending.
+func ReadBlockHashIndexProgress(db ethdb.KeyValueReader) (uint64, bool) {
+	data, err := db.Get(blockHashIndexProgressKey)
+	if err != nil {
+		return 0, true // Not started yet
+	}
+	if len(data) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(data), true
+}
+
+// WriteBlockHashIndexProgress stores the number of the block the migration
+// indexing the hashes of the existing blocks resumes at.
+func WriteBlockHashIndexProgress(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(blockHashIndexProgressKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store block hash index progress", "err", err)
+	}
+}
+
+// WriteBlockHashIndexDone marks the migration indexing the hashes of the
+// existing blocks as done.
+func WriteBlockHashIndexDone(db ethdb.KeyValueWriter) {
+	if err := db.Put(blockHashIndexProgressKey, []byte{}); err != nil {
+		log.Crit("Failed to store block hash index progress", "err", err)
+	}
+}
+
+// writeBlockHashIndex adds a block to the set of known blocks at its height.
+func writeBlockHashIndex(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Put(blockHashIndexKey(number, hash), nil); err != nil {
+		log.Crit("Failed to store block hash index", "err", err)
+	}
+}
+
+// deleteBlockHashIndex removes a block from the set of known blocks at its height.
+func deleteBlockHashIndex(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(blockHashIndexKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block hash index", "err", err)
+	}
+}
+
 type NumberHash struct {
 	Number uint64
 	Hash   common.Hash
@@ -87,12 +164,16 @@ type NumberHash struct {
 // ReadAllHashes retrieves all the hashes assigned to blocks at a certain heights,
 // both canonical and reorged forks included.
 // This method considers both limits to be _inclusive_.
-func ReadAllHashesInRange(db ethdb.Iteratee, first, last uint64) []*NumberHash {
+func ReadAllHashesInRange(db ethdb.KeyValueStore, first, last uint64) []*NumberHash {
+	prefix := headerPrefix
+	if HasBlockHashIndex(db) {
+		prefix = blockHashIndexPrefix
+	}
 	var (
 		start     = encodeBlockNumber(first)
-		keyLength = len(headerPrefix) + 8 + 32
+		keyLength = len(prefix) + 8 + 32
 		hashes    = make([]*NumberHash, 0, 1+last-first)
-		it        = db.NewIterator(headerPrefix, start)
+		it        = db.NewIterator(prefix, start)
 	)
 	defer it.Release()
 	for it.Next() {
@@ -100,7 +181,7 @@ func ReadAllHashesInRange(db ethdb.Iteratee, first, last uint64) []*NumberHash {
 		if len(key) != keyLength {
 			continue
 		}
-		num := binary.BigEndian.Uint64(key[len(headerPrefix) : len(headerPrefix)+8])
+		num := binary.BigEndian.Uint64(key[len(prefix) : len(prefix)+8])
 		if num > last {
 			break
 		}
@@ -243,6 +324,100 @@ func WriteLastPivotNumber(db ethdb.KeyValueWriter, pivot uint64) {
 	}
 }
 
+// ReadTdFreezeBlock retrieves the number of the first block whose total
+// difficulty is not stored anymore. If the total difficulty is tracked for the
+// entire chain, nil is returned.
+func ReadTdFreezeBlock(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(tdFreezeBlockKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var number uint64
+	if err := rlp.DecodeBytes(data, &number); err != nil {
+		log.Error("Invalid td freeze block number in database", "err", err)
+		return nil
+	}
+	return &number
+}
+
+// WriteTdFreezeBlock stores the number of the first block whose total
+// difficulty is not stored anymore.
+func WriteTdFreezeBlock(db ethdb.KeyValueWriter, number uint64) {
+	enc, err := rlp.EncodeToBytes(number)
+	if err != nil {
+		log.Crit("Failed to encode td freeze block number", "err", err)
+	}
+	if err := db.Put(tdFreezeBlockKey, enc); err != nil {
+		log.Crit("Failed to store td freeze block number", "err", err)
+	}
+}
+
+// PrunedRange is a range of canonical blocks whose bodies or receipts were
+// pruned on request, the missing data being frozen as empty items.
+type PrunedRange struct {
+	From     uint64 // First block of the range
+	To       uint64 // Last block of the range, inclusive
+	Bodies   bool   // Whether the bodies were pruned
+	Receipts bool   // Whether the receipts were pruned
+}
+
+// ReadPrunedRanges retrieves the ranges of canonical blocks whose bodies or
+// receipts were pruned.
+func ReadPrunedRanges(db ethdb.KeyValueReader) []PrunedRange {
+	data, _ := db.Get(prunedRangesKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var ranges []PrunedRange
+	if err := rlp.DecodeBytes(data, &ranges); err != nil {
+		log.Error("Invalid pruned ranges in database", "err", err)
+		return nil
+	}
+	return ranges
+}
+
+// WritePrunedRanges stores the ranges of canonical blocks whose bodies or
+// receipts were pruned.
+func WritePrunedRanges(db ethdb.KeyValueWriter, ranges []PrunedRange) {
+	enc, err := rlp.EncodeToBytes(ranges)
+	if err != nil {
+		log.Crit("Failed to encode pruned ranges", "err", err)
+	}
+	if err := db.Put(prunedRangesKey, enc); err != nil {
+		log.Crit("Failed to store pruned ranges", "err", err)
+	}
+}
+
+// ReadHistoryTail retrieves the number of the first block whose body and
+// receipts are kept by the history pruning, zero if it never ran.
+func ReadHistoryTail(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(historyTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteHistoryTail stores the number of the first block whose body and
+// receipts are kept by the history pruning.
+func WriteHistoryTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(historyTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the history tail", "err", err)
+	}
+}
+
+// prunedAt reports whether the body and the receipts of the given canonical
+// block were pruned.
+func prunedAt(ranges []PrunedRange, number uint64) (body bool, receipts bool) {
+	for _, r := range ranges {
+		if number >= r.From && number <= r.To {
+			body = body || r.Bodies
+			receipts = receipts || r.Receipts
+		}
+	}
+	return body, receipts
+}
+
 // ReadFastTrieProgress retrieves the number of tries nodes fast synced to allow
 // reporting correct numbers across restarts.
 func ReadFastTrieProgress(db ethdb.KeyValueReader) uint64 {
@@ -342,7 +517,7 @@ func ReadHeader(db ethdb.Reader, hash common.Hash, number uint64) *types.Header
 }
 
 // WriteHeader stores a block header into the database and also stores the hash-
-// to-number mapping.
+// to-number mapping and the block hash index entry.
 func WriteHeader(db ethdb.KeyValueWriter, header *types.Header) {
 	var (
 		hash   = header.Hash()
@@ -360,6 +535,8 @@ func WriteHeader(db ethdb.KeyValueWriter, header *types.Header) {
 	if err := db.Put(key, data); err != nil {
 		log.Crit("Failed to store header", "err", err)
 	}
+	// Track the block among all the known ones at its height
+	writeBlockHashIndex(db, hash, number)
 }
 
 // DeleteHeader removes all block header data associated with a hash.
@@ -370,12 +547,13 @@ func DeleteHeader(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
-// deleteHeaderWithoutNumber removes only the block header but does not remove
-// the hash to number mapping.
+// deleteHeaderWithoutNumber removes only the block header and its block hash
+// index entry but does not remove the hash to number mapping.
 func deleteHeaderWithoutNumber(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	if err := db.Delete(headerKey(number, hash)); err != nil {
 		log.Crit("Failed to delete header", "err", err)
 	}
+	deleteBlockHashIndex(db, hash, number)
 }
 
 // isCanon is an internal utility method, to check whether the given number/hash
@@ -395,10 +573,13 @@ func ReadBodyRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue
 	// the canonical data.
 	var data []byte
 	db.ReadAncients(func(reader ethdb.AncientReaderOp) error {
-		// Check if the data is in ancients
+		// Check if the data is in ancients, the genesis being also kept in
+		// leveldb in case it was pruned from them
 		if isCanon(reader, number, hash) {
 			data, _ = reader.Ancient(chainFreezerBodiesTable, number)
-			return nil
+			if len(data) > 0 || number > 0 {
+				return nil
+			}
 		}
 		// If not, try reading from leveldb
 		data, _ = db.Get(blockBodyKey(number, hash))
@@ -563,10 +744,13 @@ func HasReceipts(db ethdb.Reader, hash common.Hash, number uint64) bool {
 func ReadReceiptsRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
 	var data []byte
 	db.ReadAncients(func(reader ethdb.AncientReaderOp) error {
-		// Check if the data is in ancients
+		// Check if the data is in ancients, the genesis being also kept in
+		// leveldb in case it was pruned from them
 		if isCanon(reader, number, hash) {
 			data, _ = reader.Ancient(chainFreezerReceiptTable, number)
-			return nil
+			if len(data) > 0 || number > 0 {
+				return nil
+			}
 		}
 		// If not, try reading from leveldb
 		data, _ = db.Get(blockReceiptsKey(number, hash))
@@ -597,6 +781,29 @@ func ReadRawReceipts(db ethdb.Reader, hash common.Hash, number uint64) types.Rec
 	return receipts
 }
 
+// ReadReceiptsNoBloomRLP retrieves all the transaction receipts belonging to a
+// block in their network encoding without blooms, typed after the transactions
+// of the block body. It returns nil if the receipts or the body are missing.
+func ReadReceiptsNoBloomRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
+	receipts := ReadRawReceipts(db, hash, number)
+	if receipts == nil {
+		return nil
+	}
+	body := ReadBody(db, hash, number)
+	if body == nil || len(body.Transactions) != len(receipts) {
+		return nil
+	}
+	for i, receipt := range receipts {
+		receipt.Type = body.Transactions[i].Type()
+	}
+	data, err := rlp.EncodeToBytes(receipts.NoBloom())
+	if err != nil {
+		log.Error("Failed to encode block receipts", "hash", hash, "number", number, "err", err)
+		return nil
+	}
+	return data
+}
+
 // ReadReceipts retrieves all the transaction receipts belonging to a block, including
 // its correspoinding metadata fields. If it is unable to populate these metadata
 // fields then nil is returned.
@@ -616,11 +823,7 @@ func ReadReceipts(db ethdb.Reader, hash common.Hash, number uint64, config *para
 		return nil
 	}
 	header := ReadHeader(db, hash, number)
-	var blobGasPrice *big.Int
-	if header != nil && header.ExcessBlobGas != nil {
-		blobGasPrice = eip4844.CalcBlobFee(*header.ExcessBlobGas)
-	}
-	if err := receipts.DeriveFields(config, hash, number, blobGasPrice, body.Transactions); err != nil {
+	if err := receipts.DeriveFields(config, hash, number, eip4844.HeaderBlobFee(header), body.Transactions); err != nil {
 		log.Error("Failed to derive block receipts fields", "hash", hash, "number", number, "err", err)
 		return nil
 	}
@@ -651,6 +854,63 @@ func DeleteReceipts(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	}
 }
 
+// HasLegacyReceipts reports whether the receipts of a block are stored in the
+// key-value store in one of the layouts of the database versions before 5, which
+// may lack the blooms of the receipts. The frozen receipts aren't considered.
+func HasLegacyReceipts(db ethdb.KeyValueReader, hash common.Hash, number uint64) bool {
+	data, _ := db.Get(blockReceiptsKey(number, hash))
+	return isLegacyReceiptsRLP(data)
+}
+
+// isLegacyReceiptsRLP reports whether a stored receipt list is in a legacy layout,
+// told apart from the current one by the number of fields of its first receipt.
+func isLegacyReceiptsRLP(data []byte) bool {
+	list, _, err := rlp.SplitList(data)
+	if err != nil || len(list) == 0 {
+		return false
+	}
+	first, _, err := rlp.SplitList(list)
+	if err != nil {
+		return false
+	}
+	fields, err := rlp.CountValues(first)
+	return err == nil && fields != 3
+}
+
+// RewriteLegacyReceipts re-encodes the receipts of a block stored in the key-value
+// store in a legacy layout into the current one, whose blooms are regenerated from
+// the logs on every read. It returns whether the receipts were rewritten, the ones
+// in the current layout or in the freezer being left as they are.
+func RewriteLegacyReceipts(db ethdb.KeyValueReader, w ethdb.KeyValueWriter, hash common.Hash, number uint64) bool {
+	data, _ := db.Get(blockReceiptsKey(number, hash))
+	if !isLegacyReceiptsRLP(data) {
+		return false
+	}
+	var stored []*types.ReceiptForStorage
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		log.Error("Invalid receipt array RLP", "hash", hash, "err", err)
+		return false
+	}
+	receipts := make(types.Receipts, len(stored))
+	for i, receipt := range stored {
+		receipts[i] = (*types.Receipt)(receipt)
+	}
+	WriteReceipts(w, hash, number, receipts)
+	return true
+}
+
+// BlockDataSize returns the number of bytes, keys included, the body and the
+// receipts of a block take in the key-value store, zero for those not stored.
+func BlockDataSize(db ethdb.KeyValueReader, hash common.Hash, number uint64) (body int, receipts int) {
+	if data, _ := db.Get(blockBodyKey(number, hash)); len(data) > 0 {
+		body = len(blockBodyKey(number, hash)) + len(data)
+	}
+	if data, _ := db.Get(blockReceiptsKey(number, hash)); len(data) > 0 {
+		receipts = len(blockReceiptsKey(number, hash)) + len(data)
+	}
+	return body, receipts
+}
+
 // storedReceiptRLP is the storage encoding of a receipt.
 // Re-definition in core/types/receipt.go.
 type storedReceiptRLP struct {
@@ -766,7 +1026,7 @@ func ReadBlock(db ethdb.Reader, hash common.Hash, number uint64) *types.Block {
 	if body == nil {
 		return nil
 	}
-	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
+	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles).WithOperations(body.Withdrawals, body.Deposits)
 }
 
 // WriteBlock serializes a block into the database, header and body separately.
@@ -777,6 +1037,13 @@ func WriteBlock(db ethdb.KeyValueWriter, block *types.Block) {
 
 // WriteAncientBlock writes entire block data into ancient store and returns the total written size.
 func WriteAncientBlocks(db ethdb.AncientWriter, blocks []*types.Block, receipts []types.Receipts, td *big.Int) (int64, error) {
+	return WriteAncientBlocksWithTdFreeze(db, blocks, receipts, td, math.MaxUint64)
+}
+
+// WriteAncientBlocksWithTdFreeze writes entire block data into ancient store like
+// WriteAncientBlocks, but stops accumulating the total difficulty from the given
+// freeze block onwards.
+func WriteAncientBlocksWithTdFreeze(db ethdb.AncientWriter, blocks []*types.Block, receipts []types.Receipts, td *big.Int, freeze uint64) (int64, error) {
 	var (
 		tdSum      = new(big.Int).Set(td)
 		stReceipts []*types.ReceiptForStorage
@@ -789,7 +1056,7 @@ func WriteAncientBlocks(db ethdb.AncientWriter, blocks []*types.Block, receipts
 				stReceipts = append(stReceipts, (*types.ReceiptForStorage)(receipt))
 			}
 			header := block.Header()
-			if i > 0 {
+			if i > 0 && block.NumberU64() < freeze {
 				tdSum.Add(tdSum, header.Difficulty)
 			}
 			if err := writeAncientBlock(op, block, header, stReceipts, tdSum); err != nil {
@@ -827,6 +1094,7 @@ func DeleteBlock(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
 	DeleteBlobSidecars(db, hash, number)
+	DeleteBlobSidecarsStatus(db, hash, number)
 }
 
 // DeleteBlockWithoutNumber removes all block data associated with a hash, except
@@ -867,7 +1135,7 @@ func ReadBadBlock(db ethdb.Reader, hash common.Hash) *types.Block {
 	}
 	for _, bad := range badBlocks {
 		if bad.Header.Hash() == hash {
-			return types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles)
+			return types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles).WithOperations(bad.Body.Withdrawals, bad.Body.Deposits)
 		}
 	}
 	return nil
@@ -886,7 +1154,7 @@ func ReadAllBadBlocks(db ethdb.Reader) []*types.Block {
 	}
 	var blocks []*types.Block
 	for _, bad := range badBlocks {
-		blocks = append(blocks, types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles))
+		blocks = append(blocks, types.NewBlockWithHeader(bad.Header).WithBody(bad.Body.Transactions, bad.Body.Uncles).WithOperations(bad.Body.Withdrawals, bad.Body.Deposits))
 	}
 	return blocks
 }
@@ -1031,11 +1299,18 @@ func ReadDirtyAccounts(db ethdb.KeyValueReader) []*types.DirtyStateAccountsAndBl
 
 // ReadBlobSidecarsRLP retrieves the block sidecars (blobs, commitments and proofs) in RLP encoding.
 func ReadBlobSidecarsRLP(db ethdb.Reader, hash common.Hash, number uint64) rlp.RawValue {
-	// As sidecars are not stored into ancient database, try to
-	// read sidecars from leveldb instead.
-	var data []byte
-	data, _ = db.Get(blobSidecarsKey(number, hash))
-	return data
+	data, _ := db.Get(blobSidecarsKey(number, hash))
+	if len(data) > 0 {
+		return data
+	}
+	// The sidecars of the frozen blocks are moved into the blob freezer, keyed
+	// by number only, so make sure the block is the canonical one.
+	if blobs := blobFreezerOf(db); blobs != nil {
+		if data := blobs.sidecars(number); len(data) > 0 && ReadCanonicalHash(db, number) == hash {
+			return data
+		}
+	}
+	return nil
 }
 
 // WriteBlobSidecarsRLP stores an RLP encoded block sidecars into the database.
@@ -1074,3 +1349,38 @@ func DeleteBlobSidecars(db ethdb.KeyValueWriter, hash common.Hash, number uint64
 		log.Crit("Failed to delete block sidecars", "err", err)
 	}
 }
+
+// Verification statuses of the sidecars of a block imported without being
+// executed.
+const (
+	BlobSidecarsUnverified byte = iota // Sidecars never verified, the default
+	BlobSidecarsVerified               // Sidecars verified against the blob commitments of the block
+	BlobSidecarsExpired                // Sidecars dropped, the block is beyond the blob keep period
+)
+
+// ReadBlobSidecarsStatus retrieves the verification status of the sidecars of
+// the block corresponding to the hash. The status outlives the sidecars, it's
+// kept in the key-value store once the block is frozen or its sidecars pruned.
+func ReadBlobSidecarsStatus(db ethdb.KeyValueReader, hash common.Hash, number uint64) byte {
+	data, _ := db.Get(blobSidecarsStatusKey(number, hash))
+	if len(data) != 1 {
+		return BlobSidecarsUnverified
+	}
+	return data[0]
+}
+
+// WriteBlobSidecarsStatus stores the verification status of the sidecars of a
+// block into the database.
+func WriteBlobSidecarsStatus(db ethdb.KeyValueWriter, hash common.Hash, number uint64, status byte) {
+	if err := db.Put(blobSidecarsStatusKey(number, hash), []byte{status}); err != nil {
+		log.Crit("Failed to store block sidecars status", "err", err)
+	}
+}
+
+// DeleteBlobSidecarsStatus removes the verification status of the sidecars of
+// a block.
+func DeleteBlobSidecarsStatus(db ethdb.KeyValueWriter, hash common.Hash, number uint64) {
+	if err := db.Delete(blobSidecarsStatusKey(number, hash)); err != nil {
+		log.Crit("Failed to delete block sidecars status", "err", err)
+	}
+}
@@ -415,6 +415,83 @@ func TestBlockReceiptStorage(t *testing.T) {
 	}
 }
 
+// v3StoredReceiptRLP is the storage layout of the receipts of database version 3,
+// whose bloom some databases left empty.
+type v3StoredReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             types.Bloom
+	TxHash            common.Hash
+	ContractAddress   common.Address
+	Logs              []*types.LogForStorage
+	GasUsed           uint64
+}
+
+// Tests that the receipts stored in a legacy layout without their blooms are read
+// with their blooms regenerated, and rewritten into the current layout.
+func TestLegacyReceiptsRewrite(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	tx := types.NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil)
+	receipt := &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 1,
+		Logs: []*types.Log{
+			{Address: common.BytesToAddress([]byte{0x11}), Topics: []common.Hash{{0x01}}},
+		},
+		TxHash:  tx.Hash(),
+		GasUsed: 1,
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	hash := common.Hash{0x01}
+	WriteBody(db, hash, 1, &types.Body{Transactions: types.Transactions{tx}})
+
+	// Store the receipt in the legacy layout, without its bloom
+	legacy, err := rlp.EncodeToBytes([]*v3StoredReceiptRLP{{
+		PostStateOrStatus: []byte{0x01},
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		TxHash:            receipt.TxHash,
+		Logs:              []*types.LogForStorage{(*types.LogForStorage)(receipt.Logs[0])},
+		GasUsed:           receipt.GasUsed,
+	}})
+	if err != nil {
+		t.Fatalf("failed to encode legacy receipts: %v", err)
+	}
+	if err := db.Put(blockReceiptsKey(1, hash), legacy); err != nil {
+		t.Fatalf("failed to store legacy receipts: %v", err)
+	}
+	if !HasLegacyReceipts(db, hash, 1) {
+		t.Fatalf("legacy receipts not detected")
+	}
+	check := func() {
+		t.Helper()
+		receipts := ReadReceipts(db, hash, 1, params.TestChainConfig)
+		if len(receipts) != 1 || receipts[0].Bloom != receipt.Bloom {
+			t.Fatalf("bloom mismatch: have %v, want %x", receipts, receipt.Bloom)
+		}
+		if logs := ReadLogs(db, hash, 1, params.TestChainConfig); len(logs) != 1 || len(logs[0]) != 1 || logs[0][0].Address != receipt.Logs[0].Address {
+			t.Fatalf("logs mismatch: have %v", logs)
+		}
+	}
+	check()
+
+	if !RewriteLegacyReceipts(db, db, hash, 1) {
+		t.Fatalf("legacy receipts not rewritten")
+	}
+	if HasLegacyReceipts(db, hash, 1) {
+		t.Fatalf("rewritten receipts still detected as legacy")
+	}
+	check()
+
+	if RewriteLegacyReceipts(db, db, hash, 1) {
+		t.Fatalf("current receipts rewritten")
+	}
+	if RewriteLegacyReceipts(db, db, common.Hash{0x02}, 1) {
+		t.Fatalf("missing receipts rewritten")
+	}
+}
+
 func checkReceiptsRLP(have, want types.Receipts) error {
 	if len(have) != len(want) {
 		return fmt.Errorf("receipts sizes mismatch: have %d, want %d", len(have), len(want))
@@ -576,6 +653,37 @@ func TestHashesInRange(t *testing.T) {
 	}
 }
 
+// Tests that the block hash index tracks all the blocks at a height and that it
+// is pruned along with the blocks.
+func TestBlockHashIndex(t *testing.T) {
+	db := NewMemoryDatabase()
+	WriteBlockHashIndexDone(db) // Nothing to migrate, read through the index
+
+	var headers []*types.Header
+	for i := 0; i < 3; i++ {
+		header := &types.Header{Number: big.NewInt(10), Extra: []byte{byte(i)}}
+		WriteHeader(db, header)
+		WriteTd(db, header.Hash(), 10, big.NewInt(int64(i)))
+		headers = append(headers, header)
+	}
+	WriteCanonicalHash(db, headers[0].Hash(), 10)
+	WriteHeader(db, &types.Header{Number: big.NewInt(11)})
+
+	if have, want := len(ReadBlockHashes(db, 10)), 3; have != want {
+		t.Fatalf("Wrong number of hashes read, want %d, got %d", want, have)
+	}
+	if have, want := len(ReadBlockHashes(db, 12)), 0; have != want {
+		t.Fatalf("Wrong number of hashes read, want %d, got %d", want, have)
+	}
+	DeleteBlock(db, headers[1].Hash(), 10)
+	DeleteBlockWithoutNumber(db, headers[2].Hash(), 10)
+
+	hashes := ReadBlockHashes(db, 10)
+	if len(hashes) != 1 || hashes[0] != headers[0].Hash() {
+		t.Fatalf("Wrong hashes after pruning, want [%x], got %x", headers[0].Hash(), hashes)
+	}
+}
+
 // This measures the write speed of the WriteAncientBlocks operation.
 func BenchmarkWriteAncientBlocks(b *testing.B) {
 	// Open freezer database.
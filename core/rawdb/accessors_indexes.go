@@ -18,9 +18,12 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
@@ -86,6 +89,16 @@ func DeleteTxLookupEntry(db ethdb.KeyValueWriter, hash common.Hash) {
 	}
 }
 
+// TxLookupSize returns the number of bytes, the key included, the lookup entry
+// of a transaction takes, zero if it isn't indexed.
+func TxLookupSize(db ethdb.KeyValueReader, hash common.Hash) int {
+	data, _ := db.Get(txLookupKey(hash))
+	if len(data) == 0 {
+		return 0
+	}
+	return len(txLookupKey(hash)) + len(data)
+}
+
 // DeleteTxLookupEntries removes all transaction lookups for a given block.
 func DeleteTxLookupEntries(db ethdb.KeyValueWriter, hashes []common.Hash) {
 	for _, hash := range hashes {
@@ -93,6 +106,126 @@ func DeleteTxLookupEntries(db ethdb.KeyValueWriter, hashes []common.Hash) {
 	}
 }
 
+// ReceiptLookupEntry is the position and outcome of a canonical transaction,
+// allowing its receipt to be retrieved without decoding the others of its block.
+type ReceiptLookupEntry struct {
+	BlockNumber uint64
+	Index       uint64
+	Success     bool
+}
+
+// ReadReceiptLookupEntry retrieves the position and outcome of a transaction.
+func ReadReceiptLookupEntry(db ethdb.KeyValueReader, hash common.Hash) *ReceiptLookupEntry {
+	data, _ := db.Get(receiptLookupKey(hash))
+	if len(data) != 13 {
+		return nil
+	}
+	return &ReceiptLookupEntry{
+		BlockNumber: binary.BigEndian.Uint64(data[:8]),
+		Index:       uint64(binary.BigEndian.Uint32(data[8:12])),
+		Success:     data[12] == 1,
+	}
+}
+
+// WriteReceiptLookupEntries stores the position and outcome of every transaction
+// of a block, given its receipts.
+func WriteReceiptLookupEntries(db ethdb.KeyValueWriter, block *types.Block, receipts types.Receipts) {
+	txs := block.Transactions()
+	if len(txs) != len(receipts) {
+		log.Error("Receipt count mismatch", "number", block.NumberU64(), "hash", block.Hash(), "txs", len(txs), "receipts", len(receipts))
+		return
+	}
+	for i, tx := range txs {
+		data := make([]byte, 13)
+		binary.BigEndian.PutUint64(data[:8], block.NumberU64())
+		binary.BigEndian.PutUint32(data[8:12], uint32(i))
+		if receipts[i].Status == types.ReceiptStatusSuccessful {
+			data[12] = 1
+		}
+		if err := db.Put(receiptLookupKey(tx.Hash()), data); err != nil {
+			log.Crit("Failed to store receipt lookup entry", "err", err)
+		}
+	}
+}
+
+// DeleteReceiptLookupEntries removes the receipt lookups of the given transactions.
+func DeleteReceiptLookupEntries(db ethdb.KeyValueWriter, hashes []common.Hash) {
+	for _, hash := range hashes {
+		if err := db.Delete(receiptLookupKey(hash)); err != nil {
+			log.Crit("Failed to delete receipt lookup entry", "err", err)
+		}
+	}
+}
+
+// ReadReceiptIndexTail retrieves the number of the oldest block whose receipt
+// lookups were backfilled, nil if no backfill started.
+func ReadReceiptIndexTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(receiptIndexTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteReceiptIndexTail stores the number of the oldest block whose receipt
+// lookups were backfilled.
+func WriteReceiptIndexTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(receiptIndexTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the receipt index tail", "err", err)
+	}
+}
+
+// DeleteReceiptIndexTail removes the backfill progress of the receipt lookups,
+// for a later backfill to start over from the head.
+func DeleteReceiptIndexTail(db ethdb.KeyValueWriter) {
+	if err := db.Delete(receiptIndexTailKey); err != nil {
+		log.Crit("Failed to delete the receipt index tail", "err", err)
+	}
+}
+
+// ContractCreation is the metadata of the transaction deploying a contract.
+type ContractCreation struct {
+	Creator      common.Address // Account executing the creation, an EOA or a factory contract
+	TxHash       common.Hash    // Hash of the transaction the creation happened in
+	BlockHash    common.Hash
+	BlockNumber  uint64
+	InitCodeHash common.Hash // Keccak256 hash of the creation code
+}
+
+// ReadContractCreation retrieves the creation metadata of a contract.
+func ReadContractCreation(db ethdb.KeyValueReader, addr common.Address) *ContractCreation {
+	data, _ := db.Get(contractCreationKey(addr))
+	if len(data) == 0 {
+		return nil
+	}
+	creation := new(ContractCreation)
+	if err := rlp.DecodeBytes(data, creation); err != nil {
+		log.Error("Invalid contract creation RLP", "address", addr, "blob", data, "err", err)
+		return nil
+	}
+	return creation
+}
+
+// WriteContractCreation stores the creation metadata of a contract, replacing
+// any earlier record of the same address.
+func WriteContractCreation(db ethdb.KeyValueWriter, addr common.Address, creation *ContractCreation) {
+	data, err := rlp.EncodeToBytes(creation)
+	if err != nil {
+		log.Crit("Failed to encode contract creation", "err", err)
+	}
+	if err := db.Put(contractCreationKey(addr), data); err != nil {
+		log.Crit("Failed to store contract creation", "err", err)
+	}
+}
+
+// DeleteContractCreation removes the creation metadata of a contract.
+func DeleteContractCreation(db ethdb.KeyValueWriter, addr common.Address) {
+	if err := db.Delete(contractCreationKey(addr)); err != nil {
+		log.Crit("Failed to delete contract creation", "err", err)
+	}
+}
+
 // ReadTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func ReadTransaction(db ethdb.Reader, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -141,6 +274,154 @@ func ReadReceipt(db ethdb.Reader, hash common.Hash, config *params.ChainConfig)
 	return nil, common.Hash{}, 0, 0
 }
 
+// ReadCanonicalReceipt retrieves a specific transaction receipt of the canonical
+// chain, along with its added positional metadata. Transactions covered by the
+// receipt lookup index are resolved by decoding their own receipt only, the
+// others fall back to ReadReceipt.
+func ReadCanonicalReceipt(db ethdb.Reader, hash common.Hash, config *params.ChainConfig) (*types.Receipt, common.Hash, uint64, uint64) {
+	entry := ReadReceiptLookupEntry(db, hash)
+	if entry == nil {
+		return ReadReceipt(db, hash, config)
+	}
+	blockHash := ReadCanonicalHash(db, entry.BlockNumber)
+	if blockHash == (common.Hash{}) {
+		return nil, common.Hash{}, 0, 0
+	}
+	// Entries left behind by a reorg or a rewind point to another transaction,
+	// or beyond the transactions of the canonical block
+	tx, err := readTransactionAt(db, blockHash, entry.BlockNumber, entry.Index)
+	if err != nil || tx.Hash() != hash {
+		return ReadReceipt(db, hash, config)
+	}
+	receipt, gas, logs, err := readRawReceiptAt(db, blockHash, entry.BlockNumber, entry.Index)
+	if err != nil {
+		return ReadReceipt(db, hash, config)
+	}
+	// Derive the receipt fields as if it was alone in its block, then shift the
+	// ones depending on the receipts before it
+	blobGasPrice := eip4844.HeaderBlobFee(ReadHeader(db, blockHash, entry.BlockNumber))
+	if err := (types.Receipts{receipt}).DeriveFields(config, blockHash, entry.BlockNumber, blobGasPrice, types.Transactions{tx}); err != nil {
+		log.Error("Failed to derive receipt fields", "hash", blockHash, "number", entry.BlockNumber, "txhash", hash, "err", err)
+		return nil, common.Hash{}, 0, 0
+	}
+	receipt.TransactionIndex = uint(entry.Index)
+	receipt.GasUsed = receipt.CumulativeGasUsed - gas
+	for i, l := range receipt.Logs {
+		l.TxIndex = uint(entry.Index)
+		l.Index = logs + uint(i)
+	}
+	return receipt, blockHash, entry.BlockNumber, entry.Index
+}
+
+// readTransactionAt decodes the transaction at the given index of a block body,
+// skipping over the ones before it.
+func readTransactionAt(db ethdb.Reader, hash common.Hash, number uint64, index uint64) (*types.Transaction, error) {
+	data := ReadBodyRLP(db, hash, number)
+	if len(data) == 0 {
+		return nil, errors.New("missing body")
+	}
+	body, _, err := rlp.SplitList(data)
+	if err != nil {
+		return nil, err
+	}
+	txs, _, err := rlp.SplitList(body)
+	if err != nil {
+		return nil, err
+	}
+	elem, err := rlpElementAt(txs, index)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(elem, tx); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// readRawReceiptAt decodes the receipt at the given index of a block, along with
+// the cumulative gas used and the number of logs emitted before it. The receipts
+// before it are skimmed over without decoding their logs, which only works with
+// the current storage encoding, the legacy ones are reported as errors.
+func readRawReceiptAt(db ethdb.Reader, hash common.Hash, number uint64, index uint64) (*types.Receipt, uint64, uint, error) {
+	data := ReadReceiptsRLP(db, hash, number)
+	if len(data) == 0 {
+		return nil, 0, 0, errors.New("missing receipts")
+	}
+	receipts, _, err := rlp.SplitList(data)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	var (
+		gas  uint64
+		logs uint
+	)
+	for i := uint64(0); i < index; i++ {
+		elem, rest, err := rlpElement(receipts)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		// Stored receipts are [status, cumulative gas, logs]
+		fields, _, err := rlp.SplitList(elem)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		_, _, fields, err = rlp.Split(fields)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if gas, fields, err = rlp.SplitUint64(fields); err != nil {
+			return nil, 0, 0, err
+		}
+		list, fields, err := rlp.SplitList(fields)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if len(fields) != 0 {
+			return nil, 0, 0, errors.New("legacy receipt encoding")
+		}
+		count, err := rlp.CountValues(list)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		logs += uint(count)
+		receipts = rest
+	}
+	elem, _, err := rlpElement(receipts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	receipt := new(types.ReceiptForStorage)
+	if err := rlp.DecodeBytes(elem, receipt); err != nil {
+		return nil, 0, 0, err
+	}
+	return (*types.Receipt)(receipt), gas, logs, nil
+}
+
+// rlpElement splits off the first encoded element of an RLP list content.
+func rlpElement(list []byte) ([]byte, []byte, error) {
+	if len(list) == 0 {
+		return nil, nil, errors.New("index out of range")
+	}
+	_, _, rest, err := rlp.Split(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list[:len(list)-len(rest)], rest, nil
+}
+
+// rlpElementAt returns the encoded element at the given index of an RLP list
+// content.
+func rlpElementAt(list []byte, index uint64) ([]byte, error) {
+	for i := uint64(0); ; i++ {
+		elem, rest, err := rlpElement(list)
+		if err != nil || i == index {
+			return elem, err
+		}
+		list = rest
+	}
+}
+
 // ReadBloomBits retrieves the compressed bloom bit vector belonging to the given
 // section and bit index from the.
 func ReadBloomBits(db ethdb.KeyValueReader, bit uint, section uint64, head common.Hash) ([]byte, error) {
@@ -20,10 +20,12 @@ import (
 	"bytes"
 	"hash"
 	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
@@ -135,6 +137,71 @@ func TestLookupStorage(t *testing.T) {
 	}
 }
 
+// Tests that receipts looked up through the receipt index match the ones read
+// along with the whole receipt list of their block.
+func TestCanonicalReceiptLookup(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	key, _ := crypto.GenerateKey()
+	signer := types.LatestSigner(params.TestChainConfig)
+
+	var (
+		txs      types.Transactions
+		receipts types.Receipts
+		gas      uint64
+	)
+	for i := 0; i < 4; i++ {
+		var to *common.Address
+		if i != 2 {
+			to = &common.Address{byte(i)}
+		}
+		tx, _ := types.SignNewTx(key, signer, &types.LegacyTx{Nonce: uint64(i), To: to, Gas: 100000, GasPrice: big.NewInt(1)})
+		txs = append(txs, tx)
+
+		gas += 21000 + uint64(i)*1000
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: gas}
+		if i == 1 {
+			receipt.Status = types.ReceiptStatusFailed
+		}
+		for j := 0; j < i; j++ {
+			receipt.Logs = append(receipt.Logs, &types.Log{Address: common.Address{byte(j)}, Topics: []common.Hash{{byte(i)}}, Data: []byte{byte(j)}})
+		}
+		receipts = append(receipts, receipt)
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(314)}, txs, nil, receipts, newTestHasher())
+
+	WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+	WriteBlock(db, block)
+	WriteReceipts(db, block.Hash(), block.NumberU64(), receipts)
+	WriteTxLookupEntriesByBlock(db, block)
+	WriteReceiptLookupEntries(db, block, receipts)
+
+	for i, tx := range txs {
+		entry := ReadReceiptLookupEntry(db, tx.Hash())
+		if entry == nil || entry.BlockNumber != block.NumberU64() || entry.Index != uint64(i) || entry.Success != (i != 1) {
+			t.Fatalf("tx #%d: lookup entry mismatch: have %+v", i, entry)
+		}
+		want, wantHash, wantNumber, wantIndex := ReadReceipt(db, tx.Hash(), params.TestChainConfig)
+		have, hash, number, index := ReadCanonicalReceipt(db, tx.Hash(), params.TestChainConfig)
+		if have == nil || hash != wantHash || number != wantNumber || index != wantIndex {
+			t.Fatalf("tx #%d: positional metadata mismatch: have %x/%d/%d, want %x/%d/%d", i, hash, number, index, wantHash, wantNumber, wantIndex)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("tx #%d: receipt mismatch: have %+v, want %+v", i, have, want)
+		}
+	}
+	// Stale entries fall back to the transaction lookups
+	DeleteTxLookupEntry(db, txs[3].Hash())
+	WriteReceiptLookupEntries(db, types.NewBlock(&types.Header{Number: big.NewInt(314)}, txs[3:], nil, receipts[:1], newTestHasher()), receipts[:1])
+	if receipt, _, _, _ := ReadCanonicalReceipt(db, txs[3].Hash(), params.TestChainConfig); receipt != nil {
+		t.Fatalf("unindexed transaction returned: %v", receipt)
+	}
+	WriteTxLookupEntriesByBlock(db, block)
+	if receipt, _, _, index := ReadCanonicalReceipt(db, txs[3].Hash(), params.TestChainConfig); receipt == nil || index != 3 || receipt.GasUsed != 24000 {
+		t.Fatalf("stale entry not resolved: %v at %d", receipt, index)
+	}
+}
+
 func TestDeleteBloomBits(t *testing.T) {
 	// Prepare testing data
 	db := NewMemoryDatabase()
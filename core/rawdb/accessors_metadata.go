@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"encoding/binary"
 	"encoding/json"
 	"time"
 
@@ -178,3 +179,79 @@ func WriteHighestFinalityVote(db ethdb.KeyValueStore, highestFinalityVote uint64
 		log.Crit("Failed to store highest finality vote", "err", err)
 	}
 }
+
+// ChainAuditEntry is a record of the chain mutation audit log. Each entry
+// commits to the hash of its predecessor, making the log tamper-evident.
+type ChainAuditEntry struct {
+	Seq     uint64      // Position of the entry in the log
+	Time    uint64      // Unix time of the mutation
+	Kind    string      // Kind of the mutation
+	Actor   string      // Originator of the mutation
+	Context string      // Free form details of the mutation
+	Number  uint64      // Number of the head block after the mutation
+	Hash    common.Hash // Hash of the head block after the mutation
+	Prev    common.Hash // Hash of the previous entry (zero for the first one)
+}
+
+// ReadChainAuditLength retrieves the number of entries in the chain mutation
+// audit log.
+func ReadChainAuditLength(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(chainAuditLengthKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// ReadChainAuditEntry retrieves an entry of the chain mutation audit log.
+func ReadChainAuditEntry(db ethdb.KeyValueReader, seq uint64) *ChainAuditEntry {
+	data, _ := db.Get(chainAuditKey(seq))
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(ChainAuditEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid chain audit entry RLP", "seq", seq, "blob", data, "err", err)
+		return nil
+	}
+	return entry
+}
+
+// WriteChainAuditEntry appends an entry to the chain mutation audit log.
+func WriteChainAuditEntry(db ethdb.KeyValueWriter, entry *ChainAuditEntry) {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		log.Crit("Failed to encode chain audit entry", "err", err)
+	}
+	if err := db.Put(chainAuditKey(entry.Seq), data); err != nil {
+		log.Crit("Failed to store chain audit entry", "err", err)
+	}
+	if err := db.Put(chainAuditLengthKey, encodeBlockNumber(entry.Seq+1)); err != nil {
+		log.Crit("Failed to store chain audit length", "err", err)
+	}
+}
+
+// DeleteChainAuditEntry removes an entry pruned from the chain mutation audit log.
+func DeleteChainAuditEntry(db ethdb.KeyValueWriter, seq uint64) {
+	if err := db.Delete(chainAuditKey(seq)); err != nil {
+		log.Crit("Failed to delete chain audit entry", "err", err)
+	}
+}
+
+// ReadChainAuditTail retrieves the sequence number of the oldest entry retained
+// in the chain mutation audit log.
+func ReadChainAuditTail(db ethdb.KeyValueReader) uint64 {
+	data, _ := db.Get(chainAuditTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteChainAuditTail stores the sequence number of the oldest entry retained in
+// the chain mutation audit log.
+func WriteChainAuditTail(db ethdb.KeyValueWriter, tail uint64) {
+	if err := db.Put(chainAuditTailKey, encodeBlockNumber(tail)); err != nil {
+		log.Crit("Failed to store chain audit tail", "err", err)
+	}
+}
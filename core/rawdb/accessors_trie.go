@@ -312,3 +312,52 @@ func ReadStateScheme(db ethdb.Reader) string {
 	}
 	return HashScheme
 }
+
+// ReadSchemeMigration retrieves the serialized progress of the online migration
+// of the hash-based state into the path-based layout.
+func ReadSchemeMigration(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(schemeMigrationKey)
+	return data
+}
+
+// WriteSchemeMigration stores the serialized progress of the online migration
+// of the hash-based state into the path-based layout.
+func WriteSchemeMigration(db ethdb.KeyValueWriter, progress []byte) {
+	if err := db.Put(schemeMigrationKey, progress); err != nil {
+		log.Crit("Failed to store scheme migration progress", "err", err)
+	}
+}
+
+// DeleteSchemeMigration deletes the progress of the online scheme migration.
+func DeleteSchemeMigration(db ethdb.KeyValueWriter) {
+	if err := db.Delete(schemeMigrationKey); err != nil {
+		log.Crit("Failed to remove scheme migration progress", "err", err)
+	}
+}
+
+// ReadLegacyTrieCleanup retrieves the database key the deletion of the legacy
+// hash-based trie nodes is to be resumed from, and whether it's pending at all.
+func ReadLegacyTrieCleanup(db ethdb.KeyValueReader) ([]byte, bool) {
+	if ok, _ := db.Has(legacyTrieCleanupKey); !ok {
+		return nil, false
+	}
+	data, _ := db.Get(legacyTrieCleanupKey)
+	return data, true
+}
+
+// WriteLegacyTrieCleanup stores the database key the deletion of the legacy
+// hash-based trie nodes is to be resumed from, an empty one marking it pending
+// from the start.
+func WriteLegacyTrieCleanup(db ethdb.KeyValueWriter, position []byte) {
+	if err := db.Put(legacyTrieCleanupKey, position); err != nil {
+		log.Crit("Failed to store legacy trie cleanup position", "err", err)
+	}
+}
+
+// DeleteLegacyTrieCleanup deletes the position of the legacy trie node deletion,
+// marking it complete.
+func DeleteLegacyTrieCleanup(db ethdb.KeyValueWriter) {
+	if err := db.Delete(legacyTrieCleanupKey); err != nil {
+		log.Crit("Failed to remove legacy trie cleanup position", "err", err)
+	}
+}
@@ -71,14 +71,31 @@ var chainFreezerNoSnappy = map[string]bool{
 	chainFreezerDifficultyTable: true,
 }
 
+// chainFreezerPrunable configures which ancient-tables may have their tail pruned
+// on their own. The headers, hashes and difficulties of the pruned blocks are kept.
+var chainFreezerPrunable = map[string]bool{
+	chainFreezerBodiesTable:  true,
+	chainFreezerReceiptTable: true,
+}
+
+// blobFreezerSidecarTable indicates the name of the freezer blob sidecars table.
+const blobFreezerSidecarTable = "sidecars"
+
+// blobFreezerNoSnappy configures whether compression is disabled for the blob
+// sidecars. Blobs are random data which don't compress well.
+var blobFreezerNoSnappy = map[string]bool{
+	blobFreezerSidecarTable: true,
+}
+
 // The list of identifiers of ancient stores. It can split more in the futures.
 var (
 	ChainFreezerName = "chain" // the folder name of chain segment ancient store.
 	StateFreezerName = "state" // the folder name of reverse diff ancient store.
+	BlobFreezerName  = "blobs" // the folder name of blob sidecars ancient store.
 )
 
 // freezers the collections of all builtin freezers.
-var freezers = []string{ChainFreezerName, StateFreezerName}
+var freezers = []string{ChainFreezerName, StateFreezerName, BlobFreezerName}
 
 // NewStateFreezer initializes the freezer for state history.
 func NewStateFreezer(ancientDir string, readOnly bool) (*ResettableFreezer, error) {
@@ -110,6 +110,18 @@ func inspectFreezers(db ethdb.Database) ([]freezerInfo, error) {
 			}
 			infos = append(infos, info)
 
+		case BlobFreezerName:
+			blobs := blobFreezerOf(db)
+			if blobs == nil {
+				log.Info("Skip inspecting blob freezer", "reason", "blob freezer is not opened")
+				continue
+			}
+			info, err := inspect(BlobFreezerName, blobFreezerNoSnappy, blobs)
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, info)
+
 		default:
 			return nil, fmt.Errorf("unknown freezer, supported ones: %v", freezers)
 		}
@@ -132,6 +144,8 @@ func InspectFreezerTable(ancient string, freezerName string, tableName string, s
 		path, tables = resolveChainFreezerDir(ancient), chainFreezerNoSnappy
 	case StateFreezerName:
 		path, tables = filepath.Join(ancient, freezerName), stateFreezerNoSnappy
+	case BlobFreezerName:
+		path, tables = filepath.Join(ancient, freezerName), blobFreezerNoSnappy
 	default:
 		return fmt.Errorf("unknown freezer, supported ones: %v", freezers)
 	}
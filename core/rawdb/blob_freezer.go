@@ -0,0 +1,196 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// blobFreezer is the ancient store of the sidecars of the frozen blocks. It is
+// kept apart from the chain freezer, whose tables always share the same head and
+// tail, as the sidecars are pruned long before the blocks.
+//
+// The tables of a freezer start at zero, while the first frozen sidecars belong
+// to the first block having any. The items are hence keyed by the block number
+// minus that of the first block, the offset. Until then, the offset tracks the
+// progress of the search for the first block.
+type blobFreezer struct {
+	*Freezer
+	db     ethdb.KeyValueStore // Key-value store persisting the offset
+	offset atomic.Uint64       // Number of the block of the first item
+}
+
+// newBlobFreezer opens the blob freezer in the given ancient directory. It returns
+// nil if the freezer is opened in read only mode but does not exist yet.
+func newBlobFreezer(ancient string, db ethdb.KeyValueStore, namespace string, readonly bool) (*blobFreezer, error) {
+	datadir := filepath.Join(ancient, BlobFreezerName)
+	if readonly && !common.FileExist(datadir) {
+		return nil, nil
+	}
+	freezer, err := NewFreezer(datadir, namespace, readonly, freezerTableSize, blobFreezerNoSnappy)
+	if err != nil {
+		return nil, err
+	}
+	f := &blobFreezer{Freezer: freezer, db: db}
+	if data, _ := db.Get(blobFreezerOffsetKey); len(data) == 8 {
+		f.offset.Store(binary.BigEndian.Uint64(data))
+	}
+	return f, nil
+}
+
+// setOffset updates the offset of the freezer, which must be empty.
+func (f *blobFreezer) setOffset(number uint64) error {
+	if err := f.db.Put(blobFreezerOffsetKey, encodeBlockNumber(number)); err != nil {
+		return err
+	}
+	f.offset.Store(number)
+	return nil
+}
+
+// next returns the number of the block whose sidecars are to be frozen next.
+func (f *blobFreezer) next() uint64 {
+	items, _ := f.Ancients()
+	return f.offset.Load() + items
+}
+
+// sidecars retrieves the frozen RLP encoded sidecars of the block of the given
+// number, nil if they were not frozen or were pruned.
+func (f *blobFreezer) sidecars(number uint64) []byte {
+	offset := f.offset.Load()
+	if number < offset {
+		return nil
+	}
+	data, _ := f.Ancient(blobFreezerSidecarTable, number-offset)
+	return data
+}
+
+// freeze appends the RLP encoded sidecars of the blocks following the last
+// frozen ones up to the given limit, retrieving the hashes of the blocks from
+// the chain freezer. Blocks without sidecars get empty items, save the ones
+// before the first block having any. The hashes of the blocks whose sidecars
+// were frozen are returned, keyed by number.
+func (f *blobFreezer) freeze(nfdb *nofreezedb, chain *Freezer, limit uint64) (map[uint64]common.Hash, error) {
+	number := f.next()
+	if tail, _ := chain.Tail(); number < tail {
+		number = tail
+	}
+	frozen := make(map[uint64]common.Hash)
+	_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		items, _ := f.Ancients()
+		for ; number < limit; number++ {
+			blob, err := chain.Ancient(chainFreezerHashTable, number)
+			if err != nil {
+				return fmt.Errorf("canonical hash missing, can't freeze sidecars %d: %v", number, err)
+			}
+			hash := common.BytesToHash(blob)
+			data := ReadBlobSidecarsRLP(nfdb, hash, number)
+			if items == 0 && len(data) == 0 {
+				continue
+			}
+			if items == 0 {
+				if err := f.setOffset(number); err != nil {
+					return err
+				}
+			}
+			if err := op.AppendRaw(blobFreezerSidecarTable, number-f.offset.Load(), data); err != nil {
+				return fmt.Errorf("can't write sidecars to freezer: %v", err)
+			}
+			if len(data) > 0 {
+				frozen[number] = hash
+			}
+			items++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Resume the search for the first block having sidecars from the limit
+	if items, _ := f.Ancients(); items == 0 && number > f.offset.Load() {
+		if err := f.setOffset(number); err != nil {
+			return nil, err
+		}
+	}
+	return frozen, nil
+}
+
+// truncateHead discards the frozen sidecars of the blocks from the given number.
+func (f *blobFreezer) truncateHead(number uint64) error {
+	offset := f.offset.Load()
+	if items, _ := f.Ancients(); items == 0 {
+		if number < offset {
+			return f.setOffset(number)
+		}
+		return nil
+	}
+	// The pruned sidecars are gone anyway, never truncate below the tail
+	head, _ := f.Tail()
+	if number > offset+head {
+		head = number - offset
+	}
+	if _, err := f.TruncateHead(head); err != nil {
+		return err
+	}
+	if head == 0 {
+		return f.setOffset(number)
+	}
+	return nil
+}
+
+// truncateTail discards the frozen sidecars of the blocks below the given number.
+func (f *blobFreezer) truncateTail(number uint64) error {
+	items, _ := f.Ancients()
+	if items == 0 || number <= f.offset.Load() {
+		return nil
+	}
+	_, err := f.TruncateTail(min(number-f.offset.Load(), items))
+	return err
+}
+
+// blobFreezerOf returns the blob freezer backing the given database, if any.
+func blobFreezerOf(db ethdb.Reader) *blobFreezer {
+	frdb, ok := db.(*freezerdb)
+	if !ok {
+		return nil
+	}
+	chain, ok := frdb.AncientStore.(*chainFreezer)
+	if !ok || chain.blobs == nil {
+		return nil
+	}
+	return chain.blobs
+}
+
+// PruneBlobSidecars discards the frozen sidecars of the blocks below the given
+// number. It is a noop if the database has no blob freezer.
+func PruneBlobSidecars(db ethdb.Database, number uint64) error {
+	blobs := blobFreezerOf(db)
+	if blobs == nil {
+		return nil
+	}
+	if err := blobs.truncateTail(number); err != nil {
+		return err
+	}
+	log.Debug("Pruned frozen blob sidecars", "below", number)
+	return nil
+}
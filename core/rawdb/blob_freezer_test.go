@@ -0,0 +1,113 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// Tests that the sidecars of the frozen blocks are moved into the blob freezer,
+// from where they are served and pruned by block number.
+func TestBlobFreezer(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+
+	// Write a chain of ten blocks, the fourth and seventh having sidecars
+	var (
+		blocks   []*types.Block
+		sidecars = make(map[uint64]types.BlobSidecars)
+		parent   common.Hash
+	)
+	for i := 0; i < 10; i++ {
+		block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), ParentHash: parent, Extra: []byte("test")})
+		WriteBlock(db, block)
+		WriteReceipts(db, block.Hash(), block.NumberU64(), nil)
+		WriteTd(db, block.Hash(), block.NumberU64(), big.NewInt(int64(i+1)))
+		WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		if i == 3 || i == 6 {
+			sidecars[uint64(i)] = types.BlobSidecars{{
+				BlobTxSidecar: types.BlobTxSidecar{
+					Blobs:       []kzg4844.Blob{emptyBlob},
+					Commitments: []kzg4844.Commitment{emptyBlobCommit},
+					Proofs:      []kzg4844.Proof{emptyBlobProof},
+				},
+				TxHash: common.Hash{byte(i)},
+			}}
+			WriteBlobSidecars(db, block.Hash(), block.NumberU64(), sidecars[uint64(i)])
+		}
+		blocks = append(blocks, block)
+		parent = block.Hash()
+	}
+	WriteHeadHeaderHash(db, parent)
+	WriteHeadBlockHash(db, parent)
+
+	// Freeze all the blocks but the last two ones
+	if err := db.(*freezerdb).Freeze(2); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	if frozen, _ := db.Ancients(); frozen != 8 {
+		t.Fatalf("frozen blocks mismatch: have %d, want %d", frozen, 8)
+	}
+	blobs := blobFreezerOf(db)
+	if blobs == nil {
+		t.Fatal("blob freezer unavailable")
+	}
+	if offset, next := blobs.offset.Load(), blobs.next(); offset != 3 || next != 8 {
+		t.Fatalf("blob freezer range mismatch: have [%d, %d), want [%d, %d)", offset, next, 3, 8)
+	}
+	for number, want := range sidecars {
+		hash := blocks[number].Hash()
+		if has, _ := db.Has(blobSidecarsKey(number, hash)); has {
+			t.Errorf("block %d: frozen sidecars left in the key-value store", number)
+		}
+		have := ReadBlobSidecars(db, hash, number)
+		if len(have) != 1 || !sidecarsEqual(have[0], want[0]) {
+			t.Errorf("block %d: sidecars mismatch: have %v, want %v", number, have, want)
+		}
+		if have := ReadBlobSidecars(db, common.Hash{0xff}, number); have != nil {
+			t.Errorf("block %d: sidecars returned for a non canonical hash", number)
+		}
+	}
+	if have := ReadBlobSidecars(db, blocks[5].Hash(), 5); have != nil {
+		t.Errorf("block 5: unexpected sidecars: %v", have)
+	}
+	// Pruning drops the sidecars below the given number only
+	if err := PruneBlobSidecars(db, 5); err != nil {
+		t.Fatalf("failed to prune sidecars: %v", err)
+	}
+	if have := ReadBlobSidecars(db, blocks[3].Hash(), 3); have != nil {
+		t.Errorf("block 3: pruned sidecars returned")
+	}
+	if have := ReadBlobSidecars(db, blocks[6].Hash(), 6); len(have) != 1 {
+		t.Errorf("block 6: sidecars missing after pruning")
+	}
+	// Truncating the chain freezer drops the sidecars of the discarded blocks
+	if _, err := db.TruncateHead(6); err != nil {
+		t.Fatalf("failed to truncate ancients: %v", err)
+	}
+	if next := blobs.next(); next != 6 {
+		t.Errorf("blob freezer head mismatch: have %d, want %d", next, 6)
+	}
+}
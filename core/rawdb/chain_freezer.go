@@ -39,6 +39,7 @@ const (
 
 type chainFreezer struct {
 	*Freezer
+	blobs   *blobFreezer // Freezer of the sidecars of the frozen blocks, nil if unavailable
 	quit    chan struct{}
 	wg      sync.WaitGroup
 	trigger chan chan struct{} // Manual blocking frezzer trigger, test determinism
@@ -49,7 +50,7 @@ type chainFreezer struct {
 // database to flat files for saving space on live database.
 // newChainFreezer initializes the freezer for ancient chain data.
 func newChainFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*chainFreezer, error) {
-	freezer, err := NewFreezer(datadir, namespace, readonly, maxTableSize, tables)
+	freezer, err := newFreezer(datadir, namespace, readonly, maxTableSize, tables, chainFreezerPrunable)
 	if err != nil {
 		return nil, err
 	}
@@ -69,9 +70,29 @@ func (f *chainFreezer) Close() error {
 		close(f.quit)
 	}
 	f.wg.Wait()
+	if f.blobs != nil {
+		if err := f.blobs.Close(); err != nil {
+			log.Error("Failed to close blob freezer", "err", err)
+		}
+	}
 	return f.Freezer.Close()
 }
 
+// TruncateHead discards any recent data above the provided threshold number,
+// including the frozen sidecars of the discarded blocks.
+func (f *chainFreezer) TruncateHead(items uint64) (uint64, error) {
+	old, err := f.Freezer.TruncateHead(items)
+	if err != nil {
+		return 0, err
+	}
+	if f.blobs != nil {
+		if err := f.blobs.truncateHead(items); err != nil {
+			return 0, err
+		}
+	}
+	return old, nil
+}
+
 // freeze is a background thread that periodically checks the blockchain for any
 // import progress and moves ancient data from the fast database into the freezer.
 //
@@ -148,8 +169,14 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore) {
 		if limit-first > freezerBatchLimit {
 			limit = first + freezerBatchLimit
 		}
+		// Mark the range as being frozen like the ancient block writes, so that
+		// the blocks end up either in the freezer or in the key-value store
+		writeBlockWriteIntent(db, freezeIntentKey, &blockWriteIntent{First: first, Last: limit, Hash: ReadCanonicalHash(nfdb, limit)})
+
 		ancients, err := f.freezeRange(nfdb, first, limit)
 		if err != nil {
+			// The failed modification is reverted by the freezer itself
+			deleteBlockWriteIntent(db, freezeIntentKey)
 			log.Error("Error in block freeze operation", "err", err)
 			backoff = true
 			continue
@@ -159,6 +186,24 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore) {
 		if err := f.Sync(); err != nil {
 			log.Crit("Failed to flush frozen tables", "err", err)
 		}
+		// Move the sidecars of the frozen blocks along, keeping the blocks in
+		// leveldb until their sidecars are frozen too
+		sidecars, err := f.freezeSidecars(nfdb)
+		if err != nil {
+			// Roll the frozen blocks back, they are still in leveldb and are
+			// frozen again along with their sidecars by a later cycle. The
+			// marker is only dropped once the rollback is on disk.
+			if _, err := f.TruncateHead(first); err != nil {
+				log.Crit("Failed to roll back frozen blocks", "err", err)
+			}
+			if err := f.Sync(); err != nil {
+				log.Crit("Failed to flush rolled back tables", "err", err)
+			}
+			deleteBlockWriteIntent(db, freezeIntentKey)
+			log.Error("Error in blob sidecars freeze operation", "err", err)
+			backoff = true
+			continue
+		}
 
 		// Wipe out all data from the active database
 		batch := db.NewBatch()
@@ -169,6 +214,10 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore) {
 				DeleteCanonicalHash(batch, first+uint64(i))
 			}
 		}
+		for number, hash := range sidecars {
+			DeleteBlobSidecars(batch, hash, number)
+		}
+		deleteBlockWriteIntent(batch, freezeIntentKey)
 		if err := batch.Write(); err != nil {
 			log.Crit("Failed to delete frozen canonical blocks", "err", err)
 		}
@@ -241,9 +290,32 @@ func (f *chainFreezer) freeze(db ethdb.KeyValueStore) {
 	}
 }
 
+// freezeSidecars moves the sidecars of the frozen blocks into the blob freezer,
+// returning the hashes of the blocks whose sidecars were moved, keyed by number.
+func (f *chainFreezer) freezeSidecars(nfdb *nofreezedb) (map[uint64]common.Hash, error) {
+	if f.blobs == nil {
+		return nil, nil
+	}
+	sidecars, err := f.blobs.freeze(nfdb, f.Freezer, f.frozen.Load())
+	if err != nil {
+		return nil, err
+	}
+	if err := f.blobs.Sync(); err != nil {
+		return nil, err
+	}
+	return sidecars, nil
+}
+
 func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64) (hashes []common.Hash, err error) {
 	hashes = make([]common.Hash, 0, limit-number)
 
+	// Total difficulties are not stored beyond the freeze block, instead the td
+	// of the parent block is carried over into the ancient store.
+	var (
+		tdFreeze = ReadTdFreezeBlock(nfdb)
+		parentTd []byte
+		pruned   = ReadPrunedRanges(nfdb)
+	)
 	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
 		for ; number <= limit; number++ {
 			// Retrieve all the components of the canonical block.
@@ -255,18 +327,28 @@ func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64) (hash
 			if len(header) == 0 {
 				return fmt.Errorf("block header missing, can't freeze block %d", number)
 			}
+			// The bodies and receipts pruned on request are frozen as empty items
+			prunedBody, prunedReceipts := prunedAt(pruned, number)
+
 			body := ReadBodyRLP(nfdb, hash, number)
-			if len(body) == 0 {
+			if len(body) == 0 && !prunedBody {
 				return fmt.Errorf("block body missing, can't freeze block %d", number)
 			}
 			receipts := ReadReceiptsRLP(nfdb, hash, number)
-			if len(receipts) == 0 {
+			if len(receipts) == 0 && !prunedReceipts {
 				return fmt.Errorf("block receipts missing, can't freeze block %d", number)
 			}
 			td := ReadTdRLP(nfdb, hash, number)
+			if len(td) == 0 && tdFreeze != nil && number >= *tdFreeze && number > 0 {
+				if parentTd == nil {
+					parentTd, _ = f.Ancient(chainFreezerDifficultyTable, number-1)
+				}
+				td = parentTd
+			}
 			if len(td) == 0 {
 				return fmt.Errorf("total difficulty missing, can't freeze block %d", number)
 			}
+			parentTd = td
 
 			// Write to the batch.
 			if err := op.AppendRaw(chainFreezerHashTable, number, hash[:]); err != nil {
@@ -292,3 +374,56 @@ func (f *chainFreezer) freezeRange(nfdb *nofreezedb, number, limit uint64) (hash
 
 	return hashes, err
 }
+
+// chainFreezerOf returns the chain freezer backing the given database, if any.
+func chainFreezerOf(db ethdb.Reader) *chainFreezer {
+	frdb, ok := db.(*freezerdb)
+	if !ok {
+		return nil
+	}
+	chain, _ := frdb.AncientStore.(*chainFreezer)
+	return chain
+}
+
+// PruneFrozenHistory discards the frozen bodies and receipts of the blocks below
+// the given number, which must have been frozen, keeping their headers, hashes
+// and difficulties. It is a noop if the database has no chain freezer.
+func PruneFrozenHistory(db ethdb.Database, number uint64) error {
+	chain := chainFreezerOf(db)
+	if chain == nil {
+		return nil
+	}
+	old, err := chain.truncatePrunableTail(number)
+	if err != nil {
+		return err
+	}
+	if number > old {
+		log.Debug("Pruned frozen block history", "from", old, "below", number)
+	}
+	return nil
+}
+
+// FrozenHistoryTail returns the number of the first frozen block whose body and
+// receipts are kept, zero if the database has no chain freezer.
+func FrozenHistoryTail(db ethdb.Database) uint64 {
+	chain := chainFreezerOf(db)
+	if chain == nil {
+		return 0
+	}
+	return chain.prunedTail()
+}
+
+// FrozenHistorySize returns the number of bytes of the frozen bodies and receipts
+// which pruning the blocks below the given number would release on disk, zero
+// if the database has no chain freezer.
+func FrozenHistorySize(db ethdb.Database, number uint64) (bodies uint64, receipts uint64, err error) {
+	chain := chainFreezerOf(db)
+	if chain == nil {
+		return 0, 0, nil
+	}
+	sizes, err := chain.prunableTailSize(number)
+	if err != nil {
+		return 0, 0, err
+	}
+	return sizes[chainFreezerBodiesTable], sizes[chainFreezerReceiptTable], nil
+}
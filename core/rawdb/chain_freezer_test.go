@@ -0,0 +1,133 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+// Tests that the bodies and receipts pruned on request are frozen as empty
+// items, while any other missing block data aborts the freezing.
+func TestFreezePrunedRange(t *testing.T) {
+	f, err := newChainFreezer(t.TempDir(), "", false, freezerTableSize, chainFreezerNoSnappy)
+	if err != nil {
+		t.Fatalf("failed to create freezer: %v", err)
+	}
+	defer f.Close()
+
+	db := &nofreezedb{KeyValueStore: memorydb.New()}
+	var headers []*types.Header
+	for number := uint64(0); number < 4; number++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte("test")}
+		hash := header.Hash()
+
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, hash, number)
+		WriteBody(db, hash, number, &types.Body{})
+		WriteReceipts(db, hash, number, types.Receipts{})
+		WriteTd(db, hash, number, big.NewInt(int64(number+1)))
+		headers = append(headers, header)
+	}
+	DeleteBody(db, headers[2].Hash(), 2)
+	DeleteReceipts(db, headers[2].Hash(), 2)
+
+	if _, err := f.freezeRange(db, 0, 3); err == nil {
+		t.Fatal("missing block data frozen")
+	}
+	WritePrunedRanges(db, []PrunedRange{{From: 2, To: 2, Bodies: true, Receipts: true}})
+	if _, err := f.freezeRange(db, 0, 3); err != nil {
+		t.Fatalf("failed to freeze pruned range: %v", err)
+	}
+	for number, header := range headers {
+		body, _ := f.Ancient(chainFreezerBodiesTable, uint64(number))
+		receipts, _ := f.Ancient(chainFreezerReceiptTable, uint64(number))
+		if number == 2 {
+			if len(body) != 0 || len(receipts) != 0 {
+				t.Errorf("block %d: pruned data frozen: body %x, receipts %x", number, body, receipts)
+			}
+		} else if !bytes.Equal(body, ReadBodyRLP(db, header.Hash(), uint64(number))) || len(receipts) == 0 {
+			t.Errorf("block %d: frozen data mismatch", number)
+		}
+		if frozen, _ := f.Ancient(chainFreezerHeaderTable, uint64(number)); len(frozen) == 0 {
+			t.Errorf("block %d: header not frozen", number)
+		}
+	}
+}
+
+// Tests that the tail of the bodies and receipts can be pruned on its own, the
+// headers, hashes and difficulties being kept across a reopening.
+func TestPruneFrozenHistory(t *testing.T) {
+	dir := t.TempDir()
+	f, err := newChainFreezer(dir, "", false, freezerTableSize, chainFreezerNoSnappy)
+	if err != nil {
+		t.Fatalf("failed to create freezer: %v", err)
+	}
+	db := &nofreezedb{KeyValueStore: memorydb.New()}
+	for number := uint64(0); number < 4; number++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(number), Extra: []byte("test")}
+		hash := header.Hash()
+
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, hash, number)
+		WriteBody(db, hash, number, &types.Body{})
+		WriteReceipts(db, hash, number, types.Receipts{})
+		WriteTd(db, hash, number, big.NewInt(int64(number+1)))
+	}
+	if _, err := f.freezeRange(db, 0, 3); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	if _, err := f.truncatePrunableTail(5); err == nil {
+		t.Fatal("pruned above the frozen blocks")
+	}
+	if _, err := f.truncatePrunableTail(2); err != nil {
+		t.Fatalf("failed to prune frozen history: %v", err)
+	}
+	if _, err := f.TruncateHead(1); err == nil {
+		t.Fatal("truncated head below the pruned tail")
+	}
+	f.Close()
+
+	if f, err = newChainFreezer(dir, "", false, freezerTableSize, chainFreezerNoSnappy); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer f.Close()
+
+	if tail, _ := f.Tail(); tail != 0 {
+		t.Errorf("freezer tail mismatch: have %d, want 0", tail)
+	}
+	if tail := f.prunedTail(); tail != 2 {
+		t.Errorf("pruned tail mismatch: have %d, want 2", tail)
+	}
+	for number := uint64(0); number < 4; number++ {
+		for _, kind := range []string{chainFreezerHeaderTable, chainFreezerHashTable, chainFreezerDifficultyTable} {
+			if data, _ := f.Ancient(kind, number); len(data) == 0 {
+				t.Errorf("block %d: %s missing", number, kind)
+			}
+		}
+		pruned := number < 2
+		for _, kind := range []string{chainFreezerBodiesTable, chainFreezerReceiptTable} {
+			if data, _ := f.Ancient(kind, number); (len(data) == 0) != pruned {
+				t.Errorf("block %d: %s pruned mismatch: have %v, want %v", number, kind, len(data) == 0, pruned)
+			}
+		}
+	}
+}
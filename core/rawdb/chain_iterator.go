@@ -17,6 +17,7 @@
 package rawdb
 
 import (
+	"encoding/binary"
 	"runtime"
 	"sync/atomic"
 	"time"
@@ -142,10 +143,13 @@ func iterateTransactions(db ethdb.Database, from uint64, to uint64, reverse bool
 			}
 		}()
 		for data := range rlpCh {
+			// The bodies pruned on request are missing, with nothing to index
 			var body types.Body
-			if err := rlp.DecodeBytes(data.rlp, &body); err != nil {
-				log.Warn("Failed to decode block body", "block", data.number, "error", err)
-				return
+			if len(data.rlp) > 0 {
+				if err := rlp.DecodeBytes(data.rlp, &body); err != nil {
+					log.Warn("Failed to decode block body", "block", data.number, "error", err)
+					return
+				}
 			}
 			var hashes []common.Hash
 			for _, tx := range body.Transactions {
@@ -265,7 +269,8 @@ func indexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, inte
 	indexTransactions(db, from, to, interrupt, hook)
 }
 
-// unindexTransactions removes txlookup indices of the specified block range.
+// unindexTransactions removes txlookup indices of the specified block range,
+// along with the receipt lookups of their transactions.
 //
 // There is a passed channel, the whole procedure will be interrupted if any
 // signal received.
@@ -302,6 +307,7 @@ func unindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 			delivery := queue.PopItem().(*blockTxHashes)
 			nextNum = delivery.number + 1
 			DeleteTxLookupEntries(batch, delivery.hashes)
+			DeleteReceiptLookupEntries(batch, delivery.hashes)
 			txs += len(delivery.hashes)
 			blocks++
 
@@ -351,3 +357,73 @@ func UnindexTransactions(db ethdb.Database, from uint64, to uint64, interrupt ch
 func unindexTransactionsForTesting(db ethdb.Database, from uint64, to uint64, interrupt chan struct{}, hook func(uint64) bool) {
 	unindexTransactions(db, from, to, interrupt, hook)
 }
+
+// IndexBlockHashes indexes the hashes of the blocks written into the key-value
+// store before the block hash index, by number, resuming where a previous run
+// left off. The blocks written since are indexed along with their headers. The
+// lookups of the block hashes fall back to iterating the headers until it's
+// done.
+//
+// There is a passed channel, the whole procedure will be interrupted if any
+// signal received.
+func IndexBlockHashes(db ethdb.Database, interrupt chan struct{}) {
+	from, migrating := ReadBlockHashIndexProgress(db)
+	if !migrating {
+		return
+	}
+	var (
+		it      = db.NewIterator(headerPrefix, encodeBlockNumber(from))
+		batch   = db.NewBatch()
+		start   = time.Now()
+		logged  = start
+		keyLen  = len(headerPrefix) + 8 + common.HashLength
+		number  = from
+		indexed int
+	)
+	defer it.Release()
+
+	for it.Next() {
+		select {
+		case <-interrupt:
+			WriteBlockHashIndexProgress(batch, number)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed writing batch to db", "error", err)
+			}
+			log.Debug("Block hash indexing interrupted", "blocks", indexed, "number", number, "elapsed", common.PrettyDuration(time.Since(start)))
+			return
+		default:
+		}
+		key := it.Key()
+		if len(key) != keyLen {
+			continue
+		}
+		number = binary.BigEndian.Uint64(key[len(headerPrefix) : len(headerPrefix)+8])
+
+		// Skip the headers deleted since the iterator was created, not to leave
+		// a dangling index entry behind
+		hash := common.BytesToHash(key[len(key)-common.HashLength:])
+		if !HasHeader(db, hash, number) {
+			continue
+		}
+		writeBlockHashIndex(batch, hash, number)
+		indexed++
+
+		// Resume at the current number, indexing it twice is harmless
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			WriteBlockHashIndexProgress(batch, number)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed writing batch to db", "error", err)
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Indexing block hashes", "blocks", indexed, "number", number, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	WriteBlockHashIndexDone(batch)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed writing batch to db", "error", err)
+	}
+	log.Info("Indexed block hashes", "blocks", indexed, "elapsed", common.PrettyDuration(time.Since(start)))
+}
@@ -206,3 +206,47 @@ func TestIndexTransactions(t *testing.T) {
 	verify(8, 11, true, 8)
 	verify(0, 8, false, 8)
 }
+
+// Tests that the hashes of the blocks written before the block hash index are
+// indexed, resuming where an interrupted run left off.
+func TestIndexBlockHashes(t *testing.T) {
+	db := NewMemoryDatabase()
+
+	var hashes []common.Hash
+	for i := uint64(0); i < 10; i++ {
+		for j := 0; j < 2; j++ {
+			header := &types.Header{Number: new(big.Int).SetUint64(i), Extra: []byte{byte(j)}}
+			WriteHeader(db, header)
+
+			// Drop the index entry, as if the header had been written before it
+			deleteBlockHashIndex(db, header.Hash(), i)
+			hashes = append(hashes, header.Hash())
+		}
+	}
+	if HasBlockHashIndex(db) {
+		t.Fatal("Block hash index reported before the migration")
+	}
+	// Headers are iterated until the migration is done
+	if have, want := len(ReadAllHashes(db, 5)), 2; have != want {
+		t.Fatalf("Wrong number of hashes read before the migration, want %d, got %d", want, have)
+	}
+	// Interrupt the migration right away, it should record where to resume
+	interrupt := make(chan struct{})
+	close(interrupt)
+	IndexBlockHashes(db, interrupt)
+	if _, migrating := ReadBlockHashIndexProgress(db); !migrating {
+		t.Fatal("Interrupted migration reported as done")
+	}
+	IndexBlockHashes(db, nil)
+	if !HasBlockHashIndex(db) {
+		t.Fatal("Block hash index missing after the migration")
+	}
+	for i, hash := range hashes {
+		if _, err := db.Get(blockHashIndexKey(uint64(i/2), hash)); err != nil {
+			t.Fatalf("Block %d #%x not indexed: %v", i/2, hash, err)
+		}
+	}
+	if have, want := len(ReadAllHashesInRange(db, 0, 9)), len(hashes); have != want {
+		t.Fatalf("Wrong number of hashes read after the migration, want %d, got %d", want, have)
+	}
+}
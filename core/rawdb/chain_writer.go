@@ -0,0 +1,255 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// blockWriteIntent is the marker persisted before a block range is appended to
+// the ancient store, and deleted atomically with the key-value part of the write.
+// The block write batches and the chain freezer keep theirs under separate keys,
+// so that neither clobbers the marker of a write the other has in progress.
+type blockWriteIntent struct {
+	First uint64
+	Last  uint64
+	Hash  common.Hash // Hash of the last block in the range
+}
+
+// stagedBlock is a block with all its associated data, waiting to be written.
+type stagedBlock struct {
+	block    *types.Block
+	receipts types.Receipts
+	sidecars types.BlobSidecars
+	td       *big.Int
+	index    bool
+}
+
+// BlockWriteBatch stages the header, body, receipts, blob sidecars and
+// transaction lookup entries of a sequence of blocks, committing them together.
+// A block is therefore either entirely present in the database or not at all,
+// even if the process is interrupted midway.
+type BlockWriteBatch struct {
+	db     ethdb.Database
+	blocks []*stagedBlock
+	size   int // Encoded size of the staged blocks
+}
+
+// NewBlockWriteBatch creates an empty block write batch on top of the database.
+func NewBlockWriteBatch(db ethdb.Database) *BlockWriteBatch {
+	return &BlockWriteBatch{db: db}
+}
+
+// Add stages a block for writing. The total difficulty is optional and only
+// written into the key-value store, the transaction lookup entries are only
+// written if index is set.
+func (b *BlockWriteBatch) Add(block *types.Block, receipts types.Receipts, sidecars types.BlobSidecars, td *big.Int, index bool) {
+	b.blocks = append(b.blocks, &stagedBlock{
+		block:    block,
+		receipts: receipts,
+		sidecars: sidecars,
+		td:       td,
+		index:    index,
+	})
+	b.size += int(block.Size())
+}
+
+// Len returns the number of staged blocks.
+func (b *BlockWriteBatch) Len() int {
+	return len(b.blocks)
+}
+
+// Size returns the encoded size of the staged blocks, excluding their receipts.
+func (b *BlockWriteBatch) Size() int {
+	return b.size
+}
+
+// Reset drops all the staged blocks.
+func (b *BlockWriteBatch) Reset() {
+	b.blocks = b.blocks[:0]
+	b.size = 0
+}
+
+// Commit writes all the staged blocks into the key-value store in a single
+// batch, which is applied atomically by the underlying database. The optional
+// extra callback writes any other data belonging to the blocks into the same
+// batch.
+func (b *BlockWriteBatch) Commit(extra func(batch ethdb.KeyValueWriter)) (int64, error) {
+	batch := b.db.NewBatch()
+	for _, staged := range b.blocks {
+		var (
+			hash   = staged.block.Hash()
+			number = staged.block.NumberU64()
+		)
+		WriteBlock(batch, staged.block)
+		WriteReceipts(batch, hash, number, staged.receipts)
+		if staged.td != nil {
+			WriteTd(batch, hash, number, staged.td)
+		}
+		b.writeExtras(batch, staged)
+	}
+	if extra != nil {
+		extra(batch)
+	}
+	size := int64(batch.ValueSize())
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	b.Reset()
+	return size, nil
+}
+
+// CommitAncient writes all the staged blocks into the ancient store, with the
+// transaction lookup entries and blob sidecars going into the key-value store.
+// The staged blocks must be contiguous and follow the current ancient head, td
+// is the total difficulty of the first one and freeze the block from which the
+// total difficulty stops accumulating.
+//
+// As the two stores can't be written atomically, the write is ordered such that
+// an interruption at any point can be repaired by RecoverBlockWrites: an intent
+// marker is persisted first, then the ancient store is written and synced, and
+// lastly the key-value data is written in the same batch that drops the marker.
+func (b *BlockWriteBatch) CommitAncient(td *big.Int, freeze uint64) (int64, error) {
+	if len(b.blocks) == 0 {
+		return 0, nil
+	}
+	var (
+		first  = b.blocks[0].block
+		last   = b.blocks[len(b.blocks)-1].block
+		blocks = make([]*types.Block, len(b.blocks))
+		recs   = make([]types.Receipts, len(b.blocks))
+	)
+	if frozen, err := b.db.Ancients(); err != nil {
+		return 0, err
+	} else if frozen != first.NumberU64() {
+		return 0, fmt.Errorf("non-contiguous ancient write: have head %d, want %d", frozen, first.NumberU64())
+	}
+	for i, staged := range b.blocks {
+		blocks[i], recs[i] = staged.block, staged.receipts
+	}
+	writeBlockWriteIntent(b.db, blockWriteIntentKey, &blockWriteIntent{First: first.NumberU64(), Last: last.NumberU64(), Hash: last.Hash()})
+
+	size, err := WriteAncientBlocksWithTdFreeze(b.db, blocks, recs, td, freeze)
+	if err != nil {
+		// The failed modification is reverted by the freezer itself
+		deleteBlockWriteIntent(b.db, blockWriteIntentKey)
+		return 0, err
+	}
+	if err := b.db.Sync(); err != nil {
+		return 0, err
+	}
+	batch := b.db.NewBatch()
+	for _, staged := range b.blocks {
+		b.writeExtras(batch, staged)
+	}
+	deleteBlockWriteIntent(batch, blockWriteIntentKey)
+
+	size += int64(batch.ValueSize())
+	if err := batch.Write(); err != nil {
+		// Roll back the ancient store update, the marker is left in place so
+		// the recovery is retried on startup if the rollback fails too.
+		if _, err := b.db.TruncateHead(first.NumberU64()); err != nil {
+			log.Error("Can't truncate ancient store after failed insert", "err", err)
+		}
+		return 0, err
+	}
+	b.Reset()
+	return size, nil
+}
+
+// writeExtras writes the key-value only data of a staged block into the batch.
+func (b *BlockWriteBatch) writeExtras(batch ethdb.KeyValueWriter, staged *stagedBlock) {
+	if len(staged.sidecars) > 0 {
+		WriteBlobSidecars(batch, staged.block.Hash(), staged.block.NumberU64(), staged.sidecars)
+	}
+	if staged.index {
+		WriteTxLookupEntriesByBlock(batch, staged.block)
+	}
+}
+
+// RecoverBlockWrites repairs the database after an ancient block write was
+// interrupted. If an intent marker is found, the key-value part of the write
+// never made it to disk, so the ancient store is truncated back to the first
+// block of the interrupted range. It returns the number of discarded blocks.
+func RecoverBlockWrites(db ethdb.Database) (uint64, error) {
+	return recoverBlockWrites(db, db, blockWriteIntentKey)
+}
+
+// recoverBlockWrites is the implementation of RecoverBlockWrites on separate
+// key-value and ancient stores, repairing the write marked under the given key.
+func recoverBlockWrites(db ethdb.KeyValueStore, ancients ethdb.AncientStore, key []byte) (uint64, error) {
+	intent := readBlockWriteIntent(db, key)
+	if intent == nil {
+		return 0, nil
+	}
+	frozen, err := ancients.Ancients()
+	if err != nil {
+		return 0, err
+	}
+	var discarded uint64
+	if frozen > intent.First {
+		if _, err := ancients.TruncateHead(intent.First); err != nil {
+			return 0, err
+		}
+		if err := ancients.Sync(); err != nil {
+			return 0, err
+		}
+		discarded = frozen - intent.First
+	}
+	deleteBlockWriteIntent(db, key)
+	log.Warn("Recovered interrupted block write", "first", intent.First, "last", intent.Last, "hash", intent.Hash, "discarded", discarded)
+	return discarded, nil
+}
+
+// readBlockWriteIntent retrieves the marker of an interrupted ancient block write.
+func readBlockWriteIntent(db ethdb.KeyValueReader, key []byte) *blockWriteIntent {
+	data, _ := db.Get(key)
+	if len(data) == 0 {
+		return nil
+	}
+	intent := new(blockWriteIntent)
+	if err := rlp.DecodeBytes(data, intent); err != nil {
+		log.Error("Invalid block write intent in database", "err", err)
+		return nil
+	}
+	return intent
+}
+
+// writeBlockWriteIntent stores the marker of an ancient block write in progress.
+func writeBlockWriteIntent(db ethdb.KeyValueWriter, key []byte, intent *blockWriteIntent) {
+	enc, err := rlp.EncodeToBytes(intent)
+	if err != nil {
+		log.Crit("Failed to encode block write intent", "err", err)
+	}
+	if err := db.Put(key, enc); err != nil {
+		log.Crit("Failed to store block write intent", "err", err)
+	}
+}
+
+// deleteBlockWriteIntent removes the marker of an ancient block write.
+func deleteBlockWriteIntent(db ethdb.KeyValueWriter, key []byte) {
+	if err := db.Delete(key); err != nil {
+		log.Crit("Failed to delete block write intent", "err", err)
+	}
+}
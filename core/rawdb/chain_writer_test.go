@@ -0,0 +1,171 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that staged blocks are written into the key-value store together.
+func TestBlockWriteBatchCommit(t *testing.T) {
+	var (
+		db       = NewMemoryDatabase()
+		blocks   = makeTestBlocks(3, 2)
+		receipts = makeTestReceipts(3, 2)
+		writer   = NewBlockWriteBatch(db)
+	)
+	for i, block := range blocks {
+		writer.Add(block, receipts[i], nil, big.NewInt(int64(i+1)), true)
+	}
+	extra := []byte("extra")
+	if _, err := writer.Commit(func(batch ethdb.KeyValueWriter) { batch.Put(extra, extra) }); err != nil {
+		t.Fatalf("failed to commit blocks: %v", err)
+	}
+	if writer.Len() != 0 || writer.Size() != 0 {
+		t.Fatalf("staged blocks left after commit: %d, size %d", writer.Len(), writer.Size())
+	}
+	if ok, _ := db.Has(extra); !ok {
+		t.Errorf("extra data not stored")
+	}
+	for i, block := range blocks {
+		if have := ReadBlock(db, block.Hash(), block.NumberU64()); have == nil || have.Hash() != block.Hash() {
+			t.Errorf("block %d: not stored", i)
+		}
+		if len(ReadReceiptsRLP(db, block.Hash(), block.NumberU64())) == 0 {
+			t.Errorf("block %d: receipts not stored", i)
+		}
+		if have := ReadTd(db, block.Hash(), block.NumberU64()); have == nil || have.Int64() != int64(i+1) {
+			t.Errorf("block %d: td mismatch: have %v", i, have)
+		}
+	}
+	if ReadTxLookupEntry(db, blocks[0].Transactions()[0].Hash()) == nil {
+		t.Errorf("transaction lookup entry not stored")
+	}
+}
+
+// Tests that interrupted ancient block writes are rolled back on recovery, while
+// completed ones are left untouched.
+func TestBlockWriteBatchRecovery(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+
+	var (
+		blocks   = makeTestBlocks(5, 1)
+		receipts = makeTestReceipts(5, 1)
+		writer   = NewBlockWriteBatch(db)
+	)
+	for i := 0; i < 3; i++ {
+		writer.Add(blocks[i], receipts[i], nil, nil, true)
+	}
+	if _, err := writer.CommitAncient(big.NewInt(1), 0); err != nil {
+		t.Fatalf("failed to commit ancient blocks: %v", err)
+	}
+	if frozen, _ := db.Ancients(); frozen != 3 {
+		t.Fatalf("ancient count mismatch: have %d, want 3", frozen)
+	}
+	if readBlockWriteIntent(db, blockWriteIntentKey) != nil {
+		t.Fatalf("intent marker left after complete write")
+	}
+	if ReadTxLookupEntry(db, blocks[0].Transactions()[0].Hash()) == nil {
+		t.Fatalf("transaction lookup entry not stored")
+	}
+	// Non-contiguous writes must be rejected without touching the store
+	writer.Add(blocks[4], receipts[4], nil, nil, true)
+	if _, err := writer.CommitAncient(big.NewInt(1), 0); err == nil {
+		t.Fatalf("non-contiguous ancient write succeeded")
+	}
+	writer.Reset()
+
+	// Nothing to recover after a complete write
+	if discarded, err := RecoverBlockWrites(db); err != nil || discarded != 0 {
+		t.Fatalf("unexpected recovery: discarded %d, err %v", discarded, err)
+	}
+	// Simulate a crash after the ancient store was written but before the
+	// key-value data made it to disk.
+	writeBlockWriteIntent(db, blockWriteIntentKey, &blockWriteIntent{First: 3, Last: 4, Hash: blocks[4].Hash()})
+	if _, err := WriteAncientBlocks(db, []*types.Block{blocks[3], blocks[4]}, receipts[3:], big.NewInt(1)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	discarded, err := RecoverBlockWrites(db)
+	if err != nil {
+		t.Fatalf("failed to recover block writes: %v", err)
+	}
+	if discarded != 2 {
+		t.Errorf("discarded block count mismatch: have %d, want 2", discarded)
+	}
+	if frozen, _ := db.Ancients(); frozen != 3 {
+		t.Errorf("ancient count mismatch after recovery: have %d, want 3", frozen)
+	}
+	if readBlockWriteIntent(db, blockWriteIntentKey) != nil {
+		t.Errorf("intent marker left after recovery")
+	}
+	if ReadCanonicalHash(db, 2) != blocks[2].Hash() {
+		t.Errorf("completed write lost during recovery")
+	}
+}
+
+// Tests that a freeze interrupted before its blocks were wiped from the key-value
+// store is rolled back when the database is opened, and that the marker of the
+// freezer is independent from the one of the block write batches.
+func TestFreezeIntentRecovery(t *testing.T) {
+	var (
+		kvdir    = t.TempDir()
+		ancient  = t.TempDir()
+		blocks   = makeTestBlocks(5, 1)
+		receipts = makeTestReceipts(5, 1)
+	)
+	open := func() ethdb.Database {
+		kvdb, err := NewLevelDBDatabase(kvdir, 16, 16, "", false)
+		if err != nil {
+			t.Fatalf("failed to open key-value store: %v", err)
+		}
+		db, err := NewDatabaseWithFreezer(kvdb, ancient, "", false)
+		if err != nil {
+			t.Fatalf("failed to create database with ancient backend: %v", err)
+		}
+		return db
+	}
+	db := open()
+	if _, err := WriteAncientBlocks(db, blocks[:3], receipts[:3], big.NewInt(1)); err != nil {
+		t.Fatalf("failed to write ancient blocks: %v", err)
+	}
+	// Simulate a crash of the freezer after the ancient store was written, with
+	// a block write in progress beyond it
+	writeBlockWriteIntent(db, freezeIntentKey, &blockWriteIntent{First: 1, Last: 2, Hash: blocks[2].Hash()})
+	writeBlockWriteIntent(db, blockWriteIntentKey, &blockWriteIntent{First: 3, Last: 4, Hash: blocks[4].Hash()})
+	db.Close()
+
+	db = open()
+	defer db.Close()
+
+	if frozen, _ := db.Ancients(); frozen != 1 {
+		t.Errorf("ancient count mismatch after recovery: have %d, want 1", frozen)
+	}
+	if readBlockWriteIntent(db, freezeIntentKey) != nil {
+		t.Errorf("freeze marker left after recovery")
+	}
+	if intent := readBlockWriteIntent(db, blockWriteIntentKey); intent == nil || intent.First != 3 {
+		t.Errorf("block write marker mismatch: %v", intent)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -198,6 +199,11 @@ func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace st
 	if err != nil {
 		return nil, err
 	}
+	// Open the freezer of the sidecars of the frozen blocks alongside
+	if frdb.blobs, err = newBlobFreezer(ancient, db, namespace, readonly); err != nil {
+		frdb.Close()
+		return nil, err
+	}
 	// Since the freezer can be stored separately from the user's key-value database,
 	// there's a fairly high probability that the user requests invalid combinations
 	// of the freezer and database. Ensure that we don't shoot ourselves in the foot
@@ -264,6 +270,12 @@ func NewDatabaseWithFreezer(db ethdb.KeyValueStore, ancient string, namespace st
 	}
 	// Freezer is consistent with the key-value database, permit combining the two
 	if !frdb.readonly {
+		// Roll back a freeze interrupted before its blocks were wiped from the
+		// key-value store, they are frozen again from there
+		if _, err := recoverBlockWrites(db, frdb, freezeIntentKey); err != nil {
+			frdb.Close()
+			return nil, err
+		}
 		frdb.wg.Add(1)
 		go func() {
 			frdb.freeze(db)
@@ -428,9 +440,48 @@ func (s *stat) Count() string {
 	return s.count.String()
 }
 
+// DatabaseStat is the storage size and the number of items of a category of
+// data of the database.
+type DatabaseStat struct {
+	Database string             // Store holding the data, key-value or ancient
+	Category string             // Category of the data within the store
+	Size     common.StorageSize // Storage size of the data
+	Count    uint64             // Number of items of the data
+}
+
+// DatabaseStats is the result of a database inspection.
+type DatabaseStats struct {
+	Stats       []DatabaseStat     // Statistics of all the categories of data
+	Total       common.StorageSize // Storage size of the entire database
+	Unaccounted DatabaseStat       // Key-value data matching no known category
+}
+
 // InspectDatabase traverses the entire database and checks the size
 // of all different categories of data.
 func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
+	stats, err := InspectDatabaseStats(db, keyPrefix, keyStart)
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(stats.Stats))
+	for _, stat := range stats.Stats {
+		rows = append(rows, []string{stat.Database, stat.Category, stat.Size.String(), fmt.Sprintf("%d", stat.Count)})
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
+	table.SetFooter([]string{"", "Total", stats.Total.String(), " "})
+	table.AppendBulk(rows)
+	table.Render()
+
+	if stats.Unaccounted.Size > 0 {
+		log.Error("Database contains unaccounted data", "size", stats.Unaccounted.Size, "count", stats.Unaccounted.Count)
+	}
+	return nil
+}
+
+// InspectDatabaseStats traverses the entire database and returns the size of
+// all different categories of data, to be compared with DiffInspect.
+func InspectDatabaseStats(db ethdb.Database, keyPrefix, keyStart []byte) (*DatabaseStats, error) {
 	it := db.NewIterator(keyPrefix, keyStart)
 	defer it.Release()
 
@@ -446,12 +497,16 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		tds             stat
 		numHashPairings stat
 		hashNumPairings stat
+		blockHashIndex  stat
 		legacyTries     stat
 		stateLookups    stat
 		accountTries    stat
 		storageTries    stat
 		codes           stat
 		txLookups       stat
+		receiptLookups  stat
+		creations       stat
+		sidecarStatuses stat
 		accountSnaps    stat
 		storageSnaps    stat
 		preimages       stat
@@ -490,6 +545,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			numHashPairings.Add(size)
 		case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
 			hashNumPairings.Add(size)
+		case bytes.HasPrefix(key, blockHashIndexPrefix) && len(key) == (len(blockHashIndexPrefix)+8+common.HashLength):
+			blockHashIndex.Add(size)
 		case IsLegacyTrieNode(key, it.Value()):
 			legacyTries.Add(size)
 		case bytes.HasPrefix(key, stateIDPrefix) && len(key) == len(stateIDPrefix)+common.HashLength:
@@ -502,6 +559,14 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			codes.Add(size)
 		case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
 			txLookups.Add(size)
+		case bytes.HasPrefix(key, receiptLookupPrefix) && len(key) == (len(receiptLookupPrefix)+common.HashLength):
+			receiptLookups.Add(size)
+		case bytes.HasPrefix(key, contractCreationPrefix) && len(key) == (len(contractCreationPrefix)+common.AddressLength):
+			creations.Add(size)
+		case bytes.HasPrefix(key, blobSidecarsStatusPrefix) && len(key) == (len(blobSidecarsStatusPrefix)+8+common.HashLength):
+			sidecarStatuses.Add(size)
+		case bytes.HasPrefix(key, chainAuditPrefix) && len(key) == (len(chainAuditPrefix)+8):
+			metadata.Add(size)
 		case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
 			accountSnaps.Add(size)
 		case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
@@ -534,8 +599,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 				databaseVersionKey, headHeaderKey, headBlockKey, headFastBlockKey, lastPivotKey,
 				fastTrieProgressKey, snapshotDisabledKey, SnapshotRootKey, snapshotJournalKey,
 				snapshotGeneratorKey, snapshotRecoveryKey, txIndexTailKey, fastTxLookupLimitKey,
-				uncleanShutdownKey, badBlockKey, highestFinalityVoteKey, storeInternalTxsEnabledKey,
+				uncleanShutdownKey, badBlockKey, highestFinalityVoteKey, storeInternalTxsEnabledKey, tdFreezeBlockKey, blockWriteIntentKey, freezeIntentKey, schemeMigrationKey, legacyTrieCleanupKey, chainAuditLengthKey, chainAuditTailKey,
 				snapshotSyncStatusKey, persistentStateIDKey, trieJournalKey, snapshotSyncStatusKey, snapSyncStatusFlagKey,
+				blobFreezerOffsetKey, prunedRangesKey, historyTailKey, receiptIndexTailKey,
 			} {
 				if bytes.Equal(key, meta) {
 					metadata.Add(size)
@@ -554,55 +620,126 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		}
 	}
 
-	// Display the database statistic of key-value store.
-	stats := [][]string{
-		{"Key-Value store", "Headers", headers.Size(), headers.Count()},
-		{"Key-Value store", "Bodies", bodies.Size(), bodies.Count()},
-		{"Key-Value store", "Receipt lists", receipts.Size(), receipts.Count()},
-		{"Key-Value store", "Difficulties", tds.Size(), tds.Count()},
-		{"Key-Value store", "Block number->hash", numHashPairings.Size(), numHashPairings.Count()},
-		{"Key-Value store", "Block hash->number", hashNumPairings.Size(), hashNumPairings.Count()},
-		{"Key-Value store", "Transaction index", txLookups.Size(), txLookups.Count()},
-		{"Key-Value store", "Bloombit index", bloomBits.Size(), bloomBits.Count()},
-		{"Key-Value store", "Contract codes", codes.Size(), codes.Count()},
-		{"Key-Value store", "Hash trie nodes", legacyTries.Size(), legacyTries.Count()},
-		{"Key-Value store", "Path trie state lookups", stateLookups.Size(), stateLookups.Count()},
-		{"Key-Value store", "Path trie account nodes", accountTries.Size(), accountTries.Count()},
-		{"Key-Value store", "Path trie storage nodes", storageTries.Size(), storageTries.Count()},
-		{"Key-Value store", "Trie preimages", preimages.Size(), preimages.Count()},
-		{"Key-Value store", "Account snapshot", accountSnaps.Size(), accountSnaps.Count()},
-		{"Key-Value store", "Storage snapshot", storageSnaps.Size(), storageSnaps.Count()},
-		{"Key-Value store", "Clique snapshots", cliqueSnaps.Size(), cliqueSnaps.Count()},
-		{"Key-Value store", "Consortium snapshots", consortiumSnaps.Size(), consortiumSnaps.Count()},
-		{"Key-Value store", "Singleton metadata", metadata.Size(), metadata.Count()},
-		{"Light client", "CHT trie nodes", chtTrieNodes.Size(), chtTrieNodes.Count()},
-		{"Light client", "Bloom trie nodes", bloomTrieNodes.Size(), bloomTrieNodes.Count()},
+	// Collect the database statistic of key-value store.
+	stats := []DatabaseStat{
+		{Database: "Key-Value store", Category: "Headers", Size: headers.size, Count: uint64(headers.count)},
+		{Database: "Key-Value store", Category: "Bodies", Size: bodies.size, Count: uint64(bodies.count)},
+		{Database: "Key-Value store", Category: "Receipt lists", Size: receipts.size, Count: uint64(receipts.count)},
+		{Database: "Key-Value store", Category: "Difficulties", Size: tds.size, Count: uint64(tds.count)},
+		{Database: "Key-Value store", Category: "Block number->hash", Size: numHashPairings.size, Count: uint64(numHashPairings.count)},
+		{Database: "Key-Value store", Category: "Block hash->number", Size: hashNumPairings.size, Count: uint64(hashNumPairings.count)},
+		{Database: "Key-Value store", Category: "Block number->hashes", Size: blockHashIndex.size, Count: uint64(blockHashIndex.count)},
+		{Database: "Key-Value store", Category: "Transaction index", Size: txLookups.size, Count: uint64(txLookups.count)},
+		{Database: "Key-Value store", Category: "Receipt index", Size: receiptLookups.size, Count: uint64(receiptLookups.count)},
+		{Database: "Key-Value store", Category: "Contract creation index", Size: creations.size, Count: uint64(creations.count)},
+		{Database: "Key-Value store", Category: "Blob sidecars statuses", Size: sidecarStatuses.size, Count: uint64(sidecarStatuses.count)},
+		{Database: "Key-Value store", Category: "Bloombit index", Size: bloomBits.size, Count: uint64(bloomBits.count)},
+		{Database: "Key-Value store", Category: "Contract codes", Size: codes.size, Count: uint64(codes.count)},
+		{Database: "Key-Value store", Category: "Hash trie nodes", Size: legacyTries.size, Count: uint64(legacyTries.count)},
+		{Database: "Key-Value store", Category: "Path trie state lookups", Size: stateLookups.size, Count: uint64(stateLookups.count)},
+		{Database: "Key-Value store", Category: "Path trie account nodes", Size: accountTries.size, Count: uint64(accountTries.count)},
+		{Database: "Key-Value store", Category: "Path trie storage nodes", Size: storageTries.size, Count: uint64(storageTries.count)},
+		{Database: "Key-Value store", Category: "Trie preimages", Size: preimages.size, Count: uint64(preimages.count)},
+		{Database: "Key-Value store", Category: "Account snapshot", Size: accountSnaps.size, Count: uint64(accountSnaps.count)},
+		{Database: "Key-Value store", Category: "Storage snapshot", Size: storageSnaps.size, Count: uint64(storageSnaps.count)},
+		{Database: "Key-Value store", Category: "Clique snapshots", Size: cliqueSnaps.size, Count: uint64(cliqueSnaps.count)},
+		{Database: "Key-Value store", Category: "Consortium snapshots", Size: consortiumSnaps.size, Count: uint64(consortiumSnaps.count)},
+		{Database: "Key-Value store", Category: "Singleton metadata", Size: metadata.size, Count: uint64(metadata.count)},
+		{Database: "Light client", Category: "CHT trie nodes", Size: chtTrieNodes.size, Count: uint64(chtTrieNodes.count)},
+		{Database: "Light client", Category: "Bloom trie nodes", Size: bloomTrieNodes.size, Count: uint64(bloomTrieNodes.count)},
 	}
 	// Inspect all registered append-only file store then.
 	ancients, err := inspectFreezers(db)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, ancient := range ancients {
 		for _, table := range ancient.sizes {
-			stats = append(stats, []string{
-				fmt.Sprintf("Ancient store (%s)", strings.Title(ancient.name)),
-				strings.Title(table.name),
-				table.size.String(),
-				fmt.Sprintf("%d", ancient.count()),
+			stats = append(stats, DatabaseStat{
+				Database: fmt.Sprintf("Ancient store (%s)", strings.Title(ancient.name)),
+				Category: strings.Title(table.name),
+				Size:     table.size,
+				Count:    ancient.count(),
 			})
 		}
 		total += ancient.size()
 	}
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Database", "Category", "Size", "Items"})
-	table.SetFooter([]string{"", "Total", total.String(), " "})
-	table.AppendBulk(stats)
-	table.Render()
+	return &DatabaseStats{
+		Stats: stats,
+		Total: total,
+		Unaccounted: DatabaseStat{
+			Database: "Key-Value store",
+			Category: "Unaccounted",
+			Size:     unaccounted.size,
+			Count:    uint64(unaccounted.count),
+		},
+	}, nil
+}
+
+// DatabaseStatDiff is the change of a category of data of the database between
+// two inspections.
+type DatabaseStatDiff struct {
+	Database string // Store holding the data, key-value or ancient
+	Category string // Category of the data within the store
+
+	Before DatabaseStat // Statistic of the earlier inspection, zero if missing
+	After  DatabaseStat // Statistic of the later inspection, zero if missing
+}
+
+// SizeDelta returns the growth of the storage size of the category, negative
+// if it shrank.
+func (d *DatabaseStatDiff) SizeDelta() common.StorageSize {
+	return d.After.Size - d.Before.Size
+}
+
+// CountDelta returns the growth of the number of items of the category,
+// negative if it shrank.
+func (d *DatabaseStatDiff) CountDelta() int64 {
+	return int64(d.After.Count) - int64(d.Before.Count)
+}
 
-	if unaccounted.size > 0 {
-		log.Error("Database contains unaccounted data", "size", unaccounted.size, "count", unaccounted.count)
+// DiffInspect compares two inspections of the same database and attributes the
+// change of its size to the categories of data, including the unaccounted one.
+// The categories are ordered by growth, the largest first; the ones that did not
+// change are omitted.
+func DiffInspect(before, after *DatabaseStats) []DatabaseStatDiff {
+	type category struct{ database, name string }
+
+	var (
+		diffs []DatabaseStatDiff
+		index = make(map[category]int)
+	)
+	collect := func(stat DatabaseStat, later bool) {
+		key := category{stat.Database, stat.Category}
+		i, ok := index[key]
+		if !ok {
+			i = len(diffs)
+			index[key] = i
+			diffs = append(diffs, DatabaseStatDiff{Database: stat.Database, Category: stat.Category})
+		}
+		if later {
+			diffs[i].After = stat
+		} else {
+			diffs[i].Before = stat
+		}
 	}
+	for _, stat := range before.Stats {
+		collect(stat, false)
+	}
+	collect(before.Unaccounted, false)
+	for _, stat := range after.Stats {
+		collect(stat, true)
+	}
+	collect(after.Unaccounted, true)
 
-	return nil
+	changed := diffs[:0]
+	for _, diff := range diffs {
+		if diff.Before.Size != diff.After.Size || diff.Before.Count != diff.After.Count {
+			changed = append(changed, diff)
+		}
+	}
+	sort.SliceStable(changed, func(i, j int) bool {
+		return changed[i].SizeDelta() > changed[j].SizeDelta()
+	})
+	return changed
 }
@@ -15,3 +15,64 @@
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
 package rawdb
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that the growth of a database between two inspections is attributed to
+// the categories of data that grew, the largest growth first.
+func TestDiffInspect(t *testing.T) {
+	db, err := NewDatabaseWithFreezer(NewMemoryDatabase(), t.TempDir(), "", false)
+	if err != nil {
+		t.Fatalf("failed to create database with ancient backend: %v", err)
+	}
+	defer db.Close()
+
+	before, err := InspectDatabaseStats(db, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	code := make([]byte, 1024)
+	WriteCode(db, common.Hash{1}, code)
+	WriteCode(db, common.Hash{2}, code)
+	WriteCanonicalHash(db, common.Hash{3}, 1)
+	db.Put([]byte("unknown"), []byte{0x01})
+
+	after, err := InspectDatabaseStats(db, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to inspect database: %v", err)
+	}
+	diffs := DiffInspect(before, after)
+
+	want := []struct {
+		category string
+		size     common.StorageSize
+		count    int64
+	}{
+		{"Contract codes", 2 * common.StorageSize(len(CodePrefix)+common.HashLength+len(code)), 2},
+		{"Block number->hash", common.StorageSize(len(headerPrefix) + 8 + len(headerHashSuffix) + common.HashLength), 1},
+		{"Unaccounted", common.StorageSize(len("unknown") + 1), 1},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("diff count mismatch: have %d, want %d: %+v", len(diffs), len(want), diffs)
+	}
+	for i, w := range want {
+		if diffs[i].Category != w.category {
+			t.Errorf("diff %d: category mismatch: have %s, want %s", i, diffs[i].Category, w.category)
+		}
+		if diffs[i].SizeDelta() != w.size {
+			t.Errorf("diff %d: size mismatch: have %v, want %v", i, diffs[i].SizeDelta(), w.size)
+		}
+		if diffs[i].CountDelta() != w.count {
+			t.Errorf("diff %d: count mismatch: have %d, want %d", i, diffs[i].CountDelta(), w.count)
+		}
+	}
+	// Shrinking data is attributed negatively
+	diffs = DiffInspect(after, before)
+	if last := diffs[len(diffs)-1]; last.Category != "Contract codes" || last.CountDelta() != -2 {
+		t.Errorf("shrink mismatch: have %s/%d, want %s/%d", last.Category, last.CountDelta(), "Contract codes", -2)
+	}
+}
@@ -51,6 +51,9 @@ var (
 )
 
 const (
+	// freezerLockName is the name of the file-system lock of the freezer. Leveldb
+	// uses LOCK as the filelock filename, so FLOCK prevents the name collision.
+	freezerLockName = "FLOCK"
 
 	// freezerTableSize defines the maximum size of freezer data files, max size of per file is 2GB.
 	freezerTableSize = 2 * 1000 * 1000 * 1000
@@ -73,8 +76,13 @@ type Freezer struct {
 	writeLock  sync.RWMutex
 	writeBatch *freezerBatch
 
+	// This lock defers head and tail truncations while a backup copies the
+	// table files, without blocking appends.
+	truncateLock sync.RWMutex
+
 	readonly     bool
 	tables       map[string]*freezerTable // Data tables for storing everything
+	prunable     map[string]bool          // Tables whose tail may be truncated ahead of the others
 	instanceLock fileutil.Releaser        // File-system lock to prevent double opens
 
 	trigger chan chan struct{} // Manual blocking freeze trigger, test determinism
@@ -90,6 +98,12 @@ type Freezer struct {
 // The 'tables' argument defines the data tables. If the value of a map
 // entry is true, snappy compression is disabled for the table.
 func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool) (*Freezer, error) {
+	return newFreezer(datadir, namespace, readonly, maxTableSize, tables, nil)
+}
+
+// newFreezer creates a freezer whose prunable tables may have their tail
+// truncated on their own, the tail of the freezer being that of the others.
+func newFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]bool, prunable map[string]bool) (*Freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -107,9 +121,7 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 			return nil, errSymlinkDatadir
 		}
 	}
-	// Leveldb uses LOCK as the filelock filename. To prevent the
-	// name collision, we use FLOCK as the lock name.
-	lock, _, err := fileutil.Flock(filepath.Join(datadir, "FLOCK"))
+	lock, err := acquireLock(filepath.Join(datadir, freezerLockName))
 	if err != nil {
 		return nil, err
 	}
@@ -117,6 +129,7 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 	freezer := &Freezer{
 		readonly:     readonly,
 		tables:       make(map[string]*freezerTable),
+		prunable:     prunable,
 		instanceLock: lock,
 		trigger:      make(chan chan struct{}),
 		quit:         make(chan struct{}),
@@ -281,6 +294,9 @@ func (f *Freezer) TruncateHead(items uint64) (uint64, error) {
 	if f.readonly {
 		return 0, errReadOnly
 	}
+	f.truncateLock.Lock()
+	defer f.truncateLock.Unlock()
+
 	f.writeLock.Lock()
 	defer f.writeLock.Unlock()
 
@@ -289,6 +305,10 @@ func (f *Freezer) TruncateHead(items uint64) (uint64, error) {
 	if previousItems <= items {
 		return previousItems, nil
 	}
+	// Refuse upfront to leave the tables inconsistent if the pruned items are hit
+	if tail := f.prunedTail(); items < tail {
+		return 0, fmt.Errorf("truncation below pruned tail %d", tail)
+	}
 	for _, table := range f.tables {
 		if err := table.truncateHead(items); err != nil {
 			return 0, err
@@ -303,6 +323,9 @@ func (f *Freezer) TruncateTail(tail uint64) (uint64, error) {
 	if f.readonly {
 		return 0, errReadOnly
 	}
+	f.truncateLock.Lock()
+	defer f.truncateLock.Unlock()
+
 	f.writeLock.Lock()
 	defer f.writeLock.Unlock()
 
@@ -322,6 +345,70 @@ func (f *Freezer) TruncateTail(tail uint64) (uint64, error) {
 	return old, nil
 }
 
+// prunedTail returns the number of the first item stored in all the prunable
+// tables, the tail of the freezer if there are none.
+func (f *Freezer) prunedTail() uint64 {
+	tail := f.tail.Load()
+	for name := range f.prunable {
+		if table := f.tables[name]; table != nil && table.itemHidden.Load() > tail {
+			tail = table.itemHidden.Load()
+		}
+	}
+	return tail
+}
+
+// truncatePrunableTail discards the items below the provided threshold number
+// of the prunable tables only, returning their old tail number. The tail can't
+// be moved above the frozen items.
+func (f *Freezer) truncatePrunableTail(tail uint64) (uint64, error) {
+	if f.readonly {
+		return 0, errReadOnly
+	}
+	f.truncateLock.Lock()
+	defer f.truncateLock.Unlock()
+
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	old := f.prunedTail()
+	if old >= tail {
+		return old, nil
+	}
+	if frozen := f.frozen.Load(); tail > frozen {
+		return 0, fmt.Errorf("truncation above head %d", frozen)
+	}
+	for name := range f.prunable {
+		if table := f.tables[name]; table != nil {
+			if err := table.truncateTail(tail); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return old, nil
+}
+
+// prunableTailSize returns the number of bytes released in each prunable table
+// by truncating their tails to the provided threshold number.
+func (f *Freezer) prunableTailSize(tail uint64) (map[string]uint64, error) {
+	f.truncateLock.RLock()
+	defer f.truncateLock.RUnlock()
+
+	if frozen := f.frozen.Load(); tail > frozen {
+		return nil, fmt.Errorf("truncation above head %d", frozen)
+	}
+	sizes := make(map[string]uint64)
+	for name := range f.prunable {
+		if table := f.tables[name]; table != nil {
+			size, err := table.tailSize(tail)
+			if err != nil {
+				return nil, err
+			}
+			sizes[name] = size
+		}
+	}
+	return sizes, nil
+}
+
 // Sync flushes all data tables to disk.
 func (f *Freezer) Sync() error {
 	var errs []error
@@ -342,13 +429,17 @@ func (f *Freezer) repair() error {
 		head = uint64(math.MaxUint64)
 		tail = uint64(0)
 	)
-	// Looping through all tables to find the most common head and tail between tables
-	for _, table := range f.tables {
+	// Looping through all tables to find the most common head and tail between tables,
+	// the prunable tables being allowed to keep a tail of their own
+	for name, table := range f.tables {
 		items := table.items.Load()
 
 		if head > items {
 			head = items
 		}
+		if f.prunable[name] {
+			continue
+		}
 		hidden := table.itemHidden.Load()
 		if hidden > tail {
 			tail = hidden
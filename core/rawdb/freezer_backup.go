@@ -0,0 +1,219 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// freezerBackupManifest is the name of the file describing a freezer backup.
+const freezerBackupManifest = "BACKUP.json"
+
+// FreezerBackup is the manifest of a consistent copy of the freezer tables.
+type FreezerBackup struct {
+	Created time.Time         `json:"created"`
+	Items   uint64            `json:"items"` // Number of items frozen at the time of the backup
+	Tail    uint64            `json:"tail"`  // Number of the first item retained at the time of the backup
+	Files   map[string]uint64 `json:"files"` // Size of each copied file, keyed by name
+}
+
+// backupFile is a file of a freezer table to be copied into a backup.
+type backupFile struct {
+	path string // Path of the file in the freezer
+	size int64  // Number of bytes to copy, negative for the whole file
+}
+
+// backupFiles returns the files holding the current items of the table, along
+// with their sizes at this point. The caller must hold the freezer write lock so
+// that no batch is being appended.
+func (t *freezerTable) backupFiles() ([]backupFile, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	stat, err := t.index.Stat()
+	if err != nil {
+		return nil, err
+	}
+	files := []backupFile{
+		{path: t.index.Name(), size: stat.Size()},
+		{path: t.meta.Name(), size: -1},
+	}
+	for num := t.tailId; num <= t.headId; num++ {
+		file := backupFile{path: filepath.Join(t.path, t.fileName(num)), size: -1}
+		if num == t.headId {
+			file.size = t.headBytes
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Backup creates a consistent copy of the freezer tables in the given directory,
+// which must be empty or not exist yet, and writes a manifest for restoring it
+// with RestoreFreezerBackup. Appends continue while the tables are copied, only
+// head and tail truncations wait until the backup is done. All the files are
+// copied up to their size at the time of the backup, never linked: the freezer
+// truncates and appends to its sealed files in place when its head is truncated,
+// which would corrupt a backup sharing them.
+func (f *Freezer) Backup(dir string) (*FreezerBackup, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, fmt.Errorf("backup directory %s not empty", dir)
+	}
+	f.truncateLock.RLock()
+	defer f.truncateLock.RUnlock()
+
+	// Capture the table files at a point where no batch is being appended
+	f.writeLock.RLock()
+	var (
+		files  []backupFile
+		backup = &FreezerBackup{
+			Created: time.Now(),
+			Items:   f.frozen.Load(),
+			Tail:    f.tail.Load(),
+			Files:   make(map[string]uint64),
+		}
+	)
+	for _, table := range f.tables {
+		tableFiles, err := table.backupFiles()
+		if err != nil {
+			f.writeLock.RUnlock()
+			return nil, err
+		}
+		files = append(files, tableFiles...)
+	}
+	f.writeLock.RUnlock()
+
+	for _, file := range files {
+		name := filepath.Base(file.path)
+		size, err := copyFreezerFile(file.path, filepath.Join(dir, name), file.size)
+		if err != nil {
+			return nil, err
+		}
+		backup.Files[name] = size
+	}
+	// Write the manifest last, so that incomplete backups cannot be restored
+	if err := writeFreezerManifest(dir, backup); err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// BackupFreezer creates a consistent copy of the chain freezer of the database in
+// the given directory, see Freezer.Backup.
+func BackupFreezer(db ethdb.Database, dir string) (*FreezerBackup, error) {
+	chain := chainFreezerOf(db)
+	if chain == nil {
+		return nil, errors.New("database has no chain freezer")
+	}
+	return chain.Backup(dir)
+}
+
+// RestoreFreezerBackup copies a backup created by Freezer.Backup into the given
+// ancient directory, which must not hold any of its files yet. The backup is
+// checked against its manifest before anything is copied.
+func RestoreFreezerBackup(backupDir, datadir string) (*FreezerBackup, error) {
+	blob, err := os.ReadFile(filepath.Join(backupDir, freezerBackupManifest))
+	if err != nil {
+		return nil, err
+	}
+	backup := new(FreezerBackup)
+	if err := json.Unmarshal(blob, backup); err != nil {
+		return nil, err
+	}
+	for name, size := range backup.Files {
+		if filepath.Base(name) != name {
+			return nil, fmt.Errorf("invalid backup file name %q", name)
+		}
+		info, err := os.Stat(filepath.Join(backupDir, name))
+		if err != nil {
+			return nil, err
+		}
+		if uint64(info.Size()) != size {
+			return nil, fmt.Errorf("backup file %s size mismatch: have %d, want %d", name, info.Size(), size)
+		}
+		if _, err := os.Stat(filepath.Join(datadir, name)); err == nil {
+			return nil, fmt.Errorf("freezer file %s already exists", name)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(datadir, 0755); err != nil {
+		return nil, err
+	}
+	for name, size := range backup.Files {
+		if _, err := copyFreezerFile(filepath.Join(backupDir, name), filepath.Join(datadir, name), int64(size)); err != nil {
+			return nil, err
+		}
+	}
+	return backup, nil
+}
+
+// writeFreezerManifest writes the manifest of a backup into its directory.
+func writeFreezerManifest(dir string, backup *FreezerBackup) error {
+	blob, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, freezerBackupManifest), blob, 0644)
+}
+
+// copyFreezerFile copies the first size bytes of src, or all of it if size is
+// negative, into dst. It returns the size of the resulting file.
+func copyFreezerFile(src, dst string, size int64) (uint64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer out.Close()
+
+	var r io.Reader = in
+	if size >= 0 {
+		r = io.LimitReader(in, size)
+	}
+	n, err := io.Copy(out, r)
+	if err != nil {
+		return 0, err
+	}
+	if size >= 0 && n != size {
+		return 0, fmt.Errorf("short copy of %s: have %d bytes, want %d", src, n, size)
+	}
+	if err := out.Sync(); err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
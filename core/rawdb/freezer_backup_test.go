@@ -0,0 +1,161 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests that a freezer backup taken while items are being appended restores to
+// a consistent freezer holding exactly the items frozen at the time of the backup.
+func TestFreezerBackupRestore(t *testing.T) {
+	tables := map[string]bool{"raw": true, "snappy": false}
+	f, dir := newFreezerForTesting(t, tables)
+	defer os.RemoveAll(dir)
+	defer f.Close()
+
+	appendItems := func(from, to uint64) {
+		_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for item := from; item < to; item++ {
+				for kind := range tables {
+					if err := op.AppendRaw(kind, item, getChunk(64, int(item))); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	appendItems(0, 200)
+	if _, err := f.TruncateTail(40); err != nil {
+		t.Fatalf("failed to truncate tail: %v", err)
+	}
+	// Keep appending while the backup is running
+	var (
+		wg   sync.WaitGroup
+		stop = make(chan struct{})
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for item := uint64(200); ; item += 10 {
+			select {
+			case <-stop:
+				return
+			default:
+				appendItems(item, item+10)
+			}
+		}
+	}()
+	backupDir := filepath.Join(t.TempDir(), "backup")
+	backup, err := f.Backup(backupDir)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("failed to back up freezer: %v", err)
+	}
+	if backup.Items < 200 || backup.Tail != 40 {
+		t.Fatalf("backup range mismatch: have items %d tail %d, want >= 200 and 40", backup.Items, backup.Tail)
+	}
+	if _, err := f.Backup(backupDir); err == nil {
+		t.Fatalf("backed up into non-empty directory")
+	}
+	// Rewrite the live items across the data file boundaries, which truncates and
+	// appends to the sealed files in place: the backup must not be affected
+	for name := range backup.Files {
+		live, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		copied, err := os.Stat(filepath.Join(backupDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if os.SameFile(live, copied) {
+			t.Fatalf("backup file %s shared with the freezer", name)
+		}
+	}
+	if _, err := f.TruncateHead(60); err != nil {
+		t.Fatalf("failed to truncate head: %v", err)
+	}
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for item := uint64(60); item < 200; item++ {
+			for kind := range tables {
+				if err := op.AppendRaw(kind, item, getChunk(64, int(item)+1)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to rewrite items: %v", err)
+	}
+	// Restore the backup and check that it holds exactly the backed up items
+	restoreDir := filepath.Join(t.TempDir(), "restore")
+	if _, err := RestoreFreezerBackup(backupDir, restoreDir); err != nil {
+		t.Fatalf("failed to restore backup: %v", err)
+	}
+	if _, err := RestoreFreezerBackup(backupDir, restoreDir); err == nil {
+		t.Fatalf("restored over existing freezer files")
+	}
+	restored, err := NewFreezer(restoreDir, "", true, 2049, tables)
+	if err != nil {
+		t.Fatalf("failed to open restored freezer: %v", err)
+	}
+	defer restored.Close()
+
+	if items, _ := restored.Ancients(); items != backup.Items {
+		t.Fatalf("restored item count mismatch: have %d, want %d", items, backup.Items)
+	}
+	if tail, _ := restored.Tail(); tail != backup.Tail {
+		t.Fatalf("restored tail mismatch: have %d, want %d", tail, backup.Tail)
+	}
+	for kind := range tables {
+		for item := backup.Tail; item < backup.Items; item++ {
+			blob, err := restored.Ancient(kind, item)
+			if err != nil {
+				t.Fatalf("failed to read restored %s item %d: %v", kind, item, err)
+			}
+			if !bytes.Equal(blob, getChunk(64, int(item))) {
+				t.Fatalf("restored %s item %d mismatch", kind, item)
+			}
+		}
+	}
+	// Tampering with a backed up file must fail the restore
+	for name, size := range backup.Files {
+		if size == 0 {
+			continue
+		}
+		if err := os.Truncate(filepath.Join(backupDir, name), 0); err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+	if _, err := RestoreFreezerBackup(backupDir, filepath.Join(t.TempDir(), "tampered")); err == nil {
+		t.Fatalf("restored tampered backup")
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/golang/snappy"
+	lru "github.com/hashicorp/golang-lru/v2"
 )
 
 var (
@@ -46,6 +47,16 @@ var (
 	errNotSupported = errors.New("this operation is not supported")
 )
 
+// freezerTableCacheItems is the number of recently retrieved items cached by
+// each freezer table, decompressed.
+const freezerTableCacheItems = 256
+
+// freezerCacheKey is the key of an item in the cache of a freezer table.
+type freezerCacheKey struct {
+	gen  uint64 // Number of head truncations when the item was read
+	item uint64
+}
+
 // indexEntry contains the number/id of the file that the data resides in, aswell as the
 // offset within the file to the end of the data
 // In serialized form, the filenum is stored as uint16.
@@ -115,6 +126,11 @@ type freezerTable struct {
 	writeMeter metrics.Meter // Meter for measuring the effective amount of data written
 	sizeGauge  metrics.Gauge // Gauge for tracking the combined size of all freezer tables
 
+	view     atomic.Pointer[freezerView]         // Mapped files for the concurrent reads, nil if disabled
+	views    sync.WaitGroup                      // Views not released yet, current and retired
+	cache    *lru.Cache[freezerCacheKey, []byte] // Recently retrieved items, decompressed
+	cacheGen atomic.Uint64                       // Number of head truncations, invalidating the cached items
+
 	logger log.Logger   // Logger with database path and table name ambedded
 	lock   sync.RWMutex // Mutex protecting the data file descriptors
 }
@@ -168,10 +184,13 @@ func newTable(path string, name string, readMeter metrics.Meter, writeMeter metr
 		noCompression: noCompression,
 		maxFileSize:   maxFilesize,
 	}
+	tab.cache, _ = lru.New[freezerCacheKey, []byte](freezerTableCacheItems)
 	if err := tab.repair(); err != nil {
 		tab.Close()
 		return nil, err
 	}
+	tab.view.Store(tab.mapView(nil))
+
 	// Initialize the starting size counter
 	size, err := tab.sizeNolock()
 	if err != nil {
@@ -379,6 +398,13 @@ func (t *freezerTable) truncateHead(items uint64) error {
 	}
 	log("Truncating freezer table", "items", existing, "limit", items)
 
+	// Wait for the concurrent reads of the mapped files before truncating them,
+	// and drop the cached items which are being overwritten
+	t.unmapView()
+	defer func() { t.view.Store(t.mapView(nil)) }()
+	t.cacheGen.Add(1)
+	t.cache.Purge()
+
 	// Truncate the index file first, the tail position is also considered
 	// when calculating the new freezer table length.
 	// Calculate the new expected size of the data file and truncate it
@@ -512,6 +538,11 @@ func (t *freezerTable) truncateTail(items uint64) error {
 	if err := t.meta.Sync(); err != nil {
 		return err
 	}
+	// Wait for the concurrent reads of the mapped files before replacing the
+	// index file and deleting the data files
+	t.unmapView()
+	defer func() { t.view.Store(t.mapView(nil)) }()
+
 	// Close the index file before shorten it.
 	if err := t.index.Close(); err != nil {
 		return err
@@ -558,6 +589,9 @@ func (t *freezerTable) Close() error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	t.unmapView()
+	t.cache.Purge()
+
 	var errs []error
 	doClose := func(f *os.File, sync bool, close bool) {
 		if sync {
@@ -594,6 +628,63 @@ func (t *freezerTable) Close() error {
 	return nil
 }
 
+// tailSize returns the number of bytes of the data and index files which would
+// be released by truncating the tail of the table to the given number of items.
+// The hidden items sharing a data file with the new tail aren't released.
+func (t *freezerTable) tailSize(items uint64) (uint64, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.itemHidden.Load() >= items {
+		return 0, nil
+	}
+	if t.items.Load() < items {
+		return 0, errors.New("truncation above head")
+	}
+	var (
+		newTailId = t.headId
+		buffer    = make([]byte, indexEntrySize)
+	)
+	if t.items.Load() != items {
+		offset := items - t.itemOffset.Load()
+		if _, err := t.index.ReadAt(buffer, int64((offset+1)*indexEntrySize)); err != nil {
+			return 0, err
+		}
+		var newTailIndex indexEntry
+		newTailIndex.unmarshalBinary(buffer)
+		newTailId = newTailIndex.filenum
+	}
+	if t.tailId >= newTailId {
+		return 0, nil
+	}
+	var size uint64
+	for id := t.tailId; id < newTailId; id++ {
+		stat, err := os.Stat(filepath.Join(t.path, t.fileName(id)))
+		if err != nil {
+			return 0, err
+		}
+		size += uint64(stat.Size())
+	}
+	// Count the index entries dropped along with the data files, the same way
+	// as the tail truncation.
+	var (
+		newDeleted = items
+		deleted    = t.itemOffset.Load()
+	)
+	for current := items - 1; current >= deleted; current -= 1 {
+		if _, err := t.index.ReadAt(buffer, int64((current-deleted+1)*indexEntrySize)); err != nil {
+			return 0, err
+		}
+		var pre indexEntry
+		pre.unmarshalBinary(buffer)
+		if pre.filenum != newTailId {
+			break
+		}
+		newDeleted = current
+	}
+	return size + (newDeleted-deleted)*indexEntrySize, nil
+}
+
 // releaseFilesBefore closes all open files with a lower number, and optionally also deletes the files
 func (t *freezerTable) releaseFilesBefore(num uint32, remove bool) {
 	for fnum, f := range t.files {
@@ -611,13 +702,7 @@ func (t *freezerTable) releaseFilesBefore(num uint32, remove bool) {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.noCompression {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
-		f, err = opener(filepath.Join(t.path, name))
+		f, err = opener(filepath.Join(t.path, t.fileName(num)))
 		if err != nil {
 			return nil, err
 		}
@@ -626,6 +711,14 @@ func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error
 	return f, err
 }
 
+// fileName returns the name of the data file with the given number.
+func (t *freezerTable) fileName(num uint32) string {
+	if t.noCompression {
+		return fmt.Sprintf("%s.%04d.rdat", t.name, num)
+	}
+	return fmt.Sprintf("%s.%04d.cdat", t.name, num)
+}
+
 // releaseFile closes a file, and removes it from the open file cache.
 // Assumes that the caller holds the write lock
 func (t *freezerTable) releaseFile(num uint32) {
@@ -662,6 +755,12 @@ func (t *freezerTable) getIndices(from, count uint64) ([]*indexEntry, error) {
 	if _, err := t.index.ReadAt(buffer, int64(from*indexEntrySize)); err != nil {
 		return nil, err
 	}
+	return decodeIndices(buffer, from, count), nil
+}
+
+// decodeIndices decodes the count+1 index entries of the given buffer, read
+// from the given item of the index file.
+func decodeIndices(buffer []byte, from, count uint64) []*indexEntry {
 	var (
 		indices []*indexEntry
 		offset  int
@@ -681,16 +780,25 @@ func (t *freezerTable) getIndices(from, count uint64) ([]*indexEntry, error) {
 		indices[0].offset = 0
 		indices[0].filenum = indices[1].filenum
 	}
-	return indices, nil
+	return indices
 }
 
 // Retrieve looks up the data offset of an item with the given number and retrieves
 // the raw binary blob from the data file.
 func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	// The cached items are keyed by the number of head truncations, not to serve
+	// an item read before the table was truncated below it.
+	key := freezerCacheKey{gen: t.cacheGen.Load(), item: item}
+	if t.view.Load() != nil && t.has(item) {
+		if data, ok := t.cache.Get(key); ok {
+			return common.CopyBytes(data), nil
+		}
+	}
 	items, err := t.RetrieveItems(item, 1, 0)
 	if err != nil {
 		return nil, err
 	}
+	t.cache.Add(key, common.CopyBytes(items[0]))
 	return items[0], nil
 }
 
@@ -740,6 +848,15 @@ func (t *freezerTable) RetrieveItems(start, count, maxBytes uint64) ([][]byte, e
 // data if maxBytes is 0. It returns the (potentially compressed) data, and
 // the sizes.
 func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []int, error) {
+	// Read the items of the sealed data files out of the mapped view, without
+	// contending on the lock with the other readers
+	if v := t.acquireView(); v != nil {
+		output, sizes, ok, err := v.retrieve(t, start, count, maxBytes)
+		v.release()
+		if ok || err != nil {
+			return output, sizes, err
+		}
+	}
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
@@ -758,7 +875,27 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 	if start+count > items {
 		count = items - start
 	}
+	// Read all the indexes in one go
+	indices, err := t.getIndices(start, count)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t.readItems(indices, maxBytes, func(fileId, start uint32, dst []byte) error {
+		dataFile, exist := t.files[fileId]
+		if !exist {
+			return fmt.Errorf("missing data file %d", fileId)
+		}
+		if _, err := dataFile.ReadAt(dst, int64(start)); err != nil {
+			return fmt.Errorf("%w, fileid: %d, start: %d, length: %d", err, fileId, start, len(dst))
+		}
+		return nil
+	})
+}
 
+// readItems reads the data of the items delimited by the given index entries
+// with the given reader, sequential items of a data file in one go. It reads at
+// least one item, but otherwise avoids reading more than maxBytes bytes.
+func (t *freezerTable) readItems(indices []*indexEntry, maxBytes uint64, read func(fileId, start uint32, dst []byte) error) ([]byte, []int, error) {
 	var output []byte // Buffer to read data into
 
 	if maxBytes != 0 {
@@ -772,21 +909,8 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 		// In case a small limit is used, and the elements are large, may need to
 		// realloc the read-buffer when reading the first (and only) item.
 		output = grow(output, length)
-		dataFile, exist := t.files[fileId]
-		if !exist {
-			return fmt.Errorf("missing data file %d", fileId)
-		}
-		if _, err := dataFile.ReadAt(output[len(output)-length:], int64(start)); err != nil {
-			return fmt.Errorf("%w, fileid: %d, start: %d, length: %d", err, fileId, start, length)
-		}
-		return nil
-	}
-	// Read all the indexes in one go
-	indices, err := t.getIndices(start, count)
-	if err != nil {
-		return nil, nil, err
+		return read(fileId, start, output[len(output)-length:])
 	}
-
 	var (
 		sizes      []int               // The sizes for each element
 		totalSize  = 0                 // The total size of all data read so far
@@ -885,6 +1009,9 @@ func (t *freezerTable) advanceHead() error {
 	t.head = newHead
 	t.headBytes = 0
 	t.headId = nextID
+
+	// Map the sealed file for the concurrent reads
+	t.swapView(t.mapView(t.view.Load()))
 	return nil
 }
 
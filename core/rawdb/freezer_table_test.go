@@ -18,10 +18,12 @@ package rawdb
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -398,6 +400,56 @@ func TestFreezerTruncate(t *testing.T) {
 	}
 }
 
+// TestFreezerTailSize tests that the size released by a tail truncation is
+// computed exactly beforehand, whole data files only being deleted.
+func TestFreezerTailSize(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("tailsize-%d", rand.Uint64())
+	dir := t.TempDir()
+
+	f, err := newTable(dir, fname, rm, wm, sg, 50, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Write 15 bytes 30 times, 3 items per data file
+	writeChunks(t, f, 30, 15)
+
+	diskSize := func() uint64 {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var size uint64
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				t.Fatal(err)
+			}
+			size += uint64(info.Size())
+		}
+		return size
+	}
+	for _, tail := range []uint64{1, 2, 3, 7, 7, 12, 30} {
+		want, err := f.tailSize(tail)
+		if err != nil {
+			t.Fatalf("tail %d: failed to compute size: %v", tail, err)
+		}
+		before := diskSize()
+		if err := f.truncateTail(tail); err != nil {
+			t.Fatalf("tail %d: failed to truncate: %v", tail, err)
+		}
+		if have := before - diskSize(); have != want {
+			t.Fatalf("tail %d: released size mismatch: have %d, want %d", tail, have, want)
+		}
+	}
+	if _, err := f.tailSize(31); err == nil {
+		t.Fatal("expected error for size above head")
+	}
+}
+
 // TestFreezerRepairFirstFile tests a head file with the very first item only half-written.
 // That will rewind the index, and _should_ truncate the head file
 func TestFreezerRepairFirstFile(t *testing.T) {
@@ -516,6 +568,140 @@ func TestFreezerReadAndTruncate(t *testing.T) {
 	}
 }
 
+// TestFreezerCachedTruncate tests that the cached items aren't served once the
+// table was truncated below them and they were written again.
+func TestFreezerCachedTruncate(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("cached_truncate-%d", rand.Uint64())
+
+	f, err := newTable(t.TempDir(), fname, rm, wm, sg, 50, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Write 15 bytes 30 times, reading them all into the cache
+	writeChunks(t, f, 30, 15)
+	for i := 0; i < 30; i++ {
+		if _, err := f.Retrieve(uint64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	require.NoError(t, f.truncateHead(10))
+	if _, err := f.Retrieve(20); err == nil {
+		t.Fatal("expected error for truncated item")
+	}
+	batch := f.newBatch()
+	for x := 10; x < 30; x++ {
+		require.NoError(t, batch.AppendRaw(uint64(x), getChunk(15, ^x)))
+	}
+	require.NoError(t, batch.commit())
+
+	for i := 0; i < 30; i++ {
+		want := getChunk(15, i)
+		if i >= 10 {
+			want = getChunk(15, ^i)
+		}
+		have, err := f.Retrieve(uint64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(have, want) {
+			t.Fatalf("item %d mismatch: have %x, want %x", i, have, want)
+		}
+	}
+}
+
+// TestFreezerConcurrentReads tests that the items are read consistently from the
+// mapped files and the head file while the table is appended to and truncated.
+func TestFreezerConcurrentReads(t *testing.T) {
+	t.Parallel()
+	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
+	fname := fmt.Sprintf("concurrent_reads-%d", rand.Uint64())
+
+	for _, noCompression := range []bool{true, false} {
+		f, err := newTable(t.TempDir(), fname, rm, wm, sg, 50, noCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeChunks(t, f, 30, 15)
+		if v := f.view.Load(); v == nil || len(v.data) != int(f.headId-f.tailId) {
+			t.Fatalf("sealed data files not mapped")
+		}
+		var (
+			wg   sync.WaitGroup
+			stop = make(chan struct{})
+			errc = make(chan error, 8)
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(seed int64) {
+				defer wg.Done()
+
+				rnd := rand.New(rand.NewSource(seed))
+				for {
+					select {
+					case <-stop:
+						return
+					default:
+					}
+					// Items below 20 are never truncated nor rewritten
+					item := uint64(rnd.Intn(20))
+					if rnd.Intn(2) == 0 {
+						have, err := f.Retrieve(item)
+						if err != nil {
+							if errors.Is(err, errOutOfBounds) {
+								continue
+							}
+							errc <- err
+							return
+						}
+						if !bytes.Equal(have, getChunk(15, int(item))) {
+							errc <- fmt.Errorf("item %d mismatch: %x", item, have)
+							return
+						}
+						continue
+					}
+					items, err := f.RetrieveItems(item, 5, 0)
+					if err != nil {
+						if errors.Is(err, errOutOfBounds) {
+							continue
+						}
+						errc <- err
+						return
+					}
+					for j, have := range items {
+						if want := getChunk(15, int(item)+j); int(item)+j < 20 && !bytes.Equal(have, want) {
+							errc <- fmt.Errorf("item %d mismatch: %x", int(item)+j, have)
+							return
+						}
+					}
+				}
+			}(int64(i))
+		}
+		// Append, truncate and hide the tail concurrently to the readers
+		for round := 0; round < 10; round++ {
+			require.NoError(t, f.truncateHead(20))
+			batch := f.newBatch()
+			for x := 20; x < 40; x++ {
+				require.NoError(t, batch.AppendRaw(uint64(x), getChunk(15, ^x)))
+			}
+			require.NoError(t, batch.commit())
+		}
+		require.NoError(t, f.truncateTail(7))
+		close(stop)
+		wg.Wait()
+		f.Close()
+
+		select {
+		case err := <-errc:
+			t.Fatalf("noCompression %v: %v", noCompression, err)
+		default:
+		}
+	}
+}
+
 func TestFreezerOffset(t *testing.T) {
 	t.Parallel()
 	rm, wm, sg := metrics.NewMeter(), metrics.NewMeter(), metrics.NewGauge()
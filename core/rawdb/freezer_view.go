@@ -0,0 +1,202 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/edsrzf/mmap-go"
+)
+
+// errNotMapped is returned when an item lies in a data file which isn't mapped,
+// to read it from the file instead.
+var errNotMapped = errors.New("data file not mapped")
+
+// freezerMapping is a read-only memory mapping of a freezer file, shared by the
+// views of a table and unmapped once the last of them released it.
+type freezerMapping struct {
+	mem  mmap.MMap
+	refs atomic.Int64 // Number of views holding the mapping
+}
+
+// release drops a view reference to the mapping, unmapping it if it was the last.
+func (m *freezerMapping) release() {
+	if m.refs.Add(-1) == 0 {
+		m.mem.Unmap()
+	}
+}
+
+// freezerView is an immutable snapshot of the memory-mapped index and sealed
+// data files of a freezer table, allowing their items to be read concurrently
+// without taking the table lock. The items of the head file, still growing,
+// are read from the file under the lock. A view is replaced whenever the files
+// change, and releases its mappings once retired and left by its last reader.
+type freezerView struct {
+	index      *freezerMapping            // Mapped index file
+	itemOffset uint64                     // Number of items removed from the table when mapped
+	items      uint64                     // Number of items with a mapped index entry
+	data       map[uint32]*freezerMapping // Mapped sealed data files
+
+	refs  atomic.Int64    // Number of readers, plus one for the table while current
+	once  sync.Once       // Releases the mappings once
+	views *sync.WaitGroup // Views of the table not released yet
+}
+
+// mapFreezerFile maps the given file read-only, returning nil if it's empty.
+func mapFreezerFile(f *os.File) (*freezerMapping, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if stat.Size() == 0 {
+		return nil, nil
+	}
+	mem, err := mmap.Map(f, mmap.RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	m := &freezerMapping{mem: mem}
+	m.refs.Store(1)
+	return m, nil
+}
+
+// mapView maps the index and the sealed data files of the table into a new view,
+// sharing the data mappings of the given previous view. The files which can't
+// be mapped, e.g. for lack of address space, are read under the lock instead.
+// The caller must hold the write lock, or be in an init context.
+func (t *freezerTable) mapView(prev *freezerView) *freezerView {
+	v := &freezerView{
+		itemOffset: t.itemOffset.Load(),
+		data:       make(map[uint32]*freezerMapping),
+		views:      &t.views,
+	}
+	v.refs.Store(1)
+	t.views.Add(1)
+
+	index, err := mapFreezerFile(t.index)
+	if err != nil {
+		t.logger.Warn("Failed to map freezer index", "err", err)
+	}
+	if index != nil {
+		v.index, v.items = index, v.itemOffset+uint64(len(index.mem)/indexEntrySize)-1
+	}
+	for id := t.tailId; id < t.headId; id++ {
+		if prev != nil {
+			if m, ok := prev.data[id]; ok {
+				m.refs.Add(1)
+				v.data[id] = m
+				continue
+			}
+		}
+		f, ok := t.files[id]
+		if !ok {
+			continue
+		}
+		m, err := mapFreezerFile(f)
+		if err != nil {
+			t.logger.Warn("Failed to map freezer data file", "file", id, "err", err)
+			continue
+		}
+		if m != nil {
+			v.data[id] = m
+		}
+	}
+	return v
+}
+
+// swapView replaces the view of the table by the given one, retiring the
+// previous one. The caller must hold the write lock.
+func (t *freezerTable) swapView(next *freezerView) {
+	if prev := t.view.Swap(next); prev != nil {
+		prev.release()
+	}
+}
+
+// unmapView disables the concurrent reads and waits until all the views are
+// released, for the files they map to be modified or removed. The caller must
+// hold the write lock.
+func (t *freezerTable) unmapView() {
+	t.swapView(nil)
+	t.views.Wait()
+}
+
+// acquireView returns the current view of the table, which must be released
+// after reading, or nil if there's none.
+func (t *freezerTable) acquireView() *freezerView {
+	for {
+		v := t.view.Load()
+		if v == nil {
+			return nil
+		}
+		v.refs.Add(1)
+		if t.view.Load() == v {
+			return v
+		}
+		// The view was retired meanwhile, its mappings may be gone already
+		v.release()
+	}
+}
+
+// release drops a reference to the view, releasing its mappings once the view
+// is retired and left by all its readers.
+func (v *freezerView) release() {
+	if v.refs.Add(-1) == 0 {
+		v.once.Do(func() {
+			if v.index != nil {
+				v.index.release()
+			}
+			for _, m := range v.data {
+				m.release()
+			}
+			v.views.Done()
+		})
+	}
+}
+
+// retrieve reads up to count items from start out of the mapped files, the same
+// way as retrieveItems. It returns false if some of the items aren't covered
+// by the view, to be read from the files instead.
+func (v *freezerView) retrieve(t *freezerTable, start, count, maxBytes uint64) ([]byte, []int, bool, error) {
+	items := t.items.Load()
+	if items <= start || t.itemHidden.Load() > start || count == 0 {
+		return nil, nil, true, errOutOfBounds
+	}
+	if start+count > items {
+		count = items - start
+	}
+	if start < v.itemOffset || start+count > v.items {
+		return nil, nil, false, nil
+	}
+	from := start - v.itemOffset
+	indices := decodeIndices(v.index.mem[from*indexEntrySize:(from+count+1)*indexEntrySize], from, count)
+
+	output, sizes, err := t.readItems(indices, maxBytes, func(fileId, start uint32, dst []byte) error {
+		m, ok := v.data[fileId]
+		if !ok || int(start)+len(dst) > len(m.mem) {
+			return errNotMapped
+		}
+		copy(dst, m.mem[start:])
+		return nil
+	})
+	if errors.Is(err, errNotMapped) {
+		return nil, nil, false, nil
+	}
+	return output, sizes, true, err
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// HealthReport is the outcome of the integrity probes run on a chain database
+// when opening it.
+type HealthReport struct {
+	HeadHeader    *uint64  // Number of the head header (nil = unknown)
+	HeadBlock     *uint64  // Number of the head block (nil = unknown)
+	HeadFastBlock *uint64  // Number of the head fast block (nil = unknown)
+	Frozen        uint64   // Number of blocks in the freezer
+	StaleLocks    []string // Stale lock files cleared before opening
+	Problems      []string // Integrity problems found
+}
+
+// Healthy reports whether no integrity problem was found.
+func (r *HealthReport) Healthy() bool {
+	return len(r.Problems) == 0
+}
+
+// String implements fmt.Stringer, summarizing the problems found.
+func (r *HealthReport) String() string {
+	if r.Healthy() {
+		return "healthy"
+	}
+	return strings.Join(r.Problems, "; ")
+}
+
+// problem records an integrity problem.
+func (r *HealthReport) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// CheckHealth runs quick integrity probes on a chain database: the head
+// pointers are resolvable and canonical, the head block is decodable and the
+// freezer is contiguous with the key-value store.
+func CheckHealth(db ethdb.Reader) *HealthReport {
+	report := new(HealthReport)
+
+	// A fresh database has nothing to check
+	if ReadCanonicalHash(db, 0) == (common.Hash{}) {
+		return report
+	}
+	report.HeadHeader = checkHeadPointer(db, report, "header", ReadHeadHeaderHash(db))
+	report.HeadBlock = checkHeadPointer(db, report, "block", ReadHeadBlockHash(db))
+	report.HeadFastBlock = checkHeadPointer(db, report, "fast block", ReadHeadFastBlockHash(db))
+
+	if report.HeadHeader != nil {
+		if report.HeadBlock != nil && *report.HeadBlock > *report.HeadHeader {
+			report.problem("head block #%d beyond head header #%d", *report.HeadBlock, *report.HeadHeader)
+		}
+		if report.HeadFastBlock != nil && *report.HeadFastBlock > *report.HeadHeader {
+			report.problem("head fast block #%d beyond head header #%d", *report.HeadFastBlock, *report.HeadHeader)
+		}
+	}
+	if report.HeadBlock != nil {
+		if ReadBlock(db, ReadHeadBlockHash(db), *report.HeadBlock) == nil {
+			report.problem("head block #%d not decodable", *report.HeadBlock)
+		}
+	}
+	// Check the boundary between the freezer and the key-value store
+	report.Frozen, _ = db.Ancients()
+	if report.Frozen > 0 {
+		if ReadCanonicalHash(db, report.Frozen-1) == (common.Hash{}) {
+			report.problem("last frozen block #%d missing", report.Frozen-1)
+		}
+		if report.HeadHeader != nil && *report.HeadHeader >= report.Frozen {
+			if ReadCanonicalHash(db, report.Frozen) == (common.Hash{}) {
+				report.problem("gap at #%d between the freezer and the key-value store", report.Frozen)
+			}
+		}
+	}
+	return report
+}
+
+// checkHeadPointer ensures a head pointer refers to a known canonical header,
+// returning its number.
+func checkHeadPointer(db ethdb.Reader, report *HealthReport, name string, hash common.Hash) *uint64 {
+	if hash == (common.Hash{}) {
+		report.problem("head %s missing", name)
+		return nil
+	}
+	number := ReadHeaderNumber(db, hash)
+	if number == nil {
+		report.problem("head %s %x unknown", name, hash)
+		return nil
+	}
+	if canon := ReadCanonicalHash(db, *number); canon != hash {
+		report.problem("head %s #%d %x not canonical, have %x", name, *number, hash, canon)
+	}
+	return number
+}
+
+// OpenWithHealthCheck opens a database like Open, recovering beforehand the
+// stale locks left behind by crashes, and probes the integrity of the opened
+// database. The report is returned even if opening fails.
+func OpenWithHealthCheck(o OpenOptions) (ethdb.Database, *HealthReport, error) {
+	report := new(HealthReport)
+	if len(o.AncientsDirectory) > 0 && !o.ReadOnly {
+		path := filepath.Join(resolveChainFreezerDir(o.AncientsDirectory), freezerLockName)
+		if recovered, err := recoverStaleLock(path); err != nil {
+			report.problem("failed to recover lock %s: %v", path, err)
+		} else if recovered {
+			report.StaleLocks = append(report.StaleLocks, path)
+		}
+	}
+	db, err := Open(o)
+	if err != nil {
+		report.problem("failed to open database: %v", err)
+		return nil, report, err
+	}
+	probes := CheckHealth(db)
+	probes.StaleLocks = report.StaleLocks
+	probes.Problems = append(report.Problems, probes.Problems...)
+	return db, probes, nil
+}
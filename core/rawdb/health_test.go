@@ -0,0 +1,119 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/tsdb/fileutil"
+)
+
+// Tests that the health check detects inconsistent head pointers and an
+// undecodable head block.
+func TestCheckHealth(t *testing.T) {
+	db := NewMemoryDatabase()
+	if report := CheckHealth(db); !report.Healthy() {
+		t.Fatalf("empty database unhealthy: %v", report)
+	}
+	var parent common.Hash
+	blocks := make([]*types.Block, 4)
+	for i := range blocks {
+		blocks[i] = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(int64(i)), ParentHash: parent, Extra: []byte("test")})
+		parent = blocks[i].Hash()
+
+		WriteBlock(db, blocks[i])
+		WriteCanonicalHash(db, blocks[i].Hash(), uint64(i))
+	}
+	head := blocks[len(blocks)-1]
+	WriteHeadHeaderHash(db, head.Hash())
+	WriteHeadBlockHash(db, head.Hash())
+	WriteHeadFastBlockHash(db, head.Hash())
+
+	report := CheckHealth(db)
+	if !report.Healthy() {
+		t.Fatalf("database unhealthy: %v", report)
+	}
+	if report.HeadBlock == nil || *report.HeadBlock != head.NumberU64() {
+		t.Fatalf("head block mismatch: have %v, want %d", report.HeadBlock, head.NumberU64())
+	}
+	// Point the head header below the head block and drop the head block body
+	WriteHeadHeaderHash(db, blocks[1].Hash())
+	DeleteBody(db, head.Hash(), head.NumberU64())
+
+	if report := CheckHealth(db); len(report.Problems) != 3 {
+		t.Fatalf("problems mismatch: have %v, want 3", report.Problems)
+	}
+	// Point the head fast block to a side block
+	WriteHeadHeaderHash(db, head.Hash())
+	WriteBody(db, head.Hash(), head.NumberU64(), head.Body())
+	side := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(2), ParentHash: blocks[1].Hash(), Extra: []byte("side")})
+	WriteBlock(db, side)
+	WriteHeadFastBlockHash(db, side.Hash())
+
+	if report := CheckHealth(db); len(report.Problems) != 1 {
+		t.Fatalf("problems mismatch: have %v, want 1", report.Problems)
+	}
+}
+
+// Tests that a lock is only cleared if it's free and its recorded owner is gone,
+// and that a held lock is never touched.
+func TestRecoverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), freezerLockName)
+
+	// A free lock of a live owner is not stale
+	lock, err := acquireLock(path)
+	if err != nil {
+		t.Fatalf("failed to acquire lock: %v", err)
+	}
+	lock.Release()
+	if recovered, err := recoverStaleLock(path); err != nil || recovered {
+		t.Fatalf("free lock recovered: %v, err %v", recovered, err)
+	}
+	// A free lock left behind by a gone process is stale, and cleared in place
+	os.WriteFile(path, []byte(strconv.Itoa(1<<30)), 0644)
+	if recovered, err := recoverStaleLock(path); err != nil || !recovered {
+		t.Fatalf("stale lock kept: %v, err %v", recovered, err)
+	}
+	if blob, err := os.ReadFile(path); err != nil || len(blob) != 0 {
+		t.Fatalf("stale lock not cleared: %q, err %v", blob, err)
+	}
+	// A held lock is never stale, whatever its recorded owner
+	held, _, err := fileutil.Flock(path)
+	if err != nil {
+		t.Fatalf("failed to hold lock: %v", err)
+	}
+	os.WriteFile(path, []byte(strconv.Itoa(1<<30)), 0644)
+	if recovered, err := recoverStaleLock(path); err != nil || recovered {
+		t.Fatalf("held lock recovered: %v, err %v", recovered, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("held lock removed: %v", err)
+	}
+	held.Release()
+
+	lock, err = acquireLock(path)
+	if err != nil {
+		t.Fatalf("failed to acquire recovered lock: %v", err)
+	}
+	lock.Release()
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/prometheus/tsdb/fileutil"
+)
+
+// acquireLock takes the file-system lock at the given path and records the
+// owning process in it, so a lock left behind can be told stale later.
+func acquireLock(path string) (fileutil.Releaser, error) {
+	lock, _, err := fileutil.Flock(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		log.Warn("Failed to record lock owner", "path", path, "err", err)
+	}
+	return lock, nil
+}
+
+// recoverStaleLock clears the lock file at the given path if its recorded owner
+// process is gone, as left behind by a crash. The lock is only considered stale
+// once taken by this process, and the file is never unlinked, as any process
+// still holding it would otherwise keep owning an orphaned lock while a new one
+// is created beside it. It reports whether a stale owner was cleared.
+func recoverStaleLock(path string) (bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	// If the lock can't be taken, it's held by a live process, hence not stale
+	lock, _, err := fileutil.Flock(path)
+	if err != nil {
+		return false, nil
+	}
+	defer lock.Release()
+
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(blob)))
+	if err != nil || pid <= 0 || pid == os.Getpid() || processAlive(pid) {
+		return false, nil
+	}
+	log.Warn("Clearing stale database lock", "path", path, "owner", pid)
+	if err := os.Truncate(path, 0); err != nil {
+		return false, err
+	}
+	return true, nil
+}
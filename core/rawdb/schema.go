@@ -85,6 +85,35 @@ var (
 	// storeInternalTxsEnabledKey flags that internal transactions will be stored into db
 	storeInternalTxsEnabledKey = []byte("storeInternalTxsEnabled")
 
+	// tdFreezeBlockKey tracks the block number from which total difficulty is
+	// no longer stored, the td of every later block equals the one of its parent.
+	tdFreezeBlockKey = []byte("TdFreezeBlock")
+
+	// blockWriteIntentKey tracks a block range being written into the ancient
+	// store, it only exists while the write is not yet complete.
+	blockWriteIntentKey = []byte("BlockWriteIntent")
+
+	// freezeIntentKey tracks a block range being moved into the ancient store
+	// by the chain freezer, it only exists while the freeze is not yet complete.
+	freezeIntentKey = []byte("FreezeIntent")
+
+	// schemeMigrationKey tracks the progress of the online migration of the
+	// hash-based state into the path-based layout.
+	schemeMigrationKey = []byte("SchemeMigration")
+
+	// legacyTrieCleanupKey tracks the position of the deletion of the hash-based
+	// trie nodes left behind by the scheme migration, it only exists until the
+	// deletion is complete.
+	legacyTrieCleanupKey = []byte("LegacyTrieCleanup")
+
+	// chainAuditLengthKey tracks the number of entries in the chain mutation
+	// audit log.
+	chainAuditLengthKey = []byte("ChainAuditLength")
+
+	// chainAuditTailKey tracks the sequence number of the oldest entry retained
+	// in the chain mutation audit log.
+	chainAuditTailKey = []byte("ChainAuditTail")
+
 	// lastFinalityVoteKey tracks the highest finality vote
 	highestFinalityVoteKey = []byte("HighestFinalityVote")
 
@@ -93,22 +122,49 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// blobFreezerOffsetKey tracks the number of the first block whose sidecars
+	// were moved into the blob freezer.
+	blobFreezerOffsetKey = []byte("BlobFreezerOffset")
+
+	// prunedRangesKey tracks the ranges of canonical blocks whose bodies or
+	// receipts were pruned on request.
+	prunedRangesKey = []byte("PrunedRanges")
+
+	// historyTailKey tracks the number of the first block whose body and
+	// receipts are kept by the history pruning.
+	historyTailKey = []byte("HistoryTail")
+
+	// receiptIndexTailKey tracks the oldest block whose transactions have their
+	// receipt lookups backfilled.
+	receiptIndexTailKey = []byte("ReceiptIndexTail")
+
+	// blockHashIndexProgressKey tracks the migration indexing the hashes of the
+	// blocks written before the block hash index, empty once it's done.
+	blockHashIndexProgressKey = []byte("BlockHashIndexProgress")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td
 	headerHashSuffix   = []byte("n") // headerPrefix + num (uint64 big endian) + headerHashSuffix -> hash
 	headerNumberPrefix = []byte("H") // headerNumberPrefix + hash -> num (uint64 big endian)
 
+	blockHashIndexPrefix = []byte("N") // blockHashIndexPrefix + num (uint64 big endian) + hash -> nil, tracks all non-frozen blocks at a height
+
 	blockBodyPrefix     = []byte("b") // blockBodyPrefix + num (uint64 big endian) + hash -> block body
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
 
-	blobSidecarsPrefix = []byte("s") // blobSidecarsPrefix + num (uint64 big endian) + hash -> sidecars
+	blobSidecarsPrefix       = []byte("s") // blobSidecarsPrefix + num (uint64 big endian) + hash -> sidecars
+	blobSidecarsStatusPrefix = []byte("V") // blobSidecarsStatusPrefix + num (uint64 big endian) + hash -> sidecars verification status
+
+	txLookupPrefix         = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
+	receiptLookupPrefix    = []byte("R") // receiptLookupPrefix + hash -> transaction position and outcome
+	contractCreationPrefix = []byte("C") // contractCreationPrefix + address -> contract creation metadata
+	bloomBitsPrefix        = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	SnapshotAccountPrefix  = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
+	SnapshotStoragePrefix  = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
+	CodePrefix             = []byte("c") // CodePrefix + code hash -> account code
 
-	txLookupPrefix        = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
-	bloomBitsPrefix       = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
-	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
-	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
-	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
+	chainAuditPrefix = []byte("M") // chainAuditPrefix + seq (uint64 big endian) -> chain mutation audit entry
 
 	internalTxsPrefix = []byte("itxs") // internalTxsPrefix + block hash -> internal transactions
 	dirtyAccountsKey  = []byte("dacc") // dirtyAccountsPrefix + block hash -> dirty accounts
@@ -154,6 +210,16 @@ func headerKey(number uint64, hash common.Hash) []byte {
 	return append(append(headerPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// blockHashIndexKeyPrefix = blockHashIndexPrefix + num (uint64 big endian)
+func blockHashIndexKeyPrefix(number uint64) []byte {
+	return append(blockHashIndexPrefix, encodeBlockNumber(number)...)
+}
+
+// blockHashIndexKey = blockHashIndexPrefix + num (uint64 big endian) + hash
+func blockHashIndexKey(number uint64, hash common.Hash) []byte {
+	return append(append(blockHashIndexPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // headerTDKey = headerPrefix + num (uint64 big endian) + hash + headerTDSuffix
 func headerTDKey(number uint64, hash common.Hash) []byte {
 	return append(headerKey(number, hash), headerTDSuffix...)
@@ -179,16 +245,50 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// BlockDataRanges returns the key ranges of the headers, bodies and receipts of
+// the blocks from (inclusive) to limit (exclusive), e.g. for compacting them
+// away after they were migrated into the freezer.
+func BlockDataRanges(from, limit uint64) [][2][]byte {
+	var ranges [][2][]byte
+	for _, prefix := range [][]byte{headerPrefix, blockBodyPrefix, blockReceiptsPrefix} {
+		ranges = append(ranges, [2][]byte{
+			append(common.CopyBytes(prefix), encodeBlockNumber(from)...),
+			append(common.CopyBytes(prefix), encodeBlockNumber(limit)...),
+		})
+	}
+	return ranges
+}
+
 // blobSidecarsKey = blobSidecarsPrefix + num (uint64 as big endian) + hash
 func blobSidecarsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blobSidecarsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// blobSidecarsStatusKey = blobSidecarsStatusPrefix + num (uint64 as big endian) + hash
+func blobSidecarsStatusKey(number uint64, hash common.Hash) []byte {
+	return append(append(blobSidecarsStatusPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
 // internalTxsKey = internalTxsPrefix + hash
 func internalTxsKey(hash common.Hash) []byte {
 	return append(internalTxsPrefix, hash.Bytes()...)
 }
 
+// receiptLookupKey = receiptLookupPrefix + hash
+func receiptLookupKey(hash common.Hash) []byte {
+	return append(receiptLookupPrefix, hash.Bytes()...)
+}
+
+// contractCreationKey = contractCreationPrefix + address
+func contractCreationKey(addr common.Address) []byte {
+	return append(contractCreationPrefix, addr.Bytes()...)
+}
+
+// chainAuditKey = chainAuditPrefix + seq (uint64 big endian)
+func chainAuditKey(seq uint64) []byte {
+	return append(chainAuditPrefix, encodeBlockNumber(seq)...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
@@ -181,6 +181,12 @@ func (t *table) Compact(start []byte, limit []byte) error {
 	return t.db.Compact(start, limit)
 }
 
+// ThrottleCompactions limits the background compactions of the underlying
+// database, shared by all the tables.
+func (t *table) ThrottleCompactions(throttle bool) {
+	t.db.ThrottleCompactions(throttle)
+}
+
 // NewBatch creates a write-only database that buffers changes to its host db
 // until a final write is called, each operation prefixing all keys with the
 // pre-configured string.
@@ -0,0 +1,179 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
+)
+
+// errNoChainConfig is returned if the database holds no chain configuration
+// for its genesis block.
+var errNoChainConfig = errors.New("chain config not found")
+
+// ReadOnlyChain is a detached, read-only view of the chain stored in a database,
+// serving the headers, blocks, receipts and states without ever writing to the
+// database nor starting any background goroutine. It is meant for the offline
+// analysis tools working on a database snapshot of a node.
+//
+// Nothing is cached, the chain head is read from the database on each access so
+// that it tracks the head of a database still being written by a live node.
+type ReadOnlyChain struct {
+	db         ethdb.Database
+	config     *params.ChainConfig
+	triedb     *trie.Database
+	stateCache state.Database
+}
+
+// OpenReadOnly opens a read-only view of the chain stored in the database. The
+// database must hold an initialized chain, its configuration being the one
+// stored along the genesis block.
+func OpenReadOnly(db ethdb.Database) (*ReadOnlyChain, error) {
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return nil, ErrNoGenesis
+	}
+	config := rawdb.ReadChainConfig(db, genesis)
+	if config == nil {
+		return nil, errNoChainConfig
+	}
+	// The path based database is the only one supporting the read-only mode,
+	// the hash based one never writes unless committed to.
+	trieConfig := &trie.Config{HashDB: hashdb.Defaults}
+	if rawdb.ReadStateScheme(db) == rawdb.PathScheme {
+		trieConfig = &trie.Config{PathDB: pathdb.ReadOnly}
+	}
+	triedb := trie.NewDatabase(db, trieConfig)
+
+	return &ReadOnlyChain{
+		db:         db,
+		config:     config,
+		triedb:     triedb,
+		stateCache: state.NewDatabaseWithNodeDB(db, triedb),
+	}, nil
+}
+
+// Config retrieves the chain's fork configuration.
+func (c *ReadOnlyChain) Config() *params.ChainConfig { return c.config }
+
+// DB returns the database the chain is read from.
+func (c *ReadOnlyChain) DB() ethdb.Database { return c.db }
+
+// StateCache returns the state database the states are read from.
+func (c *ReadOnlyChain) StateCache() state.Database { return c.stateCache }
+
+// OpEvents returns no opcode events, the chain never executes any block.
+func (c *ReadOnlyChain) OpEvents() []*vm.PublishEvent { return nil }
+
+// CurrentHeader retrieves the current head header of the canonical chain.
+func (c *ReadOnlyChain) CurrentHeader() *types.Header {
+	hash := rawdb.ReadHeadHeaderHash(c.db)
+	return c.GetHeaderByHash(hash)
+}
+
+// CurrentBlock retrieves the current head block of the canonical chain.
+func (c *ReadOnlyChain) CurrentBlock() *types.Block {
+	hash := rawdb.ReadHeadBlockHash(c.db)
+	return c.GetBlockByHash(hash)
+}
+
+// GetHeader retrieves a block header from the database by hash and number.
+func (c *ReadOnlyChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	return rawdb.ReadHeader(c.db, hash, number)
+}
+
+// GetHeaderByHash retrieves a block header from the database by hash.
+func (c *ReadOnlyChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	number := rawdb.ReadHeaderNumber(c.db, hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadHeader(c.db, hash, *number)
+}
+
+// GetHeaderByNumber retrieves a canonical block header from the database by
+// number.
+func (c *ReadOnlyChain) GetHeaderByNumber(number uint64) *types.Header {
+	hash := rawdb.ReadCanonicalHash(c.db, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadHeader(c.db, hash, number)
+}
+
+// GetTd retrieves a block's total difficulty from the database by hash and
+// number.
+func (c *ReadOnlyChain) GetTd(hash common.Hash, number uint64) *big.Int {
+	return rawdb.ReadTd(c.db, hash, number)
+}
+
+// GetBlock retrieves a block from the database by hash and number.
+func (c *ReadOnlyChain) GetBlock(hash common.Hash, number uint64) *types.Block {
+	return rawdb.ReadBlock(c.db, hash, number)
+}
+
+// GetBlockByHash retrieves a block from the database by hash.
+func (c *ReadOnlyChain) GetBlockByHash(hash common.Hash) *types.Block {
+	number := rawdb.ReadHeaderNumber(c.db, hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadBlock(c.db, hash, *number)
+}
+
+// GetBlockByNumber retrieves a canonical block from the database by number.
+func (c *ReadOnlyChain) GetBlockByNumber(number uint64) *types.Block {
+	hash := rawdb.ReadCanonicalHash(c.db, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadBlock(c.db, hash, number)
+}
+
+// GetReceiptsByHash retrieves the receipts of all the transactions in a block,
+// with their derived fields filled in.
+func (c *ReadOnlyChain) GetReceiptsByHash(hash common.Hash) types.Receipts {
+	number := rawdb.ReadHeaderNumber(c.db, hash)
+	if number == nil {
+		return nil
+	}
+	return rawdb.ReadReceipts(c.db, hash, *number, c.config)
+}
+
+// HasState checks if the state trie of the given root is fully present in the
+// database.
+func (c *ReadOnlyChain) HasState(root common.Hash) bool {
+	_, err := c.stateCache.OpenTrie(root)
+	return err == nil
+}
+
+// StateAt returns a state database for the given root. The state may be
+// modified in memory, but it must never be committed.
+func (c *ReadOnlyChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	return state.New(root, c.stateCache, nil)
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// writeGuardDB is a database failing the test on any write.
+type writeGuardDB struct {
+	ethdb.Database
+	t *testing.T
+}
+
+func (db *writeGuardDB) Put(key []byte, value []byte) error {
+	db.t.Fatalf("unexpected database write: %x", key)
+	return nil
+}
+
+func (db *writeGuardDB) Delete(key []byte) error {
+	db.t.Fatalf("unexpected database delete: %x", key)
+	return nil
+}
+
+func (db *writeGuardDB) NewBatch() ethdb.Batch {
+	db.t.Fatalf("unexpected database batch")
+	return nil
+}
+
+func (db *writeGuardDB) NewBatchWithSize(size int) ethdb.Batch {
+	db.t.Fatalf("unexpected database batch")
+	return nil
+}
+
+// Tests that the read-only chain serves the headers, blocks, receipts and states
+// written by a live chain, without writing to the database.
+func TestReadOnlyChain(t *testing.T) {
+	testReadOnlyChain(t, rawdb.HashScheme)
+	testReadOnlyChain(t, rawdb.PathScheme)
+}
+
+func testReadOnlyChain(t *testing.T, scheme string) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		to     = common.Address{0xaa}
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+		db     = rawdb.NewMemoryDatabase()
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, b *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), to, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+	})
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(scheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	ro, err := OpenReadOnly(&writeGuardDB{Database: db, t: t})
+	if err != nil {
+		t.Fatalf("%s: failed to open read-only chain: %v", scheme, err)
+	}
+	head := blocks[len(blocks)-1]
+	if have := ro.CurrentBlock(); have == nil || have.Hash() != head.Hash() {
+		t.Fatalf("%s: head block mismatch: have %v, want %x", scheme, have, head.Hash())
+	}
+	if have := ro.CurrentHeader(); have == nil || have.Hash() != head.Hash() {
+		t.Fatalf("%s: head header mismatch: have %v, want %x", scheme, have, head.Hash())
+	}
+	for _, block := range blocks {
+		if have := ro.GetBlockByNumber(block.NumberU64()); have == nil || have.Hash() != block.Hash() {
+			t.Errorf("%s: block %d mismatch", scheme, block.NumberU64())
+		}
+		receipts := ro.GetReceiptsByHash(block.Hash())
+		if len(receipts) != 1 || receipts[0].TxHash != block.Transactions()[0].Hash() || receipts[0].BlockNumber.Uint64() != block.NumberU64() {
+			t.Errorf("%s: block %d receipts mismatch", scheme, block.NumberU64())
+		}
+	}
+	if !ro.HasState(head.Root()) {
+		t.Fatalf("%s: head state missing", scheme)
+	}
+	statedb, err := ro.StateAt(head.Root())
+	if err != nil {
+		t.Fatalf("%s: failed to open head state: %v", scheme, err)
+	}
+	if balance := statedb.GetBalance(to); balance.Cmp(big.NewInt(4000)) != 0 {
+		t.Errorf("%s: balance mismatch: have %v, want %v", scheme, balance, 4000)
+	}
+	if _, err := OpenReadOnly(rawdb.NewMemoryDatabase()); err != ErrNoGenesis {
+		t.Errorf("%s: empty database error mismatch: have %v, want %v", scheme, err, ErrNoGenesis)
+	}
+}
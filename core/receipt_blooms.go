@@ -0,0 +1,117 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// upgradeLegacyReceipts rewrites the receipts of a block left in the key-value
+// store in a legacy layout, possibly without their blooms, into the current one
+// whose blooms are regenerated from the logs, once they are read.
+func (bc *BlockChain) upgradeLegacyReceipts(hash common.Hash, number uint64) {
+	if !rawdb.HasLegacyReceipts(bc.db, hash, number) {
+		return
+	}
+	// Check again under the lock, the receipts may be pruned in the meantime
+	bc.receiptsLock.Lock()
+	defer bc.receiptsLock.Unlock()
+
+	if frozen, _ := bc.db.Ancients(); number < frozen {
+		return
+	}
+	if !rawdb.RewriteLegacyReceipts(bc.db, bc.db, hash, number) {
+		return
+	}
+	bc.dropFrozenReceipts(map[uint64]common.Hash{number: hash})
+	log.Debug("Rewrote legacy receipts", "number", number, "hash", hash)
+}
+
+// dropFrozenReceipts deletes the rewritten receipts of the given blocks if they
+// were frozen in the meantime. The freezer wipes the receipts of the blocks it
+// freezes from the key-value store without holding the receipts lock, possibly
+// before a rewrite lands, which would then be left behind for good.
+func (bc *BlockChain) dropFrozenReceipts(blocks map[uint64]common.Hash) {
+	frozen, _ := bc.db.Ancients()
+	for number, hash := range blocks {
+		if number < frozen {
+			rawdb.DeleteReceipts(bc.db, hash, number)
+		}
+	}
+}
+
+// BackfillReceiptBlooms rewrites the receipts of the canonical blocks from
+// (inclusive) to to (inclusive) left in the key-value store in a legacy layout,
+// possibly without their blooms, into the current one whose blooms are
+// regenerated from the logs, instead of waiting for them to be read. The frozen
+// receipts can't be rewritten and are skipped, their blooms being regenerated
+// on every read. It returns the number of blocks whose receipts were rewritten.
+func (bc *BlockChain) BackfillReceiptBlooms(from, to uint64) (uint64, error) {
+	if from > to {
+		return 0, fmt.Errorf("invalid range %d-%d", from, to)
+	}
+	if head := bc.CurrentBlock().NumberU64(); to > head {
+		return 0, fmt.Errorf("range %d-%d is beyond the head %d", from, to, head)
+	}
+	if frozen, _ := bc.db.Ancients(); from < frozen {
+		from = frozen
+	}
+	bc.receiptsLock.Lock()
+	defer bc.receiptsLock.Unlock()
+
+	var (
+		start     = time.Now()
+		batch     = bc.db.NewBatch()
+		rewritten uint64
+		pending   = make(map[uint64]common.Hash) // Blocks rewritten in the batch
+	)
+	for number := from; number <= to; number++ {
+		select {
+		case <-bc.quit:
+			return rewritten, errChainStopped
+		default:
+		}
+		hash := rawdb.ReadCanonicalHash(bc.db, number)
+		if hash == (common.Hash{}) {
+			continue
+		}
+		if rawdb.RewriteLegacyReceipts(bc.db, batch, hash, number) {
+			pending[number] = hash
+			rewritten++
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return rewritten, err
+			}
+			batch.Reset()
+			bc.dropFrozenReceipts(pending)
+			pending = make(map[uint64]common.Hash)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return rewritten, err
+	}
+	bc.dropFrozenReceipts(pending)
+	log.Info("Backfilled receipt blooms", "from", from, "to", to, "rewritten", rewritten, "elapsed", common.PrettyDuration(time.Since(start)))
+	return rewritten, nil
+}
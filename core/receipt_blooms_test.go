@@ -0,0 +1,199 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// v4StoredReceiptRLP is the storage layout of the receipts of database version 4.
+type v4StoredReceiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	TxHash            common.Hash
+	ContractAddress   common.Address
+	Logs              []*types.LogForStorage
+	GasUsed           uint64
+}
+
+// Tests that the receipts left in a legacy layout are rewritten once read, or by
+// the backfill, keeping their blooms.
+func TestBackfillReceiptBlooms(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		// PUSH1 0 PUSH1 0 LOG0 STOP: emits an empty log
+		logcode = common.FromHex("0x60006000a000")
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewContractCreation(b.TxNonce(addr), nil, 100000, b.header.BaseFee, logcode), signer, key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Store the receipts of all the blocks in the legacy layout
+	for _, block := range blocks {
+		var stored []*v4StoredReceiptRLP
+		for _, receipt := range rawdb.ReadRawReceipts(db, block.Hash(), block.NumberU64()) {
+			logs := make([]*types.LogForStorage, len(receipt.Logs))
+			for i, log := range receipt.Logs {
+				logs[i] = (*types.LogForStorage)(log)
+			}
+			stored = append(stored, &v4StoredReceiptRLP{
+				PostStateOrStatus: []byte{0x01},
+				CumulativeGasUsed: receipt.CumulativeGasUsed,
+				Logs:              logs,
+			})
+		}
+		legacy, err := rlp.EncodeToBytes(stored)
+		if err != nil {
+			t.Fatalf("failed to encode legacy receipts: %v", err)
+		}
+		receiptsKey := append(binary.BigEndian.AppendUint64([]byte("r"), block.NumberU64()), block.Hash().Bytes()...)
+		if err := db.Put(receiptsKey, legacy); err != nil {
+			t.Fatalf("failed to store legacy receipts: %v", err)
+		}
+		if !rawdb.HasLegacyReceipts(db, block.Hash(), block.NumberU64()) {
+			t.Fatalf("block %d: legacy receipts not detected", block.NumberU64())
+		}
+	}
+	chain.receiptsCache.Purge()
+
+	// Reading the receipts rewrites them
+	receipts := chain.GetReceiptsByHash(blocks[0].Hash())
+	if len(receipts) != 1 || types.MergeBloom(receipts) != blocks[0].Bloom() {
+		t.Fatalf("bloom mismatch: have %v, want %x", receipts, blocks[0].Bloom())
+	}
+	if rawdb.HasLegacyReceipts(db, blocks[0].Hash(), 1) {
+		t.Fatalf("legacy receipts not rewritten once read")
+	}
+	// The backfill rewrites the others
+	if _, err := chain.BackfillReceiptBlooms(1, 4); err == nil {
+		t.Fatalf("range beyond the head backfilled")
+	}
+	rewritten, err := chain.BackfillReceiptBlooms(0, 3)
+	if err != nil {
+		t.Fatalf("failed to backfill: %v", err)
+	}
+	if rewritten != 2 {
+		t.Fatalf("rewritten count mismatch: have %d, want 2", rewritten)
+	}
+	chain.receiptsCache.Purge()
+	for _, block := range blocks {
+		if rawdb.HasLegacyReceipts(db, block.Hash(), block.NumberU64()) {
+			t.Fatalf("block %d: legacy receipts not rewritten", block.NumberU64())
+		}
+		if receipts := chain.GetReceiptsByHash(block.Hash()); types.MergeBloom(receipts) != block.Bloom() {
+			t.Fatalf("block %d: bloom mismatch", block.NumberU64())
+		}
+	}
+}
+
+// freezingDatabase is a database whose blocks are frozen, from the point of view
+// of the chain, once the given number of ancient queries are answered.
+type freezingDatabase struct {
+	ethdb.Database
+	queries int
+	frozen  uint64
+}
+
+func (db *freezingDatabase) Ancients() (uint64, error) {
+	if db.queries > 0 {
+		db.queries--
+		return 0, nil
+	}
+	return db.frozen, nil
+}
+
+// Tests that the legacy receipts of the frozen blocks are not rewritten, nor left
+// behind in the key-value store by a rewrite racing with the freezer.
+func TestUpgradeLegacyReceiptsFrozen(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 1, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	db := &freezingDatabase{Database: rawdb.NewMemoryDatabase()}
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := blocks[0]
+	receiptsKey := append(binary.BigEndian.AppendUint64([]byte("r"), block.NumberU64()), block.Hash().Bytes()...)
+
+	var stored []*v4StoredReceiptRLP
+	for _, receipt := range rawdb.ReadRawReceipts(db, block.Hash(), block.NumberU64()) {
+		stored = append(stored, &v4StoredReceiptRLP{PostStateOrStatus: []byte{0x01}, CumulativeGasUsed: receipt.CumulativeGasUsed})
+	}
+	legacy, err := rlp.EncodeToBytes(stored)
+	if err != nil {
+		t.Fatalf("failed to encode legacy receipts: %v", err)
+	}
+	// The receipts of a frozen block are left alone
+	db.Put(receiptsKey, legacy)
+	db.frozen = block.NumberU64() + 1
+	chain.upgradeLegacyReceipts(block.Hash(), block.NumberU64())
+	if !rawdb.HasLegacyReceipts(db, block.Hash(), block.NumberU64()) {
+		t.Fatalf("frozen receipts rewritten")
+	}
+	// The receipts of a block frozen during the rewrite are dropped
+	db.queries = 1
+	chain.upgradeLegacyReceipts(block.Hash(), block.NumberU64())
+	if ok, _ := db.Has(receiptsKey); ok {
+		t.Fatalf("receipts of a block frozen during the rewrite left behind")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// backfillReceiptIndex writes the receipt lookups of the canonical blocks
+// preceding the enabling of the receipt index, newest first, down to the tail
+// of the transaction index as the transactions below it can't be looked up
+// anyway. The progress is persisted for an interrupted backfill to resume, the
+// lookups below the tail being removed along with the transaction lookups.
+func (bc *BlockChain) backfillReceiptIndex() {
+	defer bc.wg.Done()
+
+	next := bc.CurrentBlock().NumberU64() + 1
+	if tail := rawdb.ReadReceiptIndexTail(bc.db); tail != nil && *tail < next {
+		next = *tail
+	}
+	floor := bc.HistoryTail()
+	if tail := rawdb.ReadTxIndexTail(bc.db); tail != nil && *tail > floor {
+		floor = *tail
+	}
+	if next <= floor {
+		return
+	}
+	var (
+		start  = time.Now()
+		logged = start
+		batch  = bc.db.NewBatch()
+		blocks int
+	)
+	for next > floor {
+		select {
+		case <-bc.quit:
+			rawdb.WriteReceiptIndexTail(batch, next)
+			if err := batch.Write(); err != nil {
+				log.Error("Failed to write receipt index", "err", err)
+			}
+			log.Info("Receipt index backfill interrupted", "tail", next, "elapsed", common.PrettyDuration(time.Since(start)))
+			return
+		default:
+		}
+		number := next - 1
+		if hash := rawdb.ReadCanonicalHash(bc.db, number); hash != (common.Hash{}) {
+			if block := rawdb.ReadBlock(bc.db, hash, number); block != nil && len(block.Transactions()) > 0 {
+				rawdb.WriteReceiptLookupEntries(batch, block, rawdb.ReadRawReceipts(bc.db, hash, number))
+			}
+		}
+		next, blocks = number, blocks+1
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			rawdb.WriteReceiptIndexTail(batch, next)
+			if err := batch.Write(); err != nil {
+				log.Error("Failed to write receipt index", "err", err)
+				return
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Backfilling receipt index", "blocks", blocks, "tail", next, "floor", floor, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	rawdb.WriteReceiptIndexTail(batch, next)
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to write receipt index", "err", err)
+		return
+	}
+	log.Info("Backfilled receipt index", "blocks", blocks, "tail", next, "elapsed", common.PrettyDuration(time.Since(start)))
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxStatePins is the maximum number of distinct states which can be pinned
+// at the same time, protecting the trie cache from unbounded growth.
+const maxStatePins = 64
+
+var (
+	// ErrStateUnavailable is returned if the state of a block was already
+	// garbage collected or was never processed.
+	ErrStateUnavailable = errors.New("state not available")
+
+	// ErrTooManyStatePins is returned if a new state is pinned while the
+	// maximum number of pinned states is reached.
+	ErrTooManyStatePins = errors.New("too many pinned states")
+
+	// ErrUnknownStatePin is returned if a state is unpinned without having
+	// been pinned before.
+	ErrUnknownStatePin = errors.New("state is not pinned")
+)
+
+// recentState is the state root of a canonical block kept referenced.
+type recentState struct {
+	number uint64
+	root   common.Hash
+}
+
+// recentStates tracks the references held on the state of the recent canonical
+// blocks and on the explicitly pinned states, independently of the garbage
+// collection of the tries kept in memory.
+type recentStates struct {
+	recent []recentState       // States of the recent canonical blocks, oldest first
+	pins   map[common.Hash]int // Number of pins held on each explicitly pinned state
+	lock   sync.Mutex
+}
+
+// retainRecentState references the state of a newly written canonical block,
+// releasing the ones which fell out of the configured window of recent blocks.
+//
+// Note, the references only work with the hash based trie database. With the
+// path based one, the recent states are served by the in-memory diff layers.
+func (bc *BlockChain) retainRecentState(number uint64, root common.Hash) {
+	bc.recentStates.lock.Lock()
+	defer bc.recentStates.lock.Unlock()
+
+	bc.triedb.Reference(root, common.Hash{})
+	bc.recentStates.recent = append(bc.recentStates.recent, recentState{number: number, root: root})
+
+	// Release the states beyond the window, as well as the ones of blocks
+	// reorged out at or above the new head
+	limit := bc.cacheConfig.RecentStates
+	kept := bc.recentStates.recent[:0]
+	for i, entry := range bc.recentStates.recent {
+		last := i == len(bc.recentStates.recent)-1
+		if !last && (entry.number+limit <= number || entry.number >= number) {
+			bc.triedb.Dereference(entry.root)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	bc.recentStates.recent = kept
+}
+
+// StateAtNumber returns a new mutable state based on the canonical block with
+// the given number. The state of the last RecentStates blocks is guaranteed to
+// be available, older ones only if they are pinned or were not garbage
+// collected yet.
+func (bc *BlockChain) StateAtNumber(number uint64) (*state.StateDB, error) {
+	header := bc.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	if !bc.HasState(header.Root) {
+		return nil, fmt.Errorf("%w: block #%d", ErrStateUnavailable, number)
+	}
+	return bc.StateAt(header.Root)
+}
+
+// PinStateAtNumber keeps the state of the canonical block with the given number
+// available until it is released with UnpinState, allowing long running queries
+// to work on a stable state. The same state may be pinned multiple times, each
+// pin needing a separate release.
+func (bc *BlockChain) PinStateAtNumber(number uint64) (*types.Header, error) {
+	header := bc.GetHeaderByNumber(number)
+	if header == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	bc.recentStates.lock.Lock()
+	defer bc.recentStates.lock.Unlock()
+
+	if bc.recentStates.pins[header.Root] == 0 && len(bc.recentStates.pins) >= maxStatePins {
+		return nil, ErrTooManyStatePins
+	}
+	if !bc.HasState(header.Root) {
+		return nil, fmt.Errorf("%w: block #%d", ErrStateUnavailable, number)
+	}
+	if err := bc.triedb.Reference(header.Root, common.Hash{}); err != nil {
+		return nil, err
+	}
+	bc.recentStates.pins[header.Root]++
+
+	log.Debug("Pinned block state", "number", number, "hash", header.Hash(), "root", header.Root, "pins", bc.recentStates.pins[header.Root])
+	return header, nil
+}
+
+// UnpinState releases a pin held on a state via PinStateAtNumber.
+func (bc *BlockChain) UnpinState(root common.Hash) error {
+	bc.recentStates.lock.Lock()
+	defer bc.recentStates.lock.Unlock()
+
+	if bc.recentStates.pins[root] == 0 {
+		return ErrUnknownStatePin
+	}
+	bc.triedb.Dereference(root)
+	if bc.recentStates.pins[root]--; bc.recentStates.pins[root] == 0 {
+		delete(bc.recentStates.pins, root)
+	}
+	log.Debug("Unpinned block state", "root", root)
+	return nil
+}
+
+// releaseRecentStates drops all the references held on the recent and on the
+// pinned states.
+func (bc *BlockChain) releaseRecentStates() {
+	bc.recentStates.lock.Lock()
+	defer bc.recentStates.lock.Unlock()
+
+	for _, entry := range bc.recentStates.recent {
+		bc.triedb.Dereference(entry.root)
+	}
+	for root, pins := range bc.recentStates.pins {
+		for i := 0; i < pins; i++ {
+			bc.triedb.Dereference(root)
+		}
+	}
+	bc.recentStates.recent = nil
+	bc.recentStates.pins = make(map[common.Hash]int)
+}
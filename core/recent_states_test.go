@@ -0,0 +1,84 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the state of the recent blocks is kept beyond the in-memory tries,
+// and that pinned states survive until they are released.
+func TestRecentStates(t *testing.T) {
+	var (
+		genDb   = rawdb.NewMemoryDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), genDb, 20, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{byte(i + 1)})
+	}, true)
+
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.TriesInMemory = 2
+	cacheConfig.RecentStates = 8
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:10], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	pinned, err := chain.PinStateAtNumber(3)
+	if err != nil {
+		t.Fatalf("failed to pin state: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks[10:], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for number := uint64(13); number <= 20; number++ {
+		if _, err := chain.StateAtNumber(number); err != nil {
+			t.Errorf("block #%d: recent state unavailable: %v", number, err)
+		}
+	}
+	if _, err := chain.StateAtNumber(12); !errors.Is(err, ErrStateUnavailable) {
+		t.Errorf("stale state error mismatch: have %v, want %v", err, ErrStateUnavailable)
+	}
+	if _, err := chain.StateAtNumber(3); err != nil {
+		t.Errorf("pinned state unavailable: %v", err)
+	}
+	if err := chain.UnpinState(pinned.Root); err != nil {
+		t.Fatalf("failed to unpin state: %v", err)
+	}
+	if _, err := chain.StateAtNumber(3); !errors.Is(err, ErrStateUnavailable) {
+		t.Errorf("unpinned state error mismatch: have %v, want %v", err, ErrStateUnavailable)
+	}
+	if err := chain.UnpinState(pinned.Root); !errors.Is(err, ErrUnknownStatePin) {
+		t.Errorf("double unpin error mismatch: have %v, want %v", err, ErrUnknownStatePin)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
+)
+
+// errReplaySystemTx is returned if a system transaction is requested to be
+// replayed. System transactions are applied by the consensus engine during
+// block finalisation and can't be executed in isolation.
+var errReplaySystemTx = errors.New("system transactions can't be replayed")
+
+// ReplayOptions are the optional settings of a transaction replay.
+type ReplayOptions struct {
+	Tracer         *hooks.Hooks // Tracer to attach to the replayed transaction (nil = none)
+	DisableCode    bool         // Whether to omit the contract code from the account snapshots
+	DisableStorage bool         // Whether to omit the storage slots from the account snapshots
+}
+
+// AccountSnapshot is the state of a single account before or after a replayed
+// transaction. Only the storage slots written by the transaction are included.
+type AccountSnapshot struct {
+	Exists   bool
+	Balance  *big.Int
+	Nonce    uint64
+	CodeHash common.Hash
+	Code     []byte                      // Omitted if ReplayOptions.DisableCode is set
+	Storage  map[common.Hash]common.Hash // Omitted if ReplayOptions.DisableStorage is set
+}
+
+// ReplayResult is the outcome of replaying a single transaction.
+type ReplayResult struct {
+	Result *ExecutionResult
+	Logs   []*types.Log
+
+	Pre  map[common.Address]*AccountSnapshot // State of the touched accounts before the transaction
+	Post map[common.Address]*AccountSnapshot // State of the touched accounts after the transaction
+}
+
+// ReplayTransaction re-executes the transaction at txIndex within the given
+// block on top of the exact state it was originally executed on, returning the
+// state of all the accounts it touched before and after its execution.
+//
+// The state of the block's parent must be available.
+func (bc *BlockChain) ReplayTransaction(blockHash common.Hash, txIndex int, opts *ReplayOptions) (*ReplayResult, error) {
+	if opts == nil {
+		opts = new(ReplayOptions)
+	}
+	block := bc.GetBlockByHash(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %#x not found", blockHash)
+	}
+	txs := block.Transactions()
+	if txIndex < 0 || txIndex >= len(txs) {
+		return nil, fmt.Errorf("transaction index %d out of range for block %#x", txIndex, blockHash)
+	}
+	if posa, ok := bc.engine.(consensus.PoSA); ok {
+		isSystemTx, err := posa.IsSystemTransaction(txs[txIndex], block.Header())
+		if err != nil {
+			return nil, err
+		}
+		if isSystemTx {
+			return nil, errReplaySystemTx
+		}
+	}
+	parent := bc.GetHeader(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil, fmt.Errorf("parent %#x not found", block.ParentHash())
+	}
+	statedb, err := bc.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		config  = bc.chainConfig
+		header  = block.Header()
+		signer  = types.MakeSigner(config, header.Number)
+		gp      = new(GasPool).AddGas(block.GasLimit())
+		usedGas = new(uint64)
+	)
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	// Execute all the preceding transactions, mirroring the state processor.
+	// System transactions are always at the end of the block, so they never
+	// precede the replayed one.
+	blockContext := NewEVMBlockContext(header, bc, nil)
+	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, config, vm.Config{})
+	if evmHook := bc.GetHook(); evmHook != nil {
+		vmenv.SetHook(evmHook)
+	}
+	for i, tx := range txs[:txIndex] {
+		if err := bc.replayApply(vmenv, statedb, block, tx, i, signer, gp, usedGas); err != nil {
+			return nil, err
+		}
+	}
+	// Snapshot the state before the replayed transaction and execute it
+	pre := statedb.Copy()
+
+	vmenv.Config = vm.Config{Tracer: opts.Tracer, Debug: opts.Tracer != nil}
+	tx := txs[txIndex]
+	statedb.SetTxContext(tx.Hash(), txIndex)
+
+	msg, err := tx.AsMessage(signer, header.BaseFee)
+	if err != nil {
+		return nil, fmt.Errorf("could not replay tx %d [%v]: %w", txIndex, tx.Hash().Hex(), err)
+	}
+	vmenv.Context.CurrentTransaction = tx
+	vmenv.Context.Counter = 0
+
+	vmenv.Reset(NewEVMTxContext(msg), statedb)
+	if err := checkBlacklist(msg, config, statedb, block.Number()); err != nil {
+		return nil, fmt.Errorf("could not replay tx %d [%v]: %w", txIndex, tx.Hash().Hex(), err)
+	}
+	result, err := ApplyMessage(vmenv, msg, gp)
+	if err != nil {
+		return nil, fmt.Errorf("could not replay tx %d [%v]: %w", txIndex, tx.Hash().Hex(), err)
+	}
+	// Gather the mutations before they're flattened by the finalisation
+	mutations := statedb.Mutations()
+	statedb.Finalise(config.IsEIP158(block.Number()))
+
+	res := &ReplayResult{
+		Result: result,
+		Logs:   statedb.GetLogs(tx.Hash(), blockHash),
+		Pre:    make(map[common.Address]*AccountSnapshot, len(mutations)),
+		Post:   make(map[common.Address]*AccountSnapshot, len(mutations)),
+	}
+	for addr, slots := range mutations {
+		res.Pre[addr] = snapshotAccount(pre, addr, slots, opts)
+		res.Post[addr] = snapshotAccount(statedb, addr, slots, opts)
+	}
+	return res, nil
+}
+
+// replayApply executes a transaction preceding the replayed one on top of the
+// given state.
+func (bc *BlockChain) replayApply(vmenv *vm.EVM, statedb *state.StateDB, block *types.Block, tx *types.Transaction, index int, signer types.Signer, gp *GasPool, usedGas *uint64) error {
+	msg, err := tx.AsMessage(signer, block.BaseFee())
+	if err != nil {
+		return fmt.Errorf("could not apply tx %d [%v]: %w", index, tx.Hash().Hex(), err)
+	}
+	vmenv.Context.CurrentTransaction = tx
+	vmenv.Context.Counter = 0
+
+	statedb.SetTxContext(tx.Hash(), index)
+	if _, _, err := applyTransaction(msg, bc.chainConfig, bc, nil, gp, statedb, block.Number(), block.Hash(), tx, usedGas, vmenv, NewReceiptBloomGenerator()); err != nil {
+		return fmt.Errorf("could not apply tx %d [%v]: %w", index, tx.Hash().Hex(), err)
+	}
+	return nil
+}
+
+// snapshotAccount captures the state of an account, including the given slots.
+func snapshotAccount(statedb *state.StateDB, addr common.Address, slots []common.Hash, opts *ReplayOptions) *AccountSnapshot {
+	snap := &AccountSnapshot{
+		Exists:   statedb.Exist(addr),
+		Balance:  statedb.GetBalance(addr),
+		Nonce:    statedb.GetNonce(addr),
+		CodeHash: statedb.GetCodeHash(addr),
+	}
+	if !opts.DisableCode {
+		snap.Code = statedb.GetCode(addr)
+	}
+	if !opts.DisableStorage && len(slots) > 0 {
+		snap.Storage = make(map[common.Hash]common.Hash, len(slots))
+		for _, slot := range slots {
+			snap.Storage[slot] = statedb.GetState(addr, slot)
+		}
+	}
+	return snap
+}
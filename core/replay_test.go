@@ -0,0 +1,117 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that replaying a transaction reproduces the state it was executed on
+// and captures the touched accounts before and after its execution.
+func TestReplayTransaction(t *testing.T) {
+	var (
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key.PublicKey)
+		db      = rawdb.NewMemoryDatabase()
+		funds   = big.NewInt(params.Ether)
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{addr: {Balance: funds}}, BaseFee: big.NewInt(params.InitialBaseFee)}
+		genesis = gspec.MustCommit(db, trie.NewDatabase(db, nil))
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	// Deploy a contract storing 1 in slot 0 ahead of two plain transfers
+	// PUSH1 1 PUSH1 0 SSTORE
+	code := []byte{byte(vm.PUSH1), 0x01, byte(vm.PUSH1), 0x00, byte(vm.SSTORE)}
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 1, func(i int, b *BlockGen) {
+		b.SetCoinbase(common.Address{0xc0})
+		for nonce, to := range []common.Address{{0x01}, {0x02}} {
+			tx, _ := types.SignTx(types.NewTransaction(uint64(nonce), to, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), signer, key)
+			b.AddTx(tx)
+		}
+		tx, _ := types.SignTx(types.NewContractCreation(2, nil, 100000, b.header.BaseFee, code), signer, key)
+		b.AddTx(tx)
+	}, true)
+
+	chain, err := NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := blocks[0]
+	receipts := chain.GetReceiptsByHash(block.Hash())
+
+	// Replay the second transfer, the sender must already have paid for the first
+	res, err := chain.ReplayTransaction(block.Hash(), 1, nil)
+	if err != nil {
+		t.Fatalf("failed to replay transaction: %v", err)
+	}
+	if res.Result.UsedGas != receipts[1].GasUsed {
+		t.Errorf("gas used mismatch: have %d, want %d", res.Result.UsedGas, receipts[1].GasUsed)
+	}
+	for _, want := range []common.Address{addr, {0x02}, {0xc0}} {
+		if res.Pre[want] == nil || res.Post[want] == nil {
+			t.Fatalf("touched account %x missing from snapshots", want)
+		}
+	}
+	if pre := res.Pre[addr]; pre.Nonce != 1 {
+		t.Errorf("sender pre nonce mismatch: have %d, want 1", pre.Nonce)
+	}
+	if post := res.Post[addr]; post.Nonce != 2 {
+		t.Errorf("sender post nonce mismatch: have %d, want 2", post.Nonce)
+	}
+	if pre := res.Pre[common.Address{0x02}]; pre.Exists {
+		t.Errorf("recipient existed before the transfer")
+	}
+	if post := res.Post[common.Address{0x02}]; !post.Exists || post.Balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("recipient post balance mismatch: have %v, want 1000", post.Balance)
+	}
+	if _, ok := res.Pre[common.Address{0x01}]; ok {
+		t.Errorf("untouched account captured")
+	}
+	// Replay the contract creation and ensure the storage write is captured
+	res, err = chain.ReplayTransaction(block.Hash(), 2, nil)
+	if err != nil {
+		t.Fatalf("failed to replay contract creation: %v", err)
+	}
+	contract := receipts[2].ContractAddress
+	post := res.Post[contract]
+	if post == nil {
+		t.Fatalf("created contract missing from snapshots")
+	}
+	if have := post.Storage[common.Hash{}]; have != common.BytesToHash([]byte{0x01}) {
+		t.Errorf("contract storage mismatch: have %x, want 1", have)
+	}
+	if have := res.Pre[contract].Storage[common.Hash{}]; have != (common.Hash{}) {
+		t.Errorf("contract pre storage mismatch: have %x, want 0", have)
+	}
+	if _, err := chain.ReplayTransaction(block.Hash(), 3, nil); err == nil {
+		t.Errorf("out of range replay succeeded")
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
+)
+
+// ConvertStateScheme converts the persisted state of the database into the given
+// scheme offline, so that a node can switch schemes without resyncing. The
+// converted state is rebuilt and checked against its root before the database
+// is switched over. On the next start, the chain is rewound to the block of the
+// converted state if the head is ahead of it.
+//
+// Converting into the path scheme leaves the hash-based nodes in place. Converting
+// back removes the path-based nodes, the state history is reset by the path
+// database once it is opened again.
+func ConvertStateScheme(db ethdb.Database, scheme string) (*SchemeMigrationStatus, error) {
+	current := rawdb.ReadStateScheme(db)
+	switch {
+	case scheme != rawdb.HashScheme && scheme != rawdb.PathScheme:
+		return nil, fmt.Errorf("unknown state scheme %q", scheme)
+	case current == "":
+		return nil, errors.New("no state in database")
+	case current == scheme:
+		return nil, fmt.Errorf("state is already stored in the %s scheme", scheme)
+	}
+	// Find the block of the persisted state to convert
+	var persisted func(root common.Hash) bool
+	if current == rawdb.HashScheme {
+		persisted = func(root common.Hash) bool { return rawdb.HasLegacyTrieNode(db, root) }
+	} else {
+		_, diskRoot := rawdb.ReadAccountTrieNode(db, nil)
+		if diskRoot == (common.Hash{}) {
+			return nil, errors.New("missing persisted path-based state")
+		}
+		persisted = func(root common.Hash) bool { return root == diskRoot }
+	}
+	header := findPersistedState(db, persisted)
+	if header == nil {
+		return nil, errors.New("no block with persisted state")
+	}
+	progress := &schemeMigrationProgress{Root: header.Root, Number: header.Number.Uint64()}
+	log.Info("Converting state scheme", "from", current, "to", scheme, "number", progress.Number, "root", progress.Root)
+
+	if err := convertState(db, progress, current, scheme); err != nil {
+		return nil, err
+	}
+	if err := verifyState(db, progress.Root, scheme); err != nil {
+		// Drop the converted root, the database stays in the old scheme
+		if scheme == rawdb.PathScheme {
+			rawdb.DeleteAccountTrieNode(db, nil)
+		}
+		return nil, fmt.Errorf("converted state verification failed: %v", err)
+	}
+	if scheme == rawdb.HashScheme {
+		if err := deletePathState(db); err != nil {
+			return nil, err
+		}
+	} else {
+		rawdb.DeleteSchemeMigration(db)
+	}
+	log.Info("Converted state scheme", "scheme", scheme, "number", progress.Number, "root", progress.Root,
+		"accounts", progress.Accounts, "slots", progress.Slots, "nodes", progress.Nodes)
+	return progress.status(false), nil
+}
+
+// findPersistedState returns the header of the most recent canonical block whose
+// state is persisted according to the given check.
+func findPersistedState(db ethdb.Database, persisted func(root common.Hash) bool) *types.Header {
+	number := rawdb.ReadHeaderNumber(db, rawdb.ReadHeadBlockHash(db))
+	if number == nil {
+		return nil
+	}
+	for n := *number; ; n-- {
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, n), n)
+		if header != nil && persisted(header.Root) {
+			return header
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+// schemeConfig returns the read-only trie database configuration of a scheme.
+func schemeConfig(scheme string) *trie.Config {
+	if scheme == rawdb.PathScheme {
+		return &trie.Config{PathDB: pathdb.ReadOnly}
+	}
+	return trie.HashDefaults
+}
+
+// convertState copies the trie nodes of the state into the layout of the target
+// scheme. The path-based root node is written last, its presence switches the
+// database to the path scheme.
+func convertState(db ethdb.Database, progress *schemeMigrationProgress, from, to string) error {
+	triedb := trie.NewDatabase(db, schemeConfig(from))
+	defer triedb.Close()
+
+	tr, err := trie.New(trie.StateTrieID(progress.Root), triedb)
+	if err != nil {
+		return err
+	}
+	it, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	var (
+		batch  = db.NewBatch()
+		logged = time.Now()
+		flush  = func() error {
+			if batch.ValueSize() < ethdb.IdealBatchSize {
+				return nil
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			return nil
+		}
+	)
+	for it.Next(true) {
+		if !it.Leaf() {
+			// Embedded nodes are stored in their parent
+			if it.Hash() != (common.Hash{}) && (len(it.Path()) > 0 || to == rawdb.HashScheme) {
+				rawdb.WriteTrieNode(batch, common.Hash{}, it.Path(), it.Hash(), it.NodeBlob(), to)
+				progress.Nodes++
+			}
+			continue
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return err
+		}
+		accountHash := common.BytesToHash(it.LeafKey())
+		if account.Root != types.EmptyRootHash {
+			st, err := trie.New(trie.StorageTrieID(progress.Root, accountHash, account.Root), triedb)
+			if err != nil {
+				return err
+			}
+			sit, err := st.NodeIterator(nil)
+			if err != nil {
+				return err
+			}
+			for sit.Next(true) {
+				if sit.Leaf() {
+					progress.Slots++
+				} else if sit.Hash() != (common.Hash{}) {
+					rawdb.WriteTrieNode(batch, accountHash, sit.Path(), sit.Hash(), sit.NodeBlob(), to)
+					progress.Nodes++
+				}
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if sit.Error() != nil {
+				return sit.Error()
+			}
+		}
+		progress.Accounts++
+		progress.Marker = common.CopyBytes(accountHash[:])
+
+		if err := flush(); err != nil {
+			return err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Converting state scheme", "accounts", progress.Accounts, "slots", progress.Slots,
+				"nodes", progress.Nodes, "marker", hexutil.Bytes(progress.Marker))
+			logged = time.Now()
+		}
+	}
+	if it.Error() != nil {
+		return it.Error()
+	}
+	if to == rawdb.PathScheme {
+		blob := rawdb.ReadLegacyTrieNode(db, progress.Root)
+		if len(blob) == 0 {
+			return fmt.Errorf("missing state root %x", progress.Root)
+		}
+		rawdb.WriteAccountTrieNode(batch, nil, blob)
+		progress.Nodes++
+	}
+	return batch.Write()
+}
+
+// verifyState rebuilds the state stored in the given scheme from its leaves and
+// checks that it hashes to the given root, storage tries included.
+func verifyState(db ethdb.Database, root common.Hash, scheme string) error {
+	triedb := trie.NewDatabase(db, schemeConfig(scheme))
+	defer triedb.Close()
+
+	rebuild := func(id *trie.ID, onLeaf func(key, value []byte) error) error {
+		tr, err := trie.New(id, triedb)
+		if err != nil {
+			return err
+		}
+		nodeIt, err := tr.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		var (
+			it    = trie.NewIterator(nodeIt)
+			stack = trie.NewStackTrie(nil)
+		)
+		for it.Next() {
+			if err := stack.TryUpdate(it.Key, it.Value); err != nil {
+				return err
+			}
+			if onLeaf != nil {
+				if err := onLeaf(it.Key, it.Value); err != nil {
+					return err
+				}
+			}
+		}
+		if it.Err != nil {
+			return it.Err
+		}
+		if hash := stack.Hash(); hash != id.Root {
+			return fmt.Errorf("root mismatch for owner %x: have %x, want %x", id.Owner, hash, id.Root)
+		}
+		return nil
+	}
+	return rebuild(trie.StateTrieID(root), func(key, value []byte) error {
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return err
+		}
+		if account.Root == types.EmptyRootHash {
+			return nil
+		}
+		return rebuild(trie.StorageTrieID(root, common.BytesToHash(key), account.Root), nil)
+	})
+}
+
+// deletePathState removes the path-based state from the database. The root node
+// and the persistent state id are deleted first, switching the database to the
+// hash scheme.
+func deletePathState(db ethdb.Database) error {
+	batch := db.NewBatch()
+	rawdb.DeleteAccountTrieNode(batch, nil)
+	rawdb.WritePersistentStateID(batch, 0)
+	rawdb.DeleteTrieJournal(batch)
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	batch.Reset()
+
+	for _, prefix := range [][]byte{rawdb.TrieNodeAccountPrefix, rawdb.TrieNodeStoragePrefix} {
+		it := db.NewIterator(prefix, nil)
+		for it.Next() {
+			key := it.Key()
+			if !rawdb.IsAccountTrieNode(key) && !rawdb.IsStorageTrieNode(key) {
+				continue
+			}
+			if err := batch.Delete(key); err != nil {
+				it.Release()
+				return err
+			}
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					it.Release()
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		err := it.Error()
+		it.Release()
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the state is converted offline from the hash scheme into the path
+// scheme and back, and that the chain resumes from the converted state.
+func TestConvertStateScheme(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		storage = make(map[common.Hash]common.Hash)
+		alloc   = GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}}
+	)
+	for i := 0; i < 64; i++ {
+		storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+	for i := 0; i < 16; i++ {
+		alloc[common.BigToAddress(big.NewInt(int64(0x1000+i)))] = GenesisAccount{Balance: big.NewInt(1), Code: []byte{0x00}, Storage: storage}
+	}
+	gspec := &Genesis{Config: params.TestChainConfig, Alloc: alloc, BaseFee: big.NewInt(params.InitialBaseFee)}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 6, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), types.HomesteadSigner{}, key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+
+	openChain := func(scheme string) *BlockChain {
+		config := DefaultCacheConfigWithScheme(scheme)
+		config.SnapshotLimit = 0
+		chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to open %s chain: %v", scheme, err)
+		}
+		return chain
+	}
+	checkState := func(scheme string, root common.Hash, nonce uint64) {
+		statedb, err := state.New(root, state.NewDatabaseWithConfig(db, newDbConfig(scheme)), nil)
+		if err != nil {
+			t.Fatalf("failed to open %s state: %v", scheme, err)
+		}
+		for addr, account := range alloc {
+			if addr == sender {
+				continue
+			}
+			if balance := statedb.GetBalance(addr); balance.Cmp(account.Balance) != 0 {
+				t.Fatalf("balance mismatch for %x: have %v, want %v", addr, balance, account.Balance)
+			}
+			for slot, value := range account.Storage {
+				if have := statedb.GetState(addr, slot); have != value {
+					t.Fatalf("storage mismatch for %x %x: have %x, want %x", addr, slot, have, value)
+				}
+			}
+		}
+		if have := statedb.GetNonce(sender); have != nonce {
+			t.Fatalf("nonce mismatch: have %d, want %d", have, nonce)
+		}
+	}
+	chain := openChain(rawdb.HashScheme)
+	if _, err := chain.InsertChain(blocks[:4], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	if _, err := ConvertStateScheme(db, rawdb.HashScheme); err == nil {
+		t.Fatalf("converted state into its own scheme")
+	}
+	status, err := ConvertStateScheme(db, rawdb.PathScheme)
+	if err != nil {
+		t.Fatalf("failed to convert into path scheme: %v", err)
+	}
+	if status.Number != 4 || status.Root != blocks[3].Root() {
+		t.Fatalf("converted state mismatch: have %d %x, want 4 %x", status.Number, status.Root, blocks[3].Root())
+	}
+	if status.Accounts < uint64(len(alloc)+4) || status.Slots != uint64(16*len(storage)) {
+		t.Fatalf("converted stats mismatch: have %d accounts %d slots", status.Accounts, status.Slots)
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.PathScheme {
+		t.Fatalf("scheme mismatch: have %s, want %s", scheme, rawdb.PathScheme)
+	}
+	// Continue the chain in the path scheme, the disk layer stays at the
+	// converted state while the new blocks are journalled.
+	chain = openChain(rawdb.PathScheme)
+	if chain.CurrentBlock().NumberU64() != 4 {
+		t.Fatalf("head mismatch: have %d, want 4", chain.CurrentBlock().NumberU64())
+	}
+	checkState(rawdb.PathScheme, blocks[3].Root(), 4)
+	if _, err := chain.InsertChain(blocks[4:], nil); err != nil {
+		t.Fatalf("failed to extend chain: %v", err)
+	}
+	chain.Stop()
+
+	// Convert back, the chain is rewound to the converted state
+	if status, err = ConvertStateScheme(db, rawdb.HashScheme); err != nil {
+		t.Fatalf("failed to convert into hash scheme: %v", err)
+	}
+	if status.Number != 4 {
+		t.Fatalf("converted block mismatch: have %d, want 4", status.Number)
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+		t.Fatalf("scheme mismatch: have %s, want %s", scheme, rawdb.HashScheme)
+	}
+	it := db.NewIterator(rawdb.TrieNodeAccountPrefix, nil)
+	for it.Next() {
+		if rawdb.IsAccountTrieNode(it.Key()) {
+			t.Fatalf("path-based node left: %x", it.Key())
+		}
+	}
+	it.Release()
+
+	chain = openChain(rawdb.HashScheme)
+	defer chain.Stop()
+
+	if chain.CurrentBlock().NumberU64() != 4 {
+		t.Fatalf("head mismatch: have %d, want 4", chain.CurrentBlock().NumberU64())
+	}
+	checkState(rawdb.HashScheme, blocks[3].Root(), 4)
+	if _, err := chain.InsertChain(blocks[4:], nil); err != nil {
+		t.Fatalf("failed to reimport chain: %v", err)
+	}
+}
@@ -0,0 +1,467 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	errMigrationRunning   = errors.New("scheme migration already running")
+	errMigrationNotNeeded = errors.New("state is not stored in the hash scheme")
+	errMigrationAborted   = errors.New("scheme migration aborted")
+	errSchemePinned       = errors.New("state scheme is explicitly configured")
+)
+
+// schemeSwitchBacklog is the number of blocks left to execute on top of the
+// migrated state above which the catch-up continues without holding the chain.
+const schemeSwitchBacklog = 16
+
+// SchemeMigrationStatus is the progress of the online migration of the
+// hash-based state into the path-based layout.
+type SchemeMigrationStatus struct {
+	Root     common.Hash   `json:"root"`     // State root being migrated
+	Number   uint64        `json:"number"`   // Number of the block the state belongs to
+	Snapshot bool          `json:"snapshot"` // Whether the flat snapshot entries are populated too
+	Marker   hexutil.Bytes `json:"marker"`   // Hash of the last migrated account (nil = none yet)
+	Accounts uint64        `json:"accounts"` // Number of accounts migrated
+	Slots    uint64        `json:"slots"`    // Number of storage slots migrated
+	Nodes    uint64        `json:"nodes"`    // Number of trie nodes migrated
+}
+
+// schemeMigrationProgress is the persisted progress of a scheme migration.
+type schemeMigrationProgress struct {
+	Root     common.Hash
+	Number   uint64
+	Marker   []byte
+	Accounts uint64
+	Slots    uint64
+	Nodes    uint64
+}
+
+// StartSchemeMigration starts converting the hash-based state of the current
+// head block into the path-based layout in the background, or resumes the
+// interrupted migration. The node keeps running in the hash scheme meanwhile;
+// once the migration completes, the chain switches over to the path scheme in
+// place and the legacy hash-based nodes are deleted. If interrupted before the
+// switch, the next restart rewinds the chain to the migrated block instead.
+func (bc *BlockChain) StartSchemeMigration() error {
+	if bc.triedb.Scheme() != rawdb.HashScheme || rawdb.ReadStateScheme(bc.db) != rawdb.HashScheme {
+		return errMigrationNotNeeded
+	}
+	if bc.cacheConfig.StateSchemePinned {
+		return errSchemePinned
+	}
+	bc.migrationLock.Lock()
+	defer bc.migrationLock.Unlock()
+
+	if bc.migration != nil {
+		return errMigrationRunning
+	}
+	var progress schemeMigrationProgress
+	if blob := rawdb.ReadSchemeMigration(bc.db); blob != nil {
+		if err := rlp.DecodeBytes(blob, &progress); err != nil {
+			return fmt.Errorf("failed to decode scheme migration progress: %v", err)
+		}
+		log.Info("Resuming scheme migration", "number", progress.Number, "root", progress.Root, "accounts", progress.Accounts, "marker", hexutil.Bytes(progress.Marker))
+	} else {
+		// Make sure the state to migrate is persisted, it's not referenced in
+		// memory anymore once the chain progresses.
+		head := bc.CurrentBlock()
+		if !rawdb.HasLegacyTrieNode(bc.db, head.Root()) {
+			if !bc.chainmu.TryLock() {
+				return errChainStopped
+			}
+			err := bc.triedb.Commit(head.Root(), false)
+			bc.chainmu.Unlock()
+			if err != nil {
+				return err
+			}
+		}
+		progress = schemeMigrationProgress{Root: head.Root(), Number: head.NumberU64()}
+		writeSchemeMigration(bc.db, &progress)
+		log.Info("Starting scheme migration", "number", progress.Number, "root", progress.Root)
+	}
+	// Populate the snapshot only if it's not maintained by the chain, otherwise
+	// it keeps following the chain across the switch-over.
+	populate := bc.snaps == nil
+	bc.migration = progress.status(populate)
+
+	bc.wg.Add(1)
+	go func() {
+		defer bc.wg.Done()
+
+		err := bc.migrateState(&progress, populate, bc.quit)
+		if err == nil {
+			log.Info("Scheme migration completed", "number", progress.Number, "root", progress.Root,
+				"accounts", progress.Accounts, "slots", progress.Slots, "nodes", progress.Nodes)
+
+			// The migrated state is persisted already, the restart takes care
+			// of the switch-over if it can't be done in place.
+			switch err := bc.switchToPathScheme(bc.quit); {
+			case errors.Is(err, errMigrationAborted), errors.Is(err, errChainStopped):
+				log.Info("Scheme switch interrupted, rewinding to the migrated state on restart")
+			case err != nil:
+				log.Warn("Failed to switch to the path scheme, rewinding to the migrated state on restart", "err", err)
+			}
+		}
+		bc.migrationLock.Lock()
+		bc.migration = nil
+		bc.migrationLock.Unlock()
+
+		switch {
+		case err == nil:
+		case errors.Is(err, errMigrationAborted):
+			log.Info("Scheme migration paused", "accounts", progress.Accounts, "marker", hexutil.Bytes(progress.Marker))
+		default:
+			log.Error("Scheme migration failed", "err", err)
+		}
+	}()
+	return nil
+}
+
+// SchemeMigrationStatus returns the progress of the running scheme migration,
+// or nil if there is none.
+func (bc *BlockChain) SchemeMigrationStatus() *SchemeMigrationStatus {
+	bc.migrationLock.Lock()
+	defer bc.migrationLock.Unlock()
+
+	if bc.migration == nil {
+		return nil
+	}
+	status := *bc.migration
+	return &status
+}
+
+// migrateState copies the trie nodes of the migrated state into the path-based
+// layout, account by account from the persisted marker on. The progress is
+// flushed periodically and when aborted, so the migration can be resumed. The
+// root node is written last along with the pending deletion of the legacy
+// nodes: its presence switches the scheme on restart.
+func (bc *BlockChain) migrateState(progress *schemeMigrationProgress, populate bool, quit chan struct{}) error {
+	tr, err := trie.New(trie.StateTrieID(progress.Root), bc.triedb)
+	if err != nil {
+		return err
+	}
+	it, err := tr.NodeIterator(progress.Marker)
+	if err != nil {
+		return err
+	}
+	var (
+		batch  = bc.db.NewBatch()
+		logged = time.Now()
+	)
+	for it.Next(true) {
+		if !it.Leaf() {
+			// Embedded nodes are stored in their parent, the root is deferred
+			// to the end of the migration.
+			if it.Hash() != (common.Hash{}) && len(it.Path()) > 0 {
+				rawdb.WriteAccountTrieNode(batch, it.Path(), it.NodeBlob())
+				progress.Nodes++
+			}
+			continue
+		}
+		key := it.LeafKey()
+		if progress.Marker != nil && bytes.Compare(key, progress.Marker) <= 0 {
+			continue
+		}
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return err
+		}
+		accountHash := common.BytesToHash(key)
+		if populate {
+			rawdb.WriteAccountSnapshot(batch, accountHash, types.SlimAccountRLP(account))
+		}
+		if account.Root != types.EmptyRootHash {
+			if err := bc.migrateStorage(batch, progress, accountHash, account.Root, populate); err != nil {
+				return err
+			}
+		}
+		progress.Accounts++
+		progress.Marker = common.CopyBytes(key)
+
+		// Flush the migrated accounts along with the progress
+		select {
+		case <-quit:
+			bc.flushSchemeMigration(batch, progress, populate)
+			return errMigrationAborted
+		default:
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			bc.flushSchemeMigration(batch, progress, populate)
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Migrating state to path scheme", "number", progress.Number, "accounts", progress.Accounts,
+				"slots", progress.Slots, "nodes", progress.Nodes, "marker", hexutil.Bytes(progress.Marker))
+			logged = time.Now()
+		}
+	}
+	if it.Error() != nil {
+		return it.Error()
+	}
+	blob := rawdb.ReadLegacyTrieNode(bc.db, progress.Root)
+	if len(blob) == 0 {
+		return fmt.Errorf("missing state root %x", progress.Root)
+	}
+	rawdb.WriteAccountTrieNode(batch, nil, blob)
+	progress.Nodes++
+
+	// The snapshot generator is left out, so the snapshot is verified against
+	// the migrated state on switch-over rather than trusted blindly.
+	if populate {
+		rawdb.WriteSnapshotRoot(batch, progress.Root)
+		rawdb.DeleteSnapshotGenerator(batch)
+	}
+	rawdb.DeleteSchemeMigration(batch)
+	rawdb.WriteLegacyTrieCleanup(batch, nil)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write scheme migration", "err", err)
+	}
+	return nil
+}
+
+// switchToPathScheme switches the chain over to the migrated path-based state
+// without a restart. The blocks imported since the migrated one are executed
+// again on top of it, mostly in the background and the last few with the chain
+// held, then the trie database of the chain takes over the path-based backend
+// in place and the legacy hash-based nodes are deleted in the background.
+func (bc *BlockChain) switchToPathScheme(quit chan struct{}) error {
+	config := *bc.cacheConfig
+	config.StateScheme = rawdb.PathScheme
+	triedb := trie.NewDatabase(bc.db, config.triedbConfig())
+
+	for {
+		replayed, err := bc.replayPathState(triedb, quit)
+		if err != nil {
+			triedb.Close()
+			return err
+		}
+		if replayed <= schemeSwitchBacklog {
+			break
+		}
+	}
+	if !bc.chainmu.TryLock() {
+		triedb.Close()
+		return errChainStopped
+	}
+	defer bc.chainmu.Unlock()
+
+	if _, err := bc.replayPathState(triedb, nil); err != nil {
+		triedb.Close()
+		return err
+	}
+	bc.triedb.Replace(triedb)
+	log.Info("Switched to the path scheme", "number", bc.CurrentBlock().NumberU64(), "root", bc.CurrentBlock().Root())
+
+	bc.wg.Add(1)
+	go bc.deleteLegacyTrieNodes()
+	return nil
+}
+
+// replayPathState executes the canonical blocks missing from the path-based
+// database again, on top of the most recent canonical state it holds, up to the
+// current head. It returns the number of blocks executed.
+func (bc *BlockChain) replayPathState(triedb *trie.Database, quit chan struct{}) (int, error) {
+	// Find the most recent state known to the path database, the chain might
+	// have been reorganised since the last round.
+	var (
+		headers []*types.Header
+		parent  = bc.CurrentBlock().Header()
+	)
+	for {
+		if _, err := triedb.Reader(parent.Root); err == nil {
+			break
+		}
+		headers = append(headers, parent)
+		if parent = bc.GetHeader(parent.ParentHash, parent.Number.Uint64()-1); parent == nil {
+			return 0, errors.New("missing ancestor of the migrated state")
+		}
+	}
+	var (
+		database = state.NewDatabaseWithNodeDB(bc.db, triedb)
+		logged   = time.Now()
+	)
+	for i := len(headers) - 1; i >= 0; i-- {
+		select {
+		case <-quit:
+			return 0, errMigrationAborted
+		default:
+		}
+		block := bc.GetBlock(headers[i].Hash(), headers[i].Number.Uint64())
+		if block == nil {
+			return 0, fmt.Errorf("block #%d not found", headers[i].Number)
+		}
+		statedb, err := state.New(parent.Root, database, nil)
+		if err != nil {
+			return 0, err
+		}
+		receipts, _, _, usedGas, err := bc.processor.Process(block, statedb, bc.vmConfig, nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to replay block #%d [%x..]: %w", block.NumberU64(), block.Hash().Bytes()[:4], err)
+		}
+		if err := bc.validator.ValidateState(block, statedb, receipts, usedGas); err != nil {
+			return 0, fmt.Errorf("replay of block #%d [%x..] diverged: %w", block.NumberU64(), block.Hash().Bytes()[:4], err)
+		}
+		if _, err := statedb.Commit(block.NumberU64(), bc.chainConfig.IsEIP158(block.Number())); err != nil {
+			return 0, err
+		}
+		parent = block.Header()
+
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Catching up with the chain in path scheme", "number", block.NumberU64(), "left", i)
+			logged = time.Now()
+		}
+	}
+	return len(headers), nil
+}
+
+// deleteLegacyTrieNodes deletes the hash-based trie nodes left behind by the
+// scheme migration, scanning the database from the persisted position on. The
+// position is flushed along with the deletions, so the scan can be resumed.
+func (bc *BlockChain) deleteLegacyTrieNodes() {
+	defer bc.wg.Done()
+
+	start, _ := rawdb.ReadLegacyTrieCleanup(bc.db)
+	it := bc.db.NewIterator(nil, start)
+	defer it.Release()
+
+	var (
+		batch   = bc.db.NewBatch()
+		deleted uint64
+		logged  = time.Now()
+	)
+	for it.Next() {
+		key := it.Key()
+		if rawdb.IsLegacyTrieNode(key, it.Value()) {
+			batch.Delete(key)
+			deleted++
+		}
+		select {
+		case <-bc.quit:
+			rawdb.WriteLegacyTrieCleanup(batch, key)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to delete legacy trie nodes", "err", err)
+			}
+			log.Info("Legacy trie node deletion paused", "deleted", deleted)
+			return
+		default:
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			rawdb.WriteLegacyTrieCleanup(batch, key)
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to delete legacy trie nodes", "err", err)
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Deleting legacy trie nodes", "deleted", deleted, "position", hexutil.Bytes(key))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		log.Error("Failed to delete legacy trie nodes", "err", err)
+		return
+	}
+	rawdb.DeleteLegacyTrieCleanup(batch)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to delete legacy trie nodes", "err", err)
+	}
+	log.Info("Deleted legacy trie nodes", "deleted", deleted)
+}
+
+// migrateStorage copies the storage trie nodes of an account into the
+// path-based layout.
+func (bc *BlockChain) migrateStorage(batch ethdb.Batch, progress *schemeMigrationProgress, accountHash, root common.Hash, populate bool) error {
+	tr, err := trie.New(trie.StorageTrieID(progress.Root, accountHash, root), bc.triedb)
+	if err != nil {
+		return err
+	}
+	it, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	for it.Next(true) {
+		if it.Leaf() {
+			if populate {
+				rawdb.WriteStorageSnapshot(batch, accountHash, common.BytesToHash(it.LeafKey()), it.LeafBlob())
+			}
+			progress.Slots++
+		} else if it.Hash() != (common.Hash{}) {
+			rawdb.WriteStorageTrieNode(batch, accountHash, it.Path(), it.NodeBlob())
+			progress.Nodes++
+		}
+		// Large storage tries are flushed without progress, the account is
+		// migrated again from scratch if interrupted.
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to write scheme migration", "err", err)
+			}
+			batch.Reset()
+		}
+	}
+	return it.Error()
+}
+
+// flushSchemeMigration writes out the migrated accounts along with the progress
+// and publishes the latter.
+func (bc *BlockChain) flushSchemeMigration(batch ethdb.Batch, progress *schemeMigrationProgress, populate bool) {
+	writeSchemeMigration(batch, progress)
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write scheme migration", "err", err)
+	}
+	batch.Reset()
+
+	bc.migrationLock.Lock()
+	bc.migration = progress.status(populate)
+	bc.migrationLock.Unlock()
+}
+
+// status converts the persisted progress into its public form.
+func (p *schemeMigrationProgress) status(populate bool) *SchemeMigrationStatus {
+	return &SchemeMigrationStatus{
+		Root:     p.Root,
+		Number:   p.Number,
+		Snapshot: populate,
+		Marker:   common.CopyBytes(p.Marker),
+		Accounts: p.Accounts,
+		Slots:    p.Slots,
+		Nodes:    p.Nodes,
+	}
+}
+
+// writeSchemeMigration persists the progress of the scheme migration.
+func writeSchemeMigration(db ethdb.KeyValueWriter, progress *schemeMigrationProgress) {
+	blob, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		log.Crit("Failed to encode scheme migration progress", "err", err)
+	}
+	rawdb.WriteSchemeMigration(db, blob)
+}
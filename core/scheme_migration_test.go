@@ -0,0 +1,256 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that an interrupted migration of the hash-based state is resumed from
+// its persisted progress, and that the node switches to the path scheme with
+// the migrated state and snapshot on restart.
+func TestSchemeMigration(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		storage = make(map[common.Hash]common.Hash)
+		alloc   = GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}}
+	)
+	for i := 0; i < 64; i++ {
+		storage[common.BigToHash(big.NewInt(int64(i)))] = common.BigToHash(big.NewInt(int64(i + 1)))
+	}
+	for i := 0; i < 16; i++ {
+		alloc[common.BigToAddress(big.NewInt(int64(0x1000+i)))] = GenesisAccount{Balance: big.NewInt(1), Code: []byte{0x00}, Storage: storage}
+	}
+	gspec := &Genesis{Config: params.TestChainConfig, Alloc: alloc, BaseFee: big.NewInt(params.InitialBaseFee)}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 4, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), types.HomesteadSigner{}, key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.SnapshotLimit = 0
+
+	chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	head := chain.CurrentBlock()
+	if err := chain.triedb.Commit(head.Root(), false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	writeSchemeMigration(db, &schemeMigrationProgress{Root: head.Root(), Number: head.NumberU64()})
+
+	// Migrate one account at a time, resuming from the persisted progress
+	quit := make(chan struct{})
+	close(quit)
+
+	var rounds int
+	for blob := rawdb.ReadSchemeMigration(db); blob != nil; blob = rawdb.ReadSchemeMigration(db) {
+		var progress schemeMigrationProgress
+		if err := rlp.DecodeBytes(blob, &progress); err != nil {
+			t.Fatalf("failed to decode progress: %v", err)
+		}
+		if rawdb.ReadStateScheme(db) != rawdb.HashScheme {
+			t.Fatalf("scheme switched before the migration completed")
+		}
+		if err := chain.migrateState(&progress, true, quit); err != nil && err != errMigrationAborted {
+			t.Fatalf("failed to migrate state: %v", err)
+		}
+		if rounds++; rounds > 2*(len(alloc)+len(blocks)) {
+			t.Fatalf("migration not progressing")
+		}
+	}
+	if rounds < len(alloc) {
+		t.Fatalf("migration rounds mismatch: have %d, want at least %d", rounds, len(alloc))
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.PathScheme {
+		t.Fatalf("scheme mismatch: have %s, want %s", scheme, rawdb.PathScheme)
+	}
+	if root := rawdb.ReadSnapshotRoot(db); root != head.Root() {
+		t.Fatalf("snapshot root mismatch: have %x, want %x", root, head.Root())
+	}
+	if blob := rawdb.ReadAccountSnapshot(db, crypto.Keccak256Hash(sender.Bytes())); len(blob) == 0 {
+		t.Fatalf("missing account snapshot")
+	}
+	chain.Stop()
+
+	// Restart in the path scheme and check the migrated state
+	chain, err = NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.PathScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if chain.CurrentBlock().Hash() != head.Hash() {
+		t.Fatalf("head mismatch: have %x, want %x", chain.CurrentBlock().Hash(), head.Hash())
+	}
+	statedb, err := state.New(head.Root(), state.NewDatabaseWithConfig(db, newDbConfig(rawdb.PathScheme)), nil)
+	if err != nil {
+		t.Fatalf("failed to open migrated state: %v", err)
+	}
+	for addr, account := range alloc {
+		if addr == sender {
+			continue
+		}
+		if balance := statedb.GetBalance(addr); balance.Cmp(account.Balance) != 0 {
+			t.Fatalf("balance mismatch for %x: have %v, want %v", addr, balance, account.Balance)
+		}
+		for slot, value := range account.Storage {
+			if have := statedb.GetState(addr, slot); have != value {
+				t.Fatalf("storage mismatch for %x %x: have %x, want %x", addr, slot, have, value)
+			}
+		}
+	}
+	if nonce := statedb.GetNonce(sender); nonce != uint64(len(blocks)) {
+		t.Fatalf("nonce mismatch: have %d, want %d", nonce, len(blocks))
+	}
+	if err := chain.StartSchemeMigration(); err != errMigrationNotNeeded {
+		t.Fatalf("migration error mismatch: have %v, want %v", err, errMigrationNotNeeded)
+	}
+}
+
+// Tests that the chain switches to the migrated path-based state in place,
+// catching up with the blocks imported meanwhile, and that the legacy nodes
+// are deleted afterwards.
+func TestSchemeMigrationSwitch(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			Alloc:   GenesisAlloc{sender: {Balance: big.NewInt(params.Ether)}},
+			BaseFee: big.NewInt(params.InitialBaseFee),
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 10, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(sender), common.Address{byte(i + 1)}, big.NewInt(1000), params.TxGas, b.header.BaseFee, nil), types.HomesteadSigner{}, key)
+		b.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, err := NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks[:4], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	head := chain.CurrentBlock()
+	if err := chain.triedb.Commit(head.Root(), false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	progress := &schemeMigrationProgress{Root: head.Root(), Number: head.NumberU64()}
+	if err := chain.migrateState(progress, false, make(chan struct{})); err != nil {
+		t.Fatalf("failed to migrate state: %v", err)
+	}
+	// Keep importing in the hash scheme until the switch-over
+	if _, err := chain.InsertChain(blocks[4:8], nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if err := chain.switchToPathScheme(nil); err != nil {
+		t.Fatalf("failed to switch scheme: %v", err)
+	}
+	if scheme := chain.TrieDB().Scheme(); scheme != rawdb.PathScheme {
+		t.Fatalf("scheme mismatch: have %s, want %s", scheme, rawdb.PathScheme)
+	}
+	if !chain.HasState(blocks[7].Root()) {
+		t.Fatalf("missing head state after switch")
+	}
+	if _, err := chain.InsertChain(blocks[8:], nil); err != nil {
+		t.Fatalf("failed to insert chain after switch: %v", err)
+	}
+	// Wait for the legacy nodes to be deleted
+	for i := 0; ; i++ {
+		if _, pending := rawdb.ReadLegacyTrieCleanup(db); !pending {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("legacy trie nodes not deleted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		if rawdb.IsLegacyTrieNode(it.Key(), it.Value()) {
+			t.Fatalf("legacy trie node %x left", it.Key())
+		}
+	}
+	it.Release()
+	chain.Stop()
+
+	// Restart with the scheme of the database and check the state
+	chain, err = NewBlockChain(db, DefaultCacheConfigWithScheme(rawdb.ReadStateScheme(db)), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock(); head.Hash() != blocks[9].Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", head.NumberU64(), blocks[9].NumberU64())
+	}
+	statedb, err := chain.State()
+	if err != nil {
+		t.Fatalf("failed to open head state: %v", err)
+	}
+	if nonce := statedb.GetNonce(sender); nonce != uint64(len(blocks)) {
+		t.Fatalf("nonce mismatch: have %d, want %d", nonce, len(blocks))
+	}
+}
+
+// Tests that an explicitly configured hash scheme rules out the migration.
+func TestSchemeMigrationPinned(t *testing.T) {
+	gspec := &Genesis{
+		Config:  params.TestChainConfig,
+		Alloc:   GenesisAlloc{common.Address{0x01}: {Balance: big.NewInt(1)}},
+		BaseFee: big.NewInt(params.InitialBaseFee),
+	}
+	db := rawdb.NewMemoryDatabase()
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.SchemeMigration = true
+	config.StateSchemePinned = true
+
+	chain, err := NewBlockChain(db, config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if err := chain.StartSchemeMigration(); err != errSchemePinned {
+		t.Fatalf("migration error mismatch: have %v, want %v", err, errSchemePinned)
+	}
+	if rawdb.ReadSchemeMigration(db) != nil {
+		t.Fatalf("migration started with a pinned scheme")
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+		t.Fatalf("scheme mismatch: have %s, want %s", scheme, rawdb.HashScheme)
+	}
+}
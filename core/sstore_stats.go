@@ -0,0 +1,111 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// sstoreStatsWindow is the number of recent blocks the SSTORE counters are kept
+// for.
+const sstoreStatsWindow = 1024
+
+var (
+	sstoreCreatedMeter = metrics.NewRegisteredMeter("chain/sstore/created", nil)
+	sstoreClearedMeter = metrics.NewRegisteredMeter("chain/sstore/cleared", nil)
+	sstoreUpdatedMeter = metrics.NewRegisteredMeter("chain/sstore/updated", nil)
+	sstoreDirtyMeter   = metrics.NewRegisteredMeter("chain/sstore/dirty", nil)
+	sstoreNoopMeter    = metrics.NewRegisteredMeter("chain/sstore/noop", nil)
+)
+
+// SstoreStats are the SSTOREs executed by the transactions of a block, split by
+// the transition they applied to the storage slots, in total and per contract.
+type SstoreStats struct {
+	Number    uint64                              `json:"number"`
+	Hash      common.Hash                         `json:"hash"`
+	Total     vm.SstoreCounts                     `json:"total"`
+	Contracts map[common.Address]*vm.SstoreCounts `json:"contracts"`
+}
+
+// sstoreStats is the ring buffer of the SSTORE counters of the recent blocks.
+type sstoreStats struct {
+	stats []SstoreStats
+	next  int
+	lock  sync.RWMutex
+}
+
+// newSstoreStats creates an empty ring buffer of SSTORE counters.
+func newSstoreStats() *sstoreStats {
+	return &sstoreStats{stats: make([]SstoreStats, 0, sstoreStatsWindow)}
+}
+
+// recordSstoreStats stores the SSTORE counters collected while processing a
+// block, once validated.
+func (bc *BlockChain) recordSstoreStats(block *types.Block, counters *vm.SstoreStats) {
+	stats := SstoreStats{
+		Number:    block.NumberU64(),
+		Hash:      block.Hash(),
+		Total:     counters.Total,
+		Contracts: counters.Contracts,
+	}
+	sstoreCreatedMeter.Mark(int64(stats.Total.Created))
+	sstoreClearedMeter.Mark(int64(stats.Total.Cleared))
+	sstoreUpdatedMeter.Mark(int64(stats.Total.Updated))
+	sstoreDirtyMeter.Mark(int64(stats.Total.Dirty))
+	sstoreNoopMeter.Mark(int64(stats.Total.Noop))
+
+	s := bc.sstoreStats
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.stats) < sstoreStatsWindow {
+		s.stats = append(s.stats, stats)
+	} else {
+		s.stats[s.next] = stats
+	}
+	s.next = (s.next + 1) % sstoreStatsWindow
+}
+
+// SstoreStats returns the SSTORE counters of the at most count most recently
+// processed blocks, oldest first. Nil is returned if the instrumentation is
+// disabled.
+func (bc *BlockChain) SstoreStats(count int) []SstoreStats {
+	s := bc.sstoreStats
+	if s == nil {
+		return nil
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if count < 0 {
+		count = 0
+	}
+	if count > len(s.stats) {
+		count = len(s.stats)
+	}
+	stats := make([]SstoreStats, 0, count)
+	for i := len(s.stats) - count; i < len(s.stats); i++ {
+		// Once the buffer is full, the oldest entry is the next overwritten
+		stats = append(stats, s.stats[(s.next+i)%len(s.stats)])
+	}
+	return stats
+}
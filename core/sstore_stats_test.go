@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the SSTOREs of the imported blocks are counted by slot transition.
+func TestSstoreStats(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		writer = common.HexToAddress("0xaaaa")
+		signer = types.LatestSigner(params.TestChainConfig)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// Set slot 0 to 1 then 2, slot 1 to 0, slot 2 to 7 and slot 3 to 4
+				writer: {
+					Balance: common.Big0,
+					Code:    common.FromHex("0x60016000556002600055600060015560076002556004600355"),
+					Storage: map[common.Hash]common.Hash{
+						common.BigToHash(big.NewInt(1)): common.BigToHash(big.NewInt(5)),
+						common.BigToHash(big.NewInt(2)): common.BigToHash(big.NewInt(3)),
+						common.BigToHash(big.NewInt(3)): common.BigToHash(big.NewInt(4)),
+					},
+				},
+			},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), writer, nil, 200000, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.SstoreStats = true
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	want := []vm.SstoreCounts{
+		{Created: 1, Dirty: 1, Cleared: 1, Updated: 1, Noop: 1},
+		{Updated: 1, Dirty: 1, Noop: 3},
+	}
+	stats := chain.SstoreStats(10)
+	if len(stats) != len(want) {
+		t.Fatalf("stats count mismatch: have %d, want %d", len(stats), len(want))
+	}
+	for i, stat := range stats {
+		if stat.Hash != blocks[i].Hash() {
+			t.Errorf("block %d: hash mismatch: have %x, want %x", i, stat.Hash, blocks[i].Hash())
+		}
+		if stat.Total != want[i] {
+			t.Errorf("block %d: total mismatch: have %+v, want %+v", i, stat.Total, want[i])
+		}
+		if len(stat.Contracts) != 1 || stat.Contracts[writer] == nil || *stat.Contracts[writer] != want[i] {
+			t.Errorf("block %d: contract counts mismatch: have %v", i, stat.Contracts)
+		}
+	}
+	if stats := chain.SstoreStats(1); len(stats) != 1 || stats[0].Hash != blocks[1].Hash() {
+		t.Errorf("latest stats mismatch")
+	}
+}
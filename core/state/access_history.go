@@ -0,0 +1,133 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var accessHistorySlotsGauge = metrics.NewRegisteredGauge("state/history/slots", nil)
+
+// AccessHistory tracks the storage slots of any contract accessed by the recent
+// blocks, up to a limit. Unlike HotStorage, the slots of a contract are only
+// warmed up if the next block is expected to touch it, e.g. if it's the
+// recipient of a transaction pending in the pool.
+type AccessHistory struct {
+	contracts map[common.Address]map[common.Hash]uint64 // Recent slots of each contract, mapped to the last block accessing them
+	slots     int                                       // Number of slots tracked across all contracts
+	retention uint64                                    // Number of blocks a slot is tracked without being accessed
+	limit     int                                       // Maximum number of slots tracked
+	lock      sync.Mutex
+}
+
+// NewAccessHistory creates a tracker of the storage slots accessed by the recent
+// blocks, keeping a slot for the given number of blocks after it was last
+// accessed and tracking up to limit slots.
+func NewAccessHistory(retention uint64, limit int) *AccessHistory {
+	return &AccessHistory{
+		contracts: make(map[common.Address]map[common.Hash]uint64),
+		retention: retention,
+		limit:     limit,
+	}
+}
+
+// Warm schedules the given accounts, their given slots and the recently accessed
+// slots of them for prefetching into the tries of the state, and reads them
+// through its snapshot in the background. The state must have its prefetcher
+// started. The scheduling stops early if the optional interrupt gets set. The
+// number of accounts and slots scheduled are returned.
+func (h *AccessHistory) Warm(s *StateDB, accounts []common.Address, slots map[common.Address][]common.Hash, interrupt *uint32) (int, int) {
+	if s.prefetcher == nil || len(accounts) == 0 {
+		return 0, 0
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// Merge the requested slots with the recently accessed ones
+	wanted := make(map[common.Address][]common.Hash)
+	for _, addr := range accounts {
+		if len(h.contracts[addr]) == 0 {
+			if len(slots[addr]) > 0 {
+				wanted[addr] = slots[addr]
+			}
+			continue
+		}
+		merged := make(map[common.Hash]struct{}, len(slots[addr])+len(h.contracts[addr]))
+		for _, slot := range slots[addr] {
+			merged[slot] = struct{}{}
+		}
+		for slot := range h.contracts[addr] {
+			merged[slot] = struct{}{}
+		}
+		for slot := range merged {
+			wanted[addr] = append(wanted[addr], slot)
+		}
+	}
+	return s.prefetchState(accounts, wanted, interrupt)
+}
+
+// Record tracks the storage slots accessed while processing the given block,
+// as long as the limit permits, and drops the ones not accessed for the
+// retention period.
+func (h *AccessHistory) Record(s *StateDB, number uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	// Drop the stale slots first to make room for the fresh ones
+	for addr, slots := range h.contracts {
+		for slot, last := range slots {
+			if last+h.retention < number {
+				delete(slots, slot)
+				h.slots--
+			}
+		}
+		if len(slots) == 0 {
+			delete(h.contracts, addr)
+		}
+	}
+	for addr, obj := range s.stateObjects {
+		if len(obj.originStorage) == 0 {
+			continue
+		}
+		slots := h.contracts[addr]
+		for slot := range obj.originStorage {
+			if _, ok := slots[slot]; !ok {
+				if h.slots >= h.limit {
+					continue
+				}
+				if slots == nil {
+					slots = make(map[common.Hash]uint64)
+					h.contracts[addr] = slots
+				}
+				h.slots++
+			}
+			slots[slot] = number
+		}
+	}
+	accessHistorySlotsGauge.Update(int64(h.slots))
+}
+
+// Slots returns the number of recently accessed slots of a contract.
+func (h *AccessHistory) Slots(addr common.Address) int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return len(h.contracts[addr])
+}
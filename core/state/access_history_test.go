@@ -0,0 +1,89 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the storage slots accessed by a block are tracked up to the limit
+// for the retention period, and prefetched for the contracts expected to be
+// touched by the next block only.
+func TestAccessHistory(t *testing.T) {
+	var (
+		db       = NewDatabase(rawdb.NewMemoryDatabase())
+		contract = common.HexToAddress("0xaffeaffeaffeaffeaffeaffeaffeaffeaffeaffe")
+		other    = common.HexToAddress("0xbeefbeefbeefbeefbeefbeefbeefbeefbeefbeef")
+	)
+	state, _ := New(common.Hash{}, db, nil)
+	for i := 0; i < 10; i++ {
+		slot := common.BigToHash(big.NewInt(int64(i)))
+		state.SetState(contract, slot, slot)
+		state.SetState(other, slot, slot)
+	}
+	root, _ := state.Commit(0, false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	history := NewAccessHistory(4, 5)
+
+	// Access a few slots of both contracts, only the ones fitting the limit are
+	// recorded
+	state, _ = New(root, db, nil)
+	for i := 0; i < 3; i++ {
+		state.GetState(contract, common.BigToHash(big.NewInt(int64(i))))
+		state.GetState(other, common.BigToHash(big.NewInt(int64(i))))
+	}
+	history.Record(state, 10)
+	if n := history.Slots(contract) + history.Slots(other); n != 5 {
+		t.Fatalf("recorded slots mismatch: have %d, want 5", n)
+	}
+	// Warm the next block's state for one of the contracts and ensure only its
+	// storage trie got prefetched
+	state, _ = New(root, db, nil)
+	state.prefetcher = newTriePrefetcher(db, root, "")
+	defer state.StopPrefetcher()
+
+	target := contract
+	if history.Slots(contract) == 0 {
+		target = other
+	}
+	accounts, slots := history.Warm(state, []common.Address{target}, nil, nil)
+	if accounts != 1 || slots != history.Slots(target) {
+		t.Fatalf("scheduled mismatch: have %d/%d, want %d/%d", accounts, slots, 1, history.Slots(target))
+	}
+	obj := state.getStateObject(target)
+	if tr := state.prefetcher.trie(obj.addrHash, obj.data.Root); tr == nil {
+		t.Fatalf("recent storage not prefetched")
+	}
+	if tr := state.prefetcher.trie(common.Hash{}, root); tr == nil {
+		t.Fatalf("accounts not prefetched")
+	}
+	// The slots are dropped once not accessed for the retention period
+	history.Record(state, 14)
+	if n := history.Slots(contract) + history.Slots(other); n != 5 {
+		t.Fatalf("recorded slots mismatch within retention: have %d, want 5", n)
+	}
+	history.Record(state, 15)
+	if n := history.Slots(contract) + history.Slots(other); n != 0 {
+		t.Fatalf("recorded slots mismatch after retention: have %d, want 0", n)
+	}
+}
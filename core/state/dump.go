@@ -17,13 +17,17 @@
 package state
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -43,7 +47,8 @@ type DumpConfig struct {
 type DumpCollector interface {
 	// OnRoot is called with the state root
 	OnRoot(common.Hash)
-	// OnAccount is called once for each account in the trie
+	// OnAccount is called once for each account in the trie, in ascending order
+	// of the address hashes
 	OnAccount(common.Address, DumpAccount)
 }
 
@@ -190,7 +195,7 @@ func (s *StateDB) DumpToCollector(c DumpCollector, conf *DumpConfig) (nextKey []
 					log.Error("Failed to decode the value returned by iterator", "error", err)
 					continue
 				}
-				account.Storage[common.BytesToHash(s.trie.GetKey(storageIt.Key))] = common.Bytes2Hex(content)
+				account.Storage[storageDumpKey(s.trie.GetKey(storageIt.Key), storageIt.Key)] = common.Bytes2Hex(content)
 			}
 		}
 		c.OnAccount(addr, account)
@@ -216,6 +221,112 @@ func (s *StateDB) DumpToCollector(c DumpCollector, conf *DumpConfig) (nextKey []
 	return nextKey
 }
 
+// storageDumpKey returns the key of a slot in a dump, which is the preimage of
+// the slot hash if known, or the hash itself otherwise, so that the slots with
+// missing preimages don't overwrite each other.
+func storageDumpKey(preimage []byte, hash []byte) common.Hash {
+	if preimage == nil {
+		return common.BytesToHash(hash)
+	}
+	return common.BytesToHash(preimage)
+}
+
+// DumpSnapshotToCollector iterates the state snapshot of the given root the same
+// way as DumpToCollector iterates the state trie, producing the identical output,
+// only faster. The preimages and the contract codes are read from the database.
+func DumpSnapshotToCollector(snaps *snapshot.Tree, root common.Hash, db ethdb.KeyValueReader, c DumpCollector, conf *DumpConfig) (nextKey []byte, err error) {
+	// Sanitize the input to allow nil configs
+	if conf == nil {
+		conf = new(DumpConfig)
+	}
+	accIt, err := snaps.AccountIterator(root, common.BytesToHash(conf.Start))
+	if err != nil {
+		return nil, err
+	}
+	defer accIt.Release()
+
+	var (
+		missingPreimages int
+		accounts         uint64
+		start            = time.Now()
+		logged           = time.Now()
+	)
+	log.Info("Snapshot dumping started", "root", root)
+	c.OnRoot(root)
+
+	for accIt.Next() {
+		if conf.Max > 0 && accounts >= conf.Max {
+			nextKey = accIt.Hash().Bytes()
+			break
+		}
+		data, err := types.FullAccount(accIt.Account())
+		if err != nil {
+			return nil, err
+		}
+		account := DumpAccount{
+			Balance:   data.Balance.String(),
+			Nonce:     data.Nonce,
+			Root:      data.Root[:],
+			CodeHash:  data.CodeHash,
+			SecureKey: accIt.Hash().Bytes(),
+		}
+		addrBytes := rawdb.ReadPreimage(db, accIt.Hash())
+		if addrBytes == nil {
+			missingPreimages++
+			if conf.OnlyWithAddresses {
+				continue
+			}
+		}
+		if !conf.SkipCode && !bytes.Equal(data.CodeHash, emptyCodeHash) {
+			account.Code = rawdb.ReadCode(db, common.BytesToHash(data.CodeHash))
+		}
+		if !conf.SkipStorage {
+			account.Storage = make(map[common.Hash]string)
+
+			stIt, err := snaps.StorageIterator(root, accIt.Hash(), common.Hash{})
+			if err != nil {
+				return nil, err
+			}
+			for stIt.Next() {
+				_, content, _, err := rlp.Split(stIt.Slot())
+				if err != nil {
+					log.Error("Failed to decode the value returned by iterator", "error", err)
+					continue
+				}
+				account.Storage[storageDumpKey(rawdb.ReadPreimage(db, stIt.Hash()), stIt.Hash().Bytes())] = common.Bytes2Hex(content)
+			}
+			err = stIt.Error()
+			stIt.Release()
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.OnAccount(common.BytesToAddress(addrBytes), account)
+		accounts++
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Snapshot dumping in progress", "at", accIt.Hash(), "accounts", accounts,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return nil, err
+	}
+	if missingPreimages > 0 {
+		log.Warn("Dump incomplete due to missing preimages", "missing", missingPreimages)
+	}
+	log.Info("Snapshot dumping complete", "accounts", accounts,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+
+	return nextKey, nil
+}
+
+// NewIterativeDumpCollector creates a collector which writes the accounts out
+// as json-objects, delimited by linebreaks, in the order they are iterated.
+func NewIterativeDumpCollector(output *json.Encoder) DumpCollector {
+	return iterativeDump{output}
+}
+
 // RawDump returns the entire state an a single large object
 func (s *StateDB) RawDump(opts *DumpConfig) Dump {
 	dump := &Dump{
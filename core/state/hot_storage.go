@@ -0,0 +1,121 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var hotSlotsGauge = metrics.NewRegisteredGauge("state/hot/slots", nil)
+
+// HotStorage tracks the storage slots of designated contracts, typically the
+// system contracts touched by every block, accessed by the recent blocks. The
+// slots are warmed up in the trie and snapshot caches ahead of the execution of
+// the next block, sparing it the cold reads.
+type HotStorage struct {
+	contracts map[common.Address]map[common.Hash]uint64 // Hot slots of each contract, mapped to the last block accessing them
+	retention uint64                                    // Number of blocks a slot stays hot without being accessed
+	lock      sync.Mutex
+}
+
+// NewHotStorage creates a tracker of the hot storage slots of the given
+// contracts, keeping a slot warm for the given number of blocks after it was
+// last accessed.
+func NewHotStorage(contracts []common.Address, retention uint64) *HotStorage {
+	h := &HotStorage{
+		contracts: make(map[common.Address]map[common.Hash]uint64),
+		retention: retention,
+	}
+	for _, addr := range contracts {
+		h.contracts[addr] = make(map[common.Hash]uint64)
+	}
+	return h
+}
+
+// Warm schedules the hot slots for prefetching into the storage tries of the
+// state, and reads them through its snapshot in the background. The state must
+// have its prefetcher started.
+func (h *HotStorage) Warm(s *StateDB) {
+	if s.prefetcher == nil {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for addr, slots := range h.contracts {
+		if len(slots) == 0 {
+			continue
+		}
+		obj := s.getStateObject(addr)
+		if obj == nil || obj.data.Root == emptyRoot {
+			continue
+		}
+		keys := make([][]byte, 0, len(slots))
+		hashes := make([]common.Hash, 0, len(slots))
+		for slot := range slots {
+			keys = append(keys, common.CopyBytes(slot[:]))
+			hashes = append(hashes, crypto.Keccak256Hash(slot[:]))
+		}
+		s.prefetcher.prefetch(obj.addrHash, obj.data.Root, keys)
+
+		if snap := s.snap; snap != nil {
+			go func(addrHash common.Hash) {
+				for _, hash := range hashes {
+					if _, err := snap.Storage(addrHash, hash); err != nil {
+						return
+					}
+				}
+			}(obj.addrHash)
+		}
+	}
+}
+
+// Record marks the slots of the tracked contracts accessed while processing the
+// given block as hot, and cools down the ones not accessed for the retention
+// period.
+func (h *HotStorage) Record(s *StateDB, number uint64) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var total int
+	for addr, slots := range h.contracts {
+		if obj := s.stateObjects[addr]; obj != nil {
+			for slot := range obj.originStorage {
+				slots[slot] = number
+			}
+		}
+		for slot, last := range slots {
+			if last+h.retention < number {
+				delete(slots, slot)
+			}
+		}
+		total += len(slots)
+	}
+	hotSlotsGauge.Update(int64(total))
+}
+
+// Slots returns the number of hot slots of a tracked contract.
+func (h *HotStorage) Slots(addr common.Address) int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	return len(h.contracts[addr])
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the storage slots of the tracked contracts accessed by a block are
+// kept hot for the retention period and prefetched into the next block's state.
+func TestHotStorage(t *testing.T) {
+	var (
+		db       = NewDatabase(rawdb.NewMemoryDatabase())
+		contract = common.HexToAddress("0xaffeaffeaffeaffeaffeaffeaffeaffeaffeaffe")
+		other    = common.HexToAddress("0xbeefbeefbeefbeefbeefbeefbeefbeefbeefbeef")
+	)
+	state, _ := New(common.Hash{}, db, nil)
+	for i := 0; i < 10; i++ {
+		slot := common.BigToHash(big.NewInt(int64(i)))
+		state.SetState(contract, slot, slot)
+		state.SetState(other, slot, slot)
+	}
+	root, _ := state.Commit(0, false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	hot := NewHotStorage([]common.Address{contract}, 4)
+
+	// Access a few slots of both contracts, only the tracked one is recorded
+	state, _ = New(root, db, nil)
+	for i := 0; i < 3; i++ {
+		state.GetState(contract, common.BigToHash(big.NewInt(int64(i))))
+		state.GetState(other, common.BigToHash(big.NewInt(int64(i))))
+	}
+	hot.Record(state, 10)
+	if n := hot.Slots(contract); n != 3 {
+		t.Fatalf("hot slots mismatch: have %d, want 3", n)
+	}
+	if n := hot.Slots(other); n != 0 {
+		t.Fatalf("untracked hot slots mismatch: have %d, want 0", n)
+	}
+	// Warm the next block's state and ensure the storage trie got prefetched
+	state, _ = New(root, db, nil)
+	state.prefetcher = newTriePrefetcher(db, root, "")
+	defer state.StopPrefetcher()
+
+	hot.Warm(state)
+	obj := state.getStateObject(contract)
+	if tr := state.prefetcher.trie(obj.addrHash, obj.data.Root); tr == nil {
+		t.Fatalf("hot storage not prefetched")
+	}
+	// The slots cool down once not accessed for the retention period
+	hot.Record(state, 14)
+	if n := hot.Slots(contract); n != 3 {
+		t.Fatalf("hot slots mismatch within retention: have %d, want 3", n)
+	}
+	hot.Record(state, 15)
+	if n := hot.Slots(contract); n != 0 {
+		t.Fatalf("hot slots mismatch after retention: have %d, want 0", n)
+	}
+}
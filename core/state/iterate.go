@@ -0,0 +1,294 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// The iterators below walk the state trie, i.e. the changes not hashed into it
+// yet via IntermediateRoot or Commit are not visible. They visit the accounts in ascending order of the hashes
+// of their addresses and the storage slots in ascending order of the hashes of
+// their keys, which is the order of the leaves in the tries. As such, the order
+// only depends on the content of the state, making the output of two nodes with
+// the same state identical.
+//
+// The address and slot preimages are only available if the node was recording
+// them, otherwise the callbacks receive nil for them. Any error returned by a
+// callback aborts the iteration and is returned as is.
+
+// AccountCallback is invoked for each account visited by IterateAccounts.
+type AccountCallback func(hash common.Hash, addr *common.Address, account *types.StateAccount) error
+
+// StorageCallback is invoked for each slot visited by IterateStorage.
+type StorageCallback func(hash common.Hash, key *common.Hash, value common.Hash) error
+
+// IterateAccounts walks the accounts of the state starting at the given address
+// hash, in ascending order of the address hashes.
+func (s *StateDB) IterateAccounts(start common.Hash, fn AccountCallback) error {
+	nodeIt, err := s.trie.NodeIterator(start[:])
+	if err != nil {
+		return err
+	}
+	it := trie.NewIterator(nodeIt)
+	for it.Next() {
+		account, err := decodeAccount(it.Value)
+		if err != nil {
+			return err
+		}
+		if err := fn(common.BytesToHash(it.Key), s.addressPreimage(it.Key), account); err != nil {
+			return err
+		}
+	}
+	return it.Err
+}
+
+// IterateStorage walks the storage slots of an account starting at the given
+// slot hash, in ascending order of the slot hashes.
+func (s *StateDB) IterateStorage(addr common.Address, start common.Hash, fn StorageCallback) error {
+	tr, err := s.storageTrie(addr)
+	if err != nil || tr == nil {
+		return err
+	}
+	nodeIt, err := tr.NodeIterator(start[:])
+	if err != nil {
+		return err
+	}
+	it := trie.NewIterator(nodeIt)
+	for it.Next() {
+		value, err := decodeSlot(it.Value)
+		if err != nil {
+			return err
+		}
+		if err := fn(common.BytesToHash(it.Key), s.slotPreimage(it.Key), value); err != nil {
+			return err
+		}
+	}
+	return it.Err
+}
+
+// AccountDiffCallback is invoked for each account differing between two states,
+// with nil for the side the account is missing from.
+type AccountDiffCallback func(hash common.Hash, addr *common.Address, before, after *types.StateAccount) error
+
+// StorageDiffCallback is invoked for each slot differing between two states,
+// with an empty value for the side the slot is missing from.
+type StorageDiffCallback func(hash common.Hash, key *common.Hash, before, after common.Hash) error
+
+// DiffAccounts walks the accounts of two states in ascending order of the address
+// hashes, invoking the callback for the ones which differ.
+func DiffAccounts(from, to *StateDB, fn AccountDiffCallback) error {
+	return diffTries(from.trie, to.trie, func(key, before, after []byte) error {
+		var (
+			prev, next *types.StateAccount
+			err        error
+		)
+		if before != nil {
+			if prev, err = decodeAccount(before); err != nil {
+				return err
+			}
+		}
+		if after != nil {
+			if next, err = decodeAccount(after); err != nil {
+				return err
+			}
+		}
+		addr := to.addressPreimage(key)
+		if addr == nil {
+			addr = from.addressPreimage(key)
+		}
+		return fn(common.BytesToHash(key), addr, prev, next)
+	})
+}
+
+// DiffStorage walks the storage slots of an account in two states in ascending
+// order of the slot hashes, invoking the callback for the ones which differ.
+func DiffStorage(from, to *StateDB, addr common.Address, fn StorageDiffCallback) error {
+	prev, err := from.storageTrie(addr)
+	if err != nil {
+		return err
+	}
+	next, err := to.storageTrie(addr)
+	if err != nil {
+		return err
+	}
+	return diffTries(prev, next, func(key, before, after []byte) error {
+		var prev, next common.Hash
+		if before != nil {
+			if prev, err = decodeSlot(before); err != nil {
+				return err
+			}
+		}
+		if after != nil {
+			if next, err = decodeSlot(after); err != nil {
+				return err
+			}
+		}
+		slot := to.slotPreimage(key)
+		if slot == nil {
+			slot = from.slotPreimage(key)
+		}
+		return fn(common.BytesToHash(key), slot, prev, next)
+	})
+}
+
+// diffTries walks the leaves of two tries side by side, invoking the callback for
+// the keys whose values differ. Nil tries are treated as empty ones. The subtrees
+// the tries share are skipped, so the cost is proportional to the difference.
+func diffTries(a, b Trie, fn func(key, before, after []byte) error) error {
+	itA, itB, err := diffIterators(a, b)
+	if err != nil {
+		return err
+	}
+	okA, okB := itA != nil && itA.Next(), itB != nil && itB.Next()
+	for okA || okB {
+		var cmp int
+		switch {
+		case !okB:
+			cmp = -1
+		case !okA:
+			cmp = 1
+		default:
+			cmp = bytes.Compare(itA.Key, itB.Key)
+		}
+		switch {
+		case cmp < 0:
+			if err := fn(itA.Key, itA.Value, nil); err != nil {
+				return err
+			}
+			okA = itA.Next()
+		case cmp > 0:
+			if err := fn(itB.Key, nil, itB.Value); err != nil {
+				return err
+			}
+			okB = itB.Next()
+		default:
+			if !bytes.Equal(itA.Value, itB.Value) {
+				if err := fn(itA.Key, itA.Value, itB.Value); err != nil {
+					return err
+				}
+			}
+			okA, okB = itA.Next(), itB.Next()
+		}
+	}
+	if itA != nil && itA.Err != nil {
+		return itA.Err
+	}
+	if itB != nil && itB.Err != nil {
+		return itB.Err
+	}
+	return nil
+}
+
+// diffIterators creates the iterators over the leaves only present in the first
+// trie and the ones only present in the second one. A nil trie has no leaves, so
+// the other one is walked in full.
+func diffIterators(a, b Trie) (*trie.Iterator, *trie.Iterator, error) {
+	if a == nil || b == nil {
+		itA, err := leafIterator(a)
+		if err != nil {
+			return nil, nil, err
+		}
+		itB, err := leafIterator(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		return itA, itB, nil
+	}
+	// The node iterators are consumed by the difference ones, so each direction
+	// needs its own pair
+	var nodeIts [4]trie.NodeIterator
+	for i, tr := range []Trie{a, b, a, b} {
+		it, err := tr.NodeIterator(nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		nodeIts[i] = it
+	}
+	onlyA, _ := trie.NewDifferenceIterator(nodeIts[1], nodeIts[0])
+	onlyB, _ := trie.NewDifferenceIterator(nodeIts[2], nodeIts[3])
+	return trie.NewIterator(onlyA), trie.NewIterator(onlyB), nil
+}
+
+// leafIterator creates an iterator over the leaves of the trie, or returns nil
+// for a nil trie.
+func leafIterator(tr Trie) (*trie.Iterator, error) {
+	if tr == nil {
+		return nil, nil
+	}
+	nodeIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		return nil, err
+	}
+	return trie.NewIterator(nodeIt), nil
+}
+
+// storageTrie opens the committed storage trie of an account, returning nil if
+// the account doesn't exist.
+func (s *StateDB) storageTrie(addr common.Address) (Trie, error) {
+	blob, err := s.trie.TryGet(addr[:])
+	if err != nil || len(blob) == 0 {
+		return nil, err
+	}
+	account, err := decodeAccount(blob)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.OpenStorageTrie(s.originalRoot, crypto.Keccak256Hash(addr[:]), account.Root)
+}
+
+// addressPreimage returns the address belonging to an account hash, or nil if
+// the preimage is unknown.
+func (s *StateDB) addressPreimage(hash []byte) *common.Address {
+	if preimage := s.trie.GetKey(hash); preimage != nil {
+		addr := common.BytesToAddress(preimage)
+		return &addr
+	}
+	return nil
+}
+
+// slotPreimage returns the storage key belonging to a slot hash, or nil if the
+// preimage is unknown.
+func (s *StateDB) slotPreimage(hash []byte) *common.Hash {
+	if preimage := s.trie.GetKey(hash); preimage != nil {
+		key := common.BytesToHash(preimage)
+		return &key
+	}
+	return nil
+}
+
+func decodeAccount(blob []byte) (*types.StateAccount, error) {
+	account := new(types.StateAccount)
+	if err := rlp.DecodeBytes(blob, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func decodeSlot(blob []byte) (common.Hash, error) {
+	_, content, _, err := rlp.Split(blob)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(content), nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the accounts and slots are iterated in ascending order of their
+// hashes and that the differences of two states are reported in the same order.
+func TestIterateAndDiff(t *testing.T) {
+	var (
+		db       = rawdb.NewMemoryDatabase()
+		sdb      = NewDatabaseWithConfig(db, &trie.Config{Preimages: true})
+		contract = common.Address{0xff}
+	)
+	state, _ := New(types.EmptyRootHash, sdb, nil)
+	state.SetBalance(contract, big.NewInt(1))
+	for i := 0; i < 16; i++ {
+		state.SetBalance(common.Address{byte(i)}, big.NewInt(int64(i+1)))
+		state.SetState(contract, common.Hash{byte(i)}, common.Hash{byte(i + 1)})
+	}
+	root, _ := state.Commit(0, false)
+
+	// Change, delete and create some accounts and slots
+	state, _ = New(root, sdb, nil)
+	state.SetBalance(common.Address{1}, big.NewInt(100))
+	state.SelfDestruct(common.Address{2})
+	state.SetBalance(common.Address{0x20}, big.NewInt(1))
+	state.SetState(contract, common.Hash{3}, common.Hash{})
+	state.SetState(contract, common.Hash{4}, common.Hash{0xaa})
+	next, _ := state.Commit(0, true)
+
+	from, _ := New(root, sdb, nil)
+	to, _ := New(next, sdb, nil)
+
+	var (
+		last     []byte
+		accounts int
+	)
+	err := from.IterateAccounts(common.Hash{}, func(hash common.Hash, addr *common.Address, account *types.StateAccount) error {
+		if bytes.Compare(hash[:], last) <= 0 {
+			t.Errorf("account %x out of order", hash)
+		}
+		if addr == nil {
+			t.Errorf("account %x missing address preimage", hash)
+		}
+		last, accounts = common.CopyBytes(hash[:]), accounts+1
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate accounts: %v", err)
+	}
+	if accounts != 17 {
+		t.Fatalf("account count mismatch: have %d, want %d", accounts, 17)
+	}
+	var slots int
+	last = nil
+	err = from.IterateStorage(contract, common.Hash{}, func(hash common.Hash, key *common.Hash, value common.Hash) error {
+		if bytes.Compare(hash[:], last) <= 0 {
+			t.Errorf("slot %x out of order", hash)
+		}
+		if key == nil || value != (common.Hash{key[0] + 1}) {
+			t.Errorf("slot %x value mismatch: %x", hash, value)
+		}
+		last, slots = common.CopyBytes(hash[:]), slots+1
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate storage: %v", err)
+	}
+	if slots != 16 {
+		t.Fatalf("slot count mismatch: have %d, want %d", slots, 16)
+	}
+	// The modified, deleted and created accounts are reported, along with the
+	// contract whose storage root changed
+	changed := make(map[common.Address][2]*types.StateAccount)
+	err = DiffAccounts(from, to, func(hash common.Hash, addr *common.Address, before, after *types.StateAccount) error {
+		changed[*addr] = [2]*types.StateAccount{before, after}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to diff accounts: %v", err)
+	}
+	if len(changed) != 4 {
+		t.Fatalf("changed account count mismatch: have %d, want %d", len(changed), 4)
+	}
+	if diff := changed[common.Address{2}]; diff[0] == nil || diff[1] != nil {
+		t.Errorf("deleted account mismatch: %v", diff)
+	}
+	if diff := changed[common.Address{0x20}]; diff[0] != nil || diff[1] == nil {
+		t.Errorf("created account mismatch: %v", diff)
+	}
+	if diff := changed[common.Address{1}]; diff[1] == nil || diff[1].Balance.Int64() != 100 {
+		t.Errorf("modified account mismatch: %v", diff)
+	}
+	changedSlots := make(map[common.Hash][2]common.Hash)
+	err = DiffStorage(from, to, contract, func(hash common.Hash, key *common.Hash, before, after common.Hash) error {
+		changedSlots[*key] = [2]common.Hash{before, after}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to diff storage: %v", err)
+	}
+	if len(changedSlots) != 2 {
+		t.Fatalf("changed slot count mismatch: have %d, want %d", len(changedSlots), 2)
+	}
+	if diff := changedSlots[common.Hash{3}]; diff != [2]common.Hash{{4}, {}} {
+		t.Errorf("deleted slot mismatch: %x", diff)
+	}
+	if diff := changedSlots[common.Hash{4}]; diff != [2]common.Hash{{5}, {0xaa}} {
+		t.Errorf("modified slot mismatch: %x", diff)
+	}
+}
@@ -90,7 +90,7 @@ func NewPruner(db ethdb.Database, datadir string, bloomSize uint64) (*Pruner, er
 	}
 	// Offline pruning is only supported in legacy hash based scheme.
 	triedb := trie.NewDatabase(db, trie.HashDefaults)
-	snaptree, err := snapshot.New(db, triedb, 256, headBlock.Root(), false, false, false)
+	snaptree, err := snapshot.New(db, triedb, snapshot.CacheConfig{Size: 256}, headBlock.Root(), false, false, false)
 	if err != nil {
 		return nil, err // The relevant snapshot(s) might not exist
 	}
@@ -359,7 +359,7 @@ func RecoverPruning(datadir string, db ethdb.Database) error {
 	// still feasible to recover the pruning correctly.
 	// Offline pruning is only supported in legacy hash based scheme.
 	triedb := trie.NewDatabase(db, trie.HashDefaults)
-	snaptree, err := snapshot.New(db, triedb, 256, headBlock.Root(), false, false, true)
+	snaptree, err := snapshot.New(db, triedb, snapshot.CacheConfig{Size: 256}, headBlock.Root(), false, false, true)
 	if err != nil {
 		return err // The relevant snapshot(s) might not exist
 	}
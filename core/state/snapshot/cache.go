@@ -0,0 +1,244 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// minAccountCacheRatio and maxAccountCacheRatio bound the share of the clean
+	// cache the rebalancing may give to the accounts.
+	minAccountCacheRatio = 0.1
+	maxAccountCacheRatio = 0.9
+
+	// cacheRebalanceStep is the share of the clean cache moved between the
+	// partitions by a single rebalancing.
+	cacheRebalanceStep = 0.05
+
+	// cacheRebalanceInterval is the number of lookups between the evaluations
+	// of the partition hit rates.
+	cacheRebalanceInterval = 1 << 20
+
+	// cacheRebalanceThreshold is the minimum difference between the hit rates
+	// of the partitions to trigger a rebalancing.
+	cacheRebalanceThreshold = 0.05
+)
+
+var snapshotCleanAccountRatioGauge = metrics.NewRegisteredGaugeFloat64("state/snapshot/clean/account/ratio", nil)
+
+// CacheConfig is the configuration of the clean cache of the disk layer.
+type CacheConfig struct {
+	Size         int     // Memory allowance (MB) of the cache
+	AccountRatio float64 // Share of the cache reserved for accounts, 0 for a cache shared with storage
+	Rebalance    bool    // Whether to move the partition toward the data with the lower hit rate
+}
+
+// cleanCache is the clean cache of the disk layer, keyed by the account hash
+// for accounts and the concatenated account and slot hashes for storage.
+type cleanCache interface {
+	HasGet(dst, k []byte) ([]byte, bool)
+	Set(k, v []byte)
+	Del(k []byte)
+	Reset()
+}
+
+// newCleanCache creates the clean cache described by the config, either a
+// single cache shared by accounts and storage or a partitioned one.
+func newCleanCache(config CacheConfig) cleanCache {
+	size := config.Size * 1024 * 1024
+	if config.AccountRatio <= 0 || config.AccountRatio >= 1 {
+		return fastcache.New(size)
+	}
+	c := &partitionedCache{size: size, ratio: config.AccountRatio, rebalance: config.Rebalance}
+	c.accounts.cache = fastcache.New(c.accountSize())
+	c.storage.cache = fastcache.New(size - c.accountSize())
+	snapshotCleanAccountRatioGauge.Update(c.ratio)
+	return c
+}
+
+// cachePartition is a part of the clean cache holding a single kind of data.
+type cachePartition struct {
+	cache  *fastcache.Cache
+	retire *fastcache.Cache // Cache replaced by a resize, consulted on misses until the next rebalancing
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// hasGet looks a key up in the partition, promoting the entries found in the
+// retired cache.
+func (p *cachePartition) hasGet(dst, k []byte) ([]byte, bool) {
+	if blob, found := p.cache.HasGet(dst, k); found {
+		p.hits.Add(1)
+		return blob, true
+	}
+	if p.retire != nil {
+		if blob, found := p.retire.HasGet(dst, k); found {
+			p.cache.Set(k, blob[len(dst):])
+			p.hits.Add(1)
+			return blob, true
+		}
+	}
+	p.misses.Add(1)
+	return dst, false
+}
+
+func (p *cachePartition) set(k, v []byte) {
+	p.cache.Set(k, v)
+	if p.retire != nil {
+		p.retire.Del(k) // Stale once the new entry is evicted
+	}
+}
+
+func (p *cachePartition) del(k []byte) {
+	p.cache.Del(k)
+	if p.retire != nil {
+		p.retire.Del(k)
+	}
+}
+
+// hitRate returns the hit rate of the partition since the last call, resetting
+// the counters.
+func (p *cachePartition) hitRate() (float64, uint64) {
+	hits, misses := p.hits.Swap(0), p.misses.Swap(0)
+	if hits+misses == 0 {
+		return 0, 0
+	}
+	return float64(hits) / float64(hits+misses), hits + misses
+}
+
+// partitionedCache is a clean cache split into separately sized account and
+// storage partitions, so that a storage heavy workload can't evict the accounts.
+// The split is optionally rebalanced toward the partition with the lower hit
+// rate, one step at a time.
+//
+// A resized partition starts empty, keeping the replaced cache around as a
+// fallback until the next rebalancing, so the hot entries are carried over.
+type partitionedCache struct {
+	size      int
+	ratio     float64 // Share of the cache given to the accounts
+	rebalance bool
+
+	accounts cachePartition
+	storage  cachePartition
+	lookups  atomic.Uint64
+
+	balancing atomic.Bool  // Whether a rebalancing is in progress
+	lock      sync.RWMutex // Protects the partition caches against resizes
+}
+
+func (c *partitionedCache) accountSize() int {
+	return int(float64(c.size) * c.ratio)
+}
+
+func (c *partitionedCache) partition(k []byte) *cachePartition {
+	if len(k) == common.HashLength {
+		return &c.accounts
+	}
+	return &c.storage
+}
+
+// HasGet implements cleanCache, looking the key up in its partition.
+func (c *partitionedCache) HasGet(dst, k []byte) ([]byte, bool) {
+	c.lock.RLock()
+	blob, found := c.partition(k).hasGet(dst, k)
+	c.lock.RUnlock()
+
+	if c.rebalance && c.lookups.Add(1)%cacheRebalanceInterval == 0 {
+		c.balance()
+	}
+	return blob, found
+}
+
+// Set implements cleanCache, storing the entry in its partition.
+func (c *partitionedCache) Set(k, v []byte) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	c.partition(k).set(k, v)
+}
+
+// Del implements cleanCache, deleting the entry from its partition.
+func (c *partitionedCache) Del(k []byte) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	c.partition(k).del(k)
+}
+
+// Reset implements cleanCache, dropping the entries of both partitions.
+func (c *partitionedCache) Reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, p := range []*cachePartition{&c.accounts, &c.storage} {
+		p.cache.Reset()
+		if p.retire != nil {
+			p.retire.Reset()
+			p.retire = nil
+		}
+	}
+}
+
+// balance compares the hit rates of the partitions since the last evaluation,
+// moving a step of the cache toward the one with the lower rate if they differ
+// significantly.
+func (c *partitionedCache) balance() {
+	if !c.balancing.CompareAndSwap(false, true) {
+		return // Another lookup is rebalancing
+	}
+	defer c.balancing.Store(false)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	accountRate, accountLookups := c.accounts.hitRate()
+	storageRate, storageLookups := c.storage.hitRate()
+
+	// The previous resize had a full interval to carry the hot entries over
+	for _, p := range []*cachePartition{&c.accounts, &c.storage} {
+		if p.retire != nil {
+			p.retire.Reset()
+			p.retire = nil
+		}
+	}
+	if accountLookups == 0 || storageLookups == 0 || math.Abs(accountRate-storageRate) < cacheRebalanceThreshold {
+		return
+	}
+	ratio := c.ratio + cacheRebalanceStep
+	if accountRate > storageRate {
+		ratio = c.ratio - cacheRebalanceStep
+	}
+	ratio = math.Max(minAccountCacheRatio, math.Min(maxAccountCacheRatio, ratio))
+	if ratio == c.ratio {
+		return
+	}
+	log.Debug("Rebalancing snapshot clean cache", "accounts", accountRate, "storage", storageRate, "ratio", c.ratio, "next", ratio)
+
+	c.ratio = ratio
+	c.accounts.retire, c.accounts.cache = c.accounts.cache, fastcache.New(c.accountSize())
+	c.storage.retire, c.storage.cache = c.storage.cache, fastcache.New(c.size-c.accountSize())
+	snapshotCleanAccountRatioGauge.Update(ratio)
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that the clean cache is only partitioned when an account ratio is set,
+// and that the partitions are rebalanced toward the lower hit rate.
+func TestPartitionedCache(t *testing.T) {
+	if _, ok := newCleanCache(CacheConfig{Size: 16}).(*fastcache.Cache); !ok {
+		t.Fatal("unpartitioned cache expected without account ratio")
+	}
+	c, ok := newCleanCache(CacheConfig{Size: 64, AccountRatio: 0.5, Rebalance: true}).(*partitionedCache)
+	if !ok {
+		t.Fatal("partitioned cache expected with account ratio")
+	}
+	var (
+		account = common.Hash{0x01}
+		slot    = append(common.Hash{0x01}.Bytes(), common.Hash{0x02}.Bytes()...)
+		stale   = append(common.Hash{0x01}.Bytes(), common.Hash{0x03}.Bytes()...)
+	)
+	c.Set(account[:], []byte{0xaa})
+	c.Set(slot, []byte{0xbb})
+	c.Set(stale, []byte{0xcc})
+
+	if blob, found := c.accounts.cache.HasGet(nil, account[:]); !found || !bytes.Equal(blob, []byte{0xaa}) {
+		t.Fatalf("account missing from account partition: %x", blob)
+	}
+	if blob, found := c.storage.cache.HasGet(nil, slot); !found || !bytes.Equal(blob, []byte{0xbb}) {
+		t.Fatalf("slot missing from storage partition: %x", blob)
+	}
+	// Hit the accounts and miss the storage, shrinking the account partition
+	for i := 0; i < 100; i++ {
+		c.HasGet(nil, account[:])
+		c.HasGet(nil, append(common.Hash{0x01}.Bytes(), common.Hash{byte(i), 0xff}.Bytes()...))
+	}
+	c.HasGet(nil, slot)
+	c.balance()
+	if want := 0.5 - cacheRebalanceStep; c.ratio != want {
+		t.Fatalf("account ratio mismatch: have %v, want %v", c.ratio, want)
+	}
+	// The resized partitions carry the entries over on access
+	if blob, found := c.HasGet(nil, account[:]); !found || !bytes.Equal(blob, []byte{0xaa}) {
+		t.Fatalf("account lost by resize: %x", blob)
+	}
+	if blob, found := c.HasGet(nil, slot); !found || !bytes.Equal(blob, []byte{0xbb}) {
+		t.Fatalf("slot lost by resize: %x", blob)
+	}
+	// Deletions must reach the retired caches as well
+	c.Del(stale)
+	if _, found := c.HasGet(nil, stale); found {
+		t.Fatal("deleted slot still cached")
+	}
+	// Balanced hit rates leave the ratio alone, dropping the retired caches
+	c.HasGet(nil, common.Hash{0x09}.Bytes())
+	c.balance()
+	if want := 0.5 - cacheRebalanceStep; c.ratio != want {
+		t.Fatalf("account ratio mismatch: have %v, want %v", c.ratio, want)
+	}
+	if c.accounts.retire != nil || c.storage.retire != nil {
+		t.Fatal("retired caches kept")
+	}
+	if _, found := c.HasGet(nil, account[:]); !found {
+		t.Fatal("promoted account lost")
+	}
+	// The ratio never leaves its bounds
+	for i := 0; i < 20; i++ {
+		c.HasGet(nil, account[:])
+		c.HasGet(nil, stale)
+		c.balance()
+	}
+	if c.ratio != minAccountCacheRatio {
+		t.Fatalf("account ratio mismatch: have %v, want %v", c.ratio, minAccountCacheRatio)
+	}
+}
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/VictoriaMetrics/fastcache"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -75,6 +74,11 @@ var (
 	snapSuccessfulRangeProofMeter = metrics.NewRegisteredMeter("state/snapshot/generation/proof/success", nil)
 	snapFailedRangeProofMeter     = metrics.NewRegisteredMeter("state/snapshot/generation/proof/failure", nil)
 
+	snapGenerationAccountGauge = metrics.NewRegisteredGauge("state/snapshot/generation/progress/accounts", nil)
+	snapGenerationSlotGauge    = metrics.NewRegisteredGauge("state/snapshot/generation/progress/slots", nil)
+	snapGenerationStorageGauge = metrics.NewRegisteredGauge("state/snapshot/generation/progress/storage", nil)
+	snapGenerationETAGauge     = metrics.NewRegisteredGauge("state/snapshot/generation/progress/eta", nil)
+
 	// snapAccountProveCounter measures time spent on the account proving
 	snapAccountProveCounter = metrics.NewRegisteredCounter("state/snapshot/generation/duration/account/prove", nil)
 	// snapAccountTrieReadCounter measures time spent on the account trie iteration
@@ -128,23 +132,50 @@ func (gs *generatorStats) Log(msg string, root common.Hash, marker []byte) {
 		"elapsed", common.PrettyDuration(time.Since(gs.start)),
 	}...)
 	// Calculate the estimated indexing time based on current stats
-	if len(marker) > 0 {
-		if done := binary.BigEndian.Uint64(marker[:8]) - gs.origin; done > 0 {
-			left := math.MaxUint64 - binary.BigEndian.Uint64(marker[:8])
-
-			speed := done/uint64(time.Since(gs.start)/time.Millisecond+1) + 1 // +1s to avoid division by zero
-			ctx = append(ctx, []interface{}{
-				"eta", common.PrettyDuration(time.Duration(left/speed) * time.Millisecond),
-			}...)
-		}
+	if eta, ok := gs.eta(marker); ok {
+		ctx = append(ctx, []interface{}{
+			"eta", common.PrettyDuration(eta),
+		}...)
 	}
 	log.Info(msg, ctx...)
 }
 
+// eta estimates the time needed to index the rest of the state after the given
+// marker, based on the progress made since the generation started.
+func (gs *generatorStats) eta(marker []byte) (time.Duration, bool) {
+	if len(marker) < 8 {
+		return 0, false
+	}
+	done := binary.BigEndian.Uint64(marker[:8]) - gs.origin
+	if done == 0 {
+		return 0, false
+	}
+	left := math.MaxUint64 - binary.BigEndian.Uint64(marker[:8])
+
+	speed := done/uint64(time.Since(gs.start)/time.Millisecond+1) + 1 // +1s to avoid division by zero
+	return time.Duration(left/speed) * time.Millisecond, true
+}
+
+// publishProgress exposes the current generator marker and statistics to
+// progress queries and metrics. The caller must hold the layer's lock.
+func (dl *diskLayer) publishProgress(marker []byte, stats *generatorStats) {
+	dl.genMarker = marker
+	dl.genStats = *stats
+
+	snapGenerationAccountGauge.Update(int64(stats.accounts))
+	snapGenerationSlotGauge.Update(int64(stats.slots))
+	snapGenerationStorageGauge.Update(int64(stats.storage))
+	if eta, ok := stats.eta(marker); ok {
+		snapGenerationETAGauge.Update(int64(eta / time.Second))
+	} else if marker == nil {
+		snapGenerationETAGauge.Update(0)
+	}
+}
+
 // generateSnapshot regenerates a brand new snapshot based on an existing state
 // database and head block asynchronously. The snapshot is returned immediately
 // and generation is continued in the background until done.
-func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache int, root common.Hash) *diskLayer {
+func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache CacheConfig, root common.Hash) *diskLayer {
 	// Create a new disk layer with an initialized state marker at zero
 	var (
 		stats     = &generatorStats{start: time.Now()}
@@ -160,8 +191,9 @@ func generateSnapshot(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache i
 		diskdb:     diskdb,
 		triedb:     triedb,
 		root:       root,
-		cache:      fastcache.New(cache * 1024 * 1024),
+		cache:      newCleanCache(cache),
 		genMarker:  genMarker,
+		genStats:   *stats,
 		genPending: make(chan struct{}),
 		genAbort:   make(chan chan *generatorStats),
 	}
@@ -587,7 +619,7 @@ func (dl *diskLayer) generate(stats *generatorStats) {
 			batch.Reset()
 
 			dl.lock.Lock()
-			dl.genMarker = currentLocation
+			dl.publishProgress(currentLocation, stats)
 			dl.lock.Unlock()
 
 			if abort != nil {
@@ -757,7 +789,7 @@ func (dl *diskLayer) generate(stats *generatorStats) {
 		"storage", stats.storage, "elapsed", common.PrettyDuration(time.Since(stats.start)))
 
 	dl.lock.Lock()
-	dl.genMarker = nil
+	dl.publishProgress(nil, stats)
 	close(dl.genPending)
 	dl.lock.Unlock()
 
@@ -84,6 +84,49 @@ func testGeneration(t *testing.T, scheme string) {
 	<-stop
 }
 
+// Tests that the progress of the snapshot generation is reported, and that its
+// completion is signalled.
+func TestGenerationStatus(t *testing.T) {
+	var helper = newHelper(rawdb.HashScheme)
+	stRoot := helper.makeStorageTrie(common.Hash{}, common.Hash{}, []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, false)
+
+	helper.addTrieAccount("acc-1", &types.StateAccount{Balance: big.NewInt(1), Root: stRoot, CodeHash: emptyCode.Bytes()})
+	helper.addTrieAccount("acc-2", &types.StateAccount{Balance: big.NewInt(2), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+	helper.addTrieAccount("acc-3", &types.StateAccount{Balance: big.NewInt(3), Root: stRoot, CodeHash: emptyCode.Bytes()})
+
+	helper.makeStorageTrie(common.Hash{}, hashData([]byte("acc-1")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+	helper.makeStorageTrie(common.Hash{}, hashData([]byte("acc-3")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+
+	root, snap := helper.CommitAndGenerate()
+	tree := &Tree{layers: map[common.Hash]snapshot{root: snap}}
+
+	status, done, err := tree.GenerationStatus()
+	if err != nil {
+		t.Fatalf("failed to retrieve generation status: %v", err)
+	}
+	if status.Root != root {
+		t.Fatalf("root mismatch: have %x, want %x", status.Root, root)
+	}
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("snapshot generation not signalled as done")
+	}
+	if status, _, err = tree.GenerationStatus(); err != nil {
+		t.Fatalf("failed to retrieve generation status: %v", err)
+	}
+	if !status.Done || status.Marker != nil || status.ETA != 0 {
+		t.Fatalf("generation not reported as done: %+v", status)
+	}
+	if status.Accounts != 3 || status.Slots != 6 {
+		t.Fatalf("progress mismatch: have %d accounts and %d slots, want 3 and 6", status.Accounts, status.Slots)
+	}
+	// Signal abortion to the generator and wait for it to tear down
+	stop := make(chan *generatorStats)
+	snap.genAbort <- stop
+	<-stop
+}
+
 // Tests that snapshot generation with existent flat state.
 func TestGenerateExistentState(t *testing.T) {
 	testGenerateExistentState(t, rawdb.HashScheme)
@@ -223,7 +266,7 @@ func (t *testHelper) Commit() common.Hash {
 
 func (t *testHelper) CommitAndGenerate() (common.Hash, *diskLayer) {
 	root := t.Commit()
-	snap := generateSnapshot(t.diskdb, t.triedb, 16, root)
+	snap := generateSnapshot(t.diskdb, t.triedb, CacheConfig{Size: 16}, root)
 	return root, snap
 }
 
@@ -427,7 +470,7 @@ func testGenerateCorruptAccountTrie(t *testing.T, scheme string) {
 
 	rawdb.DeleteTrieNode(helper.diskdb, common.Hash{}, targetPath, targetHash, scheme)
 
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, root)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, CacheConfig{Size: 16}, root)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -471,7 +514,7 @@ func testGenerateMissingStorageTrie(t *testing.T, scheme string) {
 	rawdb.DeleteTrieNode(helper.diskdb, acc1, nil, stRoot, scheme)
 	rawdb.DeleteTrieNode(helper.diskdb, acc3, nil, stRoot, scheme)
 
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, root)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, CacheConfig{Size: 16}, root)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -513,7 +556,7 @@ func testGenerateCorruptStorageTrie(t *testing.T, scheme string) {
 	rawdb.DeleteTrieNode(helper.diskdb, hashData([]byte("acc-1")), targetPath, targetHash, scheme)
 	rawdb.DeleteTrieNode(helper.diskdb, hashData([]byte("acc-3")), targetPath, targetHash, scheme)
 
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, root)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, CacheConfig{Size: 16}, root)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -577,7 +620,7 @@ func testGenerateWithExtraAccounts(t *testing.T, scheme string) {
 	if data := rawdb.ReadStorageSnapshot(helper.triedb.DiskDB(), hashData([]byte("acc-2")), hashData([]byte("b-key-1"))); data == nil {
 		t.Fatalf("expected snap storage to exist")
 	}
-	snap := generateSnapshot(helper.diskdb, helper.triedb, 16, root)
+	snap := generateSnapshot(helper.diskdb, helper.triedb, CacheConfig{Size: 16}, root)
 	select {
 	case <-snap.genPending:
 		// Snapshot generation succeeded
@@ -0,0 +1,217 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Mismatch is a snapshot entry deviating from the state trie.
+type Mismatch struct {
+	Account common.Hash  // Hash of the account the entry belongs to
+	Slot    *common.Hash // Hash of the storage slot, nil for account entries
+	Want    []byte       // Value in the trie, nil if the entry must not exist
+}
+
+// RepairResult is the outcome of a full walk of the persistent snapshot
+// against the state trie.
+type RepairResult struct {
+	Root       common.Hash // State root the snapshot was verified against
+	Accounts   uint64      // Number of accounts checked
+	Slots      uint64      // Number of storage slots checked
+	Mismatches []Mismatch  // Snapshot entries deviating from the trie
+	Repaired   bool        // Whether the mismatching entries were overwritten
+}
+
+// VerifyAndRepair walks the entire persistent snapshot layer against the state
+// trie of the given root, reporting every account and storage slot which is
+// wrong, missing or dangling in the snapshot. If repair is set, the mismatching
+// entries are overwritten with the trie values, instead of regenerating the
+// whole snapshot.
+//
+// The walk doesn't block the snapshot tree, the repairs are only applied if the
+// disk layer wasn't flattened into meanwhile.
+func (t *Tree) VerifyAndRepair(root common.Hash, repair bool) (*RepairResult, error) {
+	t.lock.RLock()
+	dl := t.disklayer()
+	t.lock.RUnlock()
+
+	if dl == nil {
+		return nil, errors.New("disk layer is missing")
+	}
+	if dl.root != root {
+		return nil, fmt.Errorf("root %x is not the snapshot disk layer %x", root, dl.root)
+	}
+	dl.lock.RLock()
+	generating := dl.genMarker != nil
+	dl.lock.RUnlock()
+
+	if generating {
+		return nil, ErrNotConstructed
+	}
+	tr, err := trie.New(trie.StateTrieID(root), t.triedb)
+	if err != nil {
+		return nil, err
+	}
+	result := &RepairResult{Root: root}
+	if err := t.verifyAccounts(dl, tr, result); err != nil {
+		return nil, err
+	}
+	if dl.Stale() {
+		return nil, ErrSnapshotStale
+	}
+	if !repair || len(result.Mismatches) == 0 {
+		return result, nil
+	}
+	// Prevent the disk layer from being flattened into while it's being repaired
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if dl.Stale() {
+		return nil, ErrSnapshotStale
+	}
+	for _, mismatch := range result.Mismatches {
+		if mismatch.Slot == nil {
+			dl.repairAccount(mismatch.Account, mismatch.Want)
+		} else {
+			dl.repairStorage(mismatch.Account, *mismatch.Slot, mismatch.Want)
+		}
+	}
+	result.Repaired = true
+	log.Info("Repaired snapshot entries mismatching the trie", "root", root, "count", len(result.Mismatches))
+	return result, nil
+}
+
+// verifyAccounts cross-checks all accounts of the snapshot and the trie, along
+// with their storage.
+func (t *Tree) verifyAccounts(dl *diskLayer, tr *trie.Trie, result *RepairResult) error {
+	nodeIt, err := tr.NodeIterator(nil)
+	if err != nil {
+		return err
+	}
+	snapIt := dl.AccountIterator(common.Hash{})
+	defer snapIt.Release()
+
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	return diffSorted(trie.NewIterator(nodeIt), snapIt, snapIt.Account, func(hash common.Hash, have, blob []byte) error {
+		var (
+			want    []byte
+			stRoot  = types.EmptyRootHash
+			account types.StateAccount
+		)
+		if blob != nil {
+			if err := rlp.DecodeBytes(blob, &account); err != nil {
+				return err
+			}
+			want, stRoot = types.SlimAccountRLP(account), account.Root
+		}
+		result.Accounts++
+		verifyAccountMeter.Mark(1)
+
+		if !bytes.Equal(have, want) {
+			log.Warn("Snapshot account mismatches the trie", "root", dl.root, "account", hash, "dangling", want == nil)
+			result.Mismatches = append(result.Mismatches, Mismatch{Account: hash, Want: want})
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Verifying snapshot against the trie", "at", hash, "accounts", result.Accounts, "slots", result.Slots,
+				"mismatches", len(result.Mismatches), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		return t.verifyStorages(dl, hash, stRoot, result)
+	})
+}
+
+// verifyStorages cross-checks all storage slots of an account in the snapshot
+// and the trie.
+func (t *Tree) verifyStorages(dl *diskLayer, account common.Hash, stRoot common.Hash, result *RepairResult) error {
+	var trieIt *trie.Iterator
+	if stRoot != types.EmptyRootHash {
+		tr, err := trie.New(trie.StorageTrieID(dl.root, account, stRoot), t.triedb)
+		if err != nil {
+			return err
+		}
+		nodeIt, err := tr.NodeIterator(nil)
+		if err != nil {
+			return err
+		}
+		trieIt = trie.NewIterator(nodeIt)
+	}
+	snapIt, _ := dl.StorageIterator(account, common.Hash{})
+	defer snapIt.Release()
+
+	return diffSorted(trieIt, snapIt, snapIt.Slot, func(hash common.Hash, have, want []byte) error {
+		result.Slots++
+		verifyStorageMeter.Mark(1)
+
+		if !bytes.Equal(have, want) {
+			log.Warn("Snapshot storage mismatches the trie", "root", dl.root, "account", account, "slot", hash, "dangling", want == nil)
+			slot := hash
+			result.Mismatches = append(result.Mismatches, Mismatch{Account: account, Slot: &slot, Want: want})
+		}
+		return nil
+	})
+}
+
+// diffSorted merge-walks the leaves of a trie and the entries of a snapshot,
+// both sorted by hash, invoking the callback with the snapshot and trie values
+// of every hash present in either of them. The value missing from one side is
+// nil. A nil trie iterator stands for an empty trie.
+func diffSorted(trieIt *trie.Iterator, snapIt Iterator, snapValue func() []byte, fn func(hash common.Hash, have, want []byte) error) error {
+	trieNext := func() bool { return trieIt != nil && trieIt.Next() }
+
+	hasTrie, hasSnap := trieNext(), snapIt.Next()
+	for hasTrie || hasSnap {
+		var (
+			hash       common.Hash
+			have, want []byte
+		)
+		switch {
+		case !hasSnap || (hasTrie && bytes.Compare(trieIt.Key, snapIt.Hash().Bytes()) < 0):
+			// Entry missing from the snapshot
+			hash, want = common.BytesToHash(trieIt.Key), common.CopyBytes(trieIt.Value)
+			hasTrie = trieNext()
+
+		case !hasTrie || bytes.Compare(trieIt.Key, snapIt.Hash().Bytes()) > 0:
+			// Entry dangling in the snapshot
+			hash, have = snapIt.Hash(), common.CopyBytes(snapValue())
+			hasSnap = snapIt.Next()
+
+		default:
+			hash, have, want = snapIt.Hash(), common.CopyBytes(snapValue()), common.CopyBytes(trieIt.Value)
+			hasTrie, hasSnap = trieNext(), snapIt.Next()
+		}
+		if err := fn(hash, have, want); err != nil {
+			return err
+		}
+	}
+	if trieIt != nil && trieIt.Err != nil {
+		return trieIt.Err
+	}
+	return snapIt.Error()
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that walking the entire snapshot reports all wrong, missing and dangling
+// entries, and repairs them individually only if requested.
+func TestVerifyAndRepair(t *testing.T) {
+	helper := newHelper(rawdb.HashScheme)
+	stRoot := helper.makeStorageTrie(common.Hash{}, hashData([]byte("acc-1")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+	helper.addTrieAccount("acc-1", &types.StateAccount{Balance: big.NewInt(1), Root: stRoot, CodeHash: emptyCode.Bytes()})
+	helper.addTrieAccount("acc-2", &types.StateAccount{Balance: big.NewInt(2), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+	helper.addTrieAccount("acc-3", &types.StateAccount{Balance: big.NewInt(3), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+
+	root, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Snapshot generation failed")
+	}
+	stop := make(chan *generatorStats)
+	snap.genAbort <- stop
+	<-stop
+
+	tree := &Tree{
+		diskdb: helper.diskdb,
+		triedb: helper.triedb,
+		layers: map[common.Hash]snapshot{root: snap},
+	}
+	if _, err := tree.VerifyAndRepair(common.Hash{0x01}, false); err == nil {
+		t.Fatalf("verified a root other than the disk layer")
+	}
+	if result, err := tree.VerifyAndRepair(root, false); err != nil || len(result.Mismatches) != 0 {
+		t.Fatalf("consistent snapshot reported mismatches: %v, err %v", result, err)
+	}
+	// Corrupt the generated snapshot
+	helper.addSnapAccount("acc-2", &types.StateAccount{Balance: big.NewInt(20), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+	helper.addSnapAccount("acc-4", &types.StateAccount{Balance: big.NewInt(4), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+	helper.addSnapStorage("acc-4", []string{"key-1"}, []string{"val-1"})
+	rawdb.DeleteAccountSnapshot(helper.diskdb, hashData([]byte("acc-3")))
+	rawdb.DeleteStorageSnapshot(helper.diskdb, hashData([]byte("acc-1")), hashData([]byte("key-2")))
+	snap.cache.Reset()
+
+	// Verifying alone must report the corruptions but leave them in place
+	result, err := tree.VerifyAndRepair(root, false)
+	if err != nil {
+		t.Fatalf("failed to verify snapshot: %v", err)
+	}
+	if len(result.Mismatches) != 5 || result.Repaired {
+		t.Fatalf("verification result mismatch: have %d mismatches, repaired %v, want 5, false", len(result.Mismatches), result.Repaired)
+	}
+	if rawdb.ReadAccountSnapshot(helper.diskdb, hashData([]byte("acc-4"))) == nil {
+		t.Fatalf("dangling account removed without repair")
+	}
+	// Repairing must fix up exactly the reported entries
+	result, err = tree.VerifyAndRepair(root, true)
+	if err != nil {
+		t.Fatalf("failed to repair snapshot: %v", err)
+	}
+	if len(result.Mismatches) != 5 || !result.Repaired {
+		t.Fatalf("repair result mismatch: have %d mismatches, repaired %v, want 5, true", len(result.Mismatches), result.Repaired)
+	}
+	if result.Accounts != 4 || result.Slots != 4 {
+		t.Fatalf("checked entry count mismatch: have %d accounts, %d slots, want 4, 4", result.Accounts, result.Slots)
+	}
+	checkSnapRoot(t, snap, root)
+
+	if result, err := tree.VerifyAndRepair(root, false); err != nil || len(result.Mismatches) != 0 {
+		t.Fatalf("repaired snapshot reported mismatches: %v, err %v", result, err)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -161,10 +162,12 @@ type snapshot interface {
 type Tree struct {
 	diskdb ethdb.KeyValueStore      // Persistent database to store the snapshot
 	triedb *trie.Database           // In-memory cache to access the trie through
-	cache  int                      // Megabytes permitted to use for read caches
+	cache  CacheConfig              // Configuration of the disk layer read cache
 	layers map[common.Hash]snapshot // Collection of all known layers
 	lock   sync.RWMutex
 
+	speculations map[*Speculation]struct{} // Live speculations rooted at the layers
+
 	// Test hooks
 	onFlatten func() // Hook invoked when the bottom most diff layers are flattened
 }
@@ -184,7 +187,7 @@ type Tree struct {
 //     This case happens when the snapshot is 'ahead' of the state trie.
 //   - otherwise, the entire snapshot is considered invalid and will be recreated on
 //     a background thread.
-func New(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache int, root common.Hash, async bool, rebuild bool, recovery bool) (*Tree, error) {
+func New(diskdb ethdb.KeyValueStore, triedb *trie.Database, cache CacheConfig, root common.Hash, async bool, rebuild bool, recovery bool) (*Tree, error) {
 	// Create a new, empty snapshot tree
 	snap := &Tree{
 		diskdb: diskdb,
@@ -271,6 +274,7 @@ func (t *Tree) Disable() {
 		}
 	}
 	t.layers = map[common.Hash]snapshot{}
+	t.disposeStale(true)
 
 	// Delete all snapshot liveness information from the database
 	batch := t.diskdb.NewBatch()
@@ -397,6 +401,7 @@ func (t *Tree) Cap(root common.Hash, layers int) error {
 
 		// Replace the entire snapshot tree with the flat base
 		t.layers = map[common.Hash]snapshot{base.root: base}
+		t.disposeStale(false)
 		return nil
 	}
 	persisted := t.cap(diff, layers)
@@ -422,6 +427,8 @@ func (t *Tree) Cap(root common.Hash, layers int) error {
 			remove(root)
 		}
 	}
+	// Drop the speculations rooted at any layer flattened across
+	t.disposeStale(false)
 	// If the disk layer was modified, regenerate all the cumulative blooms
 	if persisted != nil {
 		var rebloom func(root common.Hash)
@@ -633,6 +640,7 @@ func diffToDisk(bottom *diffLayer) *diskLayer {
 		diskdb:     base.diskdb,
 		triedb:     base.triedb,
 		genMarker:  base.genMarker,
+		genStats:   base.genStats,
 		genPending: base.genPending,
 	}
 	// If snapshot generation hasn't finished yet, port over all the starts and
@@ -731,6 +739,7 @@ func (t *Tree) Rebuild(root common.Hash) {
 	t.layers = map[common.Hash]snapshot{
 		root: generateSnapshot(t.diskdb, t.triedb, t.cache, root),
 	}
+	t.disposeStale(true)
 }
 
 // AccountIterator creates a new account iterator for the specified root hash and
@@ -837,6 +846,54 @@ func (t *Tree) generating() (bool, error) {
 	return layer.genMarker != nil, nil
 }
 
+// GenerationStatus is the progress of the background generation of the snapshot
+// disk layer.
+type GenerationStatus struct {
+	Root     common.Hash        // State root the disk layer is generated for
+	Done     bool               // Whether the generation has finished
+	Marker   []byte             // Last persisted generator marker (account hash, optionally followed by a slot hash)
+	Accounts uint64             // Number of accounts indexed (generated or recovered)
+	Slots    uint64             // Number of storage slots indexed (generated or recovered)
+	Storage  common.StorageSize // Total account and storage slot size indexed
+	Started  time.Time          // Time the current generation run started
+	ETA      time.Duration      // Estimated time left until completion, zero if unknown
+}
+
+// GenerationStatus returns the progress of the disk layer generation, along with
+// a channel which is closed once the generation finishes. If the snapshot is
+// rebuilt in the meantime, the channel is never closed and the status should be
+// queried again.
+func (t *Tree) GenerationStatus() (*GenerationStatus, <-chan struct{}, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	layer := t.disklayer()
+	if layer == nil {
+		return nil, nil, errors.New("disk layer is missing")
+	}
+	layer.lock.RLock()
+	defer layer.lock.RUnlock()
+
+	status := &GenerationStatus{
+		Root:     layer.root,
+		Done:     layer.genMarker == nil,
+		Marker:   common.CopyBytes(layer.genMarker),
+		Accounts: layer.genStats.accounts,
+		Slots:    layer.genStats.slots,
+		Storage:  layer.genStats.storage,
+		Started:  layer.genStats.start,
+	}
+	if !status.Done {
+		status.ETA, _ = layer.genStats.eta(layer.genMarker)
+	}
+	done := layer.genPending
+	if done == nil {
+		done = make(chan struct{})
+		close(done)
+	}
+	return status, done, nil
+}
+
 // diskRoot is a external helper function to return the disk layer root.
 func (t *Tree) DiskRoot() common.Hash {
 	t.lock.Lock()
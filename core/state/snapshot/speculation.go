@@ -0,0 +1,159 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// errSpeculationDiscarded is returned if a discarded speculation is updated.
+var errSpeculationDiscarded = errors.New("speculation discarded")
+
+// Speculation is a branch of ephemeral diff layers rooted at a layer of the
+// tree, holding the state of blocks executed speculatively, e.g. the blocks of
+// a competing side chain or a candidate block being built.
+//
+// The ephemeral layers are never linked into the tree: they are invisible to
+// the other users of the tree and are neither flattened nor journaled. They are
+// all dropped at once when the speculation is discarded, or automatically as
+// soon as its base layer goes stale in the tree.
+type Speculation struct {
+	tree *Tree
+	base snapshot // Layer of the tree the speculation is rooted at
+
+	layers    map[common.Hash]*diffLayer // Ephemeral layers built on top of the base
+	discarded bool
+	lock      sync.RWMutex
+}
+
+// Speculate creates an empty speculation rooted at the layer of the given root.
+func (t *Tree) Speculate(root common.Hash) (*Speculation, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	base := t.layers[root]
+	if base == nil {
+		return nil, fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	if base.Stale() {
+		return nil, ErrSnapshotStale
+	}
+	spec := &Speculation{
+		tree:   t,
+		base:   base,
+		layers: make(map[common.Hash]*diffLayer),
+	}
+	if t.speculations == nil {
+		t.speculations = make(map[*Speculation]struct{})
+	}
+	t.speculations[spec] = struct{}{}
+	return spec, nil
+}
+
+// Root returns the root of the tree layer the speculation is rooted at.
+func (s *Speculation) Root() common.Hash {
+	return s.base.Root()
+}
+
+// Snapshot retrieves the ephemeral layer of the given root, or the base layer
+// if the root is the one of the base. Nil is returned if no such layer exists
+// or the speculation was discarded.
+func (s *Speculation) Snapshot(root common.Hash) Snapshot {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.discarded {
+		return nil
+	}
+	if root == s.base.Root() {
+		return s.base
+	}
+	if layer, ok := s.layers[root]; ok {
+		return layer
+	}
+	return nil
+}
+
+// Update adds a new ephemeral layer on top of the base layer or another
+// ephemeral layer of the speculation.
+//
+// Note, the maps are retained by the method to avoid copying everything.
+func (s *Speculation) Update(blockRoot common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	if blockRoot == parentRoot {
+		return errSnapshotCycle
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.discarded {
+		return errSpeculationDiscarded
+	}
+	var parent snapshot
+	if parentRoot == s.base.Root() {
+		parent = s.base
+	} else if layer, ok := s.layers[parentRoot]; ok {
+		parent = layer
+	} else {
+		return fmt.Errorf("parent [%#x] snapshot missing", parentRoot)
+	}
+	if parent.Stale() {
+		return ErrSnapshotStale
+	}
+	s.layers[blockRoot] = parent.Update(blockRoot, destructs, accounts, storage)
+	return nil
+}
+
+// Discard drops all the ephemeral layers of the speculation, which must not be
+// used afterwards.
+func (s *Speculation) Discard() {
+	s.tree.lock.Lock()
+	delete(s.tree.speculations, s)
+	s.tree.lock.Unlock()
+
+	s.dispose()
+}
+
+// dispose marks all the ephemeral layers stale and drops them.
+func (s *Speculation) dispose() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, layer := range s.layers {
+		layer.lock.Lock()
+		atomic.StoreUint32(&layer.stale, 1)
+		layer.lock.Unlock()
+	}
+	s.layers = nil
+	s.discarded = true
+}
+
+// disposeStale discards the speculations whose base layer went stale or was
+// dropped from the tree, or all of them if requested. The caller must hold the
+// tree lock.
+func (t *Tree) disposeStale(all bool) {
+	for spec := range t.speculations {
+		if all || spec.base.Stale() || t.layers[spec.base.Root()] != spec.base {
+			delete(t.speculations, spec)
+			spec.dispose()
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/VictoriaMetrics/fastcache"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// Tests that the ephemeral layers of a speculation are readable through, kept
+// out of the tree and dropped once their base layer is flattened across.
+func TestSpeculation(t *testing.T) {
+	base := &diskLayer{
+		diskdb: rawdb.NewMemoryDatabase(),
+		root:   common.HexToHash("0x01"),
+		cache:  fastcache.New(1024 * 500),
+	}
+	snaps := &Tree{
+		layers: map[common.Hash]snapshot{
+			base.root: base,
+		},
+	}
+	if err := snaps.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), nil, randomAccountSet("0xa1"), nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	if err := snaps.Update(common.HexToHash("0x03"), common.HexToHash("0x02"), nil, randomAccountSet("0xa2"), nil); err != nil {
+		t.Fatalf("failed to create a diff layer: %v", err)
+	}
+	if _, err := snaps.Speculate(common.HexToHash("0xff")); err == nil {
+		t.Fatal("speculation rooted at a missing layer")
+	}
+	spec, err := snaps.Speculate(common.HexToHash("0x02"))
+	if err != nil {
+		t.Fatalf("failed to create speculation: %v", err)
+	}
+	// Build a side branch of two ephemeral layers
+	side := randomAccountSet("0xa3")
+	if err := spec.Update(common.HexToHash("0x12"), common.HexToHash("0x02"), nil, side, nil); err != nil {
+		t.Fatalf("failed to create an ephemeral layer: %v", err)
+	}
+	if err := spec.Update(common.HexToHash("0x13"), common.HexToHash("0x12"), nil, randomAccountSet("0xa4"), nil); err != nil {
+		t.Fatalf("failed to create an ephemeral layer: %v", err)
+	}
+	if err := spec.Update(common.HexToHash("0x14"), common.HexToHash("0x03"), nil, nil, nil); err == nil {
+		t.Fatal("ephemeral layer created on a layer outside the speculation")
+	}
+	if n := len(snaps.layers); n != 3 {
+		t.Errorf("tree layer count mismatch: have %d, want %d", n, 3)
+	}
+	if snaps.Snapshot(common.HexToHash("0x13")) != nil {
+		t.Error("ephemeral layer visible in the tree")
+	}
+	head := spec.Snapshot(common.HexToHash("0x13"))
+	if head == nil {
+		t.Fatal("ephemeral layer missing")
+	}
+	if blob, err := head.AccountRLP(common.HexToHash("0xa3")); err != nil || !bytes.Equal(blob, side[common.HexToHash("0xa3")]) {
+		t.Errorf("ephemeral account mismatch: have %x (err: %v), want %x", blob, err, side[common.HexToHash("0xa3")])
+	}
+	if blob, err := head.AccountRLP(common.HexToHash("0xa1")); err != nil || len(blob) == 0 {
+		t.Errorf("base account not read through: have %x (err: %v)", blob, err)
+	}
+	if blob, err := head.AccountRLP(common.HexToHash("0xa2")); err != nil || len(blob) != 0 {
+		t.Errorf("canonical account leaked into the side branch: have %x (err: %v)", blob, err)
+	}
+	// Flattening the base of the speculation disposes of it
+	defer func(memcap uint64) { aggregatorMemoryLimit = memcap }(aggregatorMemoryLimit)
+	aggregatorMemoryLimit = 0
+
+	if err := snaps.Cap(common.HexToHash("0x03"), 1); err != nil {
+		t.Fatalf("failed to cap the tree: %v", err)
+	}
+	if spec.Snapshot(common.HexToHash("0x13")) != nil {
+		t.Error("ephemeral layer retained after the base went stale")
+	}
+	if _, err := head.AccountRLP(common.HexToHash("0xa3")); err != ErrSnapshotStale {
+		t.Errorf("disposed layer error mismatch: have %v, want %v", err, ErrSnapshotStale)
+	}
+	if err := spec.Update(common.HexToHash("0x15"), common.HexToHash("0x02"), nil, nil, nil); err != errSpeculationDiscarded {
+		t.Errorf("discarded speculation update error mismatch: have %v, want %v", err, errSpeculationDiscarded)
+	}
+	if len(snaps.speculations) != 0 {
+		t.Errorf("disposed speculation still tracked")
+	}
+	// Explicitly discarded speculations are dropped too
+	spec, err = snaps.Speculate(common.HexToHash("0x03"))
+	if err != nil {
+		t.Fatalf("failed to create speculation: %v", err)
+	}
+	if err := spec.Update(common.HexToHash("0x16"), common.HexToHash("0x03"), nil, randomAccountSet("0xa5"), nil); err != nil {
+		t.Fatalf("failed to create an ephemeral layer: %v", err)
+	}
+	spec.Discard()
+	if spec.Snapshot(common.HexToHash("0x16")) != nil || len(snaps.speculations) != 0 {
+		t.Error("discarded speculation retained")
+	}
+}
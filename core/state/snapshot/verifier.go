@@ -0,0 +1,295 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	verifyAccountMeter  = metrics.NewRegisteredMeter("state/snapshot/verify/account", nil)
+	verifyStorageMeter  = metrics.NewRegisteredMeter("state/snapshot/verify/storage", nil)
+	verifyMismatchMeter = metrics.NewRegisteredMeter("state/snapshot/verify/mismatch", nil)
+)
+
+// Verifier continuously cross-checks randomly sampled accounts and storage slots
+// of the persistent snapshot layer against the state trie, repairing every
+// mismatching snapshot entry with the value found in the trie. Contrary to
+// Verify, it never iterates the entire state, so it is cheap enough to keep
+// running on a live node.
+type Verifier struct {
+	tree     *Tree
+	interval time.Duration // Time interval between two sampling rounds
+	samples  int           // Number of positions sampled in each round
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewVerifier creates a background verifier for the given snapshot tree, which
+// samples the given number of random positions in every round.
+func NewVerifier(tree *Tree, interval time.Duration, samples int) *Verifier {
+	return &Verifier{
+		tree:     tree,
+		interval: interval,
+		samples:  samples,
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start launches the background sampling loop.
+func (v *Verifier) Start() {
+	v.wg.Add(1)
+	go v.loop()
+}
+
+// Stop terminates the background sampling loop, waiting for any running round
+// to finish.
+func (v *Verifier) Stop() {
+	close(v.quit)
+	v.wg.Wait()
+}
+
+func (v *Verifier) loop() {
+	defer v.wg.Done()
+
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			repaired, err := v.verify()
+			if err != nil {
+				log.Debug("Snapshot sampling skipped", "err", err)
+			} else if repaired > 0 {
+				log.Warn("Repaired snapshot entries mismatching the trie", "count", repaired)
+			}
+		case <-v.quit:
+			return
+		}
+	}
+}
+
+// verify runs a single sampling round against the disk layer, returning the
+// number of snapshot entries repaired.
+func (v *Verifier) verify() (int, error) {
+	// Prevent the disk layer from being flattened into while it's being sampled
+	v.tree.lock.RLock()
+	defer v.tree.lock.RUnlock()
+
+	dl := v.tree.disklayer()
+	if dl == nil {
+		return 0, errors.New("disk layer is missing")
+	}
+	dl.lock.RLock()
+	stale, generating := dl.stale, dl.genMarker != nil
+	dl.lock.RUnlock()
+
+	if stale {
+		return 0, ErrSnapshotStale
+	}
+	if generating {
+		return 0, ErrNotConstructed
+	}
+	// The state of the disk layer might not be available any more (e.g. pruned
+	// already), in which case there's nothing to verify against.
+	tr, err := trie.New(trie.StateTrieID(dl.root), v.tree.triedb)
+	if err != nil {
+		return 0, err
+	}
+	var repaired int
+	for i := 0; i < v.samples; i++ {
+		var seek common.Hash
+		rand.Read(seek[:])
+
+		n, err := v.verifyTrieAccount(dl, tr, seek)
+		repaired += n
+		if err != nil {
+			return repaired, err
+		}
+		n, err = v.verifySnapshotAccount(dl, tr, seek)
+		repaired += n
+		if err != nil {
+			return repaired, err
+		}
+	}
+	return repaired, nil
+}
+
+// verifyTrieAccount checks the snapshot entry of the first account in the trie
+// following the given position, detecting accounts missing from the snapshot.
+func (v *Verifier) verifyTrieAccount(dl *diskLayer, tr *trie.Trie, seek common.Hash) (int, error) {
+	hash, blob, err := nextLeaf(tr, seek)
+	if err != nil || blob == nil {
+		return 0, err
+	}
+	var account types.StateAccount
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		return 0, err
+	}
+	verifyAccountMeter.Mark(1)
+
+	var repaired int
+	if want := types.SlimAccountRLP(account); !bytes.Equal(rawdb.ReadAccountSnapshot(dl.diskdb, hash), want) {
+		log.Warn("Snapshot account mismatches the trie", "root", dl.root, "account", hash)
+		dl.repairAccount(hash, want)
+		repaired++
+	}
+	if account.Root == types.EmptyRootHash {
+		return repaired, nil
+	}
+	storage, err := trie.New(trie.StorageTrieID(dl.root, hash, account.Root), v.tree.triedb)
+	if err != nil {
+		return repaired, err
+	}
+	n, err := v.verifyStorage(dl, hash, storage, seek)
+	return repaired + n, err
+}
+
+// verifySnapshotAccount checks the trie entry of the first account in the
+// snapshot following the given position, detecting accounts which should not
+// be in the snapshot at all.
+func (v *Verifier) verifySnapshotAccount(dl *diskLayer, tr *trie.Trie, seek common.Hash) (int, error) {
+	it := dl.AccountIterator(seek)
+	defer it.Release()
+
+	if !it.Next() {
+		return 0, it.Error()
+	}
+	hash, have := it.Hash(), common.CopyBytes(it.Account())
+	it.Release()
+
+	blob, err := tr.TryGet(hash[:])
+	if err != nil {
+		return 0, err
+	}
+	verifyAccountMeter.Mark(1)
+
+	var want []byte
+	if blob != nil {
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(blob, &account); err != nil {
+			return 0, err
+		}
+		want = types.SlimAccountRLP(account)
+	}
+	if bytes.Equal(have, want) {
+		return 0, nil
+	}
+	log.Warn("Snapshot account mismatches the trie", "root", dl.root, "account", hash, "dangling", want == nil)
+	dl.repairAccount(hash, want)
+	return 1, nil
+}
+
+// verifyStorage cross-checks the slots following the given position in both the
+// storage trie and the snapshot of an account.
+func (v *Verifier) verifyStorage(dl *diskLayer, account common.Hash, tr *trie.Trie, seek common.Hash) (int, error) {
+	var repaired int
+
+	// Check the snapshot entry of the next slot in the trie
+	hash, want, err := nextLeaf(tr, seek)
+	if err != nil {
+		return 0, err
+	}
+	if want != nil {
+		verifyStorageMeter.Mark(1)
+		if !bytes.Equal(rawdb.ReadStorageSnapshot(dl.diskdb, account, hash), want) {
+			log.Warn("Snapshot storage mismatches the trie", "root", dl.root, "account", account, "slot", hash)
+			dl.repairStorage(account, hash, want)
+			repaired++
+		}
+	}
+	// Check the trie entry of the next slot in the snapshot
+	it, _ := dl.StorageIterator(account, seek)
+	defer it.Release()
+
+	if !it.Next() {
+		return repaired, it.Error()
+	}
+	hash, have := it.Hash(), common.CopyBytes(it.Slot())
+	it.Release()
+
+	want, err = tr.TryGet(hash[:])
+	if err != nil {
+		return repaired, err
+	}
+	verifyStorageMeter.Mark(1)
+	if !bytes.Equal(have, want) {
+		log.Warn("Snapshot storage mismatches the trie", "root", dl.root, "account", account, "slot", hash, "dangling", want == nil)
+		dl.repairStorage(account, hash, want)
+		repaired++
+	}
+	return repaired, nil
+}
+
+// nextLeaf returns the first leaf of the trie following the given position, or
+// a nil value if there's none.
+func nextLeaf(tr *trie.Trie, seek common.Hash) (common.Hash, []byte, error) {
+	it, err := tr.NodeIterator(seek[:])
+	if err != nil {
+		return common.Hash{}, nil, err
+	}
+	for it.Next(true) {
+		if it.Leaf() {
+			return common.BytesToHash(it.LeafKey()), common.CopyBytes(it.LeafBlob()), nil
+		}
+	}
+	return common.Hash{}, nil, it.Error()
+}
+
+// repairAccount overwrites the snapshot entry of an account with the given slim
+// RLP, deleting it if empty.
+func (dl *diskLayer) repairAccount(hash common.Hash, blob []byte) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	verifyMismatchMeter.Mark(1)
+	if len(blob) == 0 {
+		rawdb.DeleteAccountSnapshot(dl.diskdb, hash)
+	} else {
+		rawdb.WriteAccountSnapshot(dl.diskdb, hash, blob)
+	}
+	dl.cache.Set(hash[:], blob)
+}
+
+// repairStorage overwrites the snapshot entry of a storage slot with the given
+// value, deleting it if empty.
+func (dl *diskLayer) repairStorage(account, hash common.Hash, blob []byte) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	verifyMismatchMeter.Mark(1)
+	if len(blob) == 0 {
+		rawdb.DeleteStorageSnapshot(dl.diskdb, account, hash)
+	} else {
+		rawdb.WriteStorageSnapshot(dl.diskdb, account, hash, blob)
+	}
+	dl.cache.Set(append(account[:], hash[:]...), blob)
+}
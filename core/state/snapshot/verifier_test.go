@@ -0,0 +1,78 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that the sampling verifier detects and repairs wrong, missing and
+// dangling snapshot entries.
+func TestVerifierRepair(t *testing.T) {
+	helper := newHelper(rawdb.HashScheme)
+	stRoot := helper.makeStorageTrie(common.Hash{}, hashData([]byte("acc-1")), []string{"key-1", "key-2", "key-3"}, []string{"val-1", "val-2", "val-3"}, true)
+	helper.addTrieAccount("acc-1", &types.StateAccount{Balance: big.NewInt(1), Root: stRoot, CodeHash: emptyCode.Bytes()})
+	helper.addTrieAccount("acc-2", &types.StateAccount{Balance: big.NewInt(2), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+	helper.addTrieAccount("acc-3", &types.StateAccount{Balance: big.NewInt(3), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+
+	root, snap := helper.CommitAndGenerate()
+	select {
+	case <-snap.genPending:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("Snapshot generation failed")
+	}
+	stop := make(chan *generatorStats)
+	snap.genAbort <- stop
+	<-stop
+
+	// Corrupt the generated snapshot
+	helper.addSnapAccount("acc-2", &types.StateAccount{Balance: big.NewInt(20), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+	helper.addSnapAccount("acc-4", &types.StateAccount{Balance: big.NewInt(4), Root: emptyRoot, CodeHash: emptyCode.Bytes()})
+	rawdb.DeleteStorageSnapshot(helper.diskdb, hashData([]byte("acc-1")), hashData([]byte("key-2")))
+	snap.cache.Reset()
+
+	tree := &Tree{
+		diskdb: helper.diskdb,
+		triedb: helper.triedb,
+		layers: map[common.Hash]snapshot{root: snap},
+	}
+	verifier := NewVerifier(tree, time.Second, 64)
+
+	var repaired int
+	for i := 0; i < 100 && repaired < 3; i++ {
+		n, err := verifier.verify()
+		if err != nil {
+			t.Fatalf("failed to verify snapshot: %v", err)
+		}
+		repaired += n
+	}
+	if repaired != 3 {
+		t.Fatalf("repaired entry count mismatch: have %d, want %d", repaired, 3)
+	}
+	checkSnapRoot(t, snap, root)
+
+	// A consistent snapshot requires no repairs
+	if n, err := verifier.verify(); err != nil || n != 0 {
+		t.Fatalf("unexpected repairs: have %d, err %v", n, err)
+	}
+}
@@ -212,6 +212,7 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 		enc   []byte
 		err   error
 		meter *time.Duration
+		snap  = s.db.readSnapshot()
 	)
 	readStart := time.Now()
 	if metrics.EnabledExpensive {
@@ -224,7 +225,7 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 			}
 		}()
 	}
-	if s.db.snap != nil {
+	if snap != nil {
 		if metrics.EnabledExpensive {
 			meter = &s.db.SnapshotStorageReads
 		}
@@ -237,10 +238,10 @@ func (s *stateObject) GetCommittedState(key common.Hash) common.Hash {
 		if _, destructed := s.db.stateObjectsDestruct[s.address]; destructed {
 			return common.Hash{}
 		}
-		enc, err = s.db.snap.Storage(s.addrHash, crypto.Keccak256Hash(key.Bytes()))
+		enc, err = snap.Storage(s.addrHash, crypto.Keccak256Hash(key.Bytes()))
 	}
 	// If the snapshot is unavailable or reading from it fails, load from the database.
-	if s.db.snap == nil || err != nil {
+	if snap == nil || err != nil {
 		if meter != nil {
 			// If we already spent time checking the snapshot, account for it
 			// and reset the readStart
@@ -337,6 +338,9 @@ func (s *stateObject) finalise(prefetch bool) {
 // updateTrie writes cached storage modifications into the object's storage trie.
 // It will return nil if the trie has not been loaded and no changes have been
 // made. An error will be returned if the trie can't be loaded/updated correctly.
+//
+// The storage tries of different objects may be updated concurrently, the state
+// shared with the StateDB is only accessed under its storage lock.
 func (s *stateObject) updateTrie() (Trie, error) {
 	// Make sure all dirty slots are finalized into the pending storage area
 	s.finalise(false) // Don't prefetch anymore, pull directly if need be
@@ -345,14 +349,21 @@ func (s *stateObject) updateTrie() (Trie, error) {
 	}
 	// Track the amount of time wasted on updating the storage trie
 	if metrics.EnabledExpensive {
-		defer func(start time.Time) { s.db.StorageUpdates += time.Since(start) }(time.Now())
+		defer func(start time.Time) { s.db.addStorageTime(&s.db.StorageUpdates, time.Since(start)) }(time.Now())
 	}
 	// The snapshot storage map for the object
 	var (
-		storage map[common.Hash][]byte
-		origin  map[common.Hash][]byte
-		hasher  = s.db.hasher
+		storage          map[common.Hash][]byte
+		origin           map[common.Hash][]byte
+		hasher           = crypto.NewKeccakState()
+		updated, deleted int
 	)
+	defer func() {
+		s.db.storagesLock.Lock()
+		s.db.StorageUpdated += updated
+		s.db.StorageDeleted += deleted
+		s.db.storagesLock.Unlock()
+	}()
 	tr, err := s.getTrie()
 	if err != nil {
 		s.setError(err)
@@ -374,7 +385,7 @@ func (s *stateObject) updateTrie() (Trie, error) {
 				s.setError(err)
 				return nil, err
 			}
-			s.db.StorageDeleted += 1
+			deleted++
 		} else {
 			// Encoding []byte cannot fail, ok to ignore the error.
 			v, _ = rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
@@ -382,24 +393,28 @@ func (s *stateObject) updateTrie() (Trie, error) {
 				s.setError(err)
 				return nil, err
 			}
-			s.db.StorageUpdated += 1
+			updated++
 		}
 		// Cache the mutated storage slots until commit
 		if storage == nil {
 			// Retrieve the old storage map, if available, create a new one otherwise
+			s.db.storagesLock.Lock()
 			if storage = s.db.storages[s.addrHash]; storage == nil {
 				storage = make(map[common.Hash][]byte)
 				s.db.storages[s.addrHash] = storage
 			}
+			s.db.storagesLock.Unlock()
 		}
 		khash := crypto.HashData(hasher, key[:])
 		storage[khash] = v // v will be nil if it's deleted
 		// Cache the original value of mutated storage slots
 		if origin == nil {
+			s.db.storagesLock.Lock()
 			if origin = s.db.storagesOrigin[s.address]; origin == nil {
 				origin = make(map[common.Hash][]byte)
 				s.db.storagesOrigin[s.address] = origin
 			}
+			s.db.storagesLock.Unlock()
 		}
 		// Track the original value of slot only if it's mutated first time
 		if _, ok := origin[khash]; !ok {
@@ -438,7 +453,7 @@ func (s *stateObject) updateRoot() {
 	}
 	// Track the amount of time wasted on hashing the storage trie
 	if metrics.EnabledExpensive {
-		defer func(start time.Time) { s.db.StorageHashes += time.Since(start) }(time.Now())
+		defer func(start time.Time) { s.db.addStorageTime(&s.db.StorageHashes, time.Since(start)) }(time.Now())
 	}
 	s.data.Root = tr.Hash()
 }
@@ -460,7 +475,7 @@ func (s *stateObject) commit() (*trienode.NodeSet, error) {
 	}
 	// Track the amount of time wasted on committing the storage trie
 	if metrics.EnabledExpensive {
-		defer func(start time.Time) { s.db.StorageCommits += time.Since(start) }(time.Now())
+		defer func(start time.Time) { s.db.addStorageTime(&s.db.StorageCommits, time.Since(start)) }(time.Now())
 	}
 	root, nodes, err := tr.Commit(false)
 	if err == nil {
@@ -562,6 +577,10 @@ func (s *stateObject) CodeSize(db Database) int {
 	if bytes.Equal(s.CodeHash(), emptyCodeHash) {
 		return 0
 	}
+	// The code has to be part of the witness if tracked, load it entirely
+	if s.db.trieReads {
+		return len(s.Code())
+	}
 	size, err := db.ContractCodeSize(s.addrHash, common.BytesToHash(s.CodeHash()))
 	if err != nil {
 		s.setError(fmt.Errorf("can't load code size %x: %v", s.CodeHash(), err))
@@ -21,7 +21,10 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"runtime"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -81,6 +84,11 @@ type StateDB struct {
 
 	snaps *snapshot.Tree
 	snap  snapshot.Snapshot
+	spec  *snapshot.Speculation // Ephemeral snapshot branch updated instead of the tree, if any
+
+	// trieReads makes the reads bypass the snapshot and resolve the trie nodes
+	// instead, so that the witness of the accesses is complete.
+	trieReads bool
 
 	// These maps hold the state changes (including the corresponding
 	// original value) that occurred in this **block**.
@@ -88,6 +96,7 @@ type StateDB struct {
 	storages       map[common.Hash]map[common.Hash][]byte    // The mutated slots in prefix-zero trimmed rlp format
 	accountsOrigin map[common.Address][]byte                 // The original value of mutated accounts in 'slim RLP' encoding
 	storagesOrigin map[common.Address]map[common.Hash][]byte // The original value of mutated slots in prefix-zero trimmed rlp format
+	storagesLock   sync.Mutex                                // Lock protecting the storage maps and statistics against the concurrent storage trie updates
 
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects         map[common.Address]*stateObject
@@ -124,6 +133,9 @@ type StateDB struct {
 	validRevisions []revision
 	nextRevisionId int
 
+	// Recorder of the state accessed by each transaction (nil = not recording)
+	recorder *txRecorder
+
 	// Measurements gathered during execution for debugging purposes
 	AccountReads         time.Duration
 	AccountHashes        time.Duration
@@ -176,6 +188,23 @@ func New(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error)
 	return sdb, nil
 }
 
+// NewSpeculative creates a new state from a given trie, reading through and
+// committing into the ephemeral layers of a snapshot speculation, if any,
+// instead of the snapshot tree, leaving the latter untouched. The trie nodes
+// are committed into the given database, which is expected to be backed by a
+// throwaway trie database for them not to reach the shared one.
+func NewSpeculative(root common.Hash, db Database, spec *snapshot.Speculation) (*StateDB, error) {
+	sdb, err := New(root, db, nil)
+	if err != nil {
+		return nil, err
+	}
+	if spec != nil {
+		sdb.spec = spec
+		sdb.snap = spec.Snapshot(root)
+	}
+	return sdb, nil
+}
+
 // StartPrefetcher initializes a new trie prefetcher to pull in nodes from the
 // state trie concurrently while the state is mutated so that when we reach the
 // commit phase, most of the needed data is already hot.
@@ -184,7 +213,7 @@ func (s *StateDB) StartPrefetcher(namespace string) {
 		s.prefetcher.close()
 		s.prefetcher = nil
 	}
-	if s.snap != nil {
+	if s.snap != nil && !s.trieReads {
 		s.prefetcher = newTriePrefetcher(s.db, s.originalRoot, namespace)
 	}
 }
@@ -198,6 +227,137 @@ func (s *StateDB) StopPrefetcher() {
 	}
 }
 
+// PrefetchAccessList schedules the accounts and storage slots of the given access
+// list for prefetching into the tries of the state, and reads them through its
+// snapshot in the background. It's a hint for warming up the caches only, the
+// EIP-2929 access list of the state is left untouched as it affects the gas
+// costs of the executed transactions. The state must have its prefetcher
+// started. The number of accounts and slots scheduled are returned.
+func (s *StateDB) PrefetchAccessList(list types.AccessList) (int, int) {
+	var (
+		accounts = make([]common.Address, 0, len(list))
+		slots    = make(map[common.Address][]common.Hash)
+	)
+	for _, tuple := range list {
+		if _, ok := slots[tuple.Address]; !ok {
+			accounts = append(accounts, tuple.Address)
+		}
+		slots[tuple.Address] = append(slots[tuple.Address], tuple.StorageKeys...)
+	}
+	return s.prefetchState(accounts, slots, nil)
+}
+
+// prefetchState schedules the given accounts and storage slots for prefetching
+// into the tries of the state, and reads them through its snapshot in the
+// background. The storage of the accounts not yet known to the state is loaded
+// synchronously to find their storage tries, stopping early if the optional
+// interrupt gets set.
+func (s *StateDB) prefetchState(accounts []common.Address, slots map[common.Address][]common.Hash, interrupt *uint32) (int, int) {
+	if s.prefetcher == nil || len(accounts) == 0 {
+		return 0, 0
+	}
+	var (
+		keys      = make([][]byte, 0, len(accounts))
+		hashes    = make([]common.Hash, 0, len(accounts))
+		storage   = make(map[common.Hash][]common.Hash)
+		scheduled int
+	)
+	for _, addr := range accounts {
+		if interrupt != nil && atomic.LoadUint32(interrupt) == 1 {
+			break
+		}
+		keys = append(keys, common.CopyBytes(addr[:]))
+		hashes = append(hashes, crypto.Keccak256Hash(addr[:]))
+
+		if len(slots[addr]) == 0 {
+			continue
+		}
+		obj := s.getStateObject(addr)
+		if obj == nil || obj.data.Root == emptyRoot {
+			continue
+		}
+		slotKeys := make([][]byte, 0, len(slots[addr]))
+		slotHashes := make([]common.Hash, 0, len(slots[addr]))
+		for _, slot := range slots[addr] {
+			slotKeys = append(slotKeys, common.CopyBytes(slot[:]))
+			slotHashes = append(slotHashes, crypto.Keccak256Hash(slot[:]))
+		}
+		s.prefetcher.prefetch(obj.addrHash, obj.data.Root, slotKeys)
+		storage[obj.addrHash] = slotHashes
+		scheduled += len(slotKeys)
+	}
+	s.prefetcher.prefetch(common.Hash{}, s.originalRoot, keys)
+
+	if snap := s.snap; snap != nil {
+		go func() {
+			for _, hash := range hashes {
+				if _, err := snap.Account(hash); err != nil {
+					return
+				}
+			}
+			for addrHash, slotHashes := range storage {
+				for _, hash := range slotHashes {
+					if _, err := snap.Storage(addrHash, hash); err != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+	return len(keys), scheduled
+}
+
+// addStorageTime accumulates the time spent on a storage trie operation, which
+// may run concurrently for different state objects.
+func (s *StateDB) addStorageTime(field *time.Duration, elapsed time.Duration) {
+	s.storagesLock.Lock()
+	*field += elapsed
+	s.storagesLock.Unlock()
+}
+
+// forEachStateObject runs fn on each of the given state objects with a pool of
+// workers, returning the first error encountered. The objects must be distinct,
+// each being accessed by a single worker.
+func forEachStateObject(objs []*stateObject, fn func(obj *stateObject) error) error {
+	workers := min(runtime.GOMAXPROCS(0), len(objs))
+	if workers <= 1 {
+		for _, obj := range objs {
+			if err := fn(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var (
+		jobs = make(chan *stateObject, len(objs))
+		errs = make(chan error, workers)
+	)
+	for _, obj := range objs {
+		jobs <- obj
+	}
+	close(jobs)
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			var failure error
+			for obj := range jobs {
+				if failure != nil {
+					continue // Drain the remaining jobs
+				}
+				failure = fn(obj)
+			}
+			errs <- failure
+		}()
+	}
+	var failure error
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err != nil && failure == nil {
+			failure = err
+		}
+	}
+	return failure
+}
+
 // setError remembers the first non-nil error it is called with.
 func (s *StateDB) setError(err error) {
 	if s.dbErr == nil {
@@ -269,18 +429,21 @@ func (s *StateDB) SubRefund(gas uint64) {
 // Exist reports whether the given account address exists in the state.
 // Notably this also returns true for self-destructed accounts.
 func (s *StateDB) Exist(addr common.Address) bool {
+	s.recordAccountRead(addr)
 	return s.getStateObject(addr) != nil
 }
 
 // Empty returns whether the state object is either non-existent
 // or empty according to the EIP161 specification (balance = nonce = code = 0)
 func (s *StateDB) Empty(addr common.Address) bool {
+	s.recordAccountRead(addr)
 	so := s.getStateObject(addr)
 	return so == nil || so.empty()
 }
 
 // GetBalance retrieves the balance from the given address or 0 if object not found
 func (s *StateDB) GetBalance(addr common.Address) *big.Int {
+	s.recordAccountRead(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Balance()
@@ -289,6 +452,7 @@ func (s *StateDB) GetBalance(addr common.Address) *big.Int {
 }
 
 func (s *StateDB) GetNonce(addr common.Address) uint64 {
+	s.recordAccountRead(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Nonce()
@@ -303,6 +467,7 @@ func (s *StateDB) TxIndex() int {
 }
 
 func (s *StateDB) GetCode(addr common.Address) []byte {
+	s.recordAccountRead(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.Code()
@@ -311,6 +476,7 @@ func (s *StateDB) GetCode(addr common.Address) []byte {
 }
 
 func (s *StateDB) GetCodeSize(addr common.Address) int {
+	s.recordAccountRead(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.CodeSize(s.db)
@@ -319,6 +485,7 @@ func (s *StateDB) GetCodeSize(addr common.Address) int {
 }
 
 func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
+	s.recordAccountRead(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject == nil {
 		return common.Hash{}
@@ -328,6 +495,7 @@ func (s *StateDB) GetCodeHash(addr common.Address) common.Hash {
 
 // GetState retrieves a value from the given account's storage trie.
 func (s *StateDB) GetState(addr common.Address, hash common.Hash) common.Hash {
+	s.recordSlotRead(addr, hash)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetState(hash)
@@ -366,6 +534,7 @@ func (s *StateDB) GetStorageProof(a common.Address, key common.Hash) ([][]byte,
 
 // GetCommittedState retrieves a value from the given account's committed storage trie.
 func (s *StateDB) GetCommittedState(addr common.Address, hash common.Hash) common.Hash {
+	s.recordSlotRead(addr, hash)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.GetCommittedState(hash)
@@ -394,6 +563,7 @@ func (s *StateDB) StorageTrie(addr common.Address) (Trie, error) {
 }
 
 func (s *StateDB) HasSelfDestructed(addr common.Address) bool {
+	s.recordAccountRead(addr)
 	stateObject := s.getStateObject(addr)
 	if stateObject != nil {
 		return stateObject.selfDestructed
@@ -596,13 +766,14 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 	var (
 		data *types.StateAccount
 		err  error
+		snap = s.readSnapshot()
 	)
-	if s.snap != nil {
+	if snap != nil {
 		if metrics.EnabledExpensive {
 			defer func(start time.Time) { s.SnapshotAccountReads += time.Since(start) }(time.Now())
 		}
 		var acc *types.SlimAccount
-		if acc, err = s.snap.Account(crypto.HashData(s.hasher, addr.Bytes())); err == nil {
+		if acc, err = snap.Account(crypto.HashData(s.hasher, addr.Bytes())); err == nil {
 			if acc == nil {
 				return nil
 			}
@@ -621,7 +792,7 @@ func (s *StateDB) getDeletedStateObject(addr common.Address) *stateObject {
 		}
 	}
 	// If snapshot unavailable or reading from it failed, load from the database
-	if s.snap == nil || err != nil {
+	if snap == nil || err != nil {
 		if metrics.EnabledExpensive {
 			defer func(start time.Time) { s.AccountReads += time.Since(start) }(time.Now())
 		}
@@ -715,6 +886,9 @@ func (s *StateDB) createObject(addr common.Address) (newobj, prev *stateObject)
 //
 // Carrying over the balance ensures that Ether doesn't disappear.
 func (s *StateDB) CreateAccount(addr common.Address) {
+	// Explicit creations depend on the previous account, the balance carrying over
+	s.recordAccountRead(addr)
+
 	newObj, prev := s.createObject(addr)
 	if prev != nil {
 		newObj.setBalance(prev.data.Balance)
@@ -722,6 +896,7 @@ func (s *StateDB) CreateAccount(addr common.Address) {
 }
 
 func (s *StateDB) ForEachStorage(addr common.Address, cb func(key, value common.Hash) bool) error {
+	s.recordStorageRead(addr)
 	so := s.getStateObject(addr)
 	if so == nil {
 		return nil
@@ -797,8 +972,10 @@ func (s *StateDB) Copy() *StateDB {
 		// to the snapshot tree, we need to copy that as well. Otherwise, any
 		// block mined by ourselves will cause gaps in the tree, and force the
 		// miner to operate trie-backed only.
-		snaps: s.snaps,
-		snap:  s.snap,
+		snaps:     s.snaps,
+		snap:      s.snap,
+		spec:      s.spec,
+		trieReads: s.trieReads,
 	}
 	// Copy the dirty states, logs, and preimages
 	for addr := range s.journal.dirties {
@@ -900,6 +1077,10 @@ func (s *StateDB) GetRefund() uint64 {
 // the journal as well as the refunds. Finalise, however, will not push any updates
 // into the tries just yet. Only IntermediateRoot or Commit will do that.
 func (s *StateDB) Finalise(deleteEmptyObjects bool) {
+	var changes *TxChanges
+	if s.recorder != nil {
+		changes = s.txChanges()
+	}
 	addressesToPrefetch := make([][]byte, 0, len(s.journal.dirties))
 	for addr := range s.journal.dirties {
 		obj, exist := s.stateObjects[addr]
@@ -921,6 +1102,9 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 			if _, ok := s.stateObjectsDestruct[obj.address]; !ok {
 				s.stateObjectsDestruct[obj.address] = obj.origin
 			}
+			if changes != nil {
+				changes.markDeleted(addr)
+			}
 
 			// Note, we can't do this only at the end of a block because multiple
 			// transactions within the same block might self destruct and then
@@ -944,6 +1128,9 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 	if s.prefetcher != nil && len(addressesToPrefetch) > 0 {
 		s.prefetcher.prefetch(common.Hash{}, s.originalRoot, addressesToPrefetch)
 	}
+	if s.recorder != nil {
+		s.recorder.finish(changes)
+	}
 	// Invalidate journal because reverting across transactions is not allowed.
 	s.clearJournalAndRefund()
 }
@@ -974,11 +1161,19 @@ func (s *StateDB) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
 	// the account prefetcher. Instead, let's process all the storage updates
 	// first, giving the account prefeches just a few more milliseconds of time
 	// to pull useful data from disk.
+	//
+	// The storage tries of different accounts are independent, they're updated
+	// and hashed concurrently.
+	var objs []*stateObject
 	for addr := range s.stateObjectsPending {
 		if obj := s.stateObjects[addr]; !obj.deleted {
-			obj.updateRoot()
+			objs = append(objs, obj)
 		}
 	}
+	forEachStateObject(objs, func(obj *stateObject) error {
+		obj.updateRoot()
+		return nil
+	})
 	// Now we're about to start to write changes to the trie. The trie is so far
 	// _untouched_. We can check with the prefetcher, if it can give us a trie
 	// which has the same root, but also has some content loaded into it.
@@ -1189,6 +1384,7 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 		return common.Hash{}, err
 	}
 	// Handle all state updates afterwards
+	var objs []*stateObject
 	for addr := range s.stateObjectsDirty {
 		if obj := s.stateObjects[addr]; !obj.deleted {
 			// Write any contract code associated with the state object
@@ -1196,23 +1392,32 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 				rawdb.WriteCode(codeWriter, common.BytesToHash(obj.CodeHash()), obj.code)
 				obj.dirtyCode = false
 			}
-			// Write any storage changes in the state object to its storage trie
-			nodeSet, err := obj.commit()
-			if err != nil {
-				return common.Hash{}, err
-			}
-
-			// Merge the dirty nodes of storage trie into global set
-			if nodeSet != nil {
-				if err := nodes.Merge(nodeSet); err != nil {
-					return common.Hash{}, err
-				}
-				updated, deleted := nodeSet.Size()
-				storageTrieNodesUpdated += updated
-				storageTrieNodesDeleted += deleted
-			}
+			objs = append(objs, obj)
 		}
 	}
+	// Write the storage changes of the state objects to their storage tries
+	// concurrently, merging the dirty nodes of the storage tries into the
+	// global set.
+	var statsLock sync.Mutex
+	err = forEachStateObject(objs, func(obj *stateObject) error {
+		nodeSet, err := obj.commit()
+		if err != nil || nodeSet == nil {
+			return err
+		}
+		if err := nodes.Merge(nodeSet); err != nil {
+			return err
+		}
+		updated, deleted := nodeSet.Size()
+
+		statsLock.Lock()
+		storageTrieNodesUpdated += updated
+		storageTrieNodesDeleted += deleted
+		statsLock.Unlock()
+		return nil
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
 	if codeWriter.ValueSize() > 0 {
 		if err := codeWriter.Write(); err != nil {
 			log.Crit("Failed to commit dirty codes", "error", err)
@@ -1253,7 +1458,12 @@ func (s *StateDB) Commit(block uint64, deleteEmptyObjects bool) (common.Hash, er
 	if s.snap != nil {
 		start := time.Now()
 		// Only update if there's a state transition (skip empty Clique blocks)
-		if parent := s.snap.Root(); parent != root {
+		if parent := s.snap.Root(); parent != root && s.spec != nil {
+			// Speculative layers are kept apart from the tree, never capped
+			if err := s.spec.Update(root, parent, s.convertAccountSet(s.stateObjectsDestruct), s.accounts, s.storages); err != nil {
+				log.Warn("Failed to update snapshot speculation", "from", parent, "to", root, "err", err)
+			}
+		} else if parent != root {
 			if err := s.snaps.Update(root, parent, s.convertAccountSet(s.stateObjectsDestruct), s.accounts, s.storages); err != nil {
 				log.Warn("Failed to update snapshot tree", "from", parent, "to", root, "err", err)
 			}
@@ -1404,6 +1614,22 @@ func (s *StateDB) DirtyAccounts(hash common.Hash, number uint64) []*types.DirtyS
 	return dirtyAccounts
 }
 
+// Mutations returns the accounts modified since the last finalisation, along
+// with the storage slots written in each of them.
+func (s *StateDB) Mutations() map[common.Address][]common.Hash {
+	mutations := make(map[common.Address][]common.Hash, len(s.journal.dirties))
+	for addr := range s.journal.dirties {
+		var slots []common.Hash
+		if obj, exist := s.stateObjects[addr]; exist {
+			for key := range obj.dirtyStorage {
+				slots = append(slots, key)
+			}
+		}
+		mutations[addr] = slots
+	}
+	return mutations
+}
+
 // convertAccountSet converts a provided account set from address keyed to hash keyed.
 func (s *StateDB) convertAccountSet(set map[common.Address]*types.StateAccount) map[common.Hash]struct{} {
 	ret := make(map[common.Hash]struct{}, len(set))
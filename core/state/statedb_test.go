@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"math/rand"
 	"reflect"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
@@ -32,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/trie"
 )
 
@@ -1000,3 +1002,81 @@ func TestStateDBTransientStorage(t *testing.T) {
 		t.Fatalf("transient storage mismatch: have %x, want %x", got, value)
 	}
 }
+
+// Tests that prefetching an access list warms up the tries of the state without
+// touching the EIP-2929 access list.
+func TestPrefetchAccessList(t *testing.T) {
+	var (
+		db       = NewDatabase(rawdb.NewMemoryDatabase())
+		contract = common.HexToAddress("0xaffeaffeaffeaffeaffeaffeaffeaffeaffeaffe")
+		slot     = common.HexToHash("0x01")
+	)
+	state, _ := New(common.Hash{}, db, nil)
+	state.SetState(contract, slot, slot)
+	root, _ := state.Commit(0, false)
+	if err := db.TrieDB().Commit(root, false); err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, _ = New(root, db, nil)
+	state.prefetcher = newTriePrefetcher(db, root, "")
+	defer state.StopPrefetcher()
+
+	accounts, slots := state.PrefetchAccessList(types.AccessList{
+		{Address: contract, StorageKeys: []common.Hash{slot}},
+		{Address: common.HexToAddress("0xbeef")},
+		{Address: contract},
+	})
+	if accounts != 2 || slots != 1 {
+		t.Fatalf("scheduled mismatch: have %d/%d, want %d/%d", accounts, slots, 2, 1)
+	}
+	obj := state.getStateObject(contract)
+	if tr := state.prefetcher.trie(obj.addrHash, obj.data.Root); tr == nil {
+		t.Fatalf("storage not prefetched")
+	}
+	if state.AddressInAccessList(contract) {
+		t.Fatalf("access list warmed by prefetch hint")
+	}
+}
+
+// Tests that committing the storage tries concurrently yields the same state
+// root and trie nodes as committing them one by one.
+func TestConcurrentStorageCommit(t *testing.T) {
+	commit := func(procs int) (common.Hash, ethdb.Database) {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+
+		var (
+			disk     = rawdb.NewMemoryDatabase()
+			state, _ = New(common.Hash{}, NewDatabase(disk), nil)
+		)
+		for i := 0; i < 64; i++ {
+			addr := common.BytesToAddress([]byte{byte(i)})
+			state.SetNonce(addr, uint64(i))
+			for j := 0; j < 32; j++ {
+				state.SetState(addr, common.BytesToHash([]byte{byte(i), byte(j)}), common.BytesToHash([]byte{byte(j + 1)}))
+			}
+		}
+		if root := state.IntermediateRoot(false); root == (common.Hash{}) {
+			t.Fatalf("missing intermediate root")
+		}
+		root, err := state.Commit(0, false)
+		if err != nil {
+			t.Fatalf("failed to commit state: %v", err)
+		}
+		if err := state.Database().TrieDB().Commit(root, false); err != nil {
+			t.Fatalf("failed to flush state: %v", err)
+		}
+		return root, disk
+	}
+	wantRoot, wantDisk := commit(1)
+	haveRoot, haveDisk := commit(8)
+	if haveRoot != wantRoot {
+		t.Fatalf("root mismatch: have %x, want %x", haveRoot, wantRoot)
+	}
+	it := wantDisk.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		if blob, err := haveDisk.Get(it.Key()); err != nil || !bytes.Equal(blob, it.Value()) {
+			t.Fatalf("entry %x mismatch: have %x, want %x", it.Key(), blob, it.Value())
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BalanceChange is the balance of an account before and after the changes
+// applied since the state was opened.
+type BalanceChange struct {
+	Before *big.Int
+	After  *big.Int
+}
+
+// BalanceChanges returns the accounts whose balance changed since the state
+// was opened, destructed accounts having a zero balance afterwards. It must be
+// called after the state was finalised and before it is committed, as the
+// original account data is replaced on commit.
+func (s *StateDB) BalanceChanges() map[common.Address]BalanceChange {
+	changes := make(map[common.Address]BalanceChange)
+	for addr, obj := range s.stateObjects {
+		before := obj.origin
+		if prev, destructed := s.stateObjectsDestruct[addr]; destructed {
+			before = prev
+		}
+		after := obj.data.Balance
+		if obj.deleted {
+			after = nil
+		}
+		change := BalanceChange{Before: balanceOf(before), After: new(big.Int)}
+		if after != nil {
+			change.After.Set(after)
+		}
+		if change.Before.Cmp(change.After) != 0 {
+			changes[addr] = change
+		}
+	}
+	return changes
+}
+
+func balanceOf(account *types.StateAccount) *big.Int {
+	if account == nil || account.Balance == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(account.Balance)
+}
@@ -0,0 +1,325 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// StateReads is the set of the state read by a transaction. It is used to detect
+// whether a transaction executed out of order observed state written by any of
+// the transactions preceding it.
+type StateReads struct {
+	accounts map[common.Address]struct{}                 // Accounts whose fields or existence were read
+	slots    map[common.Address]map[common.Hash]struct{} // Storage slots read
+	storages map[common.Address]struct{}                 // Accounts whose whole storage was iterated
+}
+
+func newStateReads() *StateReads {
+	return &StateReads{
+		accounts: make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+		storages: make(map[common.Address]struct{}),
+	}
+}
+
+// accountChanges is the set of changes of a single account by a transaction.
+type accountChanges struct {
+	created  bool     // Whether the account was (re)created, wiping its storage
+	recreate bool     // Whether the creation depends on the previous account and must be replayed
+	destruct bool     // Whether the account self-destructed
+	deleted  bool     // Whether the account was deleted when finalised
+	balance  *big.Int // Difference of the balance over the transaction, nil if unchanged
+	nonce    *uint64  // Final nonce, nil if unchanged
+	code     []byte   // Final code, nil if unchanged
+
+	storage map[common.Hash]common.Hash // Final values of the written slots
+}
+
+// TxChanges is the set of the state changes done by a single transaction. As long
+// as the transaction did not read any state written in between, replaying them
+// onto a later state yields the same result as executing the transaction on it.
+//
+// Balances are replayed as differences, so that the transactions only paying to
+// the same accounts, like the coinbase, do not conflict with each other.
+type TxChanges struct {
+	accounts  map[common.Address]*accountChanges
+	logs      []*types.Log
+	preimages map[common.Hash][]byte
+}
+
+// markDeleted flags an account as deleted when the transaction was finalised.
+func (c *TxChanges) markDeleted(addr common.Address) {
+	if acc := c.accounts[addr]; acc != nil {
+		acc.deleted = true
+	}
+}
+
+// StateWrites accumulates the state written by a sequence of transactions.
+type StateWrites struct {
+	accounts map[common.Address]struct{}                 // Accounts changed in any way
+	slots    map[common.Address]map[common.Hash]struct{} // Storage slots written
+	resets   map[common.Address]struct{}                 // Accounts whose storage was wiped
+}
+
+// NewStateWrites creates an empty set of state writes.
+func NewStateWrites() *StateWrites {
+	return &StateWrites{
+		accounts: make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+		resets:   make(map[common.Address]struct{}),
+	}
+}
+
+// Add accumulates the state written by a transaction.
+func (w *StateWrites) Add(changes *TxChanges) {
+	for addr, acc := range changes.accounts {
+		w.accounts[addr] = struct{}{}
+		if acc.created || acc.destruct || acc.deleted {
+			w.resets[addr] = struct{}{}
+		}
+		if len(acc.storage) == 0 {
+			continue
+		}
+		slots := w.slots[addr]
+		if slots == nil {
+			slots = make(map[common.Hash]struct{}, len(acc.storage))
+			w.slots[addr] = slots
+		}
+		for key := range acc.storage {
+			slots[key] = struct{}{}
+		}
+	}
+}
+
+// Conflicts reports whether any of the given reads observed the accumulated
+// writes.
+func (w *StateWrites) Conflicts(reads *StateReads) bool {
+	for addr := range reads.accounts {
+		if _, ok := w.accounts[addr]; ok {
+			return true
+		}
+	}
+	for addr, slots := range reads.slots {
+		if _, ok := w.resets[addr]; ok {
+			return true
+		}
+		written := w.slots[addr]
+		if written == nil {
+			continue
+		}
+		for key := range slots {
+			if _, ok := written[key]; ok {
+				return true
+			}
+		}
+	}
+	for addr := range reads.storages {
+		if _, ok := w.resets[addr]; ok {
+			return true
+		}
+		if _, ok := w.slots[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// txRecorder tracks the state read and written by the transactions executed on
+// a state, one transaction at a time.
+type txRecorder struct {
+	reads       *StateReads // Reads of the transaction being executed
+	lastReads   *StateReads // Reads of the last finalised transaction
+	lastChanges *TxChanges  // Changes of the last finalised transaction
+}
+
+// finish closes the recording of the transaction being finalised.
+func (r *txRecorder) finish(changes *TxChanges) {
+	r.lastReads, r.lastChanges = r.reads, changes
+	r.reads = newStateReads()
+}
+
+// StartTxRecording starts recording the state read and written by each executed
+// transaction, retrievable via TxAccesses once the transaction is finalised.
+func (s *StateDB) StartTxRecording() {
+	s.recorder = &txRecorder{reads: newStateReads()}
+}
+
+// StopTxRecording stops recording the state accessed by the transactions.
+func (s *StateDB) StopTxRecording() {
+	s.recorder = nil
+}
+
+// TxAccesses returns the state read and the changes done by the last finalised
+// transaction, nil if not recording.
+func (s *StateDB) TxAccesses() (*StateReads, *TxChanges) {
+	if s.recorder == nil {
+		return nil, nil
+	}
+	return s.recorder.lastReads, s.recorder.lastChanges
+}
+
+func (s *StateDB) recordAccountRead(addr common.Address) {
+	if s.recorder != nil {
+		s.recorder.reads.accounts[addr] = struct{}{}
+	}
+}
+
+func (s *StateDB) recordSlotRead(addr common.Address, key common.Hash) {
+	if s.recorder == nil {
+		return
+	}
+	slots := s.recorder.reads.slots[addr]
+	if slots == nil {
+		slots = make(map[common.Hash]struct{})
+		s.recorder.reads.slots[addr] = slots
+	}
+	slots[key] = struct{}{}
+}
+
+func (s *StateDB) recordStorageRead(addr common.Address) {
+	if s.recorder != nil {
+		s.recorder.reads.storages[addr] = struct{}{}
+	}
+}
+
+// txChanges collects the changes done by the transaction being finalised from
+// the journal, along with the final values of the changed fields.
+func (s *StateDB) txChanges() *TxChanges {
+	changes := &TxChanges{
+		accounts:  make(map[common.Address]*accountChanges, len(s.journal.dirties)),
+		logs:      s.logs[s.thash],
+		preimages: make(map[common.Hash][]byte),
+	}
+	// The ripemd touched before Byzantium may be dirty without being loaded,
+	// which has no effect on the state.
+	for addr := range s.journal.dirties {
+		if _, ok := s.stateObjects[addr]; ok {
+			changes.accounts[addr] = new(accountChanges)
+		}
+	}
+	var (
+		balances = make(map[common.Address]*big.Int) // Balances before the first change
+		nonces   = make(map[common.Address]struct{})
+		codes    = make(map[common.Address]struct{})
+	)
+	for _, entry := range s.journal.entries {
+		var (
+			addr *common.Address
+			acc  *accountChanges
+		)
+		if addr = entry.dirtied(); addr != nil {
+			if acc = changes.accounts[*addr]; acc == nil {
+				continue
+			}
+		}
+		switch ch := entry.(type) {
+		case createObjectChange, resetObjectChange:
+			acc.created = true
+		case selfDestructChange:
+			acc.destruct = true
+		case balanceChange:
+			if _, ok := balances[*addr]; !ok {
+				balances[*addr] = ch.prev
+			}
+		case nonceChange:
+			nonces[*addr] = struct{}{}
+		case codeChange:
+			codes[*addr] = struct{}{}
+		case storageChange:
+			if acc.storage == nil {
+				acc.storage = make(map[common.Hash]common.Hash)
+			}
+			acc.storage[ch.key] = common.Hash{}
+		case addPreimageChange:
+			changes.preimages[ch.hash] = s.preimages[ch.hash]
+		}
+	}
+	for addr, acc := range changes.accounts {
+		obj := s.stateObjects[addr]
+
+		// Creations only wipe the account if done explicitly, as opposed to
+		// the ones implied by a balance change of an unknown account
+		_, read := s.recorder.reads.accounts[addr]
+		acc.recreate = acc.created && read
+
+		if prev, ok := balances[addr]; ok {
+			acc.balance = new(big.Int).Sub(obj.Balance(), prev)
+		}
+		if _, ok := nonces[addr]; ok {
+			nonce := obj.Nonce()
+			acc.nonce = &nonce
+		}
+		if _, ok := codes[addr]; ok {
+			acc.code = obj.Code()
+			if acc.code == nil {
+				acc.code = []byte{}
+			}
+		}
+		for key := range acc.storage {
+			acc.storage[key] = obj.dirtyStorage[key]
+		}
+	}
+	return changes
+}
+
+// ApplyTxChanges replays the changes of a transaction executed on another state
+// as the current transaction. The caller must ensure the transaction did not read
+// any of the state changed since the state it was executed on, and finalise the
+// state afterwards as if the transaction was executed.
+func (s *StateDB) ApplyTxChanges(changes *TxChanges) {
+	for addr, acc := range changes.accounts {
+		if acc.recreate {
+			s.CreateAccount(addr)
+		}
+		// Always apply the balance, even if unchanged, to touch the account
+		switch {
+		case acc.balance == nil:
+			s.AddBalance(addr, common.Big0)
+		case acc.balance.Sign() < 0:
+			s.SubBalance(addr, new(big.Int).Neg(acc.balance))
+		default:
+			s.AddBalance(addr, acc.balance)
+		}
+		if acc.nonce != nil {
+			s.SetNonce(addr, *acc.nonce)
+		}
+		if acc.code != nil {
+			s.SetCode(addr, acc.code)
+		}
+		for key, value := range acc.storage {
+			s.SetState(addr, key, value)
+		}
+		if acc.destruct {
+			s.SelfDestruct(addr)
+		}
+	}
+	for _, log := range changes.logs {
+		s.AddLog(&types.Log{
+			Address:     log.Address,
+			Topics:      log.Topics,
+			Data:        log.Data,
+			BlockNumber: log.BlockNumber,
+		})
+	}
+	for hash, preimage := range changes.preimages {
+		s.AddPreimage(hash, preimage)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state/snapshot"
+)
+
+// WitnessSize is an estimate of the size of the witness of the state accesses,
+// the data a stateless client would need to replay them.
+type WitnessSize struct {
+	Nodes     int `json:"nodes"`     // Number of unique trie nodes resolved
+	NodeBytes int `json:"nodeBytes"` // Total size of the unique trie nodes resolved
+	Codes     int `json:"codes"`     // Number of unique contract codes loaded
+	CodeBytes int `json:"codeBytes"` // Total size of the unique contract codes loaded
+}
+
+// witnessTrie is implemented by the tries tracking the nodes they resolve.
+type witnessTrie interface {
+	Witness() [][]byte
+}
+
+// TrackWitness makes the reads of the state bypass the snapshot and resolve the
+// trie nodes instead, so that the witness of the accesses is complete. The
+// snapshot is still updated on commit. Prefetching is disabled, as taking over
+// the tries of the prefetcher would drop the tracked accesses.
+func (s *StateDB) TrackWitness() {
+	s.trieReads = true
+	s.StopPrefetcher()
+}
+
+// readSnapshot returns the snapshot to read the state through, or nil if the
+// reads have to resolve the trie nodes.
+func (s *StateDB) readSnapshot() snapshot.Snapshot {
+	if s.trieReads {
+		return nil
+	}
+	return s.snap
+}
+
+// Witness returns the witness of the state accesses done since the state was
+// opened: the unique trie nodes resolved from the database and the contract
+// codes loaded. The same restrictions as for WitnessSize apply, and the reads
+// must have been tracked by TrackWitness for the witness to be complete.
+func (s *StateDB) Witness() (nodes [][]byte, codes [][]byte) {
+	s.collectWitness(func(blob []byte) {
+		nodes = append(nodes, blob)
+	}, func(code []byte) {
+		codes = append(codes, code)
+	})
+	return nodes, codes
+}
+
+// WitnessSize estimates the size of the witness of the state accesses done since
+// the state was opened: the unique trie nodes resolved from the database and the
+// contract codes loaded. The tries only resolve the nodes of the accessed paths
+// once hashed, so it must be called after the intermediate root is computed,
+// but before the state is committed and the tries forget their accesses.
+//
+// The reads served by the snapshot resolve no trie nodes, so they must have been
+// tracked by TrackWitness for the estimate to be complete.
+func (s *StateDB) WitnessSize() WitnessSize {
+	var size WitnessSize
+	s.collectWitness(func(blob []byte) {
+		size.Nodes++
+		size.NodeBytes += len(blob)
+	}, func(code []byte) {
+		size.Codes++
+		size.CodeBytes += len(code)
+	})
+	return size
+}
+
+// collectWitness calls the callbacks with each of the unique trie nodes resolved
+// and contract codes loaded by the state accesses.
+func (s *StateDB) collectWitness(onNode func([]byte), onCode func([]byte)) {
+	var (
+		nodes = make(map[string]struct{})
+		codes = make(map[common.Hash]struct{})
+	)
+	add := func(tr Trie) {
+		wt, ok := tr.(witnessTrie)
+		if !ok {
+			return
+		}
+		for _, blob := range wt.Witness() {
+			if _, ok := nodes[string(blob)]; ok {
+				continue
+			}
+			nodes[string(blob)] = struct{}{}
+			onNode(blob)
+		}
+	}
+	add(s.trie)
+	for _, obj := range s.stateObjects {
+		if obj.trie != nil {
+			add(obj.trie)
+		}
+		// Code deployed by the accesses is not part of the witness
+		if obj.code == nil || obj.dirtyCode {
+			continue
+		}
+		hash := common.BytesToHash(obj.CodeHash())
+		if _, ok := codes[hash]; ok {
+			continue
+		}
+		codes[hash] = struct{}{}
+		onCode(obj.code)
+	}
+}
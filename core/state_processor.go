@@ -57,7 +57,10 @@ func NewStateProcessor(config *params.ChainConfig, bc *BlockChain, engine consen
 // Process returns the receipts and logs accumulated during the process and
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
-func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config, publishEvents ...*vm.PublishEvent) (types.Receipts, []*types.Log, []*types.InternalTransaction, uint64, error) {
+//
+// The senders found in the optional sender cache are stored into the block's
+// transactions before execution, so their signatures are not recovered again.
+func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg vm.Config, senders *types.SenderCache, publishEvents ...*vm.PublishEvent) (types.Receipts, []*types.Log, []*types.InternalTransaction, uint64, error) {
 	var (
 		usedGas     = new(uint64)
 		header      = block.Header()
@@ -78,10 +81,20 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 		vmenv        = vm.NewEVM(blockContext, vm.TxContext{}, statedb, p.config, cfg)
 		signer       = types.MakeSigner(p.config, header.Number)
 	)
+	if senders != nil {
+		senderCacheHitMeter.Mark(int64(senders.Prime(block.Transactions())))
+	}
 	if evmHook := p.bc.GetHook(); evmHook != nil {
 		log.Debug("set hook function for testnet")
 		vmenv.SetHook(evmHook)
 	}
+	// Execute the transactions ahead in parallel if enabled, committing their
+	// outcomes in order below unless they conflict with the preceding ones
+	var speculative *speculation
+	if workers := p.parallelWorkers(block, cfg, publishEvents); workers > 0 {
+		speculative = p.speculate(block, statedb, cfg, signer, workers)
+		statedb.StartTxRecording()
+	}
 
 	txNum := len(block.Transactions())
 	commonTxs := make([]*types.Transaction, 0, txNum)
@@ -89,6 +102,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	systemTxs := make([]*types.Transaction, 0, 2)
 
 	posa, isPoSA := p.engine.(consensus.PoSA)
+	forcer, _ := p.engine.(consensus.ForcedTransactor)
 
 	bloomProcessors := NewAsyncReceiptBloomGenerator(txNum)
 	defer bloomProcessors.Close()
@@ -123,14 +137,25 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 			return nil, nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
 		}
 		statedb.SetTxContext(tx.Hash(), i)
-		receipt, _, err := applyTransaction(msg, p.config, p.bc, nil, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, bloomProcessors)
-		if err != nil {
-			return nil, nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+		receipt := speculative.commit(i, msg, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, bloomProcessors)
+		if receipt == nil {
+			receipt, _, err = applyTransaction(msg, p.config, p.bc, nil, gp, statedb, blockNumber, blockHash, tx, usedGas, vmenv, bloomProcessors)
+			if err != nil {
+				return nil, nil, nil, 0, fmt.Errorf("could not apply tx %d [%v]: %w", i, tx.Hash().Hex(), err)
+			}
+		}
+		speculative.track(statedb)
+		receipt.Forced = forcer != nil && forcer.IsForcedTransaction(tx, header)
+		if hasher := cfg.TraceHasher; hasher != nil {
+			hasher.Commit(tx.Hash(), receipt.GasUsed, receipt.Status)
 		}
 
 		commonTxs = append(commonTxs, tx)
 		receipts = append(receipts, receipt)
 	}
+	if speculative != nil {
+		statedb.StopTxRecording()
+	}
 	// Finalize the block, applying any consensus engine specific extras (e.g. block rewards)
 	err := p.engine.Finalize(p.bc, header, statedb, &commonTxs, block.Uncles(), &receipts, &systemTxs, blockContext.InternalTransactions, usedGas)
 	if err != nil {
@@ -156,29 +181,10 @@ func applyTransaction(
 	usedGas *uint64, evm *vm.EVM,
 	receiptProcessor ReceiptProcessor,
 ) (*types.Receipt, *ExecutionResult, error) {
-	// Create a new context to be used in the EVM environment.
-	txContext := NewEVMTxContext(msg)
-	evm.Reset(txContext, statedb)
-	from := msg.From()
-
-	// Check if sender and recipient are blacklisted
-	payer := msg.Payer()
-	// After the Venoki hardfork, all addresses now can submit transaction
-	if config.Consortium != nil && config.IsOdysseus(blockNumber) && !config.IsVenoki(blockNumber) {
-		contractAddr := config.BlacklistContractAddress
-		if state.IsAddressBlacklisted(statedb, contractAddr, &from) ||
-			state.IsAddressBlacklisted(statedb, contractAddr, msg.To()) ||
-			state.IsAddressBlacklisted(statedb, contractAddr, &payer) {
-			return nil, nil, ErrAddressBlacklisted
-		}
-	}
-
-	// Apply the transaction to the current state (included in the env).
-	result, err := ApplyMessage(evm, msg, gp)
+	result, err := executeTransaction(msg, config, gp, statedb, blockNumber, evm)
 	if err != nil {
 		return nil, nil, err
 	}
-
 	// Update the state with pending changes.
 	var root []byte
 	if config.IsByzantium(blockNumber) {
@@ -188,9 +194,34 @@ func applyTransaction(
 	}
 	*usedGas += result.UsedGas
 
+	receipt := newReceipt(msg, result, root, statedb, blockNumber, blockHash, tx, *usedGas, evm)
+	// create the bloom filter
+	receiptProcessor.Apply(receipt)
+
+	return receipt, result, err
+}
+
+// executeTransaction applies the message of a transaction to the state, leaving
+// the changes pending.
+func executeTransaction(msg types.Message, config *params.ChainConfig, gp *GasPool, statedb *state.StateDB, blockNumber *big.Int, evm *vm.EVM) (*ExecutionResult, error) {
+	// Create a new context to be used in the EVM environment.
+	txContext := NewEVMTxContext(msg)
+	evm.Reset(txContext, statedb)
+
+	// Check if sender and recipient are blacklisted
+	if err := checkBlacklist(msg, config, statedb, blockNumber); err != nil {
+		return nil, err
+	}
+	// Apply the transaction to the current state (included in the env).
+	return ApplyMessage(evm, msg, gp)
+}
+
+// newReceipt creates the receipt of an executed transaction, given the gas used
+// by the block up to and including it.
+func newReceipt(msg types.Message, result *ExecutionResult, root []byte, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas uint64, evm *vm.EVM) *types.Receipt {
 	// Create a new receipt for the transaction, storing the intermediate root and gas used
 	// by the tx.
-	receipt := &types.Receipt{Type: tx.Type(), PostState: root, CumulativeGasUsed: *usedGas}
+	receipt := &types.Receipt{Type: tx.Type(), PostState: root, CumulativeGasUsed: usedGas}
 	if result.Failed() {
 		receipt.Status = types.ReceiptStatusFailed
 	} else {
@@ -206,18 +237,32 @@ func applyTransaction(
 
 	// If the transaction created a contract, store the creation address in the receipt.
 	if msg.To() == nil {
-		receipt.ContractAddress = crypto.CreateAddress(evm.TxContext.Origin, tx.Nonce())
+		receipt.ContractAddress = crypto.CreateAddress(msg.From(), tx.Nonce())
 	}
 
-	// Set the receipt logs and create the bloom filter.
+	// Set the receipt logs.
 	receipt.Logs = statedb.GetLogs(tx.Hash(), blockHash)
 	receipt.BlockHash = blockHash
 	receipt.BlockNumber = blockNumber
 	receipt.TransactionIndex = uint(statedb.TxIndex())
-	// create the bloom filter
-	receiptProcessor.Apply(receipt)
+	return receipt
+}
 
-	return receipt, result, err
+// checkBlacklist returns ErrAddressBlacklisted if any of the sender, recipient
+// or payer of the message is blacklisted.
+func checkBlacklist(msg types.Message, config *params.ChainConfig, statedb *state.StateDB, blockNumber *big.Int) error {
+	from, payer := msg.From(), msg.Payer()
+
+	// After the Venoki hardfork, all addresses now can submit transaction
+	if config.Consortium != nil && config.IsOdysseus(blockNumber) && !config.IsVenoki(blockNumber) {
+		contractAddr := config.BlacklistContractAddress
+		if state.IsAddressBlacklisted(statedb, contractAddr, &from) ||
+			state.IsAddressBlacklisted(statedb, contractAddr, msg.To()) ||
+			state.IsAddressBlacklisted(statedb, contractAddr, &payer) {
+			return ErrAddressBlacklisted
+		}
+	}
+	return nil
 }
 
 // ApplyTransaction attempts to apply a transaction to the given state database
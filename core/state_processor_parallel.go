@@ -0,0 +1,180 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// parallelMinTxs is the minimum number of transactions in a block for their
+// parallel execution to be worth the overhead.
+const parallelMinTxs = 4
+
+var (
+	parallelCommitMeter = metrics.NewRegisteredMeter("chain/parallel/commits", nil)
+	parallelReexecMeter = metrics.NewRegisteredMeter("chain/parallel/reexecs", nil)
+)
+
+// speculativeTx is the outcome of the execution of a transaction on the state
+// at the start of its block, as if no transaction preceded it.
+type speculativeTx struct {
+	result  *ExecutionResult
+	reads   *state.StateReads
+	changes *state.TxChanges
+}
+
+// speculation is the outcome of the parallel execution of the transactions of
+// a block, each on its own copy of the state at the start of the block. The
+// transactions are then committed in order, the ones which read state written
+// by the preceding ones being executed again.
+type speculation struct {
+	txs    []*speculativeTx // Outcomes of the transactions, nil if not executable on their own
+	writes *state.StateWrites
+}
+
+// parallelWorkers returns the number of workers to execute the transactions of
+// the block with, 0 if they must be executed serially: the parallel execution
+// is only supported without tracing, opcode events, SSTORE counting, trace
+// hashing nor witness recording.
+func (p *StateProcessor) parallelWorkers(block *types.Block, cfg vm.Config, publishEvents []*vm.PublishEvent) int {
+	workers := p.bc.cacheConfig.ParallelWorkers
+	if workers <= 0 || len(block.Transactions()) < parallelMinTxs {
+		return 0
+	}
+	if cfg.Tracer != nil || cfg.SstoreStats != nil || cfg.TraceHasher != nil || len(publishEvents) > 0 || p.bc.GetHook() != nil {
+		return 0
+	}
+	// The speculative executions run on state copies, whose reads would be
+	// missing from the witness of the block
+	if p.bc.witnesses != nil || p.bc.witnessStats != nil {
+		return 0
+	}
+	// Before Byzantium, the receipts carry the intermediate roots
+	if !p.config.IsByzantium(block.Number()) {
+		return 0
+	}
+	return workers
+}
+
+// speculate executes the common transactions of the block in parallel, each on
+// its own copy of the given state, recording the state they access.
+func (p *StateProcessor) speculate(block *types.Block, statedb *state.StateDB, cfg vm.Config, signer types.Signer, workers int) *speculation {
+	var (
+		header = block.Header()
+		txs    = block.Transactions()
+		spec   = &speculation{
+			txs:    make([]*speculativeTx, len(txs)),
+			writes: state.NewStateWrites(),
+		}
+		jobs = make(chan int, len(txs))
+		wg   sync.WaitGroup
+	)
+	// System transactions are applied while finalizing the block
+	posa, isPoSA := p.engine.(consensus.PoSA)
+	for i, tx := range txs {
+		if isPoSA {
+			if isSystemTx, err := posa.IsSystemTransaction(tx, header); err != nil || isSystemTx {
+				continue
+			}
+		}
+		jobs <- i
+	}
+	close(jobs)
+
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				spec.txs[i] = p.speculateTx(header, statedb, cfg, signer, txs[i], i)
+			}
+		}()
+	}
+	wg.Wait()
+	return spec
+}
+
+// speculateTx executes a transaction on a copy of the given state, returning
+// nil if it failed.
+func (p *StateProcessor) speculateTx(header *types.Header, base *state.StateDB, cfg vm.Config, signer types.Signer, tx *types.Transaction, index int) *speculativeTx {
+	msg, err := tx.AsMessage(signer, header.BaseFee)
+	if err != nil {
+		return nil
+	}
+	statedb := base.Copy()
+	statedb.StartTxRecording()
+	statedb.SetTxContext(tx.Hash(), index)
+
+	vmenv := vm.NewEVM(NewEVMBlockContext(header, p.bc, nil), vm.TxContext{}, statedb, p.config, cfg)
+	vmenv.Context.CurrentTransaction = tx
+
+	result, err := executeTransaction(msg, p.config, new(GasPool).AddGas(header.GasLimit), statedb, header.Number, vmenv)
+	if err != nil {
+		return nil
+	}
+	statedb.Finalise(true)
+
+	reads, changes := statedb.TxAccesses()
+	return &speculativeTx{result: result, reads: reads, changes: changes}
+}
+
+// commit applies the speculative outcome of a transaction onto the state of the
+// block, unless the transaction read any state written by the preceding ones or
+// would not fit in the block gas pool. Nil is returned if the transaction must
+// be executed again.
+func (s *speculation) commit(index int, msg types.Message, gp *GasPool, statedb *state.StateDB, blockNumber *big.Int, blockHash common.Hash, tx *types.Transaction, usedGas *uint64, evm *vm.EVM, receiptProcessor ReceiptProcessor) *types.Receipt {
+	if s == nil {
+		return nil
+	}
+	spec := s.txs[index]
+	if spec == nil || s.writes.Conflicts(spec.reads) || gp.Gas() < msg.Gas() {
+		parallelReexecMeter.Mark(1)
+		return nil
+	}
+	parallelCommitMeter.Mark(1)
+
+	// Account the gas as the execution would, buying the whole gas limit and
+	// returning the remaining gas
+	gp.SubGas(msg.Gas())
+	gp.AddGas(msg.Gas() - spec.result.UsedGas)
+
+	statedb.ApplyTxChanges(spec.changes)
+	statedb.Finalise(true)
+	*usedGas += spec.result.UsedGas
+
+	receipt := newReceipt(msg, spec.result, nil, statedb, blockNumber, blockHash, tx, *usedGas, evm)
+	receiptProcessor.Apply(receipt)
+	return receipt
+}
+
+// track accumulates the state written by the transaction just applied to the
+// state of the block, either committed or executed again.
+func (s *speculation) track(statedb *state.StateDB) {
+	if s == nil {
+		return
+	}
+	_, changes := statedb.TxAccesses()
+	s.writes.Add(changes)
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that executing the transactions of the imported blocks in parallel
+// yields the same state and receipts as the serial execution, whether the
+// transactions conflict with each other or not.
+func TestParallelProcessing(t *testing.T) {
+	var (
+		keys    = make([]*ecdsa.PrivateKey, 4)
+		addrs   = make([]common.Address, len(keys))
+		counter = common.Address{0xcc}
+		// PUSH1 0 SLOAD PUSH1 1 ADD PUSH1 0 SSTORE: increments slot 0
+		code  = common.FromHex("0x600054600101600055")
+		alloc = GenesisAlloc{counter: {Code: code, Balance: common.Big0}}
+	)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+		alloc[addrs[i]] = GenesisAccount{Balance: big.NewInt(params.Ether)}
+	}
+	var (
+		genDb = rawdb.NewMemoryDatabase()
+		gspec = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   alloc,
+		}
+		genesis = gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), genDb, 4, func(i int, b *BlockGen) {
+		sign := func(key *ecdsa.PrivateKey, tx *types.LegacyTx) {
+			signed, err := types.SignNewTx(key, signer, tx)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(signed)
+		}
+		for j, key := range keys {
+			from := addrs[j]
+			switch (i + j) % 4 {
+			case 0:
+				// Independent value transfers to a fresh account
+				sign(key, &types.LegacyTx{Nonce: b.TxNonce(from), To: &common.Address{byte(i), byte(j)}, Value: big.NewInt(1000), Gas: params.TxGas, GasPrice: b.header.BaseFee})
+			case 1:
+				// Conflicting increments of the shared counter
+				sign(key, &types.LegacyTx{Nonce: b.TxNonce(from), To: &counter, Gas: 50000, GasPrice: b.header.BaseFee})
+			case 2:
+				// Contract creation followed by a transfer from the same sender
+				sign(key, &types.LegacyTx{Nonce: b.TxNonce(from), Gas: 100000, GasPrice: b.header.BaseFee, Data: common.FromHex("0x6009600c60003960096000f3")})
+				sign(key, &types.LegacyTx{Nonce: b.TxNonce(from), To: &addrs[(j+1)%len(addrs)], Value: big.NewInt(1), Gas: params.TxGas, GasPrice: b.header.BaseFee})
+			case 3:
+				// Transfers to the sender of another transaction of the block
+				sign(key, &types.LegacyTx{Nonce: b.TxNonce(from), To: &addrs[(j+2)%len(addrs)], Value: big.NewInt(params.GWei), Gas: params.TxGas, GasPrice: b.header.BaseFee})
+			}
+		}
+	}, true)
+
+	serial, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer serial.Stop()
+	if _, err := serial.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain serially: %v", err)
+	}
+
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.ParallelWorkers = 4
+
+	parallel, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer parallel.Stop()
+
+	if _, err := parallel.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain in parallel: %v", err)
+	}
+	// All the transactions of the first block but the second one of the same
+	// sender are executable on its parent state
+	statedb, err := parallel.StateAt(genesis.Root())
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	spec := parallel.processor.(*StateProcessor).speculate(blocks[0], statedb, vm.Config{}, signer, 4)
+	for i, tx := range spec.txs {
+		if executed := tx != nil; executed != (i != 3) {
+			t.Errorf("transaction %d: speculative execution mismatch: have %v, want %v", i, executed, i != 3)
+		}
+	}
+	for _, block := range blocks {
+		have, want := parallel.GetReceiptsByHash(block.Hash()), serial.GetReceiptsByHash(block.Hash())
+		if len(have) != len(want) {
+			t.Fatalf("block %d: receipt count mismatch: have %d, want %d", block.NumberU64(), len(have), len(want))
+		}
+		for i := range want {
+			if have[i].Status != want[i].Status || have[i].CumulativeGasUsed != want[i].CumulativeGasUsed || have[i].ContractAddress != want[i].ContractAddress || have[i].Bloom != want[i].Bloom {
+				t.Errorf("block %d: receipt %d mismatch: have %+v, want %+v", block.NumberU64(), i, have[i], want[i])
+			}
+		}
+	}
+	if have, want := parallel.CurrentBlock().Root(), serial.CurrentBlock().Root(); have != want {
+		t.Fatalf("state root mismatch: have %x, want %x", have, want)
+	}
+}
@@ -96,10 +96,13 @@ type Message interface {
 // ExecutionResult includes all output after executing given evm
 // message no matter the execution itself is successful or not.
 type ExecutionResult struct {
-	UsedGas     uint64 // Total used gas, not including the refunded gas
-	RefundedGas uint64 // Total gas refunded after execution
-	Err         error  // Any error encountered during the execution(listed in core/vm/errors.go)
-	ReturnData  []byte // Returned data from evm(function result or data supplied with revert opcode)
+	UsedGas       uint64             // Total used gas, not including the refunded gas
+	RefundedGas   uint64             // Total gas refunded after execution
+	RefundCounter uint64             // Gas refund counter accrued during execution, before the refund cap
+	RefundCap     uint64             // Maximum gas refundable under the refund quotient of the fork
+	Refunds       vm.RefundBreakdown // Refund counter broken down by the operations accruing it
+	Err           error              // Any error encountered during the execution(listed in core/vm/errors.go)
+	ReturnData    []byte             // Returned data from evm(function result or data supplied with revert opcode)
 }
 
 // Unwrap returns the internal evm error which allows us for further
@@ -432,15 +435,19 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 			payerAddr := st.msg.Payer()
 			payer = &payerAddr
 		}
-		tracer.CaptureTxStart(st.initialGas, payer)
-		defer func() {
-			tracer.CaptureTxEnd(st.gas)
-		}()
+		if tracer.OnTxStart != nil {
+			tracer.OnTxStart(st.evm.GetVMContext(), st.initialGas, payer)
+		}
+		if tracer.OnTxEnd != nil {
+			defer func() {
+				tracer.OnTxEnd(st.gas)
+			}()
+		}
 	}
 
 	msg := st.msg
 	sender := vm.AccountRef(msg.From())
-	rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber)
+	rules := st.evm.ChainConfig().Rules(st.evm.Context.BlockNumber, st.evm.Context.Time)
 	contractCreation := msg.To() == nil
 
 	// Check clauses 4-5, subtract intrinsic gas if everything is correct
@@ -482,15 +489,20 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 		ret, st.gas, vmerr = st.evm.Call(sender, st.to(), st.data, st.gas, st.value)
 	}
 
-	var gasRefund uint64
+	var (
+		gasRefund     uint64
+		refundCap     uint64
+		refundCounter = st.state.GetRefund()
+	)
 	if !st.evm.Config.IsSystemTransaction {
-		if !rules.IsLondon {
-			// Before EIP-3529: refunds were capped to gasUsed / 2
-			gasRefund = st.refundGas(params.RefundQuotient)
-		} else {
+		// Before EIP-3529: refunds were capped to gasUsed / 2
+		refundQuotient := params.RefundQuotient
+		if rules.IsLondon {
 			// After EIP-3529: refunds are capped to gasUsed / 5
-			gasRefund = st.refundGas(params.RefundQuotientEIP3529)
+			refundQuotient = params.RefundQuotientEIP3529
 		}
+		refundCap = st.gasUsed() / refundQuotient
+		gasRefund = st.refundGas(refundQuotient)
 	}
 
 	effectiveTip := st.gasPrice
@@ -516,10 +528,13 @@ func (st *StateTransition) TransitionDb() (*ExecutionResult, error) {
 	}
 
 	return &ExecutionResult{
-		UsedGas:     st.gasUsed(),
-		RefundedGas: gasRefund,
-		Err:         vmerr,
-		ReturnData:  ret,
+		UsedGas:       st.gasUsed(),
+		RefundedGas:   gasRefund,
+		RefundCounter: refundCounter,
+		RefundCap:     refundCap,
+		Refunds:       st.evm.Refunds(),
+		Err:           vmerr,
+		ReturnData:    ret,
 	}, nil
 }
 
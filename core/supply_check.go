@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	supplyViolationMeter = metrics.NewRegisteredMeter("chain/supply/violations", nil)
+	balanceOverflowMeter = metrics.NewRegisteredMeter("chain/supply/overflows", nil)
+)
+
+// maxBalance is the largest balance an account can hold, 2^256-1.
+var maxBalance = new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+
+// checkSupply verifies that the change of the total supply caused by a block
+// equals the rewards minted by the consensus engine minus the fees burned,
+// and that no account balance is left out of range. Violations are reported
+// through a SupplyViolationEvent, the block is not rejected. The state must
+// be the finalised, not yet committed, state of the block.
+//
+// The supply is only expected to change through rewards and fee burns: ether
+// destroyed by self-destructing to itself is reported as a violation too.
+func (bc *BlockChain) checkSupply(block *types.Block, receipts types.Receipts, statedb *state.StateDB) {
+	bridges := make(map[common.Address]struct{}, len(bc.cacheConfig.SupplyBridges))
+	for _, addr := range bc.cacheConfig.SupplyBridges {
+		bridges[addr] = struct{}{}
+	}
+	var (
+		actual    = new(big.Int)
+		bridged   = new(big.Int)
+		overflows []common.Address
+	)
+	for addr, change := range statedb.BalanceChanges() {
+		if change.After.Sign() < 0 || change.After.Cmp(maxBalance) > 0 {
+			overflows = append(overflows, addr)
+		}
+		delta := new(big.Int).Sub(change.After, change.Before)
+		if _, ok := bridges[addr]; ok {
+			bridged.Add(bridged, delta)
+		} else {
+			actual.Add(actual, delta)
+		}
+	}
+	rewards := new(big.Int)
+	if rewarder, ok := bc.engine.(consensus.Rewarder); ok {
+		rewards = rewarder.BlockReward(bc, block.Header(), block.Uncles())
+	}
+	burned, err := bc.burnedFees(block, receipts)
+	if err != nil {
+		log.Warn("Failed to compute the burned fees", "number", block.Number(), "hash", block.Hash(), "err", err)
+		return
+	}
+	// Ether locked into the bridges leaves the circulating supply
+	expected := new(big.Int).Sub(rewards, burned)
+	expected.Sub(expected, bridged)
+
+	if expected.Cmp(actual) == 0 && len(overflows) == 0 {
+		return
+	}
+	if expected.Cmp(actual) != 0 {
+		supplyViolationMeter.Mark(1)
+		log.Error("Supply change mismatches the rewards and burns", "number", block.Number(), "hash", block.Hash(),
+			"expected", expected, "actual", actual, "rewards", rewards, "burned", burned, "bridged", bridged)
+	}
+	if len(overflows) > 0 {
+		balanceOverflowMeter.Mark(int64(len(overflows)))
+		log.Error("Account balances out of range", "number", block.Number(), "hash", block.Hash(), "accounts", overflows)
+	}
+	bc.supplyFeed.Send(SupplyViolationEvent{
+		Block:     block,
+		Expected:  expected,
+		Actual:    actual,
+		Rewards:   rewards,
+		Burned:    burned,
+		Bridged:   bridged,
+		Overflows: overflows,
+	})
+}
+
+// burnedFees returns the fees of the transactions of a block which weren't
+// credited to any account: the base fee unless transferred to the treasury
+// after Venoki, and the blob fee unless there is a treasury.
+func (bc *BlockChain) burnedFees(block *types.Block, receipts types.Receipts) (*big.Int, error) {
+	var (
+		config   = bc.chainConfig
+		header   = block.Header()
+		treasury = config.RoninTreasuryAddress
+		burned   = new(big.Int)
+	)
+	burnBaseFee := header.BaseFee != nil && (treasury == nil || !config.IsVenoki(header.Number))
+
+	posa, isPoSA := bc.engine.(consensus.PoSA)
+	for i, tx := range block.Transactions() {
+		if i >= len(receipts) {
+			break
+		}
+		// System transactions are free of charge
+		if isPoSA {
+			isSystemTx, err := posa.IsSystemTransaction(tx, header)
+			if err != nil {
+				return nil, err
+			}
+			if isSystemTx {
+				continue
+			}
+		}
+		receipt := receipts[i]
+		if burnBaseFee {
+			burned.Add(burned, new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), header.BaseFee))
+		}
+		if treasury == nil && receipt.BlobGasPrice != nil {
+			burned.Add(burned, new(big.Int).Mul(new(big.Int).SetUint64(receipt.BlobGasUsed), receipt.BlobGasPrice))
+		}
+	}
+	return burned, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// unrewardedEngine is an ethash engine under-reporting its block rewards.
+type unrewardedEngine struct {
+	*ethash.Ethash
+}
+
+func (e unrewardedEngine) BlockReward(chain consensus.ChainHeaderReader, header *types.Header, uncles []*types.Header) *big.Int {
+	return new(big.Int)
+}
+
+// Tests that the supply changes of the imported blocks are matched against the
+// block rewards, the burned fees and the bridged ether.
+func TestSupplyCheck(t *testing.T) {
+	t.Run("burned", func(t *testing.T) { testSupplyCheck(t, false) })
+	t.Run("treasury", func(t *testing.T) { testSupplyCheck(t, true) })
+}
+
+func testSupplyCheck(t *testing.T, venoki bool) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		bridge = common.Address{0xbb}
+		config = *params.TestChainConfig
+		gspec  = &Genesis{
+			Config:   &config,
+			GasLimit: 10000000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+			Alloc:    GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(&config)
+	)
+	if venoki {
+		config.VenokiBlock = common.Big0
+	}
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, func(i int, b *BlockGen) {
+		tip := big.NewInt(params.GWei)
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), bridge, big.NewInt(1000), params.TxGas, new(big.Int).Add(b.header.BaseFee, tip), nil), signer, key)
+		b.AddTx(tx)
+		tx, _ = types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1000), params.TxGas, new(big.Int).Add(b.header.BaseFee, tip), nil), signer, key)
+		b.AddTx(tx)
+	})
+	check := func(engine consensus.Engine) []SupplyViolationEvent {
+		cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		cacheConfig.SupplyCheck = true
+		cacheConfig.SupplyBridges = []common.Address{bridge}
+
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, engine, vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		defer chain.Stop()
+
+		events := make(chan SupplyViolationEvent, len(blocks))
+		sub := chain.SubscribeSupplyViolationEvent(events)
+		defer sub.Unsubscribe()
+
+		if _, err := chain.InsertChain(blocks, nil); err != nil {
+			t.Fatalf("failed to insert chain: %v", err)
+		}
+		var violations []SupplyViolationEvent
+		for len(events) > 0 {
+			violations = append(violations, <-events)
+		}
+		return violations
+	}
+	if violations := check(ethash.NewFaker()); len(violations) != 0 {
+		ev := violations[0]
+		t.Fatalf("unexpected violation: number %d, expected %v, actual %v, burned %v, bridged %v",
+			ev.Block.Number(), ev.Expected, ev.Actual, ev.Burned, ev.Bridged)
+	}
+	violations := check(unrewardedEngine{ethash.NewFaker()})
+	if len(violations) != len(blocks) {
+		t.Fatalf("violation count mismatch: have %d, want %d", len(violations), len(blocks))
+	}
+	for i, ev := range violations {
+		if ev.Block.Hash() != blocks[i].Hash() {
+			t.Fatalf("violation %d: block mismatch: have %d, want %d", i, ev.Block.Number(), blocks[i].Number())
+		}
+		if ev.Bridged.Cmp(big.NewInt(1000)) != 0 {
+			t.Errorf("violation %d: bridged mismatch: have %v, want %v", i, ev.Bridged, 1000)
+		}
+		var burned *big.Int
+		if venoki {
+			burned = new(big.Int)
+		} else {
+			burned = new(big.Int).Mul(big.NewInt(2*int64(params.TxGas)), blocks[i].BaseFee())
+		}
+		if ev.Burned.Cmp(burned) != 0 {
+			t.Errorf("violation %d: burned mismatch: have %v, want %v", i, ev.Burned, burned)
+		}
+		if diff := new(big.Int).Sub(ev.Actual, ev.Expected); diff.Cmp(ethash.ConstantinopleBlockReward) != 0 {
+			t.Errorf("violation %d: unaccounted supply change mismatch: have %v, want %v", i, diff, ethash.ConstantinopleBlockReward)
+		}
+		if len(ev.Overflows) != 0 {
+			t.Errorf("violation %d: unexpected overflows: %v", i, ev.Overflows)
+		}
+	}
+}
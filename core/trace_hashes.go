@@ -0,0 +1,105 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// traceHashesWindow is the number of recent blocks the trace hashes are kept
+// for.
+const traceHashesWindow = 1024
+
+// TraceHashes are the digests of the execution of the transactions of a block,
+// for nodes to compare their executions of the block without exchanging their
+// full traces. The system transactions applied by the consensus engine aren't
+// hashed.
+type TraceHashes struct {
+	Number       uint64           `json:"number"`
+	Hash         common.Hash      `json:"hash"`
+	Digest       common.Hash      `json:"digest"` // Hash of the transaction digests, in order
+	Transactions []vm.TxTraceHash `json:"transactions"`
+}
+
+// traceHashes is the ring buffer of the trace hashes of the recent blocks.
+type traceHashes struct {
+	hashes []TraceHashes
+	next   int
+	lock   sync.RWMutex
+}
+
+// newTraceHashes creates an empty ring buffer of trace hashes.
+func newTraceHashes() *traceHashes {
+	return &traceHashes{hashes: make([]TraceHashes, 0, traceHashesWindow)}
+}
+
+// recordTraceHashes stores the trace hashes computed while processing a block,
+// once validated.
+func (bc *BlockChain) recordTraceHashes(block *types.Block, hasher *vm.TraceHasher) {
+	hashes := TraceHashes{
+		Number:       block.NumberU64(),
+		Hash:         block.Hash(),
+		Transactions: hasher.Transactions(),
+	}
+	digests := make([][]byte, 0, len(hashes.Transactions))
+	for _, tx := range hashes.Transactions {
+		digests = append(digests, tx.Digest[:])
+	}
+	hashes.Digest = crypto.Keccak256Hash(digests...)
+
+	t := bc.traceHashes
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if len(t.hashes) < traceHashesWindow {
+		t.hashes = append(t.hashes, hashes)
+	} else {
+		t.hashes[t.next] = hashes
+	}
+	t.next = (t.next + 1) % traceHashesWindow
+}
+
+// TraceHashes returns the trace hashes of the recently processed block of the
+// given hash, nil if the block wasn't processed recently or if the hashing is
+// disabled.
+func (bc *BlockChain) TraceHashes(hash common.Hash) *TraceHashes {
+	t := bc.traceHashes
+	if t == nil {
+		return nil
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	for i := range t.hashes {
+		if t.hashes[i].Hash == hash {
+			hashes := t.hashes[i]
+			return &hashes
+		}
+	}
+	return nil
+}
+
+// TraceHashesEnabled returns whether the execution of the processed blocks is
+// hashed.
+func (bc *BlockChain) TraceHashesEnabled() bool {
+	return bc.traceHashes != nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the nodes importing the same blocks compute the same trace hashes,
+// and that different executions are told apart.
+func TestTraceHashes(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		writer = common.HexToAddress("0xaaaa")
+		signer = types.LatestSigner(params.TestChainConfig)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// Store the calldata size in slot 0, the self balance in slot 1 and copy
+				// slot 1 into slot 2
+				writer: {
+					Balance: common.Big0,
+					Code:    common.FromHex("0x366000554760015560015460025500"),
+				},
+			},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *BlockGen) {
+		for j := 0; j <= i; j++ {
+			tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), writer, big.NewInt(1), 200000, b.header.BaseFee, []byte{1}), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	newChain := func(enabled bool) *BlockChain {
+		cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+		cacheConfig.TraceHashes = enabled
+
+		chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create tester chain: %v", err)
+		}
+		if _, err := chain.InsertChain(blocks, nil); err != nil {
+			t.Fatalf("failed to insert chain: %v", err)
+		}
+		return chain
+	}
+	disabled := newChain(false)
+	defer disabled.Stop()
+	if disabled.TraceHashesEnabled() || disabled.TraceHashes(blocks[0].Hash()) != nil {
+		t.Fatalf("trace hashes recorded while disabled")
+	}
+	first, second := newChain(true), newChain(true)
+	defer first.Stop()
+	defer second.Stop()
+
+	var digests []common.Hash
+	for i, block := range blocks {
+		have, want := first.TraceHashes(block.Hash()), second.TraceHashes(block.Hash())
+		if have == nil || want == nil {
+			t.Fatalf("block %d: trace hashes missing", i)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("block %d: trace hashes mismatch: have %+v, want %+v", i, have, want)
+		}
+		if have.Number != block.NumberU64() || len(have.Transactions) != len(block.Transactions()) {
+			t.Fatalf("block %d: trace hashes of the wrong block: %+v", i, have)
+		}
+		for j, tx := range have.Transactions {
+			if tx.Hash != block.Transactions()[j].Hash() || tx.Steps == 0 {
+				t.Errorf("block %d tx %d: bad trace hash %+v", i, j, tx)
+			}
+			digests = append(digests, tx.Digest)
+		}
+	}
+	// Every transaction reads a different self balance, so no two executions
+	// are the same
+	seen := make(map[common.Hash]bool)
+	for _, digest := range digests {
+		if seen[digest] {
+			t.Errorf("digest %x repeated across different executions", digest)
+		}
+		seen[digest] = true
+	}
+	if first.TraceHashes(common.Hash{1}) != nil {
+		t.Errorf("trace hashes returned for an unknown block")
+	}
+}
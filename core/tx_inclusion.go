@@ -0,0 +1,53 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// sendTxInclusionEvents fires a TxInclusionEvent for each transaction of a new
+// canonical block, or of a block reorged out if removed is set, sparing the
+// subscribers interested in a few transactions the retrieval of the whole
+// receipt sets.
+func (bc *BlockChain) sendTxInclusionEvents(block *types.Block, receipts []*types.Receipt, removed bool) {
+	txs := block.Transactions()
+	if len(txs) == 0 || len(receipts) != len(txs) {
+		return
+	}
+	events := make([]TxInclusionEvent, len(txs))
+	for i, tx := range txs {
+		tip := tx.EffectiveGasTipValue(block.BaseFee())
+		if tip.Sign() < 0 {
+			// System transactions are free of charge, below the base fee
+			tip = new(big.Int)
+		}
+		events[i] = TxInclusionEvent{
+			TxHash:       tx.Hash(),
+			BlockHash:    block.Hash(),
+			BlockNumber:  block.NumberU64(),
+			Index:        uint(i),
+			Status:       receipts[i].Status,
+			GasUsed:      receipts[i].GasUsed,
+			EffectiveTip: tip,
+			Removed:      removed,
+		}
+	}
+	bc.txInclusionFeed.Send(events)
+}
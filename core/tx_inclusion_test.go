@@ -0,0 +1,180 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the execution results of the transactions of the new canonical
+// blocks are posted.
+func TestTxInclusionEvents(t *testing.T) {
+	var (
+		key, _   = crypto.GenerateKey()
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		reverter = common.HexToAddress("0xaaaa")
+		signer   = types.LatestSigner(params.TestChainConfig)
+		tip      = big.NewInt(params.GWei)
+		gspec    = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: GenesisAlloc{
+				addr: {Balance: big.NewInt(params.Ether)},
+				// PUSH1 0 DUP1 REVERT
+				reverter: {Balance: common.Big0, Code: common.FromHex("0x600080fd")},
+			},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *BlockGen) {
+		for _, to := range []common.Address{{0x01}, reverter} {
+			tx, _ := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+				Nonce:     b.TxNonce(addr),
+				To:        &to,
+				Gas:       100000,
+				GasFeeCap: new(big.Int).Add(b.header.BaseFee, big.NewInt(2*params.GWei)),
+				GasTipCap: tip,
+			}), signer, key)
+			b.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	events := make(chan []TxInclusionEvent, len(blocks))
+	sub := chain.SubscribeTxInclusionEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for _, block := range blocks {
+		var batch []TxInclusionEvent
+		select {
+		case batch = <-events:
+		default:
+			t.Fatalf("block %d: no inclusion events", block.NumberU64())
+		}
+		if len(batch) != len(block.Transactions()) {
+			t.Fatalf("block %d: event count mismatch: have %d, want %d", block.NumberU64(), len(batch), len(block.Transactions()))
+		}
+		for i, ev := range batch {
+			tx := block.Transactions()[i]
+			if ev.TxHash != tx.Hash() || ev.BlockHash != block.Hash() || ev.BlockNumber != block.NumberU64() || ev.Index != uint(i) {
+				t.Errorf("block %d, tx %d: inclusion mismatch: %+v", block.NumberU64(), i, ev)
+			}
+			want := types.ReceiptStatusSuccessful
+			if i == 1 {
+				want = types.ReceiptStatusFailed
+			}
+			if ev.Status != want {
+				t.Errorf("block %d, tx %d: status mismatch: have %d, want %d", block.NumberU64(), i, ev.Status, want)
+			}
+			if ev.GasUsed < params.TxGas {
+				t.Errorf("block %d, tx %d: gas used too low: %d", block.NumberU64(), i, ev.GasUsed)
+			}
+			if ev.EffectiveTip.Cmp(tip) != 0 {
+				t.Errorf("block %d, tx %d: effective tip mismatch: have %v, want %v", block.NumberU64(), i, ev.EffectiveTip, tip)
+			}
+		}
+	}
+}
+
+// Tests that the transactions of the blocks reorged out are posted as removed,
+// and the ones of the new chain as included.
+func TestTxInclusionEventsReorg(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		transfer = func(to common.Address) func(int, *BlockGen) {
+			return func(i int, b *BlockGen) {
+				tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), to, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+				b.AddTx(tx)
+			}
+		}
+	)
+	_, oldBlocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, transfer(common.Address{0x01}))
+	_, newBlocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 3, transfer(common.Address{0x02}))
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), DefaultCacheConfigWithScheme(rawdb.HashScheme), gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(oldBlocks, nil); err != nil {
+		t.Fatalf("failed to insert old chain: %v", err)
+	}
+	events := make(chan []TxInclusionEvent, 16)
+	sub := chain.SubscribeTxInclusionEvent(events)
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(newBlocks, nil); err != nil {
+		t.Fatalf("failed to insert new chain: %v", err)
+	}
+	var (
+		removed  = make(map[common.Hash]int)
+		included = make(map[common.Hash]int)
+	)
+	for done := false; !done; {
+		select {
+		case batch := <-events:
+			for _, ev := range batch {
+				if ev.Removed {
+					if len(included) > 0 {
+						t.Errorf("removal posted after inclusion: %+v", ev)
+					}
+					removed[ev.TxHash]++
+				} else {
+					included[ev.TxHash]++
+				}
+			}
+		default:
+			done = true
+		}
+	}
+	for _, block := range oldBlocks {
+		if hash := block.Transactions()[0].Hash(); removed[hash] != 1 {
+			t.Errorf("block %d: removal count mismatch: have %d, want 1", block.NumberU64(), removed[hash])
+		}
+	}
+	for _, block := range newBlocks {
+		if hash := block.Transactions()[0].Hash(); included[hash] != 1 {
+			t.Errorf("block %d: inclusion count mismatch: have %d, want 1", block.NumberU64(), included[hash])
+		}
+	}
+	if len(removed) != len(oldBlocks) || len(included) != len(newBlocks) {
+		t.Errorf("event count mismatch: have %d/%d, want %d/%d", len(removed), len(included), len(oldBlocks), len(newBlocks))
+	}
+}
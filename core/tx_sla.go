@@ -0,0 +1,280 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// txSLAWindow is the number of recent inclusions the latency distribution is
+	// computed over.
+	txSLAWindow = 1024
+
+	// txSLATrackLimit is the maximum number of local transactions tracked at
+	// once, the ones admitted beyond are not accounted.
+	txSLATrackLimit = 16384
+
+	// txSLACheckInterval is the interval at which the tracked transactions are
+	// checked for SLA breaches and drops.
+	txSLACheckInterval = time.Second
+)
+
+var (
+	txSLALatencyTimer   = metrics.NewRegisteredTimer("chain/txsla/latency", nil)
+	txSLAOverdueMeter   = metrics.NewRegisteredMeter("chain/txsla/overdue", nil)
+	txSLADroppedMeter   = metrics.NewRegisteredMeter("chain/txsla/dropped", nil)
+	txSLAPendingGauge   = metrics.NewRegisteredGauge("chain/txsla/pending", nil)
+	txSLAUntrackedMeter = metrics.NewRegisteredMeter("chain/txsla/untracked", nil)
+)
+
+// TxSLAStats is the inclusion statistics of the local transactions.
+type TxSLAStats struct {
+	SLA      time.Duration `json:"sla"`
+	Pending  int           `json:"pending"`  // Number of tracked transactions awaiting inclusion
+	Included uint64        `json:"included"` // Number of tracked transactions included
+	Late     uint64        `json:"late"`     // Number of included transactions which breached the SLA
+	Overdue  uint64        `json:"overdue"`  // Number of transactions which breached the SLA while pending
+	Dropped  uint64        `json:"dropped"`  // Number of transactions dropped without inclusion
+	Samples  int           `json:"samples"`  // Number of recent inclusions the latencies are computed over
+	Mean     time.Duration `json:"mean"`
+	P50      time.Duration `json:"p50"`
+	P95      time.Duration `json:"p95"`
+	P99      time.Duration `json:"p99"`
+	Max      time.Duration `json:"max"`
+}
+
+// trackedTx is a local transaction awaiting inclusion.
+type trackedTx struct {
+	admitted time.Time
+	overdue  bool // Whether the SLA breach was already announced
+	missing  bool // Whether the transaction was missing from the pool at the last check
+}
+
+// txSLAMonitor tracks the local transactions from their admission into the pool
+// until their inclusion into the canonical chain or their drop.
+type txSLAMonitor struct {
+	sla  time.Duration
+	pool func(common.Hash) bool // Reports whether a transaction is still in the pool (nil = drops undetected)
+
+	pending   map[common.Hash]*trackedTx
+	latencies []time.Duration // Ring buffer of the recent inclusion latencies
+	next      int
+
+	included, late, overdue, dropped uint64
+
+	lock sync.Mutex
+}
+
+func newTxSLAMonitor(sla time.Duration) *txSLAMonitor {
+	return &txSLAMonitor{
+		sla:       sla,
+		pending:   make(map[common.Hash]*trackedTx),
+		latencies: make([]time.Duration, 0, txSLAWindow),
+	}
+}
+
+// SetTxPoolSource sets the function reporting whether a transaction is still in
+// the transaction pool, allowing the inclusion monitor to detect the local
+// transactions dropped without being included.
+func (bc *BlockChain) SetTxPoolSource(has func(common.Hash) bool) {
+	if bc.txSLA == nil {
+		return
+	}
+	bc.txSLA.lock.Lock()
+	bc.txSLA.pool = has
+	bc.txSLA.lock.Unlock()
+}
+
+// TrackLocalTxs starts tracking the inclusion of local transactions admitted
+// into the pool. It's a no-op unless an inclusion SLA is configured.
+func (bc *BlockChain) TrackLocalTxs(txs []*types.Transaction) {
+	m := bc.txSLA
+	if m == nil {
+		return
+	}
+	now := time.Now()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, tx := range txs {
+		if _, ok := m.pending[tx.Hash()]; ok {
+			continue
+		}
+		if len(m.pending) >= txSLATrackLimit {
+			txSLAUntrackedMeter.Mark(1)
+			continue
+		}
+		m.pending[tx.Hash()] = &trackedTx{admitted: now}
+	}
+	txSLAPendingGauge.Update(int64(len(m.pending)))
+}
+
+// include accounts the inclusion latencies of the tracked transactions of a new
+// canonical block.
+func (m *txSLAMonitor) include(block *types.Block) {
+	now := time.Now()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if len(m.pending) == 0 {
+		return
+	}
+	for _, tx := range block.Transactions() {
+		if tracked, ok := m.pending[tx.Hash()]; ok {
+			m.account(tx.Hash(), tracked, block.NumberU64(), now)
+		}
+	}
+	txSLAPendingGauge.Update(int64(len(m.pending)))
+}
+
+// account stops tracking a transaction included in the given block, recording
+// its inclusion latency. The caller must hold the lock.
+func (m *txSLAMonitor) account(hash common.Hash, tracked *trackedTx, number uint64, now time.Time) {
+	delete(m.pending, hash)
+
+	latency := now.Sub(tracked.admitted)
+	txSLALatencyTimer.Update(latency)
+	if len(m.latencies) < txSLAWindow {
+		m.latencies = append(m.latencies, latency)
+	} else {
+		m.latencies[m.next] = latency
+	}
+	m.next = (m.next + 1) % txSLAWindow
+
+	m.included++
+	if latency > m.sla {
+		m.late++
+		log.Debug("Local transaction included late", "hash", hash, "number", number, "latency", common.PrettyDuration(latency))
+	}
+}
+
+// check flags the tracked transactions pending for longer than the SLA and the
+// ones dropped from the pool without inclusion, returning the events to post.
+//
+// A transaction missing from the pool is only considered dropped if it's still
+// missing at the next check and not indexed meanwhile, as the pool may evict the
+// transactions of a new block before the chain accounts for it. The indexed ones
+// are accounted as included, catching the inclusions the chain didn't announce.
+// Without a pool source, the index is looked up for every tracked transaction.
+func (m *txSLAMonitor) check(bc *BlockChain, now time.Time) []TxSLAEvent {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var events []TxSLAEvent
+	for hash, tracked := range m.pending {
+		elapsed := now.Sub(tracked.admitted)
+		missing := m.pool != nil && !m.pool(hash)
+		if missing || m.pool == nil {
+			if number := rawdb.ReadTxLookupEntry(bc.db, hash); number != nil {
+				m.account(hash, tracked, *number, now)
+				continue
+			}
+		}
+		if missing {
+			if !tracked.missing {
+				tracked.missing = true
+				continue
+			}
+			delete(m.pending, hash)
+			m.dropped++
+			txSLADroppedMeter.Mark(1)
+			events = append(events, TxSLAEvent{TxHash: hash, Admitted: tracked.admitted, Elapsed: elapsed, Dropped: true})
+			continue
+		}
+		tracked.missing = false
+		if !tracked.overdue && elapsed > m.sla {
+			tracked.overdue = true
+			m.overdue++
+			txSLAOverdueMeter.Mark(1)
+			events = append(events, TxSLAEvent{TxHash: hash, Admitted: tracked.admitted, Elapsed: elapsed})
+		}
+	}
+	txSLAPendingGauge.Update(int64(len(m.pending)))
+	return events
+}
+
+// txSLALoop periodically checks the tracked local transactions, announcing the
+// SLA breaches.
+func (bc *BlockChain) txSLALoop() {
+	defer bc.wg.Done()
+
+	ticker := time.NewTicker(txSLACheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, event := range bc.txSLA.check(bc, now) {
+				log.Warn("Local transaction breached inclusion SLA", "hash", event.TxHash, "elapsed", common.PrettyDuration(event.Elapsed),
+					"dropped", event.Dropped)
+				bc.txSLAFeed.Send(event)
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// TxSLAStats returns the inclusion statistics of the local transactions, or nil
+// if no inclusion SLA is configured.
+func (bc *BlockChain) TxSLAStats() *TxSLAStats {
+	m := bc.txSLA
+	if m == nil {
+		return nil
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	stats := &TxSLAStats{
+		SLA:      m.sla,
+		Pending:  len(m.pending),
+		Included: m.included,
+		Late:     m.late,
+		Overdue:  m.overdue,
+		Dropped:  m.dropped,
+		Samples:  len(m.latencies),
+	}
+	if len(m.latencies) == 0 {
+		return stats
+	}
+	sorted := make([]time.Duration, len(m.latencies))
+	copy(sorted, m.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, latency := range sorted {
+		total += latency
+	}
+	percentile := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+	stats.Mean = total / time.Duration(len(sorted))
+	stats.P50, stats.P95, stats.P99 = percentile(50), percentile(95), percentile(99)
+	stats.Max = sorted[len(sorted)-1]
+	return stats
+}
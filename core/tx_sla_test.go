@@ -0,0 +1,112 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the tracked local transactions are accounted on inclusion, and
+// flagged when overdue or dropped.
+func TestTxInclusionSLA(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		signer = types.LatestSigner(params.TestChainConfig)
+		gspec  = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc:   GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+	)
+	_, blocks, _ := GenerateChainWithGenesis(gspec, ethash.NewFaker(), 1, func(i int, b *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(b.TxNonce(addr), common.Address{0x01}, big.NewInt(1), params.TxGas, b.header.BaseFee, nil), signer, key)
+		b.AddTx(tx)
+	})
+	config := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	config.TxInclusionSLA = time.Minute
+
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), config, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	var (
+		included = blocks[0].Transactions()[0]
+		overdue  = types.NewTransaction(1, common.Address{0x02}, big.NewInt(1), params.TxGas, big.NewInt(params.GWei), nil)
+		dropped  = types.NewTransaction(2, common.Address{0x03}, big.NewInt(1), params.TxGas, big.NewInt(params.GWei), nil)
+	)
+	chain.SetTxPoolSource(func(hash common.Hash) bool { return hash != dropped.Hash() })
+	chain.TrackLocalTxs([]*types.Transaction{included, overdue, dropped})
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if stats := chain.TxSLAStats(); stats.Included != 1 || stats.Pending != 2 || stats.Samples != 1 || stats.Late != 0 {
+		t.Fatalf("stats mismatch after inclusion: %+v", stats)
+	}
+	// Nothing is overdue yet, the dropped transaction is given a grace check
+	if events := chain.txSLA.check(chain, time.Now()); len(events) != 0 {
+		t.Fatalf("premature events: %+v", events)
+	}
+	events := chain.txSLA.check(chain, time.Now().Add(2*time.Minute))
+	if len(events) != 2 {
+		t.Fatalf("event count mismatch: have %d, want 2", len(events))
+	}
+	for _, event := range events {
+		switch event.TxHash {
+		case overdue.Hash():
+			if event.Dropped || event.Elapsed < time.Minute {
+				t.Errorf("overdue event mismatch: %+v", event)
+			}
+		case dropped.Hash():
+			if !event.Dropped {
+				t.Errorf("drop event mismatch: %+v", event)
+			}
+		default:
+			t.Errorf("unexpected event: %+v", event)
+		}
+	}
+	// Overdue transactions are only announced once
+	if events := chain.txSLA.check(chain, time.Now().Add(3*time.Minute)); len(events) != 0 {
+		t.Fatalf("repeated events: %+v", events)
+	}
+	if stats := chain.TxSLAStats(); stats.Pending != 1 || stats.Overdue != 1 || stats.Dropped != 1 {
+		t.Fatalf("stats mismatch after checks: %+v", stats)
+	}
+	// Transactions indexed without an announced inclusion are accounted as included
+	chain.SetTxPoolSource(func(hash common.Hash) bool { return false })
+	rawdb.WriteTxLookupEntries(chain.db, 1, []common.Hash{overdue.Hash()})
+
+	if events := chain.txSLA.check(chain, time.Now().Add(4*time.Minute)); len(events) != 0 {
+		t.Fatalf("indexed transaction announced: %+v", events)
+	}
+	if stats := chain.TxSLAStats(); stats.Pending != 0 || stats.Included != 2 || stats.Late != 1 || stats.Dropped != 1 {
+		t.Fatalf("stats mismatch after indexing: %+v", stats)
+	}
+}
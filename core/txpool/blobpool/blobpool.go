@@ -298,6 +298,7 @@ type BlobPool struct {
 	index  map[common.Address][]*blobTxMeta // Blob transactions grouped by accounts, sorted by nonce
 	spent  map[common.Address]*uint256.Int  // Expenditure tracking for individual accounts
 	evict  *evictHeap                       // Heap of cheapest accounts for eviction when full
+	pauses *txpool.AccountPauses            // Accounts whose transactions are paused by the operator
 
 	discoverFeed event.Feed // Event feed to send out new tx events on pool discovery (reorg excluded)
 	insertFeed   event.Feed // Event feed to send out new tx events on pool inclusion (reorg included)
@@ -320,6 +321,7 @@ func New(config Config, chainConfig *params.ChainConfig, chain BlockChain) *Blob
 		lookup:      make(map[common.Hash]uint64),
 		index:       make(map[common.Address][]*blobTxMeta),
 		spent:       make(map[common.Address]*uint256.Int),
+		pauses:      txpool.NewAccountPauses(),
 	}
 }
 
@@ -1006,9 +1008,9 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
-	// Store the new minimum gas tip
+	// Store the new minimum gas tip, raised to the blob floor if configured
 	old := p.gasTip
-	p.gasTip = uint256.MustFromBig(tip)
+	p.gasTip = uint256.MustFromBig(p.config.TipFloors.MinTip(types.BlobTxType, tip))
 
 	// If the min miner fee increased, remove transactions below the new threshold
 	if old == nil || p.gasTip.Cmp(old) > 0 {
@@ -1055,8 +1057,11 @@ func (p *BlobPool) SetGasTip(tip *big.Int) {
 						p.reserve(addr, false)
 					}
 					// Clear out the transactions from the data store
-					log.Warn("Dropping underpriced blob transaction", "from", addr, "rejected", tx.nonce, "tip", tx.execTipCap, "want", tip, "drop", nonces, "ids", ids)
+					log.Warn("Dropping underpriced blob transaction", "from", addr, "rejected", tx.nonce, "tip", tx.execTipCap, "want", p.gasTip, "drop", nonces, "ids", ids)
 					dropUnderpricedMeter.Mark(int64(len(ids)))
+					for range ids {
+						txpool.MarkTipDropped(types.BlobTxType)
+					}
 
 					for _, id := range ids {
 						if err := p.store.Delete(id); err != nil {
@@ -1266,6 +1271,12 @@ func (p *BlobPool) add(tx *types.Transaction) (err error) {
 	// If the address is not yet known, request exclusivity to track the account
 	// only by this subpool until all transactions are evicted
 	from, _ := types.Sender(p.signer, tx) // already validated above
+
+	// If the transaction is paused by the operator, discard it
+	if err := p.pauses.Check(p.signer, tx, from, time.Now()); err != nil {
+		addPausedMeter.Mark(1)
+		return err
+	}
 	if _, ok := p.index[from]; !ok {
 		if err := p.reserve(from, true); err != nil {
 			addNonExclusiveMeter.Mark(1)
@@ -1454,7 +1465,7 @@ func (p *BlobPool) drop() {
 func (p *BlobPool) Pending(filter *txpool.PendingFilter) map[common.Address][]*txpool.LazyTransaction {
 	// If only plain transactions are requested, this pool is unsuitable as it
 	// contains none, don't even bother.
-	if filter.OnlyPlainTxs {
+	if filter.OnlyPlainTxs || !filter.AllowsType(types.BlobTxType) {
 		return nil
 	}
 	// Track the amount of time waiting to retrieve the list of pending blob txs
@@ -1631,6 +1642,14 @@ func (p *BlobPool) Content() (map[common.Address][]*types.Transaction, map[commo
 	return make(map[common.Address][]*types.Transaction), make(map[common.Address][]*types.Transaction)
 }
 
+// ContentByType retrieves the pending and queued transactions of the given type
+// only, grouped by account and sorted by nonce.
+//
+// For the blob pool, this method will return nothing for now, as Content.
+func (p *BlobPool) ContentByType(txType uint8) (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	return p.Content()
+}
+
 // ContentFrom retrieves the data content of the transaction pool, returning the
 // pending as well as queued transactions of this address, grouped by nonce.
 //
@@ -1481,3 +1481,80 @@ func TestSponsoredTxRejection(t *testing.T) {
 		t.Fatalf("Expect error %v got %v", core.ErrTxTypeNotSupported, errs[0])
 	}
 }
+
+// Tests that the transactions of paused accounts are rejected, and that the
+// pooled ones are evicted along with their subsequent nonces.
+func TestAccountPause(t *testing.T) {
+	statedb, _ := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewDatabase(memorydb.New())), nil)
+	chain := &testBlockChain{
+		config:  testChainConfig,
+		basefee: uint256.NewInt(1050),
+		blobfee: uint256.NewInt(105),
+		statedb: statedb,
+	}
+	var (
+		key1, _   = crypto.GenerateKey()
+		key2, _   = crypto.GenerateKey()
+		addr1     = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2     = crypto.PubkeyToAddress(key2.PublicKey)
+		recipient = common.Address{0xaa}
+	)
+	statedb.AddBalance(addr1, big.NewInt(params.Ether))
+	statedb.AddBalance(addr2, big.NewInt(params.Ether))
+
+	pool := New(Config{Datadir: ""}, testChainConfig, chain)
+	if err := pool.Init(1, chain.CurrentBlock().Header(), makeAddressReserver()); err != nil {
+		t.Fatalf("failed to create blob pool: %v", err)
+	}
+	defer pool.Close()
+
+	send := func(nonce uint64, to common.Address, key *ecdsa.PrivateKey) *types.Transaction {
+		blobtx := makeUnsignedTx(nonce, 1, 10000, 1000)
+		blobtx.To = to
+		return types.MustSignNewTx(key, types.LatestSigner(testChainConfig), blobtx)
+	}
+	txs := []*types.Transaction{
+		send(0, common.Address{}, key1), send(1, common.Address{}, key1),
+		send(0, common.Address{}, key2), send(1, recipient, key2), send(2, common.Address{}, key2),
+	}
+	for i, err := range pool.Add(txs, false, true) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	// Pausing a recipient evicts the matching transactions and the ones after
+	if evicted := pool.PauseAccount(txpool.AccountPause{Address: recipient, Recipient: true}, true); evicted != 2 {
+		t.Fatalf("recipient eviction mismatch: have %d, want 2", evicted)
+	}
+	if have := len(pool.index[addr2]); have != 1 {
+		t.Fatalf("kept transactions mismatch: have %d, want 1", have)
+	}
+	verifyPoolInternals(t, pool)
+
+	// Pausing a sender evicts all its transactions and rejects the new ones
+	if evicted := pool.PauseAccount(txpool.AccountPause{Address: addr1, Sender: true}, true); evicted != 2 {
+		t.Fatalf("sender eviction mismatch: have %d, want 2", evicted)
+	}
+	if _, ok := pool.index[addr1]; ok {
+		t.Fatalf("paused sender transactions kept")
+	}
+	verifyPoolInternals(t, pool)
+
+	if errs := pool.Add([]*types.Transaction{send(0, common.Address{}, key1)}, false, true); !errors.Is(errs[0], txpool.ErrAccountPaused) {
+		t.Fatalf("paused sender error mismatch: have %v, want %v", errs[0], txpool.ErrAccountPaused)
+	}
+	if errs := pool.Add([]*types.Transaction{send(1, recipient, key2)}, false, true); !errors.Is(errs[0], txpool.ErrAccountPaused) {
+		t.Fatalf("paused recipient error mismatch: have %v, want %v", errs[0], txpool.ErrAccountPaused)
+	}
+	if paused := pool.PausedAccounts(); len(paused) != 2 {
+		t.Fatalf("paused accounts mismatch: have %d, want 2", len(paused))
+	}
+	// Resumed accounts are admitted again
+	if !pool.ResumeAccount(addr1) {
+		t.Fatalf("pause not lifted")
+	}
+	if errs := pool.Add([]*types.Transaction{send(0, common.Address{}, key1)}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add resumed transaction: %v", errs[0])
+	}
+	verifyPoolInternals(t, pool)
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package blobpool
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+)
+
+// PauseAccount implements txpool.AccountPauser, dropping the newly arriving
+// transactions from or to an account and optionally evicting the pooled ones.
+// As the pool tolerates no nonce gaps, evicting a transaction also evicts the
+// subsequent ones of its sender.
+func (p *BlobPool) PauseAccount(pause txpool.AccountPause, evict bool) int {
+	p.pauses.Set(pause)
+	if !evict || !pause.Active(time.Now()) {
+		return 0
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var evicted int
+	for addr, txs := range p.index {
+		for i, meta := range txs {
+			if p.paused(pause, addr, meta) {
+				evicted += len(txs) - i
+				p.evictFrom(addr, i)
+				break
+			}
+		}
+	}
+	if evicted > 0 {
+		log.Warn("Evicted blob transactions of paused account", "address", pause.Address, "count", evicted)
+	}
+	return evicted
+}
+
+// paused reports whether the pooled transaction, sent by the given account, is
+// matched by the pause.
+func (p *BlobPool) paused(pause txpool.AccountPause, from common.Address, meta *blobTxMeta) bool {
+	if pause.Sender && from == pause.Address {
+		return true
+	}
+	if !pause.Recipient {
+		return false
+	}
+	// The recipient isn't indexed, retrieve it from the store
+	blob, err := p.store.Get(meta.id)
+	if err != nil {
+		log.Error("Tracked blob transaction missing from store", "hash", meta.hash, "id", meta.id, "err", err)
+		return false
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(blob, tx); err != nil {
+		log.Error("Blobs corrupted for traced transaction", "hash", meta.hash, "id", meta.id, "err", err)
+		return false
+	}
+	return pause.Match(p.signer, tx, from) != nil
+}
+
+// evictFrom drops the transactions of an account from the given position in
+// its nonce ordered list onwards. The pool lock must be held.
+func (p *BlobPool) evictFrom(from common.Address, index int) {
+	txs := p.index[from]
+
+	ids := make([]uint64, 0, len(txs)-index)
+	for _, meta := range txs[index:] {
+		ids = append(ids, meta.id)
+
+		p.spent[from] = new(uint256.Int).Sub(p.spent[from], meta.costCap)
+		p.stored -= uint64(meta.size)
+		delete(p.lookup, meta.hash)
+	}
+	// The eviction thresholds are cumulative from the lowest nonce, the ones of
+	// the kept transactions stay valid
+	if index == 0 {
+		delete(p.index, from)
+		delete(p.spent, from)
+		heap.Remove(p.evict, p.evict.index[from])
+		p.reserve(from, false)
+	} else {
+		for i := index; i < len(txs); i++ {
+			txs[i] = nil
+		}
+		p.index[from] = txs[:index]
+		heap.Fix(p.evict, p.evict.index[from])
+	}
+	dropPausedMeter.Mark(int64(len(ids)))
+
+	for _, id := range ids {
+		if err := p.store.Delete(id); err != nil {
+			log.Error("Failed to delete paused blob transaction", "from", from, "id", id, "err", err)
+		}
+	}
+}
+
+// ResumeAccount implements txpool.AccountPauser, lifting the pause of an account.
+func (p *BlobPool) ResumeAccount(addr common.Address) bool {
+	return p.pauses.Remove(addr, time.Now())
+}
+
+// PausedAccounts implements txpool.AccountPauser, returning the pauses in force.
+func (p *BlobPool) PausedAccounts() []txpool.AccountPause {
+	return p.pauses.List(time.Now())
+}
@@ -0,0 +1,372 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// broadcastCleanupInterval is the time between two sweeps of the broadcast
+// decisions, dropping the ones of transactions which already left the pool.
+const broadcastCleanupInterval = time.Minute
+
+// broadcastQueueSize is the number of admitted transaction batches waiting for
+// the broadcast hooks, beyond which the batches skip the inspection and get the
+// fallback actions of the hooks instead.
+const broadcastQueueSize = 256
+
+var broadcastDropMeter = metrics.NewRegisteredMeter("txpool/broadcast/dropped", nil)
+
+// BroadcastAction is the verdict of a broadcast hook on the propagation of an
+// admitted transaction.
+type BroadcastAction uint8
+
+const (
+	BroadcastPropagate BroadcastAction = iota // Announce the transaction right away
+	BroadcastDelay                            // Announce the transaction after a delay
+	BroadcastSuppress                         // Never announce the transaction
+)
+
+// String implements fmt.Stringer.
+func (a BroadcastAction) String() string {
+	switch a {
+	case BroadcastPropagate:
+		return "propagate"
+	case BroadcastDelay:
+		return "delay"
+	case BroadcastSuppress:
+		return "suppress"
+	default:
+		return "unknown"
+	}
+}
+
+// BroadcastDecision is the outcome of inspecting a transaction by a hook.
+type BroadcastDecision struct {
+	Action BroadcastAction
+	Delay  time.Duration // Time to hold the transaction back, only for BroadcastDelay
+	Tags   []string      // Labels to attach to the transaction, e.g. the matched policy
+}
+
+// BroadcastHook is a policy consulted on every transaction admitted into the
+// pool before it gets announced to the network, e.g. to keep the transactions
+// of a private order flow local. The hooks only affect the propagation, the
+// transactions are still available to the miner and to the local APIs.
+type BroadcastHook interface {
+	// Name returns the identifier of the hook, used for logging and metrics.
+	Name() string
+
+	// Inspect decides on the propagation of a transaction. The context expires
+	// when the time allowance of the hook runs out.
+	Inspect(ctx context.Context, tx *types.Transaction) (BroadcastDecision, error)
+}
+
+// broadcastHook is a registered broadcast hook along with its limits and its
+// metrics.
+type broadcastHook struct {
+	hook     BroadcastHook
+	timeout  time.Duration   // Time allowance of a single inspection
+	fallback BroadcastAction // Action to take if the hook fails or times out
+
+	propagateMeter metrics.Meter
+	delayMeter     metrics.Meter
+	suppressMeter  metrics.Meter
+	failureMeter   metrics.Meter
+	timeoutMeter   metrics.Meter
+	latencyTimer   metrics.Timer
+}
+
+// inspect runs the hook on a transaction within its time allowance, falling
+// back to the configured action if it doesn't deliver a verdict.
+func (h *broadcastHook) inspect(parent context.Context, tx *types.Transaction) BroadcastDecision {
+	ctx, cancel := context.WithTimeout(parent, h.timeout)
+	defer cancel()
+
+	type result struct {
+		decision BroadcastDecision
+		err      error
+	}
+	var (
+		start = time.Now()
+		done  = make(chan result, 1)
+	)
+	go func() {
+		decision, err := h.hook.Inspect(ctx, tx)
+		done <- result{decision, err}
+	}()
+	select {
+	case res := <-done:
+		h.latencyTimer.UpdateSince(start)
+		if res.err != nil {
+			log.Debug("Broadcast hook failed", "hook", h.hook.Name(), "hash", tx.Hash(), "err", res.err)
+			h.failureMeter.Mark(1)
+			return h.fallbackDecision()
+		}
+		switch res.decision.Action {
+		case BroadcastPropagate:
+			h.propagateMeter.Mark(1)
+		case BroadcastDelay:
+			h.delayMeter.Mark(1)
+		case BroadcastSuppress:
+			h.suppressMeter.Mark(1)
+		}
+		return res.decision
+
+	case <-ctx.Done():
+		log.Debug("Broadcast hook timed out", "hook", h.hook.Name(), "hash", tx.Hash(), "timeout", h.timeout)
+		h.timeoutMeter.Mark(1)
+		return h.fallbackDecision()
+	}
+}
+
+// fallbackDecision returns the decision taken on a transaction the hook did not
+// deliver a verdict on.
+func (h *broadcastHook) fallbackDecision() BroadcastDecision {
+	return BroadcastDecision{Action: h.fallback, Delay: h.timeout}
+}
+
+// broadcastInfo is the combined decision of the hooks on a transaction which
+// was not propagated right away or which was tagged.
+type broadcastInfo struct {
+	tx         *types.Transaction
+	suppressed bool
+	tags       []string
+	timer      *time.Timer // Timer announcing a delayed transaction
+}
+
+// broadcaster runs the admitted transactions through the broadcast hooks and
+// feeds the ones cleared for propagation to the network handler.
+type broadcaster struct {
+	hooks []*broadcastHook
+	infos map[common.Hash]*broadcastInfo
+	lock  sync.RWMutex
+
+	feed event.Feed
+}
+
+// RegisterBroadcastHook adds a policy to be consulted before the propagation
+// of every transaction admitted from now on. Each inspection is bounded by the
+// given timeout; if the hook fails or times out, the fallback action is taken.
+// A delay fallback holds the transaction back for the timeout itself.
+func (p *TxPool) RegisterBroadcastHook(hook BroadcastHook, timeout time.Duration, fallback BroadcastAction) {
+	prefix := "txpool/broadcast/" + hook.Name() + "/"
+
+	p.broadcast.lock.Lock()
+	defer p.broadcast.lock.Unlock()
+
+	p.broadcast.hooks = append(p.broadcast.hooks, &broadcastHook{
+		hook:           hook,
+		timeout:        timeout,
+		fallback:       fallback,
+		propagateMeter: metrics.GetOrRegisterMeter(prefix+"propagated", nil),
+		delayMeter:     metrics.GetOrRegisterMeter(prefix+"delayed", nil),
+		suppressMeter:  metrics.GetOrRegisterMeter(prefix+"suppressed", nil),
+		failureMeter:   metrics.GetOrRegisterMeter(prefix+"failures", nil),
+		timeoutMeter:   metrics.GetOrRegisterMeter(prefix+"timeouts", nil),
+		latencyTimer:   metrics.GetOrRegisterTimer(prefix+"latency", nil),
+	})
+	log.Info("Registered transaction broadcast hook", "hook", hook.Name(), "timeout", timeout, "fallback", fallback)
+}
+
+// SubscribeBroadcasts registers a subscription for the newly admitted
+// transactions cleared by the broadcast hooks for propagation to the network.
+// Delayed transactions are delivered once their delay expires, suppressed ones
+// are never delivered.
+func (p *TxPool) SubscribeBroadcasts(ch chan<- core.NewTxsEvent) event.Subscription {
+	return p.subs.Track(p.broadcast.feed.Subscribe(ch))
+}
+
+// Withheld reports whether the broadcast hooks keep the transaction from the
+// network, either for good or until its delay expires.
+func (p *TxPool) Withheld(hash common.Hash) bool {
+	p.broadcast.lock.RLock()
+	defer p.broadcast.lock.RUnlock()
+
+	info := p.broadcast.infos[hash]
+	return info != nil && (info.suppressed || info.timer != nil)
+}
+
+// BroadcastTags returns the tags attached to a transaction by the broadcast
+// hooks.
+func (p *TxPool) BroadcastTags(hash common.Hash) []string {
+	p.broadcast.lock.RLock()
+	defer p.broadcast.lock.RUnlock()
+
+	if info := p.broadcast.infos[hash]; info != nil {
+		return info.tags
+	}
+	return nil
+}
+
+// broadcastLoop consumes the transactions admitted by the subpools and queues
+// them for the broadcast hooks. The hooks run asynchronously, so that a slow
+// one never holds the pool up: once the queue is full, the admitted batches get
+// the fallback actions of the hooks without being inspected.
+func (p *TxPool) broadcastLoop() {
+	var (
+		txsCh   = make(chan core.NewTxsEvent, 4096)
+		txsSub  = p.SubscribeTransactions(txsCh, false)
+		cleanup = time.NewTicker(broadcastCleanupInterval)
+		queue   = make(chan []*types.Transaction, broadcastQueueSize)
+		done    = make(chan struct{})
+
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	defer cleanup.Stop()
+
+	go p.inspectLoop(ctx, queue, done)
+	for {
+		select {
+		case ev := <-txsCh:
+			select {
+			case queue <- ev.Txs:
+			default:
+				log.Debug("Broadcast hooks overloaded, skipping inspection", "txs", len(ev.Txs))
+				broadcastDropMeter.Mark(int64(len(ev.Txs)))
+				p.feedBroadcasts(p.inspectBroadcasts(ctx, ev.Txs, false))
+			}
+		case <-cleanup.C:
+			p.cleanupBroadcasts(false)
+
+		case <-txsSub.Err():
+			cancel()
+			close(queue)
+			<-done
+			p.cleanupBroadcasts(true)
+			return
+		}
+	}
+}
+
+// inspectLoop runs the queued transaction batches through the broadcast hooks
+// until the queue is closed. The batches left once the context is cancelled on
+// shutdown are dropped.
+func (p *TxPool) inspectLoop(ctx context.Context, queue <-chan []*types.Transaction, done chan<- struct{}) {
+	defer close(done)
+
+	for txs := range queue {
+		if ctx.Err() != nil {
+			continue
+		}
+		p.feedBroadcasts(p.inspectBroadcasts(ctx, txs, true))
+	}
+}
+
+// feedBroadcasts announces the transactions cleared for propagation.
+func (p *TxPool) feedBroadcasts(txs []*types.Transaction) {
+	if len(txs) > 0 {
+		p.broadcast.feed.Send(core.NewTxsEvent{Txs: txs})
+	}
+}
+
+// inspectBroadcasts combines the decisions of all the hooks on the given batch
+// of transactions, returning the ones to be propagated right away. Suppression
+// overrules a delay, and the longest delay requested by any hook applies. If
+// inspect is false, the fallback actions of the hooks are taken instead.
+func (p *TxPool) inspectBroadcasts(ctx context.Context, txs []*types.Transaction, inspect bool) []*types.Transaction {
+	p.broadcast.lock.RLock()
+	hooks := p.broadcast.hooks
+	p.broadcast.lock.RUnlock()
+
+	if len(hooks) == 0 {
+		return txs
+	}
+	propagate := make([]*types.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		var (
+			suppress bool
+			delay    time.Duration
+			tags     []string
+		)
+		for _, hook := range hooks {
+			decision := hook.fallbackDecision()
+			if inspect {
+				decision = hook.inspect(ctx, tx)
+			}
+			switch decision.Action {
+			case BroadcastSuppress:
+				suppress = true
+			case BroadcastDelay:
+				if decision.Delay > delay {
+					delay = decision.Delay
+				}
+			}
+			tags = append(tags, decision.Tags...)
+		}
+		if !suppress && delay == 0 && len(tags) == 0 {
+			propagate = append(propagate, tx)
+			continue
+		}
+		// Store the decision before arming the timer, so a short delay can't
+		// expire before the transaction is tracked.
+		info := &broadcastInfo{tx: tx, suppressed: suppress, tags: tags}
+
+		p.broadcast.lock.Lock()
+		p.broadcast.infos[tx.Hash()] = info
+		switch {
+		case suppress:
+			log.Trace("Suppressed transaction broadcast", "hash", tx.Hash(), "tags", tags)
+		case delay > 0:
+			log.Trace("Delayed transaction broadcast", "hash", tx.Hash(), "delay", delay, "tags", tags)
+			info.timer = time.AfterFunc(delay, func() { p.releaseBroadcast(tx) })
+		default:
+			propagate = append(propagate, tx)
+		}
+		p.broadcast.lock.Unlock()
+	}
+	return propagate
+}
+
+// releaseBroadcast propagates a delayed transaction, unless it already left the
+// pool in the meantime.
+func (p *TxPool) releaseBroadcast(tx *types.Transaction) {
+	p.broadcast.lock.Lock()
+	if info := p.broadcast.infos[tx.Hash()]; info != nil {
+		info.timer = nil
+	}
+	p.broadcast.lock.Unlock()
+
+	if p.Has(tx.Hash()) {
+		p.broadcast.feed.Send(core.NewTxsEvent{Txs: []*types.Transaction{tx}})
+	}
+}
+
+// cleanupBroadcasts drops the decisions on transactions no longer in the pool,
+// or all of them if the pool is shutting down.
+func (p *TxPool) cleanupBroadcasts(all bool) {
+	p.broadcast.lock.Lock()
+	defer p.broadcast.lock.Unlock()
+
+	for hash, info := range p.broadcast.infos {
+		if !all && (info.timer != nil || p.Has(hash)) {
+			continue
+		}
+		if info.timer != nil {
+			info.timer.Stop()
+		}
+		delete(p.broadcast.infos, hash)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// churnRejectMeter counts the remote transactions rejected on admission for
+// exceeding the pool wide churn limit.
+var churnRejectMeter = metrics.NewRegisteredMeter("txpool/churn/rejected", nil)
+
+// churnLimiter is a token bucket capping the rate of transaction admissions.
+// Tokens are taken ahead of the admission and handed back if the transaction
+// ends up rejected.
+type churnLimiter struct {
+	rate  float64 // Tokens added per second
+	burst float64 // Maximum number of tokens held
+
+	tokens float64   // Number of tokens currently available
+	last   time.Time // Time the tokens were last refilled
+	lock   sync.Mutex
+}
+
+// newChurnLimiter creates a full token bucket with the given rate and burst.
+func newChurnLimiter(rate uint64, burst uint64) *churnLimiter {
+	return &churnLimiter{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// take consumes a token, reporting whether one was available.
+func (l *churnLimiter) take() bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// refund hands back a token taken for a rejected admission.
+func (l *churnLimiter) refund() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// SetChurnLimit caps the rate at which remote transactions are admitted into
+// the pool, replacements included, to the given number per second, allowing
+// bursts of the given size. A zero limit disables the cap, a zero burst
+// defaults to the limit. Local transactions are never limited nor counted.
+func (p *TxPool) SetChurnLimit(limit uint64, burst uint64) {
+	if limit == 0 {
+		p.churn.Store(nil)
+		return
+	}
+	if burst == 0 {
+		burst = limit
+	}
+	p.churn.Store(newChurnLimiter(limit, burst))
+	log.Info("Enabled transaction churn limit", "limit", limit, "burst", burst)
+}
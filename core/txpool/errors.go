@@ -56,4 +56,32 @@ var (
 	// input transaction of non-blob type when a blob transaction from this sender
 	// remains pending (and vice-versa).
 	ErrAlreadyReserved = errors.New("address already reserved")
+
+	// ErrAccountNotManaged is returned if a nonce reservation is requested for an
+	// account whose nonces are not managed by the pool.
+	ErrAccountNotManaged = errors.New("account nonces not managed")
+
+	// ErrChurnLimited is returned if a remote transaction is received while the
+	// pool wide rate of admissions and replacements is exhausted.
+	ErrChurnLimited = errors.New("transaction churn limit exceeded")
+
+	// ErrAccountPaused is returned if a transaction is sent from, sent to or paid
+	// by an account whose transactions are paused by the node operator.
+	ErrAccountPaused = errors.New("account paused")
+
+	// ErrForcedTransaction is returned if a transaction which may only be included
+	// by the consensus engine as a forced one is submitted to the pool.
+	ErrForcedTransaction = errors.New("forced transaction")
+
+	// ErrSponsorRejected is returned if the payer of a sponsored transaction
+	// doesn't agree to pay for it according to the sponsor hook of the pool.
+	ErrSponsorRejected = errors.New("sponsor rejected")
+
+	// ErrPoolClosed is returned if a transaction is submitted to a pool which is
+	// shutting down and no longer accepts new admissions.
+	ErrPoolClosed = errors.New("transaction pool closed")
+
+	// ErrLocalsDisabled is returned if an account is attempted to be marked as
+	// local while the local transaction handling is disabled.
+	ErrLocalsDisabled = errors.New("local transaction handling disabled")
 )
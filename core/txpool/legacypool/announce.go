@@ -0,0 +1,72 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var announceDupMeter = metrics.NewRegisteredMeter("txpool/announce/duplicate", nil)
+
+// txAnnouncer assembles the batches of promoted transactions announced to the
+// subscribers of the pool: ordered by sender then nonce, without the ones already
+// announced since they entered the pool, and numbered in sequence so that the
+// consumers can detect any missed batch.
+//
+// It is only used from the reorg loop, so it is not safe for concurrent use.
+type txAnnouncer struct {
+	all *lookup // Pooled transactions, tracking the announced ones
+	seq uint64  // Sequence number of the last announced batch
+}
+
+// newTxAnnouncer creates an announcer with no batch announced yet.
+func newTxAnnouncer(all *lookup) *txAnnouncer {
+	return &txAnnouncer{all: all}
+}
+
+// batch assembles the next batch to announce from the promoted transactions,
+// grouped by sender. False is returned if there is nothing new to announce.
+func (a *txAnnouncer) batch(promoted map[common.Address]*sortedMap) (core.NewTxsEvent, bool) {
+	senders := make([]common.Address, 0, len(promoted))
+	for addr := range promoted {
+		senders = append(senders, addr)
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		return bytes.Compare(senders[i][:], senders[j][:]) < 0
+	})
+	var txs []*types.Transaction
+	for _, addr := range senders {
+		for _, tx := range promoted[addr].Flatten() {
+			if !a.all.Announce(tx.Hash()) {
+				announceDupMeter.Mark(1)
+				continue
+			}
+			txs = append(txs, tx)
+		}
+	}
+	if len(txs) == 0 {
+		return core.NewTxsEvent{}, false
+	}
+	a.seq++
+	return core.NewTxsEvent{Txs: txs, Seq: a.seq}, true
+}
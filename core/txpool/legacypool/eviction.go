@@ -0,0 +1,206 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// evictionEntry is an account tracked by an eviction heap, along with the size
+// of its transaction list and its last activity.
+type evictionEntry struct {
+	addr  common.Address
+	size  int       // Number of transactions of the account in the list
+	beat  time.Time // Last heartbeat of the account
+	index int       // Position of the entry in the heap
+}
+
+// evictionHeap is a heap of accounts indexed by address, ordered by the given
+// priority function, the top entry being the first candidate for eviction.
+type evictionHeap struct {
+	entries []*evictionEntry
+	lookup  map[common.Address]*evictionEntry
+	before  func(a, b *evictionEntry) bool
+	total   uint64 // Sum of the sizes of all the entries
+}
+
+func newEvictionHeap(before func(a, b *evictionEntry) bool) *evictionHeap {
+	return &evictionHeap{
+		lookup: make(map[common.Address]*evictionEntry),
+		before: before,
+	}
+}
+
+func (h *evictionHeap) Len() int           { return len(h.entries) }
+func (h *evictionHeap) Less(i, j int) bool { return h.before(h.entries[i], h.entries[j]) }
+
+func (h *evictionHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.entries[i].index = i
+	h.entries[j].index = j
+}
+
+func (h *evictionHeap) Push(x any) {
+	entry := x.(*evictionEntry)
+	entry.index = len(h.entries)
+	h.entries = append(h.entries, entry)
+}
+
+func (h *evictionHeap) Pop() any {
+	n := len(h.entries)
+	entry := h.entries[n-1]
+	h.entries[n-1] = nil
+	h.entries = h.entries[:n-1]
+	return entry
+}
+
+// update sets the size and heartbeat of an account, removing it if its list is
+// empty.
+func (h *evictionHeap) update(addr common.Address, size int, beat time.Time) {
+	entry := h.lookup[addr]
+	if entry != nil {
+		h.total -= uint64(entry.size)
+	}
+	switch {
+	case size == 0 && entry != nil:
+		heap.Remove(h, entry.index)
+		delete(h.lookup, addr)
+
+	case size == 0:
+
+	case entry != nil:
+		entry.size, entry.beat = size, beat
+		heap.Fix(h, entry.index)
+
+	default:
+		entry = &evictionEntry{addr: addr, size: size, beat: beat}
+		heap.Push(h, entry)
+		h.lookup[addr] = entry
+	}
+	h.total += uint64(size)
+}
+
+// iterate returns an iterator over the entries in eviction order. The heap must
+// not be modified while iterating.
+func (h *evictionHeap) iterate() *evictionIterator {
+	it := &evictionIterator{heap: h}
+	if len(h.entries) > 0 {
+		it.frontier = []int{0}
+	}
+	return it
+}
+
+// evictionIterator walks an eviction heap in order without popping it, keeping
+// the frontier of the entries whose parents were visited in a heap of its own.
+// Retrieving the k first entries costs O(k log k), regardless of the heap size.
+type evictionIterator struct {
+	heap     *evictionHeap
+	frontier []int
+}
+
+func (it *evictionIterator) Len() int { return len(it.frontier) }
+func (it *evictionIterator) Less(i, j int) bool {
+	return it.heap.before(it.heap.entries[it.frontier[i]], it.heap.entries[it.frontier[j]])
+}
+func (it *evictionIterator) Swap(i, j int) {
+	it.frontier[i], it.frontier[j] = it.frontier[j], it.frontier[i]
+}
+func (it *evictionIterator) Push(x any) { it.frontier = append(it.frontier, x.(int)) }
+func (it *evictionIterator) Pop() any {
+	n := len(it.frontier)
+	index := it.frontier[n-1]
+	it.frontier = it.frontier[:n-1]
+	return index
+}
+
+// next returns the next entry in eviction order, nil once all were visited.
+func (it *evictionIterator) next() *evictionEntry {
+	if len(it.frontier) == 0 {
+		return nil
+	}
+	index := heap.Pop(it).(int)
+	for _, child := range []int{2*index + 1, 2*index + 2} {
+		if child < len(it.heap.entries) {
+			heap.Push(it, child)
+		}
+	}
+	return it.heap.entries[index]
+}
+
+// evictionIndex maintains the accounts of the pool ordered for the enforcement
+// of the global limits: the pending accounts by descending number of pending
+// transactions, and the queued accounts by ascending heartbeat. It spares the
+// truncation of the pending and queued sets from scanning all the accounts when
+// the pool is at capacity.
+//
+// The accounts whose lists changed are marked dirty under any pool lock mode,
+// the heaps only catching up on them with the pool lock held exclusively.
+type evictionIndex struct {
+	dirty   map[common.Address]struct{} // Accounts changed since the last sync
+	dirtyMu sync.Mutex                  // Leaf lock guarding the dirty accounts
+
+	pending *evictionHeap // Pending accounts, the largest first
+	queued  *evictionHeap // Queued accounts, the least recently active first
+}
+
+func newEvictionIndex() *evictionIndex {
+	return &evictionIndex{
+		dirty: make(map[common.Address]struct{}),
+		pending: newEvictionHeap(func(a, b *evictionEntry) bool {
+			return a.size > b.size
+		}),
+		queued: newEvictionHeap(func(a, b *evictionEntry) bool {
+			return a.beat.Before(b.beat)
+		}),
+	}
+}
+
+// mark flags the lists or the heartbeat of an account as changed.
+func (idx *evictionIndex) mark(addr common.Address) {
+	idx.dirtyMu.Lock()
+	idx.dirty[addr] = struct{}{}
+	idx.dirtyMu.Unlock()
+}
+
+// syncEviction updates the eviction heaps with the accounts changed since the
+// last sync. It assumes that the pool lock is held exclusively.
+func (pool *LegacyPool) syncEviction() {
+	idx := pool.evict
+
+	idx.dirtyMu.Lock()
+	dirty := idx.dirty
+	idx.dirty = make(map[common.Address]struct{})
+	idx.dirtyMu.Unlock()
+
+	for addr := range dirty {
+		beat := pool.beats[addr]
+		if list := pool.pending[addr]; list != nil {
+			idx.pending.update(addr, list.Len(), beat)
+		} else {
+			idx.pending.update(addr, 0, beat)
+		}
+		if list := pool.queue[addr]; list != nil {
+			idx.queued.update(addr, list.Len(), beat)
+		} else {
+			idx.queued.update(addr, 0, beat)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Tests that the eviction heaps walk the accounts in eviction order across
+// random updates and removals, keeping track of the total size.
+func TestEvictionHeapIterate(t *testing.T) {
+	var (
+		idx   = newEvictionIndex()
+		sizes = make(map[common.Address]int)
+		beats = make(map[common.Address]time.Time)
+		start = time.Now()
+	)
+	for i := 0; i < 2000; i++ {
+		addr := common.Address{byte(rand.Intn(256))}
+		size, beat := rand.Intn(64), start.Add(time.Duration(rand.Intn(1000))*time.Second)
+		if size == 0 {
+			delete(sizes, addr)
+			delete(beats, addr)
+		} else {
+			sizes[addr], beats[addr] = size, beat
+		}
+		idx.pending.update(addr, size, beat)
+		idx.queued.update(addr, size, beat)
+	}
+	var total uint64
+	for _, size := range sizes {
+		total += uint64(size)
+	}
+	for _, h := range []*evictionHeap{idx.pending, idx.queued} {
+		if h.total != total {
+			t.Errorf("total size mismatch: have %d, want %d", h.total, total)
+		}
+		if h.Len() != len(sizes) {
+			t.Errorf("account count mismatch: have %d, want %d", h.Len(), len(sizes))
+		}
+	}
+	// The walk must visit every account once, in order
+	walk := func(h *evictionHeap) []*evictionEntry {
+		var (
+			entries []*evictionEntry
+			it      = h.iterate()
+		)
+		for entry := it.next(); entry != nil; entry = it.next() {
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+	bySize, byBeat := walk(idx.pending), walk(idx.queued)
+	if len(bySize) != len(sizes) || len(byBeat) != len(sizes) {
+		t.Fatalf("walked account count mismatch: have %d and %d, want %d", len(bySize), len(byBeat), len(sizes))
+	}
+	if !sort.SliceIsSorted(bySize, func(i, j int) bool { return bySize[i].size > bySize[j].size }) {
+		t.Errorf("pending accounts not walked by descending size")
+	}
+	if !sort.SliceIsSorted(byBeat, func(i, j int) bool { return byBeat[i].beat.Before(byBeat[j].beat) }) {
+		t.Errorf("queued accounts not walked by ascending heartbeat")
+	}
+	for _, entry := range bySize {
+		if sizes[entry.addr] != entry.size || !beats[entry.addr].Equal(entry.beat) {
+			t.Errorf("account %x mismatch: have %d/%v, want %d/%v", entry.addr, entry.size, entry.beat, sizes[entry.addr], beats[entry.addr])
+		}
+	}
+}
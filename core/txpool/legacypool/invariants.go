@@ -0,0 +1,161 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// invariantSampleAccounts is the maximum number of pending and queued accounts
+// whose transaction lists are inspected in a single sampled invariant check.
+const invariantSampleAccounts = 64
+
+var (
+	invariantCheckMeter     = metrics.NewRegisteredMeter("txpool/invariant/checks", nil)
+	invariantViolationMeter = metrics.NewRegisteredMeter("txpool/invariant/violations", nil)
+	invariantCheckTimer     = metrics.NewRegisteredTimer("txpool/invariant/time", nil)
+)
+
+// checkInvariants verifies the consistency of the pool's internal indices. The
+// global counters are always checked, while the per-account lists and the costs
+// of the payers sponsoring them are only inspected for up to sample accounts of
+// both the pending and the queued sets (0 means every account). It assumes that
+// the pool lock is held exclusively.
+func (pool *LegacyPool) checkInvariants(sample int) error {
+	// Ensure the total transaction set is consistent with pending + queued
+	pending, queued := pool.stats()
+	if total := pool.all.Count(); total != pending+queued {
+		return fmt.Errorf("total transaction count %d != %d pending + %d queued", total, pending, queued)
+	}
+	// The eviction heaps must catch up on the changed accounts to match the lists
+	pool.syncEviction()
+	if have := pool.evict.pending.total; have != uint64(pending) {
+		return fmt.Errorf("eviction index pending count %d != %d", have, pending)
+	}
+	if have := pool.evict.queued.total; have != uint64(queued) {
+		return fmt.Errorf("eviction index queued count %d != %d", have, queued)
+	}
+	if have, want := pool.evict.pending.Len(), len(pool.pending); have != want {
+		return fmt.Errorf("eviction index pending accounts %d != %d", have, want)
+	}
+	if have, want := pool.evict.queued.Len(), len(pool.queue); have != want {
+		return fmt.Errorf("eviction index queued accounts %d != %d", have, want)
+	}
+	// The priced heaps may contain stale entries, but they must never lose track
+	// of a remote transaction
+	priced, remote := pool.priced.urgent.Len()+pool.priced.floating.Len(), pool.all.RemoteCount()
+	if priced < remote {
+		return fmt.Errorf("priced transaction count %d < %d remote", priced, remote)
+	}
+	// Inspect a sample of the pending accounts. Map iteration order is random,
+	// so consecutive checks will cover different accounts.
+	var (
+		checked   = 0
+		payerCost = make(map[common.Address]*big.Int) // Costs of the sampled sponsored transactions
+	)
+	for addr, list := range pool.pending {
+		if sample > 0 && checked >= sample {
+			break
+		}
+		checked++
+
+		// Find the last transaction
+		var last uint64
+		for nonce, tx := range list.txs.items {
+			if last < nonce {
+				last = nonce
+			}
+			if pool.all.Get(tx.Hash()) == nil {
+				return fmt.Errorf("pending tx %x of %x missing from lookup", tx.Hash(), addr)
+			}
+			if tx.Type() != types.SponsoredTxType {
+				continue
+			}
+			payer, err := types.Payer(list.signer, tx)
+			if err != nil {
+				return fmt.Errorf("failed to recover payer of pending tx %x: %v", tx.Hash(), err)
+			}
+			if payerCost[payer] == nil {
+				payerCost[payer] = new(big.Int)
+			}
+			payerCost[payer].Add(payerCost[payer], new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas())))
+		}
+		if nonce := pool.pendingNonces.get(addr); nonce != last+1 {
+			return fmt.Errorf("pending nonce mismatch for %x: have %v, want %v", addr, nonce, last+1)
+		}
+		if list.totalcost.Sign() < 0 {
+			return fmt.Errorf("totalcost of %x went negative: %v", addr, list.totalcost)
+		}
+	}
+	// The payer costs must be the sum of all pending sponsored transactions. A
+	// sample only covers some of the transactions of its payers, so their costs
+	// must merely cover the sampled ones.
+	if sample == 0 && len(payerCost) != len(pool.totalPendingPayerCost) {
+		return fmt.Errorf("payer count mismatch: have %d, want %d", len(pool.totalPendingPayerCost), len(payerCost))
+	}
+	for payer, cost := range payerCost {
+		have := pool.totalPendingPayerCost[payer]
+		if have == nil || (sample == 0 && have.Cmp(cost) != 0) || have.Cmp(cost) < 0 {
+			return fmt.Errorf("payer %x cost mismatch: have %v, want %v", payer, have, cost)
+		}
+	}
+	// Inspect a sample of the queued accounts
+	checked = 0
+	for addr, list := range pool.queue {
+		if sample > 0 && checked >= sample {
+			break
+		}
+		checked++
+
+		for _, tx := range list.txs.items {
+			if pool.all.Get(tx.Hash()) == nil {
+				return fmt.Errorf("queued tx %x of %x missing from lookup", tx.Hash(), addr)
+			}
+		}
+		if _, ok := pool.beats[addr]; !ok {
+			return fmt.Errorf("queued account %x has no heartbeat", addr)
+		}
+	}
+	return nil
+}
+
+// runInvariantCheck performs a sampled invariant check, reporting the outcome
+// via metrics and dumping the pool statistics if a violation is detected.
+func (pool *LegacyPool) runInvariantCheck() {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	defer invariantCheckTimer.UpdateSince(time.Now())
+	invariantCheckMeter.Mark(1)
+
+	if err := pool.checkInvariants(invariantSampleAccounts); err != nil {
+		invariantViolationMeter.Mark(1)
+
+		pending, queued := pool.stats()
+		log.Error("Transaction pool invariant violated", "err", err,
+			"pending", pending, "queued", queued, "all", pool.all.Count(), "remotes", pool.all.RemoteCount(),
+			"urgent", pool.priced.urgent.Len(), "floating", pool.priced.floating.Len(),
+			"payers", len(pool.totalPendingPayerCost), "slots", pool.all.Slots(), "bytes", pool.all.Bytes())
+	}
+}
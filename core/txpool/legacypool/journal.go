@@ -20,6 +20,7 @@ import (
 	"errors"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -45,6 +46,7 @@ func (*devNull) Close() error                      { return nil }
 type journal struct {
 	path   string         // Filesystem path to store the transactions at
 	writer io.WriteCloser // Output stream to write new transactions into
+	lock   sync.Mutex     // Lock protecting the writer from concurrent inserts
 }
 
 // newTxJournal creates a new transaction journal to
@@ -118,6 +120,9 @@ func (journal *journal) load(add func([]*types.Transaction) []error) error {
 
 // insert adds the specified transaction to the local disk journal.
 func (journal *journal) insert(tx *types.Transaction) error {
+	journal.lock.Lock()
+	defer journal.lock.Unlock()
+
 	if journal.writer == nil {
 		return errNoActiveJournal
 	}
@@ -130,6 +135,9 @@ func (journal *journal) insert(tx *types.Transaction) error {
 // rotate regenerates the transaction journal based on the current contents of
 // the transaction pool.
 func (journal *journal) rotate(all map[common.Address]types.Transactions) error {
+	journal.lock.Lock()
+	defer journal.lock.Unlock()
+
 	// Close the current journal (if any is open)
 	if journal.writer != nil {
 		if err := journal.writer.Close(); err != nil {
@@ -170,10 +178,19 @@ func (journal *journal) rotate(all map[common.Address]types.Transactions) error
 
 // close flushes the transaction journal contents to disk and closes the file.
 func (journal *journal) close() error {
+	journal.lock.Lock()
+	defer journal.lock.Unlock()
+
 	var err error
 
 	if journal.writer != nil {
-		err = journal.writer.Close()
+		// Make sure the journaled transactions hit the disk before returning
+		if file, ok := journal.writer.(*os.File); ok {
+			err = file.Sync()
+		}
+		if cerr := journal.writer.Close(); err == nil {
+			err = cerr
+		}
 		journal.writer = nil
 	}
 	return err
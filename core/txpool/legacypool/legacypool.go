@@ -17,16 +17,13 @@
 package legacypool
 
 import (
-	"container/heap"
 	"math"
 	"math/big"
-	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/prque"
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
@@ -74,6 +71,7 @@ var (
 
 	// General tx metrics
 	knownTxMeter       = metrics.NewRegisteredMeter("txpool/known", nil)
+	minedTxMeter       = metrics.NewRegisteredMeter("txpool/mined", nil)
 	validTxMeter       = metrics.NewRegisteredMeter("txpool/valid", nil)
 	invalidTxMeter     = metrics.NewRegisteredMeter("txpool/invalid", nil)
 	underpricedTxMeter = metrics.NewRegisteredMeter("txpool/underpriced", nil)
@@ -92,6 +90,7 @@ var (
 	queuedGauge  = metrics.NewRegisteredGauge("txpool/queued", nil)
 	localGauge   = metrics.NewRegisteredGauge("txpool/local", nil)
 	slotsGauge   = metrics.NewRegisteredGauge("txpool/slots", nil)
+	bytesGauge   = metrics.NewRegisteredGauge("txpool/bytes", nil)
 
 	reheapTimer = metrics.NewRegisteredTimer("txpool/reheap", nil)
 )
@@ -113,6 +112,14 @@ type Config struct {
 	Journal   string           // Journal of local transactions to survive node restarts
 	Rejournal time.Duration    // Time interval to regenerate the local transaction journal
 
+	LocalsJournal string // Journal of the accounts treated as local to survive node restarts
+
+	MinedJournal string // Journal of recently mined transaction hashes to survive node restarts
+	Snapshot     string // Snapshot of all the pooled transactions written on shutdown and restored on startup ("" = disabled)
+	MinedLimit   uint64 // Number of recently mined transaction hashes remembered to reject re-gossiped ones (0 = disabled)
+
+	ManagedAccounts []common.Address // Accounts whose nonces are allocated by the pool to concurrent submitters
+
 	PriceLimit uint64 // Minimum gas price to enforce for acceptance into the pool
 	PriceBump  uint64 // Minimum price bump percentage to replace an already existing transaction (nonce)
 
@@ -120,8 +127,21 @@ type Config struct {
 	GlobalSlots  uint64 // Maximum number of executable transaction slots for all accounts
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
+	GlobalBytes  uint64 // Maximum encoded size in bytes of all pooled transactions (0 = slots only)
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	InvariantCheck time.Duration // Time interval between sampled pool invariant checks (0 = disabled)
+
+	ForkLookahead uint64        // Number of upcoming blocks whose fork fee rules admitted transactions must satisfy
+	ExpirySlack   time.Duration // Margin ahead of the head timestamp within which sponsored transactions are expired
+
+	TipFloors txpool.TipFloors // Minimum gas tips per transaction type, enforced on top of the pool wide one
+
+	ChurnLimit uint64 // Maximum number of remote admissions and replacements per second across all pools (0 = unlimited)
+	ChurnBurst uint64 // Number of remote admissions and replacements allowed in a burst (0 = churn limit)
+
+	SponsorWhitelist txpool.SponsorWhitelist // Payers whose sponsored transactions are admitted across all pools (nil = any payer)
 }
 
 // DefaultConfig contains the default configurations for the transaction pool.
@@ -129,6 +149,11 @@ var DefaultConfig = Config{
 	Journal:   "transactions.rlp",
 	Rejournal: time.Hour,
 
+	LocalsJournal: "txlocals.rlp",
+
+	MinedJournal: "minedtxs.rlp",
+	MinedLimit:   65536,
+
 	PriceLimit: 1,
 	PriceBump:  10,
 
@@ -136,6 +161,7 @@ var DefaultConfig = Config{
 	GlobalSlots:  4096 + 1024, // urgent + floating queue capacity with 4:1 ratio
 	AccountQueue: 64,
 	GlobalQueue:  1024,
+	GlobalBytes:  512 * 1024 * 1024,
 
 	Lifetime: 3 * time.Hour,
 }
@@ -176,6 +202,10 @@ func (config *Config) sanitize() Config {
 		log.Warn("Sanitizing invalid txpool lifetime", "provided", conf.Lifetime, "updated", DefaultConfig.Lifetime)
 		conf.Lifetime = DefaultConfig.Lifetime
 	}
+	if conf.ExpirySlack < 0 {
+		log.Warn("Sanitizing invalid txpool expiry slack", "provided", conf.ExpirySlack, "updated", DefaultConfig.ExpirySlack)
+		conf.ExpirySlack = DefaultConfig.ExpirySlack
+	}
 	return conf
 }
 
@@ -192,16 +222,25 @@ type LegacyPool struct {
 	chain       blockChain
 	gasTip      atomic.Pointer[big.Int]
 	txFeed      event.Feed
+	announcer   *txAnnouncer // Assembler of the announced batches, owned by the reorg loop
 	scope       event.SubscriptionScope
 	signer      types.Signer
-	mu          sync.RWMutex
+	mu          sync.RWMutex // Pool-wide lock, see locks.go for the locking discipline
+
+	locks      accountLocks // Striped locks guarding the lists of each account
+	accountsMu sync.RWMutex // Lock guarding the account maps and the payer costs
+	stateMu    sync.Mutex   // Lock serializing reads of the current state
 
 	currentHead   atomic.Pointer[types.Header] // Current head of the blockchain
 	currentState  *state.StateDB               // Current state in the blockchain head
 	pendingNonces *noncer                      // Pending state tracking virtual nonces
 
-	locals  *accountSet // Set of local transaction to exempt from eviction rules
-	journal *journal    // Journal of local transaction to back up to disk
+	locals        *accountSet           // Set of local transaction to exempt from eviction rules
+	journal       *journal              // Journal of local transaction to back up to disk
+	localsJournal *localsJournal        // Journal of the local accounts to back up to disk
+	mined         *minedSet             // Recently mined transactions to reject re-gossiped ones (nil = disabled)
+	nonces        *nonceManager         // Nonce allocator of the managed accounts
+	pauses        *txpool.AccountPauses // Accounts whose transactions are paused by the operator
 
 	reserve txpool.AddressReserver       // Address reserver to ensure exclusivity across subpools
 	pending map[common.Address]*list     // All currently processable transactions
@@ -209,6 +248,7 @@ type LegacyPool struct {
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *lookup                      // All transactions to allow lookups
 	priced  *pricedList                  // All transactions sorted by price
+	evict   *evictionIndex               // Accounts ordered for the enforcement of the global limits
 
 	reqResetCh      chan *txpoolResetRequest
 	reqPromoteCh    chan *accountSet
@@ -216,6 +256,9 @@ type LegacyPool struct {
 	reorgDoneCh     chan chan struct{}
 	reorgShutdownCh chan struct{}  // requests shutdown of scheduleReorgLoop
 	wg              sync.WaitGroup // tracks loop, scheduleReorgLoop
+	admissions      sync.WaitGroup // tracks in-flight Add batches
+	closeMu         sync.RWMutex   // Lock ordering admissions against the shutdown
+	closed          bool           // Whether the pool stopped accepting admissions
 	initDoneCh      chan struct{}  // is closed once the pool is initialized (for tests)
 
 	changesSinceReorg int // A counter for how many drops we've performed in-between reorg.
@@ -243,6 +286,7 @@ func New(config Config, chainconfig *params.ChainConfig, chain blockChain) *Lega
 		queue:                 make(map[common.Address]*list),
 		beats:                 make(map[common.Address]time.Time),
 		all:                   newLookup(),
+		evict:                 newEvictionIndex(),
 		reqResetCh:            make(chan *txpoolResetRequest),
 		reqPromoteCh:          make(chan *accountSet),
 		queueTxEventCh:        make(chan *types.Transaction),
@@ -256,12 +300,36 @@ func New(config Config, chainconfig *params.ChainConfig, chain blockChain) *Lega
 		log.Info("Setting new local account", "address", addr)
 		pool.locals.add(addr)
 	}
+	// If local transactions and journaling is enabled, load the local accounts
+	if !config.NoLocals && config.LocalsJournal != "" {
+		pool.localsJournal = newLocalsJournal(config.LocalsJournal)
+		accounts, err := pool.localsJournal.load()
+		if err != nil {
+			log.Warn("Failed to load local account journal", "err", err)
+		}
+		for _, addr := range accounts {
+			pool.locals.add(addr)
+		}
+		if len(accounts) > 0 {
+			log.Info("Loaded local account journal", "accounts", len(accounts))
+		}
+	}
+	pool.nonces = newNonceManager(config.ManagedAccounts)
+	pool.pauses = txpool.NewAccountPauses()
+	pool.announcer = newTxAnnouncer(pool.all)
 	pool.priced = newPricedList(pool.all)
 
 	// If local transactions and journaling is enabled, load from disk
 	if !config.NoLocals && config.Journal != "" {
 		pool.journal = newTxJournal(config.Journal)
 	}
+	if config.MinedLimit > 0 {
+		mined, err := newMinedSet(config.MinedJournal, int(config.MinedLimit))
+		if err != nil {
+			log.Warn("Failed to create mined transaction set", "err", err)
+		}
+		pool.mined = mined
+	}
 
 	return pool
 }
@@ -314,6 +382,11 @@ func (pool *LegacyPool) Init(gasTip uint64, head *types.Header, reserve txpool.A
 			log.Warn("Failed to rotate transaction journal", "err", err)
 		}
 	}
+	if pool.config.Snapshot != "" {
+		if err := pool.restoreSnapshot(pool.config.Snapshot); err != nil {
+			log.Warn("Failed to restore transaction pool snapshot", "err", err)
+		}
+	}
 	pool.wg.Add(1)
 	go pool.loop()
 
@@ -332,11 +405,20 @@ func (pool *LegacyPool) loop() {
 		report  = time.NewTicker(statsReportInterval)
 		evict   = time.NewTicker(evictionInterval)
 		journal = time.NewTicker(pool.config.Rejournal)
+
+		// Sampled invariant checks are disabled unless explicitly requested
+		invariant <-chan time.Time
 	)
 	defer report.Stop()
 	defer evict.Stop()
 	defer journal.Stop()
 
+	if pool.config.InvariantCheck > 0 {
+		ticker := time.NewTicker(pool.config.InvariantCheck)
+		defer ticker.Stop()
+		invariant = ticker.C
+	}
+
 	// Notify tests that the init phase is done
 	close(pool.initDoneCh)
 	for {
@@ -347,9 +429,9 @@ func (pool *LegacyPool) loop() {
 
 		// Handle stats reporting ticks
 		case <-report.C:
-			pool.mu.RLock()
+			pool.mu.Lock()
 			pending, queued := pool.stats()
-			pool.mu.RUnlock()
+			pool.mu.Unlock()
 			stales := int(atomic.LoadInt64(&pool.priced.stales))
 
 			if pending != prevPending || queued != prevQueued || stales != prevStales {
@@ -385,22 +467,81 @@ func (pool *LegacyPool) loop() {
 				}
 				pool.mu.Unlock()
 			}
+			if pool.localsJournal != nil {
+				pool.mu.Lock()
+				if err := pool.journalLocals(); err != nil {
+					log.Warn("Failed to journal local accounts", "err", err)
+				}
+				pool.mu.Unlock()
+			}
+			if pool.mined != nil {
+				if err := pool.mined.journal(); err != nil {
+					log.Warn("Failed to journal mined transactions", "err", err)
+				}
+			}
+
+		// Handle sampled pool invariant checks
+		case <-invariant:
+			pool.runInvariantCheck()
 		}
 	}
 }
 
-// Stop terminates the transaction pool.
+// Close terminates the transaction pool. New admissions are rejected, the ones
+// already in flight are drained and promoted, after which the local transactions
+// are written out to the journal before the pool goroutines are stopped.
 func (pool *LegacyPool) Close() error {
+	// Reject any new admissions and wait for the in-flight ones to be queued up
+	pool.closeMu.Lock()
+	pool.closed = true
+	pool.closeMu.Unlock()
+
+	pool.admissions.Wait()
+
+	// Run a final reorg if the pool was initialized, so that the asynchronously
+	// added batches are promoted and the pool limits enforced before the journal
+	// is written out.
+	if pool.currentHead.Load() != nil {
+		<-pool.requestPromoteExecutables(newAccountSet(pool.signer))
+	}
 	close(pool.reorgShutdownCh)
 	// Unsubscribe all subscriptions registered from txpool
 	pool.scope.Close()
 
 	pool.wg.Wait()
 
+	pool.mu.Lock()
+	pending, queued := pool.stats()
+	locals := pool.local()
+	if err := pool.journalLocals(); err != nil {
+		log.Warn("Failed to journal local accounts", "err", err)
+	}
+	pool.mu.Unlock()
+
+	var journaled int
 	if pool.journal != nil {
-		pool.journal.close()
+		if err := pool.journal.rotate(locals); err != nil {
+			log.Warn("Failed to rotate local tx journal", "err", err)
+		} else {
+			for _, txs := range locals {
+				journaled += len(txs)
+			}
+		}
+		if err := pool.journal.close(); err != nil {
+			log.Warn("Failed to close local tx journal", "err", err)
+		}
+	}
+	if pool.mined != nil {
+		if err := pool.mined.journal(); err != nil {
+			log.Warn("Failed to journal mined transactions", "err", err)
+		}
 	}
-	log.Info("Transaction pool stopped")
+	if pool.config.Snapshot != "" && pool.currentHead.Load() != nil {
+		if err := pool.writeSnapshot(pool.config.Snapshot); err != nil {
+			log.Warn("Failed to snapshot transaction pool", "err", err)
+		}
+	}
+	log.Info("Transaction pool stopped", "executable", pending, "queued", queued, "journaled", journaled)
 	return nil
 }
 
@@ -430,15 +571,22 @@ func (pool *LegacyPool) SetGasTip(tip *big.Int) {
 	if tip.Cmp(old) > 0 {
 		// pool.priced is sorted by GasFeeCap, so we have to iterate through pool.all instead
 		isVenoki := pool.chainconfig.IsVenoki(pool.currentHead.Load().Number)
-		drop := pool.all.RemotesBelowTip(tip, isVenoki)
+		drop := pool.all.RemotesBelowTip(pool.minTip, isVenoki)
 		for _, tx := range drop {
 			pool.removeTx(tx.Hash(), false, true)
+			txpool.MarkTipDropped(tx.Type())
 		}
 		pool.priced.Removed(len(drop))
 	}
 	log.Info("Transaction pool tip threshold updated", "tip", tip)
 }
 
+// minTip returns the gas tip a remote transaction of the given type needs to
+// pay to be accepted into the pool.
+func (pool *LegacyPool) minTip(txType byte) *big.Int {
+	return pool.config.TipFloors.MinTip(txType, pool.gasTip.Load())
+}
+
 // Nonce returns the next nonce of an account, with all transactions executable
 // by the pool already applied on top.
 func (pool *LegacyPool) Nonce(addr common.Address) uint64 {
@@ -451,8 +599,8 @@ func (pool *LegacyPool) Nonce(addr common.Address) uint64 {
 // Stats retrieves the current pool stats, namely the number of pending and the
 // number of queued (non-executable) transactions.
 func (pool *LegacyPool) Stats() (int, int) {
-	pool.mu.RLock()
-	defer pool.mu.RUnlock()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
 
 	return pool.stats()
 }
@@ -488,18 +636,44 @@ func (pool *LegacyPool) Content() (map[common.Address][]*types.Transaction, map[
 	return pending, queued
 }
 
+// ContentByType retrieves the pending and queued transactions of the given type
+// only, grouped by account and sorted by nonce. Unlike Content, the transactions
+// of the other types are not copied, nor are the accounts without any match
+// returned.
+func (pool *LegacyPool) ContentByType(txType uint8) (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pending := make(map[common.Address][]*types.Transaction)
+	for addr, list := range pool.pending {
+		if txs := list.FlattenType(txType); len(txs) > 0 {
+			pending[addr] = txs
+		}
+	}
+	queued := make(map[common.Address][]*types.Transaction)
+	for addr, list := range pool.queue {
+		if txs := list.FlattenType(txType); len(txs) > 0 {
+			queued[addr] = txs
+		}
+	}
+	return pending, queued
+}
+
 // ContentFrom retrieves the data content of the transaction pool, returning the
 // pending as well as queued transactions of this address, grouped by nonce.
 func (pool *LegacyPool) ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
 
+	unlock := pool.locks.lock(addr)
+	defer unlock()
+
 	var pending types.Transactions
-	if list, ok := pool.pending[addr]; ok {
+	if list := pool.pendingList(addr); list != nil {
 		pending = list.Flatten()
 	}
 	var queued types.Transactions
-	if list, ok := pool.queue[addr]; ok {
+	if list := pool.queuedList(addr); list != nil {
 		queued = list.Flatten()
 	}
 	return pending, queued
@@ -512,20 +686,40 @@ func (pool *LegacyPool) Pending(filter *txpool.PendingFilter) map[common.Address
 		return nil
 	}
 
-	pool.mu.Lock()
-	defer pool.mu.Unlock()
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
 
 	// Convert the new uint256.Int types to the old big.Int ones used by the legacy pool
 	baseFeeBig := filter.BaseFee.ToBig()
 
-	pending := make(map[common.Address][]*txpool.LazyTransaction, len(pool.pending))
+	// Snapshot the pending lists and flatten each of them under its own account
+	// lock, so that admissions and reorgs of other accounts are not blocked.
+	pool.accountsMu.RLock()
+	lists := make(map[common.Address]*list, len(pool.pending))
 	for addr, list := range pool.pending {
-		txs := list.Flatten()
+		lists[addr] = list
+	}
+	pool.accountsMu.RUnlock()
+
+	pending := make(map[common.Address][]*txpool.LazyTransaction, len(lists))
+	for addr, list := range lists {
+		unlock := pool.locks.lock(addr)
+		var txs types.Transactions
+		if filter.OnlyTxTypes == nil {
+			txs = list.Flatten()
+		} else {
+			for _, tx := range list.txs.flatten() {
+				if filter.AllowsType(tx.Type()) {
+					txs = append(txs, tx)
+				}
+			}
+		}
+		unlock()
 
 		// If the miner requests tip enforcement, cap the lists now
 		if filter.EnforceTip && !pool.locals.contains(addr) {
 			for i, tx := range txs {
-				if tx.EffectiveGasTipIntCmp(pool.gasTip.Load(), baseFeeBig) < 0 {
+				if tx.EffectiveGasTipIntCmp(pool.minTip(tx.Type()), baseFeeBig) < 0 {
 					txs = txs[:i]
 					break
 				}
@@ -576,6 +770,9 @@ func (pool *LegacyPool) local() map[common.Address]types.Transactions {
 }
 
 func (pool *LegacyPool) getAccountPendingCost(account common.Address) *big.Int {
+	pool.accountsMu.RLock()
+	defer pool.accountsMu.RUnlock()
+
 	pendingCost := new(big.Int)
 	if list := pool.pending[account]; list != nil {
 		pendingCost.Add(pendingCost, list.totalcost)
@@ -586,6 +783,14 @@ func (pool *LegacyPool) getAccountPendingCost(account common.Address) *big.Int {
 	return pendingCost
 }
 
+// expiryTime returns the timestamp at or before which sponsored transactions are
+// considered expired on top of the given head, including the configured slack.
+// The pool only ever measures expiry against the chain head, never the local
+// clock, so that it agrees with the block producers on what is includable.
+func (pool *LegacyPool) expiryTime(head *types.Header) uint64 {
+	return head.Time + uint64(pool.config.ExpirySlack/time.Second)
+}
+
 // validateTxBasics checks whether a transaction is valid according to the consensus
 // rules, but does not check state-dependent validation such as sufficient balance.
 // This check is meant as an early check which only needs to be performed once,
@@ -598,8 +803,10 @@ func (pool *LegacyPool) validateTxBasics(tx *types.Transaction, local bool) erro
 			1<<types.AccessListTxType |
 			1<<types.DynamicFeeTxType,
 		MaxSize:           txMaxSize,
-		MinTip:            pool.gasTip.Load(),
+		MinTip:            pool.minTip(tx.Type()),
 		AcceptSponsoredTx: true,
+		ForkLookahead:     pool.config.ForkLookahead,
+		ExpirySlack:       uint64(pool.config.ExpirySlack / time.Second),
 	}
 	if local {
 		opts.MinTip = new(big.Int)
@@ -621,10 +828,10 @@ func (pool *LegacyPool) validateTx(tx *types.Transaction, local bool) error {
 		// The global and account slot and queue are checked later
 		UsedAndLeftSlots: func(addr common.Address) (int, int) {
 			var have int
-			if list := pool.pending[addr]; list != nil {
+			if list := pool.pendingList(addr); list != nil {
 				have += list.Len()
 			}
-			if list := pool.queue[addr]; list != nil {
+			if list := pool.queuedList(addr); list != nil {
 				have += list.Len()
 			}
 			return have, math.MaxInt
@@ -633,14 +840,18 @@ func (pool *LegacyPool) validateTx(tx *types.Transaction, local bool) error {
 			return pool.getAccountPendingCost(addr)
 		},
 		ExistingCost: func(addr common.Address, nonce uint64) *big.Int {
-			if list := pool.pending[addr]; list != nil {
+			if list := pool.pendingList(addr); list != nil {
 				if tx := list.txs.Get(nonce); tx != nil {
-					return tx.Cost()
+					senderCost, _, _, _ := tx.CostBreakdown(nil, nil)
+					return senderCost
 				}
 			}
 			return nil
 		},
 	}
+	pool.stateMu.Lock()
+	defer pool.stateMu.Unlock()
+
 	if err := txpool.ValidateTransactionWithState(tx, pool.signer, opts); err != nil {
 		return err
 	}
@@ -655,32 +866,58 @@ func (pool *LegacyPool) validateTx(tx *types.Transaction, local bool) error {
 // If a newly added transaction is marked as local, its sending account will be
 // be added to the allowlist, preventing any associated transaction from being dropped
 // out of the pool due to pricing constraints.
+//
+// Note, this method assumes the pool lock is held exclusively!
 func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, err error) {
-	// If the transaction is already known, discard it
+	return pool.addTx(tx, local, true)
+}
+
+// addTx implements add, either with the pool lock held exclusively, or shared
+// with other submitters. In the latter case only the account locks of the sender
+// and payer are taken, and errExclusiveAccess is returned if the admission needs
+// to touch other accounts, i.e. evicting transactions to make room or migrating
+// the transactions of a new local account.
+func (pool *LegacyPool) addTx(tx *types.Transaction, local bool, exclusive bool) (replaced bool, err error) {
+	// Sender is cached by the basic validation, an invalid one fails below
 	hash := tx.Hash()
+	from, _ := types.Sender(pool.signer, tx)
+
+	// If the transaction is paused by the operator, discard it
+	if err := pool.pauses.Check(pool.signer, tx, from, time.Now()); err != nil {
+		log.Trace("Discarding paused transaction", "hash", hash, "err", err)
+		pausedTxMeter.Mark(1)
+		return false, err
+	}
+	// Sponsored transactions are validated against and charged to the balance
+	// of their payer too, so hold its account lock for the whole admission
+	accounts := []common.Address{from}
+	if tx.Type() == types.SponsoredTxType {
+		if payer, err := types.Payer(pool.signer, tx); err == nil {
+			accounts = append(accounts, payer)
+		}
+	}
+	unlock := pool.locks.lock(accounts...)
+	defer unlock()
+
+	// If the transaction is already known, discard it
 	if pool.all.Get(hash) != nil {
 		log.Trace("Discarding already known transaction", "hash", hash)
 		knownTxMeter.Mark(1)
 		return false, txpool.ErrAlreadyKnown
 	}
-
 	// If the transaction fails basic validation, discard it
 	if err := pool.validateTx(tx, local); err != nil {
 		log.Trace("Discarding invalid transaction", "hash", hash, "err", err)
 		invalidTxMeter.Mark(1)
 		return false, err
 	}
-
-	// already validated by this point
-	from, _ := types.Sender(pool.signer, tx)
-
+	// Marking a new local account migrates its transactions across the pool
+	if !exclusive && local && !pool.locals.contains(from) {
+		return false, errExclusiveAccess
+	}
 	// If the address is not yet known, request exclusivity to track the account
 	// only by this subpool until all transactions are evicted
-	var (
-		_, hasPending = pool.pending[from]
-		_, hasQueued  = pool.queue[from]
-	)
-	if !hasPending && !hasQueued {
+	if pool.pendingList(from) == nil && pool.queuedList(from) == nil {
 		if err := pool.reserve(from, true); err != nil {
 			return false, err
 		}
@@ -697,8 +934,27 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		}()
 	}
 
-	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
+	// Concurrent submitters claim the room of their transaction up front, so
+	// that they cannot overshoot the pool limits together between the check
+	// and the insertion. The claim is released once the transaction is in.
+	if !exclusive {
+		if !pool.all.Reserve(tx, int(pool.config.GlobalSlots+pool.config.GlobalQueue), pool.config.GlobalBytes) {
+			return false, errExclusiveAccess
+		}
+		defer pool.all.Release(tx)
+	}
+	// If the transaction pool is full, either by slots or by bytes, discard
+	// underpriced transactions
+	overSlots := pool.all.Slots() + numSlots(tx) - int(pool.config.GlobalSlots+pool.config.GlobalQueue)
+	overBytes := 0
+	if size := pool.all.Bytes() + uint64(tx.Size()); pool.config.GlobalBytes > 0 && size > pool.config.GlobalBytes {
+		overBytes = int(size - pool.config.GlobalBytes)
+	}
+	if overSlots > 0 || overBytes > 0 {
+		// Making room evicts transactions of other accounts
+		if !exclusive {
+			return false, errExclusiveAccess
+		}
 		// If the new transaction is underpriced, don't accept it
 		if !local && pool.priced.Underpriced(tx) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
@@ -718,7 +974,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		// New transaction is better than our worse ones, make room for it.
 		// If it's a local transaction, forcibly discard all available transactions.
 		// Otherwise if we can't make enough room for new one, abort the operation.
-		drop, success := pool.priced.Discard(pool.all.Slots()-int(pool.config.GlobalSlots+pool.config.GlobalQueue)+numSlots(tx), local)
+		drop, success := pool.priced.Discard(overSlots, overBytes, local)
 
 		// Special case, we still can't make the room for the new remote one.
 		if !local && !success {
@@ -739,9 +995,7 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 			}
 			// Add all transactions back to the priced queue
 			if replacesPending {
-				for _, dropTx := range drop {
-					heap.Push(&pool.priced.urgent, dropTx)
-				}
+				pool.priced.PutBack(drop)
 				log.Trace("Discarding future transaction replacing pending tx", "hash", hash)
 				return false, txpool.ErrFutureReplacePending
 			}
@@ -760,13 +1014,17 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 	}
 
 	// Try to replace an existing transaction in the pending pool
-	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
-		if !pool.signer.Equal(pool.pending[from].Signer()) {
-			pool.pending[from].UpdateSigner(pool.signer)
+	if list := pool.pendingList(from); list != nil && list.Overlaps(tx) {
+		// Pending lists account the pool-wide payer costs, guard them
+		pool.accountsMu.Lock()
+		if !pool.signer.Equal(list.Signer()) {
+			list.UpdateSigner(pool.signer)
 		}
-
 		// Nonce already pending, check if required price bump is met
-		inserted, old := list.Add(tx, pool.config.PriceBump)
+		inserted, old := list.Add(tx, pool.config.PriceBump, pool.all.Rotated)
+		pool.markRotation(list, old, tx)
+		pool.accountsMu.Unlock()
+
 		if !inserted {
 			pendingDiscardMeter.Mark(1)
 			return false, txpool.ErrReplaceUnderpriced
@@ -780,11 +1038,15 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 		pool.all.Add(tx, local)
 		pool.priced.Put(tx, local)
 		pool.journalTx(from, tx)
+		pool.nonces.release(from, tx.Nonce())
 		pool.queueTxEvent(tx)
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// Successful promotion, bump the heartbeat
+		pool.accountsMu.Lock()
 		pool.beats[from] = time.Now()
+		pool.accountsMu.Unlock()
+		pool.evict.mark(from)
 		return old != nil, nil
 	}
 	// New transaction isn't replacing a pending one, push into queue
@@ -794,14 +1056,13 @@ func (pool *LegacyPool) add(tx *types.Transaction, local bool) (replaced bool, e
 	}
 	// Mark local addresses and journal local transactions
 	if local && !pool.locals.contains(from) {
-		log.Info("Setting new local account", "address", from)
-		pool.locals.add(from)
-		pool.priced.Removed(pool.all.RemoteToLocals(pool.locals)) // Migrate the remotes if it's marked as local first time.
+		pool.markLocal(from) // Migrate the remotes if it's marked as local first time.
 	}
 	if local {
 		localGauge.Inc(1)
 	}
 	pool.journalTx(from, tx)
+	pool.nonces.release(from, tx.Nonce())
 
 	log.Trace("Pooled new future transaction", "hash", hash, "from", from, "to", tx.To())
 	return replaced, nil
@@ -823,16 +1084,22 @@ func (pool *LegacyPool) isFuture(from common.Address, tx *types.Transaction) boo
 
 // enqueueTx inserts a new transaction into the non-executable transaction queue.
 //
-// Note, this method assumes the pool lock is held!
+// Note, this method assumes the pool lock and the sender's account lock are held!
 func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local bool, addAll bool) (bool, error) {
 	// Try to insert the transaction into the future queue
 	from, _ := types.Sender(pool.signer, tx) // already validated
+	pool.evict.mark(from)
+
+	pool.accountsMu.Lock()
 	if pool.queue[from] == nil {
 		pool.queue[from] = newList(false, pool.signer, nil)
 	} else if !pool.signer.Equal(pool.queue[from].Signer()) {
 		pool.queue[from].UpdateSigner(pool.signer)
 	}
-	inserted, old := pool.queue[from].Add(tx, pool.config.PriceBump)
+	list := pool.queue[from]
+	pool.accountsMu.Unlock()
+
+	inserted, old := list.Add(tx, pool.config.PriceBump, pool.all.Rotated)
 	if !inserted {
 		// An older transaction was better, discard this
 		queuedDiscardMeter.Mark(1)
@@ -840,6 +1107,7 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
+		pool.markRotation(list, old, tx)
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed(1)
 		queuedReplaceMeter.Mark(1)
@@ -857,9 +1125,11 @@ func (pool *LegacyPool) enqueueTx(hash common.Hash, tx *types.Transaction, local
 		pool.priced.Put(tx, local)
 	}
 	// If we never record the heartbeat, do it right now.
+	pool.accountsMu.Lock()
 	if _, exist := pool.beats[from]; !exist {
 		pool.beats[from] = time.Now()
 	}
+	pool.accountsMu.Unlock()
 	return old != nil, nil
 }
 
@@ -875,12 +1145,24 @@ func (pool *LegacyPool) journalTx(from common.Address, tx *types.Transaction) {
 	}
 }
 
+// markRotation marks tx if it replaced old in the list by rotating its payer
+// without the price bump, so that another rotation has to pay it. It must run
+// before old is dropped from the lookup.
+func (pool *LegacyPool) markRotation(list *list, old, tx *types.Transaction) {
+	if old != nil && list.freeRotation(old, tx, pool.all.Rotated) {
+		pool.all.MarkRotated(tx.Hash())
+	}
+}
+
 // promoteTx adds a transaction to the pending (processable) list of transactions
 // and returns whether it was inserted or an older was better.
 //
-// Note, this method assumes the pool lock is held!
+// Note, this method assumes the pool lock and the account lock are held!
 func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *types.Transaction) bool {
+	pool.evict.mark(addr)
+
 	// Try to insert the transaction into the pending queue
+	pool.accountsMu.Lock()
 	if pool.pending[addr] == nil {
 		pool.pending[addr] = newList(true, pool.signer, pool.totalPendingPayerCost)
 	} else if !pool.signer.Equal(pool.pending[addr].Signer()) {
@@ -888,7 +1170,14 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 	}
 	list := pool.pending[addr]
 
-	inserted, old := list.Add(tx, pool.config.PriceBump)
+	inserted, old := list.Add(tx, pool.config.PriceBump, pool.all.Rotated)
+	if inserted {
+		// Successful promotion, bump the heartbeat
+		pool.beats[addr] = time.Now()
+		pool.markRotation(list, old, tx)
+	}
+	pool.accountsMu.Unlock()
+
 	if !inserted {
 		// An older transaction was better, discard this
 		pool.all.Remove(hash)
@@ -907,9 +1196,6 @@ func (pool *LegacyPool) promoteTx(addr common.Address, hash common.Hash, tx *typ
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.pendingNonces.set(addr, tx.Nonce()+1)
-
-	// Successful promotion, bump the heartbeat
-	pool.beats[addr] = time.Now()
 	return true
 }
 
@@ -963,11 +1249,24 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 	// Do not treat as local if local transactions have been disabled
 	local = local && !pool.config.NoLocals
 
+	errs := make([]error, len(txs))
+
+	// Track the batch until it's queued for promotion, so that a concurrent
+	// shutdown waits for it instead of dropping it
+	pool.closeMu.RLock()
+	if pool.closed {
+		pool.closeMu.RUnlock()
+		for i := range errs {
+			errs[i] = txpool.ErrPoolClosed
+		}
+		return errs
+	}
+	pool.admissions.Add(1)
+	pool.closeMu.RUnlock()
+	defer pool.admissions.Done()
+
 	// Filter out known ones without obtaining the pool lock or recovering signatures
-	var (
-		errs = make([]error, len(txs))
-		news = make([]*types.Transaction, 0, len(txs))
-	)
+	news := make([]*types.Transaction, 0, len(txs))
 	for i, tx := range txs {
 		// If the transaction is known, pre-set the error slot
 		if pool.all.Get(tx.Hash()) != nil {
@@ -975,6 +1274,12 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 			knownTxMeter.Mark(1)
 			continue
 		}
+		// If the transaction was recently mined, it can't be valid any more
+		if pool.mined != nil && pool.mined.contains(tx.Hash()) {
+			errs[i] = txpool.ErrAlreadyKnown
+			minedTxMeter.Mark(1)
+			continue
+		}
 		// Exclude transactions with basic errors, e.g invalid signatures and
 		// insufficient intrinsic gas as soon as possible and cache senders
 		// in transactions before obtaining lock
@@ -991,9 +1296,7 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 	}
 
 	// Process all the new transaction and merge any errors into the original slice
-	pool.mu.Lock()
-	newErrs, dirtyAddrs := pool.addTxsLocked(news, local)
-	pool.mu.Unlock()
+	newErrs, dirtyAddrs := pool.addTxs(news, local)
 
 	var nilSlot = 0
 	for _, err := range newErrs {
@@ -1011,18 +1314,40 @@ func (pool *LegacyPool) Add(txs []*types.Transaction, local, sync bool) []error
 	return errs
 }
 
-// addTxsLocked attempts to queue a batch of transactions if they are valid.
-// The transaction pool lock must be held.
-func (pool *LegacyPool) addTxsLocked(txs []*types.Transaction, local bool) ([]error, *accountSet) {
-	dirty := newAccountSet(pool.signer)
-	errs := make([]error, len(txs))
+// addTxs attempts to queue a batch of transactions if they are valid. The
+// transactions are admitted concurrently with other submitters, only those that
+// need to touch other accounts are retried with exclusive access to the pool.
+func (pool *LegacyPool) addTxs(txs []*types.Transaction, local bool) ([]error, *accountSet) {
+	var (
+		dirty   = newAccountSet(pool.signer)
+		errs    = make([]error, len(txs))
+		retries []int
+	)
+	pool.mu.RLock()
 	for i, tx := range txs {
-		replaced, err := pool.add(tx, local)
+		replaced, err := pool.addTx(tx, local, false)
+		if err == errExclusiveAccess {
+			retries = append(retries, i)
+			continue
+		}
 		errs[i] = err
 		if err == nil && !replaced {
 			dirty.addTx(tx)
 		}
 	}
+	pool.mu.RUnlock()
+
+	if len(retries) > 0 {
+		pool.mu.Lock()
+		for _, i := range retries {
+			replaced, err := pool.add(txs[i], local)
+			errs[i] = err
+			if err == nil && !replaced {
+				dirty.addTx(txs[i])
+			}
+		}
+		pool.mu.Unlock()
+	}
 	validTxMeter.Mark(int64(len(dirty.accounts)))
 	return errs, dirty
 }
@@ -1039,9 +1364,12 @@ func (pool *LegacyPool) Status(hash common.Hash) txpool.TxStatus {
 	pool.mu.RLock()
 	defer pool.mu.RUnlock()
 
-	if txList := pool.pending[from]; txList != nil && txList.txs.items[tx.Nonce()] != nil {
+	unlock := pool.locks.lock(from)
+	defer unlock()
+
+	if txList := pool.pendingList(from); txList != nil && txList.txs.items[tx.Nonce()] != nil {
 		return txpool.TxStatusPending
-	} else if txList := pool.queue[from]; txList != nil && txList.txs.items[tx.Nonce()] != nil {
+	} else if txList := pool.queuedList(from); txList != nil && txList.txs.items[tx.Nonce()] != nil {
 		return txpool.TxStatusQueued
 	}
 	return txpool.TxStatusUnknown
@@ -1067,6 +1395,8 @@ func (pool *LegacyPool) Has(hash common.Hash) bool {
 // which could lead to a premature release of the lock.
 //
 // Returns the number of transactions removed from the pending queue.
+//
+// Note, this method assumes the pool lock is held exclusively!
 func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bool) int {
 	// Fetch the transaction we wish to delete
 	tx := pool.all.Get(hash)
@@ -1074,6 +1404,7 @@ func (pool *LegacyPool) removeTx(hash common.Hash, outofbound bool, unreserve bo
 		return 0
 	}
 	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
+	pool.evict.mark(addr)
 
 	if unreserve {
 		defer func() {
@@ -1244,11 +1575,14 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 		// the flatten operation can be avoided.
 		promoteAddrs = dirtyAccounts.flatten()
 	}
-	pool.mu.Lock()
 	if reset != nil {
 		// Reset from the old head to the new, rescheduling any reorged transactions
 		pool.reset(reset.oldHead, reset.newHead)
-
+	}
+	// Promotions and demotions are confined to single accounts, run them with
+	// shared access to let admissions and readers of other accounts proceed.
+	pool.mu.RLock()
+	if reset != nil {
 		// Nonces were reset, discard any events that became stale
 		for addr := range events {
 			events[addr].Forward(pool.pendingNonces.get(addr))
@@ -1257,10 +1591,12 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 			}
 		}
 		// Reset needs promote for all addresses
+		pool.accountsMu.RLock()
 		promoteAddrs = make([]common.Address, 0, len(pool.queue))
 		for addr := range pool.queue {
 			promoteAddrs = append(promoteAddrs, addr)
 		}
+		pool.accountsMu.RUnlock()
 	}
 	// Check for pending transactions for every account that sent new ones
 	promoted := pool.promoteExecutables(promoteAddrs)
@@ -1270,6 +1606,12 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 	// because of another transaction (e.g. higher gas price).
 	if reset != nil {
 		pool.demoteUnexecutables()
+	}
+	pool.mu.RUnlock()
+
+	// Enforcing the pool limits needs a consistent view of all the accounts
+	pool.mu.Lock()
+	if reset != nil {
 		if reset.newHead != nil {
 			if pool.chainconfig.IsLondon(new(big.Int).Add(reset.newHead.Number, big.NewInt(1))) {
 				// london fork enabled, reset given the base fee
@@ -1304,17 +1646,16 @@ func (pool *LegacyPool) runReorg(done chan struct{}, reset *txpoolResetRequest,
 		}
 		events[addr].Put(tx)
 	}
-	if len(events) > 0 {
-		var txs []*types.Transaction
-		for _, set := range events {
-			txs = append(txs, set.Flatten()...)
-		}
-		pool.txFeed.Send(core.NewTxsEvent{Txs: txs})
+	if ev, ok := pool.announcer.batch(events); ok {
+		pool.txFeed.Send(ev)
 	}
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
 // of the transaction pool is valid with regard to the chain state.
+//
+// The chain is traversed without holding the pool lock, it's only taken to swap
+// in the new state, so the caller must not hold it.
 func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 	// If we're reorging an old state, reinject all dropped transactions
 	var reinject types.Transactions
@@ -1376,8 +1717,16 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 					}
 				}
 				reinject = types.TxDifference(discarded, included)
+				if pool.mined != nil {
+					pool.mined.remove(reinject)
+					pool.mined.add(included)
+				}
 			}
 		}
+	} else if pool.mined != nil && oldHead != nil {
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			pool.mined.add(block.Transactions())
+		}
 	}
 	// Initialize the internal state to the current head
 	if newHead == nil {
@@ -1389,88 +1738,118 @@ func (pool *LegacyPool) reset(oldHead, newHead *types.Header) {
 		return
 	}
 
+	pool.mu.Lock()
 	pool.currentHead.Store(newHead)
 	pool.currentState = statedb
 	pool.pendingNonces = newNoncer(statedb)
+	pool.mu.Unlock()
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	core.SenderCacher.Recover(pool.signer, reinject)
-	pool.addTxsLocked(reinject, false)
+	pool.addTxs(reinject, false)
 }
 
 // promoteExecutables moves transactions that have become processable from the
 // future queue to the set of pending transactions. During this process, all
 // invalidated transactions (low nonce, low balance) are deleted.
+//
+// Note, this method assumes the pool lock is held, either shared or exclusively.
 func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.Transaction {
 	// Track the promoted transactions to broadcast them at once
 	var promoted []*types.Transaction
 
 	// Iterate over all accounts and promote any executable transactions
 	for _, addr := range accounts {
-		list := pool.queue[addr]
-		if list == nil {
-			continue // Just in case someone calls with a non existing account
-		}
-		// Drop all transactions that are deemed too old (low nonce)
-		forwards := list.Forward(pool.currentState.GetNonce(addr))
-		for _, tx := range forwards {
-			hash := tx.Hash()
-			pool.all.Remove(hash)
-		}
-		log.Trace("Removed old queued transactions", "count", len(forwards))
-		payers := list.Payers()
-		payerCostLimit := make(map[common.Address]*big.Int)
-		for _, payer := range payers {
-			payerCostLimit[payer] = pool.currentState.GetBalance(payer)
-		}
+		promoted = append(promoted, pool.promoteAccount(addr)...)
+	}
+	return promoted
+}
 
-		// Drop all transactions that are too costly (low balance or out of gas)
-		head := pool.currentHead.Load()
-		maxGas := txpool.CurrentBlockMaxGas(pool.chainconfig, head)
-		drops, _ := list.Filter(pool.currentState.GetBalance(addr), maxGas, payerCostLimit, head.Time)
-		for _, tx := range drops {
-			hash := tx.Hash()
-			pool.all.Remove(hash)
+// promoteAccount moves the processable transactions of a single account from the
+// future queue to the pending set, holding the lock of the account.
+func (pool *LegacyPool) promoteAccount(addr common.Address) []*types.Transaction {
+	unlock := pool.locks.lock(addr)
+	defer unlock()
+
+	pool.evict.mark(addr)
+
+	list := pool.queuedList(addr)
+	if list == nil {
+		return nil // Just in case someone calls with a non existing account
+	}
+	// Drop all transactions that are deemed too old (low nonce)
+	pool.stateMu.Lock()
+	nonce := pool.currentState.GetNonce(addr)
+	pool.stateMu.Unlock()
+
+	forwards := list.Forward(nonce)
+	for _, tx := range forwards {
+		hash := tx.Hash()
+		pool.all.Remove(hash)
+	}
+	log.Trace("Removed old queued transactions", "count", len(forwards))
+	payers := list.Payers()
+	payerCostLimit := make(map[common.Address]*big.Int)
+
+	pool.stateMu.Lock()
+	for _, payer := range payers {
+		payerCostLimit[payer] = pool.currentState.GetBalance(payer)
+	}
+	balance := pool.currentState.GetBalance(addr)
+	pool.stateMu.Unlock()
+
+	// Drop all transactions that are too costly (low balance or out of gas)
+	head := pool.currentHead.Load()
+	maxGas := txpool.CurrentBlockMaxGas(pool.chainconfig, head)
+	drops, _ := list.Filter(balance, maxGas, payerCostLimit, pool.expiryTime(head))
+	for _, tx := range drops {
+		hash := tx.Hash()
+		pool.all.Remove(hash)
+	}
+	log.Trace("Removed unpayable queued transactions", "count", len(drops))
+	queuedNofundsMeter.Mark(int64(len(drops)))
+
+	// Gather all executable transactions and promote them
+	var promoted []*types.Transaction
+
+	readies := list.Ready(pool.pendingNonces.get(addr))
+	for _, tx := range readies {
+		hash := tx.Hash()
+		if pool.promoteTx(addr, hash, tx) {
+			promoted = append(promoted, tx)
 		}
-		log.Trace("Removed unpayable queued transactions", "count", len(drops))
-		queuedNofundsMeter.Mark(int64(len(drops)))
+	}
+	log.Trace("Promoted queued transactions", "count", len(promoted))
+	queuedGauge.Dec(int64(len(readies)))
 
-		// Gather all executable transactions and promote them
-		readies := list.Ready(pool.pendingNonces.get(addr))
-		for _, tx := range readies {
+	// Drop all transactions over the allowed limit
+	var caps types.Transactions
+	if !pool.locals.contains(addr) {
+		caps = list.Cap(int(pool.config.AccountQueue))
+		for _, tx := range caps {
 			hash := tx.Hash()
-			if pool.promoteTx(addr, hash, tx) {
-				promoted = append(promoted, tx)
-			}
-		}
-		log.Trace("Promoted queued transactions", "count", len(promoted))
-		queuedGauge.Dec(int64(len(readies)))
-
-		// Drop all transactions over the allowed limit
-		var caps types.Transactions
-		if !pool.locals.contains(addr) {
-			caps = list.Cap(int(pool.config.AccountQueue))
-			for _, tx := range caps {
-				hash := tx.Hash()
-				pool.all.Remove(hash)
-				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
-			}
-			queuedRateLimitMeter.Mark(int64(len(caps)))
-		}
-		// Mark all the items dropped as removed
-		pool.priced.Removed(len(forwards) + len(drops) + len(caps))
-		queuedGauge.Dec(int64(len(forwards) + len(drops) + len(caps)))
-		if pool.locals.contains(addr) {
-			localGauge.Dec(int64(len(forwards) + len(drops) + len(caps)))
+			pool.all.Remove(hash)
+			log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
 		}
-		// Delete the entire queue entry if it became empty.
-		if list.Empty() {
-			delete(pool.queue, addr)
-			delete(pool.beats, addr)
-			if _, ok := pool.pending[addr]; !ok {
-				pool.reserve(addr, false)
-			}
+		queuedRateLimitMeter.Mark(int64(len(caps)))
+	}
+	// Mark all the items dropped as removed
+	pool.priced.Removed(len(forwards) + len(drops) + len(caps))
+	queuedGauge.Dec(int64(len(forwards) + len(drops) + len(caps)))
+	if pool.locals.contains(addr) {
+		localGauge.Dec(int64(len(forwards) + len(drops) + len(caps)))
+	}
+	// Delete the entire queue entry if it became empty.
+	if list.Empty() {
+		pool.accountsMu.Lock()
+		delete(pool.queue, addr)
+		delete(pool.beats, addr)
+		_, hasPending := pool.pending[addr]
+		pool.accountsMu.Unlock()
+
+		if !hasPending {
+			pool.reserve(addr, false)
 		}
 	}
 	return promoted
@@ -1480,34 +1859,41 @@ func (pool *LegacyPool) promoteExecutables(accounts []common.Address) []*types.T
 // pending limit. The algorithm tries to reduce transaction counts by an approximately
 // equal number for all for accounts with many pending transactions.
 func (pool *LegacyPool) truncatePending() {
-	pending := uint64(0)
-	for _, list := range pool.pending {
-		pending += uint64(list.Len())
-	}
+	pool.syncEviction()
+
+	pending := pool.evict.pending.total
 	if pending <= pool.config.GlobalSlots {
 		return
 	}
 
 	pendingBeforeCap := pending
-	// Assemble a spam order to penalize large transactors first
-	spammers := prque.New(nil)
-	for addr, list := range pool.pending {
-		// Only evict transactions from high rollers
-		if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
-			spammers.Push(addr, int64(list.Len()))
-		}
-	}
+	// Walk the accounts by descending size to penalize large transactors first
+	spammers := pool.evict.pending.iterate()
+
 	// Gradually drop transactions from offenders
 	offenders := []common.Address{}
-	for pending > pool.config.GlobalSlots && !spammers.Empty() {
-		// Retrieve the next offender if not local address
-		offender, _ := spammers.Pop()
-		offenders = append(offenders, offender.(common.Address))
+	defer func() {
+		for _, addr := range offenders {
+			pool.evict.mark(addr)
+		}
+	}()
+	for pending > pool.config.GlobalSlots {
+		// Retrieve the next offender if not local address, only evicting
+		// transactions from high rollers
+		next := spammers.next()
+		if next == nil || uint64(next.size) <= pool.config.AccountSlots {
+			break
+		}
+		if pool.locals.contains(next.addr) {
+			continue
+		}
+		offender := next.addr
+		offenders = append(offenders, offender)
 
 		// Equalize balances until all the same or below threshold
 		if len(offenders) > 1 {
 			// Calculate the equalization threshold for all current offenders
-			threshold := pool.pending[offender.(common.Address)].Len()
+			threshold := pool.pending[offender].Len()
 
 			// Iteratively reduce all offenders until below limit or threshold reached
 			for pending > pool.config.GlobalSlots && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
@@ -1565,29 +1951,26 @@ func (pool *LegacyPool) truncatePending() {
 
 // truncateQueue drops the oldes transactions in the queue if the pool is above the global queue limit.
 func (pool *LegacyPool) truncateQueue() {
-	queued := uint64(0)
-	for _, list := range pool.queue {
-		queued += uint64(list.Len())
-	}
+	pool.syncEviction()
+
+	queued := pool.evict.queued.total
 	if queued <= pool.config.GlobalQueue {
 		return
 	}
-
-	// Sort all accounts with queued transactions by heartbeat
-	addresses := make(addressesByHeartbeat, 0, len(pool.queue))
-	for addr := range pool.queue {
-		if !pool.locals.contains(addr) { // don't drop locals
-			addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
-		}
-	}
-	sort.Sort(sort.Reverse(addresses))
+	// Walk the accounts with queued transactions by heartbeat, the oldest first.
+	// The removals only mark the accounts dirty, leaving the walked heap intact.
+	stale := pool.evict.queued.iterate()
 
 	// Drop transactions until the total is below the limit or only locals remain
-	for drop := queued - pool.config.GlobalQueue; drop > 0 && len(addresses) > 0; {
-		addr := addresses[len(addresses)-1]
-		list := pool.queue[addr.address]
-
-		addresses = addresses[:len(addresses)-1]
+	for drop := queued - pool.config.GlobalQueue; drop > 0; {
+		next := stale.next()
+		if next == nil {
+			break
+		}
+		if pool.locals.contains(next.addr) { // don't drop locals
+			continue
+		}
+		list := pool.queue[next.addr]
 
 		// Drop all transactions if they are less than the overflow
 		if size := uint64(list.Len()); size <= drop {
@@ -1615,80 +1998,113 @@ func (pool *LegacyPool) truncateQueue() {
 // Note: transactions are not marked as removed in the priced list because re-heaping
 // is always explicitly triggered by SetBaseFee and it would be unnecessary and wasteful
 // to trigger a re-heap is this function
+//
+// Note, this method assumes the pool lock is held, either shared or exclusively.
 func (pool *LegacyPool) demoteUnexecutables() {
+	pool.accountsMu.RLock()
+	accounts := make([]common.Address, 0, len(pool.pending))
+	for addr := range pool.pending {
+		accounts = append(accounts, addr)
+	}
+	pool.accountsMu.RUnlock()
+
 	// Iterate over all accounts and demote any non-executable transactions
-	for addr, list := range pool.pending {
-		nonce := pool.currentState.GetNonce(addr)
+	for _, addr := range accounts {
+		pool.demoteAccount(addr)
+	}
+}
 
-		// Drop all transactions that are deemed too old (low nonce)
-		olds := list.Forward(nonce)
-		for _, tx := range olds {
-			hash := tx.Hash()
-			pool.all.Remove(hash)
-			log.Trace("Removed old pending transaction", "hash", hash)
-		}
-		payers := list.Payers()
-		payerCostLimit := make(map[common.Address]*big.Int)
-		for _, payer := range payers {
-			payerCostLimit[payer] = pool.currentState.GetBalance(payer)
-		}
+// demoteAccount removes the invalid and processed transactions from the pending
+// set of a single account, holding the lock of the account.
+func (pool *LegacyPool) demoteAccount(addr common.Address) {
+	unlock := pool.locks.lock(addr)
+	defer unlock()
 
-		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
-		head := pool.currentHead.Load()
-		maxGas := txpool.CurrentBlockMaxGas(pool.chainconfig, head)
-		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), maxGas, payerCostLimit, head.Time)
-		for _, tx := range drops {
-			hash := tx.Hash()
-			log.Trace("Removed unpayable pending transaction", "hash", hash)
-			pool.all.Remove(hash)
-		}
-		pendingNofundsMeter.Mark(int64(len(drops)))
+	pool.evict.mark(addr)
+
+	list := pool.pendingList(addr)
+	if list == nil {
+		return
+	}
+	pool.stateMu.Lock()
+	nonce := pool.currentState.GetNonce(addr)
+	pool.stateMu.Unlock()
+
+	// Drop all transactions that are deemed too old (low nonce). Pending lists
+	// account the pool-wide payer costs, so they are modified under the lock.
+	pool.accountsMu.Lock()
+	olds := list.Forward(nonce)
+	payers := list.Payers()
+	pool.accountsMu.Unlock()
 
-		for _, tx := range invalids {
+	for _, tx := range olds {
+		hash := tx.Hash()
+		pool.all.Remove(hash)
+		log.Trace("Removed old pending transaction", "hash", hash)
+	}
+	payerCostLimit := make(map[common.Address]*big.Int)
+
+	pool.stateMu.Lock()
+	for _, payer := range payers {
+		payerCostLimit[payer] = pool.currentState.GetBalance(payer)
+	}
+	balance := pool.currentState.GetBalance(addr)
+	pool.stateMu.Unlock()
+
+	// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
+	head := pool.currentHead.Load()
+	maxGas := txpool.CurrentBlockMaxGas(pool.chainconfig, head)
+
+	pool.accountsMu.Lock()
+	drops, invalids := list.Filter(balance, maxGas, payerCostLimit, pool.expiryTime(head))
+	pool.accountsMu.Unlock()
+
+	for _, tx := range drops {
+		hash := tx.Hash()
+		log.Trace("Removed unpayable pending transaction", "hash", hash)
+		pool.all.Remove(hash)
+	}
+	pendingNofundsMeter.Mark(int64(len(drops)))
+
+	for _, tx := range invalids {
+		hash := tx.Hash()
+		log.Trace("Demoting pending transaction", "hash", hash)
+
+		// Internal shuffle shouldn't touch the lookup set.
+		pool.enqueueTx(hash, tx, false, false)
+	}
+	pendingGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
+	if pool.locals.contains(addr) {
+		localGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
+	}
+	// If there's a gap in front, alert (should never happen) and postpone all transactions
+	if list.Len() > 0 && list.txs.Get(nonce) == nil {
+		pool.accountsMu.Lock()
+		gapped := list.Cap(0)
+		pool.accountsMu.Unlock()
+
+		for _, tx := range gapped {
 			hash := tx.Hash()
-			log.Trace("Demoting pending transaction", "hash", hash)
+			log.Error("Demoting invalidated transaction", "hash", hash)
 
 			// Internal shuffle shouldn't touch the lookup set.
 			pool.enqueueTx(hash, tx, false, false)
 		}
-		pendingGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
-		if pool.locals.contains(addr) {
-			localGauge.Dec(int64(len(olds) + len(drops) + len(invalids)))
-		}
-		// If there's a gap in front, alert (should never happen) and postpone all transactions
-		if list.Len() > 0 && list.txs.Get(nonce) == nil {
-			gapped := list.Cap(0)
-			for _, tx := range gapped {
-				hash := tx.Hash()
-				log.Error("Demoting invalidated transaction", "hash", hash)
+		pendingGauge.Dec(int64(len(gapped)))
+	}
+	// Delete the entire pending entry if it became empty.
+	if list.Empty() {
+		pool.accountsMu.Lock()
+		delete(pool.pending, addr)
+		_, hasQueued := pool.queue[addr]
+		pool.accountsMu.Unlock()
 
-				// Internal shuffle shouldn't touch the lookup set.
-				pool.enqueueTx(hash, tx, false, false)
-			}
-			pendingGauge.Dec(int64(len(gapped)))
-		}
-		// Delete the entire pending entry if it became empty.
-		if list.Empty() {
-			delete(pool.pending, addr)
-			if _, ok := pool.queue[addr]; !ok {
-				pool.reserve(addr, false)
-			}
+		if !hasQueued {
+			pool.reserve(addr, false)
 		}
 	}
 }
 
-// addressByHeartbeat is an account address tagged with its last activity timestamp.
-type addressByHeartbeat struct {
-	address   common.Address
-	heartbeat time.Time
-}
-
-type addressesByHeartbeat []addressByHeartbeat
-
-func (a addressesByHeartbeat) Len() int           { return len(a) }
-func (a addressesByHeartbeat) Less(i, j int) bool { return a[i].heartbeat.Before(a[j].heartbeat) }
-func (a addressesByHeartbeat) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-
 // accountSet is simply a set of addresses to check for existence, and a signer
 // capable of deriving addresses from transactions.
 type accountSet struct {
@@ -1731,6 +2147,12 @@ func (as *accountSet) add(addr common.Address) {
 	as.cache = nil
 }
 
+// remove deletes an address from the set.
+func (as *accountSet) remove(addr common.Address) {
+	delete(as.accounts, addr)
+	as.cache = nil
+}
+
 // addTx adds the sender of tx into the set.
 func (as *accountSet) addTx(tx *types.Transaction) {
 	if addr, err := types.Sender(as.signer, tx); err == nil {
@@ -1772,17 +2194,25 @@ func (as *accountSet) merge(other *accountSet) {
 // This lookup set combines the notion of "local transactions", which is useful
 // to build upper-level structure.
 type lookup struct {
-	slots   int
-	lock    sync.RWMutex
-	locals  map[common.Hash]*types.Transaction
-	remotes map[common.Hash]*types.Transaction
+	slots        int
+	bytes        uint64
+	claimed      int    // Slots claimed by transactions being admitted concurrently
+	claimedBytes uint64 // Bytes claimed by transactions being admitted concurrently
+	lock         sync.RWMutex
+	locals       map[common.Hash]*types.Transaction
+	remotes      map[common.Hash]*types.Transaction
+
+	announced map[common.Hash]struct{} // Pooled transactions already announced
+	rotated   map[common.Hash]struct{} // Pooled transactions that rotated a payer without a price bump
 }
 
 // newLookup returns a new lookup structure.
 func newLookup() *lookup {
 	return &lookup{
-		locals:  make(map[common.Hash]*types.Transaction),
-		remotes: make(map[common.Hash]*types.Transaction),
+		locals:    make(map[common.Hash]*types.Transaction),
+		remotes:   make(map[common.Hash]*types.Transaction),
+		announced: make(map[common.Hash]struct{}),
+		rotated:   make(map[common.Hash]struct{}),
 	}
 }
 
@@ -1868,6 +2298,14 @@ func (t *lookup) Slots() int {
 	return t.slots
 }
 
+// Bytes returns the current encoded size of the transactions in the lookup.
+func (t *lookup) Bytes() uint64 {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.bytes
+}
+
 // Add adds a transaction to the lookup.
 func (t *lookup) Add(tx *types.Transaction, local bool) {
 	t.lock.Lock()
@@ -1875,6 +2313,8 @@ func (t *lookup) Add(tx *types.Transaction, local bool) {
 
 	t.slots += numSlots(tx)
 	slotsGauge.Update(int64(t.slots))
+	t.bytes += uint64(tx.Size())
+	bytesGauge.Update(int64(t.bytes))
 
 	if local {
 		t.locals[tx.Hash()] = tx
@@ -1883,6 +2323,33 @@ func (t *lookup) Add(tx *types.Transaction, local bool) {
 	}
 }
 
+// Reserve claims the room of a transaction about to be added to the lookup,
+// reporting whether it fits into the given slot and byte limits together with
+// the pooled and already claimed transactions. A zero byte limit is ignored.
+func (t *lookup) Reserve(tx *types.Transaction, slots int, bytes uint64) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.slots+t.claimed+numSlots(tx) > slots {
+		return false
+	}
+	if bytes > 0 && t.bytes+t.claimedBytes+uint64(tx.Size()) > bytes {
+		return false
+	}
+	t.claimed += numSlots(tx)
+	t.claimedBytes += uint64(tx.Size())
+	return true
+}
+
+// Release drops the room claimed by a transaction in Reserve.
+func (t *lookup) Release(tx *types.Transaction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.claimed -= numSlots(tx)
+	t.claimedBytes -= uint64(tx.Size())
+}
+
 // Remove removes a transaction from the lookup.
 func (t *lookup) Remove(hash common.Hash) {
 	t.lock.Lock()
@@ -1898,9 +2365,47 @@ func (t *lookup) Remove(hash common.Hash) {
 	}
 	t.slots -= numSlots(tx)
 	slotsGauge.Update(int64(t.slots))
+	t.bytes -= uint64(tx.Size())
+	bytesGauge.Update(int64(t.bytes))
 
 	delete(t.locals, hash)
 	delete(t.remotes, hash)
+	delete(t.announced, hash)
+	delete(t.rotated, hash)
+}
+
+// Announce marks a transaction as announced, reporting whether it was not yet
+// since it entered the pool. Transactions no longer pooled are not tracked.
+func (t *lookup) Announce(hash common.Hash) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.announced[hash]; ok {
+		return false
+	}
+	if t.locals[hash] != nil || t.remotes[hash] != nil {
+		t.announced[hash] = struct{}{}
+	}
+	return true
+}
+
+// MarkRotated marks a transaction as having replaced another one by rotating its
+// payer without the price bump.
+func (t *lookup) MarkRotated(hash common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.rotated[hash] = struct{}{}
+}
+
+// Rotated reports whether a transaction replaced another one by rotating its
+// payer without the price bump.
+func (t *lookup) Rotated(hash common.Hash) bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	_, ok := t.rotated[hash]
+	return ok
 }
 
 // RemoteToLocals migrates the transactions belongs to the given locals to locals
@@ -1920,10 +2425,31 @@ func (t *lookup) RemoteToLocals(locals *accountSet) int {
 	return migrated
 }
 
-// RemotesBelowTip finds all remote transactions below the given tip threshold.
-func (t *lookup) RemotesBelowTip(threshold *big.Int, isVenoki bool) types.Transactions {
+// LocalsToRemotes migrates the local transactions not belonging to the given
+// locals set anymore to the remotes set, returning them. The assumption is held
+// the locals set is thread-safe to be used.
+func (t *lookup) LocalsToRemotes(locals *accountSet) types.Transactions {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var migrated types.Transactions
+	for hash, tx := range t.locals {
+		if !locals.containsTx(tx) {
+			t.remotes[hash] = tx
+			delete(t.locals, hash)
+			migrated = append(migrated, tx)
+		}
+	}
+	return migrated
+}
+
+// RemotesBelowTip finds all remote transactions below the tip threshold of
+// their type.
+func (t *lookup) RemotesBelowTip(thresholds func(txType byte) *big.Int, isVenoki bool) types.Transactions {
 	found := make(types.Transactions, 0, 128)
 	t.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+		threshold := thresholds(tx.Type())
+
 		// If base fee is enabled, ensure the max tip based on fee cap is high enough
 		var feeCapUnderpriced bool
 		if isVenoki {
@@ -17,6 +17,8 @@
 package legacypool
 
 import (
+	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
@@ -24,6 +26,9 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -38,6 +43,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 )
 
@@ -53,6 +59,8 @@ var (
 func init() {
 	testTxPoolConfig = DefaultConfig
 	testTxPoolConfig.Journal = ""
+	testTxPoolConfig.MinedJournal = ""
+	testTxPoolConfig.LocalsJournal = ""
 
 	cpy := *params.TestChainConfig
 	eip1559Config = &cpy
@@ -142,35 +150,19 @@ func setupPoolWithConfig(config *params.ChainConfig) (*LegacyPool, *ecdsa.Privat
 
 // validatePoolInternals checks various consistency invariants within the pool.
 func validatePoolInternals(pool *LegacyPool) error {
-	pool.mu.RLock()
-	defer pool.mu.RUnlock()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
 
-	// Ensure the total transaction set is consistent with pending + queued
-	pending, queued := pool.stats()
-	if total := pool.all.Count(); total != pending+queued {
-		return fmt.Errorf("total transaction count %d != %d pending + %d queued", total, pending, queued)
+	// Run the full (non-sampled) production invariant checks first
+	if err := pool.checkInvariants(0); err != nil {
+		return err
 	}
+	// After a reheap the priced list must track exactly the remote transactions
 	pool.priced.Reheap()
 	priced, remote := pool.priced.urgent.Len()+pool.priced.floating.Len(), pool.all.RemoteCount()
 	if priced != remote {
 		return fmt.Errorf("total priced transaction count %d != %d", priced, remote)
 	}
-	// Ensure the next nonce to assign is the correct one
-	for addr, txs := range pool.pending {
-		// Find the last transaction
-		var last uint64
-		for nonce := range txs.txs.items {
-			if last < nonce {
-				last = nonce
-			}
-		}
-		if nonce := pool.pendingNonces.get(addr); nonce != last+1 {
-			return fmt.Errorf("pending nonce mismatch: have %v, want %v", nonce, last+1)
-		}
-		if txs.totalcost.Cmp(common.Big0) < 0 {
-			return fmt.Errorf("totalcost went negative: %v", txs.totalcost)
-		}
-	}
 	return nil
 }
 
@@ -330,6 +322,48 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// Tests that contract creations are limited in their init code size and charged
+// for it per word after Shanghai (EIP-3860), but not before.
+func TestInitCodeSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	creation := func(nonce uint64, gas uint64, size int, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewContractCreation(nonce, big.NewInt(0), gas, big.NewInt(1), make([]byte, size)), types.HomesteadSigner{}, key)
+		return tx
+	}
+	// Intrinsic gas of a creation of zero bytes init code at the limit, with
+	// and without the init code word charge
+	var (
+		words   = uint64(params.MaxInitCodeSize+31) / 32
+		legacy  = params.TxGasContractCreation + uint64(params.MaxInitCodeSize)*params.TxDataZeroGas
+		charged = legacy + words*params.InitCodeWordGas
+	)
+	config := *params.TestChainConfig
+	config.ShanghaiBlock = common.Big0
+
+	pool, key := setupPoolWithConfig(&config)
+	defer pool.Close()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err, want := pool.AddRemote(creation(0, charged-1, params.MaxInitCodeSize, key)), core.ErrIntrinsicGas; !errors.Is(err, want) {
+		t.Errorf("init code word charge: want %v have %v", want, err)
+	}
+	if err := pool.AddRemote(creation(0, charged, params.MaxInitCodeSize, key)); err != nil {
+		t.Errorf("init code at limit: want nil have %v", err)
+	}
+	if err, want := pool.AddRemote(creation(1, 2*charged, params.MaxInitCodeSize+1, key)), core.ErrMaxInitCodeSizeExceeded; !errors.Is(err, want) {
+		t.Errorf("init code over limit: want %v have %v", want, err)
+	}
+	// Before Shanghai, the init code is neither limited nor charged
+	pool, key = setupPool()
+	defer pool.Close()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	if err := pool.AddRemote(creation(0, legacy+params.TxDataZeroGas, params.MaxInitCodeSize+1, key)); err != nil {
+		t.Errorf("init code over limit before Shanghai: want nil have %v", err)
+	}
+}
+
 func TestQueue(t *testing.T) {
 	t.Parallel()
 
@@ -1470,7 +1504,8 @@ func TestRepricing(t *testing.T) {
 	if err := pool.AddRemote(pricedTransaction(2, 100000, big.NewInt(2), keys[2])); err != nil {
 		t.Fatalf("failed to add queued transaction: %v", err)
 	}
-	if err := validateEvents(events, 5); err != nil {
+	// The gapped transactions promoted again were already announced
+	if err := validateEvents(events, 2); err != nil {
 		t.Fatalf("post-reprice event firing failed: %v", err)
 	}
 	if err := validatePoolInternals(pool); err != nil {
@@ -1642,7 +1677,8 @@ func TestRepricingDynamicFee(t *testing.T) {
 	if err := pool.AddRemote(tx); err != nil {
 		t.Fatalf("failed to add queued transaction: %v", err)
 	}
-	if err := validateEvents(events, 5); err != nil {
+	// The gapped transactions promoted again were already announced
+	if err := validateEvents(events, 2); err != nil {
 		t.Fatalf("post-reprice event firing failed: %v", err)
 	}
 	if err := validatePoolInternals(pool); err != nil {
@@ -1838,6 +1874,480 @@ func TestUnderpricing(t *testing.T) {
 	}
 }
 
+// Tests that transactions are rejected ahead of a scheduled fork raising the fee
+// floor, but only once the fork falls within the configured lookahead window.
+func TestForkLookaheadFeeFloor(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.VenokiBlock = big.NewInt(50)
+
+	pool, key := setupPoolWithConfig(&config)
+	defer pool.Close()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000000000))
+
+	// The fork is within the window, cheap transactions must be rejected
+	pool.config.ForkLookahead = 100
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), key)); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("cheap transaction error mismatch: have %v, want %v", err, txpool.ErrUnderpriced)
+	}
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(params.MinimumBaseFee+1), key)); err != nil {
+		t.Fatalf("failed to add transaction meeting the upcoming fee floor: %v", err)
+	}
+	// The fork is beyond the window, cheap transactions are still accepted
+	pool.config.ForkLookahead = 10
+	if err := pool.addRemoteSync(pricedTransaction(1, 100000, big.NewInt(1), key)); err != nil {
+		t.Fatalf("failed to add cheap transaction outside the lookahead window: %v", err)
+	}
+}
+
+// Tests that the tip floors of the transaction types are enforced both when
+// admitting remote transactions and when repricing the pool.
+func TestTipFloors(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupPool()
+	defer pool.Close()
+
+	pool.config.TipFloors = txpool.TipFloors{types.DynamicFeeTxType: 5}
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	// Dynamic fee transactions must pay the floor, legacy ones only the pool tip
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(2), keys[0])); err != nil {
+		t.Fatalf("failed to add legacy transaction: %v", err)
+	}
+	if err := pool.addRemoteSync(dynamicFeeTx(0, 100000, big.NewInt(4), big.NewInt(4), keys[1])); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("dynamic fee transaction error mismatch: have %v, want %v", err, txpool.ErrUnderpriced)
+	}
+	if err := pool.addRemoteSync(dynamicFeeTx(0, 100000, big.NewInt(5), big.NewInt(5), keys[2])); err != nil {
+		t.Fatalf("failed to add dynamic fee transaction paying the floor: %v", err)
+	}
+	// Raising the pool tip below the floor only drops the legacy transaction
+	pool.SetGasTip(big.NewInt(3))
+
+	if pending, _ := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 1)
+	}
+	if pool.Get(dynamicFeeTx(0, 100000, big.NewInt(5), big.NewInt(5), keys[2]).Hash()) == nil {
+		t.Fatalf("dynamic fee transaction paying the floor dropped")
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// testBroadcastHook decides on the propagation of the transactions based on
+// their nonces.
+type testBroadcastHook struct{}
+
+func (testBroadcastHook) Name() string { return "test" }
+
+func (testBroadcastHook) Inspect(ctx context.Context, tx *types.Transaction) (txpool.BroadcastDecision, error) {
+	switch tx.Nonce() {
+	case 1:
+		return txpool.BroadcastDecision{Action: txpool.BroadcastSuppress, Tags: []string{"private"}}, nil
+	case 2:
+		return txpool.BroadcastDecision{Action: txpool.BroadcastDelay, Delay: 200 * time.Millisecond}, nil
+	case 3:
+		<-ctx.Done()
+		return txpool.BroadcastDecision{}, ctx.Err()
+	}
+	return txpool.BroadcastDecision{Action: txpool.BroadcastPropagate}, nil
+}
+
+// Tests that the broadcast hooks can delay and suppress the propagation of the
+// admitted transactions.
+func TestBroadcastHooks(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	pool := New(testTxPoolConfig, params.TestChainConfig, blockchain)
+	tp, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer tp.Close()
+
+	tp.RegisterBroadcastHook(testBroadcastHook{}, 50*time.Millisecond, txpool.BroadcastSuppress)
+
+	events := make(chan core.NewTxsEvent, 32)
+	sub := tp.SubscribeBroadcasts(events)
+	defer sub.Unsubscribe()
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	txs := make([]*types.Transaction, 4)
+	for i := range txs {
+		txs[i] = transaction(uint64(i), 100000, key)
+	}
+	for _, err := range tp.Add(txs, false, true) {
+		if err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// Only the plain transaction is propagated right away, the delayed one once
+	// its delay expires
+	for _, want := range []*types.Transaction{txs[0], txs[2]} {
+		select {
+		case ev := <-events:
+			if len(ev.Txs) != 1 || ev.Txs[0].Hash() != want.Hash() {
+				t.Fatalf("broadcast mismatch: have %d txs, want %x", len(ev.Txs), want.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("broadcast of %x timeout", want.Hash())
+		}
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected broadcast of %d txs", len(ev.Txs))
+	case <-time.After(100 * time.Millisecond):
+	}
+	for i, want := range []bool{false, true, false, true} {
+		if withheld := tp.Withheld(txs[i].Hash()); withheld != want {
+			t.Errorf("tx %d: withheld mismatch: have %v, want %v", i, withheld, want)
+		}
+	}
+	if tags := tp.BroadcastTags(txs[1].Hash()); len(tags) != 1 || tags[0] != "private" {
+		t.Errorf("tags mismatch: have %v, want [private]", tags)
+	}
+}
+
+// stallingBroadcastHook holds every inspection until its time allowance runs
+// out.
+type stallingBroadcastHook struct{}
+
+func (stallingBroadcastHook) Name() string { return "stalling" }
+
+func (stallingBroadcastHook) Inspect(ctx context.Context, tx *types.Transaction) (txpool.BroadcastDecision, error) {
+	<-ctx.Done()
+	return txpool.BroadcastDecision{}, ctx.Err()
+}
+
+// Tests that a stalling broadcast hook doesn't hold the pool up, the batches
+// overflowing its queue getting the fallback action without an inspection.
+func TestBroadcastHookOverload(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	pool := New(testTxPoolConfig, params.TestChainConfig, blockchain)
+	tp, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer tp.Close()
+
+	tp.RegisterBroadcastHook(stallingBroadcastHook{}, time.Minute, txpool.BroadcastPropagate)
+
+	events := make(chan core.NewTxsEvent, 1024)
+	sub := tp.SubscribeBroadcasts(events)
+	defer sub.Unsubscribe()
+
+	// Admit the transactions one by one, each batch waiting for the hook
+	const batches = 320
+	for i := 0; i < batches; i++ {
+		key, _ := crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+		if err := tp.Add([]*types.Transaction{transaction(0, 100000, key)}, false, true)[0]; err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	// The overflowing batches are propagated by the fallback right away
+	select {
+	case ev := <-events:
+		if len(ev.Txs) == 0 {
+			t.Fatalf("empty broadcast")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("overflowing batches not propagated")
+	}
+}
+
+// Tests that the churn limit caps the remote admissions, handing back the
+// tokens of the rejected transactions and never limiting the local ones.
+func TestChurnLimit(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	pool := New(testTxPoolConfig, params.TestChainConfig, blockchain)
+	tp, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer tp.Close()
+
+	tp.SetChurnLimit(1, 2)
+
+	key, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	// A transaction rejected by the pool doesn't consume a token
+	if errs := tp.Add([]*types.Transaction{transaction(0, 20000000, key)}, false, true); !errors.Is(errs[0], txpool.ErrGasLimit) {
+		t.Fatalf("oversized tx error mismatch: have %v, want %v", errs[0], txpool.ErrGasLimit)
+	}
+	txs := make([]*types.Transaction, 4)
+	for i := range txs {
+		txs[i] = transaction(uint64(i), 100000, key)
+	}
+	errs := tp.Add(txs, false, true)
+	for i, want := range []error{nil, nil, txpool.ErrChurnLimited, txpool.ErrChurnLimited} {
+		if !errors.Is(errs[i], want) {
+			t.Errorf("tx %d: error mismatch: have %v, want %v", i, errs[i], want)
+		}
+	}
+	// Known transactions are not charged, local ones are exempt
+	if errs := tp.Add(txs[:1], false, true); !errors.Is(errs[0], txpool.ErrAlreadyKnown) {
+		t.Errorf("known tx error mismatch: have %v, want %v", errs[0], txpool.ErrAlreadyKnown)
+	}
+	for i, err := range tp.Add(txs[2:], true, true) {
+		if err != nil {
+			t.Errorf("local tx %d: failed to add: %v", i, err)
+		}
+	}
+	if pending, _ := tp.Stats(); pending != len(txs) {
+		t.Fatalf("pending transactions mismatch: have %d, want %d", pending, len(txs))
+	}
+}
+
+// Tests that the sponsor hook vets the payers of the sponsored transactions,
+// caching its decisions and rejecting the transactions it can't decide on in time.
+func TestSponsorHook(t *testing.T) {
+	t.Parallel()
+
+	chainConfig := *params.TestChainConfig
+	chainConfig.MikoBlock = common.Big0
+	chainConfig.ChainID = big.NewInt(2020)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.ForkLookahead = 0
+	pool := New(config, &chainConfig, blockchain)
+	tp, err := txpool.New(config.PriceLimit, blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer tp.Close()
+
+	var (
+		signer    = types.NewMikoSigner(chainConfig.ChainID)
+		sponsored = common.HexToAddress("0xdeadbeef")
+		other     = common.HexToAddress("0xcafebabe")
+		payerKeys = make([]*ecdsa.PrivateKey, 2)
+		payers    = make([]common.Address, 2)
+	)
+	for i := range payerKeys {
+		payerKeys[i], _ = crypto.GenerateKey()
+		payers[i] = crypto.PubkeyToAddress(payerKeys[i].PublicKey)
+		testAddBalance(pool, payers[i], big.NewInt(params.Ether))
+	}
+	sponsoredTx := func(payer int, to common.Address) *types.Transaction {
+		key, _ := crypto.GenerateKey()
+		inner := &types.SponsoredTx{
+			ChainID:     chainConfig.ChainID,
+			GasTipCap:   big.NewInt(params.GWei),
+			GasFeeCap:   big.NewInt(params.GWei),
+			Gas:         params.TxGas,
+			To:          &to,
+			Value:       common.Big0,
+			ExpiredTime: 100000,
+		}
+		var err error
+		inner.PayerR, inner.PayerS, inner.PayerV, err = types.PayerSign(payerKeys[payer], signer, crypto.PubkeyToAddress(key.PublicKey), inner)
+		if err != nil {
+			t.Fatalf("failed to payer sign transaction: %v", err)
+		}
+		tx, err := types.SignNewTx(key, signer, inner)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		return tx
+	}
+	// Only the whitelisted payer sponsoring the whitelisted recipient is admitted
+	var calls atomic.Int32
+	whitelist := txpool.SponsorWhitelist{payers[0]: {sponsored}}.Hook()
+	tp.SetSponsorHook(func(ctx context.Context, payer common.Address, tx *types.Transaction) error {
+		calls.Add(1)
+		return whitelist(ctx, payer, tx)
+	}, 0, 0)
+
+	txs := []*types.Transaction{sponsoredTx(0, sponsored), sponsoredTx(1, sponsored), sponsoredTx(0, other), sponsoredTx(0, sponsored)}
+	errs := tp.Add(txs, false, true)
+	for i, want := range []error{nil, txpool.ErrSponsorRejected, txpool.ErrSponsorRejected, nil} {
+		if !errors.Is(errs[i], want) {
+			t.Errorf("tx %d: error mismatch: have %v, want %v", i, errs[i], want)
+		}
+	}
+	// Decisions are cached per payer and recipient, known transactions skipped
+	if errs := tp.Add([]*types.Transaction{sponsoredTx(1, sponsored), txs[0]}, false, true); !errors.Is(errs[0], txpool.ErrSponsorRejected) || !errors.Is(errs[1], txpool.ErrAlreadyKnown) {
+		t.Errorf("cached decision error mismatch: have %v", errs)
+	}
+	if have := calls.Load(); have > 4 {
+		t.Errorf("hook calls mismatch: have %d, want at most 4", have)
+	}
+	// A hook not deciding in time rejects the transaction, without caching it
+	tp.SetSponsorHook(func(ctx context.Context, payer common.Address, tx *types.Transaction) error {
+		time.Sleep(time.Second)
+		return nil
+	}, 10*time.Millisecond, 0)
+
+	start := time.Now()
+	if errs := tp.Add([]*types.Transaction{sponsoredTx(0, sponsored)}, false, true); !errors.Is(errs[0], txpool.ErrSponsorRejected) {
+		t.Errorf("undecided tx error mismatch: have %v, want %v", errs[0], txpool.ErrSponsorRejected)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("undecided tx held up the admission for %v", elapsed)
+	}
+	// Removing the hook admits all sponsored transactions
+	tp.SetSponsorHook(nil, 0, 0)
+	if errs := tp.Add([]*types.Transaction{sponsoredTx(1, other)}, false, true); errs[0] != nil {
+		t.Errorf("failed to add sponsored tx without hook: %v", errs[0])
+	}
+	if pending, _ := tp.Stats(); pending != 3 {
+		t.Errorf("pending transactions mismatch: have %d, want 3", pending)
+	}
+}
+
+// Tests that the transactions rejected on admission are aggregated per minute
+// and rejection code, not counting the ones already known to the pool.
+func TestRejectionStats(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	pool := New(testTxPoolConfig, params.TestChainConfig, blockchain)
+	tp, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer tp.Close()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, addr, big.NewInt(1000000000))
+	testSetNonce(pool, addr, 1)
+
+	poor, _ := crypto.GenerateKey()
+	if errs := tp.Add([]*types.Transaction{transaction(1, 100000, key)}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add transaction: %v", errs[0])
+	}
+	tp.Add([]*types.Transaction{
+		transaction(1, 100000, key),                                     // known
+		transaction(0, 100000, key),                                     // nonce too low
+		pricedTransaction(2, 100000, big.NewInt(0), key),                // underpriced
+		pricedTransaction(1, 100001, big.NewInt(1), key),                // replacement underpriced
+		transaction(0, 100000, poor),                                    // insufficient funds
+		pricedDataTransaction(2, 100000, big.NewInt(1), key, txMaxSize), // oversized
+		transaction(2, 20000000, key),                                   // other
+	}, false, true)
+
+	stats := tp.Rejections()
+	if len(stats) != 1 {
+		t.Fatalf("rejection minutes mismatch: have %d, want 1", len(stats))
+	}
+	want := map[string]uint64{
+		txpool.RejectNonceTooLow:       1,
+		txpool.RejectUnderpriced:       2,
+		txpool.RejectInsufficientFunds: 1,
+		txpool.RejectOversized:         1,
+		txpool.RejectOther:             1,
+	}
+	if !reflect.DeepEqual(stats[0].Counts, want) {
+		t.Fatalf("rejection counts mismatch: have %v, want %v", stats[0].Counts, want)
+	}
+	if minute := time.Now().Truncate(time.Minute); stats[0].Minute.After(minute) || minute.Sub(stats[0].Minute) > time.Minute {
+		t.Errorf("rejection minute mismatch: have %v, now %v", stats[0].Minute, minute)
+	}
+	// The returned statistics are a copy
+	stats[0].Counts[txpool.RejectOther] = 100
+	if have := tp.Rejections()[0].Counts[txpool.RejectOther]; have != 1 {
+		t.Errorf("rejection counts modified through the snapshot: have %d, want 1", have)
+	}
+}
+
+// Tests that the transactions from or to a paused account are dropped on
+// arrival, optionally evicted from the pool, and admitted again once resumed.
+func TestAccountPause(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	pool := New(testTxPoolConfig, params.TestChainConfig, blockchain)
+	tp, err := txpool.New(testTxPoolConfig.PriceLimit, blockchain, []txpool.SubPool{pool})
+	if err != nil {
+		t.Fatalf("failed to create transaction pool: %v", err)
+	}
+	defer tp.Close()
+
+	var (
+		key1, _   = crypto.GenerateKey()
+		key2, _   = crypto.GenerateKey()
+		recipient = common.HexToAddress("0xdeadbeef")
+	)
+	testAddBalance(pool, crypto.PubkeyToAddress(key1.PublicKey), big.NewInt(1000000000))
+	testAddBalance(pool, crypto.PubkeyToAddress(key2.PublicKey), big.NewInt(1000000000))
+
+	send := func(nonce uint64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, recipient, big.NewInt(100), 100000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+		return tx
+	}
+	if errs := tp.Add([]*types.Transaction{transaction(0, 100000, key1), transaction(1, 100000, key1), send(0, key2)}, false, true); errs[0] != nil || errs[1] != nil || errs[2] != nil {
+		t.Fatalf("failed to add transactions: %v", errs)
+	}
+	// Pausing the sender without eviction only drops the arriving transactions
+	sender := crypto.PubkeyToAddress(key1.PublicKey)
+	if evicted := tp.PauseAccount(txpool.AccountPause{Address: sender, Sender: true}, false); evicted != 0 {
+		t.Fatalf("evicted transactions mismatch: have %d, want 0", evicted)
+	}
+	if errs := tp.Add([]*types.Transaction{transaction(2, 100000, key1)}, true, true); !errors.Is(errs[0], txpool.ErrAccountPaused) {
+		t.Fatalf("paused sender error mismatch: have %v, want %v", errs[0], txpool.ErrAccountPaused)
+	}
+	if pending, _ := tp.Stats(); pending != 3 {
+		t.Fatalf("pending transactions mismatch: have %d, want 3", pending)
+	}
+	// Pausing the recipient with eviction drops the pooled transactions too
+	if evicted := tp.PauseAccount(txpool.AccountPause{Address: recipient, Recipient: true}, true); evicted != 1 {
+		t.Fatalf("evicted transactions mismatch: have %d, want 1", evicted)
+	}
+	if errs := tp.Add([]*types.Transaction{send(0, key2)}, false, true); !errors.Is(errs[0], txpool.ErrAccountPaused) {
+		t.Fatalf("paused recipient error mismatch: have %v, want %v", errs[0], txpool.ErrAccountPaused)
+	}
+	if paused := tp.PausedAccounts(); len(paused) != 2 {
+		t.Fatalf("paused accounts mismatch: have %d, want 2", len(paused))
+	}
+	// Resumed and expired pauses are lifted
+	if !tp.ResumeAccount(recipient) {
+		t.Fatalf("recipient not resumed")
+	}
+	if errs := tp.Add([]*types.Transaction{send(0, key2)}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add transaction to resumed recipient: %v", errs[0])
+	}
+	tp.PauseAccount(txpool.AccountPause{Address: sender, Sender: true, Expiry: time.Now().Add(-time.Second)}, true)
+	if errs := tp.Add([]*types.Transaction{transaction(2, 100000, key1)}, false, true); errs[0] != nil {
+		t.Fatalf("failed to add transaction of expired pause: %v", errs[0])
+	}
+	if paused := tp.PausedAccounts(); len(paused) != 0 {
+		t.Fatalf("paused accounts mismatch: have %d, want 0", len(paused))
+	}
+	if pending, _ := tp.Stats(); pending != 4 {
+		t.Fatalf("pending transactions mismatch: have %d, want 4", pending)
+	}
+}
+
 // Tests that more expensive transactions push out cheap ones from the pool, but
 // without producing instability by creating gaps that start jumping transactions
 // back and forth between queued/pending.
@@ -1910,41 +2420,107 @@ func TestStableUnderpricing(t *testing.T) {
 	}
 }
 
-// Tests that when the pool reaches its global transaction limit, underpriced
-// transactions (legacy & dynamic fee) are gradually shifted out for more
-// expensive ones and any gapped pending transactions are moved into the queue.
-//
-// Note, local transactions are never allowed to be dropped.
-func TestUnderpricingDynamicFee(t *testing.T) {
+// Tests that the byte budget of the pool is enforced independently of the slot
+// limits, evicting the cheapest transactions to make room for better ones.
+func TestUnderpricingByteBudget(t *testing.T) {
 	t.Parallel()
 
-	pool, _ := setupPoolWithConfig(eip1559Config)
-	defer pool.Close()
-
-	pool.config.GlobalSlots = 2
-	pool.config.GlobalQueue = 2
-
-	// Keep track of transaction events to ensure all executables get announced
-	events := make(chan core.NewTxsEvent, 32)
-	sub := pool.txFeed.Subscribe(events)
-	defer sub.Unsubscribe()
+	// Create the pool to test the byte budget enforcement with
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed), 0}
 
-	// Create a number of test accounts and fund them
-	keys := make([]*ecdsa.PrivateKey, 4)
+	keys := make([]*ecdsa.PrivateKey, 3)
 	for i := 0; i < len(keys); i++ {
 		keys[i], _ = crypto.GenerateKey()
-		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
 	}
-
-	// Generate and queue a batch of transactions, both pending and queued
+	// Size the budget to fit exactly the cheap transactions, far below the slots
 	txs := types.Transactions{}
+	for i := uint64(0); i < 4; i++ {
+		txs = append(txs, pricedTransaction(i, 100000, big.NewInt(1), keys[0]))
+	}
+	var budget uint64
+	for _, tx := range txs {
+		budget += uint64(tx.Size())
+	}
+	config := testTxPoolConfig
+	config.GlobalBytes = budget
+
+	pool := New(config, params.TestChainConfig, blockchain)
+	defer pool.Close()
+	pool.Init(
+		testTxPoolConfig.PriceLimit,
+		blockchain.CurrentBlock().Header(),
+		func(addr common.Address, reserve bool) error { return nil },
+	)
+	for _, key := range keys {
+		testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+	}
+	for i, err := range pool.AddRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add cheap transaction: %v", i, err)
+		}
+	}
+	if have := pool.all.Bytes(); have != budget {
+		t.Fatalf("pooled bytes mismatch: have %d, want %d", have, budget)
+	}
+	// Ensure that adding an underpriced transaction is rejected
+	if err := pool.addRemoteSync(pricedTransaction(0, 100000, big.NewInt(1), keys[1])); !errors.Is(err, txpool.ErrUnderpriced) {
+		t.Fatalf("adding underpriced transaction error mismatch: have %v, want %v", err, txpool.ErrUnderpriced)
+	}
+	// Ensure that adding a high priced transaction evicts a cheap one
+	tx := pricedTransaction(0, 100000, big.NewInt(3), keys[2])
+	if err := pool.addRemoteSync(tx); err != nil {
+		t.Fatalf("failed to add well priced transaction: %v", err)
+	}
+	if pool.all.Get(tx.Hash()) == nil {
+		t.Fatalf("well priced transaction missing from the pool")
+	}
+	if have := pool.all.Bytes(); have > budget {
+		t.Fatalf("pooled bytes exceed budget: have %d, want at most %d", have, budget)
+	}
+	if have := pool.all.Count(); have >= len(txs)+1 {
+		t.Fatalf("pooled transaction count mismatch: have %d, want below %d", have, len(txs)+1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that when the pool reaches its global transaction limit, underpriced
+// transactions (legacy & dynamic fee) are gradually shifted out for more
+// expensive ones and any gapped pending transactions are moved into the queue.
+//
+// Note, local transactions are never allowed to be dropped.
+func TestUnderpricingDynamicFee(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupPoolWithConfig(eip1559Config)
+	defer pool.Close()
+
+	pool.config.GlobalSlots = 2
+	pool.config.GlobalQueue = 2
+
+	// Keep track of transaction events to ensure all executables get announced
+	events := make(chan core.NewTxsEvent, 32)
+	sub := pool.txFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	// Create a number of test accounts and fund them
+	keys := make([]*ecdsa.PrivateKey, 4)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+
+	// Generate and queue a batch of transactions, both pending and queued
+	txs := types.Transactions{}
+
+	txs = append(txs, dynamicFeeTx(0, 100000, big.NewInt(3), big.NewInt(2), keys[0]))
+	txs = append(txs, pricedTransaction(1, 100000, big.NewInt(2), keys[0]))
+	txs = append(txs, dynamicFeeTx(1, 100000, big.NewInt(2), big.NewInt(1), keys[1]))
+
+	ltx := dynamicFeeTx(0, 100000, big.NewInt(2), big.NewInt(1), keys[2])
 
-	txs = append(txs, dynamicFeeTx(0, 100000, big.NewInt(3), big.NewInt(2), keys[0]))
-	txs = append(txs, pricedTransaction(1, 100000, big.NewInt(2), keys[0]))
-	txs = append(txs, dynamicFeeTx(1, 100000, big.NewInt(2), big.NewInt(1), keys[1]))
-
-	ltx := dynamicFeeTx(0, 100000, big.NewInt(2), big.NewInt(1), keys[2])
-
 	// Import the batch and that both pending and queued transactions match up
 	pool.AddRemotes(txs) // Pend K0:0, K0:1; Que K1:1
 	pool.AddLocal(ltx)   // +K2:0 => Pend K0:0, K0:1, K2:0; Que K1:1
@@ -2326,6 +2902,141 @@ func TestReplacementDynamicFee(t *testing.T) {
 	}
 }
 
+// Tests that recently mined transactions are rejected without validation, and
+// that they're remembered across pool restarts.
+func TestMinedTransactionsRejected(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.MinedJournal = filepath.Join(t.TempDir(), "minedtxs.rlp")
+
+	newPool := func() *LegacyPool {
+		pool := New(config, params.TestChainConfig, blockchain)
+		pool.Init(
+			testTxPoolConfig.PriceLimit,
+			blockchain.CurrentBlock().Header(),
+			func(addr common.Address, reserve bool) error { return nil },
+		)
+		return pool
+	}
+	key, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	var (
+		mined = transaction(0, 100000, key)
+		fresh = transaction(1, 100000, key)
+	)
+	pool := newPool()
+	pool.mined.add(types.Transactions{mined})
+
+	if err := pool.addRemoteSync(mined); !errors.Is(err, txpool.ErrAlreadyKnown) {
+		t.Fatalf("mined transaction error mismatch: have %v, want %v", err, txpool.ErrAlreadyKnown)
+	}
+	if pool.all.Get(mined.Hash()) != nil {
+		t.Fatalf("mined transaction added to the pool")
+	}
+	pool.Close()
+
+	// Restart the pool and ensure the mined transaction is still rejected
+	pool = newPool()
+	defer pool.Close()
+
+	if err := pool.addRemoteSync(mined); !errors.Is(err, txpool.ErrAlreadyKnown) {
+		t.Fatalf("mined transaction error mismatch after restart: have %v, want %v", err, txpool.ErrAlreadyKnown)
+	}
+	if pool.mined.contains(fresh.Hash()) {
+		t.Fatalf("unmined transaction reported as mined")
+	}
+	// Transactions reorged out of the chain must be accepted again
+	pool.mined.remove(types.Transactions{mined})
+	if err := pool.addRemoteSync(mined); err != nil {
+		t.Fatalf("failed to add reorged out transaction: %v", err)
+	}
+}
+
+// Tests that the accounts marked local through the management API migrate their
+// pooled transactions, and that their locality is remembered across restarts.
+func TestLocalAccountJournaling(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.LocalsJournal = filepath.Join(t.TempDir(), "txlocals.rlp")
+
+	newPool := func() *LegacyPool {
+		pool := New(config, params.TestChainConfig, blockchain)
+		pool.Init(
+			testTxPoolConfig.PriceLimit,
+			blockchain.CurrentBlock().Header(),
+			func(addr common.Address, reserve bool) error { return nil },
+		)
+		return pool
+	}
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	statedb.AddBalance(addr, big.NewInt(1000000000))
+
+	sender, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(sender.PublicKey), big.NewInt(1000000000))
+
+	pool := newPool()
+	if err := pool.addRemoteSync(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add remote transaction: %v", err)
+	}
+	// Accounts made local by their transactions alone must not be persisted
+	if err := pool.AddLocal(transaction(0, 100000, sender)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if err := pool.AddLocalAccount(addr); err != nil {
+		t.Fatalf("failed to add local account: %v", err)
+	}
+	if local, remote := pool.all.LocalCount(), pool.all.RemoteCount(); local != 2 || remote != 0 {
+		t.Fatalf("pooled transactions mismatch: have %d/%d local/remote, want %d/%d", local, remote, 2, 0)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	pool.Close()
+
+	// Restart the pool without any journaled transactions, the account must stay local
+	pool = newPool()
+	if locals := pool.Locals(); len(locals) != 1 || locals[0] != addr {
+		t.Fatalf("local accounts mismatch after restart: have %v, want %v", locals, []common.Address{addr})
+	}
+	if err := pool.AddLocal(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	if local := pool.all.LocalCount(); local != 1 {
+		t.Fatalf("local transactions mismatch: have %d, want %d", local, 1)
+	}
+	// Remove the account and ensure its transactions are demoted and the removal persisted
+	if removed, err := pool.RemoveLocalAccount(addr); !removed || err != nil {
+		t.Fatalf("failed to remove local account: removed %v, err %v", removed, err)
+	}
+	if removed, _ := pool.RemoveLocalAccount(addr); removed {
+		t.Fatalf("non-local account reported removed")
+	}
+	if local, remote := pool.all.LocalCount(), pool.all.RemoteCount(); local != 0 || remote != 1 {
+		t.Fatalf("pooled transactions mismatch: have %d/%d local/remote, want %d/%d", local, remote, 0, 1)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	pool.Close()
+
+	pool = newPool()
+	defer pool.Close()
+
+	if locals := pool.Locals(); len(locals) != 0 {
+		t.Fatalf("local accounts mismatch after removal: have %v, want none", locals)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestJournaling(t *testing.T)         { testJournaling(t, false) }
@@ -2454,6 +3165,247 @@ func testJournaling(t *testing.T, nolocals bool) {
 	pool.Close()
 }
 
+// Tests that the pending and queued transactions, remote ones included, are
+// snapshotted on shutdown and restored on startup, the stale ones being dropped.
+func TestSnapshotRestore(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.Snapshot = filepath.Join(t.TempDir(), "txpool.rlp")
+
+	newPool := func() *LegacyPool {
+		pool := New(config, params.TestChainConfig, blockchain)
+		pool.Init(
+			testTxPoolConfig.PriceLimit,
+			blockchain.CurrentBlock().Header(),
+			func(addr common.Address, reserve bool) error { return nil },
+		)
+		return pool
+	}
+	local, _ := crypto.GenerateKey()
+	remote, _ := crypto.GenerateKey()
+	statedb.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+	statedb.AddBalance(crypto.PubkeyToAddress(remote.PublicKey), big.NewInt(1000000000))
+
+	pool := newPool()
+	if err := pool.AddLocal(transaction(0, 100000, local)); err != nil {
+		t.Fatalf("failed to add local transaction: %v", err)
+	}
+	for _, nonce := range []uint64{0, 1, 5} {
+		if err := pool.addRemoteSync(transaction(nonce, 100000, remote)); err != nil {
+			t.Fatalf("failed to add remote transaction %d: %v", nonce, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 3 || queued != 1 {
+		t.Fatalf("transactions mismatched: have %d/%d, want %d/%d", pending, queued, 3, 1)
+	}
+	pool.Close()
+
+	// Include the first remote transaction meanwhile, it must be dropped
+	statedb.SetNonce(crypto.PubkeyToAddress(remote.PublicKey), 1)
+
+	pool = newPool()
+	defer pool.Close()
+
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("restored transactions mismatched: have %d/%d, want %d/%d", pending, queued, 2, 1)
+	}
+	if locals := pool.Locals(); len(locals) != 1 || locals[0] != crypto.PubkeyToAddress(local.PublicKey) {
+		t.Fatalf("local accounts mismatched: have %v", locals)
+	}
+	if _, err := os.Stat(config.Snapshot); !os.IsNotExist(err) {
+		t.Fatalf("snapshot not deleted after restore: %v", err)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Snapshots of other versions must be rejected
+	var buf bytes.Buffer
+	if err := pool.Snapshot(&buf); err != nil {
+		t.Fatalf("failed to snapshot pool: %v", err)
+	}
+	buf.Reset()
+	rlp.Encode(&buf, &snapshotHeader{Version: snapshotVersion + 1})
+	if err := pool.Restore(&buf); err == nil {
+		t.Fatalf("snapshot of unsupported version restored")
+	}
+}
+
+// Tests that closing the pool drains the admissions in flight, promoting them
+// and writing the local ones into the journal, while rejecting any later ones.
+func TestCloseDrainsAdmissions(t *testing.T) {
+	t.Parallel()
+
+	journal := filepath.Join(t.TempDir(), "transactions.rlp")
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.Journal = journal
+	config.Rejournal = time.Hour
+
+	pool := New(config, params.TestChainConfig, blockchain)
+	pool.Init(
+		testTxPoolConfig.PriceLimit,
+		blockchain.CurrentBlock().Header(),
+		func(addr common.Address, reserve bool) error { return nil },
+	)
+	local, _ := crypto.GenerateKey()
+	testAddBalance(pool, crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000000))
+
+	// Submit a batch of local and a batch of remote transactions without waiting
+	// for their promotion, closing the pool concurrently with the remote ones
+	var locals types.Transactions
+	for i := uint64(0); i < 3; i++ {
+		locals = append(locals, pricedTransaction(i, 100000, big.NewInt(1), local))
+	}
+	for _, err := range pool.Add(locals, true, false) {
+		if err != nil {
+			t.Fatalf("failed to add local transaction: %v", err)
+		}
+	}
+	var (
+		remotes = make([]*ecdsa.PrivateKey, 16)
+		errs    = make([]error, len(remotes))
+		wg      sync.WaitGroup
+	)
+	for i := range remotes {
+		remotes[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(remotes[i].PublicKey), big.NewInt(1000000000))
+	}
+	for i, key := range remotes {
+		wg.Add(1)
+		go func(i int, key *ecdsa.PrivateKey) {
+			defer wg.Done()
+			errs[i] = pool.AddRemotes([]*types.Transaction{pricedTransaction(0, 100000, big.NewInt(1), key)})[0]
+		}(i, key)
+	}
+	pool.Close()
+	wg.Wait()
+
+	// Every admitted transaction must have been promoted before the shutdown
+	admitted := len(locals)
+	for i, err := range errs {
+		switch err {
+		case nil:
+			admitted++
+		case txpool.ErrPoolClosed:
+		default:
+			t.Fatalf("remote %d: unexpected admission error: %v", i, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != admitted || queued != 0 {
+		t.Fatalf("pool content mismatch: have %d/%d, want %d/%d", pending, queued, admitted, 0)
+	}
+	if err := pool.AddLocal(pricedTransaction(3, 100000, big.NewInt(1), local)); err != txpool.ErrPoolClosed {
+		t.Fatalf("admission after close mismatch: have %v, want %v", err, txpool.ErrPoolClosed)
+	}
+	// Restart the pool and ensure the local transactions were journaled
+	pool = New(config, params.TestChainConfig, blockchain)
+	pool.Init(
+		testTxPoolConfig.PriceLimit,
+		blockchain.CurrentBlock().Header(),
+		func(addr common.Address, reserve bool) error { return nil },
+	)
+	defer pool.Close()
+
+	if pending, queued := pool.Stats(); pending != len(locals) || queued != 0 {
+		t.Fatalf("journaled content mismatch: have %d/%d, want %d/%d", pending, queued, len(locals), 0)
+	}
+}
+
+// Tests that the local sponsored transactions survive restarts with their payer
+// signatures and payer cost accounting, being validated again against the new
+// head: the ones expired or whose payer cannot afford them anymore are dropped.
+func TestJournalingSponsored(t *testing.T) {
+	t.Parallel()
+
+	chainConfig := *params.TestChainConfig
+	chainConfig.MikoBlock = common.Big0
+	chainConfig.ChainID = big.NewInt(2020)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.Journal = filepath.Join(t.TempDir(), "transactions.rlp")
+	config.ForkLookahead = 0
+
+	pool := New(config, &chainConfig, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock().Header(), func(addr common.Address, reserve bool) error { return nil })
+
+	var (
+		signer    = types.NewMikoSigner(chainConfig.ChainID)
+		recipient = common.HexToAddress("0xdeadbeef")
+		payerKeys = make([]*ecdsa.PrivateKey, 2)
+		payers    = make([]common.Address, 2)
+		txs       = make([]*types.Transaction, 3)
+	)
+	for i := range payerKeys {
+		payerKeys[i], _ = crypto.GenerateKey()
+		payers[i] = crypto.PubkeyToAddress(payerKeys[i].PublicKey)
+		testAddBalance(pool, payers[i], big.NewInt(params.Ether))
+	}
+	// The first transaction stays valid, the second one expires and the payer
+	// of the third one is drained while the node is down
+	for i, expiry := range []uint64{100000, 1000, 100000} {
+		key, _ := crypto.GenerateKey()
+		pool.locals.add(crypto.PubkeyToAddress(key.PublicKey))
+
+		inner := &types.SponsoredTx{
+			ChainID:     chainConfig.ChainID,
+			GasTipCap:   big.NewInt(params.GWei),
+			GasFeeCap:   big.NewInt(params.GWei),
+			Gas:         params.TxGas,
+			To:          &recipient,
+			Value:       common.Big0,
+			ExpiredTime: expiry,
+		}
+		var err error
+		inner.PayerR, inner.PayerS, inner.PayerV, err = types.PayerSign(payerKeys[i/2], signer, crypto.PubkeyToAddress(key.PublicKey), inner)
+		if err != nil {
+			t.Fatalf("failed to payer sign transaction: %v", err)
+		}
+		if txs[i], err = types.SignNewTx(key, signer, inner); err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		if err := pool.AddLocal(txs[i]); err != nil {
+			t.Fatalf("failed to add sponsored transaction %d: %v", i, err)
+		}
+	}
+	pool.Close()
+
+	// Restart the pool past the expiry with the second payer drained
+	statedb.SetBalance(payers[1], common.Big0)
+	blockchain = &testBlockChain{10000000, statedb, new(event.Feed), 2000}
+
+	pool = New(config, &chainConfig, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock().Header(), func(addr common.Address, reserve bool) error { return nil })
+	defer pool.Close()
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 0 {
+		t.Fatalf("restored transactions mismatch: have %d/%d, want 1/0", pending, queued)
+	}
+	restored := pool.Get(txs[0].Hash())
+	if restored == nil {
+		t.Fatal("sponsored transaction not restored")
+	}
+	if payer, err := types.Payer(signer, restored); err != nil || payer != payers[0] {
+		t.Errorf("restored payer mismatch: have %x (err: %v), want %x", payer, err, payers[0])
+	}
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(txs[0].Gas()), txs[0].GasFeeCap())
+	if have := pool.totalPendingPayerCost[payers[0]]; have == nil || have.Cmp(cost) != 0 {
+		t.Errorf("restored payer cost mismatch: have %v, want %v", have, cost)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // TestStatusCheck tests that the pool can correctly retrieve the
 // pending status of individual transactions.
 func TestStatusCheck(t *testing.T) {
@@ -3023,10 +3975,10 @@ func TestExpiredTimeAndGasCheckSponsoredTx(t *testing.T) {
 	}
 }
 
-// TestSponsoredTxInTxPoolQueue tests that sponsored tx is removed from
-// txpool's queue when balance of payer/sender is insufficient or tx
-// is expired
-func TestSponsoredTxInTxPoolQueue(t *testing.T) {
+// Tests that sponsored transactions expiring within the configured slack of the
+// chain head timestamp are rejected, and that the admitted ones are dropped as
+// soon as a new head brings them within the slack.
+func TestSponsoredTxExpirySlack(t *testing.T) {
 	var chainConfig params.ChainConfig
 
 	chainConfig.EIP155Block = common.Big0
@@ -3035,29 +3987,108 @@ func TestSponsoredTxInTxPoolQueue(t *testing.T) {
 
 	recipient := common.HexToAddress("1000000000000000000000000000000000000001")
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
-	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 1000}
 
-	txpool := New(testTxPoolConfig, &chainConfig, blockchain)
+	config := testTxPoolConfig
+	config.ExpirySlack = 10 * time.Second
+
+	txpool := New(config, &chainConfig, blockchain)
 	defer txpool.Close()
 	txpool.Init(
-		testTxPoolConfig.PriceLimit,
+		config.PriceLimit,
 		blockchain.CurrentBlock().Header(),
 		func(addr common.Address, reserve bool) error { return nil },
 	)
 
-	senderKey, err := crypto.GenerateKey()
-	if err != nil {
-		t.Fatal(err)
-	}
-	senderAddr := crypto.PubkeyToAddress(senderKey.PublicKey)
-
-	payerKey, err := crypto.GenerateKey()
-	if err != nil {
-		t.Fatal(err)
-	}
-	payerAddr := crypto.PubkeyToAddress(payerKey.PublicKey)
+	senderKey, _ := crypto.GenerateKey()
+	payerKey, _ := crypto.GenerateKey()
+	statedb.SetBalance(crypto.PubkeyToAddress(payerKey.PublicKey), big.NewInt(1000000000000))
 
-	sponsoredTx1 := types.SponsoredTx{
+	mikoSigner := types.NewMikoSigner(big.NewInt(2020))
+	sponsored := func(expiredTime uint64) *types.Transaction {
+		innerTx := types.SponsoredTx{
+			ChainID:     big.NewInt(2020),
+			Nonce:       0,
+			GasTipCap:   big.NewInt(100000),
+			GasFeeCap:   big.NewInt(100000),
+			Gas:         22000,
+			To:          &recipient,
+			Value:       common.Big0,
+			ExpiredTime: expiredTime,
+		}
+		var err error
+		innerTx.PayerR, innerTx.PayerS, innerTx.PayerV, err = types.PayerSign(
+			payerKey,
+			mikoSigner,
+			crypto.PubkeyToAddress(senderKey.PublicKey),
+			&innerTx,
+		)
+		if err != nil {
+			t.Fatalf("Payer fails to sign transaction, err %s", err)
+		}
+		tx, err := types.SignNewTx(senderKey, mikoSigner, &innerTx)
+		if err != nil {
+			t.Fatalf("Fail to sign transaction, err %s", err)
+		}
+		return tx
+	}
+	// Transactions expiring within the slack are rejected at admission
+	if err := txpool.addRemoteSync(sponsored(1010)); !errors.Is(err, core.ErrExpiredSponsoredTx) {
+		t.Fatalf("Expect error %s, get %s", core.ErrExpiredSponsoredTx, err)
+	}
+	tx := sponsored(1020)
+	if err := txpool.addRemoteSync(tx); err != nil {
+		t.Fatalf("Expect successfully add tx, get %s", err)
+	}
+	// A new head not yet bringing the transaction within the slack keeps it
+	blockchain.headerTime = 1009
+	<-txpool.requestReset(nil, nil)
+	if txpool.Get(tx.Hash()) == nil {
+		t.Fatalf("Expect transaction to be kept in the pool")
+	}
+	// A new head bringing it within the slack drops it
+	blockchain.headerTime = 1010
+	<-txpool.requestReset(nil, nil)
+	if txpool.Get(tx.Hash()) != nil {
+		t.Fatalf("Expect expired transaction to be dropped from the pool")
+	}
+}
+
+// TestSponsoredTxInTxPoolQueue tests that sponsored tx is removed from
+// txpool's queue when balance of payer/sender is insufficient or tx
+// is expired
+func TestSponsoredTxInTxPoolQueue(t *testing.T) {
+	var chainConfig params.ChainConfig
+
+	chainConfig.EIP155Block = common.Big0
+	chainConfig.MikoBlock = common.Big0
+	chainConfig.ChainID = big.NewInt(2020)
+
+	recipient := common.HexToAddress("1000000000000000000000000000000000000001")
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	txpool := New(testTxPoolConfig, &chainConfig, blockchain)
+	defer txpool.Close()
+	txpool.Init(
+		testTxPoolConfig.PriceLimit,
+		blockchain.CurrentBlock().Header(),
+		func(addr common.Address, reserve bool) error { return nil },
+	)
+
+	senderKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	senderAddr := crypto.PubkeyToAddress(senderKey.PublicKey)
+
+	payerKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payerAddr := crypto.PubkeyToAddress(payerKey.PublicKey)
+
+	sponsoredTx1 := types.SponsoredTx{
 		ChainID:     big.NewInt(2020),
 		Nonce:       0,
 		GasTipCap:   big.NewInt(100000),
@@ -3342,3 +4373,564 @@ func TestFeeCapCheckVenoki(t *testing.T) {
 		t.Fatalf("Pending txpool, expect %d get %d", 0, pending)
 	}
 }
+
+// Tests that the sampled invariant checker detects a pool whose lookup index
+// went out of sync with the pending and queued lists.
+func TestInvariantCheckDetectsCorruption(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, account, big.NewInt(1000000))
+
+	txs := []*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key), transaction(3, 100000, key)}
+	for i, err := range pool.AddRemotesSync(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if err := pool.checkInvariants(invariantSampleAccounts); err != nil {
+		t.Fatalf("healthy pool reported as corrupted: %v", err)
+	}
+	// Drop a transaction from the lookup only, leaving the lists dangling
+	pool.all.Remove(txs[1].Hash())
+	if err := pool.checkInvariants(invariantSampleAccounts); err == nil {
+		t.Fatalf("corrupted pool passed invariant check")
+	}
+}
+
+// Tests that the sampled invariant checker only requires the payer costs to
+// cover the sampled sponsored transactions, while the full check demands an
+// exact match.
+func TestInvariantCheckPayerCost(t *testing.T) {
+	t.Parallel()
+
+	chainConfig := *params.TestChainConfig
+	chainConfig.MikoBlock = common.Big0
+	chainConfig.ChainID = big.NewInt(2020)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.ForkLookahead = 0
+
+	pool := New(config, &chainConfig, blockchain)
+	pool.Init(config.PriceLimit, blockchain.CurrentBlock().Header(), func(addr common.Address, reserve bool) error { return nil })
+	defer pool.Close()
+
+	var (
+		signer      = types.NewMikoSigner(chainConfig.ChainID)
+		recipient   = common.HexToAddress("0xdeadbeef")
+		key, _      = crypto.GenerateKey()
+		payerKey, _ = crypto.GenerateKey()
+		payer       = crypto.PubkeyToAddress(payerKey.PublicKey)
+	)
+	testAddBalance(pool, payer, big.NewInt(params.Ether))
+
+	inner := &types.SponsoredTx{
+		ChainID:     chainConfig.ChainID,
+		GasTipCap:   big.NewInt(params.GWei),
+		GasFeeCap:   big.NewInt(params.GWei),
+		Gas:         params.TxGas,
+		To:          &recipient,
+		Value:       common.Big0,
+		ExpiredTime: 100000,
+	}
+	var err error
+	inner.PayerR, inner.PayerS, inner.PayerV, err = types.PayerSign(payerKey, signer, crypto.PubkeyToAddress(key.PublicKey), inner)
+	if err != nil {
+		t.Fatalf("failed to payer sign transaction: %v", err)
+	}
+	tx, err := types.SignNewTx(key, signer, inner)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if err := pool.AddLocal(tx); err != nil {
+		t.Fatalf("failed to add sponsored transaction: %v", err)
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	if err := pool.checkInvariants(invariantSampleAccounts); err != nil {
+		t.Fatalf("healthy pool reported as corrupted: %v", err)
+	}
+	// An overstated cost covers the sampled transactions, only the full check catches it
+	pool.totalPendingPayerCost[payer] = new(big.Int).Add(cost, common.Big1)
+	if err := pool.checkInvariants(invariantSampleAccounts); err != nil {
+		t.Fatalf("overstated payer cost failed sampled check: %v", err)
+	}
+	if err := pool.checkInvariants(0); err == nil {
+		t.Fatalf("overstated payer cost passed full check")
+	}
+	// An understated cost is caught by the sampled check too
+	pool.totalPendingPayerCost[payer] = new(big.Int).Sub(cost, common.Big1)
+	if err := pool.checkInvariants(invariantSampleAccounts); err == nil {
+		t.Fatalf("understated payer cost passed sampled check")
+	}
+}
+
+// Tests that the nonce manager hands out distinct nonces to concurrent
+// submitters, skips pooled transactions and reuses released nonces.
+func TestNonceManagerReservations(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPool()
+	defer pool.Close()
+
+	account := crypto.PubkeyToAddress(key.PublicKey)
+	testAddBalance(pool, account, big.NewInt(1000000))
+
+	pool.mu.Lock()
+	pool.nonces = newNonceManager([]common.Address{account})
+	pool.mu.Unlock()
+
+	if _, err := pool.ReserveNonce(common.Address{0x01}); err != txpool.ErrAccountNotManaged {
+		t.Fatalf("unmanaged account error mismatch: have %v, want %v", err, txpool.ErrAccountNotManaged)
+	}
+	// Reserve a batch of nonces concurrently and ensure they're all distinct
+	var (
+		wg     sync.WaitGroup
+		nonces = make(chan uint64, 16)
+	)
+	for i := 0; i < cap(nonces); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := pool.ReserveNonce(account)
+			if err != nil {
+				t.Errorf("failed to reserve nonce: %v", err)
+			}
+			nonces <- nonce
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+
+	seen := make(map[uint64]bool)
+	for nonce := range nonces {
+		if seen[nonce] {
+			t.Fatalf("nonce %d reserved twice", nonce)
+		}
+		seen[nonce] = true
+	}
+	if len(seen) != 16 || !seen[0] || !seen[15] {
+		t.Fatalf("unexpected reserved nonces: %v", seen)
+	}
+	// The pool nonce must not be affected by the reservations
+	if nonce := pool.Nonce(account); nonce != 0 {
+		t.Fatalf("pool nonce mismatch: have %d, want 0", nonce)
+	}
+	// Submit some of the reserved nonces, release another and ensure it's the
+	// next one to be handed out
+	for i, err := range pool.AddRemotesSync([]*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key), transaction(17, 100000, key)}) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if err := pool.ReleaseNonce(account, 5); err != nil {
+		t.Fatalf("failed to release nonce: %v", err)
+	}
+	if nonce, _ := pool.ReserveNonce(account); nonce != 5 {
+		t.Fatalf("released nonce not reused: have %d, want 5", nonce)
+	}
+	// The queued transaction must be skipped over
+	if nonce, _ := pool.ReserveNonce(account); nonce != 16 {
+		t.Fatalf("next nonce mismatch: have %d, want 16", nonce)
+	}
+	if nonce, _ := pool.ReserveNonce(account); nonce != 18 {
+		t.Fatalf("queued nonce not skipped: have %d, want 18", nonce)
+	}
+	// Expired reservations must be handed out again
+	pool.mu.Lock()
+	for nonce := range pool.nonces.reserved[account] {
+		pool.nonces.reserved[account][nonce] = time.Now().Add(-time.Second)
+	}
+	pool.mu.Unlock()
+
+	if nonce, _ := pool.ReserveNonce(account); nonce != 2 {
+		t.Fatalf("expired nonce not reused: have %d, want 2", nonce)
+	}
+}
+
+// Tests that transactions of many accounts can be admitted concurrently with
+// readers and pool resets, leaving the pool internals consistent.
+func TestConcurrentAdmission(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupPool()
+	defer pool.Close()
+
+	const accounts, txs = 16, 8
+
+	keys := make([]*ecdsa.PrivateKey, accounts)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key *ecdsa.PrivateKey) {
+			defer wg.Done()
+			for nonce := uint64(0); nonce < txs; nonce++ {
+				if err := pool.addRemoteSync(transaction(nonce, 100000, key)); err != nil {
+					t.Errorf("failed to add transaction: %v", err)
+				}
+			}
+		}(key)
+	}
+	stop := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					pool.Pending(&txpool.PendingFilter{})
+					pool.ContentFrom(crypto.PubkeyToAddress(keys[0].PublicKey))
+					<-pool.requestReset(nil, nil)
+				}
+			}
+		}()
+	}
+	time.Sleep(200 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+
+	// Transactions queued while a reset was running are picked up by the next
+	// promotion of their accounts
+	dirty := newAccountSet(pool.signer)
+	for _, key := range keys {
+		dirty.add(crypto.PubkeyToAddress(key.PublicKey))
+	}
+	<-pool.requestPromoteExecutables(dirty)
+	if pending, queued := pool.Stats(); pending != accounts*txs || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d pending %d queued, want %d pending", pending, queued, accounts*txs)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the room claimed by transactions being admitted counts against the
+// limits of the lookup until released.
+func TestLookupReserve(t *testing.T) {
+	t.Parallel()
+
+	key, _ := crypto.GenerateKey()
+	var (
+		all   = newLookup()
+		first = transaction(0, 100000, key)
+		other = transaction(1, 100000, key)
+	)
+	all.Add(transaction(2, 100000, key), false)
+
+	if !all.Reserve(first, 2, 0) {
+		t.Fatal("failed to claim free room")
+	}
+	if all.Reserve(other, 2, 0) {
+		t.Fatal("claimed room taken twice")
+	}
+	if all.Reserve(other, 3, uint64(3*first.Size()-1)) {
+		t.Fatal("byte limit ignored")
+	}
+	all.Release(first)
+	if !all.Reserve(other, 2, 0) {
+		t.Fatal("released room not reclaimable")
+	}
+}
+
+// Tests that concurrently admitted transactions cannot overshoot the global pool
+// limits together, nor leave any claimed room behind.
+func TestConcurrentAdmissionLimits(t *testing.T) {
+	t.Parallel()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{1000000, statedb, new(event.Feed), 0}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 4
+	config.GlobalQueue = 4
+
+	pool := New(config, params.TestChainConfig, blockchain)
+	defer pool.Close()
+	pool.Init(
+		testTxPoolConfig.PriceLimit,
+		blockchain.CurrentBlock().Header(),
+		func(addr common.Address, reserve bool) error { return nil },
+	)
+	const accounts, txs = 16, 4
+
+	keys := make([]*ecdsa.PrivateKey, accounts)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key *ecdsa.PrivateKey) {
+			defer wg.Done()
+			for nonce := uint64(1); nonce <= txs; nonce++ {
+				pool.addRemoteSync(transaction(nonce, 100000, key)) // Overflows are expected
+			}
+		}(key)
+	}
+	wg.Wait()
+
+	if slots, limit := pool.all.Slots(), int(config.GlobalSlots+config.GlobalQueue); slots > limit {
+		t.Fatalf("pool slots overshot: have %d, limit %d", slots, limit)
+	}
+	if pool.all.claimed != 0 || pool.all.claimedBytes != 0 {
+		t.Fatalf("claimed room leaked: %d slots, %d bytes", pool.all.claimed, pool.all.claimedBytes)
+	}
+	if err := validatePoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the promoted transactions are announced ordered by sender then
+// nonce, in numbered batches, and never announced twice.
+func TestAnnouncedBatches(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupPool()
+	defer pool.Close()
+
+	events := make(chan core.NewTxsEvent, 16)
+	sub := pool.txFeed.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		testAddBalance(pool, crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	// Add the transactions of each sender in reverse nonce order
+	var txs []*types.Transaction
+	for _, key := range keys {
+		for nonce := 2; nonce >= 0; nonce-- {
+			txs = append(txs, transaction(uint64(nonce), 100000, key))
+		}
+	}
+	pool.Add(txs, false, true)
+
+	var ev core.NewTxsEvent
+	select {
+	case ev = <-events:
+	case <-time.After(time.Second):
+		t.Fatal("no batch announced")
+	}
+	if ev.Seq != 1 {
+		t.Errorf("sequence number mismatch: have %d, want 1", ev.Seq)
+	}
+	if len(ev.Txs) != len(txs) {
+		t.Fatalf("announced transactions mismatch: have %d, want %d", len(ev.Txs), len(txs))
+	}
+	for i := 1; i < len(ev.Txs); i++ {
+		prev, _ := deriveSender(ev.Txs[i-1])
+		from, _ := deriveSender(ev.Txs[i])
+		if cmp := bytes.Compare(prev[:], from[:]); cmp > 0 || (cmp == 0 && ev.Txs[i-1].Nonce() >= ev.Txs[i].Nonce()) {
+			t.Fatalf("transaction %d out of order: %x/%d after %x/%d", i, from, ev.Txs[i].Nonce(), prev, ev.Txs[i-1].Nonce())
+		}
+	}
+	// Promoting the same transactions again announces nothing new
+	promoted := make(map[common.Address]*sortedMap)
+	for _, tx := range ev.Txs[:2] {
+		from, _ := deriveSender(tx)
+		if promoted[from] == nil {
+			promoted[from] = newSortedMap()
+		}
+		promoted[from].Put(tx)
+	}
+	if dup, ok := pool.announcer.batch(promoted); ok {
+		t.Fatalf("transactions announced again: %v", dup.Txs)
+	}
+	// The next batch follows in sequence
+	pool.Add([]*types.Transaction{transaction(3, 100000, keys[0])}, false, true)
+	select {
+	case ev = <-events:
+		if ev.Seq != 2 || len(ev.Txs) != 1 {
+			t.Errorf("next batch mismatch: have seq %d with %d txs, want seq 2 with 1 tx", ev.Seq, len(ev.Txs))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no next batch announced")
+	}
+}
+
+// Tests that a pending sponsored transaction re-signed by another payer replaces
+// the old one without a price bump once, while any other change, or a further
+// rotation, still needs one.
+func TestSponsoredTxPayerRotation(t *testing.T) {
+	t.Parallel()
+
+	var chainConfig params.ChainConfig
+
+	chainConfig.EIP155Block = common.Big0
+	chainConfig.MikoBlock = common.Big0
+	chainConfig.ChainID = big.NewInt(2020)
+
+	recipient := common.HexToAddress("1000000000000000000000000000000000000001")
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	blockchain := &testBlockChain{10000000, statedb, new(event.Feed), 0}
+
+	pool := New(testTxPoolConfig, &chainConfig, blockchain)
+	defer pool.Close()
+	pool.Init(
+		testTxPoolConfig.PriceLimit,
+		blockchain.CurrentBlock().Header(),
+		func(addr common.Address, reserve bool) error { return nil },
+	)
+
+	senderKey, _ := crypto.GenerateKey()
+	oldPayerKey, _ := crypto.GenerateKey()
+	newPayerKey, _ := crypto.GenerateKey()
+
+	statedb.SetBalance(crypto.PubkeyToAddress(senderKey.PublicKey), big.NewInt(1000))
+	statedb.SetBalance(crypto.PubkeyToAddress(oldPayerKey.PublicKey), big.NewInt(1000000000000))
+	statedb.SetBalance(crypto.PubkeyToAddress(newPayerKey.PublicKey), big.NewInt(1000000000000))
+
+	mikoSigner := types.NewMikoSigner(big.NewInt(2020))
+	sponsored := func(payerKey *ecdsa.PrivateKey, fee int64, value int64) *types.Transaction {
+		inner := types.SponsoredTx{
+			ChainID:     big.NewInt(2020),
+			Nonce:       0,
+			GasTipCap:   big.NewInt(fee),
+			GasFeeCap:   big.NewInt(fee),
+			Gas:         30000,
+			To:          &recipient,
+			Value:       big.NewInt(value),
+			ExpiredTime: 100,
+		}
+		var err error
+		inner.PayerR, inner.PayerS, inner.PayerV, err = types.PayerSign(payerKey, mikoSigner, crypto.PubkeyToAddress(senderKey.PublicKey), &inner)
+		if err != nil {
+			t.Fatalf("Payer fails to sign transaction, err %s", err)
+		}
+		tx, err := types.SignNewTx(senderKey, mikoSigner, &inner)
+		if err != nil {
+			t.Fatalf("Fail to sign transaction, err %s", err)
+		}
+		return tx
+	}
+	if err := pool.addRemoteSync(sponsored(oldPayerKey, 100000, 10)); err != nil {
+		t.Fatalf("Fail to add tx to pool, err %s", err)
+	}
+	// The same payer cannot replace its transaction without a price bump
+	if err := pool.addRemoteSync(sponsored(oldPayerKey, 100000, 10)); err == nil {
+		t.Fatal("expected known transaction error")
+	}
+	if err := pool.addRemoteSync(sponsored(oldPayerKey, 100001, 10)); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Fatalf("same payer replacement error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	// Another payer cannot change the call or lower the fees
+	if err := pool.addRemoteSync(sponsored(newPayerKey, 100000, 11)); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Fatalf("changed call replacement error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	if err := pool.addRemoteSync(sponsored(newPayerKey, 99999, 10)); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Fatalf("cheaper replacement error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	// Another payer signing the same call at the same fees replaces the old one
+	rotated := sponsored(newPayerKey, 100000, 10)
+	if err := pool.addRemoteSync(rotated); err != nil {
+		t.Fatalf("rotated payer replacement failed: %v", err)
+	}
+	pending, queued := pool.Stats()
+	if pending != 1 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want 1/0", pending, queued)
+	}
+	if pool.Get(rotated.Hash()) == nil {
+		t.Fatal("rotated transaction missing from the pool")
+	}
+	// Swapping back to the old payer, or to any other one, needs the price bump
+	if err := pool.addRemoteSync(sponsored(oldPayerKey, 100000, 10)); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Fatalf("swap back replacement error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	thirdPayerKey, _ := crypto.GenerateKey()
+	statedb.SetBalance(crypto.PubkeyToAddress(thirdPayerKey.PublicKey), big.NewInt(1000000000000))
+	if err := pool.addRemoteSync(sponsored(thirdPayerKey, 100000, 10)); !errors.Is(err, txpool.ErrReplaceUnderpriced) {
+		t.Fatalf("second rotation error mismatch: have %v, want %v", err, txpool.ErrReplaceUnderpriced)
+	}
+	bumped := sponsored(oldPayerKey, 110000, 10)
+	if err := pool.addRemoteSync(bumped); err != nil {
+		t.Fatalf("bumped swap back failed: %v", err)
+	}
+	if pool.Get(bumped.Hash()) == nil || pool.Get(rotated.Hash()) != nil {
+		t.Fatal("bumped transaction did not replace the rotated one")
+	}
+	if pool.all.Rotated(rotated.Hash()) {
+		t.Fatal("rotation mark kept for a dropped transaction")
+	}
+}
+
+// Tests that the content of the pool can be retrieved for a single transaction
+// type, both in full and for mining.
+func TestContentByType(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupPoolWithConfig(eip1559Config)
+	defer pool.Close()
+
+	testAddBalance(pool, crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000))
+
+	var (
+		from = crypto.PubkeyToAddress(key.PublicKey)
+		txs  = types.Transactions{
+			pricedTransaction(0, 100000, big.NewInt(1), key),
+			dynamicFeeTx(1, 100000, big.NewInt(2), big.NewInt(1), key),
+			pricedTransaction(2, 100000, big.NewInt(1), key),
+			dynamicFeeTx(4, 100000, big.NewInt(2), big.NewInt(1), key),
+		}
+	)
+	for i, tx := range txs {
+		if err := pool.addRemoteSync(tx); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	check := func(name string, have map[common.Address][]*types.Transaction, want ...*types.Transaction) {
+		t.Helper()
+		if len(want) == 0 {
+			if len(have) != 0 {
+				t.Errorf("%s: unexpected accounts: %d", name, len(have))
+			}
+			return
+		}
+		if len(have[from]) != len(want) {
+			t.Fatalf("%s: transaction count mismatch: have %d, want %d", name, len(have[from]), len(want))
+		}
+		for i, tx := range have[from] {
+			if tx.Hash() != want[i].Hash() {
+				t.Errorf("%s: transaction %d mismatch: have nonce %d, want %d", name, i, tx.Nonce(), want[i].Nonce())
+			}
+		}
+	}
+	pending, queued := pool.ContentByType(types.DynamicFeeTxType)
+	check("dynamic pending", pending, txs[1])
+	check("dynamic queued", queued, txs[3])
+
+	pending, queued = pool.ContentByType(types.LegacyTxType)
+	check("legacy pending", pending, txs[0], txs[2])
+	check("legacy queued", queued)
+
+	pending, queued = pool.ContentByType(types.SponsoredTxType)
+	check("sponsored pending", pending)
+	check("sponsored queued", queued)
+
+	lazies := pool.Pending(&txpool.PendingFilter{OnlyTxTypes: []uint8{types.LegacyTxType}})
+	if len(lazies[from]) != 2 || lazies[from][0].Hash != txs[0].Hash() || lazies[from][1].Hash != txs[2].Hash() {
+		t.Errorf("filtered pending mismatch: have %d transactions", len(lazies[from]))
+	}
+	if lazies := pool.Pending(&txpool.PendingFilter{OnlyTxTypes: []uint8{types.BlobTxType}}); len(lazies) != 0 {
+		t.Errorf("unexpected pending accounts: %d", len(lazies))
+	}
+}
@@ -17,6 +17,7 @@
 package legacypool
 
 import (
+	"bytes"
 	"container/heap"
 	"math"
 	"math/big"
@@ -301,10 +302,14 @@ func (l *list) Overlaps(tx *types.Transaction) bool {
 //
 // If the new transaction is accepted into the list, the lists' cost and gas
 // thresholds are also potentially updated.
-func (l *list) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transaction) {
+//
+// The optional rotated callback reports whether a pooled transaction already
+// entered by rotating the payer of another one; such transactions can only be
+// replaced with the price bump.
+func (l *list) Add(tx *types.Transaction, priceBump uint64, rotated func(common.Hash) bool) (bool, *types.Transaction) {
 	// If there's an older better transaction, abort
 	old := l.txs.Get(tx.Nonce())
-	if old != nil {
+	if old != nil && !l.freeRotation(old, tx, rotated) {
 		if old.GasFeeCapCmp(tx) >= 0 || old.GasTipCapCmp(tx) >= 0 {
 			return false, nil
 		}
@@ -324,6 +329,8 @@ func (l *list) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transa
 		if tx.GasFeeCapIntCmp(thresholdFeeCap) < 0 || tx.GasTipCapIntCmp(thresholdTip) < 0 {
 			return false, nil
 		}
+	}
+	if old != nil {
 		// Old is being replaced, subtract old cost
 		l.removeTx([]*types.Transaction{old})
 	}
@@ -335,6 +342,7 @@ func (l *list) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transa
 	if gas := tx.Gas(); l.gascap < gas {
 		l.gascap = gas
 	}
+	senderCost, payerCost, _, _ := tx.CostBreakdown(nil, nil)
 	if tx.Type() == types.SponsoredTxType {
 		payer, err := types.Payer(l.signer, tx)
 		if err != nil {
@@ -342,25 +350,59 @@ func (l *list) Add(tx *types.Transaction, priceBump uint64) (bool, *types.Transa
 		} else {
 			l.payers[payer]++
 			if l.txpoolPayerCost != nil {
-				gasFee := new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
 				if l.txpoolPayerCost[payer] != nil {
-					l.txpoolPayerCost[payer].Add(l.txpoolPayerCost[payer], gasFee)
+					l.txpoolPayerCost[payer].Add(l.txpoolPayerCost[payer], payerCost)
 				} else {
-					l.txpoolPayerCost[payer] = gasFee
+					l.txpoolPayerCost[payer] = payerCost
 				}
 			}
-
-			// Add new tx value to sender's total cost
-			l.totalcost.Add(l.totalcost, tx.Value())
 		}
-	} else {
-		// Add new tx cost to totalcost
-		l.totalcost.Add(l.totalcost, tx.Cost())
 	}
+	// Add new tx cost to the sender's total cost
+	l.totalcost.Add(l.totalcost, senderCost)
 
 	return true, old
 }
 
+// freeRotation reports whether tx may replace old without the price bump: it
+// only rotates the payer of old, which did not enter the list by a rotation
+// itself. Payers can thus not be swapped back and forth for free.
+func (l *list) freeRotation(old, tx *types.Transaction, rotated func(common.Hash) bool) bool {
+	if rotated != nil && rotated(old.Hash()) {
+		return false
+	}
+	return l.rotatesPayer(old, tx)
+}
+
+// rotatesPayer reports whether tx only changes the payer of the sponsored old
+// one: both carry the same call and expiry, tx pays at least the same fees and
+// is signed by another payer. Gas sponsors rotating their payer keys re-sign
+// the pooled transactions of their users, which replace the old ones without
+// the price bump.
+func (l *list) rotatesPayer(old, tx *types.Transaction) bool {
+	if old.Type() != types.SponsoredTxType || tx.Type() != types.SponsoredTxType {
+		return false
+	}
+	if old.Gas() != tx.Gas() || old.Value().Cmp(tx.Value()) != 0 || !bytes.Equal(old.Data(), tx.Data()) || old.ExpiredTime() != tx.ExpiredTime() {
+		return false
+	}
+	if oldTo, to := old.To(), tx.To(); (oldTo == nil) != (to == nil) || (to != nil && *oldTo != *to) {
+		return false
+	}
+	if old.GasFeeCapCmp(tx) > 0 || old.GasTipCapCmp(tx) > 0 {
+		return false
+	}
+	oldPayer, err := types.Payer(l.signer, old)
+	if err != nil {
+		return false
+	}
+	payer, err := types.Payer(l.signer, tx)
+	if err != nil {
+		return false
+	}
+	return oldPayer != payer
+}
+
 // removeTx updates the tracking fields in txList after
 // removing those transactions
 func (l *list) removeTx(removed types.Transactions) {
@@ -380,8 +422,8 @@ func (l *list) removePayer(removed types.Transactions) {
 			}
 
 			if l.txpoolPayerCost != nil {
-				gasFee := new(big.Int).Mul(removedTx.GasFeeCap(), new(big.Int).SetUint64(removedTx.Gas()))
-				balance := l.txpoolPayerCost[payer].Sub(l.txpoolPayerCost[payer], gasFee)
+				_, payerCost, _, _ := removedTx.CostBreakdown(nil, nil)
+				balance := l.txpoolPayerCost[payer].Sub(l.txpoolPayerCost[payer], payerCost)
 				if balance.Cmp(common.Big0) == 0 {
 					delete(l.txpoolPayerCost, payer)
 				}
@@ -428,19 +470,18 @@ func (l *list) Filter(
 			return true
 		}
 
+		senderCost, payerCost, _, _ := tx.CostBreakdown(nil, nil)
 		if tx.Type() == types.SponsoredTxType {
 			payer, err := types.Payer(l.signer, tx)
 			if err != nil {
 				return false
 			}
-			gasFee := new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
 			expiredTime := tx.ExpiredTime()
-			return gasFee.Cmp(payerCostLimit[payer]) > 0 ||
-				tx.Value().Cmp(costLimit) > 0 ||
+			return payerCost.Cmp(payerCostLimit[payer]) > 0 ||
+				senderCost.Cmp(costLimit) > 0 ||
 				(expiredTime != 0 && expiredTime <= currentTime)
-		} else {
-			return tx.Cost().Cmp(costLimit) > 0
 		}
+		return senderCost.Cmp(costLimit) > 0
 	})
 
 	if len(removed) == 0 {
@@ -524,6 +565,18 @@ func (l *list) Flatten() types.Transactions {
 	return l.txs.Flatten()
 }
 
+// FlattenType creates a nonce-sorted slice of the transactions of the given type
+// only, without copying the others. The result is nil if there are none.
+func (l *list) FlattenType(txType uint8) types.Transactions {
+	var txs types.Transactions
+	for _, tx := range l.txs.flatten() {
+		if tx.Type() == txType {
+			txs = append(txs, tx)
+		}
+	}
+	return txs
+}
+
 // LastElement returns the last element of a flattened list, thus, the
 // transaction with the highest nonce
 func (l *list) LastElement() *types.Transaction {
@@ -545,12 +598,9 @@ func (l *list) Payers() []common.Address {
 // total cost of all transactions.
 func (l *list) subTotalCost(txs []*types.Transaction) {
 	for _, tx := range txs {
-		if tx.Type() == types.SponsoredTxType {
-			// In sponsored transaction, only the msg.value is paid by sender
-			l.totalcost.Sub(l.totalcost, tx.Value())
-		} else {
-			l.totalcost.Sub(l.totalcost, tx.Cost())
-		}
+		// In sponsored transaction, only the msg.value is paid by sender
+		senderCost, _, _, _ := tx.CostBreakdown(nil, nil)
+		l.totalcost.Sub(l.totalcost, senderCost)
 	}
 }
 
@@ -626,7 +676,7 @@ type pricedList struct {
 
 	all              *lookup    // Pointer to the map of all transactions
 	urgent, floating priceHeap  // Heaps of prices of all the stored **remote** transactions
-	reheapMu         sync.Mutex // Mutex asserts that only one routine is reheaping the list
+	mu               sync.Mutex // Mutex protecting the heaps from concurrent access
 }
 
 const (
@@ -647,26 +697,47 @@ func (l *pricedList) Put(tx *types.Transaction, local bool) {
 	if local {
 		return
 	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	// Insert every new transaction to the urgent heap first; Discard will balance the heaps
 	heap.Push(&l.urgent, tx)
 }
 
+// PutBack reinserts transactions returned by Discard that ended up not being
+// dropped from the pool.
+func (l *pricedList) PutBack(txs types.Transactions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, tx := range txs {
+		heap.Push(&l.urgent, tx)
+	}
+}
+
 // Removed notifies the prices transaction list that an old transaction dropped
 // from the pool. The list will just keep a counter of stale objects and update
 // the heap if a large enough ratio of transactions go stale.
 func (l *pricedList) Removed(count int) {
 	// Bump the stale counter, but exit if still too low (< 25%)
 	stales := atomic.AddInt64(&l.stales, int64(count))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	if int(stales) <= (len(l.urgent.list)+len(l.floating.list))/4 {
 		return
 	}
 	// Seems we've reached a critical number of stale transactions, reheap
-	l.Reheap()
+	l.reheap()
 }
 
 // Underpriced checks whether a transaction is cheaper than (or as cheap as) the
 // lowest priced (remote) transaction currently being tracked.
 func (l *pricedList) Underpriced(tx *types.Transaction) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	// Note: with two queues, being underpriced is defined as being worse than the worst item
 	// in all non-empty queues if there is any. If both queues are empty then nothing is underpriced.
 	return (l.underpricedFor(&l.urgent, tx) || len(l.urgent.list) == 0) &&
@@ -697,13 +768,17 @@ func (l *pricedList) underpricedFor(h *priceHeap, tx *types.Transaction) bool {
 }
 
 // Discard finds a number of most underpriced transactions, removes them from the
-// priced list and returns them for further removal from the entire pool.
+// priced list and returns them for further removal from the entire pool. It
+// keeps discarding until both the requested slots and bytes are freed up.
 // If noPending is set to true, we will only consider the floating list
 //
 // Note local transaction won't be considered for eviction.
-func (l *pricedList) Discard(slots int, force bool) (types.Transactions, bool) {
-	drop := make(types.Transactions, 0, slots) // Remote underpriced transactions to drop
-	for slots > 0 {
+func (l *pricedList) Discard(slots int, bytes int, force bool) (types.Transactions, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	drop := make(types.Transactions, 0, max(slots, 0)) // Remote underpriced transactions to drop
+	for slots > 0 || bytes > 0 {
 		if len(l.urgent.list)*floatingRatio > len(l.floating.list)*urgentRatio || floatingRatio == 0 {
 			// Discard stale transactions if found during cleanup
 			tx := heap.Pop(&l.urgent).(*types.Transaction)
@@ -727,10 +802,11 @@ func (l *pricedList) Discard(slots int, force bool) (types.Transactions, bool) {
 			// Non stale transaction found, discard it
 			drop = append(drop, tx)
 			slots -= numSlots(tx)
+			bytes -= int(tx.Size())
 		}
 	}
 	// If we still can't make enough room for the new transaction
-	if slots > 0 && !force {
+	if (slots > 0 || bytes > 0) && !force {
 		for _, tx := range drop {
 			heap.Push(&l.urgent, tx)
 		}
@@ -741,8 +817,14 @@ func (l *pricedList) Discard(slots int, force bool) (types.Transactions, bool) {
 
 // Reheap forcibly rebuilds the heap based on the current remote transaction set.
 func (l *pricedList) Reheap() {
-	l.reheapMu.Lock()
-	defer l.reheapMu.Unlock()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reheap()
+}
+
+// reheap rebuilds the heaps, the caller must hold the lock.
+func (l *pricedList) reheap() {
 	start := time.Now()
 	atomic.StoreInt64(&l.stales, 0)
 	l.urgent.list = make([]*types.Transaction, 0, l.all.RemoteCount())
@@ -769,6 +851,9 @@ func (l *pricedList) Reheap() {
 // SetBaseFee updates the base fee and triggers a re-heap. Note that Removed is not
 // necessary to call right before SetBaseFee when processing a new block.
 func (l *pricedList) SetBaseFee(baseFee *big.Int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	l.urgent.baseFee = baseFee
-	l.Reheap()
+	l.reheap()
 }
@@ -0,0 +1,193 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// localsJournalVersion is the version of the on-disk local account journal.
+// Journals written by other versions are discarded on load.
+const localsJournalVersion = 1
+
+// localsJournalData is the on-disk format of the local account journal.
+type localsJournalData struct {
+	Version  uint64
+	Accounts []common.Address
+}
+
+// localsJournal persists the accounts added as local through the management
+// API, so that their locality survives node restarts even if none of their
+// transactions are pending to be journaled. The accounts only made local by
+// submitting local transactions aren't persisted.
+type localsJournal struct {
+	path     string                      // Filesystem path to store the accounts at
+	accounts map[common.Address]struct{} // Accounts added explicitly, guarded by the pool lock
+	dirty    atomic.Bool                 // Whether the accounts changed since the last write
+}
+
+// newLocalsJournal creates a local account journal stored at path.
+func newLocalsJournal(path string) *localsJournal {
+	return &localsJournal{path: path, accounts: make(map[common.Address]struct{})}
+}
+
+// add records an account to persist, reporting whether it was new.
+func (j *localsJournal) add(addr common.Address) bool {
+	if _, ok := j.accounts[addr]; ok {
+		return false
+	}
+	j.accounts[addr] = struct{}{}
+	j.dirty.Store(true)
+	return true
+}
+
+// remove stops persisting an account, reporting whether it was recorded.
+func (j *localsJournal) remove(addr common.Address) bool {
+	if _, ok := j.accounts[addr]; !ok {
+		return false
+	}
+	delete(j.accounts, addr)
+	j.dirty.Store(true)
+	return true
+}
+
+// load parses the local accounts from the journal on disk.
+func (j *localsJournal) load() ([]common.Address, error) {
+	input, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer input.Close()
+
+	var data localsJournalData
+	if err := rlp.Decode(input, &data); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if data.Version != localsJournalVersion {
+		log.Info("Discarding outdated local account journal", "version", data.Version, "want", localsJournalVersion)
+		return nil, nil
+	}
+	for _, addr := range data.Accounts {
+		j.accounts[addr] = struct{}{}
+	}
+	return data.Accounts, nil
+}
+
+// write dumps the recorded accounts into a new journal file, replacing the live
+// one.
+func (j *localsJournal) write() error {
+	sorted := make([]common.Address, 0, len(j.accounts))
+	for addr := range j.accounts {
+		sorted = append(sorted, addr)
+	}
+	sort.Slice(sorted, func(i, k int) bool {
+		return bytes.Compare(sorted[i][:], sorted[k][:]) < 0
+	})
+	replacement, err := os.OpenFile(j.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := rlp.Encode(replacement, &localsJournalData{Version: localsJournalVersion, Accounts: sorted}); err != nil {
+		replacement.Close()
+		return err
+	}
+	if err := replacement.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(j.path+".new", j.path); err != nil {
+		return err
+	}
+	j.dirty.Store(false)
+	log.Debug("Regenerated local account journal", "accounts", len(sorted))
+	return nil
+}
+
+// markLocal starts treating an account as local, migrating its pooled remote
+// transactions into the local set. The pool lock must be held exclusively.
+func (pool *LegacyPool) markLocal(addr common.Address) {
+	log.Info("Setting new local account", "address", addr)
+	pool.locals.add(addr)
+
+	migrated := pool.all.RemoteToLocals(pool.locals)
+	pool.priced.Removed(migrated)
+	localGauge.Inc(int64(migrated))
+}
+
+// journalLocals writes the explicitly added local accounts out to the journal
+// if they changed since the last write. The pool lock must be held exclusively.
+func (pool *LegacyPool) journalLocals() error {
+	if pool.localsJournal == nil || !pool.localsJournal.dirty.Load() {
+		return nil
+	}
+	return pool.localsJournal.write()
+}
+
+// AddLocalAccount implements txpool.LocalAccountManager, treating an account
+// as local regardless of its transactions and persisting its locality.
+func (pool *LegacyPool) AddLocalAccount(addr common.Address) error {
+	if pool.config.NoLocals {
+		return txpool.ErrLocalsDisabled
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if !pool.locals.contains(addr) {
+		pool.markLocal(addr)
+	}
+	if pool.localsJournal != nil {
+		pool.localsJournal.add(addr)
+	}
+	return pool.journalLocals()
+}
+
+// RemoveLocalAccount implements txpool.LocalAccountManager, subjecting an
+// account and its pooled transactions to the remote pricing and eviction rules
+// again. It reports whether the account was local.
+func (pool *LegacyPool) RemoveLocalAccount(addr common.Address) (bool, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if !pool.locals.contains(addr) {
+		return false, nil
+	}
+	log.Info("Removing local account", "address", addr)
+	pool.locals.remove(addr)
+
+	demoted := pool.all.LocalsToRemotes(pool.locals)
+	for _, tx := range demoted {
+		pool.priced.Put(tx, false)
+	}
+	localGauge.Dec(int64(len(demoted)))
+
+	if pool.localsJournal != nil {
+		pool.localsJournal.remove(addr)
+	}
+	return true, pool.journalLocals()
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The pool state is guarded by a hierarchy of locks, which must always be
+// acquired in the following order to avoid deadlocks:
+//
+//  1. pool.mu: held exclusively by operations spanning many accounts (reorg
+//     bookkeeping, pool limits, eviction, tip changes), and shared by anything
+//     confined to a handful of accounts.
+//  2. account stripes: guard the pending and queued lists of the accounts
+//     mapped onto them, acquired in ascending stripe order while pool.mu is
+//     held in shared mode.
+//  3. leaf locks: pool.stateMu, pool.accountsMu, the priced list, lookup,
+//     noncer, journal, nonce manager and eviction index locks. They are held
+//     briefly and never nested into each other, except pool.stateMu which may
+//     be held while acquiring pool.accountsMu.
+//
+// With pool.mu held exclusively, none of the account stripes are held by
+// anyone else, so the account state may be accessed directly.
+
+// accountLockStripes is the number of locks the accounts are spread across.
+const accountLockStripes = 256
+
+// errExclusiveAccess is returned by the admission of a transaction under
+// shared pool access when it needs to modify pool-wide state, signalling the
+// caller to retry with the pool lock held exclusively.
+var errExclusiveAccess = errors.New("exclusive pool access required")
+
+// accountLocks is a set of striped locks guarding the per-account state of the
+// pool, allowing operations on unrelated accounts to proceed concurrently.
+type accountLocks struct {
+	stripes [accountLockStripes]sync.Mutex
+}
+
+// stripe returns the index of the lock guarding an account. Addresses are
+// derived from hashes, so any byte of them is uniformly distributed.
+func (l *accountLocks) stripe(addr common.Address) int {
+	return int(addr[common.AddressLength-1]) % accountLockStripes
+}
+
+// lock acquires the locks of all the given accounts in ascending stripe order
+// and returns a function releasing them.
+func (l *accountLocks) lock(addrs ...common.Address) func() {
+	stripes := make([]int, 0, len(addrs))
+	for _, addr := range addrs {
+		stripes = append(stripes, l.stripe(addr))
+	}
+	sort.Ints(stripes)
+
+	held := make([]int, 0, len(stripes))
+	for _, stripe := range stripes {
+		if len(held) > 0 && held[len(held)-1] == stripe {
+			continue
+		}
+		l.stripes[stripe].Lock()
+		held = append(held, stripe)
+	}
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			l.stripes[held[i]].Unlock()
+		}
+	}
+}
+
+// pendingList returns the pending transactions of an account, if any. The list
+// itself may only be accessed with the account lock held.
+func (pool *LegacyPool) pendingList(addr common.Address) *list {
+	pool.accountsMu.RLock()
+	defer pool.accountsMu.RUnlock()
+
+	return pool.pending[addr]
+}
+
+// queuedList returns the queued transactions of an account, if any. The list
+// itself may only be accessed with the account lock held.
+func (pool *LegacyPool) queuedList(addr common.Address) *list {
+	pool.accountsMu.RLock()
+	defer pool.accountsMu.RUnlock()
+
+	return pool.queue[addr]
+}
@@ -0,0 +1,161 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// minedJournalVersion is the version of the on-disk mined transaction journal.
+// Journals written by other versions are discarded on load.
+const minedJournalVersion = 1
+
+// minedJournal is the on-disk format of the mined transaction set, listing the
+// hashes from the least to the most recently mined one.
+type minedJournal struct {
+	Version uint64
+	Hashes  []common.Hash
+}
+
+// minedSet is a size limited LRU set of the hashes of recently mined
+// transactions, which is journaled to disk so that re-gossiped transactions
+// already included in the chain are rejected cheaply even after a restart,
+// without validating them against the state.
+type minedSet struct {
+	path  string                            // Filesystem path to store the journal at (empty = memory only)
+	cache *lru.Cache[common.Hash, struct{}] // Mined transaction hashes, evicted in LRU order
+	dirty atomic.Bool                       // Whether the set changed since the last journal
+	lock  sync.Mutex                        // Lock protecting the journal file
+}
+
+// newMinedSet creates a mined transaction set holding up to limit hashes,
+// preloaded from the journal at path (if any).
+func newMinedSet(path string, limit int) (*minedSet, error) {
+	cache, err := lru.New[common.Hash, struct{}](limit)
+	if err != nil {
+		return nil, err
+	}
+	set := &minedSet{
+		path:  path,
+		cache: cache,
+	}
+	if err := set.load(); err != nil {
+		log.Warn("Failed to load mined transaction journal", "path", path, "err", err)
+	}
+	return set, nil
+}
+
+// contains reports whether the transaction with the given hash was recently
+// mined.
+func (s *minedSet) contains(hash common.Hash) bool {
+	return s.cache.Contains(hash)
+}
+
+// add marks the given transactions as mined.
+func (s *minedSet) add(txs types.Transactions) {
+	for _, tx := range txs {
+		s.cache.Add(tx.Hash(), struct{}{})
+	}
+	if len(txs) > 0 {
+		s.dirty.Store(true)
+	}
+}
+
+// remove unmarks the given transactions, e.g. if their block was reorged out.
+func (s *minedSet) remove(txs types.Transactions) {
+	for _, tx := range txs {
+		if s.cache.Remove(tx.Hash()) {
+			s.dirty.Store(true)
+		}
+	}
+}
+
+// load parses the journal from disk, inserting its contents into the set.
+func (s *minedSet) load() error {
+	if s.path == "" {
+		return nil
+	}
+	input, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	var journal minedJournal
+	if err := rlp.Decode(input, &journal); err != nil && err != io.EOF {
+		return err
+	}
+	if journal.Version != minedJournalVersion {
+		log.Info("Discarding outdated mined transaction journal", "version", journal.Version, "want", minedJournalVersion)
+		return nil
+	}
+	for _, hash := range journal.Hashes {
+		s.cache.Add(hash, struct{}{})
+	}
+	log.Info("Loaded mined transaction journal", "transactions", s.cache.Len())
+	return nil
+}
+
+// journal regenerates the journal on disk based on the current contents of the
+// set. It is a noop if nothing changed since the last journal.
+func (s *minedSet) journal() error {
+	if s.path == "" {
+		return nil
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.dirty.Swap(false) {
+		return nil
+	}
+	if err := s.write(); err != nil {
+		s.dirty.Store(true)
+		return err
+	}
+	return nil
+}
+
+// write dumps the set into a new journal file, replacing the live one.
+func (s *minedSet) write() error {
+	replacement, err := os.OpenFile(s.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	journal := &minedJournal{Version: minedJournalVersion, Hashes: s.cache.Keys()}
+	if err := rlp.Encode(replacement, journal); err != nil {
+		replacement.Close()
+		return err
+	}
+	if err := replacement.Close(); err != nil {
+		return err
+	}
+	log.Debug("Regenerated mined transaction journal", "transactions", len(journal.Hashes))
+	return os.Rename(s.path+".new", s.path)
+}
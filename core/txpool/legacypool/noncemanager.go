@@ -0,0 +1,129 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+)
+
+// nonceReservationLifetime is the maximum amount of time a reserved nonce is
+// held for its submitter before it's handed out again.
+const nonceReservationLifetime = time.Minute
+
+// nonceManager allocates nonces for a set of managed accounts to concurrent
+// submitters. A reserved nonce is held until a transaction using it enters the
+// pool, it's explicitly released, or the reservation expires. Nonces used by
+// pooled transactions are never handed out, so the reservations only need to
+// cover the window between allocation and submission.
+//
+// The set of managed accounts is fixed at creation, the reservations of them are
+// protected by the manager's own lock.
+type nonceManager struct {
+	reserved map[common.Address]map[uint64]time.Time // Expiration of the reserved nonces per managed account
+	lock     sync.Mutex
+}
+
+// newNonceManager creates a nonce manager for the given accounts.
+func newNonceManager(accounts []common.Address) *nonceManager {
+	m := &nonceManager{
+		reserved: make(map[common.Address]map[uint64]time.Time),
+	}
+	for _, addr := range accounts {
+		m.reserved[addr] = make(map[uint64]time.Time)
+	}
+	return m
+}
+
+// managed returns whether the nonces of the account are managed.
+func (m *nonceManager) managed(addr common.Address) bool {
+	_, ok := m.reserved[addr]
+	return ok
+}
+
+// free returns the lowest nonce, starting at the pending one, that is neither
+// reserved nor used by a queued transaction.
+func (m *nonceManager) free(addr common.Address, pending uint64, queued *list, now time.Time) uint64 {
+	nonce := pending
+	for {
+		if expiry, ok := m.reserved[addr][nonce]; ok && now.Before(expiry) {
+			nonce++
+			continue
+		}
+		if queued != nil && queued.txs.Get(nonce) != nil {
+			nonce++
+			continue
+		}
+		return nonce
+	}
+}
+
+// reserve allocates the next free nonce of the account, dropping any stale
+// reservations below the pending nonce or past their lifetime.
+func (m *nonceManager) reserve(addr common.Address, pending uint64, queued *list) uint64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := time.Now()
+	for nonce, expiry := range m.reserved[addr] {
+		if nonce < pending || !now.Before(expiry) {
+			delete(m.reserved[addr], nonce)
+		}
+	}
+	nonce := m.free(addr, pending, queued, now)
+	m.reserved[addr][nonce] = now.Add(nonceReservationLifetime)
+	return nonce
+}
+
+// release drops the reservation of a nonce, making it available again.
+func (m *nonceManager) release(addr common.Address, nonce uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if reserved, ok := m.reserved[addr]; ok {
+		delete(reserved, nonce)
+	}
+}
+
+// ReserveNonce allocates a nonce of a managed account for exclusive use by the
+// caller. The reservation is consumed once a transaction with the nonce enters
+// the pool, and is otherwise held for a limited time unless released.
+func (pool *LegacyPool) ReserveNonce(addr common.Address) (uint64, error) {
+	if !pool.nonces.managed(addr) {
+		return 0, txpool.ErrAccountNotManaged
+	}
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	unlock := pool.locks.lock(addr)
+	defer unlock()
+
+	return pool.nonces.reserve(addr, pool.pendingNonces.get(addr), pool.queuedList(addr)), nil
+}
+
+// ReleaseNonce gives back a reserved nonce that the caller won't use, so that
+// it can be allocated to the next submitter instead of leaving a nonce gap.
+func (pool *LegacyPool) ReleaseNonce(addr common.Address, nonce uint64) error {
+	if !pool.nonces.managed(addr) {
+		return txpool.ErrAccountNotManaged
+	}
+	pool.nonces.release(addr, nonce)
+	return nil
+}
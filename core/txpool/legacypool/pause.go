@@ -0,0 +1,77 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	pausedTxMeter    = metrics.NewRegisteredMeter("txpool/paused", nil)         // Dropped on arrival due to an account pause
+	pausedEvictMeter = metrics.NewRegisteredMeter("txpool/paused/evicted", nil) // Evicted from the pool due to an account pause
+)
+
+// PauseAccount implements txpool.AccountPauser, dropping the newly arriving
+// transactions from or to an account and optionally evicting the pooled ones.
+func (pool *LegacyPool) PauseAccount(pause txpool.AccountPause, evict bool) int {
+	pool.pauses.Set(pause)
+	log.Warn("Paused transactions of account", "address", pause.Address, "sender", pause.Sender, "recipient", pause.Recipient, "expiry", pause.Expiry, "evict", evict)
+
+	if !evict || !pause.Active(time.Now()) {
+		return 0
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var drop []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction, local bool) bool {
+		from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+		if pause.Match(pool.signer, tx, from) != nil {
+			drop = append(drop, hash)
+		}
+		return true
+	}, true, true)
+
+	for _, hash := range drop {
+		pool.removeTx(hash, true, true)
+	}
+	pausedEvictMeter.Mark(int64(len(drop)))
+	if len(drop) > 0 {
+		log.Warn("Evicted transactions of paused account", "address", pause.Address, "count", len(drop))
+	}
+	return len(drop)
+}
+
+// ResumeAccount implements txpool.AccountPauser, lifting the pause of an account.
+func (pool *LegacyPool) ResumeAccount(addr common.Address) bool {
+	resumed := pool.pauses.Remove(addr, time.Now())
+	if resumed {
+		log.Info("Resumed transactions of account", "address", addr)
+	}
+	return resumed
+}
+
+// PausedAccounts implements txpool.AccountPauser, returning the pauses in force.
+func (pool *LegacyPool) PausedAccounts() []txpool.AccountPause {
+	return pool.pauses.List(time.Now())
+}
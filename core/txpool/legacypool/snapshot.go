@@ -0,0 +1,182 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package legacypool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// snapshotVersion is the version of the pool snapshot format. Snapshots written
+// by other versions are rejected on restore.
+const snapshotVersion = 1
+
+// snapshotBatch is the number of snapshotted transactions added to the pool at
+// once on restore.
+const snapshotBatch = 1024
+
+// errPoolNotInitialized is returned if a snapshot is restored into a pool which
+// wasn't initialized with a head yet.
+var errPoolNotInitialized = errors.New("pool not initialized")
+
+// snapshotHeader leads a pool snapshot, followed by the pending and queued
+// transactions of every account as a stream of snapshotEntry.
+type snapshotHeader struct {
+	Version uint64
+	Pending uint64 // Number of pending transactions snapshotted
+	Queued  uint64 // Number of queued transactions snapshotted
+}
+
+// snapshotEntry is a transaction of a pool snapshot.
+type snapshotEntry struct {
+	Tx    *types.Transaction
+	Local bool // Whether the sender was treated as local
+}
+
+// Snapshot serializes all the pending and queued transactions of the pool, the
+// remote ones included, into the given writer, for a restarting node to refill
+// its pool with Restore instead of waiting for their re-broadcast. The pending
+// transactions of an account precede its queued ones, ordered by nonce.
+func (pool *LegacyPool) Snapshot(w io.Writer) error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var (
+		pending, queued = pool.stats()
+		entries         = make([]snapshotEntry, 0, pending+queued)
+	)
+	collect := func(lists map[common.Address]*list) {
+		for _, list := range lists {
+			for _, tx := range list.Flatten() {
+				entries = append(entries, snapshotEntry{Tx: tx, Local: pool.locals.containsTx(tx)})
+			}
+		}
+	}
+	collect(pool.pending)
+	collect(pool.queue)
+
+	header := &snapshotHeader{Version: snapshotVersion, Pending: uint64(pending), Queued: uint64(queued)}
+	if err := rlp.Encode(w, header); err != nil {
+		return err
+	}
+	for i := range entries {
+		if err := rlp.Encode(w, &entries[i]); err != nil {
+			return err
+		}
+	}
+	log.Info("Snapshotted transaction pool", "pending", pending, "queued", queued)
+	return nil
+}
+
+// Restore adds the transactions of a snapshot written by Snapshot into the pool,
+// which must be initialized, waiting for them to be promoted. The transactions
+// no longer valid, e.g. included meanwhile, are dropped.
+func (pool *LegacyPool) Restore(r io.Reader) error {
+	if pool.currentHead.Load() == nil {
+		return errPoolNotInitialized
+	}
+	stream := rlp.NewStream(r, 0)
+
+	var header snapshotHeader
+	if err := stream.Decode(&header); err != nil {
+		return err
+	}
+	if header.Version != snapshotVersion {
+		return fmt.Errorf("unsupported pool snapshot version %d, want %d", header.Version, snapshotVersion)
+	}
+	var (
+		total, dropped int
+		batch          []*types.Transaction
+		local          bool
+		failure        error
+	)
+	// Add the transactions in batches of the same locality, in order for the
+	// pending ones of an account to be added before its queued ones
+	flush := func() {
+		for _, err := range pool.Add(batch, local, false) {
+			if err != nil {
+				log.Trace("Failed to restore snapshotted transaction", "err", err)
+				dropped++
+			}
+		}
+		batch = batch[:0]
+	}
+	for {
+		var entry snapshotEntry
+		if err := stream.Decode(&entry); err != nil {
+			if err != io.EOF {
+				failure = err
+			}
+			break
+		}
+		total++
+		if len(batch) > 0 && (entry.Local != local || len(batch) >= snapshotBatch) {
+			flush()
+		}
+		batch, local = append(batch, entry.Tx), entry.Local
+	}
+	if len(batch) > 0 {
+		flush()
+	}
+	<-pool.requestPromoteExecutables(newAccountSet(pool.signer))
+
+	log.Info("Restored transaction pool snapshot", "transactions", total, "dropped", dropped)
+	return failure
+}
+
+// writeSnapshot snapshots the pool into a new file at path, replacing any
+// existing one.
+func (pool *LegacyPool) writeSnapshot(path string) error {
+	output, err := os.OpenFile(path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if err := pool.Snapshot(output); err != nil {
+		output.Close()
+		return err
+	}
+	if err := output.Close(); err != nil {
+		return err
+	}
+	return os.Rename(path+".new", path)
+}
+
+// restoreSnapshot restores the pool snapshot of the file at path, if any, and
+// deletes it, not to restore outdated transactions after a later crash.
+func (pool *LegacyPool) restoreSnapshot(path string) error {
+	input, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = pool.Restore(input)
+	input.Close()
+
+	if rerr := os.Remove(path); err == nil {
+		err = rerr
+	}
+	return err
+}
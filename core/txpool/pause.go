@@ -0,0 +1,139 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// AccountPauses is the set of accounts whose transactions are not admitted into
+// a subpool, set by the node operator to contain the damage of compromised keys.
+// Expired pauses are dropped lazily.
+type AccountPauses struct {
+	pauses map[common.Address]AccountPause
+	lock   sync.RWMutex
+}
+
+// NewAccountPauses creates an empty set of account pauses.
+func NewAccountPauses() *AccountPauses {
+	return &AccountPauses{pauses: make(map[common.Address]AccountPause)}
+}
+
+// Set pauses an account, replacing any previous pause of it.
+func (p *AccountPauses) Set(pause AccountPause) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.pauses[pause.Address] = pause
+}
+
+// Remove lifts the pause of an account, reporting whether one was in force.
+func (p *AccountPauses) Remove(addr common.Address, now time.Time) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pause, ok := p.pauses[addr]
+	delete(p.pauses, addr)
+	return ok && pause.Active(now)
+}
+
+// List returns the pauses in force, ordered by account, dropping the expired
+// ones.
+func (p *AccountPauses) List(now time.Time) []AccountPause {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	pauses := make([]AccountPause, 0, len(p.pauses))
+	for addr, pause := range p.pauses {
+		if !pause.Active(now) {
+			delete(p.pauses, addr)
+			continue
+		}
+		pauses = append(pauses, pause)
+	}
+	sortPauses(pauses)
+	return pauses
+}
+
+// Check returns an error if the transaction, sent by the given account, is
+// paused.
+func (p *AccountPauses) Check(signer types.Signer, tx *types.Transaction, from common.Address, now time.Time) error {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if len(p.pauses) == 0 {
+		return nil
+	}
+	accounts := []common.Address{from}
+	if to := tx.To(); to != nil {
+		accounts = append(accounts, *to)
+	}
+	if tx.Type() == types.SponsoredTxType {
+		// The payer is recovered by the basic validation, an invalid one fails later
+		if payer, err := types.Payer(signer, tx); err == nil {
+			accounts = append(accounts, payer)
+		}
+	}
+	for _, addr := range accounts {
+		if pause, ok := p.pauses[addr]; ok && pause.Active(now) {
+			if err := pause.Match(signer, tx, from); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Active returns whether the pause is still in force.
+func (pause AccountPause) Active(now time.Time) bool {
+	return pause.Expiry.IsZero() || now.Before(pause.Expiry)
+}
+
+// Match returns an error if the transaction, sent by the given account, is
+// paused by the pause.
+func (pause AccountPause) Match(signer types.Signer, tx *types.Transaction, from common.Address) error {
+	if pause.Sender {
+		if from == pause.Address {
+			return fmt.Errorf("%w: sender %v", ErrAccountPaused, from)
+		}
+		if tx.Type() == types.SponsoredTxType {
+			if payer, err := types.Payer(signer, tx); err == nil && payer == pause.Address {
+				return fmt.Errorf("%w: payer %v", ErrAccountPaused, payer)
+			}
+		}
+	}
+	if pause.Recipient {
+		if to := tx.To(); to != nil && *to == pause.Address {
+			return fmt.Errorf("%w: recipient %v", ErrAccountPaused, *to)
+		}
+	}
+	return nil
+}
+
+// sortPauses orders the pauses by account.
+func sortPauses(pauses []AccountPause) {
+	sort.Slice(pauses, func(i, j int) bool {
+		return bytes.Compare(pauses[i].Address[:], pauses[j].Address[:]) < 0
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// rejectionWindow is the number of minutes the rejection statistics are kept.
+const rejectionWindow = 60
+
+// Codes the transactions rejected by the pool are aggregated under.
+const (
+	RejectUnderpriced       = "underpriced"
+	RejectNonceTooLow       = "nonce_too_low"
+	RejectInsufficientFunds = "insufficient_funds"
+	RejectOversized         = "oversized"
+	RejectPayerInvalid      = "payer_invalid"
+	RejectExpired           = "expired"
+	RejectSponsor           = "sponsor_rejected"
+	RejectOther             = "other"
+)
+
+// rejectionErrors maps the errors returned by the pool to their rejection code,
+// the first match wins.
+var rejectionErrors = []struct {
+	err  error
+	code string
+}{
+	{ErrUnderpriced, RejectUnderpriced},
+	{ErrReplaceUnderpriced, RejectUnderpriced},
+	{core.ErrFeeCapTooLow, RejectUnderpriced},
+	{core.ErrNonceTooLow, RejectNonceTooLow},
+	{core.ErrInsufficientFunds, RejectInsufficientFunds},
+	{core.ErrInsufficientFundsForTransfer, RejectInsufficientFunds},
+	{core.ErrInsufficientPayerFunds, RejectInsufficientFunds},
+	{core.ErrInsufficientSenderFunds, RejectInsufficientFunds},
+	{ErrOversizedData, RejectOversized},
+	{core.ErrMaxInitCodeSizeExceeded, RejectOversized},
+	{ErrInvalidPayer, RejectPayerInvalid},
+	{core.ErrExpiredSponsoredTx, RejectExpired},
+	{ErrSponsorRejected, RejectSponsor},
+}
+
+// rejectionMeters counts the rejected transactions per rejection code.
+var rejectionMeters = map[string]metrics.Meter{
+	RejectUnderpriced:       metrics.NewRegisteredMeter("txpool/rejected/underpriced", nil),
+	RejectNonceTooLow:       metrics.NewRegisteredMeter("txpool/rejected/noncetoolow", nil),
+	RejectInsufficientFunds: metrics.NewRegisteredMeter("txpool/rejected/nofunds", nil),
+	RejectOversized:         metrics.NewRegisteredMeter("txpool/rejected/oversized", nil),
+	RejectPayerInvalid:      metrics.NewRegisteredMeter("txpool/rejected/payerinvalid", nil),
+	RejectExpired:           metrics.NewRegisteredMeter("txpool/rejected/expired", nil),
+	RejectSponsor:           metrics.NewRegisteredMeter("txpool/rejected/sponsor", nil),
+	RejectOther:             metrics.NewRegisteredMeter("txpool/rejected/other", nil),
+}
+
+// RejectionCode returns the code a transaction rejected with the given error
+// is aggregated under.
+func RejectionCode(err error) string {
+	for _, rejection := range rejectionErrors {
+		if errors.Is(err, rejection.err) {
+			return rejection.code
+		}
+	}
+	return RejectOther
+}
+
+// RejectionStats is the number of transactions rejected by the pool during a
+// minute, per rejection code.
+type RejectionStats struct {
+	Minute time.Time         `json:"minute"` // Start of the minute
+	Counts map[string]uint64 `json:"counts"` // Rejected transactions per code
+}
+
+// rejections aggregates the transactions rejected by the pool per minute over
+// the last rejectionWindow minutes.
+type rejections struct {
+	stats []RejectionStats // Minutes with rejections, oldest first
+	lock  sync.Mutex
+}
+
+// record accounts the given admission errors at the given time. Transactions
+// already known to the pool are not counted as rejected.
+func (r *rejections) record(errs []error, now time.Time) {
+	minute := now.Truncate(time.Minute)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var counts map[string]uint64
+	for _, err := range errs {
+		if err == nil || errors.Is(err, ErrAlreadyKnown) {
+			continue
+		}
+		code := RejectionCode(err)
+		rejectionMeters[code].Mark(1)
+
+		if counts == nil {
+			if n := len(r.stats); n > 0 && r.stats[n-1].Minute.Equal(minute) {
+				counts = r.stats[n-1].Counts
+			} else {
+				counts = make(map[string]uint64)
+				r.stats = append(r.stats, RejectionStats{Minute: minute, Counts: counts})
+			}
+		}
+		counts[code]++
+	}
+	r.expire(minute)
+}
+
+// expire drops the minutes that fell out of the window ending at the given one.
+// The caller must hold the lock.
+func (r *rejections) expire(minute time.Time) {
+	cutoff := minute.Add(-(rejectionWindow - 1) * time.Minute)
+
+	var stale int
+	for stale < len(r.stats) && r.stats[stale].Minute.Before(cutoff) {
+		stale++
+	}
+	r.stats = r.stats[stale:]
+}
+
+// snapshot returns a copy of the statistics within the window ending at the
+// given time, oldest first.
+func (r *rejections) snapshot(now time.Time) []RejectionStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.expire(now.Truncate(time.Minute))
+
+	stats := make([]RejectionStats, len(r.stats))
+	for i, stat := range r.stats {
+		counts := make(map[string]uint64, len(stat.Counts))
+		for code, count := range stat.Counts {
+			counts[code] = count
+		}
+		stats[i] = RejectionStats{Minute: stat.Minute, Counts: counts}
+	}
+	return stats
+}
+
+// Rejections returns the number of transactions rejected by the pool per minute
+// and rejection code over the last hour, oldest first. Minutes without any
+// rejection are omitted.
+func (p *TxPool) Rejections() []RejectionStats {
+	return p.rejections.snapshot(time.Now())
+}
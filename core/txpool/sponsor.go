@@ -0,0 +1,237 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const (
+	// DefaultSponsorTimeout is the time a sponsor hook is given to decide on a
+	// transaction, if none is configured.
+	DefaultSponsorTimeout = 100 * time.Millisecond
+
+	// DefaultSponsorCacheTTL is the time the decisions of a sponsor hook are
+	// reused for, if none is configured.
+	DefaultSponsorCacheTTL = time.Minute
+
+	// sponsorCacheSize is the number of payer and recipient pairs whose sponsor
+	// decisions are cached.
+	sponsorCacheSize = 4096
+)
+
+var (
+	sponsorRejectMeter  = metrics.NewRegisteredMeter("txpool/sponsor/rejected", nil) // Rejected by the sponsor hook
+	sponsorTimeoutMeter = metrics.NewRegisteredMeter("txpool/sponsor/timeout", nil)  // Rejected for the hook not deciding in time
+	sponsorHitMeter     = metrics.NewRegisteredMeter("txpool/sponsor/cache/hit", nil)
+	sponsorMissMeter    = metrics.NewRegisteredMeter("txpool/sponsor/cache/miss", nil)
+)
+
+// SponsorHook decides whether the payer of a sponsored transaction agrees to pay
+// for it, e.g. by looking up a local whitelist or the view of a paymaster contract.
+// A nil error admits the transaction. The hook must return once the context is
+// cancelled; its decisions are cached per payer and recipient, so they must not
+// depend on anything else.
+type SponsorHook func(ctx context.Context, payer common.Address, tx *types.Transaction) error
+
+// sponsorKey identifies the cached decision of a sponsor hook.
+type sponsorKey struct {
+	payer common.Address
+	to    common.Address // Zero for contract creations
+}
+
+// sponsorDecision is a cached outcome of a sponsor hook.
+type sponsorDecision struct {
+	err    error
+	expiry time.Time
+}
+
+// sponsorGate consults a sponsor hook on the admission of the sponsored
+// transactions, bounding it by a timeout and caching its decisions.
+type sponsorGate struct {
+	hook    SponsorHook
+	timeout time.Duration
+	ttl     time.Duration
+	cache   *lru.Cache[sponsorKey, sponsorDecision]
+}
+
+// newSponsorGate creates a gate around the hook, defaulting the zero timeout
+// and cache lifetime.
+func newSponsorGate(hook SponsorHook, timeout, ttl time.Duration) *sponsorGate {
+	if timeout == 0 {
+		timeout = DefaultSponsorTimeout
+	}
+	if ttl == 0 {
+		ttl = DefaultSponsorCacheTTL
+	}
+	cache, _ := lru.New[sponsorKey, sponsorDecision](sponsorCacheSize)
+	return &sponsorGate{hook: hook, timeout: timeout, ttl: ttl, cache: cache}
+}
+
+// check consults the hook on the unknown sponsored transactions of the batch
+// whose error slot is still empty, concurrently, and fills in the rejections.
+// The transactions with an invalid payer are left to the subpools to reject.
+func (g *sponsorGate) check(txs []*types.Transaction, errs []error, known func(hash common.Hash) bool) {
+	var (
+		now = time.Now()
+		wg  sync.WaitGroup
+	)
+	for i, tx := range txs {
+		if errs[i] != nil || tx.Type() != types.SponsoredTxType || known(tx.Hash()) {
+			continue
+		}
+		payer, err := types.Payer(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			continue
+		}
+		key := sponsorKey{payer: payer}
+		if to := tx.To(); to != nil {
+			key.to = *to
+		}
+		if decision, ok := g.cache.Get(key); ok && now.Before(decision.expiry) {
+			sponsorHitMeter.Mark(1)
+			errs[i] = g.reject(payer, decision.err)
+			continue
+		}
+		sponsorMissMeter.Mark(1)
+
+		wg.Add(1)
+		go func(i int, tx *types.Transaction, key sponsorKey) {
+			defer wg.Done()
+			errs[i] = g.decide(tx, key)
+		}(i, tx, key)
+	}
+	wg.Wait()
+}
+
+// decide runs the hook on a transaction, caching the decision unless the hook
+// ran out of time.
+func (g *sponsorGate) decide(tx *types.Transaction, key sponsorKey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+
+	// The hook runs apart so that a misbehaving one can't hold up the admission
+	done := make(chan error, 1)
+	go func() { done <- g.hook(ctx, key.payer, tx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		sponsorTimeoutMeter.Mark(1)
+		return fmt.Errorf("%w: payer %v undecided in %v", ErrSponsorRejected, key.payer, g.timeout)
+	}
+	g.cache.Add(key, sponsorDecision{err: err, expiry: time.Now().Add(g.ttl)})
+	return g.reject(key.payer, err)
+}
+
+// reject wraps a rejection of the hook into ErrSponsorRejected.
+func (g *sponsorGate) reject(payer common.Address, err error) error {
+	if err == nil {
+		return nil
+	}
+	sponsorRejectMeter.Mark(1)
+	if errors.Is(err, ErrSponsorRejected) {
+		return err
+	}
+	return fmt.Errorf("%w: payer %v: %v", ErrSponsorRejected, payer, err)
+}
+
+// SetSponsorHook installs the hook deciding whether the payers of the sponsored
+// transactions agree to pay for them, local transactions included. The hook is
+// given the timeout to decide, a late one rejecting the transaction, and its
+// decisions are reused for the cache lifetime. Zero durations pick the defaults,
+// a nil hook admits all sponsored transactions.
+func (p *TxPool) SetSponsorHook(hook SponsorHook, timeout, cacheTTL time.Duration) {
+	if hook == nil {
+		p.sponsors.Store(nil)
+		return
+	}
+	gate := newSponsorGate(hook, timeout, cacheTTL)
+	p.sponsors.Store(gate)
+	log.Info("Enabled sponsored transaction hook", "timeout", gate.timeout, "cachettl", gate.ttl)
+}
+
+// SponsorWhitelist is the set of payers whose sponsored transactions are
+// admitted into the pool, along with the recipients each one sponsors. A payer
+// without recipients sponsors any transaction.
+type SponsorWhitelist map[common.Address][]common.Address
+
+// ParseSponsorWhitelist parses a comma separated list of payers, each optionally
+// followed by = and a + separated list of the recipients it sponsors.
+func ParseSponsorWhitelist(spec string) (SponsorWhitelist, error) {
+	whitelist := make(SponsorWhitelist)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		payer, recipients, _ := strings.Cut(entry, "=")
+		if !common.IsHexAddress(strings.TrimSpace(payer)) {
+			return nil, fmt.Errorf("invalid payer %q", payer)
+		}
+		addr := common.HexToAddress(strings.TrimSpace(payer))
+		whitelist[addr] = nil
+		for _, recipient := range strings.Split(recipients, "+") {
+			recipient = strings.TrimSpace(recipient)
+			if recipient == "" {
+				continue
+			}
+			if !common.IsHexAddress(recipient) {
+				return nil, fmt.Errorf("invalid recipient %q of payer %v", recipient, addr)
+			}
+			whitelist[addr] = append(whitelist[addr], common.HexToAddress(recipient))
+		}
+	}
+	return whitelist, nil
+}
+
+// Hook returns the sponsor hook admitting the transactions paid by the payers
+// of the whitelist and sent to the recipients they sponsor.
+func (w SponsorWhitelist) Hook() SponsorHook {
+	return func(ctx context.Context, payer common.Address, tx *types.Transaction) error {
+		recipients, ok := w[payer]
+		if !ok {
+			return errors.New("payer not whitelisted")
+		}
+		if len(recipients) == 0 {
+			return nil
+		}
+		if to := tx.To(); to != nil {
+			for _, recipient := range recipients {
+				if recipient == *to {
+					return nil
+				}
+			}
+		}
+		return errors.New("recipient not sponsored by payer")
+	}
+}
@@ -88,6 +88,24 @@ type PendingFilter struct {
 
 	OnlyPlainTxs bool // Return only plain EVM transactions (peer-join announces, block space filling)
 	OnlyBlobTxs  bool // Return only blob transactions (block blob-space filling)
+
+	// Return only transactions of the given types (nil = all types). As the
+	// other types are skipped, the returned lists may have nonce gaps.
+	OnlyTxTypes []uint8
+}
+
+// AllowsType reports whether the filter lets transactions of the given type
+// through.
+func (f *PendingFilter) AllowsType(txType uint8) bool {
+	if f.OnlyTxTypes == nil {
+		return true
+	}
+	for _, allowed := range f.OnlyTxTypes {
+		if allowed == txType {
+			return true
+		}
+	}
+	return false
 }
 
 // SubPool represents a specialized transaction pool that lives on its own (e.g.
@@ -157,6 +175,11 @@ type SubPool interface {
 	// pending as well as queued transactions, grouped by account and sorted by nonce.
 	Content() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction)
 
+	// ContentByType retrieves the pending and queued transactions of the given
+	// type only, grouped by account and sorted by nonce, without copying the
+	// transactions of the other types.
+	ContentByType(txType uint8) (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction)
+
 	// ContentFrom retrieves the data content of the transaction pool, returning the
 	// pending as well as queued transactions of this address, grouped by nonce.
 	ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction)
@@ -168,3 +191,51 @@ type SubPool interface {
 	// identified by their hashes.
 	Status(hash common.Hash) TxStatus
 }
+
+// NonceReserver is implemented by subpools which can allocate the nonces of
+// managed accounts to concurrent submitters.
+type NonceReserver interface {
+	// ReserveNonce allocates a nonce of a managed account for exclusive use by
+	// the caller.
+	ReserveNonce(addr common.Address) (uint64, error)
+
+	// ReleaseNonce gives back a reserved nonce that the caller won't use.
+	ReleaseNonce(addr common.Address, nonce uint64) error
+}
+
+// AccountPause suspends the admission of the transactions from or to an account.
+type AccountPause struct {
+	Address   common.Address `json:"address"`
+	Sender    bool           `json:"sender"`    // Pause the transactions sent or paid by the account
+	Recipient bool           `json:"recipient"` // Pause the transactions sent to the account
+	Expiry    time.Time      `json:"expiry"`    // Time the pause lifts, zero if never
+}
+
+// AccountPauser is implemented by subpools which can pause the admission of the
+// transactions from or to specific accounts.
+type AccountPauser interface {
+	// PauseAccount drops the newly arriving transactions matching the pause,
+	// replacing any previous pause of the same account. If evict is set, the
+	// pooled transactions matching the pause are dropped too, their number is
+	// returned.
+	PauseAccount(pause AccountPause, evict bool) int
+
+	// ResumeAccount lifts the pause of an account, reporting whether there was
+	// one in force.
+	ResumeAccount(addr common.Address) bool
+
+	// PausedAccounts returns the pauses currently in force.
+	PausedAccounts() []AccountPause
+}
+
+// LocalAccountManager is implemented by subpools which can treat accounts as
+// local independently of their transactions, persisting their locality.
+type LocalAccountManager interface {
+	// AddLocalAccount treats an account as local, exempting its transactions
+	// from the remote pricing and eviction rules.
+	AddLocalAccount(addr common.Address) error
+
+	// RemoveLocalAccount stops treating an account as local, reporting whether
+	// it was.
+	RemoveLocalAccount(addr common.Address) (bool, error)
+}
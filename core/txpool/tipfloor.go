@@ -0,0 +1,125 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txpool
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// setCodeTxType is the EIP-7702 transaction type. The pools don't admit it yet,
+// but its tip floor can already be configured ahead of the fork.
+const setCodeTxType = 0x04
+
+// txTypeNames are the human readable names of the transaction types, used both
+// for configuring the tip floors and for naming the metrics.
+var txTypeNames = map[byte]string{
+	types.LegacyTxType:     "legacy",
+	types.AccessListTxType: "accesslist",
+	types.DynamicFeeTxType: "dynamicfee",
+	types.BlobTxType:       "blob",
+	setCodeTxType:          "setcode",
+	types.SponsoredTxType:  "sponsored",
+}
+
+var (
+	// tipRejectMeters count the transactions rejected on admission for paying
+	// less than the tip required for their type.
+	tipRejectMeters = make(map[byte]metrics.Meter)
+
+	// tipDropMeters count the pooled transactions dropped on a tip increase for
+	// paying less than the tip required for their type.
+	tipDropMeters = make(map[byte]metrics.Meter)
+)
+
+func init() {
+	for typ, name := range txTypeNames {
+		tipRejectMeters[typ] = metrics.NewRegisteredMeter("txpool/tip/"+name+"/rejected", nil)
+		tipDropMeters[typ] = metrics.NewRegisteredMeter("txpool/tip/"+name+"/dropped", nil)
+	}
+}
+
+// TipFloors is the minimum gas tip demanded from each transaction type, on top
+// of the pool wide tip threshold. Types without a floor are only subject to the
+// pool wide threshold.
+type TipFloors map[byte]uint64
+
+// ParseTipFloors parses a comma separated list of type=tip pairs, where type is
+// one of legacy, accesslist, dynamicfee, blob, setcode or sponsored.
+func ParseTipFloors(spec string) (TipFloors, error) {
+	floors := make(TipFloors)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tip floor %q, want type=tip", entry)
+		}
+		typ, ok := txTypeByName(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("unknown transaction type %q", name)
+		}
+		tip, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tip floor for %s: %v", name, err)
+		}
+		floors[typ] = tip
+	}
+	return floors, nil
+}
+
+func txTypeByName(name string) (byte, bool) {
+	for typ, have := range txTypeNames {
+		if have == name {
+			return typ, true
+		}
+	}
+	return 0, false
+}
+
+// MinTip returns the gas tip a transaction of the given type needs to pay, that
+// is the larger of the pool wide threshold and the floor of the type.
+func (f TipFloors) MinTip(txType byte, threshold *big.Int) *big.Int {
+	floor, ok := f[txType]
+	if !ok || threshold.Cmp(new(big.Int).SetUint64(floor)) >= 0 {
+		return threshold
+	}
+	return new(big.Int).SetUint64(floor)
+}
+
+// MarkTipDropped records a pooled transaction of the given type being dropped
+// due to paying less than the required tip.
+func MarkTipDropped(txType byte) {
+	if meter, ok := tipDropMeters[txType]; ok {
+		meter.Mark(1)
+	}
+}
+
+// markTipRejected records a transaction of the given type being rejected on
+// admission due to paying less than the required tip.
+func markTipRejected(txType byte) {
+	if meter, ok := tipRejectMeters[txType]; ok {
+		meter.Mark(1)
+	}
+}
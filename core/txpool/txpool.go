@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -54,6 +56,15 @@ type TxPool struct {
 	reservations map[common.Address]SubPool // Map with the account to pool reservations
 	reserveLock  sync.Mutex                 // Lock protecting the account reservations
 
+	broadcast *broadcaster // Broadcast hooks deciding on the propagation of admitted transactions
+
+	churn  atomic.Pointer[churnLimiter] // Token bucket limiting the remote admissions (nil = unlimited)
+	forced atomic.Pointer[forcedFilter] // Filter of the transactions reserved to the consensus engine (nil = none)
+
+	sponsors atomic.Pointer[sponsorGate] // Hook vetting the payers of the sponsored transactions (nil = none)
+
+	rejections rejections // Statistics of the transactions rejected on admission
+
 	subs event.SubscriptionScope // Subscription scope to unsubscribe all on shutdown
 	quit chan chan error         // Quit channel to tear down the head updater
 	term chan struct{}           // Termination channel to detect a closed pool
@@ -72,6 +83,7 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 	pool := &TxPool{
 		subpools:     subpools,
 		reservations: make(map[common.Address]SubPool),
+		broadcast:    &broadcaster{infos: make(map[common.Hash]*broadcastInfo)},
 		quit:         make(chan chan error),
 		term:         make(chan struct{}),
 		sync:         make(chan chan error),
@@ -85,6 +97,7 @@ func New(gasTip uint64, chain BlockChain, subpools []SubPool) (*TxPool, error) {
 		}
 	}
 	go pool.loop(head, chain)
+	go pool.broadcastLoop()
 	return pool, nil
 }
 
@@ -301,11 +314,39 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 	// so we can piece back the returned errors into the original order.
 	txsets := make([][]*types.Transaction, len(p.subpools))
 	splits := make([]int, len(txs))
+	errs := make([]error, len(txs))
 
+	// Remote transactions not yet known are charged against the churn limit,
+	// the unused tokens are handed back once the subpools have had their say.
+	var charged []bool
+	limiter := p.churn.Load()
+	if !local && limiter != nil {
+		charged = make([]bool, len(txs))
+	}
+	// Unknown sponsored transactions need the consent of their payer
+	if gate := p.sponsors.Load(); gate != nil {
+		gate.check(txs, errs, p.Has)
+	}
+	forced := p.forced.Load()
 	for i, tx := range txs {
 		// Mark this transaction belonging to no-subpool
 		splits[i] = -1
 
+		if errs[i] != nil {
+			continue
+		}
+		if forced != nil && (*forced)(tx) {
+			errs[i] = ErrForcedTransaction
+			continue
+		}
+		if charged != nil && !p.Has(tx.Hash()) {
+			if !limiter.take() {
+				churnRejectMeter.Mark(1)
+				errs[i] = ErrChurnLimited
+				continue
+			}
+			charged[i] = true
+		}
 		// Try to find a subpool that accepts the transaction
 		for j, subpool := range p.subpools {
 			if subpool.Filter(tx) {
@@ -321,17 +362,25 @@ func (p *TxPool) Add(txs []*types.Transaction, local bool, sync bool) []error {
 	for i := 0; i < len(p.subpools); i++ {
 		errsets[i] = p.subpools[i].Add(txsets[i], local, sync)
 	}
-	errs := make([]error, len(txs))
 	for i, split := range splits {
 		// If the transaction was rejected by all subpools, mark it unsupported
 		if split == -1 {
-			errs[i] = core.ErrTxTypeNotSupported
+			if errs[i] == nil {
+				errs[i] = core.ErrTxTypeNotSupported
+			}
 			continue
 		}
 		// Find which subpool handled it and pull in the corresponding error
 		errs[i] = errsets[split][0]
 		errsets[split] = errsets[split][1:]
 	}
+	// Hand back the tokens of the transactions not admitted
+	for i := range charged {
+		if charged[i] && errs[i] != nil {
+			limiter.refund()
+		}
+	}
+	p.rejections.record(errs, time.Now())
 	return errs
 }
 
@@ -350,6 +399,21 @@ func (p *TxPool) Pending(filter *PendingFilter) map[common.Address][]*LazyTransa
 	return txs
 }
 
+// forcedFilter reports whether a transaction may only be included in the blocks
+// by the consensus engine as a forced one.
+type forcedFilter func(tx *types.Transaction) bool
+
+// SetForcedFilter installs the filter of the transactions which may only be
+// included by the consensus engine, rejected by the pool.
+func (p *TxPool) SetForcedFilter(filter func(tx *types.Transaction) bool) {
+	if filter == nil {
+		p.forced.Store(nil)
+		return
+	}
+	f := forcedFilter(filter)
+	p.forced.Store(&f)
+}
+
 // SubscribeTransactions registers a subscription for new transaction events,
 // supporting feeding only newly seen or also resurrected transactions.
 func (p *TxPool) SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription {
@@ -375,6 +439,112 @@ func (p *TxPool) Nonce(addr common.Address) uint64 {
 	return nonce
 }
 
+// ReserveNonce allocates a nonce of a managed account for exclusive use by the
+// caller, from the first subpool managing the account's nonces.
+func (p *TxPool) ReserveNonce(addr common.Address) (uint64, error) {
+	for _, subpool := range p.subpools {
+		if reserver, ok := subpool.(NonceReserver); ok {
+			nonce, err := reserver.ReserveNonce(addr)
+			if err == ErrAccountNotManaged {
+				continue
+			}
+			return nonce, err
+		}
+	}
+	return 0, ErrAccountNotManaged
+}
+
+// ReleaseNonce gives back a reserved nonce of a managed account that the caller
+// won't use.
+func (p *TxPool) ReleaseNonce(addr common.Address, nonce uint64) error {
+	for _, subpool := range p.subpools {
+		if reserver, ok := subpool.(NonceReserver); ok {
+			err := reserver.ReleaseNonce(addr, nonce)
+			if err == ErrAccountNotManaged {
+				continue
+			}
+			return err
+		}
+	}
+	return ErrAccountNotManaged
+}
+
+// PauseAccount drops the newly arriving transactions from or to an account in
+// all the subpools supporting it, and optionally evicts the pooled ones. It
+// returns the number of transactions evicted.
+func (p *TxPool) PauseAccount(pause AccountPause, evict bool) int {
+	var evicted int
+	for _, subpool := range p.subpools {
+		if pauser, ok := subpool.(AccountPauser); ok {
+			evicted += pauser.PauseAccount(pause, evict)
+		}
+	}
+	return evicted
+}
+
+// ResumeAccount lifts the pause of an account in all the subpools, reporting
+// whether any of them had one in force.
+func (p *TxPool) ResumeAccount(addr common.Address) bool {
+	var resumed bool
+	for _, subpool := range p.subpools {
+		if pauser, ok := subpool.(AccountPauser); ok {
+			resumed = pauser.ResumeAccount(addr) || resumed
+		}
+	}
+	return resumed
+}
+
+// PausedAccounts returns the pauses in force in any of the subpools supporting
+// them, ordered by account.
+func (p *TxPool) PausedAccounts() []AccountPause {
+	var (
+		pauses []AccountPause
+		seen   = make(map[common.Address]bool)
+	)
+	for _, subpool := range p.subpools {
+		if pauser, ok := subpool.(AccountPauser); ok {
+			for _, pause := range pauser.PausedAccounts() {
+				if !seen[pause.Address] {
+					seen[pause.Address] = true
+					pauses = append(pauses, pause)
+				}
+			}
+		}
+	}
+	sortPauses(pauses)
+	return pauses
+}
+
+// AddLocalAccount treats an account as local in all the subpools supporting
+// it, persisting its locality across restarts.
+func (p *TxPool) AddLocalAccount(addr common.Address) error {
+	err := ErrLocalsDisabled
+	for _, subpool := range p.subpools {
+		if manager, ok := subpool.(LocalAccountManager); ok {
+			if err = manager.AddLocalAccount(addr); err != nil {
+				return err
+			}
+		}
+	}
+	return err
+}
+
+// RemoveLocalAccount stops treating an account as local in all the subpools,
+// reporting whether any of them did.
+func (p *TxPool) RemoveLocalAccount(addr common.Address) (bool, error) {
+	var removed bool
+	for _, subpool := range p.subpools {
+		if manager, ok := subpool.(LocalAccountManager); ok {
+			ok, err := manager.RemoveLocalAccount(addr)
+			if err != nil {
+				return removed || ok, err
+			}
+			removed = removed || ok
+		}
+	}
+	return removed, nil
+}
+
 // Stats retrieves the current pool stats, namely the number of pending and the
 // number of queued (non-executable) transactions.
 func (p *TxPool) Stats() (int, int) {
@@ -408,6 +578,26 @@ func (p *TxPool) Content() (map[common.Address][]*types.Transaction, map[common.
 	return runnable, blocked
 }
 
+// ContentByType retrieves the pending and queued transactions of the given type
+// only, grouped by account and sorted by nonce.
+func (p *TxPool) ContentByType(txType uint8) (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
+	var (
+		runnable = make(map[common.Address][]*types.Transaction)
+		blocked  = make(map[common.Address][]*types.Transaction)
+	)
+	for _, subpool := range p.subpools {
+		run, block := subpool.ContentByType(txType)
+
+		for addr, txs := range run {
+			runnable[addr] = txs
+		}
+		for addr, txs := range block {
+			blocked[addr] = txs
+		}
+	}
+	return runnable, blocked
+}
+
 // ContentFrom retrieves the data content of the transaction pool, returning the
 // pending as well as queued transactions of this address, grouped by nonce.
 func (p *TxPool) ContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
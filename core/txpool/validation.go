@@ -49,6 +49,25 @@ type ValidationOptions struct {
 	// As the Accept bitmap cannot store the sponsored transaction type which is 0x64 (100),
 	// we need to create a separate bool for this case
 	AcceptSponsoredTx bool
+
+	// ForkLookahead is the number of upcoming blocks whose fee floor transactions
+	// must already satisfy, so that a fork raising it (e.g. Venoki) does not turn
+	// admitted transactions unexecutable at the fork boundary.
+	ForkLookahead uint64
+
+	// ExpirySlack is the number of seconds ahead of the head timestamp within
+	// which sponsored transactions are already considered expired, as they are
+	// unlikely to be included before their expiry.
+	ExpirySlack uint64
+}
+
+// minimumBaseFee returns the protocol floor of the base fee at the given block,
+// or nil if there is none.
+func minimumBaseFee(config *params.ChainConfig, number *big.Int) *big.Int {
+	if config.IsVenoki(number) {
+		return big.NewInt(params.MinimumBaseFee)
+	}
+	return nil
 }
 
 func CurrentBlockMaxGas(chainConfig *params.ChainConfig, header *types.Header) uint64 {
@@ -141,13 +160,23 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 	// Ensure the gasprice is high enough to cover the requirement of the calling
 	// pool and/or block producer
 	if tx.GasTipCapIntCmp(opts.MinTip) < 0 {
+		markTipRejected(tx.Type())
 		return fmt.Errorf("%w: tip needed %v, tip permitted %v", ErrUnderpriced, opts.MinTip, tx.GasTipCap())
 	}
-	// If base fee is enabled, ensure the max tip based on fee cap is high enough
+	// If base fee is enabled, ensure the max tip based on fee cap is high enough.
+	// The fee floor only ever rises across forks, so checking it at the end of
+	// the lookahead window covers both the current and any upcoming rules.
 	isVenoki := opts.Config.IsVenoki(head.Number)
-	if isVenoki {
-		minGasFeeCap := new(big.Int).Add(opts.MinTip, big.NewInt(params.MinimumBaseFee))
+	floorNumber := head.Number
+	if opts.ForkLookahead > 0 {
+		floorNumber = new(big.Int).Add(head.Number, new(big.Int).SetUint64(opts.ForkLookahead))
+	}
+	if floor := minimumBaseFee(opts.Config, floorNumber); floor != nil {
+		minGasFeeCap := new(big.Int).Add(opts.MinTip, floor)
 		if tx.GasFeeCap().Cmp(minGasFeeCap) < 0 {
+			if !isVenoki {
+				return fmt.Errorf("%w: fee cap %v, minimum needed %v from upcoming fork at block %v", ErrUnderpriced, tx.GasFeeCap(), minGasFeeCap, opts.Config.VenokiBlock)
+			}
 			return fmt.Errorf("%w: fee cap %v, minimum needed %v", ErrUnderpriced, tx.GasFeeCap(), minGasFeeCap)
 		}
 	}
@@ -185,7 +214,7 @@ func ValidateTransaction(tx *types.Transaction, head *types.Header, signer types
 
 		// Ensure sponsored transaction is not expired
 		expiredTime := tx.ExpiredTime()
-		if expiredTime != 0 && expiredTime <= head.Time {
+		if expiredTime != 0 && expiredTime <= head.Time+opts.ExpirySlack {
 			return core.ErrExpiredSponsoredTx
 		}
 
@@ -286,9 +315,9 @@ func ValidateTransactionWithState(tx *types.Transaction, signer types.Signer, op
 	var (
 		senderBalance = opts.State.GetBalance(from)
 		payerBalance  *big.Int
-		gasCost       = new(big.Int).Mul(tx.GasPrice(), new(big.Int).SetUint64(tx.Gas()))
-		senderCost    *big.Int
 		payer         common.Address
+
+		senderCost, gasCost, _, _ = tx.CostBreakdown(nil, nil)
 	)
 
 	if tx.Type() == types.SponsoredTxType {
@@ -305,7 +334,6 @@ func ValidateTransactionWithState(tx *types.Transaction, signer types.Signer, op
 				payerBalance, gasCost, new(big.Int).Sub(gasCost, payerBalance),
 			)
 		}
-		senderCost = tx.Value()
 		if senderBalance.Cmp(senderCost) < 0 {
 			return fmt.Errorf(
 				"%w: sender's balance %v, tx value %v, overshot %v", core.ErrInsufficientSenderFunds,
@@ -313,7 +341,6 @@ func ValidateTransactionWithState(tx *types.Transaction, signer types.Signer, op
 			)
 		}
 	} else {
-		senderCost = tx.Cost()
 		if senderBalance.Cmp(senderCost) < 0 {
 			return fmt.Errorf(
 				"%w: sender's balance %v, tx cost %v, overshot %v", core.ErrInsufficientFunds,
@@ -46,6 +46,7 @@ type Prefetcher interface {
 type Processor interface {
 	// Process processes the state changes according to the Ethereum rules by running
 	// the transaction messages using the statedb and applying any rewards to both
-	// the processor (coinbase) and any included uncles.
-	Process(block *types.Block, statedb *state.StateDB, cfg vm.Config, publishEvents ...*vm.PublishEvent) (types.Receipts, []*types.Log, []*types.InternalTransaction, uint64, error)
+	// the processor (coinbase) and any included uncles. The optional sender cache
+	// holds transaction senders known ahead of time, sparing their recovery.
+	Process(block *types.Block, statedb *state.StateDB, cfg vm.Config, senders *types.SenderCache, publishEvents ...*vm.PublishEvent) (types.Receipts, []*types.Log, []*types.InternalTransaction, uint64, error)
 }
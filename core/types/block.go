@@ -322,6 +322,11 @@ type Header struct {
 	// These fields were added by EIP-4844 and is ignored in legacy headers.
 	BlobGasUsed   *uint64 `json:"blobGasUsed" rlp:"optional"`
 	ExcessBlobGas *uint64 `json:"excessBlobGas" rlp:"optional"`
+
+	// These fields were added by the Ronin settlement fork and are ignored in
+	// legacy headers.
+	WithdrawalsHash *common.Hash `json:"withdrawalsRoot" rlp:"optional"`
+	DepositsHash    *common.Hash `json:"depositsRoot" rlp:"optional"`
 }
 
 // field type overrides for gencodec
@@ -383,8 +388,14 @@ func (h *Header) SanityCheck() error {
 }
 
 // EmptyBody returns true if there is no additional 'body' to complete the header
-// that is: no transactions and no uncles.
+// that is: no transactions, no uncles and no settlement operations.
 func (h *Header) EmptyBody() bool {
+	if h.WithdrawalsHash != nil && *h.WithdrawalsHash != EmptyRootHash {
+		return false
+	}
+	if h.DepositsHash != nil && *h.DepositsHash != EmptyRootHash {
+		return false
+	}
 	return h.TxHash == EmptyRootHash && h.UncleHash == EmptyUncleHash
 }
 
@@ -394,10 +405,13 @@ func (h *Header) EmptyReceipts() bool {
 }
 
 // Body is a simple (mutable, non-safe) data container for storing and moving
-// a block's data contents (transactions and uncles) together.
+// a block's data contents (transactions, uncles and settlement operations)
+// together.
 type Body struct {
 	Transactions []*Transaction
 	Uncles       []*Header
+	Withdrawals  []*Withdrawal `rlp:"optional"`
+	Deposits     []*Deposit    `rlp:"optional"`
 }
 
 // Block represents an entire block in the Ethereum blockchain.
@@ -405,6 +419,8 @@ type Block struct {
 	header       *Header
 	uncles       []*Header
 	transactions Transactions
+	withdrawals  Withdrawals
+	deposits     Deposits
 
 	// caches
 	hash atomic.Value
@@ -422,9 +438,11 @@ type Block struct {
 
 // "external" block encoding. used for eth protocol, etc.
 type extblock struct {
-	Header *Header
-	Txs    []*Transaction
-	Uncles []*Header
+	Header      *Header
+	Txs         []*Transaction
+	Uncles      []*Header
+	Withdrawals []*Withdrawal `rlp:"optional"`
+	Deposits    []*Deposit    `rlp:"optional"`
 }
 
 // NewBlock creates a new block. The input data is copied,
@@ -466,6 +484,28 @@ func NewBlock(header *Header, txs []*Transaction, uncles []*Header, receipts []*
 	return b
 }
 
+// NewBlockWithOperations creates a new block with the given settlement
+// operations. The input data is copied, changes to header and to the field
+// values will not affect the block.
+//
+// The values of WithdrawalsHash and DepositsHash in header are ignored and set
+// to values derived from the given withdrawals and deposits.
+func NewBlockWithOperations(header *Header, txs []*Transaction, uncles []*Header, receipts []*Receipt, withdrawals []*Withdrawal, deposits []*Deposit, hasher TrieHasher) *Block {
+	b := NewBlock(header, txs, uncles, receipts, hasher)
+
+	withdrawalsHash := DeriveSha(Withdrawals(withdrawals), hasher)
+	b.header.WithdrawalsHash = &withdrawalsHash
+	b.withdrawals = make(Withdrawals, len(withdrawals))
+	copy(b.withdrawals, withdrawals)
+
+	depositsHash := DeriveSha(Deposits(deposits), hasher)
+	b.header.DepositsHash = &depositsHash
+	b.deposits = make(Deposits, len(deposits))
+	copy(b.deposits, deposits)
+
+	return b
+}
+
 // NewBlockWithHeader creates a block with the given header data. The
 // header data is copied, changes to header and to the field values
 // will not affect the block.
@@ -498,6 +538,14 @@ func CopyHeader(h *Header) *Header {
 		excessBlobGas := *h.ExcessBlobGas
 		cpy.ExcessBlobGas = &excessBlobGas
 	}
+	if h.WithdrawalsHash != nil {
+		withdrawalsHash := *h.WithdrawalsHash
+		cpy.WithdrawalsHash = &withdrawalsHash
+	}
+	if h.DepositsHash != nil {
+		depositsHash := *h.DepositsHash
+		cpy.DepositsHash = &depositsHash
+	}
 	return &cpy
 }
 
@@ -509,6 +557,7 @@ func (b *Block) DecodeRLP(s *rlp.Stream) error {
 		return err
 	}
 	b.header, b.uncles, b.transactions = eb.Header, eb.Uncles, eb.Txs
+	b.withdrawals, b.deposits = eb.Withdrawals, eb.Deposits
 	b.size.Store(common.StorageSize(rlp.ListSize(size)))
 	return nil
 }
@@ -516,9 +565,11 @@ func (b *Block) DecodeRLP(s *rlp.Stream) error {
 // EncodeRLP serializes b into the Ethereum RLP block format.
 func (b *Block) EncodeRLP(w io.Writer) error {
 	return rlp.Encode(w, extblock{
-		Header: b.header,
-		Txs:    b.transactions,
-		Uncles: b.uncles,
+		Header:      b.header,
+		Txs:         b.transactions,
+		Uncles:      b.uncles,
+		Withdrawals: b.withdrawals,
+		Deposits:    b.deposits,
 	})
 }
 
@@ -526,6 +577,8 @@ func (b *Block) EncodeRLP(w io.Writer) error {
 
 func (b *Block) Uncles() []*Header          { return b.uncles }
 func (b *Block) Transactions() Transactions { return b.transactions }
+func (b *Block) Withdrawals() Withdrawals   { return b.withdrawals }
+func (b *Block) Deposits() Deposits         { return b.deposits }
 
 func (b *Block) Transaction(hash common.Hash) *Transaction {
 	for _, transaction := range b.transactions {
@@ -580,7 +633,7 @@ func (b *Block) ExcessBlobGas() *uint64 {
 func (b *Block) Header() *Header { return CopyHeader(b.header) }
 
 // Body returns the non-header content of the block.
-func (b *Block) Body() *Body { return &Body{b.transactions, b.uncles} }
+func (b *Block) Body() *Body { return &Body{b.transactions, b.uncles, b.withdrawals, b.deposits} }
 
 // Size returns the true RLP encoded storage size of the block, either by encoding
 // and returning it, or returning a previsouly cached value.
@@ -629,6 +682,8 @@ func (b *Block) WithSeal(header *Header) *Block {
 		header:       &cpy,
 		transactions: b.transactions,
 		uncles:       b.uncles,
+		withdrawals:  b.withdrawals,
+		deposits:     b.deposits,
 	}
 }
 
@@ -646,6 +701,25 @@ func (b *Block) WithBody(transactions []*Transaction, uncles []*Header) *Block {
 	return block
 }
 
+// WithOperations returns a new block with the given settlement operations,
+// keeping the rest of the contents.
+func (b *Block) WithOperations(withdrawals []*Withdrawal, deposits []*Deposit) *Block {
+	block := &Block{
+		header:       b.header,
+		transactions: b.transactions,
+		uncles:       b.uncles,
+	}
+	if withdrawals != nil {
+		block.withdrawals = make(Withdrawals, len(withdrawals))
+		copy(block.withdrawals, withdrawals)
+	}
+	if deposits != nil {
+		block.deposits = make(Deposits, len(deposits))
+		copy(block.deposits, deposits)
+	}
+	return block
+}
+
 // Hash returns the keccak256 hash of b's header.
 // The hash is computed on the first call and cached thereafter.
 func (b *Block) Hash() common.Hash {
@@ -18,6 +18,7 @@ package types
 
 import (
 	"bytes"
+	"encoding/json"
 	"hash"
 	"math/big"
 	"reflect"
@@ -301,3 +302,98 @@ func TestCopyHeaderEIP4844(t *testing.T) {
 		t.Fatalf("Excess blob gas mismatches, expect %d got %d", excessBlobGas, *header.ExcessBlobGas)
 	}
 }
+
+func TestHeaderEIP4844RoundTrip(t *testing.T) {
+	blobGasUsed := uint64(1 << 17)
+	excessBlobGas := 2 * blobGasUsed
+	header := &Header{
+		Difficulty:    big.NewInt(7),
+		Number:        big.NewInt(42),
+		GasLimit:      100000000,
+		Extra:         []byte{},
+		BaseFee:       big.NewInt(1000000000),
+		BlobGasUsed:   &blobGasUsed,
+		ExcessBlobGas: &excessBlobGas,
+	}
+	// The blob gas fields survive the RLP encoding
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var dec Header
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if !reflect.DeepEqual(&dec, header) {
+		t.Errorf("RLP round trip mismatch: have %+v, want %+v", dec, header)
+	}
+	// And the JSON one, which doesn't carry any other blob field
+	blob, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal("marshal error: ", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		t.Fatal("unmarshal error: ", err)
+	}
+	if string(fields["blobGasUsed"]) != `"0x20000"` || string(fields["excessBlobGas"]) != `"0x40000"` {
+		t.Errorf("blob gas fields mismatch: have %s/%s", fields["blobGasUsed"], fields["excessBlobGas"])
+	}
+	if _, ok := fields["blobCommitments"]; ok {
+		t.Error("unexpected blob commitments field")
+	}
+	dec = Header{}
+	if err := json.Unmarshal(blob, &dec); err != nil {
+		t.Fatal("unmarshal error: ", err)
+	}
+	if !reflect.DeepEqual(&dec, header) {
+		t.Errorf("JSON round trip mismatch: have %+v, want %+v", dec, header)
+	}
+}
+
+func TestBlockOperationsEncoding(t *testing.T) {
+	var (
+		blobGasUsed = uint64(0)
+		header      = &Header{
+			Difficulty:    big.NewInt(7),
+			Number:        big.NewInt(42),
+			GasLimit:      100000000,
+			Extra:         []byte{},
+			BaseFee:       big.NewInt(1000000000),
+			BlobGasUsed:   &blobGasUsed,
+			ExcessBlobGas: &blobGasUsed,
+		}
+		withdrawals = []*Withdrawal{{Index: 1, Token: common.Address{0x01}, Sender: common.Address{0x02}, Recipient: common.Address{0x03}, Amount: big.NewInt(100)}}
+		deposits    = []*Deposit{{Index: 7, Token: common.Address{0x01}, Recipient: common.Address{0x04}, Amount: big.NewInt(200)}}
+	)
+	block := NewBlockWithOperations(header, nil, nil, nil, withdrawals, deposits, newHasher())
+	if *block.Header().WithdrawalsHash != DeriveSha(Withdrawals(withdrawals), newHasher()) {
+		t.Errorf("withdrawal root mismatch")
+	}
+	if *block.Header().DepositsHash != DeriveSha(Deposits(deposits), newHasher()) {
+		t.Errorf("deposit root mismatch")
+	}
+	if block.Header().EmptyBody() {
+		t.Errorf("body with operations reported empty")
+	}
+	// The operations survive the RLP round trip of the block and its body
+	enc, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		t.Fatal("encode error: ", err)
+	}
+	var dec Block
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatal("decode error: ", err)
+	}
+	if dec.Hash() != block.Hash() {
+		t.Errorf("block hash mismatch: have %x, want %x", dec.Hash(), block.Hash())
+	}
+	if !reflect.DeepEqual(dec.Withdrawals(), block.Withdrawals()) || !reflect.DeepEqual(dec.Deposits(), block.Deposits()) {
+		t.Errorf("operations mismatch: have %v/%v, want %v/%v", dec.Withdrawals(), dec.Deposits(), withdrawals, deposits)
+	}
+	// Legacy bodies are encoded without the operations
+	legacy, _ := rlp.EncodeToBytes(&Body{})
+	if want, _ := rlp.EncodeToBytes([]interface{}{[]*Transaction{}, []*Header{}}); !bytes.Equal(legacy, want) {
+		t.Errorf("legacy body encoding mismatch: have %x, want %x", legacy, want)
+	}
+}
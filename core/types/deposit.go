@@ -0,0 +1,56 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//go:generate gencodec -type Deposit -field-override depositMarshaling -out gen_deposit_json.go
+
+// Deposit is a bridge deposit operation settled by the protocol, crediting the
+// tokens locked on the counterpart chain to a recipient on Ronin.
+type Deposit struct {
+	Index     uint64         `json:"index"`     // Monotonically increasing identifier of the deposit
+	Token     common.Address `json:"token"`     // Ronin token being credited
+	Recipient common.Address `json:"recipient"` // Ronin account the tokens are credited to
+	Amount    *big.Int       `json:"amount"`    // Amount of tokens deposited
+}
+
+// field type overrides for gencodec
+type depositMarshaling struct {
+	Index  hexutil.Uint64
+	Amount *hexutil.Big
+}
+
+// Deposits implements DerivableList for deposits.
+type Deposits []*Deposit
+
+// Len returns the length of s.
+func (s Deposits) Len() int { return len(s) }
+
+// EncodeIndex encodes the i'th deposit to w. Note that this does not check for
+// errors because we assume that *Deposit will only ever contain valid deposits
+// that were either constructed by decoding or via public API in this package.
+func (s Deposits) EncodeIndex(i int, w *bytes.Buffer) {
+	rlp.Encode(w, s[i])
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto/kzg4844"
 )
 
 var _ = (*headerMarshaling)(nil)
@@ -17,26 +16,27 @@ var _ = (*headerMarshaling)(nil)
 // MarshalJSON marshals as JSON.
 func (h Header) MarshalJSON() ([]byte, error) {
 	type Header struct {
-		ParentHash      common.Hash          `json:"parentHash"       gencodec:"required"`
-		UncleHash       common.Hash          `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase        common.Address       `json:"miner"            gencodec:"required"`
-		Root            common.Hash          `json:"stateRoot"        gencodec:"required"`
-		TxHash          common.Hash          `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash     common.Hash          `json:"receiptsRoot"     gencodec:"required"`
-		Bloom           Bloom                `json:"logsBloom"        gencodec:"required"`
-		Difficulty      *hexutil.Big         `json:"difficulty"       gencodec:"required"`
-		Number          *hexutil.Big         `json:"number"           gencodec:"required"`
-		GasLimit        hexutil.Uint64       `json:"gasLimit"         gencodec:"required"`
-		GasUsed         hexutil.Uint64       `json:"gasUsed"          gencodec:"required"`
-		Time            hexutil.Uint64       `json:"timestamp"        gencodec:"required"`
-		Extra           hexutil.Bytes        `json:"extraData"        gencodec:"required"`
-		MixDigest       common.Hash          `json:"mixHash"`
-		Nonce           BlockNonce           `json:"nonce"`
-		BaseFee         *hexutil.Big         `json:"baseFeePerGas" rlp:"optional"`
-		BlobGasUsed     *hexutil.Uint64      `json:"blobGasUsed" rlp:"optional"`
-		ExcessBlobGas   *hexutil.Uint64      `json:"excessBlobGas" rlp:"optional"`
-		BlobCommitments []kzg4844.Commitment `json:"blobCommitments" rlp:"optional"`
-		Hash            common.Hash          `json:"hash"`
+		ParentHash      common.Hash     `json:"parentHash"       gencodec:"required"`
+		UncleHash       common.Hash     `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase        common.Address  `json:"miner"            gencodec:"required"`
+		Root            common.Hash     `json:"stateRoot"        gencodec:"required"`
+		TxHash          common.Hash     `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash     common.Hash     `json:"receiptsRoot"     gencodec:"required"`
+		Bloom           Bloom           `json:"logsBloom"        gencodec:"required"`
+		Difficulty      *hexutil.Big    `json:"difficulty"       gencodec:"required"`
+		Number          *hexutil.Big    `json:"number"           gencodec:"required"`
+		GasLimit        hexutil.Uint64  `json:"gasLimit"         gencodec:"required"`
+		GasUsed         hexutil.Uint64  `json:"gasUsed"          gencodec:"required"`
+		Time            hexutil.Uint64  `json:"timestamp"        gencodec:"required"`
+		Extra           hexutil.Bytes   `json:"extraData"        gencodec:"required"`
+		MixDigest       common.Hash     `json:"mixHash"`
+		Nonce           BlockNonce      `json:"nonce"`
+		BaseFee         *hexutil.Big    `json:"baseFeePerGas" rlp:"optional"`
+		BlobGasUsed     *hexutil.Uint64 `json:"blobGasUsed" rlp:"optional"`
+		ExcessBlobGas   *hexutil.Uint64 `json:"excessBlobGas" rlp:"optional"`
+		WithdrawalsHash *common.Hash    `json:"withdrawalsRoot" rlp:"optional"`
+		DepositsHash    *common.Hash    `json:"depositsRoot" rlp:"optional"`
+		Hash            common.Hash     `json:"hash"`
 	}
 	var enc Header
 	enc.ParentHash = h.ParentHash
@@ -57,6 +57,8 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.BaseFee = (*hexutil.Big)(h.BaseFee)
 	enc.BlobGasUsed = (*hexutil.Uint64)(h.BlobGasUsed)
 	enc.ExcessBlobGas = (*hexutil.Uint64)(h.ExcessBlobGas)
+	enc.WithdrawalsHash = h.WithdrawalsHash
+	enc.DepositsHash = h.DepositsHash
 	enc.Hash = h.Hash()
 	return json.Marshal(&enc)
 }
@@ -64,25 +66,26 @@ func (h Header) MarshalJSON() ([]byte, error) {
 // UnmarshalJSON unmarshals from JSON.
 func (h *Header) UnmarshalJSON(input []byte) error {
 	type Header struct {
-		ParentHash      *common.Hash         `json:"parentHash"       gencodec:"required"`
-		UncleHash       *common.Hash         `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase        *common.Address      `json:"miner"            gencodec:"required"`
-		Root            *common.Hash         `json:"stateRoot"        gencodec:"required"`
-		TxHash          *common.Hash         `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash     *common.Hash         `json:"receiptsRoot"     gencodec:"required"`
-		Bloom           *Bloom               `json:"logsBloom"        gencodec:"required"`
-		Difficulty      *hexutil.Big         `json:"difficulty"       gencodec:"required"`
-		Number          *hexutil.Big         `json:"number"           gencodec:"required"`
-		GasLimit        *hexutil.Uint64      `json:"gasLimit"         gencodec:"required"`
-		GasUsed         *hexutil.Uint64      `json:"gasUsed"          gencodec:"required"`
-		Time            *hexutil.Uint64      `json:"timestamp"        gencodec:"required"`
-		Extra           *hexutil.Bytes       `json:"extraData"        gencodec:"required"`
-		MixDigest       *common.Hash         `json:"mixHash"`
-		Nonce           *BlockNonce          `json:"nonce"`
-		BaseFee         *hexutil.Big         `json:"baseFeePerGas" rlp:"optional"`
-		BlobGasUsed     *hexutil.Uint64      `json:"blobGasUsed" rlp:"optional"`
-		ExcessBlobGas   *hexutil.Uint64      `json:"excessBlobGas" rlp:"optional"`
-		BlobCommitments []kzg4844.Commitment `json:"blobCommitments" rlp:"optional"`
+		ParentHash      *common.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash       *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase        *common.Address `json:"miner"            gencodec:"required"`
+		Root            *common.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash          *common.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash     *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom           *Bloom          `json:"logsBloom"        gencodec:"required"`
+		Difficulty      *hexutil.Big    `json:"difficulty"       gencodec:"required"`
+		Number          *hexutil.Big    `json:"number"           gencodec:"required"`
+		GasLimit        *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
+		GasUsed         *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
+		Time            *hexutil.Uint64 `json:"timestamp"        gencodec:"required"`
+		Extra           *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
+		MixDigest       *common.Hash    `json:"mixHash"`
+		Nonce           *BlockNonce     `json:"nonce"`
+		BaseFee         *hexutil.Big    `json:"baseFeePerGas" rlp:"optional"`
+		BlobGasUsed     *hexutil.Uint64 `json:"blobGasUsed" rlp:"optional"`
+		ExcessBlobGas   *hexutil.Uint64 `json:"excessBlobGas" rlp:"optional"`
+		WithdrawalsHash *common.Hash    `json:"withdrawalsRoot" rlp:"optional"`
+		DepositsHash    *common.Hash    `json:"depositsRoot" rlp:"optional"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -155,5 +158,11 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 	if dec.ExcessBlobGas != nil {
 		h.ExcessBlobGas = (*uint64)(dec.ExcessBlobGas)
 	}
+	if dec.WithdrawalsHash != nil {
+		h.WithdrawalsHash = dec.WithdrawalsHash
+	}
+	if dec.DepositsHash != nil {
+		h.DepositsHash = dec.DepositsHash
+	}
 	return nil
 }
@@ -72,6 +72,11 @@ type Receipt struct {
 	BlockHash        common.Hash `json:"blockHash,omitempty"`
 	BlockNumber      *big.Int    `json:"blockNumber,omitempty"`
 	TransactionIndex uint        `json:"transactionIndex"`
+
+	// Forced reports whether the transaction was mandated by the consensus
+	// engine. It is not stored, but set when the transaction is processed and
+	// derived from the engine again when the receipt is read back.
+	Forced bool `json:"-"`
 }
 
 type receiptMarshaling struct {
@@ -101,6 +106,15 @@ type storedReceiptRLP struct {
 	Logs              []*LogForStorage
 }
 
+// receiptNoBloomRLP is the network encoding of a receipt without its bloom, which
+// the receiver recomputes from the logs.
+type receiptNoBloomRLP struct {
+	Type              uint8
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Logs              []*Log
+}
+
 // v4StoredReceiptRLP is the storage encoding of a receipt used in database version 4.
 type v4StoredReceiptRLP struct {
 	PostStateOrStatus []byte
@@ -379,12 +393,61 @@ func decodeV3StoredReceiptRLP(r *ReceiptForStorage, blob []byte) error {
 	for i, log := range stored.Logs {
 		r.Logs[i] = (*Log)(log)
 	}
+	// Some databases stored the receipts without their blooms to save space,
+	// regenerate those from the logs
+	if len(r.Logs) > 0 && r.Bloom == (Bloom{}) {
+		r.Bloom = CreateBloom(Receipts{(*Receipt)(r)})
+	}
+	return nil
+}
+
+// ReceiptNoBloom is a wrapper around a Receipt that flattens its type and consensus
+// fields for the network, leaving out the bloom which is recomputed on decoding.
+type ReceiptNoBloom Receipt
+
+// EncodeRLP implements rlp.Encoder, and flattens the type and consensus fields of
+// a receipt, except the bloom, into an RLP stream.
+func (r *ReceiptNoBloom) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, &receiptNoBloomRLP{
+		Type:              r.Type,
+		PostStateOrStatus: (*Receipt)(r).statusEncoding(),
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		Logs:              r.Logs,
+	})
+}
+
+// DecodeRLP implements rlp.Decoder, and loads the type and consensus fields of a
+// receipt from an RLP stream, recomputing the bloom from the logs.
+func (r *ReceiptNoBloom) DecodeRLP(s *rlp.Stream) error {
+	var dec receiptNoBloomRLP
+	if err := s.Decode(&dec); err != nil {
+		return err
+	}
+	switch dec.Type {
+	case LegacyTxType, AccessListTxType, DynamicFeeTxType, BlobTxType, SponsoredTxType:
+	default:
+		return ErrTxTypeNotSupported
+	}
+	if err := (*Receipt)(r).setStatus(dec.PostStateOrStatus); err != nil {
+		return err
+	}
+	r.Type, r.CumulativeGasUsed, r.Logs = dec.Type, dec.CumulativeGasUsed, dec.Logs
+	r.Bloom = CreateBloom(Receipts{(*Receipt)(r)})
 	return nil
 }
 
 // Receipts implements DerivableList for receipts.
 type Receipts []*Receipt
 
+// NoBloom returns the receipts wrapped for their network encoding without blooms.
+func (rs Receipts) NoBloom() []*ReceiptNoBloom {
+	wrapped := make([]*ReceiptNoBloom, len(rs))
+	for i, r := range rs {
+		wrapped[i] = (*ReceiptNoBloom)(r)
+	}
+	return wrapped
+}
+
 // Len returns the number of receipts in this list.
 func (rs Receipts) Len() int { return len(rs) }
 
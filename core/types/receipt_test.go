@@ -142,6 +142,10 @@ func TestLegacyReceiptDecoding(t *testing.T) {
 			"V3StoredReceiptRLP",
 			encodeAsV3StoredReceiptRLP,
 		},
+		{
+			"V3StoredReceiptRLPWithoutBloom",
+			encodeAsV3StoredReceiptRLPWithoutBloom,
+		},
 	}
 
 	tx := NewTransaction(1, common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil)
@@ -247,6 +251,12 @@ func encodeAsV3StoredReceiptRLP(want *Receipt) ([]byte, error) {
 	return rlp.EncodeToBytes(stored)
 }
 
+func encodeAsV3StoredReceiptRLPWithoutBloom(want *Receipt) ([]byte, error) {
+	stripped := *want
+	stripped.Bloom = Bloom{}
+	return encodeAsV3StoredReceiptRLP(&stripped)
+}
+
 // Tests that receipt data can be correctly derived from the contextual infos
 func TestDeriveFields(t *testing.T) {
 	// Create a few transactions to have receipts for
@@ -399,6 +409,44 @@ func TestTypedReceiptEncodingDecoding(t *testing.T) {
 	}
 }
 
+// Tests that receipts encoded without blooms for the network decode into their
+// consensus fields, with the bloom recomputed from the logs.
+func TestReceiptNoBloomEncoding(t *testing.T) {
+	receipts := Receipts{legacyReceipt, accessListReceipt, eip1559Receipt, mikoReceipt, blobReceipt}
+	enc, err := rlp.EncodeToBytes(receipts.NoBloom())
+	if err != nil {
+		t.Fatalf("failed to encode receipts: %v", err)
+	}
+	full, _ := rlp.EncodeToBytes(receipts)
+	if len(enc) >= len(full)-len(receipts)*BloomByteLength {
+		t.Errorf("encoding not shrunk by the blooms: have %d bytes, consensus %d bytes", len(enc), len(full))
+	}
+	var dec []*ReceiptNoBloom
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode receipts: %v", err)
+	}
+	if len(dec) != len(receipts) {
+		t.Fatalf("receipt count mismatch: have %d, want %d", len(dec), len(receipts))
+	}
+	for i, want := range receipts {
+		have := (*Receipt)(dec[i])
+		if have.Type != want.Type || have.Status != want.Status || have.CumulativeGasUsed != want.CumulativeGasUsed {
+			t.Errorf("receipt %d: fields mismatch: have %+v, want %+v", i, have, want)
+		}
+		if !reflect.DeepEqual(have.Logs, want.Logs) {
+			t.Errorf("receipt %d: logs mismatch: have %v, want %v", i, have.Logs, want.Logs)
+		}
+		if bloom := CreateBloom(Receipts{want}); have.Bloom != bloom {
+			t.Errorf("receipt %d: bloom mismatch: have %x, want %x", i, have.Bloom, bloom)
+		}
+	}
+	// Unknown receipt types are rejected
+	bad, _ := rlp.EncodeToBytes(&receiptNoBloomRLP{Type: 0x7f, PostStateOrStatus: receiptStatusSuccessfulRLP})
+	if err := rlp.DecodeBytes(bad, new(ReceiptNoBloom)); err != ErrTxTypeNotSupported {
+		t.Errorf("unknown type error mismatch: have %v, want %v", err, ErrTxTypeNotSupported)
+	}
+}
+
 func TestReceiptMarshalBinary(t *testing.T) {
 	// Legacy Receipt
 	legacyReceipt.Bloom = CreateBloom(Receipts{legacyReceipt})
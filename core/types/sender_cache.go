@@ -0,0 +1,118 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SenderCache is a set of transaction senders recovered ahead of time, keyed by
+// transaction hash. It is filled from wherever the senders are already known,
+// e.g. the transaction pool or a parallel recovery pass, and handed to block
+// processing so the same signatures are not recovered again.
+//
+// As the hash of a transaction commits to its signature, a cached sender is
+// valid for any transaction object with the same hash under the same signer.
+type SenderCache struct {
+	signer  Signer
+	senders map[common.Hash]common.Address
+	lock    sync.RWMutex
+}
+
+// NewSenderCache creates an empty sender cache for the given signer.
+func NewSenderCache(signer Signer) *SenderCache {
+	return &SenderCache{
+		signer:  signer,
+		senders: make(map[common.Hash]common.Address),
+	}
+}
+
+// Signer returns the signer the cached senders were derived with.
+func (c *SenderCache) Signer() Signer {
+	return c.signer
+}
+
+// Len returns the number of cached senders.
+func (c *SenderCache) Len() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return len(c.senders)
+}
+
+// Add inserts the sender of the transaction with the given hash.
+func (c *SenderCache) Add(hash common.Hash, from common.Address) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.senders[hash] = from
+}
+
+// AddTransaction inserts the sender of the transaction if it has already been
+// recovered with the cache's signer, without recovering it otherwise. It
+// returns whether the sender was known.
+func (c *SenderCache) AddTransaction(tx *Transaction) bool {
+	from, ok := CachedSender(c.signer, tx)
+	if ok {
+		c.Add(tx.Hash(), from)
+	}
+	return ok
+}
+
+// Get retrieves the cached sender of the transaction with the given hash.
+func (c *SenderCache) Get(hash common.Hash) (common.Address, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	from, ok := c.senders[hash]
+	return from, ok
+}
+
+// Sender returns the sender of the transaction, recovering and caching it if
+// it is not known yet.
+func (c *SenderCache) Sender(tx *Transaction) (common.Address, error) {
+	hash := tx.Hash()
+	if from, ok := c.Get(hash); ok {
+		tx.from.Store(sigCache{signer: c.signer, from: from})
+		return from, nil
+	}
+	from, err := Sender(c.signer, tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+	c.Add(hash, from)
+	return from, nil
+}
+
+// Prime stores the cached senders into the given transactions, so that any
+// subsequent Sender call with the cache's signer returns without recovering
+// the signature. It returns the number of transactions primed.
+func (c *SenderCache) Prime(txs Transactions) int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	var primed int
+	for _, tx := range txs {
+		if from, ok := c.senders[tx.Hash()]; ok {
+			tx.from.Store(sigCache{signer: c.signer, from: from})
+			primed++
+		}
+	}
+	return primed
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that senders known to the cache are carried over to freshly decoded
+// copies of the same transactions.
+func TestSenderCachePrime(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	signer := NewEIP155Signer(big.NewInt(18))
+
+	var known, unknown *Transaction
+	for i, tx := range []**Transaction{&known, &unknown} {
+		signed, err := SignTx(NewTransaction(uint64(i), common.Address{}, new(big.Int), 0, new(big.Int), nil), signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*tx = signed
+	}
+	cache := NewSenderCache(signer)
+	if cache.AddTransaction(known) {
+		t.Fatal("sender cached before being recovered")
+	}
+	if _, err := Sender(signer, known); err != nil {
+		t.Fatal(err)
+	}
+	if !cache.AddTransaction(known) {
+		t.Fatal("recovered sender not cached")
+	}
+	// Decode new transaction objects, dropping their own sender caches
+	var txs Transactions
+	for _, tx := range []*Transaction{known, unknown} {
+		enc, _ := rlp.EncodeToBytes(tx)
+		decoded := new(Transaction)
+		if err := rlp.DecodeBytes(enc, decoded); err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, decoded)
+	}
+	if primed := cache.Prime(txs); primed != 1 {
+		t.Fatalf("primed transaction count mismatch: have %d, want 1", primed)
+	}
+	if from, ok := CachedSender(signer, txs[0]); !ok || from != addr {
+		t.Errorf("primed sender mismatch: have %x (%v), want %x", from, ok, addr)
+	}
+	if _, ok := CachedSender(signer, txs[1]); ok {
+		t.Errorf("unknown sender primed")
+	}
+	// Senders unknown to the cache are recovered and added
+	if from, err := cache.Sender(txs[1]); err != nil || from != addr {
+		t.Errorf("recovered sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("cache size mismatch: have %d, want 2", cache.Len())
+	}
+}
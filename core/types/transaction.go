@@ -314,6 +314,40 @@ func (tx *Transaction) Cost() *big.Int {
 	return total
 }
 
+// CostBreakdown splits the funds spent by the transaction between its sender
+// and its payer, given the base fee and the blob base fee of the block including
+// it. A nil fee prices the gas at its cap, yielding the worst case split the
+// funds must cover.
+//
+// The senderCost is what the sender is charged, the value included, the payerCost
+// is the gas fee of a sponsored transaction charged to its payer, and the blobCost
+// is the part of the senderCost spent on blob gas. The maxTotal is the highest
+// amount the transaction may spend altogether, equal to Cost.
+func (tx *Transaction) CostBreakdown(baseFee, blobBaseFee *big.Int) (senderCost, payerCost, blobCost, maxTotal *big.Int) {
+	gasPrice := tx.GasPrice()
+	if baseFee != nil {
+		gasPrice = math.BigMin(new(big.Int).Add(tx.GasTipCap(), baseFee), tx.GasFeeCap())
+	}
+	gasCost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(tx.Gas()))
+
+	blobCost = new(big.Int)
+	if blobGas := tx.BlobGas(); blobGas > 0 {
+		blobPrice := tx.BlobGasFeeCap()
+		if blobBaseFee != nil {
+			blobPrice = blobBaseFee
+		}
+		blobCost.Mul(blobPrice, new(big.Int).SetUint64(blobGas))
+	}
+	senderCost = new(big.Int).Add(tx.Value(), blobCost)
+	if tx.Type() == SponsoredTxType {
+		payerCost = gasCost
+	} else {
+		payerCost = new(big.Int)
+		senderCost.Add(senderCost, gasCost)
+	}
+	return senderCost, payerCost, blobCost, tx.Cost()
+}
+
 // RawSignatureValues returns the V, R, S payer signature values of the transaction.
 // The return values should not be modified by the caller.
 func (tx *Transaction) RawPayerSignatureValues() (v, r, s *big.Int) {
@@ -713,6 +747,10 @@ type InternalTransaction struct {
 	Error   string
 	Output  []byte
 	*InternalTransactionBody
+
+	// Reverted is set if the internal transaction was rolled back by a failure
+	// of one of its enclosing call frames, regardless of its own outcome
+	Reverted bool `rlp:"optional"`
 }
 
 type InternalTransactionBody struct {
@@ -184,6 +184,18 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 	return addr, nil
 }
 
+// CachedSender returns the sender of the transaction if it has already been
+// derived with the given signer, without recovering it from the signature.
+func CachedSender(signer Signer, tx *Transaction) (common.Address, bool) {
+	if sc := tx.from.Load(); sc != nil {
+		sigCache := sc.(sigCache)
+		if sigCache.signer.Equal(signer) {
+			return sigCache.from, true
+		}
+	}
+	return common.Address{}, false
+}
+
 // Payer returns the address derived from payer's signature in sponsored
 // transaction or nil in other transaction types.
 //
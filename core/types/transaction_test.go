@@ -430,3 +430,53 @@ func assertEqual(orig *Transaction, cpy *Transaction) error {
 	}
 	return nil
 }
+
+// Tests that the cost breakdown splits the funds spent by every transaction type
+// between the sender and the payer, pricing the gas at the caps when no fees are
+// given and at the effective prices otherwise.
+func TestCostBreakdown(t *testing.T) {
+	var (
+		to      = common.HexToAddress("0x0000000000000000000000000000000000000001")
+		baseFee = big.NewInt(5)
+		value   = big.NewInt(1000)
+	)
+	blob := NewTx(&BlobTx{
+		To:         to,
+		Gas:        100,
+		GasTipCap:  uint256.NewInt(2),
+		GasFeeCap:  uint256.NewInt(10),
+		Value:      uint256.MustFromBig(value),
+		BlobFeeCap: uint256.NewInt(4),
+		BlobHashes: []common.Hash{{0x11}},
+	})
+	blobGas := int64(blob.BlobGas())
+
+	tests := []struct {
+		tx                                *Transaction
+		baseFee, blobBaseFee              *big.Int
+		sender, payer, blobCost, maxTotal int64
+	}{
+		// Legacy transactions always pay the gas price
+		{NewTransaction(0, to, value, 100, big.NewInt(7), nil), nil, nil, 1700, 0, 0, 1700},
+		{NewTransaction(0, to, value, 100, big.NewInt(7), nil), baseFee, nil, 1700, 0, 0, 1700},
+		// Dynamic fee transactions pay the cap at worst, the base fee plus the tip otherwise
+		{NewTx(&DynamicFeeTx{To: &to, Gas: 100, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(10), Value: value}), nil, nil, 2000, 0, 0, 2000},
+		{NewTx(&DynamicFeeTx{To: &to, Gas: 100, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(10), Value: value}), baseFee, nil, 1700, 0, 0, 2000},
+		{NewTx(&DynamicFeeTx{To: &to, Gas: 100, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(6), Value: value}), baseFee, nil, 1600, 0, 0, 1600},
+		// Sponsored transactions charge the gas to the payer and the value to the sender
+		{NewTx(&SponsoredTx{To: &to, Gas: 100, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(10), Value: value}), nil, nil, 1000, 1000, 0, 2000},
+		{NewTx(&SponsoredTx{To: &to, Gas: 100, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(10), Value: value}), baseFee, nil, 1000, 700, 0, 2000},
+		// Blob transactions charge the blob gas to the sender
+		{blob, nil, nil, 2000 + 4*blobGas, 0, 4 * blobGas, 2000 + 4*blobGas},
+		{blob, baseFee, big.NewInt(1), 1700 + blobGas, 0, blobGas, 2000 + 4*blobGas},
+	}
+	for i, tt := range tests {
+		sender, payer, blobCost, maxTotal := tt.tx.CostBreakdown(tt.baseFee, tt.blobBaseFee)
+		if sender.Int64() != tt.sender || payer.Int64() != tt.payer || blobCost.Int64() != tt.blobCost || maxTotal.Int64() != tt.maxTotal {
+			t.Errorf("test %d: cost mismatch: have %v/%v/%v/%v, want %d/%d/%d/%d", i, sender, payer, blobCost, maxTotal, tt.sender, tt.payer, tt.blobCost, tt.maxTotal)
+		}
+		if maxTotal.Cmp(tt.tx.Cost()) != 0 {
+			t.Errorf("test %d: max total mismatch: have %v, want %v", i, maxTotal, tt.tx.Cost())
+		}
+	}
+}
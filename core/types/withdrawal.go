@@ -0,0 +1,58 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+//go:generate gencodec -type Withdrawal -field-override withdrawalMarshaling -out gen_withdrawal_json.go
+
+// Withdrawal is a bridge withdrawal operation settled by the protocol, releasing
+// the tokens of a Ronin account to a recipient on the counterpart chain.
+type Withdrawal struct {
+	Index     uint64         `json:"index"`     // Monotonically increasing identifier of the withdrawal
+	Token     common.Address `json:"token"`     // Ronin token being withdrawn
+	Sender    common.Address `json:"sender"`    // Ronin account the tokens are withdrawn from
+	Recipient common.Address `json:"recipient"` // Recipient of the tokens on the counterpart chain
+	Amount    *big.Int       `json:"amount"`    // Amount of tokens withdrawn
+}
+
+// field type overrides for gencodec
+type withdrawalMarshaling struct {
+	Index  hexutil.Uint64
+	Amount *hexutil.Big
+}
+
+// Withdrawals implements DerivableList for withdrawals.
+type Withdrawals []*Withdrawal
+
+// Len returns the length of s.
+func (s Withdrawals) Len() int { return len(s) }
+
+// EncodeIndex encodes the i'th withdrawal to w. Note that this does not check
+// for errors because we assume that *Withdrawal will only ever contain valid
+// withdrawals that were either constructed by decoding or via public API in
+// this package.
+func (s Withdrawals) EncodeIndex(i int, w *bytes.Buffer) {
+	rlp.Encode(w, s[i])
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Witness is the execution witness of a block: the parts of the parent state
+// accessed while executing it, allowing a stateless client to replay the block
+// on top of the parent state root.
+type Witness struct {
+	Block  common.Hash     `json:"block"`
+	Number uint64          `json:"number"`
+	Root   common.Hash     `json:"root"`  // State root of the parent block the witness is against
+	State  []hexutil.Bytes `json:"state"` // Trie nodes resolved by the execution
+	Codes  []hexutil.Bytes `json:"codes"` // Contract codes loaded by the execution
+}
+
+// Size returns the total size of the trie nodes and contract codes of the
+// witness.
+func (w *Witness) Size() int {
+	var size int
+	for _, node := range w.State {
+		size += len(node)
+	}
+	for _, code := range w.Codes {
+		size += len(code)
+	}
+	return size
+}
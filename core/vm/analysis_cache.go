@@ -0,0 +1,271 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// jumpDestCacheVersion is the version of the on-disk jumpdest analysis format.
+// It must be bumped whenever the layout of the analysis bitmap changes, in which
+// case journals written by older versions are discarded on load.
+const jumpDestCacheVersion = 2
+
+var (
+	jumpDestCacheHitMeter     = metrics.NewRegisteredMeter("vm/jumpdest/cache/hit", nil)
+	jumpDestCacheMissMeter    = metrics.NewRegisteredMeter("vm/jumpdest/cache/miss", nil)
+	jumpDestCacheCorruptMeter = metrics.NewRegisteredMeter("vm/jumpdest/cache/corrupt", nil)
+)
+
+// JumpDestCache is a cache of JUMPDEST analysis results keyed by code hash,
+// shared across EVM instances.
+type JumpDestCache interface {
+	// Load retrieves the analysis of the code with the given hash, if cached.
+	Load(codeHash common.Hash) ([]byte, bool)
+
+	// Store caches the analysis of the code with the given hash.
+	Store(codeHash common.Hash, analysis []byte)
+}
+
+// jumpDestJournalHeader is the first item of a jumpdest cache journal.
+type jumpDestJournalHeader struct {
+	Version uint64
+}
+
+// jumpDestJournalEntry is a single analysis result stored in the journal.
+type jumpDestJournalEntry struct {
+	Hash common.Hash
+	Bits []byte
+	Sum  common.Hash
+}
+
+// jumpDestEntry is a cached analysis result along with its checksum.
+type jumpDestEntry struct {
+	bits []byte
+	sum  common.Hash
+}
+
+// jumpDestChecksum binds an analysis result to the hash of the code it was made
+// of, so that a corrupted or misplaced one is never served.
+func jumpDestChecksum(codeHash common.Hash, bits []byte) common.Hash {
+	return crypto.Keccak256Hash(codeHash[:], bits)
+}
+
+// DiskJumpDestCache is a size limited LRU cache of JUMPDEST analysis results,
+// which is periodically journaled to disk so that the analysis of hot contracts
+// survives node restarts.
+type DiskJumpDestCache struct {
+	path  string                                 // Filesystem path to store the journal at
+	cache *lru.Cache[common.Hash, jumpDestEntry] // Analysis results, evicted in LRU order
+	dirty atomic.Bool                            // Whether the cache changed since the last journal
+	lock  sync.Mutex                             // Lock protecting the journal file
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewDiskJumpDestCache creates a jumpdest analysis cache holding up to limit
+// entries, preloaded from the journal at path (if any). If rejournal is non
+// zero, the journal is regenerated in the background at the given interval.
+func NewDiskJumpDestCache(path string, limit int, rejournal time.Duration) (*DiskJumpDestCache, error) {
+	cache, err := lru.New[common.Hash, jumpDestEntry](limit)
+	if err != nil {
+		return nil, err
+	}
+	c := &DiskJumpDestCache{
+		path:  path,
+		cache: cache,
+		quit:  make(chan struct{}),
+	}
+	if err := c.load(); err != nil {
+		log.Warn("Failed to load jumpdest cache journal", "path", path, "err", err)
+	}
+	if rejournal > 0 {
+		c.wg.Add(1)
+		go c.loop(rejournal)
+	}
+	return c, nil
+}
+
+// Load implements JumpDestCache, retrieving a cached analysis result. Results
+// failing their checksum are dropped, for the code to be analysed again.
+func (c *DiskJumpDestCache) Load(codeHash common.Hash) ([]byte, bool) {
+	entry, ok := c.cache.Get(codeHash)
+	if ok && entry.sum != jumpDestChecksum(codeHash, entry.bits) {
+		log.Warn("Dropping corrupted jumpdest analysis", "codehash", codeHash)
+		jumpDestCacheCorruptMeter.Mark(1)
+		c.cache.Remove(codeHash)
+		c.dirty.Store(true)
+		ok = false
+	}
+	if !ok {
+		jumpDestCacheMissMeter.Mark(1)
+		return nil, false
+	}
+	jumpDestCacheHitMeter.Mark(1)
+	return entry.bits, true
+}
+
+// Store implements JumpDestCache, caching an analysis result.
+func (c *DiskJumpDestCache) Store(codeHash common.Hash, analysis []byte) {
+	c.cache.Add(codeHash, jumpDestEntry{bits: analysis, sum: jumpDestChecksum(codeHash, analysis)})
+	c.dirty.Store(true)
+}
+
+// Len returns the number of cached analysis results.
+func (c *DiskJumpDestCache) Len() int {
+	return c.cache.Len()
+}
+
+// load parses the journal from disk, inserting its contents into the cache.
+// Journals of an unknown version are silently discarded.
+func (c *DiskJumpDestCache) load() error {
+	// Skip the parsing if the journal file doesn't exist at all
+	if _, err := os.Stat(c.path); os.IsNotExist(err) {
+		return nil
+	}
+	input, err := os.Open(c.path)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+
+	stream := rlp.NewStream(input, 0)
+
+	var header jumpDestJournalHeader
+	if err := stream.Decode(&header); err != nil {
+		return err
+	}
+	if header.Version != jumpDestCacheVersion {
+		log.Info("Discarding outdated jumpdest cache journal", "version", header.Version, "want", jumpDestCacheVersion)
+		return nil
+	}
+	// Entries are stored from the least to the most recently used one, so
+	// inserting them in order restores the eviction order too.
+	for {
+		var entry jumpDestJournalEntry
+		if err := stream.Decode(&entry); err != nil {
+			if err != io.EOF {
+				return err
+			}
+			break
+		}
+		c.cache.Add(entry.Hash, jumpDestEntry{bits: entry.Bits, sum: entry.Sum})
+	}
+	log.Info("Loaded jumpdest cache journal", "entries", c.cache.Len())
+	return nil
+}
+
+// Journal regenerates the journal on disk based on the current contents of
+// the cache. It is a noop if nothing changed since the last journal.
+func (c *DiskJumpDestCache) Journal() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.dirty.Swap(false) {
+		return nil
+	}
+	replacement, err := os.OpenFile(c.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		c.dirty.Store(true)
+		return err
+	}
+	if err := c.write(replacement); err != nil {
+		replacement.Close()
+		c.dirty.Store(true)
+		return err
+	}
+	if err := replacement.Close(); err != nil {
+		c.dirty.Store(true)
+		return err
+	}
+	// Replace the live journal with the newly generated one
+	if err := os.Rename(c.path+".new", c.path); err != nil {
+		c.dirty.Store(true)
+		return err
+	}
+	return nil
+}
+
+// write dumps the cache contents into w, from the least to the most recently
+// used entry.
+func (c *DiskJumpDestCache) write(w io.Writer) error {
+	if err := rlp.Encode(w, &jumpDestJournalHeader{Version: jumpDestCacheVersion}); err != nil {
+		return err
+	}
+	journaled := 0
+	for _, hash := range c.cache.Keys() {
+		entry, ok := c.cache.Peek(hash)
+		if !ok {
+			continue // Evicted in the meantime
+		}
+		if err := rlp.Encode(w, &jumpDestJournalEntry{Hash: hash, Bits: entry.bits, Sum: entry.sum}); err != nil {
+			return err
+		}
+		journaled++
+	}
+	log.Debug("Regenerated jumpdest cache journal", "entries", journaled)
+	return nil
+}
+
+// loop periodically regenerates the journal until the cache is closed.
+func (c *DiskJumpDestCache) loop(rejournal time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(rejournal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Journal(); err != nil {
+				log.Warn("Failed to journal jumpdest cache", "err", err)
+			}
+		case <-c.quit:
+			return
+		}
+	}
+}
+
+// Close stops the background journaling and flushes the cache to disk.
+func (c *DiskJumpDestCache) Close() error {
+	select {
+	case <-c.quit:
+		return errors.New("jumpdest cache already closed")
+	default:
+		close(c.quit)
+	}
+	c.wg.Wait()
+
+	if err := c.Journal(); err != nil {
+		return fmt.Errorf("failed to journal jumpdest cache: %w", err)
+	}
+	return nil
+}
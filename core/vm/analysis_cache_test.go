@@ -0,0 +1,155 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the jumpdest cache survives a restart, evicts the least recently
+// used entries and discards journals of a different version or entries failing
+// their checksum.
+func TestDiskJumpDestCacheJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jumpdests")
+
+	cache, err := NewDiskJumpDestCache(path, 2, 0)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	codes := [][]byte{
+		{byte(PUSH1), 0x01, byte(JUMPDEST)},
+		{byte(PUSH2), byte(JUMPDEST), 0x01, byte(JUMPDEST)},
+		{byte(JUMPDEST), byte(PUSH1), byte(JUMPDEST)},
+	}
+	for _, code := range codes {
+		cache.Store(crypto.Keccak256Hash(code), codeBitmap(code))
+	}
+	if err := cache.Close(); err != nil {
+		t.Fatalf("failed to close cache: %v", err)
+	}
+	// Reopen the cache and ensure only the two most recent entries survived
+	cache, err = NewDiskJumpDestCache(path, 2, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen cache: %v", err)
+	}
+	if _, ok := cache.Load(crypto.Keccak256Hash(codes[0])); ok {
+		t.Errorf("evicted analysis loaded from journal")
+	}
+	for i, code := range codes[1:] {
+		bits, ok := cache.Load(crypto.Keccak256Hash(code))
+		if !ok {
+			t.Fatalf("analysis %d missing from journal", i+1)
+		}
+		if !bytes.Equal(bits, codeBitmap(code)) {
+			t.Errorf("analysis %d mismatch: have %x, want %x", i+1, bits, codeBitmap(code))
+		}
+	}
+	cache.Close()
+
+	// Rewrite the journal with a different version and ensure it's discarded
+	var buf bytes.Buffer
+	rlp.Encode(&buf, &jumpDestJournalHeader{Version: jumpDestCacheVersion + 1})
+	rlp.Encode(&buf, &jumpDestJournalEntry{Hash: crypto.Keccak256Hash(codes[0]), Bits: codeBitmap(codes[0])})
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write journal: %v", err)
+	}
+	cache, err = NewDiskJumpDestCache(path, 2, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen cache: %v", err)
+	}
+	if n := cache.Len(); n != 0 {
+		t.Errorf("outdated journal loaded: have %d entries", n)
+	}
+	cache.Close()
+
+	// Rewrite the journal with a corrupted and a misplaced analysis, and ensure
+	// neither is served
+	buf.Reset()
+	rlp.Encode(&buf, &jumpDestJournalHeader{Version: jumpDestCacheVersion})
+	for i, code := range codes[:2] {
+		hash, bits := crypto.Keccak256Hash(code), codeBitmap(code)
+		sum := jumpDestChecksum(hash, bits)
+		if i == 0 {
+			bits = []byte{0xff}
+		} else {
+			hash = crypto.Keccak256Hash(codes[2])
+		}
+		rlp.Encode(&buf, &jumpDestJournalEntry{Hash: hash, Bits: bits, Sum: sum})
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("failed to write journal: %v", err)
+	}
+	cache, err = NewDiskJumpDestCache(path, 2, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen cache: %v", err)
+	}
+	defer cache.Close()
+	for i, code := range [][]byte{codes[0], codes[2]} {
+		if _, ok := cache.Load(crypto.Keccak256Hash(code)); ok {
+			t.Errorf("corrupted analysis %d served", i)
+		}
+	}
+	if n := cache.Len(); n != 0 {
+		t.Errorf("corrupted analyses kept: have %d entries", n)
+	}
+}
+
+// Tests that contracts consult the persistent cache and ignore entries which
+// don't match the code.
+func TestContractPersistedAnalysis(t *testing.T) {
+	cache, err := NewDiskJumpDestCache(filepath.Join(t.TempDir(), "jumpdests"), 16, 0)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	defer cache.Close()
+
+	// PUSH1 0x5b JUMPDEST, where only the second JUMPDEST is valid
+	code := []byte{byte(PUSH1), byte(JUMPDEST), byte(JUMPDEST)}
+	hash := crypto.Keccak256Hash(code)
+
+	newContract := func() *Contract {
+		c := NewContract(AccountRef(common.Address{}), AccountRef(common.Address{1}), nil, 0)
+		c.SetCallCode(&common.Address{1}, hash, code)
+		c.persisted = cache
+		return c
+	}
+	if !newContract().isCode(2) {
+		t.Fatalf("valid jumpdest rejected")
+	}
+	if _, ok := cache.Load(hash); !ok {
+		t.Fatalf("analysis not persisted")
+	}
+	// Corrupt the cached analysis so that it marks every byte as code, it should
+	// be served to new contracts as is
+	forged := make([]byte, len(codeBitmap(code)))
+	cache.Store(hash, forged)
+	if !newContract().isCode(1) {
+		t.Fatalf("persisted analysis not used")
+	}
+	// Store an analysis of mismatching size, it should be ignored
+	cache.Store(hash, []byte{0x00})
+	if newContract().isCode(1) {
+		t.Fatalf("mismatching persisted analysis used")
+	}
+}
@@ -52,6 +52,7 @@ type Contract struct {
 
 	jumpdests map[common.Hash]bitvec // Aggregated result of JUMPDEST analysis.
 	analysis  bitvec                 // Locally cached result of JUMPDEST analysis
+	persisted JumpDestCache          // Cross-execution cache of JUMPDEST analysis (optional)
 
 	Code     []byte
 	CodeHash common.Hash
@@ -109,11 +110,19 @@ func (c *Contract) isCode(udest uint64) bool {
 	if c.CodeHash != (common.Hash{}) {
 		// Does parent context have the analysis?
 		analysis, exist := c.jumpdests[c.CodeHash]
+		if !exist {
+			// Does the persistent cache have the analysis?
+			analysis, exist = c.loadPersisted()
+		}
 		if !exist {
 			// Do the analysis and save in parent context
 			// We do not need to store it in c.analysis
 			analysis = codeBitmap(c.Code)
 			c.jumpdests[c.CodeHash] = analysis
+
+			if c.persisted != nil {
+				c.persisted.Store(c.CodeHash, analysis)
+			}
 		}
 		// Also stash it in current contract for faster access
 		c.analysis = analysis
@@ -129,6 +138,21 @@ func (c *Contract) isCode(udest uint64) bool {
 	return c.analysis.codeSegment(udest)
 }
 
+// loadPersisted retrieves the JUMPDEST analysis of the contract code from the
+// persistent cache, caching it in the parent context too. Results that do not
+// match the code size are ignored.
+func (c *Contract) loadPersisted() (bitvec, bool) {
+	if c.persisted == nil {
+		return nil, false
+	}
+	bits, ok := c.persisted.Load(c.CodeHash)
+	if !ok || len(bits) != len(c.Code)/8+1+4 {
+		return nil, false
+	}
+	c.jumpdests[c.CodeHash] = bits
+	return bits, true
+}
+
 // AsDelegate sets the contract to be a delegate call and returns the current
 // contract (for chaining calls)
 func (c *Contract) AsDelegate() *Contract {
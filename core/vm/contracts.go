@@ -18,6 +18,7 @@ package vm
 
 import (
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -59,6 +60,7 @@ var PrecompiledContractsBLS = map[common.Address]PrecompiledContract{
 }
 
 var (
+	PrecompiledAddressesPrague     []common.Address
 	PrecompiledAddressesCancun     []common.Address
 	PrecompiledAddressesBerlin     []common.Address
 	PrecompiledAddressesMiko       []common.Address
@@ -94,55 +96,59 @@ var (
 	// PrecompiledContractsCancun contains the default set of pre-compiled Ethereum
 	// contracts used in the Cancun release.
 	PrecompiledContractsCancun map[common.Address]PrecompiledContract
-)
-
-func copyPrecompiledContract(contracts map[common.Address]PrecompiledContract) map[common.Address]PrecompiledContract {
-	cpy := make(map[common.Address]PrecompiledContract)
 
-	for address, contract := range contracts {
-		cpy[address] = contract
-	}
+	// PrecompiledContractsPrague contains the batch hashing precompiled contract
+	// beside PrecompiledContractsCancun
+	PrecompiledContractsPrague map[common.Address]PrecompiledContract
 
-	return cpy
-}
+	// builtinPrecompileTables are the built-in precompiled contracts of each
+	// fork, derived from the registry.
+	builtinPrecompileTables [forkNever]map[common.Address]PrecompiledContract
+)
 
 func init() {
-	PrecompiledContractsHomestead = map[common.Address]PrecompiledContract{
-		common.BytesToAddress([]byte{1}): &ecrecover{},
-		common.BytesToAddress([]byte{2}): &sha256hash{},
-		common.BytesToAddress([]byte{3}): &ripemd160hash{},
-		common.BytesToAddress([]byte{4}): &dataCopy{},
-	}
-
-	PrecompiledContractsByzantium = copyPrecompiledContract(PrecompiledContractsHomestead)
-	PrecompiledContractsByzantium[common.BytesToAddress([]byte{5})] = &bigModExp{eip2565: false}
-	PrecompiledContractsByzantium[common.BytesToAddress([]byte{6})] = &bn256AddByzantium{}
-	PrecompiledContractsByzantium[common.BytesToAddress([]byte{7})] = &bn256ScalarMulByzantium{}
-	PrecompiledContractsByzantium[common.BytesToAddress([]byte{8})] = &bn256PairingByzantium{}
-
-	PrecompiledContractsIstanbul = copyPrecompiledContract(PrecompiledContractsByzantium)
-	PrecompiledContractsIstanbul[common.BytesToAddress([]byte{6})] = &bn256AddIstanbul{}
-	PrecompiledContractsIstanbul[common.BytesToAddress([]byte{7})] = &bn256ScalarMulIstanbul{}
-	PrecompiledContractsIstanbul[common.BytesToAddress([]byte{8})] = &bn256PairingIstanbul{}
-	PrecompiledContractsIstanbul[common.BytesToAddress([]byte{9})] = &blake2F{}
-
-	PrecompiledContractsConsortium = copyPrecompiledContract(PrecompiledContractsIstanbul)
-	PrecompiledContractsConsortium[common.BytesToAddress([]byte{101})] = &consortiumLog{}
-	PrecompiledContractsConsortium[common.BytesToAddress([]byte{102})] = &consortiumValidatorSorting{}
-	PrecompiledContractsConsortium[common.BytesToAddress([]byte{103})] = &consortiumVerifyHeaders{}
-	PrecompiledContractsConsortium[common.BytesToAddress([]byte{104})] = &consortiumPickValidatorSet{}
-	PrecompiledContractsConsortium[common.BytesToAddress([]byte{105})] = &consortiumValidateFinalityProof{}
-
-	PrecompiledContractsConsortiumMiko = copyPrecompiledContract(PrecompiledContractsConsortium)
-	PrecompiledContractsConsortiumMiko[common.BytesToAddress([]byte{106})] = &consortiumValidateProofOfPossession{}
-
-	PrecompiledContractsBerlin = copyPrecompiledContract(PrecompiledContractsConsortiumMiko)
-	PrecompiledContractsBerlin[common.BytesToAddress([]byte{5})] = &bigModExp{eip2565: true}
-
-	PrecompiledContractsCancun = copyPrecompiledContract(PrecompiledContractsBerlin)
-	PrecompiledContractsCancun[common.BytesToAddress([]byte{10})] = &kzgPointEvaluation{}
+	// Register the built-in contracts along with the forks enabling and
+	// removing them, the per-fork tables are derived from the registry
+	mustRegisterBuiltin(1, &ecrecover{}, forkHomestead, forkNever)
+	mustRegisterBuiltin(2, &sha256hash{}, forkHomestead, forkNever)
+	mustRegisterBuiltin(3, &ripemd160hash{}, forkHomestead, forkNever)
+	mustRegisterBuiltin(4, &dataCopy{}, forkHomestead, forkNever)
+
+	mustRegisterBuiltin(5, &bigModExp{eip2565: false}, forkByzantium, forkBerlin)
+	mustRegisterBuiltin(6, &bn256AddByzantium{}, forkByzantium, forkIstanbul)
+	mustRegisterBuiltin(7, &bn256ScalarMulByzantium{}, forkByzantium, forkIstanbul)
+	mustRegisterBuiltin(8, &bn256PairingByzantium{}, forkByzantium, forkIstanbul)
+
+	mustRegisterBuiltin(6, &bn256AddIstanbul{}, forkIstanbul, forkNever)
+	mustRegisterBuiltin(7, &bn256ScalarMulIstanbul{}, forkIstanbul, forkNever)
+	mustRegisterBuiltin(8, &bn256PairingIstanbul{}, forkIstanbul, forkNever)
+	mustRegisterBuiltin(9, &blake2F{}, forkIstanbul, forkNever)
+
 	// Remove consortiumLog precompiled contract after Cancun
-	delete(PrecompiledContractsCancun, common.BytesToAddress([]byte{101}))
+	mustRegisterBuiltin(101, &consortiumLog{}, forkConsortium, forkCancun)
+	mustRegisterBuiltin(102, &consortiumValidatorSorting{}, forkConsortium, forkNever)
+	mustRegisterBuiltin(103, &consortiumVerifyHeaders{}, forkConsortium, forkNever)
+	mustRegisterBuiltin(104, &consortiumPickValidatorSet{}, forkConsortium, forkNever)
+	mustRegisterBuiltin(105, &consortiumValidateFinalityProof{}, forkConsortium, forkNever)
+
+	mustRegisterBuiltin(106, &consortiumValidateProofOfPossession{}, forkMiko, forkNever)
+
+	mustRegisterBuiltin(5, &bigModExp{eip2565: true}, forkBerlin, forkNever)
+
+	mustRegisterBuiltin(10, &kzgPointEvaluation{}, forkCancun, forkNever)
+
+	mustRegisterBuiltin(107, &batchHash{}, forkPrague, forkNever)
+
+	builtinPrecompileTables = sealBuiltinPrecompiles()
+
+	PrecompiledContractsHomestead = builtinPrecompileTables[forkHomestead]
+	PrecompiledContractsByzantium = builtinPrecompileTables[forkByzantium]
+	PrecompiledContractsIstanbul = builtinPrecompileTables[forkIstanbul]
+	PrecompiledContractsConsortium = builtinPrecompileTables[forkConsortium]
+	PrecompiledContractsConsortiumMiko = builtinPrecompileTables[forkMiko]
+	PrecompiledContractsBerlin = builtinPrecompileTables[forkBerlin]
+	PrecompiledContractsCancun = builtinPrecompileTables[forkCancun]
+	PrecompiledContractsPrague = builtinPrecompileTables[forkPrague]
 
 	for k := range PrecompiledContractsHomestead {
 		PrecompiledAddressesHomestead = append(PrecompiledAddressesHomestead, k)
@@ -179,11 +185,30 @@ func init() {
 	for k := range PrecompiledContractsCancun {
 		PrecompiledAddressesCancun = append(PrecompiledAddressesCancun, k)
 	}
+	for k := range PrecompiledContractsPrague {
+		PrecompiledAddressesPrague = append(PrecompiledAddressesPrague, k)
+	}
 }
 
-// ActivePrecompiles returns the precompiles enabled with the current configuration.
+// ActivePrecompiles returns the precompiles enabled with the current configuration,
+// including the registered custom ones.
 func ActivePrecompiles(rules params.Rules) []common.Address {
+	builtins := builtinPrecompileAddresses(rules)
+	custom := activeCustomPrecompileAddresses(rules)
+	if len(custom) == 0 {
+		return builtins
+	}
+	addrs := make([]common.Address, 0, len(builtins)+len(custom))
+	addrs = append(addrs, builtins...)
+	return append(addrs, custom...)
+}
+
+// builtinPrecompileAddresses returns the addresses of the built-in precompiles
+// enabled with the current configuration.
+func builtinPrecompileAddresses(rules params.Rules) []common.Address {
 	switch {
+	case rules.IsPrague:
+		return PrecompiledAddressesPrague
 	case rules.IsCancun:
 		return PrecompiledAddressesCancun
 	case rules.IsBerlin:
@@ -201,6 +226,12 @@ func ActivePrecompiles(rules params.Rules) []common.Address {
 	}
 }
 
+// builtinPrecompiles returns the built-in precompiled contracts enabled with
+// the current configuration.
+func builtinPrecompiles(rules params.Rules) map[common.Address]PrecompiledContract {
+	return builtinPrecompileTables[precompileForkOf(rules)]
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 // It returns
 // - the returned bytes,
@@ -299,6 +330,95 @@ func (c *ripemd160hash) Run(input []byte) ([]byte, error) {
 	return common.LeftPadBytes(ripemd.Sum(nil), 32), nil
 }
 
+var (
+	errBatchHashAlgorithm = errors.New("unknown batch hash algorithm")
+	errBatchHashMalformed = errors.New("malformed batch hash input")
+	errBatchHashTooMany   = errors.New("too many messages in batch")
+)
+
+// Hash functions supported by the batch hashing precompile.
+const (
+	batchHashSha512    = 0x00
+	batchHashRipemd160 = 0x01
+)
+
+// batchHash implemented as a native contract, digesting several messages with
+// the same hash function in a single call.
+//
+// The input is a one byte hash function selector followed by the messages, each
+// prefixed with its length as a 4 byte big endian integer. The output is the
+// concatenation of the digests in order: 64 bytes each for SHA512, 32 bytes
+// each (left padded, as in the RIPEMD160 precompile) for RIPEMD160.
+type batchHash struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+//
+// Every message is metered separately, so that a batch costs about the same as
+// hashing its messages one by one, minus the overhead of the calls.
+func (c *batchHash) RequiredGas(input []byte) uint64 {
+	algorithm, messages, err := parseBatchHashInput(input)
+	if err != nil {
+		return params.BatchHashBaseGas
+	}
+	itemGas, wordGas := params.BatchHashSha512ItemGas, params.BatchHashSha512PerWordGas
+	if algorithm == batchHashRipemd160 {
+		itemGas, wordGas = params.BatchHashRipemd160ItemGas, params.BatchHashRipemd160PerWordGas
+	}
+	gas := params.BatchHashBaseGas
+	for _, message := range messages {
+		gas += itemGas + uint64(len(message)+31)/32*wordGas
+	}
+	return gas
+}
+
+func (c *batchHash) Run(input []byte) ([]byte, error) {
+	algorithm, messages, err := parseBatchHashInput(input)
+	if err != nil {
+		return nil, err
+	}
+	var output []byte
+	for _, message := range messages {
+		switch algorithm {
+		case batchHashSha512:
+			digest := sha512.Sum512(message)
+			output = append(output, digest[:]...)
+		case batchHashRipemd160:
+			ripemd := ripemd160.New()
+			ripemd.Write(message)
+			output = append(output, common.LeftPadBytes(ripemd.Sum(nil), 32)...)
+		}
+	}
+	return output, nil
+}
+
+// parseBatchHashInput splits the input of the batch hashing precompile into the
+// hash function selector and the messages to digest.
+func parseBatchHashInput(input []byte) (byte, [][]byte, error) {
+	if len(input) == 0 {
+		return 0, nil, errBatchHashMalformed
+	}
+	algorithm, input := input[0], input[1:]
+	if algorithm != batchHashSha512 && algorithm != batchHashRipemd160 {
+		return 0, nil, errBatchHashAlgorithm
+	}
+	var messages [][]byte
+	for len(input) > 0 {
+		if len(messages) == params.MaxBatchHashItems {
+			return 0, nil, errBatchHashTooMany
+		}
+		if len(input) < 4 {
+			return 0, nil, errBatchHashMalformed
+		}
+		size := uint64(binary.BigEndian.Uint32(input))
+		if uint64(len(input)-4) < size {
+			return 0, nil, errBatchHashMalformed
+		}
+		messages = append(messages, input[4:4+size])
+		input = input[4+size:]
+	}
+	return algorithm, messages, nil
+}
+
 // data copy implemented as a native contract.
 type dataCopy struct{}
 
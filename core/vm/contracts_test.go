@@ -81,6 +81,7 @@ var allPrecompiles = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{104}):  &consortiumPickValidatorSet{},
 	common.BytesToAddress([]byte{105}):  &consortiumValidateFinalityProof{},
 	common.BytesToAddress([]byte{106}):  &consortiumValidateProofOfPossession{},
+	common.BytesToAddress([]byte{107}):  &batchHash{},
 }
 
 // EIP-152 test vectors
@@ -372,6 +373,9 @@ func TestPrecompiledBLS12381MapG2(t *testing.T) {
 func TestPrecompiledPointEvaluation(t *testing.T) {
 	testJson("pointEvaluation", common.Bytes2Hex([]byte{10}), t)
 }
+func TestPrecompiledBatchHash(t *testing.T) {
+	testJson("batchHash", common.Bytes2Hex([]byte{107}), t)
+}
 func TestPrecompiledConsortiumLog(t *testing.T) {
 	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
 	glogger.Verbosity(log.LvlInfo)
@@ -454,6 +458,9 @@ func TestPrecompiledBLS12381MapG1Fail(t *testing.T) {
 func TestPrecompiledBLS12381MapG2Fail(t *testing.T) {
 	testJsonFail("blsMapG2", common.Bytes2Hex([]byte{19}), t)
 }
+func TestPrecompiledBatchHashFail(t *testing.T) {
+	testJsonFail("batchHash", common.Bytes2Hex([]byte{107}), t)
+}
 
 func loadJson(name string) ([]precompiledTest, error) {
 	data, err := ioutil.ReadFile(fmt.Sprintf("testdata/precompiles/%v.json", name))
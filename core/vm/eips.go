@@ -86,6 +86,9 @@ func enable1884(jt *JumpTable) {
 
 func opSelfBalance(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	balance, _ := uint256.FromBig(interpreter.evm.StateDB.GetBalance(scope.Contract.Address()))
+	if hasher := interpreter.evm.Config.TraceHasher; hasher != nil {
+		hasher.balance(scope.Contract.Address(), balance.Bytes32())
+	}
 	scope.Stack.push(balance)
 	return nil, nil
 }
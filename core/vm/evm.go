@@ -17,9 +17,10 @@
 package vm
 
 import (
+	"context"
 	"math/big"
+	"sync"
 	"sync/atomic"
-	"time"
 
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/log"
@@ -70,25 +71,10 @@ func (evm *EVM) precompile(caller ContractRef, addr common.Address) (Precompiled
 		}
 	}
 
-	var precompiles map[common.Address]PrecompiledContract
-	switch {
-	case evm.chainRules.IsCancun:
-		precompiles = PrecompiledContractsCancun
-	case evm.chainRules.IsBerlin:
-		precompiles = PrecompiledContractsBerlin
-	case evm.chainRules.IsMiko:
-		precompiles = PrecompiledContractsConsortiumMiko
-	case evm.chainRules.IsLastConsortiumV1Block, evm.chainRules.IsConsortiumV2:
-		precompiles = PrecompiledContractsConsortium
-	case evm.chainRules.IsIstanbul:
-		precompiles = PrecompiledContractsIstanbul
-	case evm.chainRules.IsByzantium:
-		precompiles = PrecompiledContractsByzantium
-	default:
-		precompiles = PrecompiledContractsHomestead
-	}
-
-	p, ok := precompiles[addr]
+	p, ok := builtinPrecompiles(evm.chainRules)[addr]
+	if !ok {
+		p, ok = activeCustomPrecompile(evm.chainRules, addr)
+	}
 	if ok {
 		if pWithInit, hasInit := p.(PrecompiledContractWithInit); hasInit {
 			pWithInit.Init(caller, evm)
@@ -170,10 +156,21 @@ type EVM struct {
 	// abort is used to abort the EVM calling operations
 	// NOTE: must be set atomically
 	abort int32
+	// abortErr is the reason the EVM calling operations were aborted
+	abortErr  error
+	abortLock sync.Mutex
+	// ctx is the context the execution is bound to, aborting it once done
+	ctx context.Context
+	// steps is the number of instructions executed across all the calls,
+	// checked against the step limit of the configuration
+	steps uint64
 	// callGasTemp holds the gas available for the current call. This is needed because the
 	// available gas is calculated in gasCall* according to the 63/64 rule and later
 	// applied in opCall*.
 	callGasTemp uint64
+	// refunds is the breakdown of the refund counter accrued by the current
+	// transaction, reverted along with the state of the failing call frames.
+	refunds RefundBreakdown
 
 	evmHook EVMHook
 }
@@ -192,7 +189,7 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 		StateDB:     statedb,
 		Config:      config,
 		chainConfig: chainConfig,
-		chainRules:  chainConfig.Rules(blockCtx.BlockNumber),
+		chainRules:  chainConfig.Rules(blockCtx.BlockNumber, blockCtx.Time),
 	}
 	evm.interpreter = NewEVMInterpreter(evm, config)
 	return evm
@@ -203,14 +200,63 @@ func NewEVM(blockCtx BlockContext, txCtx TxContext, statedb StateDB, chainConfig
 func (evm *EVM) Reset(txCtx TxContext, statedb StateDB) {
 	evm.TxContext = txCtx
 	evm.StateDB = statedb
+	evm.refunds = RefundBreakdown{}
+	evm.steps = 0
+}
+
+// SetContext binds the execution to the given context: the running operations
+// are aborted once the context is done. The context is only polled periodically
+// by the interpreter, so this is meant for non-consensus executions like the RPC
+// simulations, which should be abandoned when the caller is gone.
+func (evm *EVM) SetContext(ctx context.Context) {
+	evm.ctx = ctx
 }
 
 // Cancel cancels any running EVM operation. This may be called concurrently and
 // it's safe to be called multiple times.
 func (evm *EVM) Cancel() {
+	evm.cancel(ErrExecutionAborted)
+}
+
+// cancel aborts any running EVM operation, recording the reason if it is the
+// first abort.
+func (evm *EVM) cancel(err error) {
+	evm.abortLock.Lock()
+	if evm.abortErr == nil {
+		evm.abortErr = err
+	}
+	evm.abortLock.Unlock()
+
 	atomic.StoreInt32(&evm.abort, 1)
 }
 
+// AbortError returns the reason the running EVM operations were aborted: the
+// error of the bound context, ErrStepLimitReached or ErrExecutionAborted if
+// cancelled explicitly. Nil is returned if the execution was not aborted.
+func (evm *EVM) AbortError() error {
+	evm.abortLock.Lock()
+	defer evm.abortLock.Unlock()
+
+	return evm.abortErr
+}
+
+// aborted reports whether the running EVM operations should stop, aborting them
+// if the bound context is done.
+func (evm *EVM) aborted() bool {
+	if atomic.LoadInt32(&evm.abort) != 0 {
+		return true
+	}
+	if evm.ctx != nil {
+		select {
+		case <-evm.ctx.Done():
+			evm.cancel(evm.ctx.Err())
+			return true
+		default:
+		}
+	}
+	return false
+}
+
 // Cancelled returns true if Cancel has been called
 func (evm *EVM) Cancelled() bool {
 	return atomic.LoadInt32(&evm.abort) == 1
@@ -229,13 +275,8 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	debug := evm.Config.Tracer != nil
 	captureTraceEarly := func(err error) {
 		if debug && evm.Config.FullCallTracing {
-			if evm.depth == 0 {
-				evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
-				evm.Config.Tracer.CaptureEnd(ret, 0, err)
-			} else {
-				evm.Config.Tracer.CaptureEnter(CALL, caller.Address(), addr, input, gas, value)
-				evm.Config.Tracer.CaptureExit(ret, 0, err)
-			}
+			evm.captureBegin(CALL, caller.Address(), addr, input, gas, value)
+			evm.captureEnd(ret, 0, err)
 		}
 	}
 
@@ -253,20 +294,15 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		captureTraceEarly(ErrInsufficientBalance)
 		return nil, gas, ErrInsufficientBalance
 	}
-	snapshot := evm.StateDB.Snapshot()
+	snapshot, refunds := evm.StateDB.Snapshot(), evm.refunds
 	p, isPrecompile := evm.precompile(caller, addr)
 
 	if !evm.StateDB.Exist(addr) {
 		if !isPrecompile && evm.chainRules.IsEIP158 && value.Sign() == 0 {
 			// Calling a non existing account, don't do anything, but ping the tracer
 			if debug {
-				if evm.depth == 0 {
-					evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
-					evm.Config.Tracer.CaptureEnd(ret, 0, nil)
-				} else {
-					evm.Config.Tracer.CaptureEnter(CALL, caller.Address(), addr, input, gas, value)
-					evm.Config.Tracer.CaptureExit(ret, 0, nil)
-				}
+				evm.captureBegin(CALL, caller.Address(), addr, input, gas, value)
+				evm.captureEnd(ret, 0, nil)
 			}
 			return nil, gas, nil
 		}
@@ -274,20 +310,12 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	}
 	evm.Context.Transfer(evm.StateDB, caller.Address(), addr, value)
 
-	// Capture the tracer start/end events in debug mode
+	// Invoke tracer hooks that signal entering/exiting a call frame
 	if debug {
-		if evm.depth == 0 {
-			evm.Config.Tracer.CaptureStart(evm, caller.Address(), addr, false, input, gas, value)
-			defer func(startGas uint64, startTime time.Time) { // Lazy evaluation of the parameters
-				evm.Config.Tracer.CaptureEnd(ret, startGas-gas, err)
-			}(gas, time.Now())
-		} else {
-			// Handle tracer events for entering and exiting a call frame
-			evm.Config.Tracer.CaptureEnter(CALL, caller.Address(), addr, input, gas, value)
-			defer func(startGas uint64) {
-				evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
-			}(gas)
-		}
+		evm.captureBegin(CALL, caller.Address(), addr, input, gas, value)
+		defer func(startGas uint64) { // Lazy evaluation of the parameters
+			evm.captureEnd(ret, startGas-gas, err)
+		}(gas)
 	}
 
 	if isPrecompile {
@@ -313,6 +341,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 	// when we're in homestead this also counts for code storage gas errors.
 	if err != nil {
 		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.refunds = refunds
 		if err != ErrExecutionReverted {
 			gas = 0
 		}
@@ -345,13 +374,13 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	if !evm.Context.CanTransfer(evm.StateDB, caller.Address(), value) {
 		return nil, gas, ErrInsufficientBalance
 	}
-	var snapshot = evm.StateDB.Snapshot()
+	var snapshot, refunds = evm.StateDB.Snapshot(), evm.refunds
 
 	// Invoke tracer hooks that signal entering/exiting a call frame
 	if evm.Config.Tracer != nil {
-		evm.Config.Tracer.CaptureEnter(CALLCODE, caller.Address(), addr, input, gas, value)
+		evm.captureBegin(CALLCODE, caller.Address(), addr, input, gas, value)
 		defer func(startGas uint64) {
-			evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+			evm.captureEnd(ret, startGas-gas, err)
 		}(gas)
 	}
 
@@ -369,6 +398,7 @@ func (evm *EVM) CallCode(caller ContractRef, addr common.Address, input []byte,
 	}
 	if err != nil {
 		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.refunds = refunds
 		if err != ErrExecutionReverted {
 			gas = 0
 		}
@@ -386,8 +416,8 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	captureTraceEarly := func(err error) {
 		if debug && evm.Config.FullCallTracing {
 			parent := caller.(*Contract)
-			evm.Config.Tracer.CaptureEnter(DELEGATECALL, caller.Address(), addr, input, gas, parent.value)
-			evm.Config.Tracer.CaptureExit(ret, 0, err)
+			evm.captureBegin(DELEGATECALL, caller.Address(), addr, input, gas, parent.value)
+			evm.captureEnd(ret, 0, err)
 		}
 	}
 
@@ -400,7 +430,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 		captureTraceEarly(ErrDepth)
 		return nil, gas, ErrDepth
 	}
-	var snapshot = evm.StateDB.Snapshot()
+	var snapshot, refunds = evm.StateDB.Snapshot(), evm.refunds
 
 	// Invoke tracer hooks that signal entering/exiting a call frame
 	if evm.Config.Tracer != nil {
@@ -408,9 +438,9 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 		// that caller is something other than a Contract.
 		parent := caller.(*Contract)
 		// DELEGATECALL inherits value from parent call
-		evm.Config.Tracer.CaptureEnter(DELEGATECALL, caller.Address(), addr, input, gas, parent.value)
+		evm.captureBegin(DELEGATECALL, caller.Address(), addr, input, gas, parent.value)
 		defer func(startGas uint64) {
-			evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+			evm.captureEnd(ret, startGas-gas, err)
 		}(gas)
 	}
 
@@ -427,6 +457,7 @@ func (evm *EVM) DelegateCall(caller ContractRef, addr common.Address, input []by
 	}
 	if err != nil {
 		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.refunds = refunds
 		if err != ErrExecutionReverted {
 			gas = 0
 		}
@@ -451,7 +482,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	// after all empty accounts were deleted, so this is not required. However, if we omit this,
 	// then certain tests start failing; stRevertTest/RevertPrecompiledTouchExactOOG.json.
 	// We could change this, but for now it's left for legacy reasons
-	var snapshot = evm.StateDB.Snapshot()
+	var snapshot, refunds = evm.StateDB.Snapshot(), evm.refunds
 
 	// We do an AddBalance of zero here, just in order to trigger a touch.
 	// This doesn't matter on Mainnet, where all empties are gone at the time of Byzantium,
@@ -461,9 +492,9 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 
 	// Invoke tracer hooks that signal entering/exiting a call frame
 	if evm.Config.Tracer != nil {
-		evm.Config.Tracer.CaptureEnter(STATICCALL, caller.Address(), addr, input, gas, nil)
+		evm.captureBegin(STATICCALL, caller.Address(), addr, input, gas, nil)
 		defer func(startGas uint64) {
-			evm.Config.Tracer.CaptureExit(ret, startGas-gas, err)
+			evm.captureEnd(ret, startGas-gas, err)
 		}(gas)
 	}
 
@@ -486,6 +517,7 @@ func (evm *EVM) StaticCall(caller ContractRef, addr common.Address, input []byte
 	}
 	if err != nil {
 		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.refunds = refunds
 		if err != ErrExecutionReverted {
 			gas = 0
 		}
@@ -511,13 +543,8 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 
 	captureTraceEarly := func(err error) {
 		if debug && evm.Config.FullCallTracing {
-			if evm.depth == 0 {
-				evm.Config.Tracer.CaptureStart(evm, caller.Address(), address, true, codeAndHash.code, gas, value)
-				evm.Config.Tracer.CaptureEnd(nil, 0, err)
-			} else {
-				evm.Config.Tracer.CaptureEnter(typ, caller.Address(), address, codeAndHash.code, gas, value)
-				evm.Config.Tracer.CaptureExit(nil, 0, err)
-			}
+			evm.captureBegin(typ, caller.Address(), address, codeAndHash.code, gas, value)
+			evm.captureEnd(nil, 0, err)
 		}
 	}
 
@@ -572,7 +599,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 		return nil, common.Address{}, 0, ErrContractAddressCollision
 	}
 	// Create a new account on the state
-	snapshot := evm.StateDB.Snapshot()
+	snapshot, refunds := evm.StateDB.Snapshot(), evm.refunds
 	evm.StateDB.CreateAccount(address)
 	if evm.chainRules.IsEIP158 {
 		evm.StateDB.SetNonce(address, 1)
@@ -590,11 +617,7 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	}
 
 	if debug {
-		if evm.depth == 0 {
-			evm.Config.Tracer.CaptureStart(evm, caller.Address(), address, true, codeAndHash.code, gas, value)
-		} else {
-			evm.Config.Tracer.CaptureEnter(typ, caller.Address(), address, codeAndHash.code, gas, value)
-		}
+		evm.captureBegin(typ, caller.Address(), address, codeAndHash.code, gas, value)
 	}
 
 	ret, err := evm.interpreter.Run(contract, nil, false)
@@ -635,17 +658,14 @@ func (evm *EVM) create(caller ContractRef, codeAndHash *codeAndHash, gas uint64,
 	// when we're in homestead this also counts for code storage gas errors.
 	if err != nil && (evm.chainRules.IsHomestead || err != ErrCodeStoreOutOfGas) {
 		evm.StateDB.RevertToSnapshot(snapshot)
+		evm.refunds = refunds
 		if err != ErrExecutionReverted {
 			contract.UseGas(contract.Gas)
 		}
 	}
 
 	if debug {
-		if evm.depth == 0 {
-			evm.Config.Tracer.CaptureEnd(ret, gas-contract.Gas, err)
-		} else {
-			evm.Config.Tracer.CaptureExit(ret, gas-contract.Gas, err)
-		}
+		evm.captureEnd(ret, gas-contract.Gas, err)
 	}
 	return ret, address, contract.Gas, err
 }
@@ -682,7 +702,9 @@ func (evm *EVM) PublishEvent(
 	if context.CurrentTransaction == nil {
 		return
 	}
-
+	if err != nil {
+		evm.revertInternalTransactions(counter)
+	}
 	if event, ok := evm.Context.PublishEvents[opCode]; ok {
 		txHash := context.CurrentTransaction.Hash()
 		log.Debug("[EVM] PublishEvent", "transaction", txHash.Hex(), "opCode", opCode.String(), "from", from.Hash().Hex())
@@ -715,6 +737,22 @@ func (evm *EVM) PublishEvent(
 	}
 }
 
+// revertInternalTransactions marks the internal transactions of the current
+// transaction published since the given counter, i.e. within the call frame
+// started at it, as reverted along with the failed frame.
+func (evm *EVM) revertInternalTransactions(counter uint64) {
+	context := evm.Context
+	if context.CurrentTransaction == nil || context.InternalTransactions == nil {
+		return
+	}
+	txHash := context.CurrentTransaction.Hash()
+	for _, internal := range *context.InternalTransactions {
+		if internal.TransactionHash == txHash && internal.Order > counter {
+			internal.Reverted = true
+		}
+	}
+}
+
 func (evm *EVM) SetHook(evmHook EVMHook) {
 	evm.evmHook = evmHook
 }
@@ -740,3 +740,51 @@ func TestInternalTransactionOrderDelegateCalls(t *testing.T) {
 		}
 	}
 }
+
+func TestInternalTransactionRevertedFrame(t *testing.T) {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	internalTransactions := make([]*types.InternalTransaction, 0)
+	evm := NewEVM(
+		BlockContext{
+			BlockNumber:          common.Big0,
+			InternalTransactions: &internalTransactions,
+			Transfer:             func(_ StateDB, _, _ common.Address, _ *big.Int) {},
+			CanTransfer:          func(_ StateDB, _ common.Address, _ *big.Int) bool { return true },
+			PublishEvents:        make(PublishEventsMap),
+			CurrentTransaction:   types.NewTx(&types.LegacyTx{}),
+		},
+		TxContext{},
+		statedb,
+		&params.ChainConfig{
+			IstanbulBlock: common.Big0,
+		},
+		Config{},
+	)
+	for _, opcode := range []OpCode{CALL, DELEGATECALL, CREATE, CREATE2} {
+		evm.Context.PublishEvents[opcode] = &InternalTransactionEvent{}
+	}
+	// PUSH1 0 x5 PUSH2 0x0202 PUSH3 200000 CALL POP STOP: calls the reverting contract
+	callerAddress := common.BigToAddress(big.NewInt(0x201))
+	statedb.SetCode(callerAddress, common.FromHex("0x6000600060006000600061020262030d40f15000"))
+
+	// PUSH1 0 PUSH1 0 PUSH1 0 CREATE POP PUSH1 0 PUSH1 0 REVERT: creates a contract, then reverts
+	revertingAddress := common.BigToAddress(big.NewInt(0x202))
+	statedb.SetCode(revertingAddress, common.FromHex("0x600060006000f05060006000fd"))
+
+	if _, _, err = evm.Call(AccountRef(callerAddress), callerAddress, []byte{}, 1_000_000, big.NewInt(0)); err != nil {
+		t.Fatal(err)
+	}
+	internalTxs := *evm.Context.InternalTransactions
+	if len(internalTxs) != 2 {
+		t.Fatalf("Internal transactions length mismatches, got %d expect %d", len(internalTxs), 2)
+	}
+	if call := internalTxs[0]; call.Opcode != CALL.String() || call.Success || call.Reverted {
+		t.Fatalf("Unexpected failed call, %+v, body: %+v", call, call.InternalTransactionBody)
+	}
+	if create := internalTxs[1]; create.Opcode != CREATE.String() || !create.Success || !create.Reverted {
+		t.Fatalf("Unexpected reverted creation, %+v, body: %+v", create, create.InternalTransactionBody)
+	}
+}
@@ -105,6 +105,55 @@ func TestEIP2200(t *testing.T) {
 		if refund := vmenv.StateDB.GetRefund(); refund != tt.refund {
 			t.Errorf("test %d: gas refund mismatch: have %v, want %v", i, refund, tt.refund)
 		}
+		if refund := vmenv.Refunds().Total(); refund != tt.refund {
+			t.Errorf("test %d: gas refund breakdown mismatch: have %v, want %v", i, refund, tt.refund)
+		}
+	}
+}
+
+var refundBreakdownTests = []struct {
+	original byte
+	input    string
+	refunds  RefundBreakdown
+}{
+	{1, "0x6000600055", RefundBreakdown{SstoreClear: 15000}},                                          // 1 -> 0
+	{0, "0x60016000556000600055", RefundBreakdown{SstoreRestore: 19200}},                              // 0 -> 1 -> 0
+	{1, "0x60006000556001600055", RefundBreakdown{SstoreRestore: 4200}},                               // 1 -> 0 -> 1
+	{1, "0x600060005560016000556000600055", RefundBreakdown{SstoreClear: 15000, SstoreRestore: 4200}}, // 1 -> 0 -> 1 -> 0
+	{1, "0x6000600055" + "60006000fd", RefundBreakdown{}},                                             // 1 -> 0, reverted
+	{1, "0x6000600055" + "6000ff", RefundBreakdown{SstoreClear: 15000, Selfdestruct: 24000}},          // 1 -> 0, selfdestruct
+}
+
+// Tests that the refund counter is broken down by the operations accruing it,
+// and that the refunds of reverted frames are dropped from the breakdown.
+func TestRefundBreakdown(t *testing.T) {
+	// Self-destructs are only refunded before London (EIP-3529)
+	config := *params.AllEthashProtocolChanges
+	config.LondonBlock = nil
+
+	for i, tt := range refundBreakdownTests {
+		address := common.BytesToAddress([]byte("contract"))
+
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, hexutil.MustDecode(tt.input))
+		statedb.SetState(address, common.Hash{}, common.BytesToHash([]byte{tt.original}))
+		statedb.Finalise(true) // Push the state into the "original" slot
+
+		vmctx := BlockContext{
+			CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+			Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+			BlockNumber: big.NewInt(0),
+		}
+		vmenv := NewEVM(vmctx, TxContext{}, statedb, &config, Config{ExtraEips: []int{2200}})
+		vmenv.Call(AccountRef(common.Address{}), address, nil, math.MaxUint64, new(big.Int))
+
+		if refunds := vmenv.Refunds(); refunds != tt.refunds {
+			t.Errorf("test %d: refund breakdown mismatch: have %+v, want %+v", i, refunds, tt.refunds)
+		}
+		if refund := vmenv.StateDB.GetRefund(); refund != tt.refunds.Total() {
+			t.Errorf("test %d: gas refund mismatch: have %v, want %v", i, refund, tt.refunds.Total())
+		}
 	}
 }
 
@@ -118,6 +167,8 @@ var createGasTests = []struct {
 	{"0x6201000060006000f0" + "600052" + "60206000F3", false, 46357, 46357},
 	// legacy create(0, 0, 0x10000) _with_ 3860
 	{"0x6201000060006000f0" + "600052" + "60206000F3", true, 50453, 50453},
+	// legacy create(0, 0, 0x10001) (too large), with 3860
+	{"0x6201000160006000f0" + "600052" + "60206000F3", true, 0, 100_000},
 	// create2(0, 0, 0x10001, 0) without 3860
 	{"0x60006201000160006000f5" + "600052" + "60206000F3", false, 58665, 58665},
 	// create2(0, 0, 0x10001, 0) (too large), with 3860
@@ -0,0 +1,108 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package hooks defines the stable interface through which the EVM reports its
+// execution to tracers.
+//
+// A tracer fills in the hooks it is interested in and leaves the rest nil. The
+// EVM only hands out the typed contexts defined here, never its own internal
+// structures, so tracers are insulated from refactors of the interpreter. New
+// hooks may be added over time, which doesn't affect existing tracers.
+package hooks
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// StateDB gives tracers read access to the state the EVM is executing on.
+type StateDB interface {
+	GetBalance(common.Address) *big.Int
+	GetNonce(common.Address) uint64
+	GetCode(common.Address) []byte
+	GetState(common.Address, common.Hash) common.Hash
+	GetRefund() uint64
+	Exist(common.Address) bool
+}
+
+// VMContext is the environment a transaction is executed in.
+type VMContext struct {
+	Coinbase    common.Address
+	BlockNumber *big.Int
+	Time        uint64
+	BaseFee     *big.Int
+	BlobBaseFee *big.Int
+	GasPrice    *big.Int
+	BlobHashes  []common.Hash
+	ChainConfig *params.ChainConfig
+	StateDB     StateDB
+
+	// Counter returns the number of opcodes executed in the transaction so far,
+	// which orders the internal calls of the transaction.
+	Counter func() uint64
+}
+
+// OpContext is the call frame an opcode is executed in. The returned memory and
+// stack are live views of the frame, which must be copied if retained beyond
+// the hook invocation.
+type OpContext interface {
+	MemoryData() []byte
+	StackData() []uint256.Int // Bottom of the stack first
+	Caller() common.Address
+	Address() common.Address
+	CallValue() *big.Int
+	CallInput() []byte
+}
+
+type (
+	// TxStartHook is called before the execution of a transaction starts. The
+	// payer is only set for sponsored transactions.
+	TxStartHook = func(vm *VMContext, gasLimit uint64, payer *common.Address)
+
+	// TxEndHook is called after the execution of a transaction ends, with the
+	// gas left unused.
+	TxEndHook = func(restGas uint64)
+
+	// EnterHook is called when the EVM enters a new call frame, either the top
+	// level one at depth 0 or one opened by a CALL, CREATE or SELFDESTRUCT
+	// variant. The type is the opcode of the frame.
+	EnterHook = func(depth int, typ byte, from, to common.Address, input []byte, gas uint64, value *big.Int)
+
+	// ExitHook is called when the EVM exits a call frame, even if no code was
+	// executed in it.
+	ExitHook = func(depth int, output []byte, gasUsed uint64, err error)
+
+	// OpcodeHook is called before the execution of each opcode, or after its
+	// pre-execution checks failed, in which case err is set.
+	OpcodeHook = func(pc uint64, op byte, gas, cost uint64, scope OpContext, rData []byte, depth int, err error)
+
+	// FaultHook is called when the execution of an opcode fails.
+	FaultHook = func(pc uint64, op byte, gas, cost uint64, scope OpContext, depth int, err error)
+)
+
+// Hooks is the set of callbacks a tracer registers with the EVM. Any of them
+// may be nil.
+type Hooks struct {
+	OnTxStart TxStartHook
+	OnTxEnd   TxEndHook
+	OnEnter   EnterHook
+	OnExit    ExitHook
+	OnOpcode  OpcodeHook
+	OnFault   FaultHook
+}
@@ -260,6 +260,9 @@ func opBalance(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]
 	slot := scope.Stack.peek()
 	address := common.Address(slot.Bytes20())
 	slot.SetFromBig(interpreter.evm.StateDB.GetBalance(address))
+	if hasher := interpreter.evm.Config.TraceHasher; hasher != nil {
+		hasher.balance(address, slot.Bytes32())
+	}
 	return nil, nil
 }
 
@@ -516,12 +519,21 @@ func opSload(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]by
 	hash := common.Hash(loc.Bytes32())
 	val := interpreter.evm.StateDB.GetState(scope.Contract.Address(), hash)
 	loc.SetBytes(val.Bytes())
+	if hasher := interpreter.evm.Config.TraceHasher; hasher != nil {
+		hasher.storage(false, scope.Contract.Address(), hash, val)
+	}
 	return nil, nil
 }
 
 func opSstore(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([]byte, error) {
 	loc := scope.Stack.pop()
 	val := scope.Stack.pop()
+	if stats := interpreter.evm.Config.SstoreStats; stats != nil {
+		stats.record(interpreter.evm.StateDB, scope.Contract.Address(), loc.Bytes32(), val.Bytes32())
+	}
+	if hasher := interpreter.evm.Config.TraceHasher; hasher != nil {
+		hasher.storage(true, scope.Contract.Address(), loc.Bytes32(), val.Bytes32())
+	}
 	interpreter.evm.StateDB.SetState(scope.Contract.Address(), loc.Bytes32(), val.Bytes32())
 	return nil, nil
 }
@@ -709,9 +721,10 @@ func opCallCode(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext) ([
 		gas += params.CallStipend
 		bigVal = value.ToBig()
 	}
-
+	counter := interpreter.evm.Context.Counter
 	ret, returnGas, err := interpreter.evm.CallCode(scope.Contract, toAddr, args, gas, bigVal)
 	if err != nil {
+		interpreter.evm.revertInternalTransactions(counter)
 		temp.Clear()
 	} else {
 		temp.SetOne()
@@ -771,8 +784,10 @@ func opStaticCall(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext)
 	// Get arguments from the memory.
 	args := scope.Memory.GetPtr(int64(inOffset.Uint64()), int64(inSize.Uint64()))
 
+	counter := interpreter.evm.Context.Counter
 	ret, returnGas, err := interpreter.evm.StaticCall(scope.Contract, toAddr, args, gas)
 	if err != nil {
+		interpreter.evm.revertInternalTransactions(counter)
 		temp.Clear()
 	} else {
 		temp.SetOne()
@@ -810,10 +825,8 @@ func opSelfdestruct(pc *uint64, interpreter *EVMInterpreter, scope *ScopeContext
 	balance := interpreter.evm.StateDB.GetBalance(scope.Contract.Address())
 	interpreter.evm.StateDB.AddBalance(beneficiary.Bytes20(), balance)
 	interpreter.evm.StateDB.SelfDestruct(scope.Contract.Address())
-	if interpreter.evm.Config.Tracer != nil {
-		interpreter.cfg.Tracer.CaptureEnter(SELFDESTRUCT, scope.Contract.Address(), beneficiary.Bytes20(), []byte{}, 0, balance)
-		interpreter.cfg.Tracer.CaptureExit([]byte{}, 0, nil)
-	}
+	interpreter.evm.captureBegin(SELFDESTRUCT, scope.Contract.Address(), beneficiary.Bytes20(), []byte{}, 0, balance)
+	interpreter.evm.captureEnd([]byte{}, 0, nil)
 	return nil, nil
 }
 
@@ -823,10 +836,8 @@ func opSelfdestruct6780(pc *uint64, interpreter *EVMInterpreter, scope *ScopeCon
 	interpreter.evm.StateDB.SubBalance(scope.Contract.Address(), balance)
 	interpreter.evm.StateDB.AddBalance(beneficiary.Bytes20(), balance)
 	interpreter.evm.StateDB.SelfDestruct6780(scope.Contract.Address())
-	if tracer := interpreter.evm.Config.Tracer; tracer != nil {
-		tracer.CaptureEnter(SELFDESTRUCT, scope.Contract.Address(), beneficiary.Bytes20(), []byte{}, 0, balance)
-		tracer.CaptureExit([]byte{}, 0, nil)
-	}
+	interpreter.evm.captureBegin(SELFDESTRUCT, scope.Contract.Address(), beneficiary.Bytes20(), []byte{}, 0, balance)
+	interpreter.evm.captureEnd([]byte{}, 0, nil)
 	return nil, nil
 }
 
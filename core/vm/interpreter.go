@@ -18,27 +18,34 @@ package vm
 
 import (
 	"hash"
-	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/log"
 )
 
 // Config are the configuration options for the Interpreter
 type Config struct {
-	Debug                   bool      // Enables debugging
-	Tracer                  EVMLogger // Opcode logger
-	FullCallTracing         bool      // Emit trace error
-	NoRecursion             bool      // Disables call, callcode, delegate call and create
-	NoBaseFee               bool      // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
-	EnablePreimageRecording bool      // Enables recording of SHA3/keccak preimages
+	Debug                   bool         // Enables debugging
+	Tracer                  *hooks.Hooks // Execution tracing hooks
+	FullCallTracing         bool         // Emit trace error
+	NoRecursion             bool         // Disables call, callcode, delegate call and create
+	NoBaseFee               bool         // Forces the EIP-1559 baseFee to 0 (needed for 0 price calls)
+	EnablePreimageRecording bool         // Enables recording of SHA3/keccak preimages
 
 	JumpTable [256]*operation // EVM instruction table, automatically populated if unset
 
 	ExtraEips []int // Additional EIPS that are to be enabled
 
 	IsSystemTransaction bool // Used by tracer to specially handle system transaction
+
+	JumpDestCache JumpDestCache // Persistent cache of JUMPDEST analysis results (optional)
+
+	StepLimit uint64 // Maximum number of instructions executed across all the calls, 0 = unlimited (non-consensus executions only)
+
+	SstoreStats *SstoreStats // Counters of the executed SSTOREs by slot transition (optional)
+	TraceHasher *TraceHasher // Running hash of the execution events per transaction (optional)
 }
 
 // ScopeContext contains the things that are per-call, such as stack and memory,
@@ -147,6 +154,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	if len(contract.Code) == 0 {
 		return nil, nil
 	}
+	if in.cfg.JumpDestCache != nil {
+		contract.persisted = in.cfg.JumpDestCache
+	}
 
 	var (
 		op          OpCode        // current opcode
@@ -163,9 +173,9 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		pc   = uint64(0) // program counter
 		cost uint64
 		// copies used by tracer
-		pcCopy  uint64 // needed for the deferred EVMLogger
-		gasCopy uint64 // for EVMLogger to log gas remaining before execution
-		logged  bool   // deferred EVMLogger should ignore already logged steps
+		pcCopy  uint64 // needed for the deferred tracer
+		gasCopy uint64 // for the tracer to log gas remaining before execution
+		logged  bool   // deferred tracer should ignore already logged steps
 		res     []byte // result of the opcode execution function
 		debug   = in.evm.Config.Tracer != nil
 	)
@@ -181,9 +191,13 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		defer func() {
 			if err != nil {
 				if !logged {
-					in.cfg.Tracer.CaptureState(pcCopy, op, gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
+					if in.cfg.Tracer.OnOpcode != nil {
+						in.cfg.Tracer.OnOpcode(pcCopy, byte(op), gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
+					}
 				} else {
-					in.cfg.Tracer.CaptureFault(pcCopy, op, gasCopy, cost, callContext, in.evm.depth, err)
+					if in.cfg.Tracer.OnFault != nil {
+						in.cfg.Tracer.OnFault(pcCopy, byte(op), gasCopy, cost, callContext, in.evm.depth, err)
+					}
 				}
 			}
 		}()
@@ -192,10 +206,13 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 	// explicit STOP, RETURN or SELFDESTRUCT is executed, an error occurred during
 	// the execution of one of the operations or until the done flag is set by the
 	// parent context.
-	steps := 0
 	for {
-		steps++
-		if steps%1000 == 0 && atomic.LoadInt32(&in.evm.abort) != 0 {
+		in.evm.steps++
+		if limit := in.cfg.StepLimit; limit != 0 && in.evm.steps > limit {
+			in.evm.cancel(ErrStepLimitReached)
+			break
+		}
+		if in.evm.steps%1000 == 0 && in.evm.aborted() {
 			break
 		}
 		if debug {
@@ -267,9 +284,14 @@ func (in *EVMInterpreter) Run(contract *Contract, input []byte, readOnly bool) (
 		}
 
 		if debug {
-			in.cfg.Tracer.CaptureState(pc, op, gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
+			if in.cfg.Tracer.OnOpcode != nil {
+				in.cfg.Tracer.OnOpcode(pc, byte(op), gasCopy, cost, callContext, in.returnData, in.evm.depth, err)
+			}
 			logged = true
 		}
+		if hasher := in.cfg.TraceHasher; hasher != nil {
+			hasher.step(in.evm.depth, pc, op, contract.Gas+cost, cost)
+		}
 
 		// execute the operation
 		res, err = operation.execute(&pc, in, callContext)
@@ -0,0 +1,99 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// loopCode is an endless loop: JUMPDEST PUSH1 0 JUMP.
+const loopCode = "0x5b600056"
+
+// newLoopEVM creates an EVM with a contract looping forever.
+func newLoopEVM(config Config) (*EVM, common.Address) {
+	address := common.BytesToAddress([]byte("contract"))
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.CreateAccount(address)
+	statedb.SetCode(address, hexutil.MustDecode(loopCode))
+	statedb.Finalise(true)
+
+	vmctx := BlockContext{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+	}
+	return NewEVM(vmctx, TxContext{}, statedb, params.AllEthashProtocolChanges, config), address
+}
+
+// Tests that the execution is aborted once the step limit is reached.
+func TestStepLimit(t *testing.T) {
+	evm, address := newLoopEVM(Config{StepLimit: 3000})
+
+	_, gas, err := evm.Call(AccountRef(common.Address{}), address, nil, 1_000_000, new(big.Int))
+	if err != nil {
+		t.Fatalf("unexpected call error: %v", err)
+	}
+	if err := evm.AbortError(); !errors.Is(err, ErrStepLimitReached) {
+		t.Fatalf("abort error mismatch: have %v, want %v", err, ErrStepLimitReached)
+	}
+	// 1000 iterations of JUMPDEST (1) + PUSH1 (3) + JUMP (8)
+	if used := 1_000_000 - gas; used != 1000*12 {
+		t.Errorf("gas used mismatch: have %d, want %d", used, 1000*12)
+	}
+}
+
+// Tests that the execution is aborted once the bound context is done, and that
+// an explicit cancellation is reported too.
+func TestContextAbort(t *testing.T) {
+	evm, address := newLoopEVM(Config{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	evm.SetContext(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		evm.Call(AccountRef(common.Address{}), address, nil, math.MaxUint64, new(big.Int))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("execution not aborted")
+	}
+	if err := evm.AbortError(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("abort error mismatch: have %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	evm, address = newLoopEVM(Config{})
+	evm.Cancel()
+	evm.Call(AccountRef(common.Address{}), address, nil, math.MaxUint64, new(big.Int))
+	if err := evm.AbortError(); !errors.Is(err, ErrExecutionAborted) {
+		t.Fatalf("abort error mismatch: have %v, want %v", err, ErrExecutionAborted)
+	}
+}
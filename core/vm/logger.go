@@ -20,24 +20,76 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
+	"github.com/holiman/uint256"
 )
 
-// EVMLogger is used to collect execution traces from an EVM transaction
-// execution. CaptureState is called for each step of the VM with the
-// current VM state.
-// Note that reference types are actual VM data structures; make copies
-// if you need to retain them beyond the current call.
-type EVMLogger interface {
-	// Transaction level
-	CaptureTxStart(gasLimit uint64, payer *common.Address)
-	CaptureTxEnd(restGas uint64)
-	// Top call frame
-	CaptureStart(env *EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int)
-	CaptureEnd(output []byte, gasUsed uint64, err error)
-	// Rest of call frames
-	CaptureEnter(typ OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int)
-	CaptureExit(output []byte, gasUsed uint64, err error)
-	// Opcode level
-	CaptureState(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, rData []byte, depth int, err error)
-	CaptureFault(pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, depth int, err error)
+// GetVMContext provides tracers with the environment the current transaction
+// is executed in.
+func (evm *EVM) GetVMContext() *hooks.VMContext {
+	return &hooks.VMContext{
+		Coinbase:    evm.Context.Coinbase,
+		BlockNumber: evm.Context.BlockNumber,
+		Time:        evm.Context.Time,
+		BaseFee:     evm.Context.BaseFee,
+		BlobBaseFee: evm.Context.BlobBaseFee,
+		GasPrice:    evm.TxContext.GasPrice,
+		BlobHashes:  evm.TxContext.BlobHashes,
+		ChainConfig: evm.ChainConfig(),
+		StateDB:     evm.StateDB,
+		Counter:     func() uint64 { return evm.Context.Counter },
+	}
+}
+
+// captureBegin reports entering a call frame to the tracer.
+func (evm *EVM) captureBegin(typ OpCode, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if tracer := evm.Config.Tracer; tracer != nil && tracer.OnEnter != nil {
+		tracer.OnEnter(evm.depth, byte(typ), from, to, input, gas, value)
+	}
+}
+
+// captureEnd reports exiting a call frame to the tracer.
+func (evm *EVM) captureEnd(output []byte, gasUsed uint64, err error) {
+	if tracer := evm.Config.Tracer; tracer != nil && tracer.OnExit != nil {
+		tracer.OnExit(evm.depth, output, gasUsed, err)
+	}
+}
+
+// MemoryData returns the underlying memory slice. Callers must not modify the
+// contents of the returned data.
+func (ctx *ScopeContext) MemoryData() []byte {
+	if ctx.Memory == nil {
+		return nil
+	}
+	return ctx.Memory.Data()
+}
+
+// StackData returns the stack data. Callers must not modify the contents of
+// the returned data.
+func (ctx *ScopeContext) StackData() []uint256.Int {
+	if ctx.Stack == nil {
+		return nil
+	}
+	return ctx.Stack.Data()
+}
+
+// Caller returns the current caller.
+func (ctx *ScopeContext) Caller() common.Address {
+	return ctx.Contract.Caller()
+}
+
+// Address returns the address where this scope of execution is taking place.
+func (ctx *ScopeContext) Address() common.Address {
+	return ctx.Contract.Address()
+}
+
+// CallValue returns the value supplied with this call.
+func (ctx *ScopeContext) CallValue() *big.Int {
+	return ctx.Contract.Value()
+}
+
+// CallInput returns the input/calldata with this call. Callers must not modify
+// the contents of the returned data.
+func (ctx *ScopeContext) CallInput() []byte {
+	return ctx.Contract.Input
 }
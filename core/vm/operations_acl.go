@@ -62,7 +62,7 @@ func makeGasSStoreFunc(clearingRefund uint64) gasFunc {
 				return cost + params.SstoreSetGasEIP2200, nil
 			}
 			if value == (common.Hash{}) { // delete slot (2.1.2b)
-				evm.StateDB.AddRefund(clearingRefund)
+				evm.addRefund(&evm.refunds.SstoreClear, clearingRefund)
 			}
 			// EIP-2200 original clause:
 			//		return params.SstoreResetGasEIP2200, nil // write existing slot (2.1.2)
@@ -70,23 +70,23 @@ func makeGasSStoreFunc(clearingRefund uint64) gasFunc {
 		}
 		if original != (common.Hash{}) {
 			if current == (common.Hash{}) { // recreate slot (2.2.1.1)
-				evm.StateDB.SubRefund(clearingRefund)
+				evm.subRefund(&evm.refunds.SstoreClear, clearingRefund)
 			} else if value == (common.Hash{}) { // delete slot (2.2.1.2)
-				evm.StateDB.AddRefund(clearingRefund)
+				evm.addRefund(&evm.refunds.SstoreClear, clearingRefund)
 			}
 		}
 		if original == value {
 			if original == (common.Hash{}) { // reset to original inexistent slot (2.2.2.1)
 				// EIP 2200 Original clause:
 				//evm.StateDB.AddRefund(params.SstoreSetGasEIP2200 - params.SloadGasEIP2200)
-				evm.StateDB.AddRefund(params.SstoreSetGasEIP2200 - params.WarmStorageReadCostEIP2929)
+				evm.addRefund(&evm.refunds.SstoreRestore, params.SstoreSetGasEIP2200-params.WarmStorageReadCostEIP2929)
 			} else { // reset to original existing slot (2.2.2.2)
 				// EIP 2200 Original clause:
 				//	evm.StateDB.AddRefund(params.SstoreResetGasEIP2200 - params.SloadGasEIP2200)
 				// - SSTORE_RESET_GAS redefined as (5000 - COLD_SLOAD_COST)
 				// - SLOAD_GAS redefined as WARM_STORAGE_READ_COST
 				// Final: (5000 - COLD_SLOAD_COST) - WARM_STORAGE_READ_COST
-				evm.StateDB.AddRefund((params.SstoreResetGasEIP2200 - params.ColdSloadCostEIP2929) - params.WarmStorageReadCostEIP2929)
+				evm.addRefund(&evm.refunds.SstoreRestore, (params.SstoreResetGasEIP2200-params.ColdSloadCostEIP2929)-params.WarmStorageReadCostEIP2929)
 			}
 		}
 		// EIP-2200 original clause:
@@ -241,7 +241,7 @@ func makeSelfdestructGasFn(refundsEnabled bool) gasFunc {
 			gas += params.CreateBySelfdestructGas
 		}
 		if refundsEnabled && !evm.StateDB.HasSelfDestructed(contract.Address()) {
-			evm.StateDB.AddRefund(params.SelfdestructRefundGas)
+			evm.addRefund(&evm.refunds.Selfdestruct, params.SelfdestructRefundGas)
 		}
 		return gas, nil
 	}
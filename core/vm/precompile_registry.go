@@ -0,0 +1,239 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// ErrPrecompileRegistered is returned when registering a precompiled contract
+// at an address which is already taken.
+var ErrPrecompileRegistered = errors.New("precompiled contract already registered")
+
+// CustomPrecompile is a precompiled contract in the registry, either a built-in
+// one or one added by a consensus engine or a chain specific hardfork.
+type CustomPrecompile struct {
+	Address  common.Address                // Address the contract is reachable at
+	Contract PrecompiledContract           // Implementation of the contract
+	Active   func(rules params.Rules) bool // Fork gate, reporting whether the contract is enabled under the rules
+}
+
+// registeredPrecompile is a precompiled contract in the registry along with the
+// chain it is scoped to.
+type registeredPrecompile struct {
+	CustomPrecompile
+	chainID *big.Int // Chain the contract is enabled on, nil for the built-in ones
+}
+
+var (
+	// precompiles is the immutable list of the registered precompiled contracts,
+	// replaced as a whole on every registration so that the lookups during
+	// execution don't need any locking.
+	precompiles atomic.Pointer[[]registeredPrecompile]
+
+	// precompilesLock serializes the registrations.
+	precompilesLock sync.Mutex
+
+	// builtinsSealed is set once the per-fork tables of the built-in contracts
+	// are derived from the registry, after which only chain scoped contracts
+	// can be registered.
+	builtinsSealed bool
+)
+
+// RegisterPrecompile adds a precompiled contract, enabled from the fork its
+// activation function gates it behind on the chain of the given config. A nil
+// config registers a built-in contract on every chain, which is only possible
+// while the package is initialized, before the per-fork tables are derived.
+//
+// The address must not be taken by a built-in precompiled contract of any fork,
+// or by another contract of the same chain in the same fork.
+//
+// The registrations are meant to be done at startup, before executing any block,
+// as registering a contract later changes the outcome of the already executed ones.
+func RegisterPrecompile(config *params.ChainConfig, precompile CustomPrecompile) error {
+	if precompile.Contract == nil || precompile.Active == nil {
+		return errors.New("precompiled contract and activation must be set")
+	}
+	var chainID *big.Int
+	if config != nil {
+		if config.ChainID == nil {
+			return errors.New("precompiled contract registered for a chain without id")
+		}
+		chainID = new(big.Int).Set(config.ChainID)
+	}
+	precompilesLock.Lock()
+	defer precompilesLock.Unlock()
+
+	if chainID == nil && builtinsSealed {
+		return errors.New("built-in precompiled contracts are already derived")
+	}
+	var registered []registeredPrecompile
+	if list := precompiles.Load(); list != nil {
+		registered = *list
+	}
+	if _, ok := PrecompiledContractsBLS[precompile.Address]; ok {
+		return fmt.Errorf("%w: built-in contract at %v", ErrPrecompileRegistered, precompile.Address)
+	}
+	for _, p := range registered {
+		if p.Address != precompile.Address {
+			continue
+		}
+		switch {
+		case p.chainID == nil && chainID != nil:
+			return fmt.Errorf("%w: built-in contract at %v", ErrPrecompileRegistered, precompile.Address)
+
+		case p.chainID == nil && chainID == nil:
+			// Built-in contracts may replace each other across the forks
+			for _, rules := range precompileForkRules {
+				if p.Active(rules) && precompile.Active(rules) {
+					return fmt.Errorf("%w: built-in contract at %v", ErrPrecompileRegistered, precompile.Address)
+				}
+			}
+		case p.chainID != nil && chainID != nil && p.chainID.Cmp(chainID) == 0:
+			return fmt.Errorf("%w: custom contract at %v", ErrPrecompileRegistered, precompile.Address)
+		}
+	}
+	updated := make([]registeredPrecompile, 0, len(registered)+1)
+	updated = append(updated, registered...)
+	updated = append(updated, registeredPrecompile{CustomPrecompile: precompile, chainID: chainID})
+	precompiles.Store(&updated)
+	return nil
+}
+
+// precompileFork is a fork changing the set of the built-in precompiled contracts.
+type precompileFork int
+
+const (
+	forkHomestead precompileFork = iota
+	forkByzantium
+	forkIstanbul
+	forkConsortium
+	forkMiko
+	forkBerlin
+	forkCancun
+	forkPrague
+	forkNever // Fork after the latest, for the contracts never removed
+)
+
+// precompileForkRules are the rules selecting each fork of precompileFork.
+var precompileForkRules = [forkNever]params.Rules{
+	forkHomestead:  {},
+	forkByzantium:  {IsByzantium: true},
+	forkIstanbul:   {IsIstanbul: true},
+	forkConsortium: {IsConsortiumV2: true},
+	forkMiko:       {IsMiko: true},
+	forkBerlin:     {IsBerlin: true},
+	forkCancun:     {IsCancun: true},
+	forkPrague:     {IsPrague: true},
+}
+
+// precompileForkOf returns the fork selecting the set of the built-in precompiled
+// contracts enabled under the rules.
+func precompileForkOf(rules params.Rules) precompileFork {
+	switch {
+	case rules.IsPrague:
+		return forkPrague
+	case rules.IsCancun:
+		return forkCancun
+	case rules.IsBerlin:
+		return forkBerlin
+	case rules.IsMiko:
+		return forkMiko
+	case rules.IsLastConsortiumV1Block, rules.IsConsortiumV2:
+		return forkConsortium
+	case rules.IsIstanbul:
+		return forkIstanbul
+	case rules.IsByzantium:
+		return forkByzantium
+	default:
+		return forkHomestead
+	}
+}
+
+// mustRegisterBuiltin registers a built-in precompiled contract, enabled from
+// the given fork until the other one.
+func mustRegisterBuiltin(addr byte, contract PrecompiledContract, from, until precompileFork) {
+	err := RegisterPrecompile(nil, CustomPrecompile{
+		Address:  common.BytesToAddress([]byte{addr}),
+		Contract: contract,
+		Active: func(rules params.Rules) bool {
+			fork := precompileForkOf(rules)
+			return fork >= from && fork < until
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// sealBuiltinPrecompiles derives the per-fork tables of the built-in contracts
+// from the registry, closing it for further built-in registrations.
+func sealBuiltinPrecompiles() [forkNever]map[common.Address]PrecompiledContract {
+	precompilesLock.Lock()
+	defer precompilesLock.Unlock()
+
+	builtinsSealed = true
+
+	var tables [forkNever]map[common.Address]PrecompiledContract
+	for fork, rules := range precompileForkRules {
+		tables[fork] = make(map[common.Address]PrecompiledContract)
+		for _, p := range *precompiles.Load() {
+			if p.chainID == nil && p.Active(rules) {
+				tables[fork][p.Address] = p.Contract
+			}
+		}
+	}
+	return tables
+}
+
+// activeCustomPrecompile returns the chain scoped precompiled contract enabled
+// at the address under the given rules, if any.
+func activeCustomPrecompile(rules params.Rules, addr common.Address) (PrecompiledContract, bool) {
+	list := precompiles.Load()
+	if list == nil || rules.ChainID == nil {
+		return nil, false
+	}
+	for _, p := range *list {
+		if p.chainID != nil && p.Address == addr && p.chainID.Cmp(rules.ChainID) == 0 && p.Active(rules) {
+			return p.Contract, true
+		}
+	}
+	return nil, false
+}
+
+// activeCustomPrecompileAddresses returns the addresses of the chain scoped
+// precompiled contracts enabled under the given rules.
+func activeCustomPrecompileAddresses(rules params.Rules) []common.Address {
+	list := precompiles.Load()
+	if list == nil || rules.ChainID == nil {
+		return nil
+	}
+	var addrs []common.Address
+	for _, p := range *list {
+		if p.chainID != nil && p.chainID.Cmp(rules.ChainID) == 0 && p.Active(rules) {
+			addrs = append(addrs, p.Address)
+		}
+	}
+	return addrs
+}
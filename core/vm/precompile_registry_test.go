@@ -0,0 +1,148 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestRegisterPrecompile(t *testing.T) {
+	saved := precompiles.Load()
+	defer precompiles.Store(saved)
+
+	var (
+		addr     = common.BytesToAddress([]byte{0x02, 0x00})
+		contract = &dataCopy{}
+		caller   = AccountRef(common.Address{0x1})
+		config   = &params.ChainConfig{ChainID: big.NewInt(2020)}
+		other    = &params.ChainConfig{ChainID: big.NewInt(2021)}
+	)
+	err := RegisterPrecompile(config, CustomPrecompile{
+		Address:  addr,
+		Contract: contract,
+		Active:   func(rules params.Rules) bool { return rules.IsVenoki },
+	})
+	if err != nil {
+		t.Fatalf("failed to register precompile: %v", err)
+	}
+	// The contract must only be enabled from the fork gating it
+	before := &EVM{chainConfig: config, chainRules: params.Rules{ChainID: config.ChainID, IsBerlin: true}}
+	if _, ok := before.precompile(caller, addr); ok {
+		t.Fatal("custom precompile enabled before its fork")
+	}
+	if slices.Contains(ActivePrecompiles(before.chainRules), addr) {
+		t.Fatal("custom precompile listed as active before its fork")
+	}
+	after := &EVM{chainConfig: config, chainRules: params.Rules{ChainID: config.ChainID, IsBerlin: true, IsVenoki: true}}
+	if p, ok := after.precompile(caller, addr); !ok || p != contract {
+		t.Fatalf("custom precompile mismatch after its fork: have %v, %v", p, ok)
+	}
+	active := ActivePrecompiles(after.chainRules)
+	if !slices.Contains(active, addr) {
+		t.Fatal("custom precompile not listed as active after its fork")
+	}
+	if len(active) != len(PrecompiledAddressesBerlin)+1 {
+		t.Fatalf("active precompile count mismatch: have %d, want %d", len(active), len(PrecompiledAddressesBerlin)+1)
+	}
+	if len(PrecompiledAddressesBerlin) != len(PrecompiledAddressesIstanbul) {
+		t.Fatal("built-in precompile addresses modified")
+	}
+	// The contract must not leak into other chains
+	elsewhere := &EVM{chainConfig: other, chainRules: params.Rules{ChainID: other.ChainID, IsBerlin: true, IsVenoki: true}}
+	if _, ok := elsewhere.precompile(caller, addr); ok {
+		t.Fatal("custom precompile enabled on another chain")
+	}
+	if slices.Contains(ActivePrecompiles(elsewhere.chainRules), addr) {
+		t.Fatal("custom precompile listed as active on another chain")
+	}
+	// Built-in contracts must still be reachable
+	if p, ok := after.precompile(caller, common.BytesToAddress([]byte{5})); !ok || !p.(*bigModExp).eip2565 {
+		t.Fatal("built-in precompile mismatch")
+	}
+	// Taken addresses must be rejected, the custom one only on its own chain
+	for i, tt := range []struct {
+		config *params.ChainConfig
+		addr   common.Address
+		taken  bool
+	}{
+		{config, addr, true},
+		{other, addr, false},
+		{config, common.BytesToAddress([]byte{101}), true},
+		{other, common.BytesToAddress([]byte{107}), true},
+		{other, common.BytesToAddress([]byte{11}), true},
+	} {
+		err := RegisterPrecompile(tt.config, CustomPrecompile{
+			Address:  tt.addr,
+			Contract: &dataCopy{},
+			Active:   func(rules params.Rules) bool { return true },
+		})
+		if taken := errors.Is(err, ErrPrecompileRegistered); taken != tt.taken {
+			t.Fatalf("test %d: registration mismatch at %v: have %v, want taken %v", i, tt.addr, err, tt.taken)
+		}
+	}
+	// Built-in contracts can't be registered once the tables are derived
+	err = RegisterPrecompile(nil, CustomPrecompile{
+		Address:  common.BytesToAddress([]byte{0x03, 0x00}),
+		Contract: &dataCopy{},
+		Active:   func(rules params.Rules) bool { return true },
+	})
+	if err == nil {
+		t.Fatal("registered built-in precompile after deriving the tables")
+	}
+}
+
+// Tests that the per-fork tables are derived from the built-in registrations,
+// replacing the contracts across the forks.
+func TestBuiltinPrecompileTables(t *testing.T) {
+	for i, tt := range []struct {
+		table map[common.Address]PrecompiledContract
+		size  int
+	}{
+		{PrecompiledContractsHomestead, 4},
+		{PrecompiledContractsByzantium, 8},
+		{PrecompiledContractsIstanbul, 9},
+		{PrecompiledContractsConsortium, 14},
+		{PrecompiledContractsConsortiumMiko, 15},
+		{PrecompiledContractsBerlin, 15},
+		{PrecompiledContractsCancun, 15},
+		{PrecompiledContractsPrague, 16},
+	} {
+		if len(tt.table) != tt.size {
+			t.Errorf("table %d: size mismatch: have %d, want %d", i, len(tt.table), tt.size)
+		}
+	}
+	modexp := common.BytesToAddress([]byte{5})
+	if PrecompiledContractsConsortiumMiko[modexp].(*bigModExp).eip2565 || !PrecompiledContractsBerlin[modexp].(*bigModExp).eip2565 {
+		t.Error("modexp not replaced in berlin")
+	}
+	if _, ok := PrecompiledContractsIstanbul[common.BytesToAddress([]byte{6})].(*bn256AddIstanbul); !ok {
+		t.Error("bn256 add not replaced in istanbul")
+	}
+	consortiumLog := common.BytesToAddress([]byte{101})
+	if _, ok := PrecompiledContractsBerlin[consortiumLog]; !ok {
+		t.Error("consortium log missing before cancun")
+	}
+	if _, ok := PrecompiledContractsCancun[consortiumLog]; ok {
+		t.Error("consortium log not removed in cancun")
+	}
+}
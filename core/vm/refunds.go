@@ -0,0 +1,55 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+// RefundBreakdown is the gas refund counter of a transaction split by the
+// operations that accrued it. The sum of the fields equals the refund counter
+// of the state, before the refund cap of the fork is applied.
+type RefundBreakdown struct {
+	SstoreClear   uint64 `json:"sstoreClear"`   // Refunds for clearing storage slots
+	SstoreRestore uint64 `json:"sstoreRestore"` // Refunds for restoring storage slots to their original values
+	Selfdestruct  uint64 `json:"selfdestruct"`  // Refunds for self-destructing contracts
+}
+
+// Total returns the sum of all the refunds.
+func (r RefundBreakdown) Total() uint64 {
+	return r.SstoreClear + r.SstoreRestore + r.Selfdestruct
+}
+
+// Refunds returns the breakdown of the refund counter accrued by the current
+// transaction. Refunds of reverted call frames are not included, mirroring the
+// refund counter of the state.
+func (evm *EVM) Refunds() RefundBreakdown {
+	return evm.refunds
+}
+
+// addRefund adds gas to the refund counter of the state, accounting it to the
+// given field of the breakdown.
+func (evm *EVM) addRefund(kind *uint64, gas uint64) {
+	evm.StateDB.AddRefund(gas)
+	*kind += gas
+}
+
+// subRefund removes gas from the refund counter of the state, accounting it to
+// the given field of the breakdown.
+func (evm *EVM) subRefund(kind *uint64, gas uint64) {
+	evm.StateDB.SubRefund(gas)
+	if gas > *kind {
+		gas = *kind
+	}
+	*kind -= gas
+}
@@ -122,7 +122,7 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 		address = common.BytesToAddress([]byte("contract"))
 		vmenv   = NewEnv(cfg)
 		sender  = vm.AccountRef(cfg.Origin)
-		rules   = cfg.ChainConfig.Rules(vmenv.Context.BlockNumber)
+		rules   = cfg.ChainConfig.Rules(vmenv.Context.BlockNumber, vmenv.Context.Time)
 	)
 	// Execute the preparatory steps for state transition which includes:
 	// - prepare accessList(post-berlin)
@@ -133,13 +133,19 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 	// set the receiver's (the executing contract) code for execution.
 	cfg.State.SetCode(address, code)
 	// Call the code with the given configuration.
-	ret, _, err := vmenv.Call(
+	if tracer := cfg.EVMConfig.Tracer; tracer != nil && tracer.OnTxStart != nil {
+		tracer.OnTxStart(vmenv.GetVMContext(), cfg.GasLimit, nil)
+	}
+	ret, leftOverGas, err := vmenv.Call(
 		sender,
 		common.BytesToAddress([]byte("contract")),
 		input,
 		cfg.GasLimit,
 		cfg.Value,
 	)
+	if tracer := cfg.EVMConfig.Tracer; tracer != nil && tracer.OnTxEnd != nil {
+		tracer.OnTxEnd(leftOverGas)
+	}
 	return ret, cfg.State, err
 }
 
@@ -156,7 +162,7 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 	var (
 		vmenv  = NewEnv(cfg)
 		sender = vm.AccountRef(cfg.Origin)
-		rules  = cfg.ChainConfig.Rules(vmenv.Context.BlockNumber)
+		rules  = cfg.ChainConfig.Rules(vmenv.Context.BlockNumber, vmenv.Context.Time)
 	)
 	// Execute the preparatory steps for state transition which includes:
 	// - prepare accessList(post-berlin)
@@ -164,12 +170,18 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 	cfg.State.Prepare(rules, cfg.Origin, cfg.Coinbase, nil, vm.ActivePrecompiles(rules), nil)
 
 	// Call the code with the given configuration.
+	if tracer := cfg.EVMConfig.Tracer; tracer != nil && tracer.OnTxStart != nil {
+		tracer.OnTxStart(vmenv.GetVMContext(), cfg.GasLimit, nil)
+	}
 	code, address, leftOverGas, err := vmenv.Create(
 		sender,
 		input,
 		cfg.GasLimit,
 		cfg.Value,
 	)
+	if tracer := cfg.EVMConfig.Tracer; tracer != nil && tracer.OnTxEnd != nil {
+		tracer.OnTxEnd(leftOverGas)
+	}
 	return code, address, leftOverGas, err
 }
 
@@ -185,7 +197,7 @@ func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, er
 		vmenv   = NewEnv(cfg)
 		sender  = cfg.State.GetOrNewStateObject(cfg.Origin)
 		statedb = cfg.State
-		rules   = cfg.ChainConfig.Rules(vmenv.Context.BlockNumber)
+		rules   = cfg.ChainConfig.Rules(vmenv.Context.BlockNumber, vmenv.Context.Time)
 	)
 	// Execute the preparatory steps for state transition which includes:
 	// - prepare accessList(post-berlin)
@@ -193,6 +205,9 @@ func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, er
 	statedb.Prepare(rules, cfg.Origin, cfg.Coinbase, &address, vm.ActivePrecompiles(rules), nil)
 
 	// Call the code with the given configuration.
+	if tracer := cfg.EVMConfig.Tracer; tracer != nil && tracer.OnTxStart != nil {
+		tracer.OnTxStart(vmenv.GetVMContext(), cfg.GasLimit, nil)
+	}
 	ret, leftOverGas, err := vmenv.Call(
 		sender,
 		address,
@@ -200,5 +215,8 @@ func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, er
 		cfg.GasLimit,
 		cfg.Value,
 	)
+	if tracer := cfg.EVMConfig.Tracer; tracer != nil && tracer.OnTxEnd != nil {
+		tracer.OnTxEnd(leftOverGas)
+	}
 	return ret, leftOverGas, err
 }
@@ -0,0 +1,80 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SstoreCounts are the numbers of SSTOREs executed, split by the transition
+// they applied to the storage slot. The original value of a slot is its value
+// at the start of the transaction, as in the EIP-2200 gas metering.
+type SstoreCounts struct {
+	Created uint64 `json:"created"` // Clean slots set from zero to non-zero
+	Cleared uint64 `json:"cleared"` // Clean slots set from non-zero to zero
+	Updated uint64 `json:"updated"` // Clean slots set from non-zero to another non-zero
+	Dirty   uint64 `json:"dirty"`   // Rewrites of slots already changed by the transaction
+	Noop    uint64 `json:"noop"`    // Writes of the current value
+}
+
+// SstoreStats counts the SSTOREs executed by the EVMs configured with it, in
+// total and per contract. The SSTOREs of reverted call frames are counted too,
+// as they were charged for.
+type SstoreStats struct {
+	Total     SstoreCounts                     `json:"total"`
+	Contracts map[common.Address]*SstoreCounts `json:"contracts"`
+
+	lock sync.Mutex
+}
+
+// NewSstoreStats creates an empty set of SSTORE counters.
+func NewSstoreStats() *SstoreStats {
+	return &SstoreStats{Contracts: make(map[common.Address]*SstoreCounts)}
+}
+
+// record classifies the SSTORE of value into the given slot of a contract, the
+// state not being updated yet.
+func (s *SstoreStats) record(db StateDB, addr common.Address, key, value common.Hash) {
+	var (
+		current = db.GetState(addr, key)
+		count   func(c *SstoreCounts)
+	)
+	switch {
+	case current == value:
+		count = func(c *SstoreCounts) { c.Noop++ }
+	case db.GetCommittedState(addr, key) != current:
+		count = func(c *SstoreCounts) { c.Dirty++ }
+	case current == (common.Hash{}):
+		count = func(c *SstoreCounts) { c.Created++ }
+	case value == (common.Hash{}):
+		count = func(c *SstoreCounts) { c.Cleared++ }
+	default:
+		count = func(c *SstoreCounts) { c.Updated++ }
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	contract := s.Contracts[addr]
+	if contract == nil {
+		contract = new(SstoreCounts)
+		s.Contracts[addr] = contract
+	}
+	count(&s.Total)
+	count(contract)
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/crypto/sha3"
+)
+
+// Kinds of the events folded into the trace hashes.
+const (
+	traceEventStep byte = iota
+	traceEventStorageRead
+	traceEventStorageWrite
+	traceEventBalanceRead
+	traceEventOutcome
+)
+
+// TxTraceHash is the digest of the execution of a transaction.
+type TxTraceHash struct {
+	Hash   common.Hash `json:"hash"`   // Hash of the transaction
+	Steps  uint64      `json:"steps"`  // Number of opcodes executed
+	Digest common.Hash `json:"digest"` // Running hash of the execution events
+}
+
+// TraceHasher folds the canonical execution events of the EVMs configured with
+// it into a running hash per transaction: every executed opcode with its call
+// depth, program counter, available gas and cost, every storage slot read or
+// written and every balance read with its value, and the outcome of the
+// transaction. Two nodes executing a transaction the same way compute the same
+// digest, which can be compared instead of the full traces. It isn't safe for
+// concurrent use, the EVMs must execute the transactions serially.
+type TraceHasher struct {
+	hasher keccakState
+	buf    []byte
+	steps  uint64
+	txs    []TxTraceHash
+}
+
+// NewTraceHasher creates a trace hasher with no transaction hashed yet.
+func NewTraceHasher() *TraceHasher {
+	return &TraceHasher{
+		hasher: sha3.NewLegacyKeccak256().(keccakState),
+		buf:    make([]byte, 0, 1+common.AddressLength+2*common.HashLength),
+	}
+}
+
+// step folds an executed opcode, its gas being already charged.
+func (h *TraceHasher) step(depth int, pc uint64, op OpCode, gas, cost uint64) {
+	h.steps++
+	buf := append(h.buf[:0], traceEventStep, byte(op))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(depth))
+	buf = binary.BigEndian.AppendUint64(buf, pc)
+	buf = binary.BigEndian.AppendUint64(buf, gas)
+	buf = binary.BigEndian.AppendUint64(buf, cost)
+	h.hasher.Write(buf)
+}
+
+// storage folds a read or a write of a storage slot of a contract.
+func (h *TraceHasher) storage(write bool, addr common.Address, key, value common.Hash) {
+	kind := traceEventStorageRead
+	if write {
+		kind = traceEventStorageWrite
+	}
+	buf := append(h.buf[:0], kind)
+	buf = append(buf, addr[:]...)
+	buf = append(buf, key[:]...)
+	buf = append(buf, value[:]...)
+	h.hasher.Write(buf)
+}
+
+// balance folds a read of the balance of an account.
+func (h *TraceHasher) balance(addr common.Address, value [32]byte) {
+	buf := append(h.buf[:0], traceEventBalanceRead)
+	buf = append(buf, addr[:]...)
+	buf = append(buf, value[:]...)
+	h.hasher.Write(buf)
+}
+
+// Commit folds the outcome of the transaction of the given hash into its events
+// hashed since the last commit, and records the resulting digest.
+func (h *TraceHasher) Commit(hash common.Hash, gasUsed uint64, status uint64) {
+	buf := append(h.buf[:0], traceEventOutcome)
+	buf = binary.BigEndian.AppendUint64(buf, gasUsed)
+	buf = binary.BigEndian.AppendUint64(buf, status)
+	h.hasher.Write(buf)
+
+	tx := TxTraceHash{Hash: hash, Steps: h.steps}
+	h.hasher.Read(tx.Digest[:])
+	h.txs = append(h.txs, tx)
+
+	h.hasher.Reset()
+	h.steps = 0
+}
+
+// Transactions returns the digests of the committed transactions, in order.
+func (h *TraceHasher) Transactions() []TxTraceHash {
+	return h.txs
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// witnessStatsWindow is the number of recent blocks the witness size estimates
+// are kept for.
+const witnessStatsWindow = 1024
+
+var (
+	witnessNodesHist     = metrics.NewRegisteredHistogram("chain/witness/nodes", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessNodeBytesHist = metrics.NewRegisteredHistogram("chain/witness/nodebytes", nil, metrics.NewExpDecaySample(1028, 0.015))
+	witnessCodeBytesHist = metrics.NewRegisteredHistogram("chain/witness/codebytes", nil, metrics.NewExpDecaySample(1028, 0.015))
+)
+
+// WitnessStats is the estimated size of the execution witness of a block, the
+// state a stateless client would need to execute it.
+type WitnessStats struct {
+	Number  uint64      `json:"number"`
+	Hash    common.Hash `json:"hash"`
+	Txs     int         `json:"txs"`
+	GasUsed uint64      `json:"gasUsed"`
+
+	state.WitnessSize
+}
+
+// witnessStats is the ring buffer of the witness size estimates of the recent
+// blocks.
+type witnessStats struct {
+	stats []WitnessStats
+	next  int
+	lock  sync.RWMutex
+}
+
+// newWitnessStats creates an empty ring buffer of witness size estimates.
+func newWitnessStats() *witnessStats {
+	return &witnessStats{stats: make([]WitnessStats, 0, witnessStatsWindow)}
+}
+
+// recordWitnessSize estimates the size of the execution witness of a block from
+// the state it was processed on, once validated but before being committed. The
+// state must have tracked its reads through the tries, bypassing the snapshot.
+func (bc *BlockChain) recordWitnessSize(block *types.Block, statedb *state.StateDB) {
+	stats := WitnessStats{
+		Number:      block.NumberU64(),
+		Hash:        block.Hash(),
+		Txs:         len(block.Transactions()),
+		GasUsed:     block.GasUsed(),
+		WitnessSize: statedb.WitnessSize(),
+	}
+	witnessNodesHist.Update(int64(stats.Nodes))
+	witnessNodeBytesHist.Update(int64(stats.NodeBytes))
+	witnessCodeBytesHist.Update(int64(stats.CodeBytes))
+
+	w := bc.witnessStats
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if len(w.stats) < witnessStatsWindow {
+		w.stats = append(w.stats, stats)
+	} else {
+		w.stats[w.next] = stats
+	}
+	w.next = (w.next + 1) % witnessStatsWindow
+}
+
+// WitnessStats returns the witness size estimates of the at most count most
+// recently processed blocks, oldest first. Nil is returned if the estimation
+// is disabled.
+func (bc *BlockChain) WitnessStats(count int) []WitnessStats {
+	w := bc.witnessStats
+	if w == nil {
+		return nil
+	}
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	if count > len(w.stats) {
+		count = len(w.stats)
+	}
+	stats := make([]WitnessStats, 0, count)
+	for i := len(w.stats) - count; i < len(w.stats); i++ {
+		// Once the buffer is full, the oldest entry is the next overwritten
+		stats = append(stats, w.stats[(w.next+i)%len(w.stats)])
+	}
+	return stats
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// Tests that the witness size of the imported blocks is estimated, accounting
+// the code of the called contracts and the state read through the snapshot.
+func TestWitnessStats(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr     = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.Address{0xcc}
+		reader   = common.Address{0xdd}
+		// PUSH1 0 SLOAD PUSH1 1 ADD PUSH1 0 SSTORE: increments slot 0
+		code = common.FromHex("0x600054600101600055")
+		// PUSH1 0 SLOAD POP: reads slot 0 only
+		readCode = common.FromHex("0x60005450")
+		genDb    = rawdb.NewMemoryDatabase()
+		gspec    = &Genesis{
+			Config:  params.TestChainConfig,
+			BaseFee: big.NewInt(params.InitialBaseFee),
+			Alloc: GenesisAlloc{
+				addr:     {Balance: big.NewInt(params.Ether)},
+				contract: {Code: code, Balance: common.Big0},
+				reader:   {Code: readCode, Balance: common.Big0, Storage: map[common.Hash]common.Hash{{}: {0x01}}},
+			},
+		}
+		genesis = gspec.MustCommit(genDb, trie.NewDatabase(genDb, newDbConfig(rawdb.HashScheme)))
+		signer  = types.LatestSigner(gspec.Config)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), genDb, 3, func(i int, b *BlockGen) {
+		if i == 1 {
+			return // Empty block
+		}
+		tx, err := types.SignTx(types.NewTransaction(b.TxNonce(addr), contract, nil, 50000, b.header.BaseFee, nil), signer, key)
+		if err != nil {
+			t.Fatalf("failed to sign tx: %v", err)
+		}
+		b.AddTx(tx)
+		if i == 2 {
+			tx, err = types.SignTx(types.NewTransaction(b.TxNonce(addr), reader, nil, 50000, b.header.BaseFee, nil), signer, key)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			b.AddTx(tx)
+		}
+	}, true)
+
+	// Estimation is disabled by default
+	chain, err := NewBlockChain(rawdb.NewMemoryDatabase(), nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if stats := chain.WitnessStats(10); stats != nil {
+		t.Fatalf("witness stats recorded while disabled: %v", stats)
+	}
+	chain.Stop()
+
+	cacheConfig := DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.WitnessStats = true
+
+	chain, err = NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	stats := chain.WitnessStats(10)
+	if len(stats) != len(blocks) {
+		t.Fatalf("stats count mismatch: have %d, want %d", len(stats), len(blocks))
+	}
+	for i, s := range stats {
+		if s.Number != blocks[i].NumberU64() || s.Hash != blocks[i].Hash() {
+			t.Errorf("stats %d: block mismatch: have %d %x, want %d %x", i, s.Number, s.Hash, blocks[i].NumberU64(), blocks[i].Hash())
+		}
+		if s.Nodes == 0 || s.NodeBytes == 0 {
+			t.Errorf("stats %d: no trie nodes accounted", i)
+		}
+		wantCodes, wantBytes := 1, len(code)
+		switch i {
+		case 1:
+			wantCodes, wantBytes = 0, 0
+		case 2:
+			wantCodes, wantBytes = 2, len(code)+len(readCode)
+		}
+		if s.Codes != wantCodes || s.CodeBytes != wantBytes {
+			t.Errorf("stats %d: code mismatch: have %d/%d, want %d/%d", i, s.Codes, s.CodeBytes, wantCodes, wantBytes)
+		}
+	}
+	if stats := chain.WitnessStats(1); len(stats) != 1 || stats[0].Number != blocks[len(blocks)-1].NumberU64() {
+		t.Errorf("latest stats mismatch: %v", stats)
+	}
+	// The estimates must not depend on the reads being served by the snapshot
+	cacheConfig = DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.WitnessStats = true
+	cacheConfig.SnapshotLimit = 0
+
+	nosnap, err := NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer nosnap.Stop()
+
+	if _, err := nosnap.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	for i, s := range nosnap.WitnessStats(10) {
+		if s != stats[i] {
+			t.Errorf("stats %d: snapshot dependent: have %+v, want %+v", i, stats[i], s)
+		}
+	}
+}
@@ -17,14 +17,17 @@
 package eth
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -33,9 +36,12 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
@@ -67,6 +73,60 @@ func (api *PublicEthereumAPI) Hashrate() hexutil.Uint64 {
 	return hexutil.Uint64(api.e.Miner().Hashrate())
 }
 
+// FeeChange is a material change of the base fee or a change of the gas limit
+// between a new canonical head and its parent.
+type FeeChange struct {
+	Number          hexutil.Uint64 `json:"number"`
+	Hash            common.Hash    `json:"hash"`
+	BaseFeeBefore   *hexutil.Big   `json:"baseFeeBefore"`
+	BaseFeeAfter    *hexutil.Big   `json:"baseFeeAfter"`
+	BaseFeeChange   float64        `json:"baseFeeChange"` // In percent
+	GasLimitBefore  hexutil.Uint64 `json:"gasLimitBefore"`
+	GasLimitAfter   hexutil.Uint64 `json:"gasLimitAfter"`
+	GasTargetBefore hexutil.Uint64 `json:"gasTargetBefore"`
+	GasTargetAfter  hexutil.Uint64 `json:"gasTargetAfter"`
+}
+
+// FeeChanges creates a subscription that fires for every new canonical head
+// changing the base fee by more than the configured threshold, or the gas limit.
+func (api *PublicEthereumAPI) FeeChanges(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		events := make(chan core.FeeChangeEvent, 16)
+		sub := api.e.blockchain.SubscribeFeeChangeEvent(events)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-events:
+				notifier.Notify(rpcSub.ID, &FeeChange{
+					Number:          hexutil.Uint64(ev.Header.Number.Uint64()),
+					Hash:            ev.Header.Hash(),
+					BaseFeeBefore:   (*hexutil.Big)(ev.BaseFeeBefore),
+					BaseFeeAfter:    (*hexutil.Big)(ev.BaseFeeAfter),
+					BaseFeeChange:   ev.BaseFeeChange(),
+					GasLimitBefore:  hexutil.Uint64(ev.GasLimitBefore),
+					GasLimitAfter:   hexutil.Uint64(ev.GasLimitAfter),
+					GasTargetBefore: hexutil.Uint64(ev.GasTargetBefore),
+					GasTargetAfter:  hexutil.Uint64(ev.GasTargetAfter),
+				})
+			case <-sub.Err():
+				return
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // PublicMinerAPI provides an API to control the miner.
 // It offers only methods that operate on data that pose no security risk when it is publicly accessible.
 type PublicMinerAPI struct {
@@ -130,10 +190,19 @@ func (api *PrivateMinerAPI) SetGasPrice(gasPrice hexutil.Big) bool {
 	return true
 }
 
-// SetGasLimit sets the gaslimit to target towards during mining.
-func (api *PrivateMinerAPI) SetGasLimit(gasLimit hexutil.Uint64) bool {
+// SetGasLimit sets the gaslimit to target towards during mining. Produced blocks
+// move the gas limit towards the target by at most the protocol bound per block.
+func (api *PrivateMinerAPI) SetGasLimit(gasLimit hexutil.Uint64) (bool, error) {
+	if uint64(gasLimit) < params.MinGasLimit || uint64(gasLimit) > math.MaxInt64 {
+		return false, fmt.Errorf("gas limit %d out of range [%d, %d]", gasLimit, params.MinGasLimit, uint64(math.MaxInt64))
+	}
 	api.e.Miner().SetGasCeil(uint64(gasLimit))
-	return true
+	return true, nil
+}
+
+// GasLimit returns the gaslimit targeted during mining.
+func (api *PrivateMinerAPI) GasLimit() hexutil.Uint64 {
+	return hexutil.Uint64(api.e.Miner().GasCeil())
 }
 
 // SetEtherbase sets the etherbase of the miner
@@ -280,6 +349,71 @@ func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	return true, nil
 }
 
+// TxPauseArgs are the arguments of a pause of the transactions of an account.
+type TxPauseArgs struct {
+	Sender    *bool  `json:"sender"`    // Pause the transactions sent or paid by the account, default true
+	Recipient *bool  `json:"recipient"` // Pause the transactions sent to the account, default true
+	TTL       uint64 `json:"ttl"`       // Seconds until the pause lifts, 0 if never
+	Evict     bool   `json:"evict"`     // Evict the pooled transactions matching the pause
+}
+
+// PauseTxAccount drops the transactions from or to an account arriving into the
+// transaction pool, replacing any previous pause of the account. It returns the
+// number of pooled transactions evicted.
+func (api *PrivateAdminAPI) PauseTxAccount(addr common.Address, args *TxPauseArgs) (int, error) {
+	if args == nil {
+		args = new(TxPauseArgs)
+	}
+	pause := txpool.AccountPause{Address: addr, Sender: true, Recipient: true}
+	if args.Sender != nil {
+		pause.Sender = *args.Sender
+	}
+	if args.Recipient != nil {
+		pause.Recipient = *args.Recipient
+	}
+	if !pause.Sender && !pause.Recipient {
+		return 0, errors.New("neither sender nor recipient paused")
+	}
+	if args.TTL > 0 {
+		pause.Expiry = time.Now().Add(time.Duration(args.TTL) * time.Second)
+	}
+	return api.eth.TxPool().PauseAccount(pause, args.Evict), nil
+}
+
+// ResumeTxAccount lifts the pause of the transactions of an account, reporting
+// whether there was one in force.
+func (api *PrivateAdminAPI) ResumeTxAccount(addr common.Address) bool {
+	return api.eth.TxPool().ResumeAccount(addr)
+}
+
+// PausedTxAccounts returns the pauses of the transactions of accounts in force.
+func (api *PrivateAdminAPI) PausedTxAccounts() []txpool.AccountPause {
+	return api.eth.TxPool().PausedAccounts()
+}
+
+// AddLocalAccount treats an account as local in the transaction pool, exempting
+// its transactions from the remote pricing and eviction rules. The locality is
+// persisted across restarts.
+func (api *PrivateAdminAPI) AddLocalAccount(addr common.Address) error {
+	return api.eth.TxPool().AddLocalAccount(addr)
+}
+
+// RemoveLocalAccount stops treating an account as local in the transaction pool,
+// reporting whether it was.
+func (api *PrivateAdminAPI) RemoveLocalAccount(addr common.Address) (bool, error) {
+	return api.eth.TxPool().RemoveLocalAccount(addr)
+}
+
+// ListLocalAccounts returns the accounts treated as local by the transaction
+// pool, ordered by address.
+func (api *PrivateAdminAPI) ListLocalAccounts() []common.Address {
+	accounts := api.eth.TxPool().Locals()
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+	})
+	return accounts
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -323,6 +457,91 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	return stateDb.RawDump(opts), nil
 }
 
+// ProveAncestry returns the RLP encoded headers linking the ancestor block to
+// the descendant block, ordered from the ancestor to the descendant.
+func (api *PublicDebugAPI) ProveAncestry(ancestor, descendant common.Hash) ([]hexutil.Bytes, error) {
+	proof, err := api.eth.blockchain.ProveAncestry(ancestor, descendant)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]hexutil.Bytes, len(proof.Headers))
+	for i, header := range proof.Headers {
+		blob, err := rlp.EncodeToBytes(header)
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = blob
+	}
+	return headers, nil
+}
+
+// ContractCreationResult is the creation metadata of a contract.
+type ContractCreationResult struct {
+	Creator      common.Address `json:"creator"`
+	TxHash       common.Hash    `json:"transactionHash"`
+	BlockHash    common.Hash    `json:"blockHash"`
+	BlockNumber  hexutil.Uint64 `json:"blockNumber"`
+	InitCodeHash common.Hash    `json:"initCodeHash"`
+}
+
+// GetContractCreation returns the account and transaction which deployed the
+// contract at the given address, or null if the deployment is not indexed.
+func (api *PublicDebugAPI) GetContractCreation(addr common.Address) (*ContractCreationResult, error) {
+	if !api.eth.config.ContractCreationIndex {
+		return nil, errors.New("contract creation index is disabled")
+	}
+	creation := api.eth.blockchain.GetContractCreation(addr)
+	if creation == nil {
+		return nil, nil
+	}
+	return &ContractCreationResult{
+		Creator:      creation.Creator,
+		TxHash:       creation.TxHash,
+		BlockHash:    creation.BlockHash,
+		BlockNumber:  hexutil.Uint64(creation.BlockNumber),
+		InitCodeHash: creation.InitCodeHash,
+	}, nil
+}
+
+// FinalizedCheckpoint returns the header of the latest finalized block, whose
+// state gets pinned to be exported via ExportAccountRange and ExportStorageRange.
+func (api *PublicDebugAPI) FinalizedCheckpoint() (*types.Header, error) {
+	return api.eth.blockchain.FinalizedCheckpoint()
+}
+
+// GetImportAck retrieves the signed acknowledgment of the local node importing
+// the block with the given hash and making it canonical.
+func (api *PublicDebugAPI) GetImportAck(hash common.Hash) (*core.ImportAck, error) {
+	return api.eth.blockchain.GetImportAck(hash)
+}
+
+// GetBlockWitness retrieves the execution witness collected while importing the
+// block with the given hash, or nil if it isn't kept anymore.
+func (api *PublicDebugAPI) GetBlockWitness(hash common.Hash) (*types.Witness, error) {
+	if api.eth.config.WitnessCache == 0 {
+		return nil, errors.New("witness collection is disabled")
+	}
+	return api.eth.blockchain.GetBlockWitness(hash), nil
+}
+
+// ExportAccountRange returns a proven range of accounts from the state of a
+// finalized checkpoint, starting at the given account hash.
+func (api *PublicDebugAPI) ExportAccountRange(root common.Hash, origin common.Hash, maxResults int) (*core.StateRange, error) {
+	if maxResults <= 0 || maxResults > AccountRangeMaxResults {
+		maxResults = AccountRangeMaxResults
+	}
+	return api.eth.blockchain.ExportAccountRange(root, origin, maxResults)
+}
+
+// ExportStorageRange returns a proven range of storage slots of an account from
+// the state of a finalized checkpoint, starting at the given slot hash.
+func (api *PublicDebugAPI) ExportStorageRange(root common.Hash, account common.Hash, origin common.Hash, maxResults int) (*core.StateRange, error) {
+	if maxResults <= 0 || maxResults > AccountRangeMaxResults {
+		maxResults = AccountRangeMaxResults
+	}
+	return api.eth.blockchain.ExportStorageRange(root, account, origin, maxResults)
+}
+
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -380,6 +599,28 @@ func (api *PrivateDebugAPI) GetBadBlocks(ctx context.Context) ([]*BadBlockArgs,
 	return results, nil
 }
 
+// GetBlockHashes returns the hashes of all the known blocks at the given height,
+// canonical and side chains alike.
+func (api *PrivateDebugAPI) GetBlockHashes(number hexutil.Uint64) []common.Hash {
+	return rawdb.ReadBlockHashes(api.eth.chainDb, uint64(number))
+}
+
+// GetBlobSidecarsStatus returns the verification status of the sidecars of a
+// block imported along with its receipts: "unverified", "verified" or "expired".
+func (api *PrivateDebugAPI) GetBlobSidecarsStatus(hash common.Hash) (string, error) {
+	if api.eth.blockchain.GetHeaderByHash(hash) == nil {
+		return "", fmt.Errorf("block %#x not found", hash)
+	}
+	switch api.eth.blockchain.GetBlobSidecarsStatus(hash) {
+	case rawdb.BlobSidecarsVerified:
+		return "verified", nil
+	case rawdb.BlobSidecarsExpired:
+		return "expired", nil
+	default:
+		return "unverified", nil
+	}
+}
+
 // AccountRangeMaxResults is the maximum number of results to be returned per call
 const AccountRangeMaxResults = 256
 
@@ -592,6 +833,259 @@ func (api *PrivateDebugAPI) getModifiedAccounts(startBlock, endBlock *types.Bloc
 	return dirty, nil
 }
 
+// PinState keeps the state of the canonical block with the given number
+// available until released via UnpinState, returning the pinned header.
+func (api *PrivateDebugAPI) PinState(number rpc.BlockNumber) (*types.Header, error) {
+	if number < 0 {
+		number = rpc.BlockNumber(api.eth.blockchain.CurrentBlock().NumberU64())
+	}
+	return api.eth.blockchain.PinStateAtNumber(uint64(number))
+}
+
+// UnpinState releases a pin held on the state with the given root.
+func (api *PrivateDebugAPI) UnpinState(root common.Hash) error {
+	return api.eth.blockchain.UnpinState(root)
+}
+
+// WitnessStats returns the execution witness size estimates of the at most count
+// most recently processed blocks, oldest first.
+func (api *PrivateDebugAPI) WitnessStats(count int) ([]core.WitnessStats, error) {
+	stats := api.eth.blockchain.WitnessStats(count)
+	if stats == nil {
+		return nil, errors.New("witness size estimation disabled")
+	}
+	return stats, nil
+}
+
+// TraceHashes returns the digests of the execution of the transactions of a
+// recently processed block, to be compared with those of another node.
+func (api *PrivateDebugAPI) TraceHashes(blockNrOrHash rpc.BlockNumberOrHash) (*core.TraceHashes, error) {
+	if !api.eth.blockchain.TraceHashesEnabled() {
+		return nil, errors.New("trace hashing disabled")
+	}
+	var header *types.Header
+	if number, ok := blockNrOrHash.Number(); ok {
+		switch number {
+		case rpc.PendingBlockNumber:
+			return nil, errors.New("pending block not processed")
+		case rpc.LatestBlockNumber:
+			header = api.eth.blockchain.CurrentHeader()
+		case rpc.FinalizedBlockNumber:
+			header = api.eth.blockchain.CurrentFinalBlock()
+		default:
+			header = api.eth.blockchain.GetHeaderByNumber(uint64(number))
+		}
+	} else if hash, ok := blockNrOrHash.Hash(); ok {
+		header = api.eth.blockchain.GetHeaderByHash(hash)
+	}
+	if header == nil {
+		return nil, errors.New("block not found")
+	}
+	hashes := api.eth.blockchain.TraceHashes(header.Hash())
+	if hashes == nil {
+		return nil, fmt.Errorf("block #%d not processed recently", header.Number)
+	}
+	return hashes, nil
+}
+
+// PruneRange deletes the given classes of block data, "bodies" and "receipts",
+// of the canonical blocks from (inclusive) to to (inclusive). The range must be
+// finalized and not frozen yet.
+func (api *PrivateDebugAPI) PruneRange(from, to uint64, classes []string) error {
+	set, err := core.ParseHistoryClasses(classes)
+	if err != nil {
+		return err
+	}
+	return api.eth.blockchain.PruneRange(from, to, set)
+}
+
+// PruneRangeForecast computes what PruneRange would release for the given
+// classes of block data of the range, and which queries it would break, without
+// pruning anything.
+func (api *PrivateDebugAPI) PruneRangeForecast(from, to uint64, classes []string) (*core.PruneForecast, error) {
+	set, err := core.ParseHistoryClasses(classes)
+	if err != nil {
+		return nil, err
+	}
+	return api.eth.blockchain.PruneRangeForecast(from, to, set)
+}
+
+// HistoryPruneForecast computes what pruning the history beyond a retention
+// window of the given number of blocks, the configured one if zero, would
+// release at the current head, and which queries it would break, without
+// pruning anything.
+func (api *PrivateDebugAPI) HistoryPruneForecast(retain uint64) (*core.PruneForecast, error) {
+	return api.eth.blockchain.HistoryPruneForecast(retain)
+}
+
+// BackfillReceiptBlooms rewrites the receipts of the canonical blocks from
+// (inclusive) to to (inclusive) left in a legacy layout, possibly without their
+// blooms, into the current one, returning the number of blocks rewritten.
+func (api *PrivateDebugAPI) BackfillReceiptBlooms(from, to uint64) (uint64, error) {
+	return api.eth.blockchain.BackfillReceiptBlooms(from, to)
+}
+
+// BackupFreezer creates a consistent copy of the chain freezer in the given
+// directory, which must be empty or not exist yet, for restoring it offline with
+// the "db freezer-restore" command.
+func (api *PrivateDebugAPI) BackupFreezer(dir string) (*rawdb.FreezerBackup, error) {
+	return rawdb.BackupFreezer(api.eth.ChainDb(), dir)
+}
+
+// SstoreStats returns the SSTORE counters by slot transition of the at most count
+// most recently processed blocks, oldest first.
+func (api *PrivateDebugAPI) SstoreStats(count int) ([]core.SstoreStats, error) {
+	stats := api.eth.blockchain.SstoreStats(count)
+	if stats == nil {
+		return nil, errors.New("sstore instrumentation disabled")
+	}
+	return stats, nil
+}
+
+// BlockDelayStats returns the rolling statistics of the arrival delays of the
+// recent blocks of each validator, compared to their expected sealing times.
+func (api *PrivateDebugAPI) BlockDelayStats() []core.BlockDelayStats {
+	return api.eth.blockchain.BlockDelayStats()
+}
+
+// TxSLAStats returns the inclusion latency statistics of the local transactions
+// submitted through the node, along with their SLA breaches.
+func (api *PrivateDebugAPI) TxSLAStats() (*core.TxSLAStats, error) {
+	stats := api.eth.blockchain.TxSLAStats()
+	if stats == nil {
+		return nil, errors.New("transaction inclusion SLA disabled")
+	}
+	return stats, nil
+}
+
+// StartSchemeMigration starts migrating the hash-based state into the path-based
+// layout in the background. The node switches to the path scheme in place once
+// the migration completes, deleting the legacy hash-based nodes afterwards.
+func (api *PrivateDebugAPI) StartSchemeMigration() error {
+	return api.eth.blockchain.StartSchemeMigration()
+}
+
+// SchemeMigrationStatus returns the progress of the running scheme migration,
+// or nil if there is none.
+func (api *PrivateDebugAPI) SchemeMigrationStatus() *core.SchemeMigrationStatus {
+	return api.eth.blockchain.SchemeMigrationStatus()
+}
+
+// ChainAuditEntry is an entry of the chain mutation audit log.
+type ChainAuditEntry struct {
+	Seq       hexutil.Uint64 `json:"seq"`       // Position of the entry in the log
+	Time      hexutil.Uint64 `json:"time"`      // Unix time of the mutation
+	Kind      string         `json:"kind"`      // Kind of the mutation
+	Actor     string         `json:"actor"`     // Originator of the mutation
+	Context   string         `json:"context"`   // Details of the mutation
+	Number    hexutil.Uint64 `json:"number"`    // Number of the head block after the mutation
+	Hash      common.Hash    `json:"hash"`      // Hash of the head block after the mutation
+	Prev      common.Hash    `json:"prev"`      // Hash of the previous entry
+	EntryHash common.Hash    `json:"entryHash"` // Hash of this entry, chained by the next one
+}
+
+// ChainAuditLog returns at most count entries of the chain mutation audit log
+// starting at the given position, or at the oldest entry retained.
+func (api *PrivateDebugAPI) ChainAuditLog(from hexutil.Uint64, count hexutil.Uint64) []*ChainAuditEntry {
+	entries, hashes := api.eth.blockchain.ChainAuditLog(uint64(from), uint64(count))
+
+	result := make([]*ChainAuditEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = &ChainAuditEntry{
+			Seq:       hexutil.Uint64(entry.Seq),
+			Time:      hexutil.Uint64(entry.Time),
+			Kind:      entry.Kind,
+			Actor:     entry.Actor,
+			Context:   entry.Context,
+			Number:    hexutil.Uint64(entry.Number),
+			Hash:      entry.Hash,
+			Prev:      entry.Prev,
+			EntryHash: hashes[i],
+		}
+	}
+	return result
+}
+
+// VerifyChainAuditLog checks the integrity of the retained chain mutation audit
+// log, returning the number of entries verified.
+func (api *PrivateDebugAPI) VerifyChainAuditLog() (hexutil.Uint64, error) {
+	n, err := api.eth.blockchain.VerifyChainAuditLog()
+	return hexutil.Uint64(n), err
+}
+
+// VerifiedBlock is the outcome of verifying a block without importing it.
+type VerifiedBlock struct {
+	Number   hexutil.Uint64 `json:"number"`
+	Hash     common.Hash    `json:"hash"`
+	Known    bool           `json:"known"`           // Whether the block is already part of the local chain
+	GasUsed  hexutil.Uint64 `json:"gasUsed"`         // Gas used by executing the block locally
+	Receipts types.Receipts `json:"receipts"`        // Receipts produced by executing the block locally
+	Elapsed  string         `json:"elapsed"`         // Time spent on verifying and executing the block
+	Error    string         `json:"error,omitempty"` // Reason of the verification failure
+}
+
+// VerifyBlocks fully validates and executes the given contiguous RLP encoded
+// blocks on top of the local chain without importing them, so that relays can
+// check the blocks before propagating them.
+func (api *PrivateDebugAPI) VerifyBlocks(blobs []hexutil.Bytes) ([]*VerifiedBlock, error) {
+	blocks := make(types.Blocks, len(blobs))
+	for i, blob := range blobs {
+		blocks[i] = new(types.Block)
+		if err := rlp.DecodeBytes(blob, blocks[i]); err != nil {
+			return nil, fmt.Errorf("could not decode block %d: %v", i, err)
+		}
+	}
+	results, err := api.eth.blockchain.VerifyChainSegment(blocks, nil)
+	if err != nil {
+		return nil, err
+	}
+	verified := make([]*VerifiedBlock, len(results))
+	for i, result := range results {
+		verified[i] = &VerifiedBlock{
+			Number:   hexutil.Uint64(result.Number),
+			Hash:     result.Hash,
+			Known:    result.Known,
+			GasUsed:  hexutil.Uint64(result.GasUsed),
+			Receipts: result.Receipts,
+			Elapsed:  result.Elapsed.String(),
+		}
+		if result.Err != nil {
+			verified[i].Error = result.Err.Error()
+		}
+	}
+	return verified, nil
+}
+
+// TransactionRefunds is the gas refund accounting of an executed transaction.
+type TransactionRefunds struct {
+	GasUsed       hexutil.Uint64     `json:"gasUsed"`       // Gas used, after the refund
+	RefundCounter hexutil.Uint64     `json:"refundCounter"` // Refund counter accrued during execution
+	RefundCap     hexutil.Uint64     `json:"refundCap"`     // Maximum gas refundable under the fork rules
+	Refunded      hexutil.Uint64     `json:"refunded"`      // Gas actually refunded
+	Breakdown     vm.RefundBreakdown `json:"breakdown"`     // Refund counter by accruing operation
+}
+
+// GetTransactionRefunds re-executes the transaction with the given hash and
+// returns how its gas refund was accrued and capped.
+func (api *PrivateDebugAPI) GetTransactionRefunds(hash common.Hash) (*TransactionRefunds, error) {
+	tx, blockHash, _, index := rawdb.ReadTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %#x not found", hash)
+	}
+	replay, err := api.eth.blockchain.ReplayTransaction(blockHash, int(index), &core.ReplayOptions{DisableCode: true, DisableStorage: true})
+	if err != nil {
+		return nil, err
+	}
+	result := replay.Result
+	return &TransactionRefunds{
+		GasUsed:       hexutil.Uint64(result.UsedGas),
+		RefundCounter: hexutil.Uint64(result.RefundCounter),
+		RefundCap:     hexutil.Uint64(result.RefundCap),
+		Refunded:      hexutil.Uint64(result.RefundedGas),
+		Breakdown:     result.Refunds,
+	}, nil
+}
+
 // GetAccessibleState returns the first number where the node has accessible
 // state on disk. Note this being the post-state of that block and the pre-state
 // of the next block.
@@ -63,7 +63,7 @@ func (b *EthAPIBackend) CurrentBlock() *types.Block {
 
 func (b *EthAPIBackend) SetHead(number uint64) {
 	b.eth.handler.downloader.Cancel()
-	b.eth.blockchain.SetHead(number)
+	b.eth.blockchain.SetHeadAs(number, "rpc", "debug_setHead")
 }
 
 func (b *EthAPIBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
@@ -122,11 +122,26 @@ func (b *EthAPIBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumbe
 	if number == rpc.FinalizedBlockNumber {
 		return b.eth.blockchain.FinalizedBlock(), nil
 	}
-	return b.eth.blockchain.GetBlockByNumber(uint64(number)), nil
+	if block := b.eth.blockchain.GetBlockByNumber(uint64(number)); block != nil {
+		return block, nil
+	}
+	return nil, b.eth.blockchain.HistoryPruned(uint64(number))
 }
 
 func (b *EthAPIBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
-	return b.eth.blockchain.GetBlockByHash(hash), nil
+	if block := b.eth.blockchain.GetBlockByHash(hash); block != nil {
+		return block, nil
+	}
+	return nil, b.historyPruned(hash)
+}
+
+// historyPruned returns a HistoryPrunedError if the block of the given hash is
+// known but its body and receipts were pruned, nil otherwise.
+func (b *EthAPIBackend) historyPruned(hash common.Hash) error {
+	if header := b.eth.blockchain.GetHeaderByHash(hash); header != nil {
+		return b.eth.blockchain.HistoryPruned(header.Number.Uint64())
+	}
+	return nil
 }
 
 func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*types.Block, error) {
@@ -143,6 +158,9 @@ func (b *EthAPIBackend) BlockByNumberOrHash(ctx context.Context, blockNrOrHash r
 		}
 		block := b.eth.blockchain.GetBlock(hash, header.Number.Uint64())
 		if block == nil {
+			if err := b.eth.blockchain.HistoryPruned(header.Number.Uint64()); err != nil {
+				return nil, err
+			}
 			return nil, errors.New("header found, but block body is missing")
 		}
 		return block, nil
@@ -200,7 +218,23 @@ func (b *EthAPIBackend) StateAndHeaderByNumberOrHash(ctx context.Context, blockN
 }
 
 func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
-	return b.eth.blockchain.GetReceiptsByHash(hash), nil
+	if receipts := b.eth.blockchain.GetReceiptsByHash(hash); receipts != nil {
+		return receipts, nil
+	}
+	return nil, b.historyPruned(hash)
+}
+
+// GetReceipt retrieves the receipt of the transaction at the given index of a
+// block, resolved through the receipt index if it covers the transaction.
+func (b *EthAPIBackend) GetReceipt(ctx context.Context, txHash common.Hash, blockHash common.Hash, index uint64) (*types.Receipt, error) {
+	if receipt, hash, _, i := b.eth.blockchain.GetCanonicalReceipt(txHash); receipt != nil && hash == blockHash && i == index {
+		return receipt, nil
+	}
+	receipts, err := b.GetReceipts(ctx, blockHash)
+	if err != nil || len(receipts) <= int(index) {
+		return nil, err
+	}
+	return receipts[index], nil
 }
 
 func (b *EthAPIBackend) BlobSidecarsByNumber(ctx context.Context, number rpc.BlockNumber) (types.BlobSidecars, error) {
@@ -305,7 +339,11 @@ func (b *EthAPIBackend) SubscribeDirtyAccountEvent(ch chan<- []*types.DirtyState
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	return b.eth.txPool.Add([]*types.Transaction{signedTx}, true, false)[0]
+	if err := b.eth.txPool.Add([]*types.Transaction{signedTx}, true, false)[0]; err != nil {
+		return err
+	}
+	b.eth.blockchain.TrackLocalTxs([]*types.Transaction{signedTx})
+	return nil
 }
 
 func (b *EthAPIBackend) GetPoolTransactions() (types.Transactions, error) {
@@ -332,6 +370,14 @@ func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (
 	return b.eth.txPool.Nonce(addr), nil
 }
 
+func (b *EthAPIBackend) ReserveNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return b.eth.txPool.ReserveNonce(addr)
+}
+
+func (b *EthAPIBackend) ReleaseNonce(ctx context.Context, addr common.Address, nonce uint64) error {
+	return b.eth.txPool.ReleaseNonce(addr, nonce)
+}
+
 func (b *EthAPIBackend) Stats() (pending int, queued int) {
 	return b.eth.txPool.Stats()
 }
@@ -344,6 +390,10 @@ func (b *EthAPIBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transac
 	return b.eth.TxPool().ContentFrom(addr)
 }
 
+func (b *EthAPIBackend) TxPoolRejections() []txpool.RejectionStats {
+	return b.eth.TxPool().Rejections()
+}
+
 func (b *EthAPIBackend) TxPool() *txpool.TxPool {
 	return b.eth.TxPool()
 }
@@ -399,6 +449,10 @@ func (b *EthAPIBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *EthAPIBackend) RPCEVMStepLimit() uint64 {
+	return b.eth.config.RPCEVMStepLimit
+}
+
 func (b *EthAPIBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
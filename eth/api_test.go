@@ -18,18 +18,25 @@ package eth
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math/big"
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/ethereum/go-ethereum/trie"
 )
 
@@ -225,3 +232,72 @@ func TestStorageRangeAt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the fee changes of the new canonical heads are delivered to the
+// subscribers.
+func TestFeeChangesSubscription(t *testing.T) {
+	var (
+		key, _ = crypto.GenerateKey()
+		addr   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig
+		gspec  = &core.Genesis{
+			Config:   &config,
+			GasLimit: 100000,
+			BaseFee:  big.NewInt(params.InitialBaseFee),
+			Alloc:    core.GenesisAlloc{addr: {Balance: big.NewInt(params.Ether)}},
+		}
+		signer = types.LatestSigner(&config)
+	)
+	config.VenokiBlock = common.Big0
+
+	// Fill the first block above its gas target, bumping the base fee of the
+	// second one
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, b *core.BlockGen) {
+		if i == 0 {
+			for nonce := uint64(0); nonce < 4; nonce++ {
+				tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, b.BaseFee(), nil), signer, key)
+				b.AddTx(tx)
+			}
+		}
+	})
+	cacheConfig := core.DefaultCacheConfigWithScheme(rawdb.HashScheme)
+	cacheConfig.FeeChangeThreshold = 1
+
+	chain, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), cacheConfig, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", NewPublicEthereumAPI(&Ethereum{blockchain: chain})); err != nil {
+		t.Fatalf("failed to register api: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	changes := make(chan *FeeChange, 4)
+	sub, err := client.EthSubscribe(context.Background(), changes, "feeChanges")
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	if _, err := chain.InsertChain(blocks, nil); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	select {
+	case change := <-changes:
+		if change.Hash != blocks[1].Hash() {
+			t.Fatalf("fee change block mismatch: have %x, want %x", change.Hash, blocks[1].Hash())
+		}
+		if change.BaseFeeChange <= 1 || change.BaseFeeBefore.ToInt().Cmp(blocks[0].BaseFee()) != 0 || change.BaseFeeAfter.ToInt().Cmp(blocks[1].BaseFee()) != 0 {
+			t.Fatalf("base fee change mismatch: have %v->%v (%.2f%%), want %v->%v", change.BaseFeeBefore, change.BaseFeeAfter, change.BaseFeeChange, blocks[0].BaseFee(), blocks[1].BaseFee())
+		}
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(time.Second):
+		t.Fatalf("fee change not delivered")
+	}
+}
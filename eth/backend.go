@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math/big"
 	"runtime"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -84,6 +85,8 @@ type Ethereum struct {
 	// DB interfaces
 	chainDb ethdb.Database // Block chain database
 
+	jumpDestCache *vm.DiskJumpDestCache // Journaled JUMPDEST analysis cache (optional)
+
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
 	accountManager *accounts.Manager
@@ -196,6 +199,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		cacheConfig = &core.CacheConfig{
 			TrieCleanLimit:      config.TrieCleanCache,
 			TrieCleanNoPrefetch: config.NoPrefetch,
+			HotContracts:        config.HotContracts,
 			TrieDirtyLimit:      config.TrieDirtyCache,
 			TrieDirtyDisabled:   config.NoPruning,
 			TrieTimeLimit:       config.TrieTimeout,
@@ -203,10 +207,43 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			Preimages:           config.Preimages,
 			TriesInMemory:       config.TriesInMemory,
 			NoPruningSideCar:    config.NoPruningSideCar,
+			SideCarRetention:    config.SideCarRetention,
 			StateHistory:        config.StateHistory,
 			StateScheme:         config.StateScheme,
+			ReorgProtectWindow:  config.ReorgProtectWindow,
+			MaxReorgDepth:       config.MaxReorgDepth,
+
+			ContractCreationIndex:  config.ContractCreationIndex,
+			ReceiptIndex:           config.ReceiptIndex,
+			SnapshotVerifyInterval: config.SnapshotVerifyInterval,
+			SnapshotAccountRatio:   config.SnapshotAccountRatio,
+			SnapshotRebalance:      config.SnapshotRebalance,
+			RecentStates:           config.RecentStates,
+			SchemeMigration:        config.StateMigration,
+			StateSchemePinned:      config.StateSchemePinned,
+			ChainAudit:             config.ChainAudit,
+			WitnessStats:           config.WitnessStats,
+			WitnessCache:           config.WitnessCache,
+			SupplyCheck:            config.SupplyCheck,
+			SupplyBridges:          config.SupplyBridges,
+			SstoreStats:            config.SstoreStats,
+			TraceHashes:            config.TraceHashes,
+			ParallelWorkers:        config.ParallelWorkers,
+			DuplicateTxWindow:      config.DuplicateTxWindow,
+			BlockDelayThreshold:    config.BlockDelayThreshold,
+			TxInclusionSLA:         config.TxInclusionSLA,
+			CompactionInterval:     config.CompactionInterval,
+			FeeChangeThreshold:     config.FeeChangeThreshold,
+			HistoryPrune:           config.HistoryPrune,
 		}
 	)
+	if config.JumpDestCacheJournal != "" {
+		eth.jumpDestCache, err = vm.NewDiskJumpDestCache(stack.ResolvePath(config.JumpDestCacheJournal), config.JumpDestCacheSize, config.JumpDestCacheRejournal)
+		if err != nil {
+			return nil, err
+		}
+		vmConfig.JumpDestCache = eth.jumpDestCache
+	}
 	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, config.Genesis, config.OverrideArrowGlacier, eth.engine, vmConfig, eth.shouldPreserve, &config.TransactionHistory)
 	if err != nil {
 		return nil, err
@@ -235,12 +272,36 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = stack.ResolvePath(config.TxPool.Journal)
 	}
+	if config.TxPool.LocalsJournal != "" {
+		config.TxPool.LocalsJournal = stack.ResolvePath(config.TxPool.LocalsJournal)
+	}
+	if config.TxPool.MinedJournal != "" {
+		config.TxPool.MinedJournal = stack.ResolvePath(config.TxPool.MinedJournal)
+	}
+	if config.TxPool.Snapshot != "" {
+		config.TxPool.Snapshot = stack.ResolvePath(config.TxPool.Snapshot)
+	}
 	legacyPool := legacypool.New(config.TxPool, eth.blockchain.Config(), eth.blockchain)
 
 	eth.txPool, err = txpool.New(config.TxPool.PriceLimit, eth.blockchain, []txpool.SubPool{legacyPool, blobPool})
 	if err != nil {
 		return nil, err
 	}
+	eth.txPool.SetChurnLimit(config.TxPool.ChurnLimit, config.TxPool.ChurnBurst)
+	if len(config.TxPool.SponsorWhitelist) > 0 {
+		eth.txPool.SetSponsorHook(config.TxPool.SponsorWhitelist.Hook(), 0, 0)
+	}
+	if forcer, ok := eth.engine.(consensus.ForcedTransactor); ok {
+		eth.txPool.SetForcedFilter(func(tx *types.Transaction) bool {
+			return forcer.IsForcedTransaction(tx, eth.blockchain.CurrentHeader())
+		})
+	}
+	// Reuse the senders recovered on pool admission when importing blocks
+	eth.blockchain.SetSenderSource(eth.txPool.Get)
+	// Warm up the state touched by the pending transactions when importing blocks
+	eth.blockchain.SetPendingSource(pendingSource(eth.txPool))
+	// Detect the local transactions dropped from the pool without inclusion
+	eth.blockchain.SetTxPoolSource(eth.txPool.Has)
 
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := cacheConfig.TrieCleanLimit + cacheConfig.TrieDirtyLimit + cacheConfig.SnapshotLimit
@@ -604,6 +665,16 @@ func (s *Ethereum) StartMining(threads int) error {
 			}
 			consortium.Authorize(eb, wallet.SignData, wallet.SignTx)
 		}
+		if s.config.ImportAcks {
+			wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+			if wallet == nil || err != nil {
+				log.Error("Etherbase account unavailable locally", "err", err)
+				return fmt.Errorf("signer missing: %v", err)
+			}
+			s.blockchain.SetImportSigner(eb, func(data []byte) ([]byte, error) {
+				return wallet.SignData(accounts.Account{Address: eb}, accounts.MimetypeTextPlain, data)
+			})
+		}
 		// If mining is started, we can disable the transaction rejection mechanism
 		// introduced to speed sync times.
 		s.handler.enableSyncedFeatures()
@@ -647,6 +718,11 @@ func (s *Ethereum) BloomIndexer() *core.ChainIndexer   { return s.bloomIndexer }
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
 	protos := eth.MakeProtocols((*ethHandler)(s.handler), s.networkID, s.ethDialCandidates)
+	if !s.config.BloomlessReceipts {
+		protos = slices.DeleteFunc(protos, func(proto p2p.Protocol) bool {
+			return proto.Name == eth.ProtocolName && proto.Version == eth.ETH101
+		})
+	}
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler), s.snapDialCandidates)...)
 	}
@@ -693,6 +769,11 @@ func (s *Ethereum) Stop() error {
 	s.txPool.Close()
 	s.miner.Close()
 	s.blockchain.Stop()
+	if s.jumpDestCache != nil {
+		if err := s.jumpDestCache.Close(); err != nil {
+			log.Warn("Failed to close jumpdest cache", "err", err)
+		}
+	}
 	s.engine.Close()
 	rawdb.PopUncleanShutdownMarker(s.chainDb)
 	s.chainDb.Close()
@@ -700,3 +781,23 @@ func (s *Ethereum) Stop() error {
 
 	return nil
 }
+
+// pendingSource returns a source of the executable transactions of the pool,
+// grouped by sender, resolving up to the requested number of them.
+func pendingSource(pool *txpool.TxPool) func(int) map[common.Address][]*types.Transaction {
+	return func(limit int) map[common.Address][]*types.Transaction {
+		txs := make(map[common.Address][]*types.Transaction)
+		for addr, lazies := range pool.Pending(&txpool.PendingFilter{OnlyPlainTxs: true}) {
+			for _, lazy := range lazies {
+				if limit <= 0 {
+					return txs
+				}
+				if tx := lazy.Resolve(); tx != nil {
+					txs[addr] = append(txs[addr], tx)
+					limit--
+				}
+			}
+		}
+		return txs
+	}
+}
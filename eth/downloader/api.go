@@ -19,12 +19,17 @@ package downloader
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// syncProgressInterval is the interval at which the progress of a running sync
+// is broadcast to the syncing subscriptions.
+const syncProgressInterval = 8 * time.Second
+
 // PublicDownloaderAPI provides an API which gives information about the current synchronisation status.
 // It offers only methods that operates on data that can be available to anyone without security risks.
 type PublicDownloaderAPI struct {
@@ -53,14 +58,29 @@ func NewPublicDownloaderAPI(d *Downloader, m *event.TypeMux) *PublicDownloaderAP
 
 // eventLoop runs a loop until the event mux closes. It will install and uninstall new
 // sync subscriptions and broadcasts sync status updates to the installed sync subscriptions.
+// While a sync is running, its progress is also broadcast periodically.
 func (api *PublicDownloaderAPI) eventLoop() {
 	var (
 		sub               = api.mux.Subscribe(StartEvent{}, DoneEvent{}, FailedEvent{})
 		syncSubscriptions = make(map[chan interface{}]struct{})
+		progressTicker    = time.NewTicker(syncProgressInterval)
+		syncing           bool
 	)
+	defer progressTicker.Stop()
 
 	for {
 		select {
+		case <-progressTicker.C:
+			if !syncing {
+				continue
+			}
+			notification := &SyncingResult{
+				Syncing: true,
+				Status:  api.d.Progress(),
+			}
+			for c := range syncSubscriptions {
+				c <- notification
+			}
 		case i := <-api.installSyncSubscription:
 			syncSubscriptions[i] = struct{}{}
 		case u := <-api.uninstallSyncSubscription:
@@ -74,11 +94,13 @@ func (api *PublicDownloaderAPI) eventLoop() {
 			var notification interface{}
 			switch event.Data.(type) {
 			case StartEvent:
+				syncing = true
 				notification = &SyncingResult{
 					Syncing: true,
 					Status:  api.d.Progress(),
 				}
 			case DoneEvent, FailedEvent:
+				syncing = false
 				notification = false
 			}
 			// broadcast
@@ -198,7 +198,7 @@ type BlockChain interface {
 	InsertChain(types.Blocks, [][]*types.BlobTxSidecar) (int, error)
 
 	// InsertReceiptChain inserts a batch of receipts into the local chain.
-	InsertReceiptChain(types.Blocks, []types.Receipts, uint64) (int, error)
+	InsertReceiptChain(types.Blocks, []types.Receipts, [][]*types.BlobTxSidecar, uint64) (int, error)
 
 	// Snapshots returns the blockchain snapshot tree to paused it during sync.
 	Snapshots() *snapshot.Tree
@@ -269,13 +269,30 @@ func (d *Downloader) Progress() ethereum.SyncProgress {
 	default:
 		log.Error("Unknown downloader chain/mode combo", "light", d.lightchain != nil, "full", d.blockchain != nil, "mode", mode)
 	}
-	return ethereum.SyncProgress{
+	progress := ethereum.SyncProgress{
 		StartingBlock: d.syncStatsChainOrigin,
 		CurrentBlock:  current,
 		HighestBlock:  d.syncStatsChainHeight,
 		PulledStates:  d.syncStatsState.processed,
 		KnownStates:   d.syncStatsState.processed + d.syncStatsState.pending,
 	}
+	if d.SnapSyncer != nil {
+		snap := d.SnapSyncer.Progress()
+
+		progress.SyncedAccounts = snap.AccountSynced
+		progress.SyncedAccountBytes = uint64(snap.AccountBytes)
+		progress.SyncedBytecodes = snap.BytecodeSynced
+		progress.SyncedBytecodeBytes = uint64(snap.BytecodeBytes)
+		progress.SyncedStorage = snap.StorageSynced
+		progress.SyncedStorageBytes = uint64(snap.StorageBytes)
+		progress.HealedTrienodes = snap.TrienodeHealSynced
+		progress.HealedTrienodeBytes = uint64(snap.TrienodeHealBytes)
+		progress.HealedBytecodes = snap.BytecodeHealSynced
+		progress.HealedBytecodeBytes = uint64(snap.BytecodeHealBytes)
+		progress.HealingTrienodes = snap.TrienodeHealPending
+		progress.HealingBytecode = snap.BytecodeHealPending
+	}
+	return progress
 }
 
 // Synchronising returns whether the downloader is currently retrieving blocks.
@@ -1288,7 +1305,7 @@ func (d *Downloader) fetchBodies(from uint64) error {
 	var (
 		deliver = func(packet dataPack) (int, error) {
 			pack := packet.(*bodyPack)
-			return d.queue.DeliverBodies(pack.peerID, pack.transactions, pack.uncles, pack.sidecars)
+			return d.queue.DeliverBodies(pack.peerID, pack.transactions, pack.uncles, pack.withdrawals, pack.deposits, pack.sidecars)
 		}
 		expire   = func() map[string]int { return d.queue.ExpireBodies(d.peers.rates.TargetTimeout()) }
 		fetch    = func(p *peerConnection, req *fetchRequest) error { return p.FetchBodies(req) }
@@ -1738,7 +1755,7 @@ func (d *Downloader) importBlockResults(results []*fetchResult) error {
 	blocks := make([]*types.Block, len(results))
 	sidecars := make([][]*types.BlobTxSidecar, len(results))
 	for i, result := range results {
-		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
+		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles).WithOperations(result.Withdrawals, result.Deposits)
 		if d.verifyBlobHeader != nil {
 			if err := d.verifyBlobHeader(blocks[i], &result.Sidecars); err != nil {
 				return fmt.Errorf("%w: %v", errInvalidBody, err)
@@ -1945,11 +1962,13 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 	)
 	blocks := make([]*types.Block, len(results))
 	receipts := make([]types.Receipts, len(results))
+	sidecars := make([][]*types.BlobTxSidecar, len(results))
 	for i, result := range results {
-		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
+		blocks[i] = types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles).WithOperations(result.Withdrawals, result.Deposits)
 		receipts[i] = result.Receipts
+		sidecars[i] = result.Sidecars
 	}
-	if index, err := d.blockchain.InsertReceiptChain(blocks, receipts, d.ancientLimit); err != nil {
+	if index, err := d.blockchain.InsertReceiptChain(blocks, receipts, sidecars, d.ancientLimit); err != nil {
 		log.Debug("Downloaded item processing failed", "number", results[index].Header.Number, "hash", results[index].Header.Hash(), "err", err)
 		return fmt.Errorf("%w: %v", errInvalidChain, err)
 	}
@@ -1957,11 +1976,11 @@ func (d *Downloader) commitFastSyncData(results []*fetchResult, stateSync *state
 }
 
 func (d *Downloader) commitPivotBlock(result *fetchResult) error {
-	block := types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles)
+	block := types.NewBlockWithHeader(result.Header).WithBody(result.Transactions, result.Uncles).WithOperations(result.Withdrawals, result.Deposits)
 	log.Debug("Committing fast sync pivot as new head", "number", block.Number(), "hash", block.Hash())
 
 	// Commit the pivot block as the new head, will require full sync from here on
-	if _, err := d.blockchain.InsertReceiptChain([]*types.Block{block}, []types.Receipts{result.Receipts}, d.ancientLimit); err != nil {
+	if _, err := d.blockchain.InsertReceiptChain([]*types.Block{block}, []types.Receipts{result.Receipts}, [][]*types.BlobTxSidecar{result.Sidecars}, d.ancientLimit); err != nil {
 		return err
 	}
 	if err := d.blockchain.FastSyncCommitHead(block.Hash()); err != nil {
@@ -1987,8 +2006,8 @@ func (d *Downloader) DeliverHeaders(id string, headers []*types.Header) error {
 }
 
 // DeliverBodies injects a new batch of block bodies received from a remote node.
-func (d *Downloader) DeliverBodies(id string, transactions [][]*types.Transaction, uncles [][]*types.Header, sidecars [][]*types.BlobTxSidecar) error {
-	return d.deliver(d.bodyCh, &bodyPack{id, transactions, uncles, sidecars}, bodyInMeter, bodyDropMeter)
+func (d *Downloader) DeliverBodies(id string, transactions [][]*types.Transaction, uncles [][]*types.Header, withdrawals [][]*types.Withdrawal, deposits [][]*types.Deposit, sidecars [][]*types.BlobTxSidecar) error {
+	return d.deliver(d.bodyCh, &bodyPack{id, transactions, uncles, withdrawals, deposits, sidecars}, bodyInMeter, bodyDropMeter)
 }
 
 // DeliverReceipts injects a new batch of receipts received from a remote node.
@@ -318,7 +318,7 @@ func (dl *downloadTester) InsertChain(blocks types.Blocks, _ [][]*types.BlobTxSi
 }
 
 // InsertReceiptChain injects a new batch of receipts into the simulated chain.
-func (dl *downloadTester) InsertReceiptChain(blocks types.Blocks, receipts []types.Receipts, ancientLimit uint64) (i int, err error) {
+func (dl *downloadTester) InsertReceiptChain(blocks types.Blocks, receipts []types.Receipts, sidecars [][]*types.BlobTxSidecar, ancientLimit uint64) (i int, err error) {
 	dl.lock.Lock()
 	defer dl.lock.Unlock()
 
@@ -461,8 +461,8 @@ func (dlp *downloadTesterPeer) RequestHeadersByNumber(origin uint64, amount int,
 // peer in the download tester. The returned function can be used to retrieve
 // batches of block bodies from the particularly requested peer.
 func (dlp *downloadTesterPeer) RequestBodies(hashes []common.Hash) error {
-	txs, uncles := dlp.chain.bodies(hashes)
-	go dlp.dl.downloader.DeliverBodies(dlp.id, txs, uncles, [][]*types.BlobTxSidecar{})
+	txs, uncles, withdrawals, deposits := dlp.chain.bodies(hashes)
+	go dlp.dl.downloader.DeliverBodies(dlp.id, txs, uncles, withdrawals, deposits, [][]*types.BlobTxSidecar{})
 	return nil
 }
 
@@ -553,6 +553,40 @@ func testCanonSync(t *testing.T, protocol uint, mode SyncMode) {
 	assertOwnChain(t, tester, chain.len())
 }
 
+// Tests that the settlement operations in the block bodies are downloaded and
+// reassembled into the imported blocks.
+func TestSettlementSynchronisation66Full(t *testing.T) { testSettlementSync(t, eth.ETH66, FullSync) }
+func TestSettlementSynchronisation66Fast(t *testing.T) { testSettlementSync(t, eth.ETH66, FastSync) }
+
+func testSettlementSync(t *testing.T, protocol uint, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	chain := testChainBase.shorten(blockCacheMaxItems - 15).makeSettlement(16)
+	tester.newPeer("peer", protocol, chain)
+
+	if err := tester.sync("peer", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, chain.len())
+
+	for _, hash := range chain.chain[chain.len()-16:] {
+		want := chain.blockm[hash]
+		have := tester.GetBlockByHash(hash)
+		if have == nil {
+			t.Fatalf("block %d missing", want.NumberU64())
+		}
+		if len(have.Withdrawals()) != 1 || len(have.Deposits()) != 1 {
+			t.Fatalf("block %d: settlement operations mismatch: have %d/%d, want 1/1", want.NumberU64(), len(have.Withdrawals()), len(have.Deposits()))
+		}
+		if have.Withdrawals()[0].Index != want.Withdrawals()[0].Index || have.Deposits()[0].Amount.Cmp(want.Deposits()[0].Amount) != 0 {
+			t.Fatalf("block %d: settlement operations content mismatch", want.NumberU64())
+		}
+	}
+}
+
 // Tests that if a large batch of blocks are being downloaded, it is throttled
 // until the cached blocks are retrieved.
 func TestThrottling66Full(t *testing.T) { testThrottling(t, eth.ETH66, FullSync) }
@@ -772,7 +806,7 @@ func TestInactiveDownloader63(t *testing.T) {
 	if err := tester.downloader.DeliverHeaders("bad peer", []*types.Header{}); err != errNoSyncActive {
 		t.Errorf("error mismatch: have %v, want %v", err, errNoSyncActive)
 	}
-	if err := tester.downloader.DeliverBodies("bad peer", [][]*types.Transaction{}, [][]*types.Header{}, [][]*types.BlobTxSidecar{}); err != errNoSyncActive {
+	if err := tester.downloader.DeliverBodies("bad peer", [][]*types.Transaction{}, [][]*types.Header{}, nil, nil, [][]*types.BlobTxSidecar{}); err != errNoSyncActive {
 		t.Errorf("error mismatch: have %v, want %v", err, errNoSyncActive)
 	}
 	if err := tester.downloader.DeliverReceipts("bad peer", [][]*types.Receipt{}); err != errNoSyncActive {
@@ -68,6 +68,8 @@ type fetchResult struct {
 	Header       *types.Header
 	Uncles       []*types.Header
 	Transactions types.Transactions
+	Withdrawals  types.Withdrawals
+	Deposits     types.Deposits
 	Sidecars     []*types.BlobTxSidecar
 	Receipts     types.Receipts
 }
@@ -755,10 +757,23 @@ func (q *queue) DeliverHeaders(id string, headers []*types.Header, headerProcCh
 // DeliverBodies injects a block body retrieval response into the results queue.
 // The method returns the number of blocks bodies accepted from the delivery and
 // also wakes any threads waiting for data delivery.
-func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, uncleLists [][]*types.Header, sidecars [][]*types.BlobTxSidecar) (int, error) {
+func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, uncleLists [][]*types.Header, withdrawalLists [][]*types.Withdrawal, depositLists [][]*types.Deposit, sidecars [][]*types.BlobTxSidecar) (int, error) {
 	q.lock.Lock()
 	defer q.lock.Unlock()
 	trieHasher := trie.NewStackTrie(nil)
+	operations := func(index int) ([]*types.Withdrawal, []*types.Deposit) {
+		var (
+			withdrawals []*types.Withdrawal
+			deposits    []*types.Deposit
+		)
+		if len(withdrawalLists) > 0 {
+			withdrawals = withdrawalLists[index]
+		}
+		if len(depositLists) > 0 {
+			deposits = depositLists[index]
+		}
+		return withdrawals, deposits
+	}
 	validate := func(index int, header *types.Header) error {
 		if types.DeriveSha(types.Transactions(txLists[index]), trieHasher) != header.TxHash {
 			return errInvalidBody
@@ -766,6 +781,21 @@ func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, uncleLi
 		if types.CalcUncleHash(uncleLists[index]) != header.UncleHash {
 			return errInvalidBody
 		}
+		// Settlement operations must match the header roots after the fork, and
+		// be absent before it
+		withdrawals, deposits := operations(index)
+		if header.WithdrawalsHash == nil || header.DepositsHash == nil {
+			if withdrawals != nil || deposits != nil {
+				return errInvalidBody
+			}
+		} else {
+			if types.DeriveSha(types.Withdrawals(withdrawals), trieHasher) != *header.WithdrawalsHash {
+				return errInvalidBody
+			}
+			if types.DeriveSha(types.Deposits(deposits), trieHasher) != *header.DepositsHash {
+				return errInvalidBody
+			}
+		}
 		// Blocks must have a number of blobs corresponding to the header gas usage,
 		// and zero before the Cancun hardfork
 		var blobs int
@@ -787,6 +817,7 @@ func (q *queue) DeliverBodies(id string, txLists [][]*types.Transaction, uncleLi
 	reconstruct := func(index int, result *fetchResult) {
 		result.Transactions = txLists[index]
 		result.Uncles = uncleLists[index]
+		result.Withdrawals, result.Deposits = operations(index)
 		if len(sidecars) > 0 {
 			result.Sidecars = sidecars[index]
 		}
@@ -88,6 +88,39 @@ func (tc *testChain) makeFork(length int, heavy bool, seed byte) *testChain {
 	return fork
 }
 
+// makeSettlement creates a fork on top of the test chain whose blocks carry
+// settlement operations in their bodies.
+func (tc *testChain) makeSettlement(length int) *testChain {
+	fork := tc.copy(tc.len() + length)
+
+	parent := tc.headBlock()
+	td := new(big.Int).Set(tc.td(parent.Hash()))
+	for i := 0; i < length; i++ {
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     new(big.Int).Add(parent.Number(), common.Big1),
+			Difficulty: common.Big1,
+			GasLimit:   parent.GasLimit(),
+			Time:       parent.Time() + 10,
+			Root:       parent.Root(),
+			BaseFee:    parent.BaseFee(),
+		}
+		withdrawals := []*types.Withdrawal{{Index: uint64(i), Sender: testAddress, Recipient: common.Address{1}, Amount: big.NewInt(1)}}
+		deposits := []*types.Deposit{{Index: uint64(i), Recipient: testAddress, Amount: big.NewInt(2)}}
+		block := types.NewBlockWithOperations(header, nil, nil, nil, withdrawals, deposits, trie.NewStackTrie(nil))
+
+		hash := block.Hash()
+		td.Add(td, block.Difficulty())
+		fork.chain = append(fork.chain, hash)
+		fork.blockm[hash] = block
+		fork.headerm[hash] = block.Header()
+		fork.receiptm[hash] = nil
+		fork.tdm[hash] = new(big.Int).Set(td)
+		parent = block
+	}
+	return fork
+}
+
 // shorten creates a copy of the chain with the given length. It panics if the
 // length is longer than the number of available blocks.
 func (tc *testChain) shorten(length int) *testChain {
@@ -214,16 +247,20 @@ func (tc *testChain) receipts(hashes []common.Hash) [][]*types.Receipt {
 }
 
 // bodies returns the block bodies of the given block hashes.
-func (tc *testChain) bodies(hashes []common.Hash) ([][]*types.Transaction, [][]*types.Header) {
+func (tc *testChain) bodies(hashes []common.Hash) ([][]*types.Transaction, [][]*types.Header, [][]*types.Withdrawal, [][]*types.Deposit) {
 	transactions := make([][]*types.Transaction, 0, len(hashes))
 	uncles := make([][]*types.Header, 0, len(hashes))
+	withdrawals := make([][]*types.Withdrawal, 0, len(hashes))
+	deposits := make([][]*types.Deposit, 0, len(hashes))
 	for _, hash := range hashes {
 		if block, ok := tc.blockm[hash]; ok {
 			transactions = append(transactions, block.Transactions())
 			uncles = append(uncles, block.Uncles())
+			withdrawals = append(withdrawals, block.Withdrawals())
+			deposits = append(deposits, block.Deposits())
 		}
 	}
-	return transactions, uncles
+	return transactions, uncles, withdrawals, deposits
 }
 
 func (tc *testChain) hashToNumber(target common.Hash) (uint64, bool) {
@@ -50,6 +50,8 @@ type bodyPack struct {
 	peerID       string
 	transactions [][]*types.Transaction
 	uncles       [][]*types.Header
+	withdrawals  [][]*types.Withdrawal
+	deposits     [][]*types.Deposit
 	sidecars     [][]*types.BlobTxSidecar
 }
 
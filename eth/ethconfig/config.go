@@ -89,6 +89,9 @@ var Defaults = Config{
 	TrieDirtyCache:     256,
 	TrieTimeout:        60 * time.Minute,
 	SnapshotCache:      102,
+
+	JumpDestCacheRejournal: time.Hour,
+	JumpDestCacheSize:      4096,
 	Miner: miner.Config{
 		GasCeil:              8000000,
 		GasPrice:             big.NewInt(params.GWei),
@@ -145,7 +148,10 @@ type Config struct {
 	NoPruning  bool // Whether to disable pruning and flush everything to disk
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
-	NoPruningSideCar bool // Whether to disable blob sidecar pruning
+	HotContracts []common.Address `toml:",omitempty"` // Contracts whose storage is kept warm across blocks (nil = system contracts)
+
+	NoPruningSideCar bool   // Whether to disable blob sidecar pruning
+	SideCarRetention uint64 `toml:",omitempty"` // Number of blocks from head whose blob sidecars are kept (0 = default)
 
 	// Deprecated, use 'TransactionHistory' instead.
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
@@ -180,6 +186,9 @@ type Config struct {
 	TrieCleanCache          int
 	TrieCleanCacheJournal   string        `toml:",omitempty"` // Disk journal directory for trie cache to survive node restarts
 	TrieCleanCacheRejournal time.Duration `toml:",omitempty"` // Time interval to regenerate the journal for clean cache
+	JumpDestCacheJournal    string        `toml:",omitempty"` // Disk journal for JUMPDEST analysis to survive node restarts
+	JumpDestCacheRejournal  time.Duration `toml:",omitempty"` // Time interval to regenerate the JUMPDEST analysis journal
+	JumpDestCacheSize       int           // Maximum number of cached JUMPDEST analysis results
 	TrieDirtyCache          int
 	TrieTimeout             time.Duration
 	SnapshotCache           int
@@ -213,6 +222,10 @@ type Config struct {
 	// RPCEVMTimeout is the global timeout for eth-call.
 	RPCEVMTimeout time.Duration
 
+	// RPCEVMStepLimit is the global cap on the instructions executed by
+	// eth-call variants.
+	RPCEVMStepLimit uint64
+
 	// RPCTxFeeCap is the global transaction fee(price * gaslimit) cap for
 	// send-transction variants. The unit is ether.
 	RPCTxFeeCap float64
@@ -232,9 +245,86 @@ type Config struct {
 	// Enable finality vote monitoring
 	EnableMonitorFinalityVote bool
 
+	// Duration after sealing during which local blocks are protected from
+	// being reorged out by a non-finalized branch
+	ReorgProtectWindow time.Duration
+
+	// Maximum number of canonical blocks a reorg may retract (0 = unlimited)
+	MaxReorgDepth uint64
+
+	// Index the creator and deploying transaction of every contract
+	ContractCreationIndex bool
+
+	// Index the position and outcome of every canonical transaction
+	ReceiptIndex bool
+
+	// Time interval between sampled snapshot verification rounds (0 = disabled)
+	SnapshotVerifyInterval time.Duration
+
+	// Share of the snapshot cache reserved for accounts (0 = shared with storage)
+	SnapshotAccountRatio float64 `toml:",omitempty"`
+
+	// Rebalance the snapshot cache partitions by hit rate
+	SnapshotRebalance bool `toml:",omitempty"`
+
+	// Number of recent blocks whose state is kept available (0 = disabled)
+	RecentStates uint64
+
+	// Whether to migrate the hash-based state into the path-based layout
+	StateMigration bool
+
+	// Whether the state scheme is explicitly configured, ruling out any migration
+	StateSchemePinned bool
+
+	// Whether to keep the hash-chained audit log of the chain mutations
+	ChainAudit bool
+
+	// Whether to estimate the execution witness size of the processed blocks
+	WitnessStats bool
+
+	// Number of recent execution witnesses of the imported blocks to keep (0 = disabled)
+	WitnessCache int
+
+	// Whether to verify the supply change of the imported blocks against the rewards and burns
+	SupplyCheck   bool
+	SupplyBridges []common.Address `toml:",omitempty"` // Accounts holding the ether locked by the bridges
+
+	// Whether to count the SSTOREs of the processed blocks by slot transition
+	SstoreStats bool
+
+	// Whether to hash the execution events of the transactions of the processed blocks
+	TraceHashes bool
+
+	// Number of workers executing the block transactions in parallel (0 = disabled)
+	ParallelWorkers int
+
+	// Number of recent blocks whose transactions can't be included again (0 = disabled)
+	DuplicateTxWindow uint64
+
+	// Interval of the database compactions scheduled away from the sealing turns (0 = disabled)
+	CompactionInterval time.Duration
+
+	// Arrival delay of a block above which it is reported as late (0 = disabled)
+	BlockDelayThreshold time.Duration
+
+	// Time within which the local transactions are expected to be included (0 = untracked)
+	TxInclusionSLA time.Duration
+
+	// Whether to sign acknowledgments of the imported canonical blocks with the etherbase key
+	ImportAcks bool
+
+	// Base fee change between blocks in percent above which it is reported (0 = disabled)
+	FeeChangeThreshold uint64
+
+	// Number of recent blocks whose bodies and receipts are kept (0 = entire chain)
+	HistoryPrune uint64
+
 	// Disable ronin p2p protocol
 	DisableRoninProtocol bool
 
+	// Advertise eth/101, exchanging the receipts without blooms
+	BloomlessReceipts bool
+
 	// Send additional chain event
 	EnableAdditionalChainEvent bool
 }
@@ -127,6 +127,8 @@ type bodyFilterTask struct {
 	peer         string                   // The source peer of block bodies
 	transactions [][]*types.Transaction   // Collection of transactions per block bodies
 	uncles       [][]*types.Header        // Collection of uncles per block bodies
+	withdrawals  [][]*types.Withdrawal    // Collection of settlement withdrawals per block bodies
+	deposits     [][]*types.Deposit       // Collection of settlement deposits per block bodies
 	sidecars     [][]*types.BlobTxSidecar // Collection of sidecars per block bodies
 	time         time.Time                // Arrival time of the blocks' contents
 }
@@ -313,7 +315,7 @@ func (f *BlockFetcher) FilterHeaders(peer string, headers []*types.Header, time
 
 // FilterBodies extracts all the block bodies that were explicitly requested by
 // the fetcher, returning those that should be handled differently.
-func (f *BlockFetcher) FilterBodies(peer string, transactions [][]*types.Transaction, uncles [][]*types.Header, sidecars [][]*types.BlobTxSidecar, time time.Time) ([][]*types.Transaction, [][]*types.Header, [][]*types.BlobTxSidecar) {
+func (f *BlockFetcher) FilterBodies(peer string, transactions [][]*types.Transaction, uncles [][]*types.Header, withdrawals [][]*types.Withdrawal, deposits [][]*types.Deposit, sidecars [][]*types.BlobTxSidecar, time time.Time) ([][]*types.Transaction, [][]*types.Header, [][]*types.Withdrawal, [][]*types.Deposit, [][]*types.BlobTxSidecar) {
 	log.Trace("Filtering bodies", "peer", peer, "txs", len(transactions), "uncles", len(uncles))
 
 	// Send the filter channel to the fetcher
@@ -322,20 +324,20 @@ func (f *BlockFetcher) FilterBodies(peer string, transactions [][]*types.Transac
 	select {
 	case f.bodyFilter <- filter:
 	case <-f.quit:
-		return nil, nil, nil
+		return nil, nil, nil, nil, nil
 	}
 	// Request the filtering of the body list
 	select {
-	case filter <- &bodyFilterTask{peer: peer, transactions: transactions, uncles: uncles, sidecars: sidecars, time: time}:
+	case filter <- &bodyFilterTask{peer: peer, transactions: transactions, uncles: uncles, withdrawals: withdrawals, deposits: deposits, sidecars: sidecars, time: time}:
 	case <-f.quit:
-		return nil, nil, nil
+		return nil, nil, nil, nil, nil
 	}
 	// Retrieve the bodies remaining after filtering
 	select {
 	case task := <-filter:
-		return task.transactions, task.uncles, task.sidecars
+		return task.transactions, task.uncles, task.withdrawals, task.deposits, task.sidecars
 	case <-f.quit:
-		return nil, nil, nil
+		return nil, nil, nil, nil, nil
 	}
 }
 
@@ -626,7 +628,16 @@ func (f *BlockFetcher) loop() {
 						matched   = false
 						uncleHash common.Hash // calculated lazily and reused
 						txnHash   common.Hash // calculated lazily and reused
+
+						withdrawals []*types.Withdrawal
+						deposits    []*types.Deposit
 					)
+					if len(task.withdrawals) > 0 {
+						withdrawals = task.withdrawals[i]
+					}
+					if len(task.deposits) > 0 {
+						deposits = task.deposits[i]
+					}
 					for hash, announce := range f.completing {
 						if f.queued[hash] != nil || announce.origin != task.peer {
 							continue
@@ -643,10 +654,13 @@ func (f *BlockFetcher) loop() {
 						if txnHash != announce.header.TxHash {
 							continue
 						}
+						if !matchOperations(announce.header, withdrawals, deposits) {
+							continue
+						}
 						// Mark the body matched, reassemble if still unknown
 						matched = true
 						if f.getBlock(hash) == nil {
-							block := types.NewBlockWithHeader(announce.header).WithBody(task.transactions[i], task.uncles[i])
+							block := types.NewBlockWithHeader(announce.header).WithBody(task.transactions[i], task.uncles[i]).WithOperations(withdrawals, deposits)
 							block.ReceivedAt = task.time
 							blocks = append(blocks, block)
 							if len(task.sidecars) > 0 {
@@ -660,6 +674,12 @@ func (f *BlockFetcher) loop() {
 					if matched {
 						task.transactions = append(task.transactions[:i], task.transactions[i+1:]...)
 						task.uncles = append(task.uncles[:i], task.uncles[i+1:]...)
+						if len(task.withdrawals) > 0 {
+							task.withdrawals = append(task.withdrawals[:i], task.withdrawals[i+1:]...)
+						}
+						if len(task.deposits) > 0 {
+							task.deposits = append(task.deposits[:i], task.deposits[i+1:]...)
+						}
 						if len(task.sidecars) > 0 {
 							task.sidecars = append(task.sidecars[:i], task.sidecars[i+1:]...)
 						}
@@ -688,6 +708,19 @@ func (f *BlockFetcher) loop() {
 	}
 }
 
+// matchOperations reports whether the settlement operations of a block body
+// match the roots of the header. Bodies before the settlement fork must not
+// carry any operations.
+func matchOperations(header *types.Header, withdrawals []*types.Withdrawal, deposits []*types.Deposit) bool {
+	if header.WithdrawalsHash == nil || header.DepositsHash == nil {
+		return withdrawals == nil && deposits == nil
+	}
+	if types.DeriveSha(types.Withdrawals(withdrawals), trie.NewStackTrie(nil)) != *header.WithdrawalsHash {
+		return false
+	}
+	return types.DeriveSha(types.Deposits(deposits), trie.NewStackTrie(nil)) == *header.DepositsHash
+}
+
 // rescheduleFetch resets the specified fetch timer to the next blockAnnounce timeout.
 func (f *BlockFetcher) rescheduleFetch(fetch *time.Timer) {
 	// Short circuit if no blocks are announced
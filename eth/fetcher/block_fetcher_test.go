@@ -237,7 +237,7 @@ func (f *fetcherTester) makeBodyFetcher(peer string, blocks map[common.Hash]*typ
 			}
 		}
 		// Return on a new thread
-		go f.fetcher.FilterBodies(peer, transactions, uncles, nil, time.Now().Add(drift))
+		go f.fetcher.FilterBodies(peer, transactions, uncles, nil, nil, nil, time.Now().Add(drift))
 
 		return nil
 	}
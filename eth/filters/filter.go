@@ -18,15 +18,14 @@ package filters
 
 import (
 	"context"
-	"errors"
-	"math"
-	"math/big"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
+	"github.com/ethereum/go-ethereum/core/logfilter"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -51,324 +50,96 @@ type Backend interface {
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
-// Filter can be used to retrieve and filter logs.
-type Filter struct {
-	backend Backend
-
-	db        ethdb.Database
-	addresses []common.Address
-	topics    [][]common.Hash
-
-	block      common.Hash // Block hash if filtering a single block
-	begin, end int64       // Range interval if filtering multiple blocks
-
-	matcher *bloombits.Matcher
-}
-
-var blockRangeLimit uint64 = math.MaxUint64
+// limits bounds the resources of every log query served by the filters.
+var limits = logfilter.DefaultLimits
 
 func init() {
-	env := os.Getenv("FILTER_BLOCK_RANGE_LIMIT")
-	if env != "" {
+	if env := os.Getenv("FILTER_BLOCK_RANGE_LIMIT"); env != "" {
 		newLimit, err := strconv.ParseUint(env, 10, 64)
 		if err != nil {
 			log.Info("Failed to parse filter block range limit", "err", err)
 		} else {
 			log.Info("New block range limit in log filter", "limit", newLimit)
-			blockRangeLimit = newLimit
+			limits.MaxBlockRange = newLimit
 		}
 	}
-}
-
-// NewRangeFilter creates a new filter which uses a bloom filter on blocks to
-// figure out whether a particular block is interesting or not.
-func NewRangeFilter(backend Backend, begin, end int64, addresses []common.Address, topics [][]common.Hash) *Filter {
-	// Flatten the address and topic filter clauses into a single bloombits filter
-	// system. Since the bloombits are not positional, nil topics are permitted,
-	// which get flattened into a nil byte slice.
-	var filters [][][]byte
-	if len(addresses) > 0 {
-		filter := make([][]byte, len(addresses))
-		for i, address := range addresses {
-			filter[i] = address.Bytes()
+	if env := os.Getenv("FILTER_RESULT_LIMIT"); env != "" {
+		newLimit, err := strconv.Atoi(env)
+		if err != nil {
+			log.Info("Failed to parse filter result limit", "err", err)
+		} else {
+			log.Info("New result limit in log filter", "limit", newLimit)
+			limits.MaxResults = newLimit
 		}
-		filters = append(filters, filter)
 	}
-	for _, topicList := range topics {
-		filter := make([][]byte, len(topicList))
-		for i, topic := range topicList {
-			filter[i] = topic.Bytes()
+	if env := os.Getenv("FILTER_QUERY_TIMEOUT"); env != "" {
+		timeout, err := time.ParseDuration(env)
+		if err != nil {
+			log.Info("Failed to parse filter query timeout", "err", err)
+		} else {
+			log.Info("New query timeout in log filter", "timeout", timeout)
+			limits.Timeout = timeout
 		}
-		filters = append(filters, filter)
 	}
-	size, _ := backend.BloomStatus()
-
-	// Create a generic filter and convert it into a range filter
-	filter := newFilter(backend, addresses, topics)
-
-	filter.matcher = bloombits.NewMatcher(size, filters)
-	filter.begin = begin
-	filter.end = end
-
-	return filter
 }
 
-// NewBlockFilter creates a new filter which directly inspects the contents of
-// a block to figure out whether it is interesting or not.
-func NewBlockFilter(backend Backend, block common.Hash, addresses []common.Address, topics [][]common.Hash) *Filter {
-	// Create a generic filter and convert it into a block filter
-	filter := newFilter(backend, addresses, topics)
-	filter.block = block
-	return filter
+// chainBackend exposes a filter Backend as the chain of the core log filter engine.
+type chainBackend struct {
+	backend Backend
 }
 
-// newFilter creates a generic filter that can either filter based on a block hash,
-// or based on range queries. The search criteria needs to be explicitly set.
-func newFilter(backend Backend, addresses []common.Address, topics [][]common.Hash) *Filter {
-	return &Filter{
-		backend:   backend,
-		addresses: addresses,
-		topics:    topics,
-		db:        backend.ChainDb(),
-	}
+func (b *chainBackend) CurrentHeader(ctx context.Context) (*types.Header, error) {
+	return b.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 }
 
-// Logs searches the blockchain for matching log entries, returning all from the
-// first block that contains matches, updating the start of the filter accordingly.
-func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
-	// If we're doing singleton block filtering, execute and return
-	if f.block != (common.Hash{}) {
-		header, err := f.backend.HeaderByHash(ctx, f.block)
-		if err != nil {
-			return nil, err
-		}
-		if header == nil {
-			return nil, errors.New("unknown block")
-		}
-		return f.blockLogs(ctx, header)
-	}
-	// Figure out the limits of the filter range
-	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
-	if header == nil {
-		return nil, nil
-	}
-	head := header.Number.Uint64()
-
-	if f.begin == -1 {
-		f.begin = int64(head)
-	}
-	end := uint64(f.end)
-	if f.end == -1 {
-		end = head
-	}
-	if end-uint64(f.begin) > blockRangeLimit {
-		log.Info(
-			"Filter block range is higher than the limit",
-			"limit", blockRangeLimit, "request", end-uint64(f.begin),
-		)
-		return nil, errors.New("filter block range is higher than the limit")
-	}
-	// Gather all indexed logs, and finish with non indexed ones
-	var (
-		logs []*types.Log
-		err  error
-	)
-	size, sections := f.backend.BloomStatus()
-	if indexed := sections * size; indexed > uint64(f.begin) {
-		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
-		} else {
-			logs, err = f.indexedLogs(ctx, indexed-1)
-		}
-		if err != nil {
-			return logs, err
-		}
-	}
-	rest, err := f.unindexedLogs(ctx, end)
-	logs = append(logs, rest...)
-	return logs, err
+func (b *chainBackend) HeaderByNumber(ctx context.Context, number uint64) (*types.Header, error) {
+	return b.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
 }
 
-// indexedLogs returns the logs matching the filter criteria based on the bloom
-// bits indexed available locally or via the network.
-func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
-	// Create a matcher session and request servicing from the backend
-	matches := make(chan uint64, 64)
-
-	session, err := f.matcher.Start(ctx, uint64(f.begin), end, matches)
-	if err != nil {
-		return nil, err
-	}
-	defer session.Close()
-
-	f.backend.ServiceFilter(ctx, session)
-
-	// Iterate over the matches until exhausted or context closed
-	var logs []*types.Log
-
-	for {
-		select {
-		case number, ok := <-matches:
-			// Abort if all matches have been fulfilled
-			if !ok {
-				err := session.Error()
-				if err == nil {
-					f.begin = int64(end) + 1
-				}
-				return logs, err
-			}
-			f.begin = int64(number) + 1
-
-			// Retrieve the suggested block and pull any truly matching logs
-			header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
-			if header == nil || err != nil {
-				return logs, err
-			}
-			found, err := f.checkMatches(ctx, header)
-			if err != nil {
-				return logs, err
-			}
-			logs = append(logs, found...)
-
-		case <-ctx.Done():
-			return logs, ctx.Err()
-		}
-	}
+func (b *chainBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return b.backend.HeaderByHash(ctx, hash)
 }
 
-// unindexedLogs returns the logs matching the filter criteria based on raw block
-// iteration and bloom matching.
-func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
-	var logs []*types.Log
-
-	for ; f.begin <= int64(end); f.begin++ {
-		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
-		if header == nil || err != nil {
-			return logs, err
-		}
-		found, err := f.blockLogs(ctx, header)
-		if err != nil {
-			return logs, err
-		}
-		logs = append(logs, found...)
-	}
-	return logs, nil
+func (b *chainBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.backend.GetReceipts(ctx, hash)
 }
 
-// blockLogs returns the logs matching the filter criteria within a single block.
-func (f *Filter) blockLogs(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
-	if bloomFilter(header.Bloom, f.addresses, f.topics) {
-		found, err := f.checkMatches(ctx, header)
-		if err != nil {
-			return logs, err
-		}
-		logs = append(logs, found...)
-	}
-	return logs, nil
+func (b *chainBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
+	return b.backend.GetLogs(ctx, hash)
 }
 
-// checkMatches checks if the receipts belonging to the given header contain any log events that
-// match the filter criteria. This function is called when the bloom filter signals a potential match.
-func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs []*types.Log, err error) {
-	// Get the logs of the block
-	logsList, err := f.backend.GetLogs(ctx, header.Hash())
-	if err != nil {
-		return nil, err
-	}
-	var unfiltered []*types.Log
-	for _, logs := range logsList {
-		unfiltered = append(unfiltered, logs...)
-	}
-	logs = filterLogs(unfiltered, nil, nil, f.addresses, f.topics)
-	if len(logs) > 0 {
-		// We have matching logs, check if we need to resolve full logs via the light client
-		if logs[0].TxHash == (common.Hash{}) {
-			receipts, err := f.backend.GetReceipts(ctx, header.Hash())
-			if err != nil {
-				return nil, err
-			}
-			unfiltered = unfiltered[:0]
-			for _, receipt := range receipts {
-				unfiltered = append(unfiltered, receipt.Logs...)
-			}
-			logs = filterLogs(unfiltered, nil, nil, f.addresses, f.topics)
-		}
-		return logs, nil
-	}
-	return nil, nil
+func (b *chainBackend) BloomStatus() (uint64, uint64) {
+	return b.backend.BloomStatus()
 }
 
-func includes(addresses []common.Address, a common.Address) bool {
-	for _, addr := range addresses {
-		if addr == a {
-			return true
-		}
-	}
+func (b *chainBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
+	b.backend.ServiceFilter(ctx, session)
+}
 
-	return false
+// newEngine creates a core log filter engine over the given backend.
+func newEngine(backend Backend) *logfilter.Engine {
+	return logfilter.NewEngine(&chainBackend{backend}, limits)
 }
 
-// filterLogs creates a slice of logs matching the given criteria.
-func filterLogs(logs []*types.Log, fromBlock, toBlock *big.Int, addresses []common.Address, topics [][]common.Hash) []*types.Log {
-	var ret []*types.Log
-Logs:
-	for _, log := range logs {
-		if fromBlock != nil && fromBlock.Int64() >= 0 && fromBlock.Uint64() > log.BlockNumber {
-			continue
-		}
-		if toBlock != nil && toBlock.Int64() >= 0 && toBlock.Uint64() < log.BlockNumber {
-			continue
-		}
+// Filter can be used to retrieve and filter logs.
+type Filter struct {
+	query *logfilter.Query
+}
 
-		if len(addresses) > 0 && !includes(addresses, log.Address) {
-			continue
-		}
-		// If the to filtered topics is greater than the amount of topics in logs, skip.
-		if len(topics) > len(log.Topics) {
-			continue Logs
-		}
-		for i, sub := range topics {
-			match := len(sub) == 0 // empty rule set == wildcard
-			for _, topic := range sub {
-				if log.Topics[i] == topic {
-					match = true
-					break
-				}
-			}
-			if !match {
-				continue Logs
-			}
-		}
-		ret = append(ret, log)
-	}
-	return ret
+// NewRangeFilter creates a new filter which uses a bloom filter on blocks to
+// figure out whether a particular block is interesting or not.
+func NewRangeFilter(backend Backend, begin, end int64, addresses []common.Address, topics [][]common.Hash) *Filter {
+	return &Filter{query: newEngine(backend).NewRangeQuery(begin, end, addresses, topics)}
 }
 
-func bloomFilter(bloom types.Bloom, addresses []common.Address, topics [][]common.Hash) bool {
-	if len(addresses) > 0 {
-		var included bool
-		for _, addr := range addresses {
-			if types.BloomLookup(bloom, addr) {
-				included = true
-				break
-			}
-		}
-		if !included {
-			return false
-		}
-	}
+// NewBlockFilter creates a new filter which directly inspects the contents of
+// a block to figure out whether it is interesting or not.
+func NewBlockFilter(backend Backend, block common.Hash, addresses []common.Address, topics [][]common.Hash) *Filter {
+	return &Filter{query: newEngine(backend).NewBlockQuery(block, addresses, topics)}
+}
 
-	for _, sub := range topics {
-		included := len(sub) == 0 // empty rule set == wildcard
-		for _, topic := range sub {
-			if types.BloomLookup(bloom, topic) {
-				included = true
-				break
-			}
-		}
-		if !included {
-			return false
-		}
-	}
-	return true
+// Logs searches the blockchain for matching log entries, bounded by the
+// resource limits of the log filter engine.
+func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	return f.query.Logs(ctx)
 }
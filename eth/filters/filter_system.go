@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/logfilter"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
@@ -76,6 +77,7 @@ type subscription struct {
 	typ        Type
 	created    time.Time
 	logsCrit   ethereum.FilterQuery
+	matcher    *logfilter.LogMatcher // Compiled address and topic criteria of the log subscriptions
 	logs       chan []*types.Log
 	hashes     chan []common.Hash
 	headers    chan *types.Header
@@ -235,6 +237,7 @@ func (es *EventSystem) subscribeMinedPendingLogs(crit ethereum.FilterQuery, logs
 		id:         rpc.NewID(),
 		typ:        MinedAndPendingLogsSubscription,
 		logsCrit:   crit,
+		matcher:    logfilter.NewLogMatcher(crit.Addresses, crit.Topics),
 		created:    time.Now(),
 		logs:       logs,
 		hashes:     make(chan []common.Hash),
@@ -253,6 +256,7 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		id:         rpc.NewID(),
 		typ:        LogsSubscription,
 		logsCrit:   crit,
+		matcher:    logfilter.NewLogMatcher(crit.Addresses, crit.Topics),
 		created:    time.Now(),
 		logs:       logs,
 		hashes:     make(chan []common.Hash),
@@ -271,6 +275,7 @@ func (es *EventSystem) subscribePendingLogs(crit ethereum.FilterQuery, logs chan
 		id:         rpc.NewID(),
 		typ:        PendingLogsSubscription,
 		logsCrit:   crit,
+		matcher:    logfilter.NewLogMatcher(crit.Addresses, crit.Topics),
 		created:    time.Now(),
 		logs:       logs,
 		hashes:     make(chan []common.Hash),
@@ -340,7 +345,7 @@ func (es *EventSystem) handleLogs(filters filterIndex, ev []*types.Log) {
 		return
 	}
 	for _, f := range filters[LogsSubscription] {
-		matchedLogs := filterLogs(ev, f.logsCrit.FromBlock, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics)
+		matchedLogs := f.matcher.Filter(ev, f.logsCrit.FromBlock, f.logsCrit.ToBlock)
 		if len(matchedLogs) > 0 {
 			f.logs <- matchedLogs
 		}
@@ -352,7 +357,7 @@ func (es *EventSystem) handlePendingLogs(filters filterIndex, ev []*types.Log) {
 		return
 	}
 	for _, f := range filters[PendingLogsSubscription] {
-		matchedLogs := filterLogs(ev, nil, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics)
+		matchedLogs := f.matcher.Filter(ev, nil, f.logsCrit.ToBlock)
 		if len(matchedLogs) > 0 {
 			f.logs <- matchedLogs
 		}
@@ -361,7 +366,7 @@ func (es *EventSystem) handlePendingLogs(filters filterIndex, ev []*types.Log) {
 
 func (es *EventSystem) handleRemovedLogs(filters filterIndex, ev core.RemovedLogsEvent) {
 	for _, f := range filters[LogsSubscription] {
-		matchedLogs := filterLogs(ev.Logs, f.logsCrit.FromBlock, f.logsCrit.ToBlock, f.logsCrit.Addresses, f.logsCrit.Topics)
+		matchedLogs := f.matcher.Filter(ev.Logs, f.logsCrit.FromBlock, f.logsCrit.ToBlock)
 		if len(matchedLogs) > 0 {
 			f.logs <- matchedLogs
 		}
@@ -398,7 +403,7 @@ func (es *EventSystem) handleChainEvent(filters filterIndex, ev core.ChainEvent)
 	if es.lightMode && len(filters[LogsSubscription]) > 0 {
 		es.lightFilterNewHead(ev.Block.Header(), func(header *types.Header, remove bool) {
 			for _, f := range filters[LogsSubscription] {
-				if matchedLogs := es.lightFilterLogs(header, f.logsCrit.Addresses, f.logsCrit.Topics, remove); len(matchedLogs) > 0 {
+				if matchedLogs := es.lightFilterLogs(header, f.logsCrit.Addresses, f.logsCrit.Topics, f.matcher, remove); len(matchedLogs) > 0 {
 					f.logs <- matchedLogs
 				}
 			}
@@ -440,8 +445,8 @@ func (es *EventSystem) lightFilterNewHead(newHeader *types.Header, callBack func
 }
 
 // filter logs of a single header in light client mode
-func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.Address, topics [][]common.Hash, remove bool) []*types.Log {
-	if bloomFilter(header.Bloom, addresses, topics) {
+func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.Address, topics [][]common.Hash, matcher *logfilter.LogMatcher, remove bool) []*types.Log {
+	if logfilter.BloomFilter(header.Bloom, addresses, topics) {
 		// Get the logs of the block
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 		defer cancel()
@@ -457,7 +462,7 @@ func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.
 				unfiltered = append(unfiltered, &logcopy)
 			}
 		}
-		logs := filterLogs(unfiltered, nil, nil, addresses, topics)
+		logs := matcher.Filter(unfiltered, nil, nil)
 		if len(logs) > 0 && logs[0].TxHash == (common.Hash{}) {
 			// We have matching but non-derived logs
 			receipts, err := es.backend.GetReceipts(ctx, header.Hash())
@@ -472,7 +477,7 @@ func (es *EventSystem) lightFilterLogs(header *types.Header, addresses []common.
 					unfiltered = append(unfiltered, &logcopy)
 				}
 			}
-			logs = filterLogs(unfiltered, nil, nil, addresses, topics)
+			logs = matcher.Filter(unfiltered, nil, nil)
 		}
 		return logs
 	}
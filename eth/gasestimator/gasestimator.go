@@ -44,6 +44,7 @@ type Options struct {
 	State  *state.StateDB      // Pre-state on top of which to estimate the gas
 
 	ErrorRatio float64 // Allowed overestimation ratio for faster estimation termination
+	StepLimit  uint64  // Maximum number of instructions executed per call (0 = unlimited)
 }
 
 // Estimate returns the lowest possible gas limit that allows the transaction to
@@ -234,23 +235,20 @@ func run(ctx context.Context, call core.Message, opts *Options) (*core.Execution
 		evmContext = core.NewEVMBlockContext(opts.Header, opts.Chain, nil)
 
 		dirtyState = opts.State.Copy()
-		evm        = vm.NewEVM(evmContext, msgContext, dirtyState, opts.Config, vm.Config{NoBaseFee: true})
+		evm        = vm.NewEVM(evmContext, msgContext, dirtyState, opts.Config, vm.Config{NoBaseFee: true, StepLimit: opts.StepLimit})
 	)
-	// Monitor the outer context and interrupt the EVM upon cancellation. To avoid
-	// a dangling goroutine until the outer estimation finishes, create an internal
-	// context for the lifetime of this method call.
-	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
+	// Interrupt the EVM upon cancellation of the outer context
+	evm.SetContext(ctx)
 
-	go func() {
-		<-ctx.Done()
-		evm.Cancel()
-	}()
 	// Execute the call, returning a wrapped error or the result
 	result, err := core.ApplyMessage(evm, call, new(core.GasPool).AddGas(math.MaxUint64))
 	if vmerr := dirtyState.Error(); vmerr != nil {
 		return nil, vmerr
 	}
+	// An aborted execution is not a failure fixable by raising the gas limit
+	if aborterr := evm.AbortError(); aborterr != nil {
+		return nil, fmt.Errorf("execution aborted: %w", aborterr)
+	}
 	if err != nil {
 		return result, fmt.Errorf("failed with %d gas: %w", call.Gas(), err)
 	}
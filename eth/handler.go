@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -85,6 +86,14 @@ type txPool interface {
 	// SubscribeTransactions should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeTransactions(ch chan<- core.NewTxsEvent, reorgs bool) event.Subscription
+
+	// SubscribeBroadcasts should return an event subscription of NewTxsEvent
+	// carrying the transactions cleared for propagation to the network.
+	SubscribeBroadcasts(ch chan<- core.NewTxsEvent) event.Subscription
+
+	// Withheld returns whether a transaction is not to be propagated to the
+	// network.
+	Withheld(hash common.Hash) bool
 }
 
 // handlerConfig is the collection of initialization parameters to create a full
@@ -336,7 +345,7 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 		number  = head.Number.Uint64()
 		td      = h.chain.GetTd(hash, number)
 	)
-	forkID := forkid.NewID(h.chain.Config(), h.chain.Genesis().Hash(), h.chain.CurrentHeader().Number.Uint64())
+	forkID := forkid.NewIDWithChain(h.chain)
 	if err := peer.Handshake(h.networkID, td, hash, genesis.Hash(), forkID, h.forkFilter); err != nil {
 		peer.Log().Debug("Ethereum handshake failed", "err", err)
 		return err
@@ -382,6 +391,9 @@ func (h *handler) runEthPeer(peer *eth.Peer, handler eth.Handler) error {
 			return err
 		}
 	}
+	if err := h.resolvePeerHead(peer); err != nil {
+		return err
+	}
 	h.chainSync.handlePeerEvent()
 
 	// Propagate existing transactions. new transactions appearing
@@ -500,7 +512,7 @@ func (h *handler) Start(maxPeers int) {
 	// broadcast transactions
 	h.wg.Add(1)
 	h.txsCh = make(chan core.NewTxsEvent, txChanSize)
-	h.txsSub = h.txpool.SubscribeTransactions(h.txsCh, false)
+	h.txsSub = h.txpool.SubscribeBroadcasts(h.txsCh)
 	go h.txBroadcastLoop()
 
 	// broadcast mined blocks
@@ -545,6 +557,33 @@ func (h *handler) Stop() {
 	log.Info("Ethereum protocol stopped")
 }
 
+// resolvePeerHead records the number of the head a peer advertised in its
+// handshake, needed to rank the peers after the finality-only fork. An head not
+// known locally is requested from the peer, its number recorded on delivery.
+func (h *handler) resolvePeerHead(peer *eth.Peer) error {
+	if h.chain.Config().FinalityOnlyBlock == nil {
+		return nil
+	}
+	head, _ := peer.Head()
+	if header := h.chain.GetHeaderByHash(head); header != nil {
+		number := header.Number.Uint64()
+		peer.SetHeadNumbers(number, h.finalizedNumber(head, number))
+		return nil
+	}
+	return peer.RequestOneHeader(head)
+}
+
+// finalizedNumber returns the number of the block finalized by the given block,
+// zero if the block isn't known locally or the engine has no fast finality.
+func (h *handler) finalizedNumber(hash common.Hash, number uint64) uint64 {
+	engine, ok := h.chain.Engine().(consensus.FastFinalityPoSA)
+	if !ok || !h.chain.HasHeader(hash, number) {
+		return 0
+	}
+	finalized, _ := engine.GetFinalizedBlock(h.chain, number, hash)
+	return finalized
+}
+
 // BroadcastBlock will either propagate a block to a subset of its peers, or
 // will only announce its availability (depending what's requested).
 func (h *handler) BroadcastBlock(block *types.Block, sidecars []*types.BlobTxSidecar, propagate bool) {
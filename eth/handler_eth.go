@@ -68,12 +68,12 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		return h.handleHeaders(peer, *packet)
 
 	case *eth.BlockBodiesPacket:
-		txset, uncleset := packet.Unpack()
-		return h.handleBodies(peer, txset, uncleset, [][]*types.BlobTxSidecar{})
+		txset, uncleset, withdrawalset, depositset := packet.Unpack()
+		return h.handleBodies(peer, txset, uncleset, withdrawalset, depositset, [][]*types.BlobTxSidecar{})
 
 	case *eth.BlockBodiesPacket100:
-		txset, uncleset, sidecarset := packet.Unpack()
-		return h.handleBodies(peer, txset, uncleset, sidecarset)
+		txset, uncleset, withdrawalset, depositset, sidecarset := packet.Unpack()
+		return h.handleBodies(peer, txset, uncleset, withdrawalset, depositset, sidecarset)
 
 	case *eth.NodeDataPacket:
 		if err := h.downloader.DeliverNodeData(peer.ID(), *packet); err != nil {
@@ -163,6 +163,13 @@ func (h *ethHandler) handleHeaders(peer *eth.Peer, headers []*types.Header) erro
 			}
 			peer.Log().Debug("Whitelist block verified", "number", headers[0].Number.Uint64(), "hash", want)
 		}
+		// If it's the head the peer advertised, record its number
+		if head, _ := peer.Head(); headers[0].Hash() == head {
+			if number, _ := peer.HeadNumbers(); headers[0].Number.Uint64() > number {
+				peer.SetHeadNumbers(headers[0].Number.Uint64(), 0)
+				h.chainSync.handlePeerEvent()
+			}
+		}
 		// Irrelevant of the fork checks, send the header to the fetcher just in case
 		headers = h.blockFetcher.FilterHeaders(peer.ID(), headers, time.Now())
 	}
@@ -177,14 +184,14 @@ func (h *ethHandler) handleHeaders(peer *eth.Peer, headers []*types.Header) erro
 
 // handleBodies is invoked from a peer's message handler when it transmits a batch
 // of block bodies for the local node to process.
-func (h *ethHandler) handleBodies(peer *eth.Peer, txs [][]*types.Transaction, uncles [][]*types.Header, sidecars [][]*types.BlobTxSidecar) error {
+func (h *ethHandler) handleBodies(peer *eth.Peer, txs [][]*types.Transaction, uncles [][]*types.Header, withdrawals [][]*types.Withdrawal, deposits [][]*types.Deposit, sidecars [][]*types.BlobTxSidecar) error {
 	// Filter out any explicitly requested bodies, deliver the rest to the downloader
 	filter := len(txs) > 0 || len(uncles) > 0
 	if filter {
-		txs, uncles, sidecars = h.blockFetcher.FilterBodies(peer.ID(), txs, uncles, sidecars, time.Now())
+		txs, uncles, withdrawals, deposits, sidecars = h.blockFetcher.FilterBodies(peer.ID(), txs, uncles, withdrawals, deposits, sidecars, time.Now())
 	}
 	if len(txs) > 0 || len(uncles) > 0 || !filter {
-		err := h.downloader.DeliverBodies(peer.ID(), txs, uncles, sidecars)
+		err := h.downloader.DeliverBodies(peer.ID(), txs, uncles, withdrawals, deposits, sidecars)
 		if err != nil {
 			log.Debug("Failed to deliver bodies", "err", err)
 		}
@@ -209,6 +216,23 @@ func (h *ethHandler) handleBlockAnnounces(peer *eth.Peer, hashes []common.Hash,
 	for i := 0; i < len(unknownHashes); i++ {
 		h.blockFetcher.Notify(peer.ID(), unknownHashes[i], unknownNumbers[i], time.Now(), peer.RequestOneHeader, peer.RequestBodies)
 	}
+	// After the finality-only fork the total difficulty doesn't tell the heads
+	// of the peers apart, track the highest block they announced instead
+	var (
+		best      = -1
+		number, _ = peer.HeadNumbers()
+		_, headTD = peer.Head()
+	)
+	for i := range hashes {
+		if numbers[i] > number && h.chain.Config().IsFinalityOnly(new(big.Int).SetUint64(numbers[i])) {
+			best, number = i, numbers[i]
+		}
+	}
+	if best >= 0 {
+		peer.SetHead(hashes[best], headTD)
+		peer.SetHeadNumbers(numbers[best], (*handler)(h).finalizedNumber(hashes[best], numbers[best]))
+		h.chainSync.handlePeerEvent()
+	}
 	return nil
 }
 
@@ -219,14 +243,23 @@ func (h *ethHandler) handleBlockBroadcast(peer *eth.Peer, block *types.Block, td
 	h.blockFetcher.Enqueue(peer.ID(), block, sidecars)
 
 	// Assuming the block is importable by the peer, but possibly not yet done so,
-	// calculate the head hash and TD that the peer truly must have.
+	// calculate the head hash, number and TD that the peer truly must have.
 	var (
-		trueHead = block.ParentHash()
-		trueTD   = new(big.Int).Sub(td, block.Difficulty())
+		trueHead   = block.ParentHash()
+		trueNumber = block.NumberU64() - 1
+		trueTD     = new(big.Int).Sub(td, block.Difficulty())
 	)
-	// Update the peer's total difficulty if better than the previous
-	if _, td := peer.Head(); trueTD.Cmp(td) > 0 {
+	// Update the peer's head if better than the previous: by total difficulty,
+	// or by number after the finality-only fork where the total difficulty stops
+	// growing
+	_, headTD := peer.Head()
+	number, _ := peer.HeadNumbers()
+	if trueTD.Cmp(headTD) > 0 || (trueNumber > number && h.chain.Config().IsFinalityOnly(block.Number())) {
+		if trueTD.Cmp(headTD) < 0 {
+			trueTD = headTD
+		}
 		peer.SetHead(trueHead, trueTD)
+		peer.SetHeadNumbers(trueNumber, (*handler)(h).finalizedNumber(trueHead, trueNumber))
 		h.chainSync.handlePeerEvent()
 	}
 	return nil
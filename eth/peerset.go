@@ -317,6 +317,27 @@ func (ps *peerSet) peerWithHighestTD() *eth.Peer {
 	return bestPeer
 }
 
+// peerWithHighestHead retrieves the known peer whose head finalizes the highest
+// block, then whose head is the highest, to rank the peers after the finality-only
+// fork where their total difficulties stop growing.
+func (ps *peerSet) peerWithHighestHead() *eth.Peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var (
+		bestPeer      *eth.Peer
+		bestNumber    uint64
+		bestFinalized uint64
+	)
+	for _, p := range ps.peers {
+		number, finalized := p.HeadNumbers()
+		if bestPeer == nil || finalized > bestFinalized || (finalized == bestFinalized && number > bestNumber) {
+			bestPeer, bestNumber, bestFinalized = p.Peer, number, finalized
+		}
+	}
+	return bestPeer
+}
+
 func (ps *peerSet) roninPeerWithoutVote(hash common.Hash) []*ronin.Peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
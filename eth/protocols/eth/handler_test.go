@@ -80,6 +80,8 @@ func newTestBackendWithGenerator(blocks int, generator func(int, *core.BlockGen)
 	}
 	txconfig := legacypool.DefaultConfig
 	txconfig.Journal = "" // Don't litter the disk with test journals
+	txconfig.MinedJournal = ""
+	txconfig.LocalsJournal = ""
 
 	legacyPool := legacypool.New(txconfig, params.TestChainConfig, chain)
 	txPool, err := txpool.New(txconfig.PriceLimit, chain, []txpool.SubPool{legacyPool})
@@ -526,7 +528,8 @@ func testGetNodeData(t *testing.T, protocol uint, drop bool) {
 }
 
 // Tests that the transaction receipts can be retrieved based on hashes.
-func TestGetBlockReceipts66(t *testing.T) { testGetBlockReceipts(t, ETH66) }
+func TestGetBlockReceipts66(t *testing.T)  { testGetBlockReceipts(t, ETH66) }
+func TestGetBlockReceipts101(t *testing.T) { testGetBlockReceipts(t, ETH101) }
 
 func testGetBlockReceipts(t *testing.T, protocol uint) {
 	t.Parallel()
@@ -589,10 +592,21 @@ func testGetBlockReceipts(t *testing.T, protocol uint) {
 		RequestId:         123,
 		GetReceiptsPacket: hashes,
 	})
-	if err := p2p.ExpectMsg(peer.app, ReceiptsMsg, ReceiptsPacket66{
+	var want interface{} = ReceiptsPacket66{
 		RequestId:      123,
 		ReceiptsPacket: receipts,
-	}); err != nil {
+	}
+	if protocol >= ETH101 {
+		bloomless := make(ReceiptsNoBloomPacket, len(receipts))
+		for i, block := range receipts {
+			bloomless[i] = types.Receipts(block).NoBloom()
+		}
+		want = ReceiptsNoBloomPacket101{
+			RequestId:             123,
+			ReceiptsNoBloomPacket: bloomless,
+		}
+	}
+	if err := p2p.ExpectMsg(peer.app, ReceiptsMsg, want); err != nil {
 		t.Errorf("receipts mismatch: %v", err)
 	}
 }
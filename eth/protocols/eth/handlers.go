@@ -256,15 +256,24 @@ func answerGetReceiptsQuery(backend Backend, query GetReceiptsPacket, peer *Peer
 			lookups >= 2*maxReceiptsServe {
 			break
 		}
-		// Retrieve the requested block's receipts
-		results := backend.Chain().GetReceiptsByHash(hash)
-		if results == nil {
+		// Retrieve the requested block's receipts, without blooms if supported
+		var (
+			encoded []byte
+			err     error
+		)
+		if peer.Version() >= ETH101 {
+			encoded = backend.Chain().GetReceiptsNoBloomRLP(hash)
+		} else if results := backend.Chain().GetReceiptsByHash(hash); results != nil {
+			encoded, err = rlp.EncodeToBytes(results)
+		}
+		if encoded == nil && err == nil {
 			if header := backend.Chain().GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
 				continue
 			}
+			encoded = rlp.EmptyList
 		}
-		// If known, encode and queue for response packet
-		if encoded, err := rlp.EncodeToBytes(results); err != nil {
+		// If known, queue for response packet
+		if err != nil {
 			log.Error("Failed to encode receipt", "err", err)
 		} else {
 			receipts = append(receipts, encoded)
@@ -400,6 +409,25 @@ func handleReceipts66(backend Backend, msg Decoder, peer *Peer) error {
 	return backend.Handle(peer, &res.ReceiptsPacket)
 }
 
+func handleReceipts101(backend Backend, msg Decoder, peer *Peer) error {
+	// A batch of receipts without blooms arrived to one of our previous requests,
+	// the blooms are recomputed on decoding
+	res := new(ReceiptsNoBloomPacket101)
+	if err := msg.Decode(res); err != nil {
+		return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+	}
+	requestTracker.Fulfil(peer.id, peer.version, ReceiptsMsg, res.RequestId)
+
+	receipts := make(ReceiptsPacket, len(res.ReceiptsNoBloomPacket))
+	for i, block := range res.ReceiptsNoBloomPacket {
+		receipts[i] = make([]*types.Receipt, len(block))
+		for j, receipt := range block {
+			receipts[i][j] = (*types.Receipt)(receipt)
+		}
+	}
+	return backend.Handle(peer, &receipts)
+}
+
 func handleNewPooledTransactionHashes66(backend Backend, msg Decoder, peer *Peer) error {
 	// New transaction announcement arrived, make sure we have
 	// a valid and fresh chain to handle them
@@ -64,8 +64,10 @@ type Peer struct {
 	rw        p2p.MsgReadWriter // Input/output streams for snap
 	version   uint              // Protocol version negotiated
 
-	head common.Hash // Latest advertised head block hash
-	td   *big.Int    // Latest advertised head block total difficulty
+	head      common.Hash // Latest advertised head block hash
+	td        *big.Int    // Latest advertised head block total difficulty
+	number    uint64      // Latest advertised head block number (zero = unknown)
+	finalized uint64      // Number of the block finalized by the advertised head (zero = unknown)
 
 	knownBlocks     *protocols.KnownCache  // Set of block hashes known to be known by this peer
 	queuedBlocks    chan *blockPropagation // Queue of blocks to broadcast to the peer
@@ -140,6 +142,28 @@ func (p *Peer) SetHead(hash common.Hash, td *big.Int) {
 	p.td.Set(td)
 }
 
+// HeadNumbers retrieves the number of the head of the peer and the one of the
+// block finalized by it, zero if unknown.
+func (p *Peer) HeadNumbers() (number uint64, finalized uint64) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.number, p.finalized
+}
+
+// SetHeadNumbers updates the number of the head of the peer and the one of the
+// block finalized by it. The finalized number never decreases, an unknown one
+// keeping the previous.
+func (p *Peer) SetHeadNumbers(number uint64, finalized uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.number = number
+	if finalized > p.finalized {
+		p.finalized = finalized
+	}
+}
+
 // KnownBlock returns whether peer is known to already have a block.
 func (p *Peer) KnownBlock(hash common.Hash) bool {
 	return p.knownBlocks.Contains(hash)
@@ -32,6 +32,7 @@ import (
 const (
 	ETH66  = 66
 	ETH100 = 100
+	ETH101 = 101 // ETH100 with the receipts sent without blooms
 )
 
 // ProtocolName is the official short name of the `eth` protocol used during
@@ -40,11 +41,11 @@ const ProtocolName = "eth"
 
 // ProtocolVersions are the supported versions of the `eth` protocol (first
 // is primary).
-var ProtocolVersions = []uint{ETH100, ETH66}
+var ProtocolVersions = []uint{ETH101, ETH100, ETH66}
 
 // protocolLengths are the number of implemented message corresponding to
 // different protocol versions.
-var protocolLengths = map[uint]uint64{ETH100: 17, ETH66: 17}
+var protocolLengths = map[uint]uint64{ETH101: 17, ETH100: 17, ETH66: 17}
 
 // maxMessageSize is the maximum cap on the size of a protocol message.
 const maxMessageSize = 10 * 1024 * 1024
@@ -267,32 +268,33 @@ type BlockBodiesRLPPacket100 struct {
 type BlockBody struct {
 	Transactions []*types.Transaction // Transactions contained within a block
 	Uncles       []*types.Header      // Uncles contained within a block
+	Withdrawals  []*types.Withdrawal  `rlp:"optional"` // Bridge withdrawals contained within a block after the settlement fork
+	Deposits     []*types.Deposit     `rlp:"optional"` // Bridge deposits contained within a block after the settlement fork
 }
 
-// Unpack retrieves the transactions and uncles from the range packet and returns
-// them in a split flat format that's more consistent with the internal data structures.
-func (p *BlockBodiesPacket) Unpack() ([][]*types.Transaction, [][]*types.Header) {
+// Unpack retrieves the transactions, uncles and settlement operations from the
+// range packet and returns them in a split flat format that's more consistent
+// with the internal data structures.
+func (p *BlockBodiesPacket) Unpack() ([][]*types.Transaction, [][]*types.Header, [][]*types.Withdrawal, [][]*types.Deposit) {
 	var (
-		txset    = make([][]*types.Transaction, len(*p))
-		uncleset = make([][]*types.Header, len(*p))
+		txset         = make([][]*types.Transaction, len(*p))
+		uncleset      = make([][]*types.Header, len(*p))
+		withdrawalset = make([][]*types.Withdrawal, len(*p))
+		depositset    = make([][]*types.Deposit, len(*p))
 	)
 	for i, body := range *p {
 		txset[i], uncleset[i] = body.Transactions, body.Uncles
+		withdrawalset[i], depositset[i] = body.Withdrawals, body.Deposits
 	}
-	return txset, uncleset
+	return txset, uncleset, withdrawalset, depositset
 }
 
-// Unpack retrieves the transactions, uncles, blobs, proofs, and commitments from the range packet and returns
-// them in a split flat format that's more consistent with the internal data structures.
-func (p *BlockBodiesPacket100) Unpack() ([][]*types.Transaction, [][]*types.Header, [][]*types.BlobTxSidecar) {
-	var (
-		txset    = make([][]*types.Transaction, len(p.BlockBodiesPacket))
-		uncleset = make([][]*types.Header, len(p.BlockBodiesPacket))
-	)
-	for i, body := range p.BlockBodiesPacket {
-		txset[i], uncleset[i] = body.Transactions, body.Uncles
-	}
-	return txset, uncleset, p.Sidecars
+// Unpack retrieves the transactions, uncles, settlement operations, blobs, proofs,
+// and commitments from the range packet and returns them in a split flat format
+// that's more consistent with the internal data structures.
+func (p *BlockBodiesPacket100) Unpack() ([][]*types.Transaction, [][]*types.Header, [][]*types.Withdrawal, [][]*types.Deposit, [][]*types.BlobTxSidecar) {
+	txset, uncleset, withdrawalset, depositset := p.BlockBodiesPacket.Unpack()
+	return txset, uncleset, withdrawalset, depositset, p.Sidecars
 }
 
 // GetNodeDataPacket represents a trie node data query.
@@ -331,6 +333,16 @@ type ReceiptsPacket66 struct {
 	ReceiptsPacket
 }
 
+// ReceiptsNoBloomPacket is the network packet for block receipts distribution
+// without blooms, which are recomputed from the logs on decoding.
+type ReceiptsNoBloomPacket [][]*types.ReceiptNoBloom
+
+// ReceiptsNoBloomPacket101 is the eth/101 version of ReceiptsPacket.
+type ReceiptsNoBloomPacket101 struct {
+	RequestId uint64
+	ReceiptsNoBloomPacket
+}
+
 // ReceiptsRLPPacket is used for receipts, when we already have it encoded
 type ReceiptsRLPPacket []rlp.RawValue
 
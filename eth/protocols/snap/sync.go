@@ -474,10 +474,30 @@ type Syncer struct {
 	startTime time.Time // Time instance when snapshot sync started
 	logTime   time.Time // Time instance when status was last reported
 
+	extProgress Progress // Snapshot of the sync statistics for external reporting (protected by lock)
+
 	pend sync.WaitGroup // Tracks network request goroutines for graceful shutdown
 	lock sync.RWMutex   // Protects fields that can change outside of sync (peers, reqs, root)
 }
 
+// Progress is a snapshot of the snap sync statistics, including the healing
+// work that is still pending.
+type Progress struct {
+	AccountSynced      uint64             // Number of accounts downloaded
+	AccountBytes       common.StorageSize // Number of account trie bytes persisted to disk
+	BytecodeSynced     uint64             // Number of bytecodes downloaded
+	BytecodeBytes      common.StorageSize // Number of bytecode bytes downloaded
+	StorageSynced      uint64             // Number of storage slots downloaded
+	StorageBytes       common.StorageSize // Number of storage trie bytes persisted to disk
+	TrienodeHealSynced uint64             // Number of state trie nodes downloaded
+	TrienodeHealBytes  common.StorageSize // Number of state trie bytes persisted to disk
+	BytecodeHealSynced uint64             // Number of bytecodes downloaded
+	BytecodeHealBytes  common.StorageSize // Number of bytecodes persisted to disk
+
+	TrienodeHealPending uint64 // Number of state trie nodes pending
+	BytecodeHealPending uint64 // Number of bytecodes pending
+}
+
 // NewSyncer creates a new snapshot syncer to download the Ethereum state over the
 // snap protocol.
 func NewSyncer(db ethdb.KeyValueStore, scheme string) *Syncer {
@@ -586,6 +606,7 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 	}
 	// Retrieve the previous sync status from LevelDB and abort if already synced
 	s.loadSyncStatus()
+	s.updateProgress()
 	if len(s.tasks) == 0 && s.healer.scheduler.Pending() == 0 {
 		log.Debug("Snapshot sync already completed")
 		return nil
@@ -2996,8 +3017,42 @@ func (s *Syncer) onHealState(paths [][]byte, value []byte) error {
 // hashSpace is the total size of the 256 bit hash space for accounts.
 var hashSpace = new(big.Int).Exp(common.Big2, common.Big256, nil)
 
+// Progress returns the statistics of the current or last snap sync cycle.
+func (s *Syncer) Progress() Progress {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.extProgress
+}
+
+// updateProgress refreshes the statistics exposed to external progress reporting.
+// It must be called from the sync loop, which owns the counters.
+func (s *Syncer) updateProgress() {
+	progress := Progress{
+		AccountSynced:      s.accountSynced,
+		AccountBytes:       s.accountBytes,
+		BytecodeSynced:     s.bytecodeSynced,
+		BytecodeBytes:      s.bytecodeBytes,
+		StorageSynced:      s.storageSynced,
+		StorageBytes:       s.storageBytes,
+		TrienodeHealSynced: s.trienodeHealSynced,
+		TrienodeHealBytes:  s.trienodeHealBytes,
+		BytecodeHealSynced: s.bytecodeHealSynced,
+		BytecodeHealBytes:  s.bytecodeHealBytes,
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.healer != nil {
+		progress.TrienodeHealPending = uint64(len(s.healer.trieTasks))
+		progress.BytecodeHealPending = uint64(len(s.healer.codeTasks))
+	}
+	s.extProgress = progress
+}
+
 // report calculates various status reports and provides it to the user.
 func (s *Syncer) report(force bool) {
+	s.updateProgress()
 	if len(s.tasks) > 0 {
 		s.reportSyncProgress(force)
 		return
@@ -780,6 +780,15 @@ func testSyncWithStorage(t *testing.T, scheme string) {
 	}
 	close(done)
 	verifyTrie(scheme, syncer.db, sourceAccountTrie.Hash(), t)
+
+	progress := syncer.Progress()
+	if progress.AccountSynced != 3 || progress.StorageSynced != 3*3000 {
+		t.Errorf("sync progress mismatch: have %d accounts, %d slots, want %d accounts, %d slots",
+			progress.AccountSynced, progress.StorageSynced, 3, 3*3000)
+	}
+	if progress.TrienodeHealPending != 0 || progress.BytecodeHealPending != 0 {
+		t.Errorf("pending heal tasks after sync: %d trie nodes, %d bytecodes", progress.TrienodeHealPending, progress.BytecodeHealPending)
+	}
 }
 
 // TestMultiSyncManyUseless contains one good peer, and many which doesn't return anything valuable at all
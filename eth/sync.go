@@ -48,6 +48,9 @@ func (h *handler) syncTransactions(p *eth.Peer) {
 	pending := h.txpool.Pending(&txpool.PendingFilter{OnlyPlainTxs: true})
 	for _, batch := range pending {
 		for _, tx := range batch {
+			if h.txpool.Withheld(tx.Hash) {
+				continue
+			}
 			txs = append(txs, tx.Resolve())
 		}
 	}
@@ -165,16 +168,39 @@ func (cs *chainSyncer) nextSyncOp() *chainSyncOp {
 	if peer == nil {
 		return nil
 	}
-	mode, ourTD := cs.modeAndLocalHead()
+	mode, ourTD, ourHead := cs.modeAndLocalHead()
 	if mode == downloader.FastSync && atomic.LoadUint32(&cs.handler.snapSync) == 1 {
 		// Fast sync via the snap protocol
 		mode = downloader.SnapSync
 	}
 	op := peerToSyncOp(mode, peer)
-	if op.td.Cmp(ourTD) <= 0 {
+	if op.td.Cmp(ourTD) > 0 {
+		return op
+	}
+	// The total difficulty stops growing at the finality-only fork, past which
+	// the peers are ranked by their finalized and head blocks instead
+	if !cs.handler.chain.Config().IsFinalityOnly(new(big.Int).SetUint64(ourHead + 1)) {
+		return nil // We're in sync.
+	}
+	peer = cs.handler.peers.peerWithHighestHead()
+	if peer == nil {
+		return nil
+	}
+	// A peer whose head finalizes less than ours can't lead to a better chain,
+	// the finalized block of a head not known locally being unknown
+	number, finalized := peer.HeadNumbers()
+	if number <= ourHead || (finalized != 0 && finalized < cs.localFinalized()) {
 		return nil // We're in sync.
 	}
-	return op
+	return peerToSyncOp(mode, peer)
+}
+
+// localFinalized returns the number of the local finalized block, zero if none.
+func (cs *chainSyncer) localFinalized() uint64 {
+	if final := cs.handler.chain.CurrentFinalBlock(); final != nil {
+		return final.Number.Uint64()
+	}
+	return 0
 }
 
 func peerToSyncOp(mode downloader.SyncMode, p *eth.Peer) *chainSyncOp {
@@ -182,12 +208,12 @@ func peerToSyncOp(mode downloader.SyncMode, p *eth.Peer) *chainSyncOp {
 	return &chainSyncOp{mode: mode, peer: p, td: peerTD, head: peerHead}
 }
 
-func (cs *chainSyncer) modeAndLocalHead() (downloader.SyncMode, *big.Int) {
+func (cs *chainSyncer) modeAndLocalHead() (downloader.SyncMode, *big.Int, uint64) {
 	// If we're in fast sync mode, return that directly
 	if atomic.LoadUint32(&cs.handler.fastSync) == 1 {
 		block := cs.handler.chain.CurrentFastBlock()
 		td := cs.handler.chain.GetTd(block.Hash(), block.NumberU64())
-		return downloader.FastSync, td
+		return downloader.FastSync, td, block.NumberU64()
 	}
 	// We are probably in full sync, but we might have rewound to before the
 	// fast sync pivot, check if we should reenable
@@ -196,7 +222,7 @@ func (cs *chainSyncer) modeAndLocalHead() (downloader.SyncMode, *big.Int) {
 		if head.NumberU64() < *pivot {
 			block := cs.handler.chain.CurrentFastBlock()
 			td := cs.handler.chain.GetTd(block.Hash(), block.NumberU64())
-			return downloader.FastSync, td
+			return downloader.FastSync, td, block.NumberU64()
 		}
 	}
 	// We are in a full sync, but the associated head state is missing. To complete
@@ -206,11 +232,11 @@ func (cs *chainSyncer) modeAndLocalHead() (downloader.SyncMode, *big.Int) {
 		block := cs.handler.chain.CurrentFastBlock()
 		td := cs.handler.chain.GetTd(block.Hash(), block.NumberU64())
 		log.Info("Reenabled snap sync as chain is stateless")
-		return downloader.SnapSync, td
+		return downloader.SnapSync, td, block.NumberU64()
 	}
 	// Nope, we're really full syncing
 	td := cs.handler.chain.GetTd(head.Hash(), head.NumberU64())
-	return downloader.FullSync, td
+	return downloader.FullSync, td, head.NumberU64()
 }
 
 // startSync launches doSync in a new goroutine.
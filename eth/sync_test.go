@@ -17,14 +17,22 @@
 package eth
 
 import (
+	"math/big"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 // Tests that fast sync is disabled after a successful sync cycle.
@@ -77,3 +85,75 @@ func testFastSyncDisabling(t *testing.T, protocol uint) {
 		t.Fatalf("fast sync not disabled after successful synchronisation")
 	}
 }
+
+// Tests that a node past the finality-only fork, where the total difficulty stops
+// growing, still syncs with a peer whose chain is longer.
+func TestFinalityOnlySync66(t *testing.T) { testFinalityOnlySync(t, eth.ETH66) }
+
+func testFinalityOnlySync(t *testing.T, protocol uint) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.FinalityOnlyBlock = big.NewInt(8)
+	gspec := &core.Genesis{
+		Config: &config,
+		Alloc:  core.GenesisAlloc{testAddr: {Balance: big.NewInt(1000000)}},
+	}
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 32, nil)
+
+	newHandler := func(blocks []*types.Block) *testHandler {
+		db := rawdb.NewMemoryDatabase()
+		gspec.MustCommit(db, trie.NewDatabase(db, nil))
+
+		chain, err := core.NewBlockChain(db, nil, gspec, nil, ethash.NewFaker(), vm.Config{}, nil, nil)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		if _, err := chain.InsertChain(blocks, nil); err != nil {
+			t.Fatalf("failed to insert chain: %v", err)
+		}
+		txpool := newTestTxPool()
+		handler, _ := newHandler(&handlerConfig{
+			Database:   db,
+			Chain:      chain,
+			TxPool:     txpool,
+			Network:    1,
+			Sync:       downloader.FullSync,
+			BloomCache: 1,
+		})
+		handler.Start(1) // Sync with a single peer without waiting for the force timer
+		return &testHandler{db: db, chain: chain, txpool: txpool, handler: handler}
+	}
+	// Create a source with the whole chain and a sink stopping past the fork
+	source := newHandler(blocks)
+	defer source.close()
+	sink := newHandler(blocks[:16])
+	defer sink.close()
+
+	sourceHead, sinkHead := source.chain.CurrentBlock(), sink.chain.CurrentBlock()
+	if source.chain.GetTd(sourceHead.Hash(), 32).Cmp(sink.chain.GetTd(sinkHead.Hash(), 16)) != 0 {
+		t.Fatalf("total difficulty still growing after the fork")
+	}
+	sourcePipe, sinkPipe := p2p.MsgPipe()
+	defer sourcePipe.Close()
+	defer sinkPipe.Close()
+
+	sourcePeer := eth.NewPeer(protocol, p2p.NewPeer(enode.ID{1}, "", nil), sourcePipe, source.txpool)
+	sinkPeer := eth.NewPeer(protocol, p2p.NewPeer(enode.ID{2}, "", nil), sinkPipe, sink.txpool)
+	defer sourcePeer.Close()
+	defer sinkPeer.Close()
+
+	go source.handler.runEthPeer(sourcePeer, func(peer *eth.Peer) error {
+		return eth.Handle((*ethHandler)(source.handler), peer)
+	})
+	go sink.handler.runEthPeer(sinkPeer, func(peer *eth.Peer) error {
+		return eth.Handle((*ethHandler)(sink.handler), peer)
+	})
+	// Wait for the sink to learn the head of the source and sync with it
+	for i := 0; i < 250 && sink.chain.CurrentBlock().Hash() != sourceHead.Hash(); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if head := sink.chain.CurrentBlock(); head.Hash() != sourceHead.Hash() {
+		t.Fatalf("sink head mismatch: have %d, want %d", head.NumberU64(), sourceHead.NumberU64())
+	}
+}
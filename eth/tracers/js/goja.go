@@ -27,9 +27,11 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	jsassets "github.com/ethereum/go-ethereum/eth/tracers/js/internal/tracers"
+	"github.com/holiman/uint256"
 )
 
 var assetTracers = make(map[string]string)
@@ -96,7 +98,7 @@ func fromBuf(vm *goja.Runtime, bufType goja.Value, buf goja.Value, allowString b
 // JS functions on the relevant EVM hooks. It uses Goja as its JS engine.
 type jsTracer struct {
 	vm                *goja.Runtime
-	env               *vm.EVM
+	env               *hooks.VMContext
 	toBig             toBigFn               // Converts a hex string into a JS bigint
 	toBuf             toBufFn               // Converts a []byte into a JS buffer
 	fromBuf           fromBufFn             // Converts an array, hex string or Uint8Array to a []byte
@@ -210,24 +212,40 @@ func newJsTracer(code string, ctx *tracers.Context, cfg json.RawMessage) (tracer
 	return t, nil
 }
 
-// CaptureTxStart implements the Tracer interface and is invoked at the beginning of
-// transaction processing.
-func (t *jsTracer) CaptureTxStart(gasLimit uint64, payer *common.Address) {
+// Hooks returns the tracing hooks of the tracer.
+func (t *jsTracer) Hooks() *hooks.Hooks {
+	return &hooks.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnTxEnd:   t.OnTxEnd,
+		OnEnter:   t.OnEnter,
+		OnExit:    t.OnExit,
+		OnOpcode:  t.OnOpcode,
+		OnFault:   t.OnFault,
+	}
+}
+
+// OnTxStart is invoked at the beginning of transaction processing.
+func (t *jsTracer) OnTxStart(env *hooks.VMContext, gasLimit uint64, payer *common.Address) {
+	t.env = env
 	t.gasLimit = gasLimit
+
+	db := &dbObj{db: env.StateDB, vm: t.vm, toBig: t.toBig, toBuf: t.toBuf, fromBuf: t.fromBuf}
+	t.dbValue = db.setupObject()
+	t.ctx["gasPrice"] = t.vm.ToValue(env.GasPrice)
+	t.ctx["block"] = t.vm.ToValue(env.BlockNumber.Uint64())
+	// Update list of precompiles based on current block
+	rules := env.ChainConfig.Rules(env.BlockNumber, env.Time)
+	t.activePrecompiles = vm.ActivePrecompiles(rules)
 }
 
-// CaptureTxEnd implements the Tracer interface and is invoked at the end of
-// transaction processing.
-func (t *jsTracer) CaptureTxEnd(restGas uint64) {
+// OnTxEnd is invoked at the end of transaction processing.
+func (t *jsTracer) OnTxEnd(restGas uint64) {
 	t.ctx["gasUsed"] = t.vm.ToValue(t.gasLimit - restGas)
 }
 
-// CaptureStart implements the Tracer interface to initialize the tracing operation.
-func (t *jsTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
-	t.env = env
-	db := &dbObj{db: env.StateDB, vm: t.vm, toBig: t.toBig, toBuf: t.toBuf, fromBuf: t.fromBuf}
-	t.dbValue = db.setupObject()
-	if create {
+// onStart fills in the details of the top level call frame.
+func (t *jsTracer) onStart(typ vm.OpCode, from common.Address, to common.Address, input []byte, value *big.Int) {
+	if typ == vm.CREATE || typ == vm.CREATE2 {
 		t.ctx["type"] = t.vm.ToValue("CREATE")
 	} else {
 		t.ctx["type"] = t.vm.ToValue("CALL")
@@ -236,21 +254,16 @@ func (t *jsTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Addr
 	t.ctx["to"] = t.vm.ToValue(to.Bytes())
 	t.ctx["input"] = t.vm.ToValue(input)
 	t.ctx["gas"] = t.vm.ToValue(t.gasLimit)
-	t.ctx["gasPrice"] = t.vm.ToValue(env.TxContext.GasPrice)
 	valueBig, err := t.toBig(t.vm, value.String())
 	if err != nil {
 		t.err = err
 		return
 	}
 	t.ctx["value"] = valueBig
-	t.ctx["block"] = t.vm.ToValue(env.Context.BlockNumber.Uint64())
-	// Update list of precompiles based on current block
-	rules := env.ChainConfig().Rules(env.Context.BlockNumber)
-	t.activePrecompiles = vm.ActivePrecompiles(rules)
 }
 
-// CaptureState implements the Tracer interface to trace a single step of VM execution.
-func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+// OnOpcode traces a single step of VM execution.
+func (t *jsTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope hooks.OpContext, rData []byte, depth int, err error) {
 	if !t.traceStep {
 		return
 	}
@@ -259,10 +272,10 @@ func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	}
 
 	log := t.log
-	log.op.op = op
-	log.memory.memory = scope.Memory
-	log.stack.stack = scope.Stack
-	log.contract.contract = scope.Contract
+	log.op.op = vm.OpCode(op)
+	log.memory.memory = scope.MemoryData()
+	log.stack.stack = scope.StackData()
+	log.contract.scope = scope
 	log.pc = pc
 	log.gas = gas
 	log.cost = cost
@@ -274,28 +287,32 @@ func (t *jsTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope
 	}
 }
 
-// CaptureFault implements the Tracer interface to trace an execution fault
-func (t *jsTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+// OnFault traces an execution fault.
+func (t *jsTracer) OnFault(pc uint64, op byte, gas, cost uint64, scope hooks.OpContext, depth int, err error) {
 	if t.err != nil {
 		return
 	}
-	// Other log fields have been already set as part of the last CaptureState.
+	// Other log fields have been already set as part of the last OnOpcode.
 	t.log.err = err
 	if _, err := t.fault(t.obj, t.logValue, t.dbValue); err != nil {
 		t.onError("fault", err)
 	}
 }
 
-// CaptureEnd is called after the call finishes to finalize the tracing.
-func (t *jsTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+// onEnd is called after the top level call finishes to finalize the tracing.
+func (t *jsTracer) onEnd(output []byte, err error) {
 	t.ctx["output"] = t.vm.ToValue(output)
 	if err != nil {
 		t.ctx["error"] = t.vm.ToValue(err.Error())
 	}
 }
 
-// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
-func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *jsTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if depth == 0 {
+		t.onStart(vm.OpCode(typ), from, to, input, value)
+		return
+	}
 	if !t.traceFrame {
 		return
 	}
@@ -303,7 +320,7 @@ func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Ad
 		return
 	}
 
-	t.frame.typ = typ.String()
+	t.frame.typ = vm.OpCode(typ).String()
 	t.frame.from = from
 	t.frame.to = to
 	t.frame.input = common.CopyBytes(input)
@@ -318,9 +335,13 @@ func (t *jsTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Ad
 	}
 }
 
-// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// OnExit is called when EVM exits a scope, even if the scope didn't
 // execute any code.
-func (t *jsTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+func (t *jsTracer) OnExit(depth int, output []byte, gasUsed uint64, err error) {
+	if depth == 0 {
+		t.onEnd(output, err)
+		return
+	}
 	if !t.traceFrame {
 		return
 	}
@@ -354,13 +375,9 @@ func (t *jsTracer) Stop(err error) {
 }
 
 // onError is called anytime the running JS code is interrupted
-// and returns an error. It in turn pings the EVM to cancel its
-// execution.
+// and returns an error. Any further hooks are skipped once it is set.
 func (t *jsTracer) onError(context string, err error) {
 	t.err = wrapError(context, err)
-	// `env` is set on CaptureStart which comes before any JS execution.
-	// So it should be non-nil.
-	t.env.Cancel()
 }
 
 func wrapError(context string, err error) error {
@@ -539,7 +556,7 @@ func (o *opObj) setupObject() *goja.Object {
 }
 
 type memoryObj struct {
-	memory *vm.Memory
+	memory []byte
 	vm     *goja.Runtime
 	toBig  toBigFn
 	toBuf  toBufFn
@@ -590,14 +607,14 @@ func (mo *memoryObj) GetUint(addr int64) goja.Value {
 
 // getUint returns the 32 bytes at the specified address interpreted as a uint.
 func (mo *memoryObj) getUint(addr int64) (*big.Int, error) {
-	if mo.memory.Len() < int(addr)+32 || addr < 0 {
-		return nil, fmt.Errorf("tracer accessed out of bound memory: available %d, offset %d, size %d", mo.memory.Len(), addr, 32)
+	if len(mo.memory) < int(addr)+32 || addr < 0 {
+		return nil, fmt.Errorf("tracer accessed out of bound memory: available %d, offset %d, size %d", len(mo.memory), addr, 32)
 	}
-	return new(big.Int).SetBytes(mo.memory.GetPtr(addr, 32)), nil
+	return new(big.Int).SetBytes(mo.memory[addr : addr+32]), nil
 }
 
 func (mo *memoryObj) Length() int {
-	return mo.memory.Len()
+	return len(mo.memory)
 }
 
 func (m *memoryObj) setupObject() *goja.Object {
@@ -609,7 +626,7 @@ func (m *memoryObj) setupObject() *goja.Object {
 }
 
 type stackObj struct {
-	stack *vm.Stack
+	stack []uint256.Int
 	vm    *goja.Runtime
 	toBig toBigFn
 }
@@ -630,14 +647,14 @@ func (s *stackObj) Peek(idx int) goja.Value {
 
 // peek returns the nth-from-the-top element of the stack.
 func (s *stackObj) peek(idx int) (*big.Int, error) {
-	if len(s.stack.Data()) <= idx || idx < 0 {
-		return nil, fmt.Errorf("tracer accessed out of bound stack: size %d, index %d", len(s.stack.Data()), idx)
+	if len(s.stack) <= idx || idx < 0 {
+		return nil, fmt.Errorf("tracer accessed out of bound stack: size %d, index %d", len(s.stack), idx)
 	}
-	return s.stack.Back(idx).ToBig(), nil
+	return s.stack[len(s.stack)-idx-1].ToBig(), nil
 }
 
 func (s *stackObj) Length() int {
-	return len(s.stack.Data())
+	return len(s.stack)
 }
 
 func (s *stackObj) setupObject() *goja.Object {
@@ -648,7 +665,7 @@ func (s *stackObj) setupObject() *goja.Object {
 }
 
 type dbObj struct {
-	db      vm.StateDB
+	db      hooks.StateDB
 	vm      *goja.Runtime
 	toBig   toBigFn
 	toBuf   toBufFn
@@ -740,14 +757,14 @@ func (do *dbObj) setupObject() *goja.Object {
 }
 
 type contractObj struct {
-	contract *vm.Contract
-	vm       *goja.Runtime
-	toBig    toBigFn
-	toBuf    toBufFn
+	scope hooks.OpContext
+	vm    *goja.Runtime
+	toBig toBigFn
+	toBuf toBufFn
 }
 
 func (co *contractObj) GetCaller() goja.Value {
-	caller := co.contract.Caller().Bytes()
+	caller := co.scope.Caller().Bytes()
 	res, err := co.toBuf(co.vm, caller)
 	if err != nil {
 		co.vm.Interrupt(err)
@@ -757,7 +774,7 @@ func (co *contractObj) GetCaller() goja.Value {
 }
 
 func (co *contractObj) GetAddress() goja.Value {
-	addr := co.contract.Address().Bytes()
+	addr := co.scope.Address().Bytes()
 	res, err := co.toBuf(co.vm, addr)
 	if err != nil {
 		co.vm.Interrupt(err)
@@ -767,7 +784,7 @@ func (co *contractObj) GetAddress() goja.Value {
 }
 
 func (co *contractObj) GetValue() goja.Value {
-	value := co.contract.Value()
+	value := co.scope.CallValue()
 	res, err := co.toBig(co.vm, value.String())
 	if err != nil {
 		co.vm.Interrupt(err)
@@ -777,7 +794,7 @@ func (co *contractObj) GetValue() goja.Value {
 }
 
 func (co *contractObj) GetInput() goja.Value {
-	input := common.CopyBytes(co.contract.Input)
+	input := common.CopyBytes(co.scope.CallInput())
 	res, err := co.toBuf(co.vm, input)
 	if err != nil {
 		co.vm.Interrupt(err)
@@ -24,6 +24,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 )
 
@@ -46,15 +47,14 @@ func init() {
 //	  0xc281d19e-0: 1
 //	}
 type fourByteTracer struct {
-	noopTracer
 	ids               map[string]int   // ids aggregates the 4byte ids found
 	interrupt         atomic.Bool      // Atomic flag to signal execution interruption
 	reason            error            // Textual reason for the interruption
-	activePrecompiles []common.Address // Updated on CaptureStart based on given rules
+	activePrecompiles []common.Address // Updated on OnTxStart based on given rules
 }
 
 // newFourByteTracer returns a native go tracer which collects
-// 4 byte-identifiers of a tx, and implements vm.EVMLogger.
+// 4 byte-identifiers of a tx.
 func newFourByteTracer(ctx *tracers.Context, _ json.RawMessage) (tracers.Tracer, error) {
 	t := &fourByteTracer{
 		ids: make(map[string]int),
@@ -78,20 +78,22 @@ func (t *fourByteTracer) store(id []byte, size int) {
 	t.ids[key] += 1
 }
 
-// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
-func (t *fourByteTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
-	// Update list of precompiles based on current block
-	rules := env.ChainConfig().Rules(env.Context.BlockNumber)
-	t.activePrecompiles = vm.ActivePrecompiles(rules)
-
-	// Save the outer calldata also
-	if len(input) >= 4 {
-		t.store(input[0:4], len(input)-4)
+// Hooks returns the tracing hooks of the tracer.
+func (t *fourByteTracer) Hooks() *hooks.Hooks {
+	return &hooks.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnEnter:   t.OnEnter,
 	}
 }
 
-// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
-func (t *fourByteTracer) CaptureEnter(op vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+// OnTxStart updates the list of precompiles based on the current block.
+func (t *fourByteTracer) OnTxStart(env *hooks.VMContext, gasLimit uint64, payer *common.Address) {
+	rules := env.ChainConfig.Rules(env.BlockNumber, env.Time)
+	t.activePrecompiles = vm.ActivePrecompiles(rules)
+}
+
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *fourByteTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
 	// Skip if tracing was interrupted
 	if t.interrupt.Load() {
 		return
@@ -99,6 +101,12 @@ func (t *fourByteTracer) CaptureEnter(op vm.OpCode, from common.Address, to comm
 	if len(input) < 4 {
 		return
 	}
+	// Save the outer calldata also
+	if depth == 0 {
+		t.store(input[0:4], len(input)-4)
+		return
+	}
+	op := vm.OpCode(typ)
 	// primarily we want to avoid CREATE/CREATE2/SELFDESTRUCT
 	if op != vm.DELEGATECALL && op != vm.STATICCALL &&
 		op != vm.CALL && op != vm.CALLCODE {
@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 )
 
@@ -98,7 +99,6 @@ type callFrameMarshaling struct {
 }
 
 type callTracer struct {
-	noopTracer
 	callstack []callFrame
 	config    callTracerConfig
 	gasLimit  uint64
@@ -112,7 +112,7 @@ type callTracerConfig struct {
 }
 
 // newCallTracer returns a native go tracer which tracks
-// call frames of a tx, and implements vm.EVMLogger.
+// call frames of a tx.
 func newCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
 	var config callTracerConfig
 	if cfg != nil {
@@ -125,29 +125,19 @@ func newCallTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, e
 	return &callTracer{callstack: make([]callFrame, 1), config: config}, nil
 }
 
-// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
-func (t *callTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
-	toCopy := to
-	t.callstack[0] = callFrame{
-		Type:  vm.CALL,
-		From:  from,
-		To:    &toCopy,
-		Input: common.CopyBytes(input),
-		Gas:   t.gasLimit,
-		Value: value,
-	}
-	if create {
-		t.callstack[0].Type = vm.CREATE
+// Hooks returns the tracing hooks of the tracer.
+func (t *callTracer) Hooks() *hooks.Hooks {
+	return &hooks.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnTxEnd:   t.OnTxEnd,
+		OnEnter:   t.OnEnter,
+		OnExit:    t.OnExit,
+		OnOpcode:  t.OnOpcode,
 	}
 }
 
-// CaptureEnd is called after the call finishes to finalize the tracing.
-func (t *callTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
-	t.callstack[0].processOutput(output, err)
-}
-
-// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
-func (t *callTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+// OnOpcode traces a single step of VM execution.
+func (t *callTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope hooks.OpContext, rData []byte, depth int, err error) {
 	// skip if the previous op caused an error
 	if err != nil {
 		return
@@ -164,12 +154,11 @@ func (t *callTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, sco
 	if t.interrupt.Load() {
 		return
 	}
-	switch op {
+	switch op := vm.OpCode(opcode); op {
 	case vm.LOG0, vm.LOG1, vm.LOG2, vm.LOG3, vm.LOG4:
 		size := int(op - vm.LOG0)
 
-		stack := scope.Stack
-		stackData := stack.Data()
+		stackData := scope.StackData()
 
 		// Don't modify the stack
 		mStart := stackData[len(stackData)-1]
@@ -180,19 +169,31 @@ func (t *callTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, sco
 			topics[i] = common.Hash(topic.Bytes32())
 		}
 
-		data, err := tracers.GetMemoryCopyPadded(scope.Memory, int64(mStart.Uint64()), int64(mSize.Uint64()))
+		data, err := tracers.GetMemoryCopyPadded(scope.MemoryData(), int64(mStart.Uint64()), int64(mSize.Uint64()))
 		if err != nil {
 			// mSize was unrealistically large
 			return
 		}
 
-		log := callLog{Address: scope.Contract.Address(), Topics: topics, Data: hexutil.Bytes(data)}
+		log := callLog{Address: scope.Address(), Topics: topics, Data: hexutil.Bytes(data)}
 		t.callstack[len(t.callstack)-1].Logs = append(t.callstack[len(t.callstack)-1].Logs, log)
 	}
 }
 
-// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
-func (t *callTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *callTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	toCopy := to
+	if depth == 0 {
+		t.callstack[0] = callFrame{
+			Type:  vm.OpCode(typ),
+			From:  from,
+			To:    &toCopy,
+			Input: common.CopyBytes(input),
+			Gas:   t.gasLimit,
+			Value: value,
+		}
+		return
+	}
 	if t.config.OnlyTopCall {
 		return
 	}
@@ -201,9 +202,8 @@ func (t *callTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.
 		return
 	}
 
-	toCopy := to
 	call := callFrame{
-		Type:  typ,
+		Type:  vm.OpCode(typ),
 		From:  from,
 		To:    &toCopy,
 		Input: common.CopyBytes(input),
@@ -213,9 +213,13 @@ func (t *callTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.
 	t.callstack = append(t.callstack, call)
 }
 
-// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// OnExit is called when EVM exits a scope, even if the scope didn't
 // execute any code.
-func (t *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+func (t *callTracer) OnExit(depth int, output []byte, gasUsed uint64, err error) {
+	if depth == 0 {
+		t.callstack[0].processOutput(output, err)
+		return
+	}
 	if t.config.OnlyTopCall {
 		return
 	}
@@ -233,11 +237,11 @@ func (t *callTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
 	t.callstack[size-1].Calls = append(t.callstack[size-1].Calls, call)
 }
 
-func (t *callTracer) CaptureTxStart(gasLimit uint64, payer *common.Address) {
+func (t *callTracer) OnTxStart(env *hooks.VMContext, gasLimit uint64, payer *common.Address) {
 	t.gasLimit = gasLimit
 }
 
-func (t *callTracer) CaptureTxEnd(restGas uint64) {
+func (t *callTracer) OnTxEnd(restGas uint64) {
 	t.callstack[0].GasUsed = t.gasLimit - restGas
 	if t.config.WithLog {
 		// Logs are not emitted when the call fails
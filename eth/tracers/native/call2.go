@@ -21,6 +21,7 @@ import (
 	"errors"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"math/big"
 	"strconv"
@@ -47,15 +48,14 @@ type callFrame2 struct {
 }
 
 type callTracer2 struct {
-	noopTracer
-	env       *vm.EVM
+	env       *hooks.VMContext
 	callstack []callFrame2
 	interrupt uint32 // Atomic flag to signal execution interruption
 	reason    error  // Textual reason for the interruption
 }
 
 // newCallTracer2 returns a native go tracer which tracks
-// call frames of a tx, and implements vm.EVMLogger.
+// call frames of a tx.
 func newCallTracer2(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
 	// First callframe contains tx context info
 	// and is populated on start and end.
@@ -63,52 +63,40 @@ func newCallTracer2(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer,
 	return t, nil
 }
 
-// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
-func (t *callTracer2) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
-	t.env = env
-	t.callstack[0] = callFrame2{
-		Type:  "CALL",
-		From:  addrToHex(from),
-		To:    addrToHex(to),
-		Input: bytesToHex(input),
-		Gas:   uintToHex(gas),
-		Value: bigToHex(value),
-	}
-	if create {
-		t.callstack[0].Type = "CREATE"
+// Hooks returns the tracing hooks of the tracer.
+func (t *callTracer2) Hooks() *hooks.Hooks {
+	return &hooks.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnEnter:   t.OnEnter,
+		OnExit:    t.OnExit,
 	}
 }
 
-// CaptureEnd is called after the call finishes to finalize the tracing.
-func (t *callTracer2) CaptureEnd(output []byte, gasUsed uint64, err error) {
-	t.callstack[0].GasUsed = uintToHex(gasUsed)
-	if err != nil {
-		t.callstack[0].Error = err.Error()
-		t.callstack[0].Output = bytesToHex(output)
-	} else {
-		t.callstack[0].Output = bytesToHex(output)
-	}
-}
-
-// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
-func (t *callTracer2) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
-}
-
-// CaptureFault implements the EVMLogger interface to trace an execution fault.
-func (t *callTracer2) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, _ *vm.ScopeContext, depth int, err error) {
+func (t *callTracer2) OnTxStart(env *hooks.VMContext, gasLimit uint64, payer *common.Address) {
+	t.env = env
 }
 
-// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
-func (t *callTracer2) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *callTracer2) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if depth == 0 {
+		t.callstack[0] = callFrame2{
+			Type:  vm.OpCode(typ).String(),
+			From:  addrToHex(from),
+			To:    addrToHex(to),
+			Input: bytesToHex(input),
+			Gas:   uintToHex(gas),
+			Value: bigToHex(value),
+		}
+		return
+	}
 	// Skip if tracing was interrupted
 	if atomic.LoadUint32(&t.interrupt) > 0 {
-		t.env.Cancel()
 		return
 	}
 
 	call := callFrame2{
-		Type:  typ.String(),
-		Order: t.env.Context.Counter,
+		Type:  vm.OpCode(typ).String(),
+		Order: t.env.Counter(),
 		From:  addrToHex(from),
 		To:    addrToHex(to),
 		Input: bytesToHex(input),
@@ -118,9 +106,17 @@ func (t *callTracer2) CaptureEnter(typ vm.OpCode, from common.Address, to common
 	t.callstack = append(t.callstack, call)
 }
 
-// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// OnExit is called when EVM exits a scope, even if the scope didn't
 // execute any code.
-func (t *callTracer2) CaptureExit(output []byte, gasUsed uint64, err error) {
+func (t *callTracer2) OnExit(depth int, output []byte, gasUsed uint64, err error) {
+	if depth == 0 {
+		t.callstack[0].GasUsed = uintToHex(gasUsed)
+		t.callstack[0].Output = bytesToHex(output)
+		if err != nil {
+			t.callstack[0].Error = err.Error()
+		}
+		return
+	}
 	size := len(t.callstack)
 	if size <= 1 {
 		return
@@ -21,7 +21,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 )
 
@@ -34,6 +34,7 @@ func init() {
 type muxTracer struct {
 	names   []string
 	tracers []tracers.Tracer
+	hooks   []*hooks.Hooks
 }
 
 // newMuxTracer returns a new mux tracer.
@@ -45,6 +46,7 @@ func newMuxTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, er
 		}
 	}
 	objects := make([]tracers.Tracer, 0, len(config))
+	objectHooks := make([]*hooks.Hooks, 0, len(config))
 	names := make([]string, 0, len(config))
 	for k, v := range config {
 		t, err := tracers.DefaultDirectory.New(k, ctx, v)
@@ -52,64 +54,76 @@ func newMuxTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, er
 			return nil, err
 		}
 		objects = append(objects, t)
+		objectHooks = append(objectHooks, t.Hooks())
 		names = append(names, k)
 	}
 
-	return &muxTracer{names: names, tracers: objects}, nil
+	return &muxTracer{names: names, tracers: objects, hooks: objectHooks}, nil
 }
 
-// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
-func (t *muxTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
-	for _, t := range t.tracers {
-		t.CaptureStart(env, from, to, create, input, gas, value)
-	}
-}
-
-// CaptureEnd is called after the call finishes to finalize the tracing.
-func (t *muxTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
-	for _, t := range t.tracers {
-		t.CaptureEnd(output, gasUsed, err)
+// Hooks returns the tracing hooks of the tracer, which fan out to the hooks of
+// every wrapped tracer.
+func (t *muxTracer) Hooks() *hooks.Hooks {
+	return &hooks.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnTxEnd:   t.OnTxEnd,
+		OnEnter:   t.OnEnter,
+		OnExit:    t.OnExit,
+		OnOpcode:  t.OnOpcode,
+		OnFault:   t.OnFault,
 	}
 }
 
-// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
-func (t *muxTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
-	for _, t := range t.tracers {
-		t.CaptureState(pc, op, gas, cost, scope, rData, depth, err)
+func (t *muxTracer) OnTxStart(env *hooks.VMContext, gasLimit uint64, payer *common.Address) {
+	for _, h := range t.hooks {
+		if h.OnTxStart != nil {
+			h.OnTxStart(env, gasLimit, payer)
+		}
 	}
 }
 
-// CaptureFault implements the EVMLogger interface to trace an execution fault.
-func (t *muxTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
-	for _, t := range t.tracers {
-		t.CaptureFault(pc, op, gas, cost, scope, depth, err)
+func (t *muxTracer) OnTxEnd(restGas uint64) {
+	for _, h := range t.hooks {
+		if h.OnTxEnd != nil {
+			h.OnTxEnd(restGas)
+		}
 	}
 }
 
-// CaptureEnter is called when EVM enters a new scope (via call, create or selfdestruct).
-func (t *muxTracer) CaptureEnter(typ vm.OpCode, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
-	for _, t := range t.tracers {
-		t.CaptureEnter(typ, from, to, input, gas, value)
+// OnEnter is called when EVM enters a new scope (via call, create or selfdestruct).
+func (t *muxTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	for _, h := range t.hooks {
+		if h.OnEnter != nil {
+			h.OnEnter(depth, typ, from, to, input, gas, value)
+		}
 	}
 }
 
-// CaptureExit is called when EVM exits a scope, even if the scope didn't
+// OnExit is called when EVM exits a scope, even if the scope didn't
 // execute any code.
-func (t *muxTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
-	for _, t := range t.tracers {
-		t.CaptureExit(output, gasUsed, err)
+func (t *muxTracer) OnExit(depth int, output []byte, gasUsed uint64, err error) {
+	for _, h := range t.hooks {
+		if h.OnExit != nil {
+			h.OnExit(depth, output, gasUsed, err)
+		}
 	}
 }
 
-func (t *muxTracer) CaptureTxStart(gasLimit uint64, payer *common.Address) {
-	for _, t := range t.tracers {
-		t.CaptureTxStart(gasLimit, payer)
+// OnOpcode traces a single step of VM execution.
+func (t *muxTracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope hooks.OpContext, rData []byte, depth int, err error) {
+	for _, h := range t.hooks {
+		if h.OnOpcode != nil {
+			h.OnOpcode(pc, op, gas, cost, scope, rData, depth, err)
+		}
 	}
 }
 
-func (t *muxTracer) CaptureTxEnd(restGas uint64) {
-	for _, t := range t.tracers {
-		t.CaptureTxEnd(restGas)
+// OnFault traces an execution fault.
+func (t *muxTracer) OnFault(pc uint64, op byte, gas, cost uint64, scope hooks.OpContext, depth int, err error) {
+	for _, h := range t.hooks {
+		if h.OnFault != nil {
+			h.OnFault(pc, op, gas, cost, scope, depth, err)
+		}
 	}
 }
 
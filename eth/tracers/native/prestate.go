@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/params"
@@ -55,8 +56,7 @@ type accountMarshaling struct {
 }
 
 type prestateTracer struct {
-	noopTracer
-	env       *vm.EVM
+	env       *hooks.VMContext
 	pre       state
 	post      state
 	create    bool
@@ -90,9 +90,25 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage) (tracers.Trace
 	}, nil
 }
 
-// CaptureStart implements the EVMLogger interface to initialize the tracing operation.
-func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
-	t.env = env
+// Hooks returns the tracing hooks of the tracer.
+func (t *prestateTracer) Hooks() *hooks.Hooks {
+	return &hooks.Hooks{
+		OnTxStart: t.OnTxStart,
+		OnTxEnd:   t.OnTxEnd,
+		OnEnter:   t.OnEnter,
+		OnExit:    t.OnExit,
+		OnOpcode:  t.OnOpcode,
+	}
+}
+
+// OnEnter records the accounts touched by the transaction once the top level
+// call frame is entered.
+func (t *prestateTracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if depth != 0 {
+		return
+	}
+	env := t.env
+	create := vm.OpCode(typ) == vm.CREATE || vm.OpCode(typ) == vm.CREATE2
 	t.create = create
 	t.to = to
 
@@ -101,8 +117,8 @@ func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to commo
 	}
 	t.lookupAccount(from)
 	t.lookupAccount(to)
-	t.lookupAccount(env.Context.Coinbase)
-	treasury := env.ChainConfig().RoninTreasuryAddress
+	t.lookupAccount(env.Coinbase)
+	treasury := env.ChainConfig.RoninTreasuryAddress
 	if treasury != nil {
 		t.lookupAccount(*treasury)
 	}
@@ -114,7 +130,7 @@ func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to commo
 	// The sender/payer balance is after reducing: value and gasLimit.
 	// We need to re-add them to get the pre-tx balance.
 	fromBal := new(big.Int).Set(t.pre[from].Balance)
-	gasPrice := env.TxContext.GasPrice
+	gasPrice := env.GasPrice
 	consumedGas := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(t.gasLimit))
 	diffFromBal := new(big.Int).Set(value)
 	if t.payer != nil {
@@ -126,7 +142,7 @@ func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to commo
 	// So we need to add blob fee back to sender and subtract from treasury to get the correct
 	// pre-tx balance.
 	if len(env.BlobHashes) > 0 {
-		blobFee := new(big.Int).Mul(big.NewInt(int64(len(env.BlobHashes)*params.BlobTxBlobGasPerBlob)), env.Context.BlobBaseFee)
+		blobFee := new(big.Int).Mul(big.NewInt(int64(len(env.BlobHashes)*params.BlobTxBlobGasPerBlob)), env.BlobBaseFee)
 		diffFromBal.Add(diffFromBal, blobFee)
 		if treasury != nil {
 			t.pre[*treasury].Balance = new(big.Int).Sub(t.pre[*treasury].Balance, blobFee)
@@ -142,9 +158,9 @@ func (t *prestateTracer) CaptureStart(env *vm.EVM, from common.Address, to commo
 	}
 }
 
-// CaptureEnd is called after the call finishes to finalize the tracing.
-func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
-	if t.config.DiffMode {
+// OnExit is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) OnExit(depth int, output []byte, gasUsed uint64, err error) {
+	if depth != 0 || t.config.DiffMode {
 		return
 	}
 
@@ -157,8 +173,8 @@ func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
 	}
 }
 
-// CaptureState implements the EVMLogger interface to trace a single step of VM execution.
-func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+// OnOpcode traces a single step of VM execution.
+func (t *prestateTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope hooks.OpContext, rData []byte, depth int, err error) {
 	if err != nil {
 		return
 	}
@@ -166,10 +182,10 @@ func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64,
 	if t.interrupt.Load() {
 		return
 	}
-	stack := scope.Stack
-	stackData := stack.Data()
+	op := vm.OpCode(opcode)
+	stackData := scope.StackData()
 	stackLen := len(stackData)
-	caller := scope.Contract.Address()
+	caller := scope.Address()
 	switch {
 	case stackLen >= 1 && (op == vm.SLOAD || op == vm.SSTORE):
 		slot := common.Hash(stackData[stackLen-1].Bytes32())
@@ -191,7 +207,11 @@ func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64,
 	case stackLen >= 4 && op == vm.CREATE2:
 		offset := stackData[stackLen-2]
 		size := stackData[stackLen-3]
-		init := scope.Memory.GetCopy(int64(offset.Uint64()), int64(size.Uint64()))
+		init, err := tracers.GetMemoryCopyPadded(scope.MemoryData(), int64(offset.Uint64()), int64(size.Uint64()))
+		if err != nil {
+			// size was unrealistically large
+			return
+		}
 		inithash := crypto.Keccak256(init)
 		salt := stackData[stackLen-4]
 		addr := crypto.CreateAddress2(caller, salt.Bytes32(), inithash)
@@ -200,12 +220,13 @@ func (t *prestateTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64,
 	}
 }
 
-func (t *prestateTracer) CaptureTxStart(gasLimit uint64, payer *common.Address) {
+func (t *prestateTracer) OnTxStart(env *hooks.VMContext, gasLimit uint64, payer *common.Address) {
+	t.env = env
 	t.gasLimit = gasLimit
 	t.payer = payer
 }
 
-func (t *prestateTracer) CaptureTxEnd(restGas uint64) {
+func (t *prestateTracer) OnTxEnd(restGas uint64) {
 	if !t.config.DiffMode {
 		return
 	}
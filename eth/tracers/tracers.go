@@ -23,7 +23,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/hooks"
 )
 
 // Context contains some contextual infos for a transaction execution that is not
@@ -35,10 +35,10 @@ type Context struct {
 	TxHash      common.Hash // Hash of the transaction being traced (zero if dangling call)
 }
 
-// Tracer interface extends vm.EVMLogger and additionally
-// allows collecting the tracing result.
+// Tracer interface provides the execution hooks to attach to the EVM and
+// additionally allows collecting the tracing result.
 type Tracer interface {
-	vm.EVMLogger
+	Hooks() *hooks.Hooks
 	GetResult() (json.RawMessage, error)
 	// Stop terminates execution of the tracer at the first opportune moment.
 	Stop(err error)
@@ -103,20 +103,31 @@ const (
 
 // GetMemoryCopyPadded returns offset + size as a new slice.
 // It zero-pads the slice if it extends beyond memory bounds.
-func GetMemoryCopyPadded(m *vm.Memory, offset, size int64) ([]byte, error) {
+func GetMemoryCopyPadded(m []byte, offset, size int64) ([]byte, error) {
 	if offset < 0 || size < 0 {
 		return nil, fmt.Errorf("offset or size must not be negative")
 	}
-	if int(offset+size) < m.Len() { // slice fully inside memory
-		return m.GetCopy(offset, size), nil
+	length := int64(len(m))
+	if offset+size < length { // slice fully inside memory
+		return memoryCopy(m, offset, size), nil
 	}
-	paddingNeeded := int(offset+size) - m.Len()
+	paddingNeeded := offset + size - length
 	if paddingNeeded > memoryPadLimit {
 		return nil, fmt.Errorf("reached limit for padding memory slice: %d", paddingNeeded)
 	}
 	cpy := make([]byte, size)
-	if overlap := int64(m.Len()) - offset; overlap > 0 {
-		copy(cpy, m.GetPtr(offset, overlap))
+	if overlap := length - offset; overlap > 0 {
+		copy(cpy, m[offset:offset+overlap])
 	}
 	return cpy, nil
 }
+
+// memoryCopy returns a copy of size bytes of the memory starting at offset.
+func memoryCopy(m []byte, offset, size int64) []byte {
+	if size == 0 {
+		return nil
+	}
+	cpy := make([]byte, size)
+	copy(cpy, m[offset:offset+size])
+	return cpy
+}
@@ -89,7 +89,7 @@ func BenchmarkTransactionTrace(b *testing.B) {
 		//EnableMemory: false,
 		//EnableReturnData: false,
 	})
-	evm := vm.NewEVM(context, txContext, statedb, params.AllEthashProtocolChanges, vm.Config{Tracer: tracer})
+	evm := vm.NewEVM(context, txContext, statedb, params.AllEthashProtocolChanges, vm.Config{Tracer: tracer.Hooks()})
 	msg, err := tx.AsMessage(signer, nil)
 	if err != nil {
 		b.Fatalf("failed to prepare transaction for tracing: %v", err)
@@ -131,7 +131,7 @@ func TestMemCopying(t *testing.T) {
 	} {
 		mem := vm.NewMemory()
 		mem.Resize(uint64(tc.memsize))
-		cpy, err := GetMemoryCopyPadded(mem, tc.offset, tc.size)
+		cpy, err := GetMemoryCopyPadded(mem.Data(), tc.offset, tc.size)
 		if want := tc.wantErr; want != "" {
 			if err == nil {
 				t.Fatalf("test %d: want '%v' have no error", i, want)
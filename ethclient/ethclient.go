@@ -306,6 +306,19 @@ type rpcProgress struct {
 	HighestBlock  hexutil.Uint64
 	PulledStates  hexutil.Uint64
 	KnownStates   hexutil.Uint64
+
+	SyncedAccounts      hexutil.Uint64
+	SyncedAccountBytes  hexutil.Uint64
+	SyncedBytecodes     hexutil.Uint64
+	SyncedBytecodeBytes hexutil.Uint64
+	SyncedStorage       hexutil.Uint64
+	SyncedStorageBytes  hexutil.Uint64
+	HealedTrienodes     hexutil.Uint64
+	HealedTrienodeBytes hexutil.Uint64
+	HealedBytecodes     hexutil.Uint64
+	HealedBytecodeBytes hexutil.Uint64
+	HealingTrienodes    hexutil.Uint64
+	HealingBytecode     hexutil.Uint64
 }
 
 // SyncProgress retrieves the current progress of the sync algorithm. If there's
@@ -330,6 +343,19 @@ func (ec *Client) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, err
 		HighestBlock:  uint64(progress.HighestBlock),
 		PulledStates:  uint64(progress.PulledStates),
 		KnownStates:   uint64(progress.KnownStates),
+
+		SyncedAccounts:      uint64(progress.SyncedAccounts),
+		SyncedAccountBytes:  uint64(progress.SyncedAccountBytes),
+		SyncedBytecodes:     uint64(progress.SyncedBytecodes),
+		SyncedBytecodeBytes: uint64(progress.SyncedBytecodeBytes),
+		SyncedStorage:       uint64(progress.SyncedStorage),
+		SyncedStorageBytes:  uint64(progress.SyncedStorageBytes),
+		HealedTrienodes:     uint64(progress.HealedTrienodes),
+		HealedTrienodeBytes: uint64(progress.HealedTrienodeBytes),
+		HealedBytecodes:     uint64(progress.HealedBytecodes),
+		HealedBytecodeBytes: uint64(progress.HealedBytecodeBytes),
+		HealingTrienodes:    uint64(progress.HealingTrienodes),
+		HealingBytecode:     uint64(progress.HealingBytecode),
 	}, nil
 }
 
@@ -43,7 +43,8 @@ type KeyValueStater interface {
 	Stat(property string) (string, error)
 }
 
-// Compacter wraps the Compact method of a backing data store.
+// Compacter wraps the Compact and ThrottleCompactions methods of a backing data
+// store.
 type Compacter interface {
 	// Compact flattens the underlying data store for the given key range. In essence,
 	// deleted and overwritten versions are discarded, and the data is rearranged to
@@ -53,6 +54,12 @@ type Compacter interface {
 	// is treated as a key after all keys in the data store. If both is nil then it
 	// will compact entire data store.
 	Compact(start []byte, limit []byte) error
+
+	// ThrottleCompactions limits the background compactions of the data store
+	// to a single one at a time while set, keeping the disk available for the
+	// latency sensitive work. Stores running a single compaction at a time
+	// anyway ignore it.
+	ThrottleCompactions(throttle bool)
 }
 
 // KeyValueStore contains all the methods required to allow handling different
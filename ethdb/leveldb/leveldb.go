@@ -264,6 +264,10 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
 }
 
+// ThrottleCompactions is a no-op, leveldb runs a single table compaction at a
+// time anyway.
+func (db *Database) ThrottleCompactions(throttle bool) {}
+
 // Path returns the path to the database directory.
 func (db *Database) Path() string {
 	return db.fn
@@ -192,6 +192,9 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 	return nil
 }
 
+// ThrottleCompactions is a no-op, a memory database doesn't compact.
+func (db *Database) ThrottleCompactions(throttle bool) {}
+
 // Len returns the number of entries currently present in the memory database.
 //
 // Note, this method is only used for testing (i.e. not public in general) and
@@ -84,6 +84,7 @@ type Database struct {
 	writeDelayStartTime time.Time     // The start time of the latest write stall
 	writeDelayCount     atomic.Int64  // Total number of write stall counts
 	writeDelayTime      atomic.Int64  // Total time spent in write stalls
+	compThrottled       atomic.Bool   // Whether the background compactions are throttled
 
 	writeOptions *pebble.WriteOptions
 }
@@ -198,7 +199,13 @@ func New(file string, cache int, handles int, namespace string, readonly bool, e
 
 		// The default compaction concurrency(1 thread),
 		// Here use all available CPUs for faster compaction.
-		MaxConcurrentCompactions: func() int { return runtime.NumCPU() },
+		MaxConcurrentCompactions: func() int {
+			// Never return zero, pebble divides by the value
+			if db.compThrottled.Load() {
+				return 1
+			}
+			return runtime.NumCPU()
+		},
 
 		// Per-level options. Options for at least one level must be specified. The
 		// options for the last level are used for all subsequent levels.
@@ -436,6 +443,12 @@ func (d *Database) Compact(start []byte, limit []byte) error {
 	return d.db.Compact(start, limit, true) // Parallelization is preferred
 }
 
+// ThrottleCompactions limits the background compactions to a single one at a
+// time while set. The running compactions are not interrupted.
+func (d *Database) ThrottleCompactions(throttle bool) {
+	d.compThrottled.Store(throttle)
+}
+
 // Path returns the path to the database directory.
 func (d *Database) Path() string {
 	return d.fn
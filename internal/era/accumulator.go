@@ -0,0 +1,76 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ComputeAccumulator computes the accumulator root of the blocks of an archive:
+// the SSZ hash tree root of a list of (block hash, total difficulty) records,
+// limited to MaxEraBatchSize entries.
+func ComputeAccumulator(hashes []common.Hash, tds []*big.Int) (common.Hash, error) {
+	if len(hashes) != len(tds) {
+		return common.Hash{}, fmt.Errorf("mismatching hash and total difficulty counts: %d != %d", len(hashes), len(tds))
+	}
+	if len(hashes) > MaxEraBatchSize {
+		return common.Hash{}, fmt.Errorf("too many records: %d > %d", len(hashes), MaxEraBatchSize)
+	}
+	leaves := make([][32]byte, len(hashes))
+	for i, hash := range hashes {
+		if tds[i].Sign() < 0 || tds[i].BitLen() > 256 {
+			return common.Hash{}, fmt.Errorf("invalid total difficulty of record %d: %v", i, tds[i])
+		}
+		leaves[i] = sha256.Sum256(append(hash.Bytes(), encodeTD(tds[i])...))
+	}
+	root := merkleize(leaves, MaxEraBatchSize)
+
+	// Mix the length of the list into the root
+	var length [32]byte
+	binary.LittleEndian.PutUint64(length[:8], uint64(len(hashes)))
+	return sha256.Sum256(append(root[:], length[:]...)), nil
+}
+
+// merkleize computes the root of a binary merkle tree over the given leaves,
+// padded with zero leaves up to the limit, which must be a power of two.
+func merkleize(leaves [][32]byte, limit int) [32]byte {
+	var (
+		layer = leaves
+		zero  [32]byte // Root of an all-zero subtree of the current depth
+	)
+	for width := limit; width > 1; width /= 2 {
+		next := make([][32]byte, (len(layer)+1)/2)
+		for i := range next {
+			right := zero
+			if 2*i+1 < len(layer) {
+				right = layer[2*i+1]
+			}
+			next[i] = sha256.Sum256(append(layer[2*i][:], right[:]...))
+		}
+		zero = sha256.Sum256(append(zero[:], zero[:]...))
+		layer = next
+	}
+	if len(layer) == 0 {
+		return zero
+	}
+	return layer[0]
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+// Builder writes an archive of a contiguous range of blocks, one block at a
+// time, followed by the accumulator and the block index on Finalize:
+//
+//	archive     := Version | block-tuple* | Accumulator | BlockIndex
+//	block-tuple := CompressedHeader | CompressedBody | CompressedReceipts | TotalDifficulty
+//	BlockIndex  := start-number | offset* | count
+//
+// The offsets of the index are relative to the start of the index entry.
+type Builder struct {
+	w       *Writer
+	written uint64
+
+	start   *uint64
+	offsets []uint64
+	hashes  []common.Hash
+	tds     []*big.Int
+
+	buf    *bytes.Buffer
+	snappy *snappy.Writer
+}
+
+// NewBuilder creates an archive builder writing into w.
+func NewBuilder(w io.Writer) *Builder {
+	buf := bytes.NewBuffer(nil)
+	return &Builder{
+		w:      NewWriter(w),
+		buf:    buf,
+		snappy: snappy.NewBufferedWriter(buf),
+	}
+}
+
+// Add writes a block, its receipts and its total difficulty into the archive.
+func (b *Builder) Add(block *types.Block, receipts types.Receipts, td *big.Int) error {
+	header, err := rlp.EncodeToBytes(block.Header())
+	if err != nil {
+		return err
+	}
+	body, err := rlp.EncodeToBytes(block.Body())
+	if err != nil {
+		return err
+	}
+	rs, err := rlp.EncodeToBytes(receipts)
+	if err != nil {
+		return err
+	}
+	return b.AddRLP(header, body, rs, block.NumberU64(), block.Hash(), td)
+}
+
+// AddRLP writes the RLP encoded header, body and receipts of a block into the
+// archive, along with its total difficulty.
+func (b *Builder) AddRLP(header, body, receipts []byte, number uint64, hash common.Hash, td *big.Int) error {
+	if b.start == nil {
+		if err := b.write(TypeVersion, nil); err != nil {
+			return err
+		}
+		b.start = &number
+	}
+	if want := *b.start + uint64(len(b.offsets)); number != want {
+		return fmt.Errorf("non-contiguous block: have %d, want %d", number, want)
+	}
+	if len(b.offsets) >= MaxEraBatchSize {
+		return fmt.Errorf("archive full: %d blocks", MaxEraBatchSize)
+	}
+	b.offsets = append(b.offsets, b.written)
+	b.hashes = append(b.hashes, hash)
+	b.tds = append(b.tds, new(big.Int).Set(td))
+
+	for _, entry := range []struct {
+		typ  uint16
+		blob []byte
+	}{
+		{TypeCompressedHeader, header},
+		{TypeCompressedBody, body},
+		{TypeCompressedReceipts, receipts},
+	} {
+		if err := b.writeCompressed(entry.typ, entry.blob); err != nil {
+			return err
+		}
+	}
+	return b.write(TypeTotalDifficulty, encodeTD(td))
+}
+
+// Finalize writes the accumulator and the block index, completing the archive.
+// It returns the accumulator root, which identifies the archive.
+func (b *Builder) Finalize() (common.Hash, error) {
+	if b.start == nil {
+		return common.Hash{}, errors.New("finalizing empty archive")
+	}
+	root, err := ComputeAccumulator(b.hashes, b.tds)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := b.write(TypeAccumulator, root.Bytes()); err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		base  = int64(b.written)
+		index = make([]byte, 16+8*len(b.offsets))
+	)
+	binary.LittleEndian.PutUint64(index, *b.start)
+	for i, offset := range b.offsets {
+		binary.LittleEndian.PutUint64(index[8+i*8:], uint64(int64(offset)-base))
+	}
+	binary.LittleEndian.PutUint64(index[8+len(b.offsets)*8:], uint64(len(b.offsets)))
+	if err := b.write(TypeBlockIndex, index); err != nil {
+		return common.Hash{}, err
+	}
+	return root, nil
+}
+
+// writeCompressed writes an entry with the snappy compressed value.
+func (b *Builder) writeCompressed(typ uint16, value []byte) error {
+	b.buf.Reset()
+	b.snappy.Reset(b.buf)
+	if _, err := b.snappy.Write(value); err != nil {
+		return err
+	}
+	if err := b.snappy.Flush(); err != nil {
+		return err
+	}
+	return b.write(typ, b.buf.Bytes())
+}
+
+func (b *Builder) write(typ uint16, value []byte) error {
+	n, err := b.w.Write(typ, value)
+	b.written += uint64(n)
+	return err
+}
+
+// encodeTD encodes a total difficulty as a little endian uint256.
+func encodeTD(td *big.Int) []byte {
+	buf := make([]byte, 32)
+	td.FillBytes(buf)
+	for l, r := 0, len(buf)-1; l < r; l, r = l+1, r-1 {
+		buf[l], buf[r] = buf[r], buf[l]
+	}
+	return buf
+}
+
+// decodeTD decodes a little endian uint256 total difficulty.
+func decodeTD(buf []byte) *big.Int {
+	be := make([]byte, len(buf))
+	for i := range buf {
+		be[len(buf)-1-i] = buf[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
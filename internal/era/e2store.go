@@ -0,0 +1,119 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// headerSize is the size of the header preceding the value of every entry:
+// a 2 byte type, a 4 byte length and 2 reserved zero bytes.
+const headerSize = 8
+
+// valueSizeLimit caps the size of an entry value, guarding against reading
+// absurdly large entries out of a corrupted file.
+const valueSizeLimit = 1024 * 1024 * 50
+
+// Entry is a type-length-value record of an e2store file.
+type Entry struct {
+	Type  uint16
+	Value []byte
+}
+
+// Writer writes entries in the e2store format.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter creates an e2store writer on top of w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Write writes a single entry, returning the number of bytes written.
+func (w *Writer) Write(typ uint16, value []byte) (int, error) {
+	var header [headerSize]byte
+	binary.LittleEndian.PutUint16(header[:2], typ)
+	binary.LittleEndian.PutUint32(header[2:6], uint32(len(value)))
+
+	n, err := w.w.Write(header[:])
+	if err != nil {
+		return n, err
+	}
+	m, err := w.w.Write(value)
+	return n + m, err
+}
+
+// Reader reads entries out of an e2store file at arbitrary offsets.
+type Reader struct {
+	r io.ReaderAt
+}
+
+// NewReader creates an e2store reader on top of r.
+func NewReader(r io.ReaderAt) *Reader {
+	return &Reader{r: r}
+}
+
+// ReadAt reads the entry starting at the given offset, returning it along with
+// its total length, header included.
+func (r *Reader) ReadAt(off int64) (*Entry, int, error) {
+	typ, length, err := r.ReadMetadataAt(off)
+	if err != nil {
+		return nil, 0, err
+	}
+	entry := &Entry{Type: typ, Value: make([]byte, length)}
+	if _, err := r.r.ReadAt(entry.Value, off+headerSize); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, 0, io.ErrUnexpectedEOF
+		}
+		return nil, 0, err
+	}
+	return entry, headerSize + int(length), nil
+}
+
+// ReaderAt returns a reader of the value of the entry starting at the given
+// offset, along with the value length, without loading it into memory.
+func (r *Reader) ReaderAt(typ uint16, off int64) (io.Reader, int, error) {
+	have, length, err := r.ReadMetadataAt(off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if have != typ {
+		return nil, 0, fmt.Errorf("wrong entry type at %d: have %#x, want %#x", off, have, typ)
+	}
+	return io.NewSectionReader(r.r, off+headerSize, int64(length)), int(length), nil
+}
+
+// ReadMetadataAt reads the type and value length of the entry starting at the
+// given offset.
+func (r *Reader) ReadMetadataAt(off int64) (uint16, uint32, error) {
+	var header [headerSize]byte
+	if _, err := r.r.ReadAt(header[:], off); err != nil {
+		return 0, 0, err
+	}
+	if header[6] != 0 || header[7] != 0 {
+		return 0, 0, fmt.Errorf("reserved bytes of the entry at %d are non-zero", off)
+	}
+	length := binary.LittleEndian.Uint32(header[2:6])
+	if length > valueSizeLimit {
+		return 0, 0, fmt.Errorf("entry at %d too large: %d bytes", off, length)
+	}
+	return binary.LittleEndian.Uint16(header[:2]), length, nil
+}
@@ -0,0 +1,307 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package era implements an archive format of the chain history: files of a
+// contiguous range of blocks with their receipts and total difficulties, each
+// verifiable against an accumulator root and indexed for random access.
+package era
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+// Entry types of an archive.
+const (
+	TypeVersion            uint16 = 0x3265
+	TypeCompressedHeader   uint16 = 0x03
+	TypeCompressedBody     uint16 = 0x04
+	TypeCompressedReceipts uint16 = 0x05
+	TypeTotalDifficulty    uint16 = 0x06
+	TypeAccumulator        uint16 = 0x07
+	TypeBlockIndex         uint16 = 0x3266
+)
+
+// MaxEraBatchSize is the maximum number of blocks of an archive.
+const MaxEraBatchSize = 8192
+
+// Extension is the file extension of the archives.
+const Extension = ".era1"
+
+// Filename returns the name of the archive of the given network and epoch,
+// suffixed with the first bytes of its accumulator root.
+func Filename(network string, epoch int, root common.Hash) string {
+	return fmt.Sprintf("%s-%05d-%s%s", network, epoch, common.Bytes2Hex(root[:4]), Extension)
+}
+
+// ParseFilename extracts the network and the epoch out of an archive name.
+func ParseFilename(name string) (string, int, error) {
+	parts := strings.Split(strings.TrimSuffix(filepath.Base(name), Extension), "-")
+	if !strings.HasSuffix(name, Extension) || len(parts) != 3 {
+		return "", 0, fmt.Errorf("malformed archive name: %s", name)
+	}
+	epoch, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed archive epoch: %s", name)
+	}
+	return parts[0], epoch, nil
+}
+
+// ReadDir returns the paths of the archives of the given network in a directory,
+// ordered by epoch. The epochs must be consecutive, starting from any of them.
+func ReadDir(dir, network string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		epochs = make(map[int]string)
+		sorted []int
+	)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != Extension {
+			continue
+		}
+		have, epoch, err := ParseFilename(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if have != network {
+			continue
+		}
+		if prev, ok := epochs[epoch]; ok {
+			return nil, fmt.Errorf("duplicate archives of epoch %d: %s, %s", epoch, prev, entry.Name())
+		}
+		epochs[epoch] = entry.Name()
+		sorted = append(sorted, epoch)
+	}
+	sort.Ints(sorted)
+
+	paths := make([]string, len(sorted))
+	for i, epoch := range sorted {
+		if i > 0 && epoch != sorted[i-1]+1 {
+			return nil, fmt.Errorf("missing archive of epoch %d", sorted[i-1]+1)
+		}
+		paths[i] = filepath.Join(dir, epochs[epoch])
+	}
+	return paths, nil
+}
+
+// ReadAtCloser is the file an archive is read from.
+type ReadAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// Era is a reader of an archive.
+type Era struct {
+	f      ReadAtCloser
+	s      *Reader
+	start  uint64
+	count  uint64
+	index  int64 // Offset of the block index entry
+	length int64 // Total size of the archive
+}
+
+// Open opens the archive at the given path.
+func Open(path string) (*Era, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	e, err := From(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// From creates an archive reader on top of f, which is length bytes long. The
+// file is closed along with the reader.
+func From(f ReadAtCloser, length int64) (*Era, error) {
+	e := &Era{f: f, s: NewReader(f), length: length}
+
+	// The block count is the last field of the index, the last entry
+	if length < headerSize+16 {
+		return nil, errors.New("archive too short")
+	}
+	var buf [8]byte
+	if _, err := f.ReadAt(buf[:], length-8); err != nil {
+		return nil, err
+	}
+	e.count = binary.LittleEndian.Uint64(buf[:])
+	if e.count == 0 || e.count > MaxEraBatchSize {
+		return nil, fmt.Errorf("invalid archive block count: %d", e.count)
+	}
+	e.index = length - int64(headerSize+16+8*e.count)
+	if e.index < 0 {
+		return nil, errors.New("archive too short")
+	}
+	typ, size, err := e.s.ReadMetadataAt(e.index)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TypeBlockIndex || int64(size) != 16+8*int64(e.count) {
+		return nil, errors.New("malformed archive block index")
+	}
+	if _, err := f.ReadAt(buf[:], e.index+headerSize); err != nil {
+		return nil, err
+	}
+	e.start = binary.LittleEndian.Uint64(buf[:])
+	return e, nil
+}
+
+// Close closes the archive file.
+func (e *Era) Close() error {
+	return e.f.Close()
+}
+
+// Start returns the number of the first block of the archive.
+func (e *Era) Start() uint64 {
+	return e.start
+}
+
+// Count returns the number of blocks of the archive.
+func (e *Era) Count() uint64 {
+	return e.count
+}
+
+// GetBlockByNumber returns the block of the given number out of the archive.
+func (e *Era) GetBlockByNumber(number uint64) (*types.Block, error) {
+	off, err := e.tupleOffset(number)
+	if err != nil {
+		return nil, err
+	}
+	var header types.Header
+	n, err := e.readCompressed(TypeCompressedHeader, off, &header)
+	if err != nil {
+		return nil, err
+	}
+	var body types.Body
+	if _, err := e.readCompressed(TypeCompressedBody, off+int64(n), &body); err != nil {
+		return nil, err
+	}
+	return types.NewBlockWithHeader(&header).WithBody(body.Transactions, body.Uncles).WithOperations(body.Withdrawals, body.Deposits), nil
+}
+
+// GetReceiptsByNumber returns the receipts of the block of the given number out
+// of the archive. Only the consensus fields of the receipts are filled.
+func (e *Era) GetReceiptsByNumber(number uint64) (types.Receipts, error) {
+	off, err := e.tupleOffset(number)
+	if err != nil {
+		return nil, err
+	}
+	// Skip the header and the body
+	for i := 0; i < 2; i++ {
+		_, length, err := e.s.ReadMetadataAt(off)
+		if err != nil {
+			return nil, err
+		}
+		off += headerSize + int64(length)
+	}
+	var receipts types.Receipts
+	if _, err := e.readCompressed(TypeCompressedReceipts, off, &receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+// GetTotalDifficulty returns the total difficulty of the block of the given
+// number out of the archive.
+func (e *Era) GetTotalDifficulty(number uint64) (*big.Int, error) {
+	off, err := e.tupleOffset(number)
+	if err != nil {
+		return nil, err
+	}
+	// Skip the header, the body and the receipts
+	for i := 0; i < 3; i++ {
+		_, length, err := e.s.ReadMetadataAt(off)
+		if err != nil {
+			return nil, err
+		}
+		off += headerSize + int64(length)
+	}
+	entry, _, err := e.s.ReadAt(off)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != TypeTotalDifficulty || len(entry.Value) != 32 {
+		return nil, fmt.Errorf("malformed total difficulty of block %d", number)
+	}
+	return decodeTD(entry.Value), nil
+}
+
+// Accumulator returns the accumulator root stored in the archive.
+func (e *Era) Accumulator() (common.Hash, error) {
+	// The accumulator immediately precedes the block index
+	off := e.index - headerSize - common.HashLength
+	entry, _, err := e.s.ReadAt(off)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if entry.Type != TypeAccumulator || len(entry.Value) != common.HashLength {
+		return common.Hash{}, errors.New("malformed archive accumulator")
+	}
+	return common.BytesToHash(entry.Value), nil
+}
+
+// tupleOffset returns the offset of the block tuple of the given number.
+func (e *Era) tupleOffset(number uint64) (int64, error) {
+	if number < e.start || number >= e.start+e.count {
+		return 0, fmt.Errorf("block %d out of archive range [%d, %d)", number, e.start, e.start+e.count)
+	}
+	var buf [8]byte
+	if _, err := e.f.ReadAt(buf[:], e.index+headerSize+8+int64(number-e.start)*8); err != nil {
+		return 0, err
+	}
+	off := e.index + int64(binary.LittleEndian.Uint64(buf[:]))
+	if off < 0 || off >= e.index {
+		return 0, fmt.Errorf("invalid offset of block %d", number)
+	}
+	return off, nil
+}
+
+// readCompressed decodes the snappy compressed value of the entry at the given
+// offset into val, returning the total length of the entry.
+func (e *Era) readCompressed(typ uint16, off int64, val interface{}) (int, error) {
+	r, length, err := e.s.ReaderAt(typ, off)
+	if err != nil {
+		return 0, err
+	}
+	if err := rlp.Decode(snappy.NewReader(r), val); err != nil {
+		return 0, err
+	}
+	return headerSize + length, nil
+}
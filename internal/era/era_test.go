@@ -0,0 +1,136 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package era
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// memFile is an in-memory archive file.
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error { return nil }
+
+func TestRoundTrip(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		builder  = NewBuilder(&buf)
+		blocks   []*types.Block
+		receipts []types.Receipts
+		hashes   []common.Hash
+		tds      []*big.Int
+		td       = new(big.Int)
+	)
+	for i := uint64(0); i < 128; i++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(100 + i), Difficulty: big.NewInt(7), Extra: make([]byte, i)}
+		tx := types.NewTransaction(i, common.Address{byte(i)}, big.NewInt(int64(i)), 21000, big.NewInt(1), nil)
+		block := types.NewBlockWithHeader(header).WithBody([]*types.Transaction{tx}, nil)
+		receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000 * i, Logs: []*types.Log{}}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		td.Add(td, header.Difficulty)
+
+		if err := builder.Add(block, types.Receipts{receipt}, td); err != nil {
+			t.Fatalf("failed to add block %d: %v", i, err)
+		}
+		blocks, receipts = append(blocks, block), append(receipts, types.Receipts{receipt})
+		hashes, tds = append(hashes, block.Hash()), append(tds, new(big.Int).Set(td))
+	}
+	// Non-contiguous blocks must be rejected
+	if err := builder.Add(blocks[0], receipts[0], td); err == nil {
+		t.Fatal("non-contiguous block accepted")
+	}
+	root, err := builder.Finalize()
+	if err != nil {
+		t.Fatalf("failed to finalize: %v", err)
+	}
+	if want, _ := ComputeAccumulator(hashes, tds); root != want {
+		t.Fatalf("accumulator mismatch: have %x, want %x", root, want)
+	}
+	e, err := From(memFile{bytes.NewReader(buf.Bytes())}, int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	if e.Start() != 100 || e.Count() != 128 {
+		t.Fatalf("range mismatch: have [%d, +%d), want [100, +128)", e.Start(), e.Count())
+	}
+	if have, err := e.Accumulator(); err != nil || have != root {
+		t.Fatalf("stored accumulator mismatch: have %x (%v), want %x", have, err, root)
+	}
+	for i, want := range blocks {
+		number := want.NumberU64()
+		block, err := e.GetBlockByNumber(number)
+		if err != nil {
+			t.Fatalf("failed to read block %d: %v", number, err)
+		}
+		if block.Hash() != want.Hash() || block.Transactions()[0].Hash() != want.Transactions()[0].Hash() {
+			t.Fatalf("block %d mismatch", number)
+		}
+		rs, err := e.GetReceiptsByNumber(number)
+		if err != nil {
+			t.Fatalf("failed to read receipts %d: %v", number, err)
+		}
+		if types.DeriveSha(rs, trie.NewStackTrie(nil)) != types.DeriveSha(receipts[i], trie.NewStackTrie(nil)) {
+			t.Fatalf("receipts %d mismatch", number)
+		}
+		have, err := e.GetTotalDifficulty(number)
+		if err != nil || have.Cmp(tds[i]) != 0 {
+			t.Fatalf("total difficulty %d mismatch: have %v (%v), want %v", number, have, err, tds[i])
+		}
+	}
+	if _, err := e.GetBlockByNumber(228); err == nil {
+		t.Fatal("block out of range returned")
+	}
+}
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		Filename("ronin", 3, common.Hash{1}),
+		Filename("ronin", 2, common.Hash{2}),
+		Filename("saigon", 0, common.Hash{3}),
+		"ronin-00004-01000000.era1.tmp",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths, err := ReadDir(dir, "ronin")
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	want := []string{filepath.Join(dir, "ronin-00002-02000000.era1"), filepath.Join(dir, "ronin-00003-01000000.era1")}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("paths mismatch: have %v, want %v", paths, want)
+	}
+	// Gaps between the epochs must be rejected
+	if err := os.WriteFile(filepath.Join(dir, Filename("ronin", 5, common.Hash{})), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadDir(dir, "ronin"); err == nil {
+		t.Fatal("gap between epochs accepted")
+	}
+}
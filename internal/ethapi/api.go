@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -167,6 +168,19 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 		"highestBlock":  hexutil.Uint64(progress.HighestBlock),
 		"pulledStates":  hexutil.Uint64(progress.PulledStates),
 		"knownStates":   hexutil.Uint64(progress.KnownStates),
+
+		"syncedAccounts":      hexutil.Uint64(progress.SyncedAccounts),
+		"syncedAccountBytes":  hexutil.Uint64(progress.SyncedAccountBytes),
+		"syncedBytecodes":     hexutil.Uint64(progress.SyncedBytecodes),
+		"syncedBytecodeBytes": hexutil.Uint64(progress.SyncedBytecodeBytes),
+		"syncedStorage":       hexutil.Uint64(progress.SyncedStorage),
+		"syncedStorageBytes":  hexutil.Uint64(progress.SyncedStorageBytes),
+		"healedTrienodes":     hexutil.Uint64(progress.HealedTrienodes),
+		"healedTrienodeBytes": hexutil.Uint64(progress.HealedTrienodeBytes),
+		"healedBytecodes":     hexutil.Uint64(progress.HealedBytecodes),
+		"healedBytecodeBytes": hexutil.Uint64(progress.HealedBytecodeBytes),
+		"healingTrienodes":    hexutil.Uint64(progress.HealingTrienodes),
+		"healingBytecode":     hexutil.Uint64(progress.HealingBytecode),
 	}, nil
 }
 
@@ -239,6 +253,30 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// RPCRejectionStats is the number of transactions rejected by the pool during a
+// minute, per rejection code.
+type RPCRejectionStats struct {
+	Minute hexutil.Uint64            `json:"minute"`
+	Counts map[string]hexutil.Uint64 `json:"counts"`
+}
+
+// Rejections returns the number of transactions rejected by the pool per minute
+// and rejection code over the last hour, oldest first. Minutes are given as unix
+// timestamps.
+func (s *PublicTxPoolAPI) Rejections() []RPCRejectionStats {
+	stats := s.b.TxPoolRejections()
+
+	result := make([]RPCRejectionStats, len(stats))
+	for i, stat := range stats {
+		counts := make(map[string]hexutil.Uint64, len(stat.Counts))
+		for code, count := range stat.Counts {
+			counts[code] = hexutil.Uint64(count)
+		}
+		result[i] = RPCRejectionStats{Minute: hexutil.Uint64(stat.Minute.Unix()), Counts: counts}
+	}
+	return result
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
@@ -1064,16 +1102,13 @@ func doCall(
 	if blockOverrides != nil {
 		blockOverrides.Apply(&blockCtx)
 	}
-	evm, vmError, err := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true}, &blockCtx)
+	evm, vmError, err := b.GetEVM(ctx, msg, state, header, &vm.Config{NoBaseFee: true, StepLimit: b.RPCEVMStepLimit()}, &blockCtx)
 	if err != nil {
 		return nil, err
 	}
-	// Wait for the context to be done and cancel the evm. Even if the
-	// EVM has finished, cancelling may be done (repeatedly)
-	go func() {
-		<-ctx.Done()
-		evm.Cancel()
-	}()
+	// Abort the execution once the context is done, either timed out or
+	// cancelled by the client going away
+	evm.SetContext(ctx)
 
 	// Execute the message.
 	gp := new(core.GasPool).AddGas(math.MaxUint64)
@@ -1082,9 +1117,13 @@ func doCall(
 		return nil, err
 	}
 
-	// If the timer caused an abort, return an appropriate error message
-	if evm.Cancelled() {
-		return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+	// If the timer, the client or the step limit caused an abort, return an
+	// appropriate error message
+	if err := evm.AbortError(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("execution aborted (timeout = %v)", timeout)
+		}
+		return nil, fmt.Errorf("execution aborted: %w", err)
 	}
 	if err != nil {
 		return result, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
@@ -1181,6 +1220,7 @@ func DoEstimateGas(ctx context.Context, b Backend, args TransactionArgs, blockNr
 		Header:     header,
 		State:      state,
 		ErrorRatio: estimateGasErrorRatio,
+		StepLimit:  b.RPCEVMStepLimit(),
 	}
 	// Run the gas estimation andwrap any revertals into a custom return
 	call, err := args.ToMessage(gasCap, header.BaseFee)
@@ -1304,6 +1344,14 @@ func RPCMarshalHeader(head *types.Header) map[string]interface{} {
 	if head.ExcessBlobGas != nil {
 		result["excessBlobGas"] = (*hexutil.Uint64)(head.ExcessBlobGas)
 	}
+
+	if head.WithdrawalsHash != nil {
+		result["withdrawalsRoot"] = head.WithdrawalsHash
+	}
+
+	if head.DepositsHash != nil {
+		result["depositsRoot"] = head.DepositsHash
+	}
 	return result
 }
 
@@ -1339,7 +1387,12 @@ func RPCMarshalBlock(block *types.Block, inclTx bool, fullTx bool, config *param
 		uncleHashes[i] = uncle.Hash()
 	}
 	fields["uncles"] = uncleHashes
-
+	if block.Header().WithdrawalsHash != nil {
+		fields["withdrawals"] = block.Withdrawals()
+	}
+	if block.Header().DepositsHash != nil {
+		fields["deposits"] = block.Deposits()
+	}
 	return fields, nil
 }
 
@@ -1585,7 +1638,7 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 		to = crypto.CreateAddress(args.from(), uint64(*args.Nonce))
 	}
 	// Retrieve the precompiles since they don't need to be added to the access list
-	precompiles := vm.ActivePrecompiles(b.ChainConfig().Rules(header.Number))
+	precompiles := vm.ActivePrecompiles(b.ChainConfig().Rules(header.Number, header.Time))
 
 	// Create an initial tracer
 	prevTracer := logger.NewAccessListTracer(nil, args.from(), to, precompiles)
@@ -1617,7 +1670,7 @@ func AccessList(ctx context.Context, b Backend, blockNrOrHash rpc.BlockNumberOrH
 
 		// Apply the transaction with the access list tracer
 		tracer := logger.NewAccessListTracer(accessList, args.from(), to, precompiles)
-		config := vm.Config{Tracer: tracer, Debug: true, NoBaseFee: true}
+		config := vm.Config{Tracer: tracer.Hooks(), Debug: true, NoBaseFee: true}
 		vmenv, _, err := b.GetEVM(ctx, msg, statedb, header, &config, nil)
 		if err != nil {
 			return nil, 0, nil, err
@@ -1763,15 +1816,13 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if err != nil || tx == nil {
 		return nil, nil
 	}
-	receipts, err := s.b.GetReceipts(ctx, blockHash)
+	receipt, err := s.b.GetReceipt(ctx, hash, blockHash, index)
 	if err != nil {
 		return nil, err
 	}
-	if len(receipts) <= int(index) {
+	if receipt == nil {
 		return nil, nil
 	}
-	receipt := receipts[index]
-
 	// Derive the sender.
 	bigblock := new(big.Int).SetUint64(blockNumber)
 	signer := types.MakeSigner(s.b.ChainConfig(), bigblock)
@@ -1809,6 +1860,9 @@ func marshalReceipt(
 		payer, _ := types.Payer(signer, tx)
 		fields["payer"] = payer
 	}
+	if receipt.Forced {
+		fields["forced"] = true
+	}
 
 	// Assign the effective gas price paid
 	if !backend.ChainConfig().IsLondon(new(big.Int).SetUint64(blockNumber)) {
@@ -1930,8 +1984,25 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Tra
 // FillTransaction fills the defaults (nonce, gas, gasPrice or 1559 fields)
 // on a given unsigned transaction, and returns it to the caller for further
 // processing (signing + broadcast).
-func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args TransactionArgs) (*SignTransactionResult, error) {
+//
+// The nonce of an account managed by the pool is reserved for the caller, so
+// that concurrent submitters filling their transactions don't collide.
+func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args TransactionArgs) (res *SignTransactionResult, err error) {
 	args.blobSidecarAllowed = true
+	if args.Nonce == nil {
+		nonce, err := s.b.ReserveNonce(ctx, args.from())
+		switch {
+		case err == nil:
+			args.Nonce = (*hexutil.Uint64)(&nonce)
+			defer func() {
+				if res == nil {
+					s.b.ReleaseNonce(ctx, args.from(), nonce)
+				}
+			}()
+		case !errors.Is(err, txpool.ErrAccountNotManaged):
+			return nil, err
+		}
+	}
 	// Set some sanity defaults and terminate on failure
 	if err := args.setDefaults(ctx, s.b); err != nil {
 		return nil, err
@@ -1945,6 +2016,40 @@ func (s *PublicTransactionPoolAPI) FillTransaction(ctx context.Context, args Tra
 	return &SignTransactionResult{data, tx}, nil
 }
 
+// TransactionCost is the breakdown of the funds spent by a transaction.
+type TransactionCost struct {
+	SenderCost *hexutil.Big `json:"senderCost"`
+	PayerCost  *hexutil.Big `json:"payerCost"`
+	BlobCost   *hexutil.Big `json:"blobCost"`
+	MaxTotal   *hexutil.Big `json:"maxTotal"`
+}
+
+// PreviewTransactionCost fills the defaults on a given unsigned transaction like
+// FillTransaction, and returns the funds it would spend if included in the next
+// block, split between its sender and its payer.
+func (s *PublicTransactionPoolAPI) PreviewTransactionCost(ctx context.Context, args TransactionArgs) (*TransactionCost, error) {
+	args.blobSidecarAllowed = true
+	if err := args.setDefaults(ctx, s.b); err != nil {
+		return nil, err
+	}
+	var (
+		tx      = args.toTransaction()
+		head    = s.b.CurrentHeader()
+		config  = s.b.ChainConfig()
+		baseFee *big.Int
+	)
+	if config.IsLondon(new(big.Int).Add(head.Number, common.Big1)) {
+		baseFee = eip1559.CalcBaseFee(config, head)
+	}
+	senderCost, payerCost, blobCost, maxTotal := tx.CostBreakdown(baseFee, s.b.BlobBaseFee(ctx))
+	return &TransactionCost{
+		SenderCost: (*hexutil.Big)(senderCost),
+		PayerCost:  (*hexutil.Big)(payerCost),
+		BlobCost:   (*hexutil.Big)(blobCost),
+		MaxTotal:   (*hexutil.Big)(maxTotal),
+	}, nil
+}
+
 // SendRawTransaction will add the signed transaction to the transaction pool.
 // The sender is responsible for signing the transaction and using the correct nonce.
 func (s *PublicTransactionPoolAPI) SendRawTransaction(ctx context.Context, input hexutil.Bytes) (common.Hash, error) {
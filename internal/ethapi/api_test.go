@@ -40,6 +40,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -392,6 +393,7 @@ type testBackend struct {
 	pending *types.Block
 	accman  *accounts.Manager
 	acc     accounts.Account
+	nonces  map[common.Address]uint64 // Next nonces to reserve of the managed accounts
 }
 
 func newTestAccountManager(t *testing.T) (*accounts.Manager, accounts.Account) {
@@ -459,6 +461,7 @@ func (b testBackend) AccountManager() *accounts.Manager { return b.accman }
 func (b testBackend) ExtRPCEnabled() bool               { return false }
 func (b testBackend) RPCGasCap() uint64                 { return 10000000 }
 func (b testBackend) RPCEVMTimeout() time.Duration      { return time.Second }
+func (b testBackend) RPCEVMStepLimit() uint64           { return 0 }
 func (b testBackend) RPCTxFeeCap() float64              { return 0 }
 func (b testBackend) UnprotectedAllowed() bool          { return false }
 func (b testBackend) SetHead(number uint64)             {}
@@ -547,6 +550,13 @@ func (b testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.R
 	receipts := rawdb.ReadReceipts(b.db, hash, header.Number.Uint64(), b.chain.Config())
 	return receipts, nil
 }
+func (b testBackend) GetReceipt(ctx context.Context, txHash common.Hash, blockHash common.Hash, index uint64) (*types.Receipt, error) {
+	receipts, err := b.GetReceipts(ctx, blockHash)
+	if err != nil || len(receipts) <= int(index) {
+		return nil, err
+	}
+	return receipts[index], nil
+}
 func (b testBackend) GetTd(ctx context.Context, hash common.Hash) *big.Int {
 	if b.pending != nil && hash == b.pending.Hash() {
 		return nil
@@ -586,6 +596,23 @@ func (b testBackend) GetPoolTransaction(txHash common.Hash) *types.Transaction {
 func (b testBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
 	return 0, nil
 }
+func (b testBackend) ReserveNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	nonce, ok := b.nonces[addr]
+	if !ok {
+		return 0, txpool.ErrAccountNotManaged
+	}
+	b.nonces[addr] = nonce + 1
+	return nonce, nil
+}
+func (b testBackend) ReleaseNonce(ctx context.Context, addr common.Address, nonce uint64) error {
+	if _, ok := b.nonces[addr]; !ok {
+		return txpool.ErrAccountNotManaged
+	}
+	if b.nonces[addr] == nonce+1 {
+		b.nonces[addr] = nonce
+	}
+	return nil
+}
 func (b testBackend) Stats() (pending int, queued int) { panic("implement me") }
 func (b testBackend) TxPoolContent() (map[common.Address][]*types.Transaction, map[common.Address][]*types.Transaction) {
 	panic("implement me")
@@ -593,6 +620,7 @@ func (b testBackend) TxPoolContent() (map[common.Address][]*types.Transaction, m
 func (b testBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transaction, []*types.Transaction) {
 	panic("implement me")
 }
+func (b testBackend) TxPoolRejections() []txpool.RejectionStats { panic("implement me") }
 func (b testBackend) SubscribeNewTxsEvent(events chan<- core.NewTxsEvent) event.Subscription {
 	panic("implement me")
 }
@@ -981,7 +1009,7 @@ func TestHeader4844MarshalJson(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	expect := `{"difficulty":"0x7","extraData":"0x","gasLimit":"0x0","gasUsed":"0x0","hash":"0x7638fef16ccc17d30038b807c09ca0f0bb47a6132d81253799448855504ed217","logsBloom":"0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","miner":"0x0000000000000000000000000000000000000000","mixHash":"0x0000000000000000000000000000000000000000000000000000000000000000","nonce":"0x0000000000000000","number":"0x64","parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","sha3Uncles":"0x0000000000000000000000000000000000000000000000000000000000000000","size":"0x239","stateRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","timestamp":"0x0","transactionsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000"}`
+	expect := `{"difficulty":"0x7","extraData":"0x","gasLimit":"0x0","gasUsed":"0x0","hash":"0x7638fef16ccc17d30038b807c09ca0f0bb47a6132d81253799448855504ed217","logsBloom":"0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","miner":"0x0000000000000000000000000000000000000000","mixHash":"0x0000000000000000000000000000000000000000000000000000000000000000","nonce":"0x0000000000000000","number":"0x64","parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","sha3Uncles":"0x0000000000000000000000000000000000000000000000000000000000000000","size":"0x249","stateRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","timestamp":"0x0","transactionsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000"}`
 	if string(data) != expect {
 		t.Fatalf("Header mismatches, expect: %s\n got: %s", expect, string(data))
 	}
@@ -996,7 +1024,7 @@ func TestHeader4844MarshalJson(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	expect = `{"blobGasUsed":"0x20000","difficulty":"0x7","excessBlobGas":"0x40000","extraData":"0x","gasLimit":"0x0","gasUsed":"0x0","hash":"0xd2bae9d64fe00db8bc637990b38432d8281604d1caf81bfe7c0b46ecc1dfd1ca","logsBloom":"0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","miner":"0x0000000000000000000000000000000000000000","mixHash":"0x0000000000000000000000000000000000000000000000000000000000000000","nonce":"0x0000000000000000","number":"0x64","parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","sha3Uncles":"0x0000000000000000000000000000000000000000000000000000000000000000","size":"0x239","stateRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","timestamp":"0x0","transactionsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000"}`
+	expect = `{"blobGasUsed":"0x20000","difficulty":"0x7","excessBlobGas":"0x40000","extraData":"0x","gasLimit":"0x0","gasUsed":"0x0","hash":"0xd2bae9d64fe00db8bc637990b38432d8281604d1caf81bfe7c0b46ecc1dfd1ca","logsBloom":"0x00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000","miner":"0x0000000000000000000000000000000000000000","mixHash":"0x0000000000000000000000000000000000000000000000000000000000000000","nonce":"0x0000000000000000","number":"0x64","parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000","receiptsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","sha3Uncles":"0x0000000000000000000000000000000000000000000000000000000000000000","size":"0x249","stateRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","timestamp":"0x0","transactionsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000"}`
 	if string(data) != expect {
 		t.Fatalf("Header mismatches, expect: %s\n got: %s", expect, string(data))
 	}
@@ -1313,6 +1341,57 @@ func TestBlobTransactionApi(t *testing.T) {
 	})
 }
 
+// Tests that filling the transactions of a managed account reserves distinct
+// nonces, giving them back if the fill fails.
+func TestFillTransactionReservesNonce(t *testing.T) {
+	t.Parallel()
+	var (
+		accounts = newAccounts(2)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+			},
+		}
+	)
+	b := newTestBackend(t, 1, genesis, ethash.NewFaker(), func(i int, b *core.BlockGen) {})
+	b.setPendingBlock(b.CurrentBlock())
+	b.nonces = map[common.Address]uint64{accounts[0].addr: 3}
+	api := NewPublicTransactionPoolAPI(b, nil)
+
+	fill := func(input []byte) (*SignTransactionResult, error) {
+		args := TransactionArgs{
+			From:  &accounts[0].addr,
+			To:    &accounts[1].addr,
+			Value: (*hexutil.Big)(big.NewInt(1)),
+		}
+		if input != nil {
+			args.Data, args.Input = newRPCBytes(input), newRPCBytes(nil)
+		}
+		return api.FillTransaction(context.Background(), args)
+	}
+	for want := uint64(3); want < 5; want++ {
+		res, err := fill(nil)
+		if err != nil {
+			t.Fatalf("failed to fill transaction: %v", err)
+		}
+		if res.Tx.Nonce() != want {
+			t.Fatalf("nonce mismatch: have %d, want %d", res.Tx.Nonce(), want)
+		}
+	}
+	// A failed fill must give its nonce back
+	if _, err := fill([]byte{0x01}); err == nil {
+		t.Fatal("filled transaction with conflicting data and input")
+	}
+	res, err := fill(nil)
+	if err != nil {
+		t.Fatalf("failed to fill transaction: %v", err)
+	}
+	if res.Tx.Nonce() != 5 {
+		t.Fatalf("released nonce not reused: have %d, want 5", res.Tx.Nonce())
+	}
+}
+
 func newRPCBytes(bytes []byte) *hexutil.Bytes {
 	rpcBytes := hexutil.Bytes(bytes)
 	return &rpcBytes
@@ -0,0 +1,110 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Thrift compact protocol type identifiers.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter is a minimal encoder of the Thrift compact protocol, supporting
+// just the types needed by the Parquet metadata structures.
+type thriftWriter struct {
+	buf   bytes.Buffer
+	last  []int16 // Last written field id of each open struct
+	field int16   // Last written field id of the current struct
+}
+
+func (w *thriftWriter) varint(v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	w.buf.Write(scratch[:binary.PutUvarint(scratch[:], v)])
+}
+
+func (w *thriftWriter) zigzag(v int64) {
+	w.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+// fieldHeader writes the header of the field with the given id and type.
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.field; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.zigzag(int64(id))
+	}
+	w.field = id
+}
+
+func (w *thriftWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.zigzag(int64(v))
+}
+
+func (w *thriftWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.zigzag(v)
+}
+
+func (w *thriftWriter) stringField(id int16, v string) {
+	w.fieldHeader(id, thriftBinary)
+	w.binary(v)
+}
+
+func (w *thriftWriter) binary(v string) {
+	w.varint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+// listField writes the header of a list field, the elements must be written
+// by the caller afterwards.
+func (w *thriftWriter) listField(id int16, elem byte, size int) {
+	w.fieldHeader(id, thriftList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | elem)
+	} else {
+		w.buf.WriteByte(0xf0 | elem)
+		w.varint(uint64(size))
+	}
+}
+
+// structField writes the header of a nested struct field and opens it.
+func (w *thriftWriter) structField(id int16) {
+	w.fieldHeader(id, thriftStruct)
+	w.begin()
+}
+
+// begin opens a struct, either nested in a field or as a list element.
+func (w *thriftWriter) begin() {
+	w.last = append(w.last, w.field)
+	w.field = 0
+}
+
+// end closes the current struct.
+func (w *thriftWriter) end() {
+	w.buf.WriteByte(0)
+	w.field = w.last[len(w.last)-1]
+	w.last = w.last[:len(w.last)-1]
+}
@@ -0,0 +1,355 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package parquet implements a minimal streaming writer of Apache Parquet files
+// with flat schemas of required columns, using plain encoding and no compression.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// magic is the marker at the start and at the end of every Parquet file.
+var magic = []byte("PAR1")
+
+// Parquet physical types, converted types and other enum values used.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeByteArray = 6
+
+	convertedUTF8   = 0
+	convertedUint64 = 14
+
+	repetitionRequired = 0
+
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	codecUncompressed = 0
+
+	pageTypeData = 0
+)
+
+// Default row group limits, whichever is reached first triggers a flush.
+const (
+	defaultRowGroupRows  = 64 * 1024
+	defaultRowGroupBytes = 64 * 1024 * 1024
+)
+
+// ColumnType is the logical type of a column.
+type ColumnType int
+
+const (
+	Int64  ColumnType = iota // Signed 64 bit integer, written from int64
+	Uint64                   // Unsigned 64 bit integer, written from uint64
+	Bool                     // Boolean, written from bool
+	Bytes                    // Raw binary data, written from []byte
+	String                   // UTF-8 string, written from string
+)
+
+// Column is the definition of a single column of a file.
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// columnBuffer accumulates the plain encoded values of a column within the
+// current row group.
+type columnBuffer struct {
+	Column
+	data  bytes.Buffer
+	bools []bool
+}
+
+// columnChunk is the metadata of a column chunk already written to the file.
+type columnChunk struct {
+	offset int64 // Offset of the data page
+	size   int64 // Size of the page including its header
+	values int64 // Number of values in the chunk
+}
+
+// rowGroup is the metadata of a row group already written to the file.
+type rowGroup struct {
+	columns []columnChunk
+	size    int64
+	rows    int64
+}
+
+// Writer streams rows into a Parquet file. Rows are buffered in memory until a
+// row group is complete, at which point it is written out. The file footer is
+// written when the writer is closed.
+type Writer struct {
+	out      io.Writer
+	offset   int64
+	columns  []*columnBuffer
+	metadata map[string]string
+
+	rows      int64 // Number of rows in the current row group
+	total     int64 // Total number of rows written
+	groups    []rowGroup
+	groupRows int64
+	closed    bool
+}
+
+// NewWriter creates a Parquet writer with the given columns and key-value file
+// metadata, writing the file header immediately.
+func NewWriter(out io.Writer, columns []Column, metadata map[string]string) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("no columns")
+	}
+	w := &Writer{
+		out:       out,
+		metadata:  metadata,
+		groupRows: defaultRowGroupRows,
+	}
+	for _, column := range columns {
+		if column.Type < Int64 || column.Type > String {
+			return nil, fmt.Errorf("column %q: unknown type %d", column.Name, column.Type)
+		}
+		w.columns = append(w.columns, &columnBuffer{Column: column})
+	}
+	if err := w.write(magic); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write appends a row to the file, flushing the current row group if it grew
+// too large. The values must match the column types in order.
+func (w *Writer) Write(values ...interface{}) error {
+	if w.closed {
+		return errors.New("writer closed")
+	}
+	if len(values) != len(w.columns) {
+		return fmt.Errorf("column count mismatch: have %d, want %d", len(values), len(w.columns))
+	}
+	// Validate all the values before touching the buffers to keep them aligned
+	for i, column := range w.columns {
+		var ok bool
+		switch column.Type {
+		case Int64:
+			_, ok = values[i].(int64)
+		case Uint64:
+			_, ok = values[i].(uint64)
+		case Bool:
+			_, ok = values[i].(bool)
+		case Bytes:
+			_, ok = values[i].([]byte)
+		case String:
+			_, ok = values[i].(string)
+		}
+		if !ok {
+			return fmt.Errorf("column %q: invalid value type %T", column.Name, values[i])
+		}
+	}
+	var size int
+	for i, column := range w.columns {
+		switch v := values[i].(type) {
+		case int64:
+			binary.Write(&column.data, binary.LittleEndian, v)
+		case uint64:
+			binary.Write(&column.data, binary.LittleEndian, v)
+		case bool:
+			column.bools = append(column.bools, v)
+		case []byte:
+			binary.Write(&column.data, binary.LittleEndian, uint32(len(v)))
+			column.data.Write(v)
+		case string:
+			binary.Write(&column.data, binary.LittleEndian, uint32(len(v)))
+			column.data.WriteString(v)
+		}
+		size += column.data.Len()
+	}
+	w.rows++
+	if w.rows >= w.groupRows || size >= defaultRowGroupBytes {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows out as a row group.
+func (w *Writer) Flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	group := rowGroup{rows: w.rows}
+	for _, column := range w.columns {
+		data := column.data.Bytes()
+		if column.Type == Bool {
+			data = packBools(column.bools)
+		}
+		header := new(thriftWriter)
+		header.begin()
+		header.i32Field(1, pageTypeData)
+		header.i32Field(2, int32(len(data)))
+		header.i32Field(3, int32(len(data)))
+		header.structField(5)
+		header.i32Field(1, int32(w.rows))
+		header.i32Field(2, encodingPlain)
+		header.i32Field(3, encodingRLE)
+		header.i32Field(4, encodingRLE)
+		header.end()
+		header.end()
+
+		chunk := columnChunk{
+			offset: w.offset,
+			size:   int64(header.buf.Len() + len(data)),
+			values: w.rows,
+		}
+		if err := w.write(header.buf.Bytes()); err != nil {
+			return err
+		}
+		if err := w.write(data); err != nil {
+			return err
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+
+		column.data.Reset()
+		column.bools = column.bools[:0]
+	}
+	w.groups = append(w.groups, group)
+	w.total += w.rows
+	w.rows = 0
+	return nil
+}
+
+// Close flushes any buffered rows and writes the file footer. It does not close
+// the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return errors.New("writer closed")
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	w.closed = true
+
+	meta := new(thriftWriter)
+	meta.begin()
+	meta.i32Field(1, 1)
+
+	// The schema is a root element followed by the flat list of columns
+	meta.listField(2, thriftStruct, len(w.columns)+1)
+	meta.begin()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(w.columns)))
+	meta.end()
+	for _, column := range w.columns {
+		meta.begin()
+		meta.i32Field(1, physicalType(column.Type))
+		meta.i32Field(3, repetitionRequired)
+		meta.stringField(4, column.Name)
+		switch column.Type {
+		case Uint64:
+			meta.i32Field(6, convertedUint64)
+		case String:
+			meta.i32Field(6, convertedUTF8)
+		}
+		meta.end()
+	}
+	meta.i64Field(3, w.total)
+
+	meta.listField(4, thriftStruct, len(w.groups))
+	for _, group := range w.groups {
+		meta.begin()
+		meta.listField(1, thriftStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			meta.begin()
+			meta.i64Field(2, chunk.offset)
+			meta.structField(3)
+			meta.i32Field(1, physicalType(w.columns[i].Type))
+			meta.listField(2, thriftI32, 2)
+			meta.zigzag(encodingPlain)
+			meta.zigzag(encodingRLE)
+			meta.listField(3, thriftBinary, 1)
+			meta.binary(w.columns[i].Name)
+			meta.i32Field(4, codecUncompressed)
+			meta.i64Field(5, chunk.values)
+			meta.i64Field(6, chunk.size)
+			meta.i64Field(7, chunk.size)
+			meta.i64Field(9, chunk.offset)
+			meta.end()
+			meta.end()
+		}
+		meta.i64Field(2, group.size)
+		meta.i64Field(3, group.rows)
+		meta.end()
+	}
+	if len(w.metadata) > 0 {
+		keys := make([]string, 0, len(w.metadata))
+		for key := range w.metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		meta.listField(5, thriftStruct, len(keys))
+		for _, key := range keys {
+			meta.begin()
+			meta.stringField(1, key)
+			meta.stringField(2, w.metadata[key])
+			meta.end()
+		}
+	}
+	meta.stringField(6, "ronin")
+	meta.end()
+
+	if err := w.write(meta.buf.Bytes()); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(meta.buf.Len()))
+	if err := w.write(length[:]); err != nil {
+		return err
+	}
+	return w.write(magic)
+}
+
+// write writes data to the output, tracking the file offset.
+func (w *Writer) write(data []byte) error {
+	n, err := w.out.Write(data)
+	w.offset += int64(n)
+	return err
+}
+
+// physicalType returns the Parquet physical type backing a column type.
+func physicalType(typ ColumnType) int32 {
+	switch typ {
+	case Int64, Uint64:
+		return typeInt64
+	case Bool:
+		return typeBoolean
+	default:
+		return typeByteArray
+	}
+}
+
+// packBools plain encodes booleans, packing them into bits LSB first.
+func packBools(values []bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// thriftReader is a generic decoder of the Thrift compact protocol, decoding
+// structs into maps keyed by field id.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.varint())
+		v := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return v
+	case thriftList:
+		header := r.byte()
+		size, elem := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	panic(fmt.Sprintf("unsupported thrift type %d", typ))
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(header & 0x0f)
+	}
+}
+
+// readFile decodes a Parquet file written by Writer, returning its metadata and
+// the values of each column.
+func readFile(t *testing.T, file []byte) (map[int16]interface{}, map[string][]interface{}) {
+	if !bytes.HasPrefix(file, magic) || !bytes.HasSuffix(file, magic) {
+		t.Fatalf("missing magic markers")
+	}
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := &thriftReader{data: file[len(file)-8-size : len(file)-8]}
+	meta := footer.structure()
+
+	schema := meta[2].([]interface{})
+	columns := make(map[string][]interface{})
+	for _, group := range meta[4].([]interface{}) {
+		for i, chunk := range group.(map[int16]interface{})[1].([]interface{}) {
+			var (
+				element = schema[i+1].(map[int16]interface{})
+				name    = element[4].(string)
+				cmeta   = chunk.(map[int16]interface{})[3].(map[int16]interface{})
+				page    = &thriftReader{data: file, pos: int(cmeta[9].(int64))}
+				header  = page.structure()
+				values  = int(header[5].(map[int16]interface{})[1].(int64))
+				data    = file[page.pos : page.pos+int(header[3].(int64))]
+			)
+			for j := 0; j < values; j++ {
+				switch element[1].(int64) {
+				case typeInt64:
+					v := binary.LittleEndian.Uint64(data[8*j:])
+					if _, unsigned := element[6]; unsigned {
+						columns[name] = append(columns[name], v)
+					} else {
+						columns[name] = append(columns[name], int64(v))
+					}
+				case typeBoolean:
+					columns[name] = append(columns[name], data[j/8]&(1<<(j%8)) != 0)
+				case typeByteArray:
+					n := int(binary.LittleEndian.Uint32(data))
+					v := data[4 : 4+n]
+					data = data[4+n:]
+					if converted, ok := element[6]; ok && converted.(int64) == convertedUTF8 {
+						columns[name] = append(columns[name], string(v))
+					} else {
+						columns[name] = append(columns[name], v)
+					}
+				}
+			}
+		}
+	}
+	return meta, columns
+}
+
+// Tests that rows written across multiple row groups can be decoded back.
+func TestWriterRoundtrip(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{
+		{Name: "i", Type: Int64},
+		{Name: "u", Type: Uint64},
+		{Name: "b", Type: Bool},
+		{Name: "raw", Type: Bytes},
+		{Name: "str", Type: String},
+	}, map[string]string{"version": "1"})
+	if err != nil {
+		t.Fatalf("failed to create writer: %v", err)
+	}
+	w.groupRows = 3
+
+	want := make(map[string][]interface{})
+	for i := 0; i < 20; i++ {
+		row := []interface{}{int64(-i), uint64(i) << 40, i%3 == 0, bytes.Repeat([]byte{byte(i)}, i), fmt.Sprintf("row %d", i)}
+		if err := w.Write(row...); err != nil {
+			t.Fatalf("failed to write row %d: %v", i, err)
+		}
+		for j, name := range []string{"i", "u", "b", "raw", "str"} {
+			want[name] = append(want[name], row[j])
+		}
+	}
+	if err := w.Write(int64(0)); err == nil {
+		t.Fatalf("short row accepted")
+	}
+	if err := w.Write(uint64(0), uint64(0), false, []byte{}, ""); err == nil {
+		t.Fatalf("mistyped row accepted")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	meta, have := readFile(t, buf.Bytes())
+	if rows := meta[3].(int64); rows != 20 {
+		t.Errorf("row count mismatch: have %d, want 20", rows)
+	}
+	if groups := len(meta[4].([]interface{})); groups != 7 {
+		t.Errorf("row group count mismatch: have %d, want 7", groups)
+	}
+	kv := meta[5].([]interface{})[0].(map[int16]interface{})
+	if kv[1] != "version" || kv[2] != "1" {
+		t.Errorf("metadata mismatch: have %v", kv)
+	}
+	for name, values := range want {
+		if len(have[name]) != len(values) {
+			t.Fatalf("column %s: value count mismatch: have %d, want %d", name, len(have[name]), len(values))
+		}
+		for i := range values {
+			if !reflect.DeepEqual(have[name][i], values[i]) && !(name == "raw" && len(values[i].([]byte)) == 0 && len(have[name][i].([]byte)) == 0) {
+				t.Errorf("column %s row %d: have %v, want %v", name, i, have[name][i], values[i])
+			}
+		}
+	}
+}
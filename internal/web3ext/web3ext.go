@@ -157,6 +157,30 @@ web3._extend({
 			call: 'admin_sleepBlocks',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'pauseTxAccount',
+			call: 'admin_pauseTxAccount',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'resumeTxAccount',
+			call: 'admin_resumeTxAccount',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'addLocalAccount',
+			call: 'admin_addLocalAccount',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'removeLocalAccount',
+			call: 'admin_removeLocalAccount',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'startHTTP',
 			call: 'admin_startHTTP',
@@ -203,6 +227,14 @@ web3._extend({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'pausedTxAccounts',
+			getter: 'admin_pausedTxAccounts'
+		}),
+		new web3._extend.Property({
+			name: 'localAccounts',
+			getter: 'admin_listLocalAccounts'
+		}),
 	]
 });
 `
@@ -255,6 +287,42 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'proveAncestry',
+			call: 'debug_proveAncestry',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getContractCreation',
+			call: 'debug_getContractCreation',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'finalizedCheckpoint',
+			call: 'debug_finalizedCheckpoint',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getImportAck',
+			call: 'debug_getImportAck',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getBlockWitness',
+			call: 'debug_getBlockWitness',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'exportAccountRange',
+			call: 'debug_exportAccountRange',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'exportStorageRange',
+			call: 'debug_exportStorageRange',
+			params: 4
+		}),
 		new web3._extend.Method({
 			name: 'chaindbProperty',
 			call: 'debug_chaindbProperty',
@@ -443,6 +511,17 @@ web3._extend({
 			call: 'debug_getBadBlocks',
 			params: 0,
 		}),
+		new web3._extend.Method({
+			name: 'getBlockHashes',
+			call: 'debug_getBlockHashes',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal],
+		}),
+		new web3._extend.Method({
+			name: 'getBlobSidecarsStatus',
+			call: 'debug_getBlobSidecarsStatus',
+			params: 1,
+		}),
 		new web3._extend.Method({
 			name: 'storageRangeAt',
 			call: 'debug_storageRangeAt',
@@ -471,6 +550,94 @@ web3._extend({
 			params: 2,
 			inputFormatter:[web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter],
 		}),
+		new web3._extend.Method({
+			name: 'pinState',
+			call: 'debug_pinState',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter],
+		}),
+		new web3._extend.Method({
+			name: 'unpinState',
+			call: 'debug_unpinState',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'witnessStats',
+			call: 'debug_witnessStats',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'pruneRange',
+			call: 'debug_pruneRange',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'pruneRangeForecast',
+			call: 'debug_pruneRangeForecast',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'historyPruneForecast',
+			call: 'debug_historyPruneForecast',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new web3._extend.Method({
+			name: 'traceHashes',
+			call: 'debug_traceHashes',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'backfillReceiptBlooms',
+			call: 'debug_backfillReceiptBlooms',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'backupFreezer',
+			call: 'debug_backupFreezer',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'sstoreStats',
+			call: 'debug_sstoreStats',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'blockDelayStats',
+			call: 'debug_blockDelayStats',
+		}),
+		new web3._extend.Method({
+			name: 'txSLAStats',
+			call: 'debug_txSLAStats',
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionRefunds',
+			call: 'debug_getTransactionRefunds',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'chainAuditLog',
+			call: 'debug_chainAuditLog',
+			params: 2,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'verifyChainAuditLog',
+			call: 'debug_verifyChainAuditLog',
+		}),
+		new web3._extend.Method({
+			name: 'verifyBlocks',
+			call: 'debug_verifyBlocks',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'startSchemeMigration',
+			call: 'debug_startSchemeMigration',
+		}),
+		new web3._extend.Method({
+			name: 'schemeMigrationStatus',
+			call: 'debug_schemeMigrationStatus',
+		}),
 	],
 	properties: []
 });
@@ -522,6 +689,12 @@ web3._extend({
 			params: 1,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
 		}),
+		new web3._extend.Method({
+			name: 'previewTransactionCost',
+			call: 'eth_previewTransactionCost',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputTransactionFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getHeaderByNumber',
 			call: 'eth_getHeaderByNumber',
@@ -662,7 +835,13 @@ web3._extend({
 			call: 'miner_getHashrate'
 		}),
 	],
-	properties: []
+	properties: [
+		new web3._extend.Property({
+			name: 'gasLimit',
+			getter: 'miner_gasLimit',
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+	]
 });
 `
 
@@ -762,6 +941,10 @@ web3._extend({
 			name: 'inspect',
 			getter: 'txpool_inspect'
 		}),
+		new web3._extend.Property({
+			name: 'rejections',
+			getter: 'txpool_rejections'
+		}),
 		new web3._extend.Property({
 			name: 'status',
 			getter: 'txpool_status',
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -171,6 +172,14 @@ func (b *LesApiBackend) GetReceipts(ctx context.Context, hash common.Hash) (type
 	return nil, nil
 }
 
+func (b *LesApiBackend) GetReceipt(ctx context.Context, txHash common.Hash, blockHash common.Hash, index uint64) (*types.Receipt, error) {
+	receipts, err := b.GetReceipts(ctx, blockHash)
+	if err != nil || len(receipts) <= int(index) {
+		return nil, err
+	}
+	return receipts[index], nil
+}
+
 func (b *LesApiBackend) BlobSidecarsByHash(ctx context.Context, hash common.Hash) (types.BlobSidecars, error) {
 	return nil, nil
 }
@@ -233,6 +242,14 @@ func (b *LesApiBackend) GetPoolNonce(ctx context.Context, addr common.Address) (
 	return b.eth.txPool.GetNonce(ctx, addr)
 }
 
+func (b *LesApiBackend) ReserveNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return 0, txpool.ErrAccountNotManaged
+}
+
+func (b *LesApiBackend) ReleaseNonce(ctx context.Context, addr common.Address, nonce uint64) error {
+	return txpool.ErrAccountNotManaged
+}
+
 func (b *LesApiBackend) Stats() (pending int, queued int) {
 	return b.eth.txPool.Stats(), 0
 }
@@ -245,6 +262,10 @@ func (b *LesApiBackend) TxPoolContentFrom(addr common.Address) ([]*types.Transac
 	return b.eth.txPool.ContentFrom(addr)
 }
 
+func (b *LesApiBackend) TxPoolRejections() []txpool.RejectionStats {
+	return nil
+}
+
 func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subscription {
 	return b.eth.txPool.SubscribeNewTxsEvent(ch)
 }
@@ -335,6 +356,10 @@ func (b *LesApiBackend) RPCEVMTimeout() time.Duration {
 	return b.eth.config.RPCEVMTimeout
 }
 
+func (b *LesApiBackend) RPCEVMStepLimit() uint64 {
+	return b.eth.config.RPCEVMStepLimit
+}
+
 func (b *LesApiBackend) RPCTxFeeCap() float64 {
 	return b.eth.config.RPCTxFeeCap
 }
@@ -55,6 +55,7 @@ type Config struct {
 	Noverify             bool           // Disable remote mining solution verification(only useful in ethash).
 	BlockProduceLeftOver time.Duration
 	BlockSizeReserve     uint64
+	TipBucket            *big.Int `toml:",omitempty"` // Granularity of miner tips within which transactions are ordered by arrival time (nil = by price)
 }
 
 // Miner creates blocks and searches for proof-of-work values.
@@ -219,6 +220,11 @@ func (miner *Miner) SetGasCeil(ceil uint64) {
 	miner.worker.setGasCeil(ceil)
 }
 
+// GasCeil returns the gaslimit to strive for when mining blocks.
+func (miner *Miner) GasCeil() uint64 {
+	return miner.worker.gasCeil()
+}
+
 func (miner *Miner) SetBlockProducerLeftover(interval time.Duration) {
 	miner.worker.setBlockProducerLeftover(interval)
 }
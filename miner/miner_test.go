@@ -220,6 +220,18 @@ func TestMinerSetEtherbase(t *testing.T) {
 	}
 }
 
+// TestMinerSetGasCeil checks that the gas limit target can be changed at runtime.
+func TestMinerSetGasCeil(t *testing.T) {
+	miner, _ := createMiner(t)
+	if have := miner.GasCeil(); have != 0 {
+		t.Fatalf("initial gas ceil mismatch: have %d, want 0", have)
+	}
+	miner.SetGasCeil(30_000_000)
+	if have := miner.GasCeil(); have != 30_000_000 {
+		t.Fatalf("gas ceil mismatch: have %d, want %d", have, 30_000_000)
+	}
+}
+
 // waitForMiningState waits until either
 // * the desired mining state was reached
 // * a timeout was reached which fails the test
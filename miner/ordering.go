@@ -15,12 +15,16 @@ type TxWithMinerFee struct {
 	from     common.Address
 	tx       *txpool.LazyTransaction
 	minerFee *big.Int
+	rank     *big.Int // Miner fee used for ordering, floored to the tip bucket if any
 }
 
 // newTxWithMinerFee creates a wrapped transaction, calculating the effective
 // miner gasTipCap if a base fee is provided.
 // Returns error in case of a negative effective miner gasTipCap.
-func newTxWithMinerFee(tx *txpool.LazyTransaction, from common.Address, baseFee *big.Int) (*TxWithMinerFee, error) {
+//
+// If a tip bucket is provided, transactions whose miner fees fall into the same
+// bucket rank equally and are ordered by the time they were first seen.
+func newTxWithMinerFee(tx *txpool.LazyTransaction, from common.Address, baseFee *big.Int, tipBucket *big.Int) (*TxWithMinerFee, error) {
 	var (
 		minerFee *big.Int
 		tipCap   = tx.GasTipCap.ToBig()
@@ -34,10 +38,15 @@ func newTxWithMinerFee(tx *txpool.LazyTransaction, from common.Address, baseFee
 		}
 		minerFee = math.BigMin(tipCap, new(big.Int).Sub(feeCap, baseFee))
 	}
+	rank := minerFee
+	if tipBucket != nil && tipBucket.Sign() > 0 {
+		rank = new(big.Int).Div(minerFee, tipBucket)
+	}
 	return &TxWithMinerFee{
 		from:     from,
 		tx:       tx,
 		minerFee: minerFee,
+		rank:     rank,
 	}, nil
 }
 
@@ -49,7 +58,7 @@ func (s TxByPriceAndTime) Len() int { return len(s) }
 func (s TxByPriceAndTime) Less(i, j int) bool {
 	// If the prices are equal, use the time the transaction was first seen for
 	// deterministic sorting
-	cmp := s[i].minerFee.Cmp(s[j].minerFee)
+	cmp := s[i].rank.Cmp(s[j].rank)
 	if cmp == 0 {
 		return s[i].tx.Time.Before(s[j].tx.Time)
 	}
@@ -73,10 +82,11 @@ func (s *TxByPriceAndTime) Pop() interface{} {
 // transactions in a profit-maximizing sorted order, while supporting removing
 // entire batches of transactions for non-executable accounts.
 type TransactionsByPriceAndNonce struct {
-	txs     map[common.Address][]*txpool.LazyTransaction // Per account nonce-sorted list of transactions
-	heads   TxByPriceAndTime                             // Next transaction for each unique account (price heap)
-	signer  types.Signer                                 // Signer for the set of transactions
-	baseFee *big.Int                                     // Current base fee
+	txs       map[common.Address][]*txpool.LazyTransaction // Per account nonce-sorted list of transactions
+	heads     TxByPriceAndTime                             // Next transaction for each unique account (price heap)
+	signer    types.Signer                                 // Signer for the set of transactions
+	baseFee   *big.Int                                     // Current base fee
+	tipBucket *big.Int                                     // Granularity of miner fees ordered by arrival time
 }
 
 // NewTransactionsByPriceAndNonce creates a transaction set that can retrieve
@@ -85,10 +95,21 @@ type TransactionsByPriceAndNonce struct {
 // Note, the input map is reowned so the caller should not interact any more with
 // if after providing it to the constructor.
 func NewTransactionsByPriceAndNonce(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int) *TransactionsByPriceAndNonce {
+	return NewTransactionsByBucketAndTime(signer, txs, baseFee, nil)
+}
+
+// NewTransactionsByBucketAndTime creates a transaction set that can retrieve
+// transactions in a nonce-honouring way, ordered by miner fee floored to the
+// given tip bucket, and by the time they were first seen within a bucket. A nil
+// bucket orders strictly by price.
+//
+// Note, the input map is reowned so the caller should not interact any more with
+// if after providing it to the constructor.
+func NewTransactionsByBucketAndTime(signer types.Signer, txs map[common.Address][]*txpool.LazyTransaction, baseFee *big.Int, tipBucket *big.Int) *TransactionsByPriceAndNonce {
 	// Initialize a price and received time based heap with the head transactions
 	heads := make(TxByPriceAndTime, 0, len(txs))
 	for from, accTxs := range txs {
-		wrapped, err := newTxWithMinerFee(accTxs[0], from, baseFee)
+		wrapped, err := newTxWithMinerFee(accTxs[0], from, baseFee, tipBucket)
 		if err != nil {
 			delete(txs, from)
 			continue
@@ -100,10 +121,11 @@ func NewTransactionsByPriceAndNonce(signer types.Signer, txs map[common.Address]
 
 	// Assemble and return the transaction set
 	return &TransactionsByPriceAndNonce{
-		txs:     txs,
-		heads:   heads,
-		signer:  signer,
-		baseFee: baseFee,
+		txs:       txs,
+		heads:     heads,
+		signer:    signer,
+		baseFee:   baseFee,
+		tipBucket: tipBucket,
 	}
 }
 
@@ -119,7 +141,7 @@ func (t *TransactionsByPriceAndNonce) Peek() (*txpool.LazyTransaction, *big.Int)
 func (t *TransactionsByPriceAndNonce) Shift() {
 	acc := t.heads[0].from
 	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		if wrapped, err := newTxWithMinerFee(txs[0], acc, t.baseFee); err == nil {
+		if wrapped, err := newTxWithMinerFee(txs[0], acc, t.baseFee, t.tipBucket); err == nil {
 			t.heads[0], t.txs[acc] = wrapped, txs[1:]
 			heap.Fix(&t.heads, 0)
 			return
@@ -169,3 +169,51 @@ func TestTransactionTimeSort(t *testing.T) {
 		}
 	}
 }
+
+// Tests that transactions whose tips fall into the same bucket are ordered by the
+// time they were first seen, while different buckets are still ordered by price.
+func TestTransactionBucketTimeSort(t *testing.T) {
+	signer := types.LatestSignerForChainID(common.Big1)
+
+	// Three accounts with tips in the same bucket arriving in reverse order of
+	// their tips, and a fourth one paying a tip in a higher bucket last.
+	var (
+		tips   = []int64{10, 12, 14, 25}
+		groups = map[common.Address][]*txpool.LazyTransaction{}
+		order  = make([]common.Address, len(tips))
+		now    = time.Now()
+	)
+	for i, tip := range tips {
+		key, _ := crypto.GenerateKey()
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+			To:       &common.Address{},
+			Gas:      100,
+			GasPrice: big.NewInt(tip),
+		}), signer, key)
+
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		groups[addr] = []*txpool.LazyTransaction{{
+			Tx:        tx,
+			Time:      now.Add(time.Duration(i) * time.Second),
+			GasFeeCap: uint256.MustFromBig(tx.GasFeeCap()),
+			GasTipCap: uint256.MustFromBig(tx.GasTipCap()),
+		}}
+		order[i] = addr
+	}
+	want := []common.Address{order[3], order[0], order[1], order[2]}
+
+	txset := NewTransactionsByBucketAndTime(signer, groups, nil, big.NewInt(10))
+	for i, addr := range want {
+		tx, _ := txset.Peek()
+		if tx == nil {
+			t.Fatalf("transaction %d: missing", i)
+		}
+		if from, _ := types.Sender(signer, tx.Tx); from != addr {
+			t.Fatalf("transaction %d: sender mismatch: have %x, want %x", i, from, addr)
+		}
+		txset.Shift()
+	}
+	if tx, _ := txset.Peek(); tx != nil {
+		t.Fatalf("unexpected transaction left: %x", tx.Hash)
+	}
+}
@@ -19,6 +19,7 @@ package miner
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -196,6 +197,7 @@ type worker struct {
 	current      *environment                 // An environment for current running cycle.
 	localUncles  map[common.Hash]*types.Block // A set of side blocks generated locally as the possible uncle blocks.
 	remoteUncles map[common.Hash]*types.Block // A set of side blocks as the possible uncle blocks.
+	uncleFree    bool                         // Whether the engine never accepts uncles, skipping their bookkeeping.
 	unconfirmed  *unconfirmedBlocks           // A set of locally mined blocks pending canonicalness confirmations.
 
 	mu       sync.RWMutex // The lock used to protect the coinbase and extra fields
@@ -238,6 +240,7 @@ func newWorker(config *Config, chainConfig *params.ChainConfig, engine consensus
 		config:             config,
 		chainConfig:        chainConfig,
 		engine:             engine,
+		uncleFree:          consensus.IsUncleFree(engine),
 		eth:                eth,
 		mux:                mux,
 		chain:              eth.BlockChain(),
@@ -298,6 +301,13 @@ func (w *worker) setGasCeil(ceil uint64) {
 	w.config.GasCeil = ceil
 }
 
+// gasCeil returns the gas limit the worker votes towards in new blocks.
+func (w *worker) gasCeil() uint64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.config.GasCeil
+}
+
 // setExtra sets the content used to initialize the block extra field.
 func (w *worker) setExtra(extra []byte) {
 	w.mu.Lock()
@@ -521,6 +531,10 @@ func (w *worker) mainLoop() {
 			w.commitNewWork(req.interrupt, req.noempty, req.timestamp)
 
 		case ev := <-w.chainSideCh:
+			// Side blocks are never included by engines without uncles
+			if w.uncleFree {
+				continue
+			}
 			// Short circuit for duplicate side blocks
 			if _, exist := w.localUncles[ev.Block.Hash()]; exist {
 				continue
@@ -587,7 +601,7 @@ func (w *worker) mainLoop() {
 						BlobGas:   tx.BlobGas(),
 					})
 				}
-				txset := NewTransactionsByPriceAndNonce(w.current.signer, txs, w.current.header.BaseFee)
+				txset := NewTransactionsByBucketAndTime(w.current.signer, txs, w.current.header.BaseFee, w.config.TipBucket)
 				emptyset := NewTransactionsByPriceAndNonce(w.current.signer, make(map[common.Address][]*txpool.LazyTransaction), w.current.header.BaseFee)
 				tcount := w.current.tcount
 				w.commitTransactions(txset, emptyset, coinbase, nil)
@@ -784,13 +798,16 @@ func (w *worker) makeCurrent(parent *types.Block, header *types.Header) error {
 		header:             header,
 		estimatedBlockSize: 0,
 	}
-	// when 08 is processed ancestors contain 07 (quick block)
-	for _, ancestor := range w.chain.GetBlocksFromHash(parent.Hash(), 7) {
-		for _, uncle := range ancestor.Uncles() {
-			env.family.Add(uncle.Hash())
+	// when 08 is processed ancestors contain 07 (quick block). The family is
+	// only needed to validate uncles, so skip it if the engine takes none.
+	if !w.uncleFree {
+		for _, ancestor := range w.chain.GetBlocksFromHash(parent.Hash(), 7) {
+			for _, uncle := range ancestor.Uncles() {
+				env.family.Add(uncle.Hash())
+			}
+			env.family.Add(ancestor.Hash())
+			env.ancestors.Add(ancestor.Hash())
 		}
-		env.family.Add(ancestor.Hash())
-		env.ancestors.Add(ancestor.Hash())
 	}
 	// Keep track of transactions which return errors so they can be removed
 	env.tcount = 0
@@ -898,6 +915,69 @@ func (w *worker) commitTransaction(tx *types.Transaction, coinbase common.Addres
 	return receipt.Logs, nil
 }
 
+// commitForcedTransactions leads the current block with the transactions
+// mandated by the consensus engine, failing if any of them cannot be included.
+func (w *worker) commitForcedTransactions(coinbase common.Address) error {
+	forcer, ok := w.engine.(consensus.ForcedTransactor)
+	if !ok {
+		return nil
+	}
+	forced, err := forcer.ForcedTransactions(w.chain, w.current.header)
+	if err != nil || len(forced) == 0 {
+		return err
+	}
+	var gas uint64
+	for _, tx := range forced {
+		gas += tx.Gas()
+	}
+	if limit := core.ForcedGasLimit(w.current.header.GasLimit); gas > limit {
+		return fmt.Errorf("%w: have %d, limit %d", core.ErrForcedGasExceeded, gas, limit)
+	}
+	bloomProcessor := core.NewAsyncReceiptBloomGenerator(len(forced))
+	defer bloomProcessor.Close()
+
+	for _, tx := range forced {
+		w.current.state.SetTxContext(tx.Hash(), w.current.tcount)
+		if _, err := w.commitTransaction(tx, coinbase, bloomProcessor); err != nil {
+			return fmt.Errorf("forced transaction %x: %w", tx.Hash(), err)
+		}
+		w.current.receipts[len(w.current.receipts)-1].Forced = true
+	}
+	return nil
+}
+
+// prefetchPending schedules the senders, recipients and access lists of the
+// pending transactions fitting into the block of the given environment for
+// prefetching into its state, sparing the cold reads during their execution.
+// The access lists only serve as a hint for the caches, the EIP-2929 access
+// tracking of the transactions is not affected.
+func (w *worker) prefetchPending(env *environment, pending map[common.Address][]*txpool.LazyTransaction) {
+	var (
+		list types.AccessList
+		gas  uint64
+	)
+	for from, txs := range pending {
+		list = append(list, types.AccessTuple{Address: from})
+		for _, lazy := range txs {
+			if gas += lazy.Gas; gas > env.header.GasLimit {
+				break
+			}
+			tx := lazy.Resolve()
+			if tx == nil {
+				break
+			}
+			if to := tx.To(); to != nil {
+				list = append(list, types.AccessTuple{Address: *to})
+			}
+			list = append(list, tx.AccessList()...)
+		}
+		if gas > env.header.GasLimit {
+			break
+		}
+	}
+	env.state.PrefetchAccessList(list)
+}
+
 func (w *worker) commitTransactions(plainTxs, blobTxs *TransactionsByPriceAndNonce, coinbase common.Address, interrupt *int32) bool {
 	// Short circuit if current is nil
 	if w.current == nil {
@@ -1162,6 +1242,11 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		header.BlobGasUsed = new(uint64)
 		header.ExcessBlobGas = &excessBlobGas
 	}
+	// No settlement operations are produced yet, commit to the empty lists
+	if w.chainConfig.IsSettlement(header.Number) {
+		header.WithdrawalsHash = &types.EmptyRootHash
+		header.DepositsHash = &types.EmptyRootHash
+	}
 	// Only set the coinbase if our consensus engine is running (avoid spurious block rewards)
 	if w.isRunning() {
 		if w.coinbase == (common.Address{}) {
@@ -1221,9 +1306,16 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 	}
 	// Prefer to locally generated uncle
-	commitUncles(w.localUncles)
-	commitUncles(w.remoteUncles)
+	if !w.uncleFree {
+		commitUncles(w.localUncles)
+		commitUncles(w.remoteUncles)
+	}
 
+	// Even the empty blocks start with the transactions mandated by the engine
+	if err := w.commitForcedTransactions(w.coinbase); err != nil {
+		log.Error("Failed to commit forced transactions", "err", err)
+		return
+	}
 	// Fast commit an empty block which has block number smaller
 	// than current block in canonical chain but have greater
 	// difficulty
@@ -1261,6 +1353,9 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		return
 	}
 
+	// Warm up the state the pending transactions are expected to access
+	w.prefetchPending(w.current, pendingPlainTxs)
+
 	// Split the pending transactions into locals and remotes
 	localAccounts := w.eth.TxPool().Locals()
 	localPlainTxs, remotePlainTxs := make(map[common.Address][]*txpool.LazyTransaction), pendingPlainTxs
@@ -1278,15 +1373,15 @@ func (w *worker) commitNewWork(interrupt *int32, noempty bool, timestamp int64)
 		}
 	}
 	if len(localPlainTxs) > 0 || len(localBlobTxs) > 0 {
-		plainTxs := NewTransactionsByPriceAndNonce(w.current.signer, localPlainTxs, header.BaseFee)
-		blobTxs := NewTransactionsByPriceAndNonce(w.current.signer, localBlobTxs, header.BaseFee)
+		plainTxs := NewTransactionsByBucketAndTime(w.current.signer, localPlainTxs, header.BaseFee, w.config.TipBucket)
+		blobTxs := NewTransactionsByBucketAndTime(w.current.signer, localBlobTxs, header.BaseFee, w.config.TipBucket)
 		if w.commitTransactions(plainTxs, blobTxs, w.coinbase, interrupt) {
 			return
 		}
 	}
 	if len(remotePlainTxs) > 0 || len(remoteBlobTxs) > 0 {
-		plainTxs := NewTransactionsByPriceAndNonce(w.current.signer, remotePlainTxs, header.BaseFee)
-		blobTxs := NewTransactionsByPriceAndNonce(w.current.signer, remoteBlobTxs, header.BaseFee)
+		plainTxs := NewTransactionsByBucketAndTime(w.current.signer, remotePlainTxs, header.BaseFee, w.config.TipBucket)
+		blobTxs := NewTransactionsByBucketAndTime(w.current.signer, remoteBlobTxs, header.BaseFee, w.config.TipBucket)
 		if w.commitTransactions(plainTxs, blobTxs, w.coinbase, interrupt) {
 			return
 		}
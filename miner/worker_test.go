@@ -82,6 +82,8 @@ var (
 func init() {
 	testTxPoolConfig = legacypool.DefaultConfig
 	testTxPoolConfig.Journal = ""
+	testTxPoolConfig.MinedJournal = ""
+	testTxPoolConfig.LocalsJournal = ""
 	ethashChainConfig = new(params.ChainConfig)
 	*ethashChainConfig = *params.TestChainConfig
 	ethashChainConfig.CancunBlock = nil
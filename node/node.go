@@ -613,7 +613,8 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, ancient
 	if n.config.DataDir == "" {
 		db = rawdb.NewMemoryDatabase()
 	} else {
-		db, err = rawdb.Open(rawdb.OpenOptions{
+		var report *rawdb.HealthReport
+		db, report, err = rawdb.OpenWithHealthCheck(rawdb.OpenOptions{
 			Type:              n.config.DBEngine,
 			Directory:         n.ResolvePath(name),
 			AncientsDirectory: n.ResolveAncient(name, ancient),
@@ -622,6 +623,12 @@ func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, ancient
 			Handles:           handles,
 			ReadOnly:          readonly,
 		})
+		for _, lock := range report.StaleLocks {
+			n.log.Warn("Recovered stale database lock", "database", name, "lock", lock)
+		}
+		if !report.Healthy() {
+			n.log.Warn("Database health check failed", "database", name, "problems", report)
+		}
 	}
 
 	if err == nil {
@@ -168,6 +168,13 @@ const (
 	ValidateFinalityProofGas      uint64 = 200000                 // Gas for validating finality proof
 	ValidateProofOfPossession     uint64 = 100000                 // Gas for validating proof of possession
 
+	BatchHashBaseGas             uint64 = 60  // Base price for a batch hashing operation
+	BatchHashSha512ItemGas       uint64 = 30  // Per-message price for a SHA512 digest in a batch
+	BatchHashSha512PerWordGas    uint64 = 12  // Per-word price for a SHA512 digest in a batch
+	BatchHashRipemd160ItemGas    uint64 = 300 // Per-message price for a RIPEMD160 digest in a batch
+	BatchHashRipemd160PerWordGas uint64 = 120 // Per-word price for a RIPEMD160 digest in a batch
+	MaxBatchHashItems                   = 256 // Maximum number of messages digested in a single batch
+
 	// The Refund Quotient is the cap on how much of the used gas can be refunded. Before EIP-3529,
 	// up to half the consumed gas could be refunded. Redefined as 1/5th in EIP-3529
 	RefundQuotient        uint64 = 2
@@ -74,6 +74,7 @@ func (s *spongeDb) NewBatchWithSize(size int) ethdb.Batch    { return &spongeBat
 func (s *spongeDb) NewSnapshot() (ethdb.Snapshot, error)     { panic("implement me") }
 func (s *spongeDb) Stat(property string) (string, error)     { panic("implement me") }
 func (s *spongeDb) Compact(start []byte, limit []byte) error { panic("implement me") }
+func (s *spongeDb) ThrottleCompactions(throttle bool)        {}
 func (s *spongeDb) Close() error                             { return nil }
 
 func (s *spongeDb) Put(key []byte, value []byte) error {
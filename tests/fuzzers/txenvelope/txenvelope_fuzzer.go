@@ -0,0 +1,198 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package txenvelope checks that transaction envelopes survive a round trip
+// through their binary, RLP and JSON encodings unchanged. The checks can be run
+// against any input by fuzzers, or against known transactions by tests.
+package txenvelope
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Fuzz decodes the input as a binary and as a JSON transaction, panicking if an
+// accepted transaction does not round-trip.
+func Fuzz(input []byte) int {
+	accepted, err := CheckBinary(input)
+	if err != nil {
+		panic(err)
+	}
+	jsonAccepted, err := CheckJSON(input)
+	if err != nil {
+		panic(err)
+	}
+	if accepted || jsonAccepted {
+		return 1
+	}
+	return 0
+}
+
+// CheckBinary decodes the input as a binary transaction envelope. If it is
+// accepted, the transaction must encode back into the input and round-trip
+// through all other encodings. Transactions with values the JSON decoder rightly
+// rejects are not accepted.
+func CheckBinary(input []byte) (bool, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(input); err != nil {
+		return false, nil
+	}
+	if !jsonCompatible(tx) {
+		return false, nil
+	}
+	output, err := tx.MarshalBinary()
+	if err != nil {
+		return true, fmt.Errorf("type %d: failed to encode decoded transaction: %v", tx.Type(), err)
+	}
+	if !bytes.Equal(input, output) {
+		return true, fmt.Errorf("type %d: binary round trip mismatch\ninput : %x\noutput: %x", tx.Type(), input, output)
+	}
+	return true, CheckTransaction(tx)
+}
+
+// CheckJSON decodes the input as a JSON transaction. If it is accepted, the
+// transaction must round-trip through all encodings.
+func CheckJSON(input []byte) (bool, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalJSON(input); err != nil {
+		return false, nil
+	}
+	return true, CheckTransaction(tx)
+}
+
+// CheckTransaction checks that the transaction survives a round trip through its
+// binary, RLP and JSON encodings with the same hash and encoding. The JSON form
+// is stricter than the binary one about signature values, the transaction must
+// pass its checks.
+func CheckTransaction(tx *types.Transaction) error {
+	binary, err := tx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("type %d: failed to encode: %v", tx.Type(), err)
+	}
+	// Binary envelope, as used in the pool and by RPC
+	dec := new(types.Transaction)
+	if err := dec.UnmarshalBinary(binary); err != nil {
+		return fmt.Errorf("type %d: failed to decode binary: %v", tx.Type(), err)
+	}
+	if err := compare("binary", tx, dec); err != nil {
+		return err
+	}
+	// RLP envelope, as used in blocks and on the wire
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return fmt.Errorf("type %d: failed to encode rlp: %v", tx.Type(), err)
+	}
+	dec = new(types.Transaction)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		return fmt.Errorf("type %d: failed to decode rlp: %v", tx.Type(), err)
+	}
+	if err := compare("rlp", tx, dec); err != nil {
+		return err
+	}
+	// JSON, as used by RPC and SDKs
+	blob, err := tx.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("type %d: failed to encode json: %v", tx.Type(), err)
+	}
+	dec = new(types.Transaction)
+	if err := dec.UnmarshalJSON(blob); err != nil {
+		return fmt.Errorf("type %d: failed to decode json: %v\ninput: %s", tx.Type(), err, blob)
+	}
+	if err := compare("json", tx, dec); err != nil {
+		return err
+	}
+	reenc, err := dec.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("type %d: failed to reencode json: %v", tx.Type(), err)
+	}
+	if !bytes.Equal(blob, reenc) {
+		return fmt.Errorf("type %d: json round trip mismatch\ninput : %s\noutput: %s", tx.Type(), blob, reenc)
+	}
+	return nil
+}
+
+// jsonCompatible reports whether the transaction passes the checks of the JSON
+// decoder: its quantities fit in 256 bits and its signature values, and those of
+// its payer if sponsored, are sane.
+func jsonCompatible(tx *types.Transaction) bool {
+	v, r, s := tx.RawSignatureValues()
+	quantities := []*big.Int{tx.ChainId(), tx.Value(), tx.GasPrice(), tx.GasTipCap(), tx.GasFeeCap(), tx.BlobGasFeeCap(), v, r, s}
+	if tx.Type() == types.SponsoredTxType {
+		pv, pr, ps := tx.RawPayerSignatureValues()
+		if !saneSignature(pv, pr, ps, false) {
+			return false
+		}
+		quantities = append(quantities, pv, pr, ps)
+	}
+	for _, quantity := range quantities {
+		if quantity != nil && quantity.BitLen() > 256 {
+			return false
+		}
+	}
+	if v.Sign() != 0 || r.Sign() != 0 || s.Sign() != 0 {
+		return saneSignature(v, r, s, tx.Type() == types.LegacyTxType)
+	}
+	return true
+}
+
+// saneSignature mirrors the signature value checks of the JSON decoder.
+func saneSignature(v, r, s *big.Int, maybeProtected bool) bool {
+	protected := v.BitLen() > 8 || (v.Uint64() != 27 && v.Uint64() != 28 && v.Uint64() != 1 && v.Uint64() != 0)
+	if protected && !maybeProtected {
+		return false
+	}
+	var plainV byte
+	switch {
+	case protected:
+		var chainID uint64
+		if v.BitLen() <= 64 {
+			chainID = (v.Uint64() - 35) / 2
+		} else {
+			chainID = new(big.Int).Div(new(big.Int).Sub(v, big.NewInt(35)), big.NewInt(2)).Uint64()
+		}
+		plainV = byte(v.Uint64() - 35 - 2*chainID)
+	case maybeProtected:
+		plainV = byte(v.Uint64() - 27)
+	default:
+		plainV = byte(v.Uint64())
+	}
+	return crypto.ValidateSignatureValues(plainV, r, s, false)
+}
+
+// compare checks that a decoded transaction matches the original in type, hash
+// and binary encoding.
+func compare(kind string, want, have *types.Transaction) error {
+	if have.Type() != want.Type() {
+		return fmt.Errorf("%s round trip type mismatch: have %d, want %d", kind, have.Type(), want.Type())
+	}
+	if have.Hash() != want.Hash() {
+		return fmt.Errorf("type %d: %s round trip hash mismatch: have %x, want %x", want.Type(), kind, have.Hash(), want.Hash())
+	}
+	wantBin, _ := want.MarshalBinary()
+	haveBin, err := have.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("type %d: failed to encode %s round trip: %v", want.Type(), kind, err)
+	}
+	if !bytes.Equal(haveBin, wantBin) {
+		return fmt.Errorf("type %d: %s round trip encoding mismatch\nhave: %x\nwant: %x", want.Type(), kind, haveBin, wantBin)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txenvelope
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+// envelopes returns a signed transaction of every supported envelope type.
+func envelopes(t testing.TB) []*types.Transaction {
+	var (
+		key, _    = crypto.GenerateKey()
+		payer, _  = crypto.GenerateKey()
+		signer    = types.LatestSignerForChainID(big.NewInt(2020))
+		to        = common.HexToAddress("0xa0b0c0d0")
+		accesses  = types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}}}}
+		sponsored = &types.SponsoredTx{
+			ChainID:     big.NewInt(2020),
+			Nonce:       4,
+			To:          &to,
+			Gas:         50000,
+			GasTipCap:   big.NewInt(20),
+			GasFeeCap:   big.NewInt(20),
+			Value:       big.NewInt(1),
+			Data:        []byte{0xca, 0xfe},
+			ExpiredTime: 1700000000,
+		}
+		err error
+	)
+	sponsored.PayerR, sponsored.PayerS, sponsored.PayerV, err = types.PayerSign(payer, signer, crypto.PubkeyToAddress(key.PublicKey), sponsored)
+	if err != nil {
+		t.Fatalf("failed to sign as payer: %v", err)
+	}
+	txdata := []types.TxData{
+		&types.LegacyTx{Nonce: 0, To: &to, Gas: 21000, GasPrice: big.NewInt(20), Value: big.NewInt(1)},
+		&types.LegacyTx{Nonce: 1, Gas: 100000, GasPrice: big.NewInt(20), Data: []byte{0x60, 0x00}},
+		&types.AccessListTx{ChainID: big.NewInt(2020), Nonce: 2, To: &to, Gas: 30000, GasPrice: big.NewInt(20), AccessList: accesses},
+		&types.DynamicFeeTx{ChainID: big.NewInt(2020), Nonce: 3, To: &to, Gas: 30000, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(30), AccessList: accesses},
+		sponsored,
+		&types.BlobTx{
+			ChainID:    uint256.NewInt(2020),
+			Nonce:      5,
+			To:         to,
+			Gas:        30000,
+			GasTipCap:  uint256.NewInt(1),
+			GasFeeCap:  uint256.NewInt(30),
+			BlobFeeCap: uint256.NewInt(5),
+			BlobHashes: []common.Hash{{0x01, 0x02}},
+			AccessList: accesses,
+		},
+	}
+	txs := make([]*types.Transaction, len(txdata))
+	for i, data := range txdata {
+		if txs[i], err = types.SignNewTx(key, signer, data); err != nil {
+			t.Fatalf("failed to sign transaction %d: %v", i, err)
+		}
+	}
+	return txs
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, tx := range envelopes(t) {
+		if err := CheckTransaction(tx); err != nil {
+			t.Fatal(err)
+		}
+		blob, _ := tx.MarshalBinary()
+		if accepted, err := CheckBinary(blob); !accepted || err != nil {
+			t.Fatalf("type %d: binary check failed: accepted %v, err %v", tx.Type(), accepted, err)
+		}
+		json, _ := tx.MarshalJSON()
+		if accepted, err := CheckJSON(json); !accepted || err != nil {
+			t.Fatalf("type %d: json check failed: accepted %v, err %v", tx.Type(), accepted, err)
+		}
+	}
+}
+
+func FuzzBinary(f *testing.F) {
+	for _, tx := range envelopes(f) {
+		blob, _ := tx.MarshalBinary()
+		f.Add(blob)
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		if _, err := CheckBinary(input); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func FuzzJSON(f *testing.F) {
+	for _, tx := range envelopes(f) {
+		blob, _ := tx.MarshalJSON()
+		f.Add(blob)
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		if _, err := CheckJSON(input); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -282,7 +282,7 @@ func MakePreState(db ethdb.Database, accounts core.GenesisAlloc, snapshotter boo
 
 	var snaps *snapshot.Tree
 	if snapshotter {
-		snaps, _ = snapshot.New(db, triedb, 1, root, false, true, false)
+		snaps, _ = snapshot.New(db, triedb, snapshot.CacheConfig{Size: 1}, root, false, true, false)
 	}
 	statedb, _ = state.New(root, sdb, snaps)
 	return triedb, snaps, statedb
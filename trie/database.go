@@ -18,6 +18,7 @@ package trie
 
 import (
 	"errors"
+	"sync/atomic"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -75,10 +76,10 @@ type backend interface {
 // types of node backend as an entrypoint. It's responsible for all interactions
 // relevant with trie nodes and node preimages.
 type Database struct {
-	config    *Config        // Configuration for trie database
-	diskdb    ethdb.Database // Persistent database to store the snapshot
-	preimages *preimageStore // The store for caching preimages
-	backend   backend        // The backend for managing trie nodes
+	config    *Config                 // Configuration for trie database
+	diskdb    ethdb.Database          // Persistent database to store the snapshot
+	preimages *preimageStore          // The store for caching preimages
+	current   atomic.Pointer[backend] // The backend for managing trie nodes
 }
 
 // prepare initializes the database with provided configs, but the
@@ -121,22 +122,43 @@ func NewDatabase(diskdb ethdb.Database, config *Config) *Database {
 		log.Crit("Both 'hash' and 'path' mode are configured")
 	}
 	if config.PathDB != nil {
-		db.backend = pathdb.New(diskdb, config.PathDB)
+		db.setBackend(pathdb.New(diskdb, config.PathDB))
 	} else {
 		// Use hashdb by default
-		db.backend = hashdb.New(diskdb, config.HashDB, mptResolver{})
+		db.setBackend(hashdb.New(diskdb, config.HashDB, mptResolver{}))
 	}
 	return db
 }
 
+// backend returns the backend currently managing the trie nodes.
+func (db *Database) backend() backend {
+	return *db.current.Load()
+}
+
+// setBackend sets the backend managing the trie nodes.
+func (db *Database) setBackend(b backend) {
+	db.current.Store(&b)
+}
+
+// Replace takes over the backend of the given database, switching this one to
+// its node scheme in place, so that all the users of the database follow along.
+// The given database must not be used on its own afterwards. The previous
+// backend is left open for the readers still holding it, the states only known
+// to it become unreachable through this database.
+func (db *Database) Replace(other *Database) {
+	db.setBackend(other.backend())
+}
+
 // Reader returns a reader for accessing all trie nodes with provided state root.
 // Nil is returned in case the state is not available.
 func (db *Database) Reader(blockRoot common.Hash) (Reader, error) {
-	switch b := db.backend.(type) {
+	switch b := db.backend().(type) {
 	case *hashdb.Database:
 		return b.Reader(blockRoot)
 	case *pathdb.Database:
 		return b.Reader(blockRoot)
+	case *throwawayDB:
+		return b.Reader(blockRoot)
 	}
 	return nil, errors.New("unsupported")
 }
@@ -152,7 +174,7 @@ func (db *Database) Update(root common.Hash, parent common.Hash, block uint64, n
 	if db.preimages != nil {
 		db.preimages.commit(false)
 	}
-	return db.backend.Update(root, parent, block, nodes, states)
+	return db.backend().Update(root, parent, block, nodes, states)
 }
 
 // Commit iterates over all the children of a particular node, writes them out
@@ -162,7 +184,7 @@ func (db *Database) Commit(root common.Hash, report bool) error {
 	if db.preimages != nil {
 		db.preimages.commit(true)
 	}
-	return db.backend.Commit(root, report)
+	return db.backend().Commit(root, report)
 }
 
 // Size returns the storage size of dirty trie nodes in front of the persistent
@@ -172,7 +194,7 @@ func (db *Database) Size() (common.StorageSize, common.StorageSize) {
 		storages  common.StorageSize
 		preimages common.StorageSize
 	)
-	storages = db.backend.Size()
+	storages = db.backend().Size()
 	if db.preimages != nil {
 		preimages = db.preimages.size()
 	}
@@ -182,17 +204,17 @@ func (db *Database) Size() (common.StorageSize, common.StorageSize) {
 // Initialized returns an indicator if the state data is already initialized
 // according to the state scheme.
 func (db *Database) Initialized(genesisRoot common.Hash) bool {
-	return db.backend.Initialized(genesisRoot)
+	return db.backend().Initialized(genesisRoot)
 }
 
 // Scheme returns the node scheme used in the database.
 func (db *Database) Scheme() string {
-	return db.backend.Scheme()
+	return db.backend().Scheme()
 }
 
 // DiskDB retrieves the persistent storage backing the trie database.
 func (db *Database) DiskDB() ethdb.KeyValueStore {
-	return db.backend.DiskDB()
+	return db.backend().DiskDB()
 }
 
 // Close flushes the dangling preimages to disk and closes the trie database.
@@ -202,7 +224,7 @@ func (db *Database) Close() error {
 	if db.preimages != nil {
 		db.preimages.commit(true)
 	}
-	return db.backend.Close()
+	return db.backend().Close()
 }
 
 // Cap iteratively flushes old but still referenced trie nodes until the total
@@ -211,7 +233,7 @@ func (db *Database) Close() error {
 //
 // It's only supported by hash-based database and will return an error for others.
 func (db *Database) Cap(limit common.StorageSize) error {
-	hdb, ok := db.backend.(*hashdb.Database)
+	hdb, ok := db.backend().(*hashdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -227,7 +249,7 @@ func (db *Database) Cap(limit common.StorageSize) error {
 //
 // It's only supported by hash-based database and will return an error for others.
 func (db *Database) Reference(root common.Hash, parent common.Hash) error {
-	hdb, ok := db.backend.(*hashdb.Database)
+	hdb, ok := db.backend().(*hashdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -238,7 +260,7 @@ func (db *Database) Reference(root common.Hash, parent common.Hash) error {
 // Dereference removes an existing reference from a root node. It's only
 // supported by hash-based database and will return an error for others.
 func (db *Database) Dereference(root common.Hash) error {
-	hdb, ok := db.backend.(*hashdb.Database)
+	hdb, ok := db.backend().(*hashdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -250,7 +272,7 @@ func (db *Database) Dereference(root common.Hash) error {
 // only supported by hash-based database and will return an error for others.
 // Note, this function should be deprecated once ETH66 is deprecated.
 func (db *Database) Node(hash common.Hash) ([]byte, error) {
-	hdb, ok := db.backend.(*hashdb.Database)
+	hdb, ok := db.backend().(*hashdb.Database)
 	if !ok {
 		return nil, errors.New("not supported")
 	}
@@ -262,7 +284,7 @@ func (db *Database) Node(hash common.Hash) ([]byte, error) {
 // corresponding trie histories are existent. It's only supported by path-based
 // database and will return an error for others.
 func (db *Database) Recover(target common.Hash) error {
-	pdb, ok := db.backend.(*pathdb.Database)
+	pdb, ok := db.backend().(*pathdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -273,7 +295,7 @@ func (db *Database) Recover(target common.Hash) error {
 // recovered. It's only supported by path-based database and will return an
 // error for others.
 func (db *Database) Recoverable(root common.Hash) (bool, error) {
-	pdb, ok := db.backend.(*pathdb.Database)
+	pdb, ok := db.backend().(*pathdb.Database)
 	if !ok {
 		return false, errors.New("not supported")
 	}
@@ -286,7 +308,7 @@ func (db *Database) Recoverable(root common.Hash) (bool, error) {
 //
 // It's only supported by path-based database and will return an error for others.
 func (db *Database) Disable() error {
-	pdb, ok := db.backend.(*pathdb.Database)
+	pdb, ok := db.backend().(*pathdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -296,7 +318,7 @@ func (db *Database) Disable() error {
 // Enable activates database and resets the state tree with the provided persistent
 // state root once the state sync is finished.
 func (db *Database) Enable(root common.Hash) error {
-	pdb, ok := db.backend.(*pathdb.Database)
+	pdb, ok := db.backend().(*pathdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -308,7 +330,7 @@ func (db *Database) Enable(root common.Hash) error {
 // flattening everything down (bad for reorgs). It's only supported by path-based
 // database and will return an error for others.
 func (db *Database) Journal(root common.Hash) error {
-	pdb, ok := db.backend.(*pathdb.Database)
+	pdb, ok := db.backend().(*pathdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -319,7 +341,7 @@ func (db *Database) Journal(root common.Hash) error {
 // It's only supported by path-based database and will return an error for
 // others.
 func (db *Database) SetBufferSize(size int) error {
-	pdb, ok := db.backend.(*pathdb.Database)
+	pdb, ok := db.backend().(*pathdb.Database)
 	if !ok {
 		return errors.New("not supported")
 	}
@@ -17,10 +17,14 @@
 package trie
 
 import (
+	"testing"
+
 	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/trie/triedb/hashdb"
 	"github.com/ethereum/go-ethereum/trie/triedb/pathdb"
+	"github.com/ethereum/go-ethereum/trie/trienode"
 )
 
 // newTestDatabase initializes the trie database with specified scheme.
@@ -28,9 +32,63 @@ import (
 func newTestDatabase(diskdb ethdb.Database, scheme string) *Database {
 	db := prepare(diskdb, nil)
 	if scheme == rawdb.HashScheme {
-		db.backend = hashdb.New(diskdb, &hashdb.Config{}, mptResolver{})
+		db.setBackend(hashdb.New(diskdb, &hashdb.Config{}, mptResolver{}))
 	} else {
-		db.backend = pathdb.New(diskdb, &pathdb.Config{}) // disable clean/dirty cache
+		db.setBackend(pathdb.New(diskdb, &pathdb.Config{})) // disable clean/dirty cache
 	}
 	return db
 }
+
+// Tests that the nodes committed into a throwaway database are readable through
+// it, on top of the states of the base database, but never reach the latter.
+func TestThrowawayDatabase(t *testing.T) {
+	testThrowawayDatabase(t, rawdb.HashScheme)
+	testThrowawayDatabase(t, rawdb.PathScheme)
+}
+
+func testThrowawayDatabase(t *testing.T, scheme string) {
+	db := newTestDatabase(rawdb.NewMemoryDatabase(), scheme)
+
+	tr := NewEmpty(db)
+	updateString(tr, "120000", "qwerqwerqwerqwerqwerqwerqwerqwer")
+	updateString(tr, "123456", "asdfasdfasdfasdfasdfasdfasdfasdf")
+	base, nodes, _ := tr.Commit(false)
+	db.Update(base, types.EmptyRootHash, 0, trienode.NewWithNodeSet(nodes), nil)
+
+	// Build two states on top of each other in the throwaway database
+	throwaway := db.Throwaway()
+	parent := base
+	for _, key := range []string{"120099", "123499"} {
+		tr, err := New(TrieID(parent), throwaway)
+		if err != nil {
+			t.Fatalf("%s: failed to open trie %x: %v", scheme, parent, err)
+		}
+		updateString(tr, key, "zxcvzxcvzxcvzxcvzxcvzxcvzxcvzxcv")
+		root, nodes, _ := tr.Commit(false)
+		if err := throwaway.Update(root, parent, 0, trienode.NewWithNodeSet(nodes), nil); err != nil {
+			t.Fatalf("%s: failed to update throwaway database: %v", scheme, err)
+		}
+		parent = root
+	}
+	tr, err := New(TrieID(parent), throwaway)
+	if err != nil {
+		t.Fatalf("%s: failed to open throwaway trie: %v", scheme, err)
+	}
+	for _, key := range []string{"120000", "123456", "120099", "123499"} {
+		if val, err := tr.TryGet([]byte(key)); err != nil || len(val) == 0 {
+			t.Fatalf("%s: failed to read %s from throwaway trie: %v", scheme, key, err)
+		}
+	}
+	if err := throwaway.Commit(parent, false); err == nil {
+		t.Fatalf("%s: throwaway database committed", scheme)
+	}
+	if _, err := db.Reader(parent); err == nil {
+		if _, err := New(TrieID(parent), db); err == nil {
+			t.Fatalf("%s: throwaway state reached the base database", scheme)
+		}
+	}
+	throwaway.Close()
+	if _, err := New(TrieID(parent), throwaway); err == nil {
+		t.Fatalf("%s: throwaway state retained after closing", scheme)
+	}
+}
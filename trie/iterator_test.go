@@ -586,6 +586,10 @@ func (l *loggingDb) Compact(start []byte, limit []byte) error {
 	return l.backend.Compact(start, limit)
 }
 
+func (l *loggingDb) ThrottleCompactions(throttle bool) {
+	l.backend.ThrottleCompactions(throttle)
+}
+
 func (l *loggingDb) Close() error {
 	return l.backend.Close()
 }
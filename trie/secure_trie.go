@@ -190,6 +190,12 @@ func (t *SecureTrie) Hash() common.Hash {
 	return t.trie.Hash()
 }
 
+// Witness returns the blobs of the trie nodes resolved from the database since
+// the trie was opened or last committed.
+func (t *SecureTrie) Witness() [][]byte {
+	return t.trie.Witness()
+}
+
 // Copy returns a copy of SecureTrie.
 func (t *SecureTrie) Copy() *SecureTrie {
 	return &SecureTrie{
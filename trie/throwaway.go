@@ -0,0 +1,159 @@
+// Copyright 2024 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/trie/triestate"
+)
+
+// errThrowawayCommit is returned if the nodes of a throwaway database are
+// attempted to be persisted.
+var errThrowawayCommit = errors.New("throwaway trie database can't be committed")
+
+// throwawayLayer is the set of trie nodes committed into a throwaway database
+// by a single state transition.
+type throwawayLayer struct {
+	parent common.Hash                               // Root of the state the layer is built on
+	nodes  map[common.Hash]map[string]*trienode.Node // Nodes keyed by owner and path
+}
+
+// throwawayDB is a trie database backend keeping all the committed nodes in
+// memory, on top of the states of another trie database which is only ever
+// read. The nodes are dropped along with the backend.
+type throwawayDB struct {
+	base   *Database                       // Trie database the states are built on
+	layers map[common.Hash]*throwawayLayer // Committed layers, keyed by state root
+	size   common.StorageSize              // Memory held by the committed nodes
+	lock   sync.RWMutex
+}
+
+// Throwaway returns a trie database reading through this one, whose updates are
+// kept in memory and released along with it instead of reaching this database
+// or the disk. It is meant for executing blocks which are not to be imported.
+func (db *Database) Throwaway() *Database {
+	throwaway := &Database{diskdb: db.diskdb}
+	throwaway.setBackend(&throwawayDB{
+		base:   db,
+		layers: make(map[common.Hash]*throwawayLayer),
+	})
+	return throwaway
+}
+
+// Scheme returns the node scheme of the base database.
+func (db *throwawayDB) Scheme() string {
+	return db.base.Scheme()
+}
+
+// Initialized returns whether the base database is initialized.
+func (db *throwawayDB) Initialized(genesisRoot common.Hash) bool {
+	return db.base.Initialized(genesisRoot)
+}
+
+// Size returns the memory held by the committed nodes.
+func (db *throwawayDB) Size() common.StorageSize {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.size
+}
+
+// Update adds the nodes of a state transition as a new layer, on top of either
+// a state of the base database or another layer.
+func (db *throwawayDB) Update(root common.Hash, parent common.Hash, block uint64, nodes *trienode.MergedNodeSet, states *triestate.Set) error {
+	layer := &throwawayLayer{parent: parent, nodes: nodes.Flatten()}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if _, ok := db.layers[root]; ok {
+		return nil
+	}
+	for _, subset := range layer.nodes {
+		for path, n := range subset {
+			db.size += common.StorageSize(len(path) + n.Size())
+		}
+	}
+	db.layers[root] = layer
+	return nil
+}
+
+// DiskDB retrieves the persistent storage backing the base database.
+func (db *throwawayDB) DiskDB() ethdb.KeyValueStore {
+	return db.base.DiskDB()
+}
+
+// Commit always fails, the nodes of a throwaway database are never persisted.
+func (db *throwawayDB) Commit(root common.Hash, report bool) error {
+	return errThrowawayCommit
+}
+
+// Close drops all the committed nodes, leaving the base database untouched.
+func (db *throwawayDB) Close() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.layers = make(map[common.Hash]*throwawayLayer)
+	db.size = 0
+	return nil
+}
+
+// Reader returns a reader for accessing all the trie nodes of the given state,
+// either committed into the throwaway database or available in the base one.
+func (db *throwawayDB) Reader(root common.Hash) (Reader, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var layers []*throwawayLayer
+	for {
+		layer, ok := db.layers[root]
+		if !ok {
+			break
+		}
+		layers = append(layers, layer)
+		root = layer.parent
+	}
+	base, err := db.base.Reader(root)
+	if err != nil {
+		return nil, err
+	}
+	return &throwawayReader{layers: layers, base: base}, nil
+}
+
+// throwawayReader is a node reader resolving the nodes of a state through the
+// layers of a throwaway database first, from the top down, then through the
+// state of the base database these are built on.
+type throwawayReader struct {
+	layers []*throwawayLayer
+	base   Reader
+}
+
+// Node retrieves the trie node blob with the provided trie identifier, node
+// path and the corresponding node hash.
+func (r *throwawayReader) Node(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	for _, layer := range r.layers {
+		if n, ok := layer.nodes[owner][string(path)]; ok && n.Hash == hash {
+			return n.Blob, nil
+		}
+	}
+	return r.base.Node(owner, path, hash)
+}
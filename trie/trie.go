@@ -687,6 +687,17 @@ func (t *Trie) Reset() {
 	t.committed = false
 }
 
+// Witness returns the blobs of the trie nodes resolved from the database since
+// the trie was opened or last committed, the part of the trie needed to replay
+// the accesses to it. The blobs must not be modified.
+func (t *Trie) Witness() [][]byte {
+	blobs := make([][]byte, 0, len(t.tracer.accessList))
+	for _, blob := range t.tracer.accessList {
+		blobs = append(blobs, blob)
+	}
+	return blobs
+}
+
 // Copy returns a copy of Trie.
 func (t *Trie) Copy() *Trie {
 	return &Trie{
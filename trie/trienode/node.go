@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -160,9 +161,11 @@ func (set *NodeSet) Summary() string {
 	return out.String()
 }
 
-// MergedNodeSet represents a merged node set for a group of tries.
+// MergedNodeSet represents a merged node set for a group of tries. The sets of
+// different tries may be merged concurrently.
 type MergedNodeSet struct {
 	Sets map[common.Hash]*NodeSet
+	lock sync.Mutex
 }
 
 // NewMergedNodeSet initializes an empty merged set.
@@ -180,6 +183,9 @@ func NewWithNodeSet(set *NodeSet) *MergedNodeSet {
 // Merge merges the provided dirty nodes of a trie into the set. The assumption
 // is held that no duplicated set belonging to the same trie will be merged twice.
 func (set *MergedNodeSet) Merge(other *NodeSet) error {
+	set.lock.Lock()
+	defer set.lock.Unlock()
+
 	subset, present := set.Sets[other.Owner]
 	if present {
 		return subset.Merge(other.Owner, other.Nodes)